@@ -0,0 +1,77 @@
+// idmctl - служебные команды IDM, выполняемые напрямую против базы данных.
+//
+//	idmctl [-env .env] audit verify
+//
+// audit verify проходит цепочку audit_chain и сообщает о первом разрыве.
+// Код выхода: 0 - цепочка цела, 1 - цепочка нарушена, 2 - ошибка запуска
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"idm/inner/audit"
+	"idm/inner/config"
+	"idm/inner/database"
+	"idm/inner/validator"
+	"os"
+)
+
+const (
+	exitOk      = 0
+	exitBroken  = 1
+	exitFailure = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	var flags = flag.NewFlagSet("idmctl", flag.ContinueOnError)
+	var envFile = flags.String("env", ".env", "path to env file with DB_DRIVER_NAME and DB_DSN")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: idmctl [-env .env] audit verify")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	switch command := flags.Args(); {
+	case len(command) == 2 && command[0] == "audit" && command[1] == "verify":
+		return auditVerify(*envFile)
+	default:
+		flags.Usage()
+		return exitFailure
+	}
+}
+
+// auditVerify - проверка цепочки аудита, результат печатается в stdout в JSON
+func auditVerify(envFile string) int {
+	var cfg = config.GetConfig(envFile)
+	var db = database.ConnectDbWithCfg(cfg)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var service = audit.NewService(audit.NewRepository(db), validator.NewValidator())
+	result, err := service.VerifyChain(context.Background())
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "audit verify failed: %v\n", err)
+		return exitFailure
+	}
+
+	var encoder = json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "audit verify failed: %v\n", err)
+		return exitFailure
+	}
+	if !result.Valid {
+		_, _ = fmt.Fprintf(os.Stderr, "audit chain is broken at seq %d: %s\n", result.BrokenSeq, result.Reason)
+		return exitBroken
+	}
+	return exitOk
+}
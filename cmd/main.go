@@ -5,9 +5,23 @@ import (
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
+	"idm/inner/accessrequest"
+	"idm/inner/audit"
+	"idm/inner/auth"
+	"idm/inner/certification"
 	"idm/inner/common"
+	"idm/inner/group"
+	"idm/inner/orgunit"
+	"idm/inner/outbox"
+	"idm/inner/permission"
+	"idm/inner/provisioning"
 	"idm/inner/role"
+	"idm/inner/scheduler"
+	"idm/inner/scim"
+	"idm/inner/sod"
 	"idm/inner/validator"
+	"idm/inner/webhook"
+	"net/http"
 	"os/signal"
 	"sync"
 	"syscall"
@@ -20,6 +34,7 @@ import (
 	"idm/inner/employee"
 	"idm/inner/info"
 	"idm/inner/web"
+	"idm/inner/web/middleware"
 )
 
 // @title 	 	  IDM API documentation
@@ -29,6 +44,9 @@ import (
 // @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
 // @host      	   127.0.0.1:8080
 // @BasePath	  /api/v1/
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
 	//1. считывание конфигурации
 	cfg := config.GetConfig(".env")
@@ -52,8 +70,10 @@ func main() {
 		)
 	}
 
-	//4. создание сервера
-	var server = build(ctx, db, cfg, logger)
+	//4. создание сервера и фоновых задач
+	var server, jobs, events = build(ctx, db, cfg, logger)
+	jobs.Start(ctx)
+	events.Start(ctx)
 
 	//5. Запускаем сервер в отдельной горутине
 	go func() {
@@ -71,7 +91,7 @@ func main() {
 	wg.Add(1)
 
 	//7. Запускаем gracefulShutdown в отдельной горутине
-	go gracefulShutdown(ctx, server, db, wg, logger)
+	go gracefulShutdown(ctx, server, jobs, events, db, wg, logger)
 
 	//8. Ожидаем сигнал от горутины gracefulShutdown, что сервер завершил работу
 	wg.Wait()
@@ -113,14 +133,15 @@ func initializeDatabase(
 }
 
 // Build - функция, конструирующая наш веб-сервер( - иначе Создание сервера с контекстом)
+// планировщик фоновых задач и отправку доменных событий подписчикам
 func build(
 	ctx context.Context,
 	dbase *sqlx.DB,
 	cfg config.Config,
 	logger *common.Logger,
-) *web.Server {
-	var server = web.NewServer(logger) // создаём веб-сервер
-	var vld = validator.NewValidator() // создаём валидатор
+) (*web.Server, *scheduler.Scheduler, *outbox.Dispatcher) {
+	var server = web.NewServer(cfg, logger) // создаём веб-сервер
+	var vld = validator.NewValidator()      // создаём валидатор
 
 	//routing
 	var employeeRepo = employee.NewRepository(dbase)                                 // создаём репозиторий
@@ -133,17 +154,292 @@ func build(
 	var roleController = role.NewController(server, roleService, logger)
 	roleController.RegisterRoutes()
 
+	var orgUnitRepo = orgunit.NewRepository(dbase)
+	var orgUnitService = orgunit.NewService(orgUnitRepo, vld)
+	var orgUnitController = orgunit.NewController(server, orgUnitService, logger)
+	orgUnitController.RegisterRoutes()
+
+	var groupRepo = group.NewRepository(dbase)
+	var groupService = group.NewService(groupRepo, vld)
+	var groupController = group.NewController(server, groupService, logger)
+	groupController.RegisterRoutes()
+
+	var accessRequestRepo = accessrequest.NewRepository(dbase)
+	var accessRequestService = accessrequest.NewService(accessRequestRepo, vld, cfg.AccessRequestTtl)
+	var accessRequestController = accessrequest.NewController(server, accessRequestService, logger)
+	accessRequestController.RegisterRoutes()
+
+	var sodRepo = sod.NewRepository(dbase)
+	var sodService = sod.NewService(sodRepo, vld)
+	var sodController = sod.NewController(server, sodService, logger)
+	sodController.RegisterRoutes()
+
+	var certificationRepo = certification.NewRepository(dbase)
+	var certificationService = certification.NewService(certificationRepo, vld)
+	var certificationController = certification.NewController(server, certificationService, logger)
+	certificationController.RegisterRoutes()
+
+	// SCIM 2.0: пользователи и группы - это сотрудники и роли, все проверки выполняют их сервисы
+	var scimService = scim.NewService(employeeService, roleService)
+	var scimController = scim.NewController(server, scimService, logger)
+	scimController.RegisterRoutes()
+
+	// исходящий провижининг: изменения сотрудников и ролей доставляются в целевые системы по SCIM
+	var provisioningRepo = provisioning.NewRepository(dbase)
+	var provisioningService = provisioning.NewService(provisioningRepo, vld, scimService, provisioning.NewConnector)
+	var provisioningController = provisioning.NewController(server, provisioningService, logger)
+	provisioningController.RegisterRoutes()
+
+	var permissionRepo = permission.NewRepository(dbase)
+	var permissionService = permission.NewService(permissionRepo, vld)
+	server.Authorizer = middleware.NewAuthorizer(permissionService, logger) // разрешения маршрутов через роли сотрудника
+	var permissionController = permission.NewController(server, permissionService, logger)
+	permissionController.RegisterRoutes()
+
+	tokenIssuer, err := auth.NewTokenIssuer(cfg) // ключи подписи токенов из конфигурации
+	if err != nil {
+		logger.Fatal("token issuer initialization failed:", zap.Error(err))
+	}
+	var authRepo = auth.NewRepository(dbase)
+	var authService = auth.NewService(authRepo, vld, tokenIssuer)
+	var authController = auth.NewController(server, authService, logger)
+	authController.RegisterRoutes()
+
+	var auditRepo = audit.NewRepository(dbase)
+	var auditService = audit.NewService(auditRepo, vld)
+	var auditController = audit.NewController(server, auditService, logger)
+	auditController.RegisterRoutes()
+
 	var healthService = info.NewService(dbase, logger)
 	var infoController = info.NewController(server, cfg, healthService, logger)
 	infoController.RegisterRoutes()
 
-	return server
+	// доменные события изменений сотрудников и ролей пишутся в outbox в транзакции изменения
+	// и отправляются подписчикам не реже одного раза
+	var outboxRepo = outbox.NewRepository(dbase)
+
+	// подписки webhook: события ставятся в очередь доставки каждой подписке и отправляются отдельной задачей
+	var webhookRepo = webhook.NewRepository(dbase)
+	var webhookService = webhook.NewService(webhookRepo, vld, &http.Client{Timeout: webhookTimeout})
+	var webhookController = webhook.NewController(server, webhookService, logger)
+	webhookController.RegisterRoutes()
+
+	var events = outbox.NewDispatcher(
+		outboxRepo,
+		cfg.OutboxInterval,
+		logger,
+		outbox.NewLogSink(logger),
+		webhook.NewSink(webhookRepo),
+	)
+
+	// окончательное удаление мягко удалённых сотрудников и ролей, отправленных доменных событий
+	// и доставленных событий webhook по истечении срока хранения
+	// и отзыв назначений ролей с истёкшим сроком действия, закрытие нерассмотренных заявок на роли
+	// и кампаний ресертификации с наступившим сроком, доставка изменений в целевые системы провижининга
+	// и их периодическая сверка, доставка событий подписчикам webhook
+	var jobs = scheduler.NewScheduler(
+		logger,
+		purgeJob("purge deleted employees", cfg, employeeService.PurgeDeleted, logger),
+		purgeJob("purge deleted roles", cfg, roleService.PurgeDeleted, logger),
+		purgeJob("purge published domain events", cfg, events.PurgePublished, logger),
+		purgeJob("purge delivered webhook events", cfg, webhookService.PurgeDelivered, logger),
+		expiryJob(cfg, roleService, logger),
+		accessRequestExpiryJob(cfg, accessRequestService, logger),
+		certificationJob(cfg, certificationService, logger),
+		provisioningJob(cfg, provisioningService, logger),
+		reconcileJob(cfg, provisioningService, logger),
+		webhookJob(cfg, webhookService, logger),
+	)
+
+	return server, jobs, events
+}
+
+// purgeJob - задача очистки мягко удалённых записей старше cfg.SoftDeleteRetention
+func purgeJob(
+	name string,
+	cfg config.Config,
+	purge func(ctx context.Context, retention time.Duration) (int64, error),
+	logger *common.Logger,
+) scheduler.Job {
+	return scheduler.Job{
+		Name:     name,
+		Interval: cfg.PurgeInterval,
+		Run: func(ctx context.Context) error {
+			purged, err := purge(ctx, cfg.SoftDeleteRetention)
+			if purged > 0 {
+				logger.Info(name, zap.Int64("purged", purged))
+			}
+			return err
+		},
+	}
+}
+
+// expiryJob - задача отзыва назначений ролей, срок действия которых истёк; каждое отозванное назначение логируется
+func expiryJob(cfg config.Config, roleService *role.Service, logger *common.Logger) scheduler.Job {
+	return scheduler.Job{
+		Name:     "revoke expired role assignments",
+		Interval: cfg.RoleExpiryInterval,
+		Run: func(ctx context.Context) error {
+			expired, err := roleService.RevokeExpired(ctx)
+			for _, assignment := range expired {
+				logger.Info(
+					"role assignment expired",
+					zap.Int64("role_id", assignment.RoleId),
+					zap.Int64("employee_id", assignment.EmployeeId),
+					zap.Timep("valid_until", assignment.ValidUntil),
+				)
+			}
+			return err
+		},
+	}
+}
+
+// accessRequestExpiryJob - задача закрытия заявок на роли, не рассмотренных за cfg.AccessRequestTtl
+func accessRequestExpiryJob(
+	cfg config.Config,
+	accessRequestService *accessrequest.Service,
+	logger *common.Logger,
+) scheduler.Job {
+	return scheduler.Job{
+		Name:     "expire access requests",
+		Interval: cfg.RoleExpiryInterval,
+		Run: func(ctx context.Context) error {
+			expired, err := accessRequestService.ExpirePending(ctx)
+			for _, request := range expired {
+				logger.Info(
+					"access request expired",
+					zap.Int64("id", request.Id),
+					zap.Int64("employee_id", request.EmployeeId),
+					zap.Int64("role_id", request.RoleId),
+				)
+			}
+			return err
+		},
+	}
+}
+
+// certificationJob - задача закрытия кампаний ресертификации, срок которых наступил
+func certificationJob(
+	cfg config.Config,
+	certificationService *certification.Service,
+	logger *common.Logger,
+) scheduler.Job {
+	return scheduler.Job{
+		Name:     "complete certification campaigns",
+		Interval: cfg.CertificationInterval,
+		Run: func(ctx context.Context) error {
+			completed, err := certificationService.CompleteDue(ctx)
+			for _, campaign := range completed {
+				logger.Info(
+					"certification campaign completed",
+					zap.Int64("id", campaign.Id),
+					zap.Bool("auto_revoke", campaign.AutoRevoke),
+					zap.Int64("certified", campaign.Progress.Certified),
+					zap.Int64("revoked", campaign.Progress.Revoked),
+					zap.Int64("expired", campaign.Progress.Expired),
+				)
+			}
+			return err
+		},
+	}
+}
+
+// provisioningJob - задача постановки новых изменений из журнала аудита в очередь провижининга и их доставки
+func provisioningJob(
+	cfg config.Config,
+	provisioningService *provisioning.Service,
+	logger *common.Logger,
+) scheduler.Job {
+	return scheduler.Job{
+		Name:     "deliver provisioning operations",
+		Interval: cfg.ProvisioningInterval,
+		Run: func(ctx context.Context) error {
+			captured, err := provisioningService.CaptureChanges(ctx)
+			if err != nil {
+				return err
+			}
+			if captured > 0 {
+				logger.Info("provisioning operations queued", zap.Int64("captured", captured))
+			}
+			delivered, err := provisioningService.Deliver(ctx)
+			for _, operation := range delivered {
+				logger.Info(
+					"provisioning operation processed",
+					zap.Int64("id", operation.Id),
+					zap.Int64("target_id", operation.TargetId),
+					zap.String("resource_type", operation.ResourceType),
+					zap.Int64("resource_id", operation.ResourceId),
+					zap.String("status", operation.Status),
+					zap.Int("attempts", operation.Attempts),
+				)
+			}
+			return err
+		},
+	}
+}
+
+// reconcileJob - задача сверки целевых систем провижининга; найденные расхождения ставятся в очередь
+func reconcileJob(
+	cfg config.Config,
+	provisioningService *provisioning.Service,
+	logger *common.Logger,
+) scheduler.Job {
+	return scheduler.Job{
+		Name:     "reconcile provisioning targets",
+		Interval: cfg.ReconcileInterval,
+		Run: func(ctx context.Context) error {
+			reconciled, err := provisioningService.ReconcileAll(ctx)
+			for _, result := range reconciled {
+				logger.Info(
+					"provisioning target reconciled",
+					zap.Int64("target_id", result.TargetId),
+					zap.Int("users_create", len(result.Users.Create)),
+					zap.Int("users_update", len(result.Users.Update)),
+					zap.Int("users_delete", len(result.Users.Delete)),
+					zap.Int("users_orphaned", len(result.Users.Orphaned)),
+					zap.Int("groups_create", len(result.Groups.Create)),
+					zap.Int("groups_update", len(result.Groups.Update)),
+					zap.Int("groups_delete", len(result.Groups.Delete)),
+					zap.Int("groups_orphaned", len(result.Groups.Orphaned)),
+				)
+			}
+			return err
+		},
+	}
+}
+
+// webhookTimeout - сколько ждать ответа подписчика webhook; дольше - попытка неудачна
+const webhookTimeout = 10 * time.Second
+
+// webhookJob - задача доставки событий подписчикам webhook; исход каждой попытки логируется
+func webhookJob(cfg config.Config, webhookService *webhook.Service, logger *common.Logger) scheduler.Job {
+	return scheduler.Job{
+		Name:     "deliver webhook events",
+		Interval: cfg.WebhookInterval,
+		Run: func(ctx context.Context) error {
+			delivered, err := webhookService.Deliver(ctx)
+			for _, delivery := range delivered {
+				logger.Info(
+					"webhook delivery processed",
+					zap.Int64("id", delivery.Id),
+					zap.Int64("webhook_id", delivery.WebhookId),
+					zap.Int64("event_id", delivery.EventId),
+					zap.String("status", delivery.Status),
+					zap.Int("attempts", delivery.Attempts),
+					zap.Int("status_code", delivery.LastStatusCode),
+				)
+			}
+			return err
+		},
+	}
 }
 
 // Функция "элегантного" завершения работы сервера по сигналу от операционной системы
 func gracefulShutdown(
 	ctx context.Context,
 	server *web.Server,
+	jobs *scheduler.Scheduler,
+	events *outbox.Dispatcher,
 	db *sqlx.DB,
 	wg *sync.WaitGroup,
 	logger *common.Logger,
@@ -182,6 +478,23 @@ func gracefulShutdown(
 		logger.Info("Server shut down successfully")
 	}
 
+	// Останавливаем фоновые задачи до закрытия БД
+	jobs.Stop()
+	logger.Info("Scheduled jobs stopped")
+
+	// Отправляем накопившиеся доменные события: изменений больше не будет, сервер и задачи остановлены.
+	// Контекст сигнала уже отменён, поэтому на отправку отводится собственный срок
+	drainCtx, cancelDrain := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancelDrain()
+	if err := events.Drain(drainCtx); err != nil {
+		logger.Error(
+			"Domain events drain ended with an error, the rest will be sent after restart:",
+			zap.Error(err),
+		)
+	} else {
+		logger.Info("Domain events drained")
+	}
+
 	// Закрываем БД, чтобы координировать с завершением сервера.
 	if err := db.Close(); err != nil {
 		logger.Error(
@@ -0,0 +1,357 @@
+package accessrequest
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server               *web.Server
+	accessRequestService Svc
+	logger               *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	Create(ctx context.Context, request CreateRequest) (Response, error)
+	FindById(ctx context.Context, id int64) (Response, error)
+	FindMine(ctx context.Context) ([]Response, error)
+	FindAwaitingApproval(ctx context.Context) ([]Response, error)
+	Approve(ctx context.Context, id int64, request DecisionRequest) (Response, error)
+	Reject(ctx context.Context, id int64, request DecisionRequest) (Response, error)
+	Cancel(ctx context.Context, id int64) (Response, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	accessRequestService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:               server,
+		accessRequestService: accessRequestService,
+		logger:               logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/access-requests"
+	c.server.GroupAccessRequests.Post("/", c.server.Require(web.PermAccessRequestsCreate), c.Create)
+	c.server.GroupAccessRequests.Get("/mine", c.server.Require(web.PermAccessRequestsCreate), c.FindMine)
+	c.server.GroupAccessRequests.Get("/awaiting-approval", c.server.Require(web.PermAccessRequestsApprove), c.FindAwaitingApproval)
+	// заявку видят только запросивший и согласующий - это проверяет сервис, отдельного разрешения нет
+	c.server.GroupAccessRequests.Get("/:id", c.server.Require(), c.FindById)
+	c.server.GroupAccessRequests.Post("/:id/approve", c.server.Require(web.PermAccessRequestsApprove), c.Approve)
+	c.server.GroupAccessRequests.Post("/:id/reject", c.server.Require(web.PermAccessRequestsApprove), c.Reject)
+	c.server.GroupAccessRequests.Post("/:id/cancel", c.server.Require(web.PermAccessRequestsCreate), c.Cancel)
+}
+
+// Create 		 godoc
+// @Summary      request a role
+// @Description  Request a role for the calling employee; the request is routed to the role owner or the employee's manager
+// @Tags 		 access-request
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	accessrequest.CreateRequest true "Requested role and justification"
+// @Success 	 201  {object}  accessrequest.Response	"Access request"
+// @Failure      400  {object}  http.Response	"Bad request"
+// @Failure      403  {object}  http.Response	"Caller is not an employee"
+// @Failure      404  {object}  http.Response	"Role not found"
+// @Failure      409  {object}  http.Response	"Role already assigned or requested"
+// @Failure      500  {object}  http.Response	"Bad request"
+// @Router 		 /access-requests/ 	[post]
+func (c *Controller) Create(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an Create AccessRequest ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.accessRequestService.Create(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create AccessRequest ended with an error:",
+			zap.Error(err),
+			zap.Int64("role_id", request.RoleId),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// FindMine 	 godoc
+// @Description  Find access requests of the calling employee, newest first
+// @Summary		 get my access requests
+// @Tags 		 access-request
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		accessrequest.Response	"Access requests"
+// @Failure      403  {object}  	http.Response	"Caller is not an employee"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /access-requests/mine		[get]
+func (c *Controller) FindMine(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.accessRequestService.FindMine(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find my AccessRequests ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindAwaitingApproval godoc
+// @Description  Find pending access requests routed to the calling employee, oldest first
+// @Summary		 get access requests awaiting my approval
+// @Tags 		 access-request
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		accessrequest.Response	"Access requests"
+// @Failure      403  {object}  	http.Response	"Caller is not an employee"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /access-requests/awaiting-approval		[get]
+func (c *Controller) FindAwaitingApproval(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.accessRequestService.FindAwaitingApproval(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find AccessRequests awaiting approval ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find access request by ID, visible to the requester and the approver only
+// @Summary 	 find access request by ID
+// @Tags 		 access-request
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  		"Access request ID"
+// @Success 	 200  {object}  	accessrequest.Response	"Access request"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /access-requests/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	id, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.accessRequestService.FindById(appContext, id)
+	if err != nil {
+		c.logger.Error(
+			"When the get AccessRequest ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", id),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Approve 		 godoc
+// @Summary      approve access request
+// @Description  Approve pending access request routed to the caller; the role is assigned to the requester
+// @Tags 		 access-request
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  							true  	"Access request ID"
+// @Param 		 request 	body 		accessrequest.DecisionRequest 	false 	"Decision comment"
+// @Success 	 200  {object}  	accessrequest.Response	"Access request"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      403  {object}  	http.Response	"Caller is not the approver"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      409  {object}  	http.Response	"Request is not pending or segregation of duties violated"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /access-requests/{id}/approve 	[post]
+func (c *Controller) Approve(ctx *fiber.Ctx) error {
+	return c.decide(ctx, "approve", c.accessRequestService.Approve)
+}
+
+// Reject 		 godoc
+// @Summary      reject access request
+// @Description  Reject pending access request routed to the caller
+// @Tags 		 access-request
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  							true  	"Access request ID"
+// @Param 		 request 	body 		accessrequest.DecisionRequest 	false 	"Decision comment"
+// @Success 	 200  {object}  	accessrequest.Response	"Access request"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      403  {object}  	http.Response	"Caller is not the approver"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      409  {object}  	http.Response	"Request is not pending"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /access-requests/{id}/reject 	[post]
+func (c *Controller) Reject(ctx *fiber.Ctx) error {
+	return c.decide(ctx, "reject", c.accessRequestService.Reject)
+}
+
+// Cancel 		 godoc
+// @Summary      cancel access request
+// @Description  Cancel own pending access request
+// @Tags 		 access-request
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  true  	"Access request ID"
+// @Success 	 200  {object}  	accessrequest.Response	"Access request"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      403  {object}  	http.Response	"Caller is not the requester"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      409  {object}  	http.Response	"Request is not pending"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /access-requests/{id}/cancel 	[post]
+func (c *Controller) Cancel(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	id, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.accessRequestService.Cancel(appContext, id)
+	if err != nil {
+		c.logger.Error(
+			"When the cancel AccessRequest ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", id),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// decide - общий обработчик решений согласующего; комментарий в теле запроса необязателен
+func (c *Controller) decide(
+	ctx *fiber.Ctx,
+	action string,
+	decide func(ctx context.Context, id int64, request DecisionRequest) (Response, error),
+) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	id, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request DecisionRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&request); err != nil {
+			c.logger.Error(
+				"When the body parse an AccessRequest decision ended with an error:",
+				zap.Error(err),
+				zap.String("action", action),
+				zap.String("request_id", requestId),
+			)
+
+			return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+		}
+	}
+
+	response, err := decide(appContext, id, request)
+	if err != nil {
+		c.logger.Error(
+			"When the decision on AccessRequest ended with an error:",
+			zap.Error(err),
+			zap.String("action", action),
+			zap.Int64("id", id),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.ForbiddenError{}):
+		return http.ErrResponse(ctx, fiber.StatusForbidden, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}), errors.As(err, &domain.ConflictError{}),
+		errors.As(err, &domain.SodViolationError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,126 @@
+package accessrequest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAccessRequest_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockAccessRequestService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupAccessRequests:   app.Group("/api/v1/access-requests"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should create access request", func(t *testing.T) {
+		request := CreateRequest{RoleId: 5, Justification: "on-call rotation"}
+		created := Response{Id: 100, EmployeeId: 1, RoleId: 5, ApproverId: 2, Status: StatusPending}
+		mockService.On("Create", appContext, request).Return(created, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/access-requests/",
+			strings.NewReader(`{"roleId": 5, "justification": "on-call rotation"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data Response
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, created, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should list requests awaiting approval", func(t *testing.T) {
+		awaiting := []Response{{Id: 100, EmployeeId: 1, RoleId: 5, ApproverId: 2, Status: StatusPending}}
+		mockService.On("FindAwaitingApproval", appContext).Return(awaiting, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/access-requests/awaiting-approval", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should approve without decision comment", func(t *testing.T) {
+		approved := Response{Id: 100, Status: StatusApproved}
+		mockService.On("Approve", appContext, int64(100), DecisionRequest{}).Return(approved, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/access-requests/100/approve", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 403 when caller is not the approver", func(t *testing.T) {
+		forbidden := domain.ForbiddenError{Message: "only the approver can decide on access request 100"}
+		request := DecisionRequest{Comment: "no"}
+		mockService.On("Reject", appContext, int64(100), request).Return(Response{}, forbidden).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/access-requests/100/reject", strings.NewReader(`{"comment": "no"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, forbidden.Message, body.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when request is no longer pending", func(t *testing.T) {
+		conflict := domain.ConflictError{Message: "access request 100 is already approved"}
+		mockService.On("Cancel", appContext, int64(100)).Return(Response{}, conflict).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/access-requests/100/cancel", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,96 @@
+package accessrequest
+
+import (
+	"time"
+)
+
+// Состояния заявки на роль
+const (
+	StatusPending   = "pending"   // ожидает решения согласующего
+	StatusApproved  = "approved"  // согласована, роль назначена
+	StatusRejected  = "rejected"  // отклонена согласующим
+	StatusExpired   = "expired"   // не рассмотрена до expires_at
+	StatusCancelled = "cancelled" // отозвана самим сотрудником
+)
+
+// Entity - заявка вместе с именами сотрудника, роли и согласующего (заполняются только при выборке)
+type Entity struct {
+	Id              int64      `db:"id"`
+	EmployeeId      int64      `db:"employee_id"`
+	RoleId          int64      `db:"role_id"`
+	ApproverId      int64      `db:"approver_id"`
+	Status          string     `db:"status"`
+	Justification   string     `db:"justification"`
+	ValidUntil      *time.Time `db:"valid_until"` // запрошенный срок действия назначения, nil - бессрочно
+	DecisionComment string     `db:"decision_comment"`
+	DecidedAt       *time.Time `db:"decided_at"`
+	ExpiresAt       time.Time  `db:"expires_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	EmployeeName    string     `db:"employee_name"`
+	RoleName        string     `db:"role_name"`
+	ApproverName    string     `db:"approver_name"`
+}
+
+// Response model info
+// @Description Access request of employee for role
+// @Description with requester, role, approver, status, justification and decision
+type Response struct {
+	Id              int64      `json:"id"`
+	EmployeeId      int64      `json:"employeeId"`
+	EmployeeName    string     `json:"employeeName,omitempty"`
+	RoleId          int64      `json:"roleId"`
+	RoleName        string     `json:"roleName,omitempty"`
+	ApproverId      int64      `json:"approverId"`
+	ApproverName    string     `json:"approverName,omitempty"`
+	Status          string     `json:"status"`
+	Justification   string     `json:"justification"`
+	ValidUntil      *time.Time `json:"validUntil,omitempty"`
+	DecisionComment string     `json:"decisionComment,omitempty"`
+	DecidedAt       *time.Time `json:"decidedAt,omitempty"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	CreateAt        time.Time  `json:"createAt"`
+	UpdateAt        time.Time  `json:"updateAt"`
+}
+
+func (e *Entity) ToResponse() Response {
+	return Response{
+		Id:              e.Id,
+		EmployeeId:      e.EmployeeId,
+		EmployeeName:    e.EmployeeName,
+		RoleId:          e.RoleId,
+		RoleName:        e.RoleName,
+		ApproverId:      e.ApproverId,
+		ApproverName:    e.ApproverName,
+		Status:          e.Status,
+		Justification:   e.Justification,
+		ValidUntil:      e.ValidUntil,
+		DecisionComment: e.DecisionComment,
+		DecidedAt:       e.DecidedAt,
+		ExpiresAt:       e.ExpiresAt,
+		CreateAt:        e.CreatedAt,
+		UpdateAt:        e.UpdatedAt,
+	}
+}
+
+// isOverdue - заявка на рассмотрении, но срок рассмотрения уже истёк (ещё не закрыта планировщиком)
+func (e *Entity) isOverdue(now time.Time) bool {
+	return e.Status == StatusPending && !e.ExpiresAt.After(now)
+}
+
+// CreateRequest - заявка вызывающего сотрудника на роль
+type CreateRequest struct {
+	RoleId        int64      `json:"roleId" validate:"required,min=1"`
+	Justification string     `json:"justification" validate:"required,min=3,max=1000"`
+	ValidUntil    *time.Time `json:"validUntil"` // не задан - роль запрашивается бессрочно
+}
+
+// DecisionRequest - решение согласующего по заявке
+type DecisionRequest struct {
+	Id      int64  `json:"-" validate:"required,min=1"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
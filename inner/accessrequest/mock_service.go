@@ -0,0 +1,45 @@
+package accessrequest
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccessRequestService struct {
+	mock.Mock
+}
+
+func (m *MockAccessRequestService) Create(ctx context.Context, request CreateRequest) (Response, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockAccessRequestService) FindById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockAccessRequestService) FindMine(ctx context.Context) ([]Response, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockAccessRequestService) FindAwaitingApproval(ctx context.Context) ([]Response, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockAccessRequestService) Approve(ctx context.Context, id int64, request DecisionRequest) (Response, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockAccessRequestService) Reject(ctx context.Context, id int64, request DecisionRequest) (Response, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockAccessRequestService) Cancel(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
@@ -0,0 +1,317 @@
+package accessrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"idm/inner/outbox"
+	"idm/inner/sod"
+	"time"
+)
+
+// selectRequests - выборка заявок с именами сотрудника, роли и согласующего
+const selectRequests = `
+	SELECT ar.*, e.name AS employee_name, r.name AS role_name, a.name AS approver_name
+	FROM access_requests ar
+	JOIN employees e ON e.id = ar.employee_id
+	JOIN roles r ON r.id = ar.role_id
+	JOIN employees a ON a.id = ar.approver_id
+`
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindById - найти заявку по id
+func (r *Repository) FindById(ctx context.Context, id int64) (request Entity, err error) {
+	err = r.db.GetContext(ctx, &request, selectRequests+"WHERE ar.id = $1", id)
+
+	return request, err
+}
+
+// FindByEmployeeId - все заявки сотрудника, новые первыми
+func (r *Repository) FindByEmployeeId(ctx context.Context, employeeId int64) (requests []Entity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&requests,
+		selectRequests+"WHERE ar.employee_id = $1 ORDER BY ar.created_at DESC, ar.id DESC",
+		employeeId,
+	)
+
+	return requests, err
+}
+
+// FindPendingByApproverId - заявки, ожидающие решения согласующего (с не истёкшим сроком рассмотрения),
+// старые первыми
+func (r *Repository) FindPendingByApproverId(ctx context.Context, approverId int64) (requests []Entity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&requests,
+		selectRequests+`WHERE ar.approver_id = $1 AND ar.status = 'pending' AND ar.expires_at > NOW()
+		ORDER BY ar.created_at, ar.id`,
+		approverId,
+	)
+
+	return requests, err
+}
+
+// ExistsPending - есть ли у сотрудника заявка на роль, ожидающая решения
+func (r *Repository) ExistsPending(ctx context.Context, employeeId int64, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		`SELECT EXISTS(
+			SELECT 1 FROM access_requests
+			WHERE employee_id = $1 AND role_id = $2 AND status = 'pending' AND expires_at > NOW()
+		)`,
+		employeeId, roleId,
+	)
+
+	return isExists, err
+}
+
+// ExistsAssignment - назначена ли роль сотруднику напрямую (истёкшие назначения не учитываются)
+func (r *Repository) ExistsAssignment(ctx context.Context, employeeId int64, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		`SELECT EXISTS(
+			SELECT 1 FROM employee_roles
+			WHERE employee_id = $1 AND role_id = $2 AND (valid_until IS NULL OR valid_until > NOW())
+		)`,
+		employeeId, roleId,
+	)
+
+	return isExists, err
+}
+
+// ExistsRoleById - проверить наличие не удалённой роли
+func (r *Repository) ExistsRoleById(ctx context.Context, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)",
+		roleId,
+	)
+
+	return isExists, err
+}
+
+// ExistsAssignableEmployeeById - проверить наличие сотрудника, которому можно назначать роли
+// (мягко удалённые и уволенные не учитываются)
+func (r *Repository) ExistsAssignableEmployeeById(ctx context.Context, employeeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL AND status <> 'terminated')",
+		employeeId,
+	)
+
+	return isExists, err
+}
+
+// FindApproverId - согласующий заявки сотрудника на роль: владелец роли, а если его нет (или он сам
+// запрашивает роль) - руководитель сотрудника. Согласующим может быть только работающий сотрудник;
+// nil - согласовать некому
+func (r *Repository) FindApproverId(ctx context.Context, employeeId int64, roleId int64) (approverId *int64, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&approverId,
+		`SELECT COALESCE(
+			(SELECT o.id FROM roles r
+			JOIN employees o ON o.id = r.owner_id AND o.deleted_at IS NULL AND o.status = 'active'
+			WHERE r.id = $2 AND o.id <> $1),
+			(SELECT m.id FROM employees e
+			JOIN employees m ON m.id = e.manager_id AND m.deleted_at IS NULL AND m.status = 'active'
+			WHERE e.id = $1)
+		)`,
+		employeeId, roleId,
+	)
+
+	return approverId, err
+}
+
+// Create - добавить заявку
+func (r *Repository) Create(ctx context.Context, entity *Entity) (created Entity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(
+			ctx,
+			&created,
+			`INSERT INTO access_requests
+				(employee_id, role_id, approver_id, status, justification, valid_until, expires_at, created_at, updated_at)
+			VALUES ($1, $2, $3, 'pending', $4, $5, $6, $7, $7)
+			RETURNING *`,
+			entity.EmployeeId, entity.RoleId, entity.ApproverId, entity.Justification,
+			entity.ValidUntil, entity.ExpiresAt, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityAccessRequest,
+			EntityId:   created.Id,
+			After:      created.ToResponse(),
+		})
+	})
+
+	return created, err
+}
+
+// Approve - согласовать заявку и в той же транзакции назначить роль сотруднику на запрошенный срок
+// (уже существующее назначение только продлевается, бессрочное остаётся бессрочным).
+// Возвращает false, если заявка уже не ожидает решения; если назначение нарушает правило разделения
+// полномочий - sod.ErrViolation, и заявка остаётся на рассмотрении
+func (r *Repository) Approve(ctx context.Context, id int64, comment string) (isDecided bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		approved, err := r.decideTx(ctx, tx, id, StatusApproved, comment)
+		if err != nil || approved == nil {
+			return err
+		}
+
+		if err := sod.CheckAssignmentTx(ctx, tx, approved.EmployeeId, approved.RoleId); err != nil {
+			return err
+		}
+
+		var validFrom, validUntil *time.Time
+		err = tx.QueryRowxContext(
+			ctx,
+			`INSERT INTO employee_roles (employee_id, role_id, created_at, valid_from, valid_until)
+			VALUES ($1, $2, $3, $3, $4)
+			ON CONFLICT (employee_id, role_id) DO UPDATE
+			SET valid_from = LEAST(employee_roles.valid_from, EXCLUDED.valid_from),
+				valid_until = CASE
+					WHEN employee_roles.valid_until IS NULL OR $4::TIMESTAMPTZ IS NULL THEN NULL
+					ELSE GREATEST(employee_roles.valid_until, $4::TIMESTAMPTZ)
+				END
+			RETURNING valid_from, valid_until`,
+			approved.EmployeeId, approved.RoleId, time.Now(), approved.ValidUntil,
+		).Scan(&validFrom, &validUntil)
+		if err != nil {
+			return err
+		}
+
+		isDecided = true
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionAssign,
+			EntityType: audit.EntityEmployeeRole,
+			EntityId:   approved.EmployeeId,
+			After: audit.EmployeeRoleSnapshot{
+				EmployeeId: approved.EmployeeId,
+				RoleId:     approved.RoleId,
+				ValidFrom:  validFrom,
+				ValidUntil: validUntil,
+			},
+		})
+	})
+
+	return isDecided && err == nil, err
+}
+
+// Decide - перевести заявку, ожидающую решения, в состояние status (отклонение, отзыв).
+// Возвращает false, если заявка уже не ожидает решения
+func (r *Repository) Decide(ctx context.Context, id int64, status string, comment string) (isDecided bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		decided, err := r.decideTx(ctx, tx, id, status, comment)
+		isDecided = decided != nil
+		return err
+	})
+
+	return isDecided && err == nil, err
+}
+
+// decideTx - закрыть заявку решением; nil, если заявки нет, она уже закрыта или истёк срок рассмотрения
+func (r *Repository) decideTx(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	id int64,
+	status string,
+	comment string,
+) (*Entity, error) {
+	var before Entity
+	err := tx.GetContext(
+		ctx,
+		&before,
+		"SELECT * FROM access_requests WHERE id = $1 AND status = 'pending' AND expires_at > NOW() FOR UPDATE",
+		id,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var after Entity
+	err = tx.GetContext(
+		ctx,
+		&after,
+		`UPDATE access_requests SET status = $2, decision_comment = $3, decided_at = $4, updated_at = $4
+		WHERE id = $1
+		RETURNING *`,
+		id, status, comment, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = audit.InsertEventTx(ctx, tx, audit.Event{
+		Action:     audit.ActionStatusChange,
+		EntityType: audit.EntityAccessRequest,
+		EntityId:   id,
+		Before:     before.ToResponse(),
+		After:      after.ToResponse(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &after, nil
+}
+
+// ExpirePending - закрыть заявки, не рассмотренные до истечения срока, по событию аудита на каждую
+func (r *Repository) ExpirePending(ctx context.Context, now time.Time) (expired []Entity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(
+			ctx,
+			&expired,
+			`UPDATE access_requests SET status = 'expired', updated_at = $1
+			WHERE status = 'pending' AND expires_at <= $1
+			RETURNING *`,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, request := range expired {
+			var before = request
+			before.Status = StatusPending
+			err := audit.InsertEventTx(ctx, tx, audit.Event{
+				Action:     audit.ActionStatusChange,
+				EntityType: audit.EntityAccessRequest,
+				EntityId:   request.Id,
+				Before:     before.ToResponse(),
+				After:      request.ToResponse(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,359 @@
+package accessrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/sod"
+	"idm/inner/web/middleware"
+	"strconv"
+	"time"
+)
+
+type Service struct {
+	repo       Repo
+	validator  Validator
+	pendingTtl time.Duration // сколько заявка ждёт решения, прежде чем истечь
+}
+
+type Repo interface {
+	FindById(ctx context.Context, id int64) (Entity, error)
+	FindByEmployeeId(ctx context.Context, employeeId int64) ([]Entity, error)
+	FindPendingByApproverId(ctx context.Context, approverId int64) ([]Entity, error)
+	ExistsPending(ctx context.Context, employeeId int64, roleId int64) (bool, error)
+	ExistsAssignment(ctx context.Context, employeeId int64, roleId int64) (bool, error)
+	ExistsRoleById(ctx context.Context, roleId int64) (bool, error)
+	ExistsAssignableEmployeeById(ctx context.Context, employeeId int64) (bool, error)
+	FindApproverId(ctx context.Context, employeeId int64, roleId int64) (*int64, error)
+	Create(ctx context.Context, entity *Entity) (Entity, error)
+	Approve(ctx context.Context, id int64, comment string) (bool, error)
+	Decide(ctx context.Context, id int64, status string, comment string) (bool, error)
+	ExpirePending(ctx context.Context, now time.Time) ([]Entity, error)
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator, pendingTtl time.Duration) *Service {
+	return &Service{
+		repo:       repo,
+		validator:  validator,
+		pendingTtl: pendingTtl,
+	}
+}
+
+// Create - вызывающий сотрудник запрашивает роль. Заявка направляется владельцу роли,
+// а если его нет - руководителю сотрудника
+func (svc *Service) Create(ctx context.Context, request CreateRequest) (Response, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.ValidUntil != nil && !request.ValidUntil.After(time.Now()) {
+		return Response{}, domain.RequestValidationError{Message: "validUntil must be in the future"}
+	}
+
+	employeeId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	if err = svc.checkAssignable(ctx, employeeId, request.RoleId); err != nil {
+		return Response{}, err
+	}
+
+	isAssigned, err := svc.repo.ExistsAssignment(ctx, employeeId, request.RoleId)
+	if err != nil {
+		return Response{}, fmt.Errorf("error checking assignment of role %d: %w", request.RoleId, err)
+	}
+	if isAssigned {
+		return Response{}, domain.AlreadyExistsError{
+			Message: fmt.Sprintf("role %d is already assigned to employee %d", request.RoleId, employeeId),
+		}
+	}
+
+	isPending, err := svc.repo.ExistsPending(ctx, employeeId, request.RoleId)
+	if err != nil {
+		return Response{}, fmt.Errorf("error checking pending access requests for role %d: %w", request.RoleId, err)
+	}
+	if isPending {
+		return Response{}, domain.AlreadyExistsError{
+			Message: fmt.Sprintf("access request for role %d is already pending", request.RoleId),
+		}
+	}
+
+	approverId, err := svc.repo.FindApproverId(ctx, employeeId, request.RoleId)
+	if err != nil {
+		return Response{}, fmt.Errorf("error finding approver for role %d: %w", request.RoleId, err)
+	}
+	if approverId == nil {
+		return Response{}, domain.RequestValidationError{
+			Message: fmt.Sprintf("no approver for role %d: role has no owner and employee has no manager", request.RoleId),
+		}
+	}
+
+	created, err := svc.repo.Create(ctx, &Entity{
+		EmployeeId:    employeeId,
+		RoleId:        request.RoleId,
+		ApproverId:    *approverId,
+		Justification: request.Justification,
+		ValidUntil:    request.ValidUntil,
+		ExpiresAt:     time.Now().Add(svc.pendingTtl),
+	})
+	if err != nil {
+		return Response{}, fmt.Errorf("error creating access request for role %d: %w", request.RoleId, err)
+	}
+
+	return svc.findById(ctx, created.Id)
+}
+
+// FindById - заявка видна только запросившему её сотруднику и согласующему
+func (svc *Service) FindById(ctx context.Context, id int64) (Response, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	callerId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	entity, err := svc.findVisible(ctx, id, callerId)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// FindMine - заявки вызывающего сотрудника, новые первыми
+func (svc *Service) FindMine(ctx context.Context) ([]Response, error) {
+	employeeId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindByEmployeeId(ctx, employeeId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding access requests of employee %d: %w", employeeId, err)
+	}
+
+	return toResponses(entities), nil
+}
+
+// FindAwaitingApproval - заявки, ожидающие решения вызывающего сотрудника, старые первыми
+func (svc *Service) FindAwaitingApproval(ctx context.Context) ([]Response, error) {
+	approverId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindPendingByApproverId(ctx, approverId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding access requests awaiting approval of employee %d: %w", approverId, err)
+	}
+
+	return toResponses(entities), nil
+}
+
+// Approve - согласующий одобряет заявку, роль назначается сотруднику автоматически
+func (svc *Service) Approve(ctx context.Context, id int64, request DecisionRequest) (Response, error) {
+	request.Id = id
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findPendingForApprover(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+	err = svc.checkAssignable(ctx, entity.EmployeeId, entity.RoleId)
+	if errors.As(err, &domain.NotFoundError{}) {
+		// роль удалили или сотрудника уволили, пока заявка ждала решения
+		return Response{}, domain.ConflictError{
+			Message: fmt.Sprintf("access request %d can no longer be approved: %v", id, err),
+		}
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	isDecided, err := svc.repo.Approve(ctx, id, request.Comment)
+	if errors.Is(err, sod.ErrViolation) {
+		return Response{}, domain.SodViolationError{Message: err.Error()}
+	}
+	if err != nil {
+		return Response{}, fmt.Errorf("error approving access request %d: %w", id, err)
+	}
+	if !isDecided {
+		return Response{}, notPendingError(id)
+	}
+
+	return svc.findById(ctx, id)
+}
+
+// Reject - согласующий отклоняет заявку
+func (svc *Service) Reject(ctx context.Context, id int64, request DecisionRequest) (Response, error) {
+	request.Id = id
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findPendingForApprover(ctx, id); err != nil {
+		return Response{}, err
+	}
+
+	return svc.decide(ctx, id, StatusRejected, request.Comment)
+}
+
+// Cancel - сотрудник отзывает свою заявку, пока по ней нет решения
+func (svc *Service) Cancel(ctx context.Context, id int64) (Response, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	callerId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+	entity, err := svc.findVisible(ctx, id, callerId)
+	if err != nil {
+		return Response{}, err
+	}
+	if entity.EmployeeId != callerId {
+		return Response{}, domain.ForbiddenError{
+			Message: fmt.Sprintf("only the requester can cancel access request %d", id),
+		}
+	}
+	if err = checkPending(entity); err != nil {
+		return Response{}, err
+	}
+
+	return svc.decide(ctx, id, StatusCancelled, "")
+}
+
+// ExpirePending - закрыть заявки, не рассмотренные вовремя, возвращает закрытые заявки
+func (svc *Service) ExpirePending(ctx context.Context) ([]Response, error) {
+	expired, err := svc.repo.ExpirePending(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("error expiring access requests: %w", err)
+	}
+
+	return toResponses(expired), nil
+}
+
+func (svc *Service) decide(ctx context.Context, id int64, status string, comment string) (Response, error) {
+	isDecided, err := svc.repo.Decide(ctx, id, status, comment)
+	if err != nil {
+		return Response{}, fmt.Errorf("error changing access request %d to %s: %w", id, status, err)
+	}
+	if !isDecided {
+		return Response{}, notPendingError(id)
+	}
+
+	return svc.findById(ctx, id)
+}
+
+// findPendingForApprover - заявка, по которой вызывающий может принять решение
+func (svc *Service) findPendingForApprover(ctx context.Context, id int64) (Entity, error) {
+	callerId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return Entity{}, err
+	}
+	entity, err := svc.findVisible(ctx, id, callerId)
+	if err != nil {
+		return Entity{}, err
+	}
+	if entity.ApproverId != callerId {
+		return Entity{}, domain.ForbiddenError{
+			Message: fmt.Sprintf("only the approver can decide on access request %d", id),
+		}
+	}
+
+	return entity, checkPending(entity)
+}
+
+// findVisible - заявка, если вызывающий её запросил или согласует; для остальных её как будто нет
+func (svc *Service) findVisible(ctx context.Context, id int64, callerId int64) (Entity, error) {
+	entity, err := svc.repo.FindById(ctx, id)
+	if err == nil && entity.EmployeeId != callerId && entity.ApproverId != callerId {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entity{}, domain.NotFoundError{Message: fmt.Sprintf("access request with id %d not found", id)}
+	}
+	if err != nil {
+		return Entity{}, fmt.Errorf("error finding access request with id %d: %w", id, err)
+	}
+
+	return entity, nil
+}
+
+func (svc *Service) findById(ctx context.Context, id int64) (Response, error) {
+	entity, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return Response{}, fmt.Errorf("error finding access request with id %d: %w", id, err)
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// checkAssignable - роль существует, а сотруднику можно назначать роли
+func (svc *Service) checkAssignable(ctx context.Context, employeeId int64, roleId int64) error {
+	isExists, err := svc.repo.ExistsRoleById(ctx, roleId)
+	if err != nil {
+		return fmt.Errorf("error checking role with id %d: %w", roleId, err)
+	}
+	if !isExists {
+		return domain.NotFoundError{Message: fmt.Sprintf("role with id %d not found", roleId)}
+	}
+
+	isExists, err = svc.repo.ExistsAssignableEmployeeById(ctx, employeeId)
+	if err != nil {
+		return fmt.Errorf("error checking employee with id %d: %w", employeeId, err)
+	}
+	if !isExists {
+		return domain.NotFoundError{Message: fmt.Sprintf("employee with id %d not found", employeeId)}
+	}
+
+	return nil
+}
+
+// checkPending - по заявке ещё можно принять решение
+func checkPending(entity Entity) error {
+	if entity.isOverdue(time.Now()) {
+		return domain.ConflictError{Message: fmt.Sprintf("access request %d has expired", entity.Id)}
+	}
+	if entity.Status != StatusPending {
+		return domain.ConflictError{Message: fmt.Sprintf("access request %d is already %s", entity.Id, entity.Status)}
+	}
+
+	return nil
+}
+
+func notPendingError(id int64) error {
+	return domain.ConflictError{Message: fmt.Sprintf("access request %d is no longer pending", id)}
+}
+
+// callerEmployeeId - id вызывающего сотрудника; заявки подают и согласуют только сотрудники
+func callerEmployeeId(ctx context.Context) (int64, error) {
+	var caller = common.CallerFromContext(ctx)
+	if caller.SubjectType == "" || caller.SubjectType == middleware.SubjectTypeEmployee {
+		if employeeId, err := strconv.ParseInt(caller.Subject, 10, 64); err == nil {
+			return employeeId, nil
+		}
+	}
+
+	return 0, domain.ForbiddenError{Message: "access requests are available to employees only"}
+}
+
+func toResponses(entities []Entity) []Response {
+	responses := make([]Response, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses
+}
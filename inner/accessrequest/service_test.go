@@ -0,0 +1,274 @@
+package accessrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"testing"
+	"time"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindById(ctx context.Context, id int64) (Entity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindByEmployeeId(ctx context.Context, employeeId int64) ([]Entity, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindPendingByApproverId(ctx context.Context, approverId int64) ([]Entity, error) {
+	args := m.Called(ctx, approverId)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsPending(ctx context.Context, employeeId int64, roleId int64) (bool, error) {
+	args := m.Called(ctx, employeeId, roleId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ExistsAssignment(ctx context.Context, employeeId int64, roleId int64) (bool, error) {
+	args := m.Called(ctx, employeeId, roleId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ExistsRoleById(ctx context.Context, roleId int64) (bool, error) {
+	args := m.Called(ctx, roleId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ExistsAssignableEmployeeById(ctx context.Context, employeeId int64) (bool, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) FindApproverId(ctx context.Context, employeeId int64, roleId int64) (*int64, error) {
+	args := m.Called(ctx, employeeId, roleId)
+	return args.Get(0).(*int64), args.Error(1)
+}
+
+func (m *MockRepo) Create(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) Approve(ctx context.Context, id int64, comment string) (bool, error) {
+	args := m.Called(ctx, id, comment)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) Decide(ctx context.Context, id int64, status string, comment string) (bool, error) {
+	args := m.Called(ctx, id, status, comment)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ExpirePending(ctx context.Context, now time.Time) ([]Entity, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+// asEmployee - контекст запроса от имени сотрудника, как его кладёт JwtAuthMiddleware
+func asEmployee(subject string) context.Context {
+	return common.WithCaller(context.Background(), common.Caller{Subject: subject, SubjectType: "employee"})
+}
+
+func TestAccessRequestService_Create(t *testing.T) {
+	var a = assert.New(t)
+	var ttl = 72 * time.Hour
+	ctx := asEmployee("1")
+	request := CreateRequest{RoleId: 5, Justification: "on-call rotation"}
+
+	t.Run("should route request to approver", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+		var approverId = int64(2)
+		created := Entity{Id: 100, EmployeeId: 1, RoleId: 5, ApproverId: 2, Status: StatusPending}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsRoleById", ctx, int64(5)).Return(true, nil).Once()
+		repo.On("ExistsAssignableEmployeeById", ctx, int64(1)).Return(true, nil).Once()
+		repo.On("ExistsAssignment", ctx, int64(1), int64(5)).Return(false, nil).Once()
+		repo.On("ExistsPending", ctx, int64(1), int64(5)).Return(false, nil).Once()
+		repo.On("FindApproverId", ctx, int64(1), int64(5)).Return(&approverId, nil).Once()
+		repo.On("Create", ctx, mock.MatchedBy(func(entity *Entity) bool {
+			return entity.EmployeeId == 1 && entity.RoleId == 5 && entity.ApproverId == 2 &&
+				entity.Justification == request.Justification &&
+				entity.ExpiresAt.After(time.Now().Add(ttl-time.Minute))
+		})).Return(created, nil).Once()
+		repo.On("FindById", ctx, int64(100)).Return(created, nil).Once()
+
+		got, err := service.Create(ctx, request)
+
+		a.Nil(err)
+		a.Equal(int64(2), got.ApproverId)
+		a.Equal(StatusPending, got.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject request when nobody can approve it", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsRoleById", ctx, int64(5)).Return(true, nil).Once()
+		repo.On("ExistsAssignableEmployeeById", ctx, int64(1)).Return(true, nil).Once()
+		repo.On("ExistsAssignment", ctx, int64(1), int64(5)).Return(false, nil).Once()
+		repo.On("ExistsPending", ctx, int64(1), int64(5)).Return(false, nil).Once()
+		repo.On("FindApproverId", ctx, int64(1), int64(5)).Return((*int64)(nil), nil).Once()
+
+		_, err := service.Create(ctx, request)
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return already exists when request is pending", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsRoleById", ctx, int64(5)).Return(true, nil).Once()
+		repo.On("ExistsAssignableEmployeeById", ctx, int64(1)).Return(true, nil).Once()
+		repo.On("ExistsAssignment", ctx, int64(1), int64(5)).Return(false, nil).Once()
+		repo.On("ExistsPending", ctx, int64(1), int64(5)).Return(true, nil).Once()
+
+		_, err := service.Create(ctx, request)
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "FindApproverId", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should forbid requests from clients", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+		clientCtx := common.WithCaller(context.Background(), common.Caller{Subject: "billing", SubjectType: "client"})
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		_, err := service.Create(clientCtx, request)
+
+		a.True(errors.As(err, &domain.ForbiddenError{}))
+		repo.AssertNotCalled(t, "ExistsRoleById", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAccessRequestService_Decisions(t *testing.T) {
+	var a = assert.New(t)
+	var ttl = 72 * time.Hour
+	approverCtx := asEmployee("2")
+	pending := Entity{Id: 100, EmployeeId: 1, RoleId: 5, ApproverId: 2, Status: StatusPending,
+		ExpiresAt: time.Now().Add(time.Hour)}
+
+	t.Run("should approve request routed to caller", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+		request := DecisionRequest{Id: 100, Comment: "ok"}
+		approved := pending
+		approved.Status = StatusApproved
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", approverCtx, int64(100)).Return(pending, nil).Once()
+		repo.On("ExistsRoleById", approverCtx, int64(5)).Return(true, nil).Once()
+		repo.On("ExistsAssignableEmployeeById", approverCtx, int64(1)).Return(true, nil).Once()
+		repo.On("Approve", approverCtx, int64(100), "ok").Return(true, nil).Once()
+		repo.On("FindById", approverCtx, int64(100)).Return(approved, nil).Once()
+
+		got, err := service.Approve(approverCtx, 100, DecisionRequest{Comment: "ok"})
+
+		a.Nil(err)
+		a.Equal(StatusApproved, got.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should forbid requester to approve own request", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+		requesterCtx := asEmployee("1")
+
+		validator.On("Validate", DecisionRequest{Id: 100}).Return(nil).Once()
+		repo.On("FindById", requesterCtx, int64(100)).Return(pending, nil).Once()
+
+		_, err := service.Approve(requesterCtx, 100, DecisionRequest{})
+
+		a.True(errors.As(err, &domain.ForbiddenError{}))
+		repo.AssertNotCalled(t, "Approve", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should hide request from other employees", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+		strangerCtx := asEmployee("3")
+
+		validator.On("Validate", FindByIDRequest{ID: 100}).Return(nil).Once()
+		repo.On("FindById", strangerCtx, int64(100)).Return(pending, nil).Once()
+
+		_, err := service.FindById(strangerCtx, 100)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+
+	t.Run("should return conflict when request is overdue", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+		overdue := pending
+		overdue.ExpiresAt = time.Now().Add(-time.Minute)
+
+		validator.On("Validate", DecisionRequest{Id: 100}).Return(nil).Once()
+		repo.On("FindById", approverCtx, int64(100)).Return(overdue, nil).Once()
+
+		_, err := service.Reject(approverCtx, 100, DecisionRequest{})
+
+		a.True(errors.As(err, &domain.ConflictError{}))
+		repo.AssertNotCalled(t, "Decide", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should cancel own pending request", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+		requesterCtx := asEmployee("1")
+		cancelled := pending
+		cancelled.Status = StatusCancelled
+
+		validator.On("Validate", FindByIDRequest{ID: 100}).Return(nil).Once()
+		repo.On("FindById", requesterCtx, int64(100)).Return(pending, nil).Once()
+		repo.On("Decide", requesterCtx, int64(100), StatusCancelled, "").Return(true, nil).Once()
+		repo.On("FindById", requesterCtx, int64(100)).Return(cancelled, nil).Once()
+
+		got, err := service.Cancel(requesterCtx, 100)
+
+		a.Nil(err)
+		a.Equal(StatusCancelled, got.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found for missing request", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, ttl)
+
+		validator.On("Validate", FindByIDRequest{ID: 100}).Return(nil).Once()
+		repo.On("FindById", approverCtx, int64(100)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		_, err := service.Cancel(approverCtx, 100)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GenesisHash - prev_hash первой записи цепочки
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// chainContent - то, что покрывает хеш записи. Порядок полей фиксирован,
+// время - в UTC с точностью до микросекунд (как хранит PostgreSQL)
+type chainContent struct {
+	Seq        int64   `json:"seq"`
+	OccurredAt string  `json:"occurredAt"`
+	Actor      string  `json:"actor"`
+	ActorType  string  `json:"actorType"`
+	Action     string  `json:"action"`
+	EntityType string  `json:"entityType"`
+	EntityId   int64   `json:"entityId"`
+	Before     *string `json:"before"`
+	After      *string `json:"after"`
+	RequestId  string  `json:"requestId"`
+	PrevHash   string  `json:"prevHash"`
+}
+
+// ComputeHash - SHA-256 (hex) содержимого записи вместе с хешем предыдущей
+func (e *ChainEntity) ComputeHash() string {
+	data, _ := json.Marshal(chainContent{
+		Seq:        e.Seq,
+		OccurredAt: e.OccurredAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		Actor:      e.Actor,
+		ActorType:  e.ActorType,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityId:   e.EntityId,
+		Before:     nullableString(e.BeforeData),
+		After:      nullableString(e.AfterData),
+		RequestId:  e.RequestId,
+		PrevHash:   e.PrevHash,
+	})
+	var sum = sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func nullableString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+// chainVerifier - пошаговая проверка цепочки, записи подаются по возрастанию seq
+type chainVerifier struct {
+	result   VerifyResponse
+	prevSeq  int64
+	prevHash string
+}
+
+func newChainVerifier() *chainVerifier {
+	return &chainVerifier{
+		result:   VerifyResponse{Valid: true, LastHash: GenesisHash},
+		prevHash: GenesisHash,
+	}
+}
+
+// check - проверить очередную запись, возвращает false на первом разрыве
+func (v *chainVerifier) check(entity ChainEntity) bool {
+	switch {
+	case entity.Seq != v.prevSeq+1:
+		v.broken(entity.Seq, "sequence gap: expected seq %d", v.prevSeq+1)
+	case entity.PrevHash != v.prevHash:
+		v.broken(entity.Seq, "prev_hash does not match hash of seq %d", v.prevSeq)
+	case entity.ComputeHash() != entity.Hash:
+		v.broken(entity.Seq, "content hash mismatch")
+	default:
+		v.prevSeq = entity.Seq
+		v.prevHash = entity.Hash
+		v.result.Checked++
+		v.result.LastSeq = entity.Seq
+		v.result.LastHash = entity.Hash
+		return true
+	}
+	return false
+}
+
+func (v *chainVerifier) broken(seq int64, format string, args ...any) {
+	v.result.Valid = false
+	v.result.BrokenSeq = seq
+	v.result.Reason = fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,199 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+	"time"
+)
+
+const (
+	internalServerError   = "Internal server error"
+	invalidQueryParamsFmt = "Invalid query parameters: %s"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server       *web.Server
+	auditService Svc
+	logger       *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	GetPage(ctx context.Context, request PageRequest) (PageResponse, error)
+	VerifyChain(ctx context.Context) (VerifyResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	auditService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:       server,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/audit"
+	c.server.GroupAudit.Get("/", c.server.Require(web.PermAuditRead), c.GetPage)
+
+	// полный маршрут получится "/internal/audit/verify"
+	c.server.GroupInternalAudit.Get("/verify", c.server.Require(web.PermAuditRead), c.VerifyChain)
+}
+
+// GetPage       godoc
+// @Description  Find audit events by page, newest first
+// @Summary		 get audit events by page
+// @Tags 		 audit
+// @Accept  	 json
+// @Produce 	 json
+// @Param   	 pageNumber 	query	int		false	"page number, default 1"
+// @Param   	 pageSize 		query   int 	false  	"page size, default 10"
+// @Param   	 actor 			query   string  false  	"subject of the caller"
+// @Param   	 action 		query   string  false  	"create, update, delete, assign, revoke"
+// @Param   	 entityType 	query   string  false  	"employee, role, employee_role"
+// @Param   	 entityId 		query   int  	false  	"entity id"
+// @Param   	 from 			query   string  false  	"RFC3339, inclusive"
+// @Param   	 to 			query   string  false  	"RFC3339, exclusive"
+// @Success 	 200  {object} 		audit.PageResponse	"Audit events page"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /audit/ 			[get]
+func (c *Controller) GetPage(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	request, err := parsePageRequest(ctx)
+	if err != nil {
+		c.logger.Error(
+			"Invalid parse audit page request values, error:",
+			zap.Error(err),
+			zap.String("url", ctx.OriginalURL()),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, fmt.Sprintf(invalidQueryParamsFmt, err.Error()))
+	}
+
+	response, err := c.auditService.GetPage(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the get Audit events by Page ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkPageResponse(ctx, response)
+}
+
+// VerifyChain   godoc
+// @Description  Walk the tamper-evident audit chain and report the first broken link.
+// @Description  Every call re-hashes the whole chain from the first record (cost grows with the audit log),
+// @Description  so only one verification runs at a time; a concurrent call gets 409
+// @Summary		 verify audit chain
+// @Tags 		 audit
+// @Produce 	 json
+// @Success 	 200  {object} 		audit.VerifyResponse	"Verification result, valid=false with brokenSeq on tampering"
+// @Failure      409  {object}  	http.Response			"Verification is already running"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /internal/audit/verify [get]
+func (c *Controller) VerifyChain(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.auditService.VerifyChain(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the verify Audit chain ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+	if !response.Valid {
+		c.logger.Error(
+			"audit chain is broken",
+			zap.Int64("broken_seq", response.BrokenSeq),
+			zap.String("reason", response.Reason),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// parsePageRequest - параметры страницы и фильтры из query
+func parsePageRequest(ctx *fiber.Ctx) (PageRequest, error) {
+	var request = PageRequest{
+		Actor:      ctx.Query("actor"),
+		Action:     ctx.Query("action"),
+		EntityType: ctx.Query("entityType"),
+	}
+	var err error
+	if request.PageNumber, err = parseInt(ctx, "pageNumber", "1"); err != nil {
+		return PageRequest{}, err
+	}
+	if request.PageSize, err = parseInt(ctx, "pageSize", "10"); err != nil {
+		return PageRequest{}, err
+	}
+	if request.EntityId, err = parseInt(ctx, "entityId", "0"); err != nil {
+		return PageRequest{}, err
+	}
+	if request.From, err = parseTime(ctx, "from"); err != nil {
+		return PageRequest{}, err
+	}
+	if request.To, err = parseTime(ctx, "to"); err != nil {
+		return PageRequest{}, err
+	}
+	return request, nil
+}
+
+func parseInt(ctx *fiber.Ctx, name string, defaultValue string) (int64, error) {
+	value, err := strconv.ParseInt(ctx.Query(name, defaultValue), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return value, nil
+}
+
+func parseTime(ctx *fiber.Ctx, name string) (*time.Time, error) {
+	var value = ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be RFC3339 time", name)
+	}
+	return &parsed, nil
+}
+
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.ConflictError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
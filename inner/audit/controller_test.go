@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAudit_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockAuditService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupAudit:            app.Group("/api/v1/audit"),
+		GroupInternalAudit:    app.Group("/internal/audit"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should pass filters to service", func(t *testing.T) {
+		from := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+		request := PageRequest{
+			PageNumber: 2, PageSize: 20, Actor: "42", Action: ActionDelete,
+			EntityType: EntityRole, EntityId: 7, From: &from, To: &to,
+		}
+		page := PageResponse{
+			Result:     []Response{{Id: 1, Actor: "42", Action: ActionDelete, EntityType: EntityRole, EntityId: 7}},
+			PageNumber: 2,
+			PageSize:   20,
+			Total:      21,
+		}
+		mockService.On("GetPage", appContext, request).Return(page, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET",
+			"/api/v1/audit/?pageNumber=2&pageSize=20&actor=42&action=delete&entityType=role&entityId=7"+
+				"&from=2025-07-01T00:00:00Z&to=2025-08-01T00:00:00Z", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data PageResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, int64(21), data.Total)
+		assert.Equal(t, int64(7), data.Result[0].EntityId)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on invalid time", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/audit/?from=yesterday", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Contains(t, body.Error, "from must be RFC3339 time")
+	})
+
+	t.Run("should return 400 on validation error", func(t *testing.T) {
+		mockService.On("GetPage", appContext, PageRequest{PageNumber: 1, PageSize: 500}).
+			Return(PageResponse{}, domain.RequestValidationError{Message: "Field PageSize must not exceed 155"}).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/audit/?pageSize=500", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("should return 500 on service error", func(t *testing.T) {
+		mockService.On("GetPage", appContext, PageRequest{PageNumber: 1, PageSize: 10}).
+			Return(PageResponse{}, errors.New("db is down")).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/audit/", nil))
+		require.NoError(t, err)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, internalServerError, body.Error)
+	})
+
+	t.Run("should return chain verification result", func(t *testing.T) {
+		verify := VerifyResponse{Valid: false, Checked: 4, LastSeq: 4, BrokenSeq: 5, Reason: "content hash mismatch"}
+		mockService.On("VerifyChain", appContext).Return(verify, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/internal/audit/verify", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data VerifyResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, verify, data)
+	})
+
+	t.Run("should return 409 while verification is running", func(t *testing.T) {
+		mockService.On("VerifyChain", appContext).
+			Return(VerifyResponse{}, domain.ConflictError{Message: "audit chain verification is already running"}).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/internal/audit/verify", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	})
+}
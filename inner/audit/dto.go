@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Действия, которые попадают в журнал аудита
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionAssign  = "assign"
+	ActionRevoke  = "revoke"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	// ActionStatusChange - смена состояния жизненного цикла сотрудника
+	ActionStatusChange = "status_change"
+	// ActionMove - перевод сотрудника в другое подразделение
+	ActionMove = "move"
+)
+
+// Типы сущностей журнала аудита
+const (
+	EntityEmployee        = "employee"
+	EntityRole            = "role"
+	EntityEmployeeRole    = "employee_role"
+	EntityOrgUnit         = "org_unit"
+	EntityGroup           = "group"
+	EntityGroupMember     = "group_member"
+	EntityGroupRole       = "group_role"
+	EntityRoleInheritance = "role_inheritance"
+	EntityAccessRequest   = "access_request"
+	EntitySodRule         = "sod_rule"
+	// кампании ресертификации и проверяемые в них назначения
+	EntityCertificationCampaign = "certification_campaign"
+	EntityCertificationItem     = "certification_item"
+	// целевые системы исходящего провижининга
+	EntityProvisioningTarget = "provisioning_target"
+	// подписки на доменные события по HTTP
+	EntityWebhook = "webhook"
+)
+
+// Event - изменение, которое записывается в журнал в транзакции самого изменения.
+// Before и After - снимки сущности (сериализуются в JSON), nil для create/delete соответственно
+type Event struct {
+	Action     string
+	EntityType string
+	EntityId   int64
+	Before     any
+	After      any
+}
+
+// EmployeeRoleSnapshot - снимок назначения роли сотруднику (entity_id события - id сотрудника)
+type EmployeeRoleSnapshot struct {
+	EmployeeId int64      `json:"employeeId"`
+	RoleId     int64      `json:"roleId"`
+	ValidFrom  *time.Time `json:"validFrom,omitempty"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"` // nil - бессрочное назначение
+}
+
+type Entity struct {
+	Id         int64     `db:"id"`
+	OccurredAt time.Time `db:"occurred_at"`
+	Actor      string    `db:"actor"`
+	ActorType  string    `db:"actor_type"`
+	Action     string    `db:"action"`
+	EntityType string    `db:"entity_type"`
+	EntityId   int64     `db:"entity_id"`
+	BeforeData []byte    `db:"before_data"`
+	AfterData  []byte    `db:"after_data"`
+	RequestId  string    `db:"request_id"`
+}
+
+// Response model info
+// @Description Audit event
+// @Description with actor, action, entity, before/after snapshots and request id
+type Response struct {
+	Id         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Actor      string          `json:"actor"`
+	ActorType  string          `json:"actorType"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entityType"`
+	EntityId   int64           `json:"entityId"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+	RequestId  string          `json:"requestId"`
+}
+
+func (e *Entity) ToResponse() Response {
+	return Response{
+		Id:         e.Id,
+		OccurredAt: e.OccurredAt,
+		Actor:      e.Actor,
+		ActorType:  e.ActorType,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityId:   e.EntityId,
+		Before:     toRawJson(e.BeforeData),
+		After:      toRawJson(e.AfterData),
+		RequestId:  e.RequestId,
+	}
+}
+
+// toRawJson - снимок как есть, NULL из БД отдаётся как JSON null
+func toRawJson(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return json.RawMessage("null")
+	}
+	return data
+}
+
+// PageResponse model info
+// @Description Audit events page
+// @Description with result, page_size, page_number, total
+type PageResponse struct {
+	Result     []Response `json:"result"`
+	PageSize   int64      `json:"page_size"`
+	PageNumber int64      `json:"page_number"`
+	Total      int64      `json:"total"`
+}
+
+// PageRequest - страница журнала с необязательными фильтрами
+type PageRequest struct {
+	PageSize   int64      `validate:"required,min=1,max=155"`
+	PageNumber int64      `validate:"required,min=1,max=1000"`
+	Actor      string     `validate:"omitempty,max=155,no_sql_injection"`
+	Action     string     `validate:"omitempty,max=55,no_sql_injection"`
+	EntityType string     `validate:"omitempty,max=55,no_sql_injection"`
+	EntityId   int64      `validate:"omitempty,min=1"`
+	From       *time.Time `validate:"omitempty"`
+	To         *time.Time `validate:"omitempty"`
+}
+
+// Filter - условия выборки журнала для репозитория
+type Filter struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityId   int64
+	From       *time.Time
+	To         *time.Time
+	Limit      int64
+	Offset     int64
+}
+
+func (req *PageRequest) ToFilter() Filter {
+	return Filter{
+		Actor:      req.Actor,
+		Action:     req.Action,
+		EntityType: req.EntityType,
+		EntityId:   req.EntityId,
+		From:       req.From,
+		To:         req.To,
+		Limit:      req.PageSize,
+		Offset:     (req.PageNumber - 1) * req.PageSize,
+	}
+}
+
+// ChainEntity - запись неизменяемой цепочки аудита (таблица audit_chain)
+type ChainEntity struct {
+	Seq        int64          `db:"seq"`
+	OccurredAt time.Time      `db:"occurred_at"`
+	Actor      string         `db:"actor"`
+	ActorType  string         `db:"actor_type"`
+	Action     string         `db:"action"`
+	EntityType string         `db:"entity_type"`
+	EntityId   int64          `db:"entity_id"`
+	BeforeData sql.NullString `db:"before_data"`
+	AfterData  sql.NullString `db:"after_data"`
+	RequestId  string         `db:"request_id"`
+	PrevHash   string         `db:"prev_hash"`
+	Hash       string         `db:"hash"`
+}
+
+// VerifyResponse model info
+// @Description Result of audit chain verification
+// @Description with number of checked records and the first broken link, if any
+type VerifyResponse struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	LastSeq  int64  `json:"lastSeq"`
+	LastHash string `json:"lastHash"`
+	// BrokenSeq - номер первой записи, не прошедшей проверку (0, если цепочка цела)
+	BrokenSeq int64  `json:"brokenSeq,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
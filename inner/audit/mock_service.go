@@ -0,0 +1,20 @@
+package audit
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) GetPage(ctx context.Context, request PageRequest) (PageResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(PageResponse), args.Error(1)
+}
+
+func (m *MockAuditService) VerifyChain(ctx context.Context) (VerifyResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(VerifyResponse), args.Error(1)
+}
@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/common"
+	"strings"
+	"time"
+)
+
+// chainLockKey - ключ pg_advisory_xact_lock, сериализующий запись в audit_chain
+const chainLockKey int64 = 0x61756469745f6368 // "audit_ch"
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// InsertEventTx - записать событие аудита в транзакции изменения: в журнал audit_events
+// и в неизменяемую цепочку audit_chain. Исполнитель и request_id берутся из контекста
+// запроса (common.CallerFromContext)
+func InsertEventTx(ctx context.Context, tx *sqlx.Tx, event Event) error {
+	before, err := snapshot(event.Before)
+	if err != nil {
+		return fmt.Errorf("error marshal audit before snapshot: %w", err)
+	}
+	after, err := snapshot(event.After)
+	if err != nil {
+		return fmt.Errorf("error marshal audit after snapshot: %w", err)
+	}
+
+	var caller = common.CallerFromContext(ctx)
+	var occurredAt = time.Now().UTC().Truncate(time.Microsecond) // точность TIMESTAMPTZ, иначе хеш не воспроизвести
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO audit_events
+		(occurred_at, actor, actor_type, action, entity_type, entity_id, before_data, after_data, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		occurredAt, caller.Subject, caller.SubjectType, event.Action, event.EntityType, event.EntityId,
+		before, after, caller.RequestId,
+	)
+	if err != nil {
+		return fmt.Errorf("error insert audit event %s %s %d: %w", event.Action, event.EntityType, event.EntityId, err)
+	}
+
+	err = appendChainTx(ctx, tx, ChainEntity{
+		OccurredAt: occurredAt,
+		Actor:      caller.Subject,
+		ActorType:  caller.SubjectType,
+		Action:     event.Action,
+		EntityType: event.EntityType,
+		EntityId:   event.EntityId,
+		BeforeData: before,
+		AfterData:  after,
+		RequestId:  caller.RequestId,
+	})
+	if err != nil {
+		return fmt.Errorf("error append audit chain %s %s %d: %w", event.Action, event.EntityType, event.EntityId, err)
+	}
+	return nil
+}
+
+// appendChainTx - добавить запись в конец цепочки. Запись в цепочку сериализуется
+// транзакционной advisory-блокировкой: seq и prev_hash берутся у последней записи
+func appendChainTx(ctx context.Context, tx *sqlx.Tx, entity ChainEntity) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", chainLockKey)
+	if err != nil {
+		return err
+	}
+
+	var last ChainEntity
+	err = tx.GetContext(ctx, &last, "SELECT seq, hash FROM audit_chain ORDER BY seq DESC LIMIT 1")
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		entity.Seq = 1
+		entity.PrevHash = GenesisHash
+	case err != nil:
+		return err
+	default:
+		entity.Seq = last.Seq + 1
+		entity.PrevHash = last.Hash
+	}
+	entity.Hash = entity.ComputeHash()
+
+	_, err = tx.NamedExecContext(
+		ctx,
+		`INSERT INTO audit_chain
+		(seq, occurred_at, actor, actor_type, action, entity_type, entity_id, before_data, after_data, request_id, prev_hash, hash)
+		VALUES (:seq, :occurred_at, :actor, :actor_type, :action, :entity_type, :entity_id, :before_data, :after_data,
+		:request_id, :prev_hash, :hash)`,
+		entity,
+	)
+	return err
+}
+
+// snapshot - JSON снимка сущности, nil -> NULL
+func snapshot(value any) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// FindPage - страница журнала по фильтру, новые события первыми. Возвращает также общее число событий
+func (r *Repository) FindPage(ctx context.Context, filter Filter) ([]Entity, int64, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" {
+		addCondition("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.EntityType != "" {
+		addCondition("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityId > 0 {
+		addCondition("entity_id = $%d", filter.EntityId)
+	}
+	if filter.From != nil {
+		addCondition("occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("occurred_at < $%d", *filter.To)
+	}
+
+	var where string
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM audit_events"+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT * FROM audit_events%s ORDER BY occurred_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		where, len(args)+1, len(args)+2,
+	)
+	var events []Entity
+	err = r.db.SelectContext(ctx, &events, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// FindChainAfter - следующие limit записей цепочки после seq, по возрастанию seq
+func (r *Repository) FindChainAfter(ctx context.Context, seq int64, limit int64) (entities []ChainEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&entities,
+		"SELECT * FROM audit_chain WHERE seq > $1 ORDER BY seq LIMIT $2",
+		seq, limit,
+	)
+
+	return entities, err
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"idm/inner/domain"
+	"sync/atomic"
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+	verifying atomic.Bool // идёт проверка цепочки - одновременно выполняется только одна
+}
+
+type Repo interface {
+	FindPage(ctx context.Context, filter Filter) ([]Entity, int64, error)
+	FindChainAfter(ctx context.Context, seq int64, limit int64) ([]ChainEntity, error)
+}
+
+// verifyBatchSize - сколько записей цепочки читается за один запрос при проверке
+const verifyBatchSize = 1000
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+	}
+}
+
+// GetPage - страница журнала аудита с фильтрами по исполнителю, действию, сущности и периоду
+func (svc *Service) GetPage(ctx context.Context, request PageRequest) (PageResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return PageResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.From != nil && request.To != nil && !request.From.Before(*request.To) {
+		return PageResponse{}, domain.RequestValidationError{Message: "from must be before to"}
+	}
+
+	entities, total, err := svc.repo.FindPage(ctx, request.ToFilter())
+	if err != nil {
+		return PageResponse{}, fmt.Errorf("error finding audit events: %w", err)
+	}
+
+	responses := make([]Response, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return PageResponse{
+		Result:     responses,
+		PageSize:   request.PageSize,
+		PageNumber: request.PageNumber,
+		Total:      total,
+	}, nil
+}
+
+// VerifyChain - пройти цепочку audit_chain от первой записи и найти первый разрыв:
+// пропуск seq, несовпадение prev_hash с хешем предыдущей записи или изменённое содержимое.
+// Проверка перечитывает всю цепочку, поэтому параллельный вызов не ждёт, а получает ConflictError
+func (svc *Service) VerifyChain(ctx context.Context) (VerifyResponse, error) {
+	if !svc.verifying.CompareAndSwap(false, true) {
+		return VerifyResponse{}, domain.ConflictError{Message: "audit chain verification is already running"}
+	}
+	defer svc.verifying.Store(false)
+
+	var verifier = newChainVerifier()
+	var lastSeq int64
+	for {
+		entities, err := svc.repo.FindChainAfter(ctx, lastSeq, verifyBatchSize)
+		if err != nil {
+			return VerifyResponse{}, fmt.Errorf("error reading audit chain after seq %d: %w", lastSeq, err)
+		}
+		for _, entity := range entities {
+			if !verifier.check(entity) {
+				return verifier.result, nil
+			}
+			lastSeq = entity.Seq
+		}
+		if len(entities) < verifyBatchSize {
+			return verifier.result, nil
+		}
+	}
+}
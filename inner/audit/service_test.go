@@ -0,0 +1,232 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"testing"
+	"time"
+)
+
+// Объявляем структуру мок-репозитория
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindPage(ctx context.Context, filter Filter) ([]Entity, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Entity), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) FindChainAfter(ctx context.Context, seq int64, limit int64) ([]ChainEntity, error) {
+	args := m.Called(ctx, seq, limit)
+	return args.Get(0).([]ChainEntity), args.Error(1)
+}
+
+// buildChain - корректная цепочка из n записей
+func buildChain(n int, now time.Time) []ChainEntity {
+	var chain []ChainEntity
+	var prevHash = GenesisHash
+	for i := 1; i <= n; i++ {
+		entity := ChainEntity{
+			Seq:        int64(i),
+			OccurredAt: now.Add(time.Duration(i) * time.Second),
+			Actor:      "42",
+			ActorType:  "employee",
+			Action:     ActionUpdate,
+			EntityType: EntityEmployee,
+			EntityId:   int64(i),
+			BeforeData: sql.NullString{String: `{"name":"old"}`, Valid: true},
+			AfterData:  sql.NullString{String: `{"name":"new"}`, Valid: true},
+			PrevHash:   prevHash,
+		}
+		entity.Hash = entity.ComputeHash()
+		prevHash = entity.Hash
+		chain = append(chain, entity)
+	}
+	return chain
+}
+
+func TestAuditService(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should return filtered page with offset", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := PageRequest{PageNumber: 3, PageSize: 5, Actor: "42", EntityType: EntityEmployee}
+		entities := []Entity{{
+			Id: 1, OccurredAt: now, Actor: "42", ActorType: "employee", Action: ActionUpdate,
+			EntityType: EntityEmployee, EntityId: 7,
+			BeforeData: []byte(`{"name":"old"}`), AfterData: []byte(`{"name":"new"}`), RequestId: "req-1",
+		}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindPage", appContext, Filter{Actor: "42", EntityType: EntityEmployee, Limit: 5, Offset: 10}).
+			Return(entities, int64(11), nil).Once()
+
+		got, err := service.GetPage(appContext, request)
+
+		a.Nil(err)
+		a.Equal(int64(11), got.Total)
+		a.Equal(int64(3), got.PageNumber)
+		a.Len(got.Result, 1)
+		a.JSONEq(`{"name":"old"}`, string(got.Result[0].Before))
+		a.Equal("req-1", got.Result[0].RequestId)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should render missing snapshot as null", func(t *testing.T) {
+		entity := Entity{Action: ActionCreate, AfterData: []byte(`{"id":1}`)}
+
+		got := entity.ToResponse()
+
+		a.Equal("null", string(got.Before))
+		a.Equal(`{"id":1}`, string(got.After))
+	})
+
+	t.Run("should return validation error when period is empty", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		from := now
+		to := now.Add(-time.Hour)
+		request := PageRequest{PageNumber: 1, PageSize: 10, From: &from, To: &to}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		_, err := service.GetPage(appContext, request)
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "FindPage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should wrap repository error", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := PageRequest{PageNumber: 1, PageSize: 10}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindPage", appContext, Filter{Limit: 10}).Return([]Entity(nil), int64(0), errors.New("db is down")).Once()
+
+		_, err := service.GetPage(appContext, request)
+
+		a.NotNil(err)
+		a.False(errors.As(err, &domain.RequestValidationError{}))
+	})
+
+	t.Run("should verify intact chain", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		chain := buildChain(3, now)
+
+		repo.On("FindChainAfter", appContext, int64(0), int64(verifyBatchSize)).Return(chain, nil).Once()
+
+		got, err := service.VerifyChain(appContext)
+
+		a.Nil(err)
+		a.True(got.Valid)
+		a.Equal(int64(3), got.Checked)
+		a.Equal(chain[2].Hash, got.LastHash)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should verify empty chain", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+
+		repo.On("FindChainAfter", appContext, int64(0), int64(verifyBatchSize)).Return([]ChainEntity{}, nil).Once()
+
+		got, err := service.VerifyChain(appContext)
+
+		a.Nil(err)
+		a.True(got.Valid)
+		a.Equal(GenesisHash, got.LastHash)
+	})
+
+	t.Run("should report edited content", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		chain := buildChain(3, now)
+		chain[1].AfterData = sql.NullString{String: `{"name":"forged"}`, Valid: true}
+
+		repo.On("FindChainAfter", appContext, int64(0), int64(verifyBatchSize)).Return(chain, nil).Once()
+
+		got, err := service.VerifyChain(appContext)
+
+		a.Nil(err)
+		a.False(got.Valid)
+		a.Equal(int64(2), got.BrokenSeq)
+		a.Equal(int64(1), got.Checked)
+		a.Equal("content hash mismatch", got.Reason)
+	})
+
+	t.Run("should report rehashed record by prev_hash of the next one", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		chain := buildChain(3, now)
+		chain[1].Actor = "system"
+		chain[1].Hash = chain[1].ComputeHash()
+
+		repo.On("FindChainAfter", appContext, int64(0), int64(verifyBatchSize)).Return(chain, nil).Once()
+
+		got, err := service.VerifyChain(appContext)
+
+		a.Nil(err)
+		a.False(got.Valid)
+		a.Equal(int64(3), got.BrokenSeq)
+		a.Contains(got.Reason, "prev_hash")
+	})
+
+	t.Run("should report deleted record as sequence gap", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		chain := buildChain(3, now)
+
+		repo.On("FindChainAfter", appContext, int64(0), int64(verifyBatchSize)).
+			Return([]ChainEntity{chain[0], chain[2]}, nil).Once()
+
+		got, err := service.VerifyChain(appContext)
+
+		a.Nil(err)
+		a.False(got.Valid)
+		a.Equal(int64(3), got.BrokenSeq)
+		a.Contains(got.Reason, "sequence gap")
+	})
+
+	t.Run("should reject concurrent verification", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		started, release := make(chan struct{}), make(chan struct{})
+
+		repo.On("FindChainAfter", appContext, int64(0), int64(verifyBatchSize)).
+			Run(func(mock.Arguments) {
+				close(started)
+				<-release
+			}).
+			Return([]ChainEntity{}, nil).Once()
+		var done = make(chan error)
+		go func() {
+			_, err := service.VerifyChain(appContext)
+			done <- err
+		}()
+		<-started
+
+		_, err := service.VerifyChain(appContext)
+		a.True(errors.As(err, &domain.ConflictError{}))
+		close(release)
+		a.Nil(<-done)
+
+		// после завершения первой проверки можно запустить следующую
+		repo.On("FindChainAfter", appContext, int64(0), int64(verifyBatchSize)).Return([]ChainEntity{}, nil).Once()
+		_, err = service.VerifyChain(appContext)
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,386 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server      *web.Server
+	authService Svc
+	logger      *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	IssueToken(ctx context.Context, request TokenRequest) (TokenResponse, error)
+	RevokeToken(ctx context.Context, request RevokeRequest) error
+	SetPassword(ctx context.Context, employeeId int64, request SetPasswordRequest) error
+	FindAllClients(ctx context.Context) ([]ClientResponse, error)
+	CreateClient(ctx context.Context, request CreateClientRequest) (CreateClientResponse, error)
+	SetClientRoles(ctx context.Context, request SetClientRolesRequest) (ClientResponse, error)
+	DeleteClient(ctx context.Context, clientId string) error
+	Jwks() JwksResponse
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	authService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:      server,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/auth"
+	c.server.GroupAuth.Post(web.AuthTokenPath, c.IssueToken)
+	c.server.GroupAuth.Post(web.AuthRevokePath, c.RevokeToken)
+	c.server.GroupAuth.Get("/clients", c.server.Require(web.PermClientsRead), c.FindAllClients)
+	c.server.GroupAuth.Post("/clients", c.server.Require(web.PermClientsWrite), c.CreateClient)
+	c.server.GroupAuth.Put("/clients/:clientId/roles", c.server.Require(web.PermClientsWrite), c.SetClientRoles)
+	c.server.GroupAuth.Delete("/clients/:clientId", c.server.Require(web.PermClientsWrite), c.DeleteClient)
+
+	// полный маршрут получится "/api/v1/employees/:id/password"
+	c.server.GroupEmployees.Put("/:id/password", c.server.Require(web.PermCredentialsWrite), c.SetPassword)
+
+	// полный маршрут получится "/.well-known/jwks.json"
+	c.server.GroupWellKnown.Get("/jwks.json", c.Jwks)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/auth" --//
+
+// IssueToken 	 godoc
+// @Description  Issue tokens by grant_type password (username is employee id), client_credentials or refresh_token
+// @Summary		 issue access token
+// @Tags 		 auth
+// @Accept 		 json,x-www-form-urlencoded
+// @Produce 	 json
+// @Param 		 request body 	auth.TokenRequest true "Token request"
+// @Success 	 200  {object}  auth.TokenResponse	"Issued tokens"
+// @Failure      400  {object}  http.Response		"Bad request"
+// @Failure      401  {object}  http.Response		"Invalid credentials"
+// @Failure      500  {object}  http.Response		"Bad request"
+// @Router 		 /auth/token 	[post]
+func (c *Controller) IssueToken(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request TokenRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an IssueToken ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.authService.IssueToken(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the issue Token ended with an error:",
+			zap.Error(err),
+			zap.String("grant_type", request.GrantType),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	// токены не должны кэшироваться (RFC 6749, 5.1)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return http.OkResponse(ctx, response)
+}
+
+// RevokeToken 	 godoc
+// @Description  Revoke refresh token together with its rotation chain
+// @Summary		 revoke refresh token
+// @Tags 		 auth
+// @Accept 		 json,x-www-form-urlencoded
+// @Produce 	 json
+// @Param 		 request body 	auth.RevokeRequest true "Revoke request"
+// @Success 	 200  {object}  http.Response	"Revoked"
+// @Failure      400  {object}  http.Response	"Bad request"
+// @Failure      500  {object}  http.Response	"Bad request"
+// @Router 		 /auth/revoke 	[post]
+func (c *Controller) RevokeToken(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request RevokeRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an RevokeToken ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	if err := c.authService.RevokeToken(appContext, request); err != nil {
+		c.logger.Error(
+			"When the revoke Token ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, nil)
+}
+
+// SetPassword 	 godoc
+// @Description  Set or change employee password used by grant_type=password
+// @Summary		 set employee password
+// @Tags 		 employee
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  						true  	"Employee ID"
+// @Param 		 request 	body 		auth.SetPasswordRequest 	true 	"New password"
+// @Success 	 200  {object}  http.Response	"Password set"
+// @Failure      400  {object}  http.Response	"Bad request"
+// @Failure      404  {object}  http.Response	"Not found"
+// @Failure      500  {object}  http.Response	"Bad request"
+// @Router 		 /employees/{id}/password 	[put]
+func (c *Controller) SetPassword(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	employeeID, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String("id", ctx.Params("id")),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request SetPasswordRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an SetPassword ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	if err := c.authService.SetPassword(appContext, employeeID, request); err != nil {
+		c.logger.Error(
+			"When the set Employee password ended with an error:",
+			zap.Error(err),
+			zap.Int64("employee_id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, nil)
+}
+
+// FindAllClients godoc
+// @Description  Find all clients registered for grant_type=client_credentials
+// @Summary		 get all clients
+// @Tags 		 auth
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		auth.ClientResponse	"Client response"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /auth/clients 		[get]
+func (c *Controller) FindAllClients(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.authService.FindAllClients(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Clients ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// CreateClient godoc
+// @Description  Register a client, the generated secret is returned only once
+// @Summary		 create a new client
+// @Tags 		 auth
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	auth.CreateClientRequest true "Client creation details"
+// @Success 	 201  {object}  auth.CreateClientResponse	"Client response"
+// @Failure      400  {object}  http.Response				"Bad request"
+// @Failure      409  {object}  http.Response				"Conflict"
+// @Failure      500  {object}  http.Response				"Bad request"
+// @Router 		 /auth/clients 	[post]
+func (c *Controller) CreateClient(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateClientRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an CreateClient ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.authService.CreateClient(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Client ended with an error:",
+			zap.Error(err),
+			zap.String("client_id", request.ClientId),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// SetClientRoles godoc
+// @Description  Replace roles of the client, the client gets the permissions of these roles
+// @Summary		 set client roles
+// @Tags 		 auth
+// @Accept 		 json
+// @Produce 	 json
+// @Param        clientId   path      	string  true  	"Client ID"
+// @Param 		 request body 	auth.SetClientRolesRequest true "Client roles"
+// @Success 	 200  {object}  auth.ClientResponse	"Client response"
+// @Failure      400  {object}  http.Response		"Bad request"
+// @Failure      404  {object}  http.Response		"Not found"
+// @Failure      500  {object}  http.Response		"Bad request"
+// @Router 		 /auth/clients/{clientId}/roles 	[put]
+func (c *Controller) SetClientRoles(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request SetClientRolesRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an SetClientRoles ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+	request.ClientId = ctx.Params("clientId")
+
+	response, err := c.authService.SetClientRoles(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the set Client roles ended with an error:",
+			zap.Error(err),
+			zap.String("client_id", request.ClientId),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// DeleteClient godoc
+// @Description  Delete client by client id
+// @Summary		 delete client
+// @Tags 		 auth
+// @Accept 		 json
+// @Produce 	 json
+// @Param        clientId   path      	string  true  	"Client ID"
+// @Success 	 200  {object}  http.Response	"Deleted"
+// @Failure      400  {object}  http.Response	"Bad request"
+// @Failure      404  {object}  http.Response	"Not found"
+// @Failure      500  {object}  http.Response	"Bad request"
+// @Router 		 /auth/clients/{clientId} 	[delete]
+func (c *Controller) DeleteClient(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	clientId := ctx.Params("clientId")
+	if err := c.authService.DeleteClient(appContext, clientId); err != nil {
+		c.logger.Error(
+			"When the delete Client ended with an error:",
+			zap.Error(err),
+			zap.String("client_id", clientId),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, nil)
+}
+
+// Jwks 	 	 godoc
+// @Description  Public keys for token signature verification (RFC 7517), empty when tokens are signed with HS256
+// @Summary		 get JSON Web Key Set
+// @Tags 		 auth
+// @Produce 	 json
+// @Success 	 200  {object}  auth.JwksResponse	"JSON Web Key Set"
+// @Router 		 /.well-known/jwks.json 	[get]
+func (c *Controller) Jwks(ctx *fiber.Ctx) error {
+	// формат ответа фиксирован RFC 7517, поэтому без обёртки http.Response
+	return ctx.Status(fiber.StatusOK).JSON(c.authService.Jwks())
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.UnauthorizedError{}):
+		return http.ErrResponse(ctx, fiber.StatusUnauthorized, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAuth_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockAuthService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupEmployees:        app.Group("/api/v1/employees"),
+		GroupAuth:             app.Group("/api/v1/auth"),
+		GroupWellKnown:        app.Group("/.well-known"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should issue token from form body", func(t *testing.T) {
+		request := TokenRequest{GrantType: GrantTypePassword, Username: "7", Password: "secret-password"}
+		tokens := TokenResponse{AccessToken: "access", TokenType: TokenTypeBearer, ExpiresIn: 900, RefreshToken: "refresh"}
+		mockService.On("IssueToken", appContext, request).Return(tokens, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/auth/token",
+			strings.NewReader("grant_type=password&username=7&password=secret-password"))
+		req.Header.Set("Content-Type", fiber.MIMEApplicationForm)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "no-store", resp.Header.Get(fiber.HeaderCacheControl))
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data TokenResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, tokens, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 401 for invalid credentials", func(t *testing.T) {
+		request := TokenRequest{GrantType: GrantTypeClientCredentials, ClientId: "billing", ClientSecret: "wrong"}
+		mockService.On("IssueToken", appContext, request).
+			Return(TokenResponse{}, domain.UnauthorizedError{Message: "invalid credentials"}).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/auth/token",
+			strings.NewReader(`{"grant_type":"client_credentials","client_id":"billing","client_secret":"wrong"}`))
+		req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.False(t, body.Success)
+		assert.Equal(t, "invalid credentials", body.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should revoke refresh token", func(t *testing.T) {
+		mockService.On("RevokeToken", appContext, RevokeRequest{RefreshToken: "refresh"}).Return(nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/auth/revoke", strings.NewReader(`{"refresh_token":"refresh"}`))
+		req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should set employee password", func(t *testing.T) {
+		mockService.On("SetPassword", appContext, int64(7), SetPasswordRequest{Password: "new-password"}).Return(nil).Once()
+
+		req := httptest.NewRequest("PUT", "/api/v1/employees/7/password", strings.NewReader(`{"password":"new-password"}`))
+		req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when set password of missing employee", func(t *testing.T) {
+		mockService.On("SetPassword", appContext, int64(8), SetPasswordRequest{Password: "new-password"}).
+			Return(domain.NotFoundError{Message: "employee with id 8 not found"}).Once()
+
+		req := httptest.NewRequest("PUT", "/api/v1/employees/8/password", strings.NewReader(`{"password":"new-password"}`))
+		req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should create client", func(t *testing.T) {
+		request := CreateClientRequest{ClientId: "billing", Name: "Billing"}
+		created := CreateClientResponse{ClientResponse: ClientResponse{ClientId: "billing", Name: "Billing"}, ClientSecret: "generated"}
+		mockService.On("CreateClient", appContext, request).Return(created, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/auth/clients", strings.NewReader(`{"clientId":"billing","name":"Billing"}`))
+		req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data CreateClientResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, "generated", data.ClientSecret)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when delete missing client", func(t *testing.T) {
+		mockService.On("DeleteClient", appContext, "billing").
+			Return(domain.NotFoundError{Message: "client billing not found"}).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/auth/clients/billing", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return JWKS without response envelope", func(t *testing.T) {
+		jwks := JwksResponse{Keys: []Jwk{{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: "kid", N: "n", E: "AQAB"}}}
+		mockService.On("Jwks").Return(jwks).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var data JwksResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+		assert.Equal(t, jwks, data)
+		mockService.AssertExpectations(t)
+	})
+}
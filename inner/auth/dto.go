@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+	"idm/inner/web/middleware"
+	"time"
+)
+
+const (
+	GrantTypePassword          = "password"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+
+	SubjectTypeEmployee = middleware.SubjectTypeEmployee // токен выдан сотруднику (sub - id сотрудника)
+	SubjectTypeClient   = middleware.SubjectTypeClient   // токен выдан клиенту (sub - client_id)
+
+	TokenTypeBearer = "Bearer"
+)
+
+// CredentialEntity - учётные данные сотрудника
+type CredentialEntity struct {
+	EmployeeId   int64     `db:"employee_id"`
+	PasswordHash string    `db:"password_hash"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// ClientEntity - клиент для grant_type=client_credentials
+type ClientEntity struct {
+	Id         int64     `db:"id"`
+	ClientId   string    `db:"client_id"`
+	Name       string    `db:"name"`
+	SecretHash string    `db:"secret_hash"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+	// RoleIds - роли клиента: его разрешения - разрешения этих ролей
+	RoleIds pq.Int64Array `db:"role_ids"`
+}
+
+// RefreshTokenEntity - выданный refresh-токен (хранится только хеш)
+type RefreshTokenEntity struct {
+	Id         int64        `db:"id"`
+	TokenHash  string       `db:"token_hash"`
+	FamilyId   string       `db:"family_id"`
+	EmployeeId int64        `db:"employee_id"`
+	ExpiresAt  time.Time    `db:"expires_at"`
+	RevokedAt  sql.NullTime `db:"revoked_at"`
+	CreatedAt  time.Time    `db:"created_at"`
+}
+
+// ClientResponse model info
+// @Description Client information
+// @Description with client id, name, role ids, createAt, updateAt
+type ClientResponse struct {
+	ClientId string    `json:"clientId"`
+	Name     string    `json:"name"`
+	RoleIds  []int64   `json:"roleIds"`
+	CreateAt time.Time `json:"createAt"`
+	UpdateAt time.Time `json:"updateAt"`
+}
+
+func (e *ClientEntity) ToResponse() ClientResponse {
+	var roleIds = []int64(e.RoleIds)
+	if roleIds == nil {
+		roleIds = []int64{}
+	}
+	return ClientResponse{
+		ClientId: e.ClientId,
+		Name:     e.Name,
+		RoleIds:  roleIds,
+		CreateAt: e.CreatedAt,
+		UpdateAt: e.UpdatedAt,
+	}
+}
+
+// CreateClientResponse model info
+// @Description Created client information
+// @Description with the generated client secret which is shown only once
+type CreateClientResponse struct {
+	ClientResponse
+	ClientSecret string `json:"clientSecret"`
+}
+
+// TokenRequest model info
+// @Description OAuth2-style token request (form or json)
+// @Description username is the employee id for grant_type=password
+type TokenRequest struct {
+	GrantType    string `json:"grant_type" form:"grant_type" validate:"required,oneof=password client_credentials refresh_token"`
+	Username     string `json:"username" form:"username" validate:"required_if=GrantType password,max=155"`
+	Password     string `json:"password" form:"password" validate:"required_if=GrantType password,max=72"`
+	ClientId     string `json:"client_id" form:"client_id" validate:"required_if=GrantType client_credentials,max=64"`
+	ClientSecret string `json:"client_secret" form:"client_secret" validate:"required_if=GrantType client_credentials,max=255"`
+	RefreshToken string `json:"refresh_token" form:"refresh_token" validate:"required_if=GrantType refresh_token,max=255"`
+}
+
+// TokenResponse model info
+// @Description Issued tokens
+// @Description refresh_token is present only for grant_type=password and refresh_token
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// RevokeRequest model info
+// @Description Refresh token revocation request (form or json)
+type RevokeRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" validate:"required,max=255"`
+}
+
+// SetPasswordRequest model info
+// @Description Employee password
+type SetPasswordRequest struct {
+	EmployeeID int64  `json:"-" validate:"required,min=1"`
+	Password   string `json:"password" validate:"required,min=8,max=72"` // bcrypt учитывает не более 72 байт
+}
+
+// CreateClientRequest model info
+// @Description Client creation details
+// @Description roleIds grant the client the permissions of these roles
+type CreateClientRequest struct {
+	ClientId string  `json:"clientId" validate:"required,min=3,max=64,no_sql_injection"`
+	Name     string  `json:"name" validate:"max=155,no_sql_injection"`
+	RoleIds  []int64 `json:"roleIds" validate:"max=100,dive,min=1"`
+}
+
+// SetClientRolesRequest model info
+// @Description Roles of the client, replacing the current ones (empty - no permissions)
+type SetClientRolesRequest struct {
+	ClientId string  `json:"-" validate:"required,min=3,max=64"`
+	RoleIds  []int64 `json:"roleIds" validate:"max=100,dive,min=1"`
+}
+
+// DeleteClientRequest - DTO для валидации client_id
+type DeleteClientRequest struct {
+	ClientId string `validate:"required,min=3,max=64"`
+}
+
+// Jwk model info
+// @Description JSON Web Key (RFC 7517) of the RS256 signing key
+type Jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JwksResponse model info
+// @Description JSON Web Key Set with public token signing keys
+type JwksResponse struct {
+	Keys []Jwk `json:"keys"`
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) IssueToken(ctx context.Context, request TokenRequest) (TokenResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(TokenResponse), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeToken(ctx context.Context, request RevokeRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) SetPassword(ctx context.Context, employeeId int64, request SetPasswordRequest) error {
+	args := m.Called(ctx, employeeId, request)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) FindAllClients(ctx context.Context) ([]ClientResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ClientResponse), args.Error(1)
+}
+
+func (m *MockAuthService) CreateClient(ctx context.Context, request CreateClientRequest) (CreateClientResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(CreateClientResponse), args.Error(1)
+}
+
+func (m *MockAuthService) SetClientRoles(ctx context.Context, request SetClientRolesRequest) (ClientResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(ClientResponse), args.Error(1)
+}
+
+func (m *MockAuthService) DeleteClient(ctx context.Context, clientId string) error {
+	args := m.Called(ctx, clientId)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) Jwks() JwksResponse {
+	args := m.Called()
+	return args.Get(0).(JwksResponse)
+}
@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+// clientColumns - колонки клиента вместе с id его ролей
+const clientColumns = `id, client_id, name, secret_hash, created_at, updated_at,
+	ARRAY(SELECT cr.role_id FROM oauth_client_roles cr WHERE cr.client_id = oauth_clients.id ORDER BY cr.role_id) AS role_ids`
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// ExistsEmployeeById - проверить наличие сотрудника (мягко удалённые не учитываются)
+func (r *Repository) ExistsEmployeeById(ctx context.Context, employeeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL)",
+		employeeId,
+	)
+
+	return isExists, err
+}
+
+// FindCredentialByEmployeeId - найти учётные данные сотрудника. Входить могут только активные
+// (status = active) и не удалённые сотрудники, для остальных учётные данные не находятся
+func (r *Repository) FindCredentialByEmployeeId(ctx context.Context, employeeId int64) (entity CredentialEntity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&entity,
+		`SELECT c.employee_id, c.password_hash, c.created_at, c.updated_at
+		FROM employee_credentials c
+		JOIN employees e ON e.id = c.employee_id
+		WHERE c.employee_id = $1 AND e.deleted_at IS NULL AND e.status = 'active'`,
+		employeeId,
+	)
+
+	return entity, err
+}
+
+// UpsertCredential - установить (или сменить) пароль сотрудника
+func (r *Repository) UpsertCredential(ctx context.Context, employeeId int64, passwordHash string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO employee_credentials (employee_id, password_hash, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (employee_id) DO UPDATE SET password_hash = EXCLUDED.password_hash, updated_at = EXCLUDED.updated_at`,
+		employeeId, passwordHash, time.Now(),
+	)
+
+	return err
+}
+
+// FindAllClients - найти всех клиентов
+func (r *Repository) FindAllClients(ctx context.Context) (clients []ClientEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&clients,
+		"SELECT "+clientColumns+" FROM oauth_clients ORDER BY id",
+	)
+
+	return clients, err
+}
+
+// FindClientByClientId - найти клиента по client_id
+func (r *Repository) FindClientByClientId(ctx context.Context, clientId string) (entity ClientEntity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT "+clientColumns+" FROM oauth_clients WHERE client_id = $1",
+		clientId,
+	)
+
+	return entity, err
+}
+
+// ExistsClientByClientId - проверить наличие клиента
+func (r *Repository) ExistsClientByClientId(ctx context.Context, clientId string) (isExists bool, err error) {
+	err = r.db.GetContext(ctx, &isExists, "SELECT EXISTS(SELECT 1 FROM oauth_clients WHERE client_id = $1)", clientId)
+
+	return isExists, err
+}
+
+// CreateClient - добавить нового клиента вместе с его ролями entity.RoleIds (без повторов)
+func (r *Repository) CreateClient(ctx context.Context, entity *ClientEntity) (result ClientEntity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&result,
+		`WITH created AS (
+			INSERT INTO oauth_clients (client_id, name, secret_hash, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $4)
+			RETURNING id, client_id, name, secret_hash, created_at, updated_at
+		), granted AS (
+			INSERT INTO oauth_client_roles (client_id, role_id, created_at)
+			SELECT created.id, role_id, $4 FROM created, unnest($5::bigint[]) AS role_id
+		)
+		SELECT created.*, $5::bigint[] AS role_ids FROM created`,
+		entity.ClientId, entity.Name, entity.SecretHash, time.Now(), pq.Int64Array(entity.RoleIds),
+	)
+
+	return result, err
+}
+
+// ReplaceClientRoles - в одной транзакции заменить роли клиента на roleIds (без повторов).
+// Возвращает sql.ErrNoRows, если клиента нет
+func (r *Repository) ReplaceClientRoles(ctx context.Context, clientId string, roleIds []int64) (result ClientEntity, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ClientEntity{}, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var now = time.Now()
+	err = tx.GetContext(
+		ctx,
+		&result,
+		`UPDATE oauth_clients SET updated_at = $2 WHERE client_id = $1
+		RETURNING id, client_id, name, secret_hash, created_at, updated_at`,
+		clientId, now,
+	)
+	if err != nil {
+		return ClientEntity{}, err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM oauth_client_roles WHERE client_id = $1", result.Id); err != nil {
+		return ClientEntity{}, err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO oauth_client_roles (client_id, role_id, created_at)
+		SELECT $1, role_id, $3 FROM unnest($2::bigint[]) AS role_id`,
+		result.Id, pq.Int64Array(roleIds), now,
+	)
+	if err != nil {
+		return ClientEntity{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return ClientEntity{}, fmt.Errorf("error commit transaction: %w", err)
+	}
+	result.RoleIds = roleIds
+	return result, nil
+}
+
+// FindMissingRoleIds - id из roleIds, для которых нет неудалённой роли
+func (r *Repository) FindMissingRoleIds(ctx context.Context, roleIds []int64) (missing []int64, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&missing,
+		`SELECT u.id FROM unnest($1::bigint[]) AS u(id)
+		WHERE NOT EXISTS (SELECT 1 FROM roles r WHERE r.id = u.id AND r.deleted_at IS NULL)
+		ORDER BY u.id`,
+		pq.Int64Array(roleIds),
+	)
+
+	return missing, err
+}
+
+// DeleteClientByClientId - удалить клиента, возвращает false, если клиента не было
+func (r *Repository) DeleteClientByClientId(ctx context.Context, clientId string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM oauth_clients WHERE client_id = $1", clientId)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+
+	return rows > 0, err
+}
+
+// CreateRefreshToken - сохранить выданный refresh-токен
+func (r *Repository) CreateRefreshToken(ctx context.Context, entity *RefreshTokenEntity) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO refresh_tokens (token_hash, family_id, employee_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		entity.TokenHash, entity.FamilyId, entity.EmployeeId, entity.ExpiresAt, time.Now(),
+	)
+
+	return err
+}
+
+// FindRefreshTokenByHash - найти refresh-токен по хешу. Токены удалённых и неактивных
+// (приостановленных, уволенных) сотрудников не находятся
+func (r *Repository) FindRefreshTokenByHash(ctx context.Context, tokenHash string) (entity RefreshTokenEntity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&entity,
+		`SELECT t.id, t.token_hash, t.family_id, t.employee_id, t.expires_at, t.revoked_at, t.created_at
+		FROM refresh_tokens t
+		JOIN employees e ON e.id = t.employee_id
+		WHERE t.token_hash = $1 AND e.deleted_at IS NULL AND e.status = 'active'`,
+		tokenHash,
+	)
+
+	return entity, err
+}
+
+// RotateRefreshToken - в одной транзакции отзывает использованный токен и сохраняет новый.
+// Возвращает false, если старый токен уже был отозван (параллельное повторное использование)
+func (r *Repository) RotateRefreshToken(ctx context.Context, oldId int64, entity *RefreshTokenEntity) (rotated bool, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil || !rotated {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var now = time.Now()
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE refresh_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL",
+		oldId, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO refresh_tokens (token_hash, family_id, employee_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		entity.TokenHash, entity.FamilyId, entity.EmployeeId, entity.ExpiresAt, now,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// RevokeRefreshTokenFamily - отозвать все действующие токены цепочки ротации
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL",
+		familyId, time.Now(),
+	)
+
+	return err
+}
@@ -0,0 +1,366 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"idm/inner/domain"
+	"slices"
+	"strconv"
+)
+
+const (
+	invalidCredentials  = "invalid credentials"
+	invalidRefreshToken = "invalid refresh token"
+	clientSecretBytes   = 32
+	// dummyHash - сравнение с ним выравнивает время ответа для несуществующих пользователей и клиентов
+	dummyHash = "$2a$10$FsBRm1NN718ISS5cfM2MH.23yjMpPAxZ3rLHYEiYC4EjxDa3us.nG"
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+	issuer    *TokenIssuer
+}
+
+type Repo interface {
+	ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error)
+	FindCredentialByEmployeeId(ctx context.Context, employeeId int64) (CredentialEntity, error)
+	UpsertCredential(ctx context.Context, employeeId int64, passwordHash string) error
+	FindAllClients(ctx context.Context) ([]ClientEntity, error)
+	FindClientByClientId(ctx context.Context, clientId string) (ClientEntity, error)
+	ExistsClientByClientId(ctx context.Context, clientId string) (bool, error)
+	CreateClient(ctx context.Context, entity *ClientEntity) (ClientEntity, error)
+	DeleteClientByClientId(ctx context.Context, clientId string) (bool, error)
+	ReplaceClientRoles(ctx context.Context, clientId string, roleIds []int64) (ClientEntity, error)
+	FindMissingRoleIds(ctx context.Context, roleIds []int64) ([]int64, error)
+	CreateRefreshToken(ctx context.Context, entity *RefreshTokenEntity) error
+	FindRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshTokenEntity, error)
+	RotateRefreshToken(ctx context.Context, oldId int64, entity *RefreshTokenEntity) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyId string) error
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator, issuer *TokenIssuer) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+		issuer:    issuer,
+	}
+}
+
+// IssueToken - выдача токенов по grant_type: password, client_credentials или refresh_token
+func (svc *Service) IssueToken(ctx context.Context, request TokenRequest) (TokenResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return TokenResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	switch request.GrantType {
+	case GrantTypePassword:
+		return svc.passwordGrant(ctx, request)
+	case GrantTypeClientCredentials:
+		return svc.clientCredentialsGrant(ctx, request)
+	case GrantTypeRefreshToken:
+		return svc.refreshTokenGrant(ctx, request)
+	default:
+		return TokenResponse{}, domain.RequestValidationError{
+			Message: fmt.Sprintf("unsupported grant_type %s", request.GrantType),
+		}
+	}
+}
+
+// RevokeToken - отзыв refresh-токена вместе со всей цепочкой ротации (выход из сессии).
+// Неизвестный токен не считается ошибкой (RFC 7009)
+func (svc *Service) RevokeToken(ctx context.Context, request RevokeRequest) error {
+	if err := svc.validator.Validate(request); err != nil {
+		return domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.repo.FindRefreshTokenByHash(ctx, HashToken(request.RefreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error finding refresh token: %w", err)
+	}
+
+	if err = svc.repo.RevokeRefreshTokenFamily(ctx, entity.FamilyId); err != nil {
+		return fmt.Errorf("error revoking refresh tokens of employee %d: %w", entity.EmployeeId, err)
+	}
+	return nil
+}
+
+// SetPassword - установка пароля сотрудника (хранится только bcrypt-хеш)
+func (svc *Service) SetPassword(ctx context.Context, employeeId int64, request SetPasswordRequest) error {
+	request.EmployeeID = employeeId
+	if err := svc.validator.Validate(request); err != nil {
+		return domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isExists, err := svc.repo.ExistsEmployeeById(ctx, employeeId)
+	if err != nil {
+		return fmt.Errorf("error checking employee with id %d: %w", employeeId, err)
+	}
+	if !isExists {
+		return domain.NotFoundError{Message: fmt.Sprintf("employee with id %d not found", employeeId)}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error hashing password of employee %d: %w", employeeId, err)
+	}
+
+	if err = svc.repo.UpsertCredential(ctx, employeeId, string(hash)); err != nil {
+		return fmt.Errorf("error saving password of employee %d: %w", employeeId, err)
+	}
+	return nil
+}
+
+// FindAllClients - найти всех клиентов
+func (svc *Service) FindAllClients(ctx context.Context) ([]ClientResponse, error) {
+	entities, err := svc.repo.FindAllClients(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding Clients: %w", err)
+	}
+
+	var responses = make([]ClientResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+	return responses, nil
+}
+
+// CreateClient - регистрация клиента, секрет генерируется и возвращается только один раз
+func (svc *Service) CreateClient(ctx context.Context, request CreateClientRequest) (CreateClientResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return CreateClientResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isExists, err := svc.repo.ExistsClientByClientId(ctx, request.ClientId)
+	if err != nil {
+		return CreateClientResponse{}, fmt.Errorf("error checking client %s: %w", request.ClientId, err)
+	}
+	if isExists {
+		return CreateClientResponse{}, domain.AlreadyExistsError{
+			Message: fmt.Sprintf("client %s already exists", request.ClientId),
+		}
+	}
+
+	roleIds, err := svc.checkClientRoles(ctx, request.RoleIds)
+	if err != nil {
+		return CreateClientResponse{}, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return CreateClientResponse{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return CreateClientResponse{}, fmt.Errorf("error hashing secret of client %s: %w", request.ClientId, err)
+	}
+
+	entity, err := svc.repo.CreateClient(ctx, &ClientEntity{
+		ClientId:   request.ClientId,
+		Name:       request.Name,
+		SecretHash: string(hash),
+		RoleIds:    roleIds,
+	})
+	if err != nil {
+		return CreateClientResponse{}, fmt.Errorf("error creating client %s: %w", request.ClientId, err)
+	}
+
+	return CreateClientResponse{ClientResponse: entity.ToResponse(), ClientSecret: secret}, nil
+}
+
+// SetClientRoles - заменить роли клиента: его разрешения - разрешения этих ролей
+func (svc *Service) SetClientRoles(ctx context.Context, request SetClientRolesRequest) (ClientResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return ClientResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	roleIds, err := svc.checkClientRoles(ctx, request.RoleIds)
+	if err != nil {
+		return ClientResponse{}, err
+	}
+
+	entity, err := svc.repo.ReplaceClientRoles(ctx, request.ClientId, roleIds)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ClientResponse{}, domain.NotFoundError{Message: fmt.Sprintf("client %s not found", request.ClientId)}
+	}
+	if err != nil {
+		return ClientResponse{}, fmt.Errorf("error setting roles of client %s: %w", request.ClientId, err)
+	}
+	return entity.ToResponse(), nil
+}
+
+// checkClientRoles - роли клиента без повторов по возрастанию id; все они должны существовать
+func (svc *Service) checkClientRoles(ctx context.Context, roleIds []int64) ([]int64, error) {
+	var unique = slices.Compact(slices.Sorted(slices.Values(roleIds)))
+	if len(unique) == 0 {
+		return []int64{}, nil
+	}
+
+	missing, err := svc.repo.FindMissingRoleIds(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("error checking client roles: %w", err)
+	}
+	if len(missing) > 0 {
+		return nil, domain.NotFoundError{Message: fmt.Sprintf("roles not found: %v", missing)}
+	}
+	return unique, nil
+}
+
+// DeleteClient - удаление клиента
+func (svc *Service) DeleteClient(ctx context.Context, clientId string) error {
+	if err := svc.validator.Validate(DeleteClientRequest{ClientId: clientId}); err != nil {
+		return domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isDeleted, err := svc.repo.DeleteClientByClientId(ctx, clientId)
+	if err != nil {
+		return fmt.Errorf("error deleting client %s: %w", clientId, err)
+	}
+	if !isDeleted {
+		return domain.NotFoundError{Message: fmt.Sprintf("client %s not found", clientId)}
+	}
+	return nil
+}
+
+// Jwks - публичные ключи проверки подписи токенов
+func (svc *Service) Jwks() JwksResponse {
+	return svc.issuer.Jwks()
+}
+
+func (svc *Service) passwordGrant(ctx context.Context, request TokenRequest) (TokenResponse, error) {
+	employeeId, err := strconv.ParseInt(request.Username, 10, 64)
+	if err != nil {
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(request.Password))
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidCredentials}
+	}
+
+	credential, err := svc.repo.FindCredentialByEmployeeId(ctx, employeeId)
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(request.Password))
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidCredentials}
+	}
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("error finding credentials of employee %d: %w", employeeId, err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(credential.PasswordHash), []byte(request.Password)) != nil {
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidCredentials}
+	}
+
+	refreshToken, entity, err := svc.newRefreshToken(employeeId, uuid.New().String())
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if err = svc.repo.CreateRefreshToken(ctx, &entity); err != nil {
+		return TokenResponse{}, fmt.Errorf("error saving refresh token of employee %d: %w", employeeId, err)
+	}
+
+	return svc.tokenResponse(strconv.FormatInt(employeeId, 10), SubjectTypeEmployee, refreshToken)
+}
+
+func (svc *Service) clientCredentialsGrant(ctx context.Context, request TokenRequest) (TokenResponse, error) {
+	client, err := svc.repo.FindClientByClientId(ctx, request.ClientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(request.ClientSecret))
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidCredentials}
+	}
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("error finding client %s: %w", request.ClientId, err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(request.ClientSecret)) != nil {
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidCredentials}
+	}
+
+	// для client_credentials refresh-токен не выдаётся (RFC 6749, 4.4.3)
+	return svc.tokenResponse(client.ClientId, SubjectTypeClient, "")
+}
+
+func (svc *Service) refreshTokenGrant(ctx context.Context, request TokenRequest) (TokenResponse, error) {
+	current, err := svc.repo.FindRefreshTokenByHash(ctx, HashToken(request.RefreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidRefreshToken}
+	}
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("error finding refresh token: %w", err)
+	}
+
+	if current.RevokedAt.Valid {
+		// повторное использование отозванного токена - вероятна утечка, отзываем всю цепочку
+		if err = svc.repo.RevokeRefreshTokenFamily(ctx, current.FamilyId); err != nil {
+			return TokenResponse{}, fmt.Errorf("error revoking refresh tokens of employee %d: %w", current.EmployeeId, err)
+		}
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidRefreshToken}
+	}
+	if !svc.issuer.Now().Before(current.ExpiresAt) {
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidRefreshToken}
+	}
+
+	refreshToken, entity, err := svc.newRefreshToken(current.EmployeeId, current.FamilyId)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	isRotated, err := svc.repo.RotateRefreshToken(ctx, current.Id, &entity)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("error rotating refresh token of employee %d: %w", current.EmployeeId, err)
+	}
+	if !isRotated {
+		// токен успели использовать параллельно - считаем это повторным использованием
+		if err = svc.repo.RevokeRefreshTokenFamily(ctx, current.FamilyId); err != nil {
+			return TokenResponse{}, fmt.Errorf("error revoking refresh tokens of employee %d: %w", current.EmployeeId, err)
+		}
+		return TokenResponse{}, domain.UnauthorizedError{Message: invalidRefreshToken}
+	}
+
+	return svc.tokenResponse(strconv.FormatInt(current.EmployeeId, 10), SubjectTypeEmployee, refreshToken)
+}
+
+// newRefreshToken - новый refresh-токен в цепочке familyId
+func (svc *Service) newRefreshToken(employeeId int64, familyId string) (string, RefreshTokenEntity, error) {
+	token, tokenHash, err := svc.issuer.NewRefreshToken()
+	if err != nil {
+		return "", RefreshTokenEntity{}, err
+	}
+
+	return token, RefreshTokenEntity{
+		TokenHash:  tokenHash,
+		FamilyId:   familyId,
+		EmployeeId: employeeId,
+		ExpiresAt:  svc.issuer.RefreshTokenExpiresAt(),
+	}, nil
+}
+
+func (svc *Service) tokenResponse(subject string, subjectType string, refreshToken string) (TokenResponse, error) {
+	accessToken, err := svc.issuer.IssueAccessToken(subject, subjectType)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("error signing access token for %s %s: %w", subjectType, subject, err)
+	}
+
+	return TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    TokenTypeBearer,
+		ExpiresIn:    int64(svc.issuer.AccessTokenTtl().Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// generateSecret - случайный секрет клиента
+func generateSecret() (string, error) {
+	var buf = make([]byte, clientSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating client secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
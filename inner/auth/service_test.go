@@ -0,0 +1,475 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Объявляем структуру мок-репозитория
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindCredentialByEmployeeId(ctx context.Context, employeeId int64) (CredentialEntity, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).(CredentialEntity), args.Error(1)
+}
+
+func (m *MockRepo) UpsertCredential(ctx context.Context, employeeId int64, passwordHash string) error {
+	args := m.Called(ctx, employeeId, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockRepo) FindAllClients(ctx context.Context) ([]ClientEntity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ClientEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindClientByClientId(ctx context.Context, clientId string) (ClientEntity, error) {
+	args := m.Called(ctx, clientId)
+	return args.Get(0).(ClientEntity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsClientByClientId(ctx context.Context, clientId string) (bool, error) {
+	args := m.Called(ctx, clientId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) CreateClient(ctx context.Context, entity *ClientEntity) (ClientEntity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(ClientEntity), args.Error(1)
+}
+
+func (m *MockRepo) ReplaceClientRoles(ctx context.Context, clientId string, roleIds []int64) (ClientEntity, error) {
+	args := m.Called(ctx, clientId, roleIds)
+	return args.Get(0).(ClientEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindMissingRoleIds(ctx context.Context, roleIds []int64) ([]int64, error) {
+	args := m.Called(ctx, roleIds)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockRepo) DeleteClientByClientId(ctx context.Context, clientId string) (bool, error) {
+	args := m.Called(ctx, clientId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) CreateRefreshToken(ctx context.Context, entity *RefreshTokenEntity) error {
+	args := m.Called(ctx, entity)
+	return args.Error(0)
+}
+
+func (m *MockRepo) FindRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshTokenEntity, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(RefreshTokenEntity), args.Error(1)
+}
+
+func (m *MockRepo) RotateRefreshToken(ctx context.Context, oldId int64, entity *RefreshTokenEntity) (bool, error) {
+	args := m.Called(ctx, oldId, entity)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	args := m.Called(ctx, familyId)
+	return args.Error(0)
+}
+
+func testConfig() config.Config {
+	return config.Config{
+		JwtHs256Secret:     "test-hs256-secret",
+		JwtIssuer:          "idm",
+		JwtAccessTokenTtl:  15 * time.Minute,
+		JwtRefreshTokenTtl: time.Hour,
+	}
+}
+
+func TestAuthService(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	cfg := testConfig()
+	issuer, err := NewTokenIssuer(cfg)
+	require.NoError(t, err)
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	parseAccessToken := func(t *testing.T, token string) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		_, err := jwt.NewParser(jwt.WithIssuer("idm")).ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(cfg.JwtHs256Secret), nil
+		})
+		require.NoError(t, err)
+		return claims
+	}
+
+	t.Run("should issue tokens for password grant", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := TokenRequest{GrantType: GrantTypePassword, Username: "7", Password: "secret-password"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindCredentialByEmployeeId", appContext, int64(7)).
+			Return(CredentialEntity{EmployeeId: 7, PasswordHash: string(passwordHash)}, nil).Once()
+		repo.On("CreateRefreshToken", appContext, mock.MatchedBy(func(entity *RefreshTokenEntity) bool {
+			return entity.EmployeeId == 7 && entity.FamilyId != "" && len(entity.TokenHash) == 64
+		})).Return(nil).Once()
+
+		got, err := service.IssueToken(appContext, request)
+
+		a.Nil(err)
+		a.Equal(TokenTypeBearer, got.TokenType)
+		a.Equal(int64(900), got.ExpiresIn)
+		a.NotEmpty(got.RefreshToken)
+		claims := parseAccessToken(t, got.AccessToken)
+		a.Equal("7", claims["sub"])
+		a.Equal(SubjectTypeEmployee, claims[ClaimSubjectType])
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject wrong password", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := TokenRequest{GrantType: GrantTypePassword, Username: "7", Password: "wrong-password"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindCredentialByEmployeeId", appContext, int64(7)).
+			Return(CredentialEntity{EmployeeId: 7, PasswordHash: string(passwordHash)}, nil).Once()
+
+		_, err := service.IssueToken(appContext, request)
+
+		a.True(errors.As(err, &domain.UnauthorizedError{}))
+		repo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject unknown employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := TokenRequest{GrantType: GrantTypePassword, Username: "8", Password: "secret-password"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindCredentialByEmployeeId", appContext, int64(8)).Return(CredentialEntity{}, sql.ErrNoRows).Once()
+
+		_, err := service.IssueToken(appContext, request)
+
+		a.True(errors.As(err, &domain.UnauthorizedError{}))
+	})
+
+	t.Run("should issue access token for client credentials grant", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := TokenRequest{GrantType: GrantTypeClientCredentials, ClientId: "billing", ClientSecret: "secret-password"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindClientByClientId", appContext, "billing").
+			Return(ClientEntity{ClientId: "billing", SecretHash: string(passwordHash)}, nil).Once()
+
+		got, err := service.IssueToken(appContext, request)
+
+		a.Nil(err)
+		a.Empty(got.RefreshToken)
+		claims := parseAccessToken(t, got.AccessToken)
+		a.Equal("billing", claims["sub"])
+		a.Equal(SubjectTypeClient, claims[ClaimSubjectType])
+	})
+
+	t.Run("should rotate refresh token", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := TokenRequest{GrantType: GrantTypeRefreshToken, RefreshToken: "old-token"}
+		current := RefreshTokenEntity{Id: 3, FamilyId: "family", EmployeeId: 7, ExpiresAt: time.Now().Add(time.Hour)}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindRefreshTokenByHash", appContext, HashToken("old-token")).Return(current, nil).Once()
+		repo.On("RotateRefreshToken", appContext, int64(3), mock.MatchedBy(func(entity *RefreshTokenEntity) bool {
+			return entity.FamilyId == "family" && entity.EmployeeId == 7
+		})).Return(true, nil).Once()
+
+		got, err := service.IssueToken(appContext, request)
+
+		a.Nil(err)
+		a.NotEmpty(got.RefreshToken)
+		a.NotEqual("old-token", got.RefreshToken)
+		a.Equal("7", parseAccessToken(t, got.AccessToken)["sub"])
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should revoke token family on refresh token reuse", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := TokenRequest{GrantType: GrantTypeRefreshToken, RefreshToken: "used-token"}
+		current := RefreshTokenEntity{
+			Id:         3,
+			FamilyId:   "family",
+			EmployeeId: 7,
+			ExpiresAt:  time.Now().Add(time.Hour),
+			RevokedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindRefreshTokenByHash", appContext, HashToken("used-token")).Return(current, nil).Once()
+		repo.On("RevokeRefreshTokenFamily", appContext, "family").Return(nil).Once()
+
+		_, err := service.IssueToken(appContext, request)
+
+		a.True(errors.As(err, &domain.UnauthorizedError{}))
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject expired refresh token", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := TokenRequest{GrantType: GrantTypeRefreshToken, RefreshToken: "expired-token"}
+		current := RefreshTokenEntity{Id: 3, FamilyId: "family", EmployeeId: 7, ExpiresAt: time.Now().Add(-time.Minute)}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindRefreshTokenByHash", appContext, HashToken("expired-token")).Return(current, nil).Once()
+
+		_, err := service.IssueToken(appContext, request)
+
+		a.True(errors.As(err, &domain.UnauthorizedError{}))
+	})
+
+	t.Run("should revoke refresh token family", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := RevokeRequest{RefreshToken: "token"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindRefreshTokenByHash", appContext, HashToken("token")).
+			Return(RefreshTokenEntity{Id: 3, FamilyId: "family", EmployeeId: 7}, nil).Once()
+		repo.On("RevokeRefreshTokenFamily", appContext, "family").Return(nil).Once()
+
+		err := service.RevokeToken(appContext, request)
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should ignore revocation of unknown refresh token", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := RevokeRequest{RefreshToken: "unknown"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindRefreshTokenByHash", appContext, HashToken("unknown")).Return(RefreshTokenEntity{}, sql.ErrNoRows).Once()
+
+		a.Nil(service.RevokeToken(appContext, request))
+	})
+
+	t.Run("should store password hash", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := SetPasswordRequest{EmployeeID: 7, Password: "new-password"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(7)).Return(true, nil).Once()
+		repo.On("UpsertCredential", appContext, int64(7), mock.MatchedBy(func(hash string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-password")) == nil
+		})).Return(nil).Once()
+
+		err := service.SetPassword(appContext, 7, SetPasswordRequest{Password: "new-password"})
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when set password of missing employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := SetPasswordRequest{EmployeeID: 7, Password: "new-password"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(7)).Return(false, nil).Once()
+
+		err := service.SetPassword(appContext, 7, SetPasswordRequest{Password: "new-password"})
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+
+	t.Run("should create client with generated secret", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := CreateClientRequest{ClientId: "billing", Name: "Billing"}
+		var savedHash string
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsClientByClientId", appContext, "billing").Return(false, nil).Once()
+		repo.On("CreateClient", appContext, mock.AnythingOfType("*auth.ClientEntity")).
+			Run(func(args mock.Arguments) { savedHash = args.Get(1).(*ClientEntity).SecretHash }).
+			Return(ClientEntity{Id: 1, ClientId: "billing", Name: "Billing"}, nil).Once()
+
+		got, err := service.CreateClient(appContext, request)
+
+		a.Nil(err)
+		a.Equal("billing", got.ClientId)
+		a.NotEmpty(got.ClientSecret)
+		a.Nil(bcrypt.CompareHashAndPassword([]byte(savedHash), []byte(got.ClientSecret)))
+	})
+
+	t.Run("should create client with unique existing roles", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := CreateClientRequest{ClientId: "hr-sync", RoleIds: []int64{7, 3, 7}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsClientByClientId", appContext, "hr-sync").Return(false, nil).Once()
+		repo.On("FindMissingRoleIds", appContext, []int64{3, 7}).Return([]int64{}, nil).Once()
+		repo.On("CreateClient", appContext, mock.MatchedBy(func(entity *ClientEntity) bool {
+			return slices.Equal(entity.RoleIds, []int64{3, 7})
+		})).Return(ClientEntity{Id: 1, ClientId: "hr-sync", RoleIds: []int64{3, 7}}, nil).Once()
+
+		got, err := service.CreateClient(appContext, request)
+
+		a.Nil(err)
+		a.Equal([]int64{3, 7}, got.RoleIds)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when client role is missing", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := SetClientRolesRequest{ClientId: "hr-sync", RoleIds: []int64{3, 9}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindMissingRoleIds", appContext, []int64{3, 9}).Return([]int64{9}, nil).Once()
+
+		_, err := service.SetClientRoles(appContext, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		a.ErrorContains(err, "9")
+		repo.AssertNotCalled(t, "ReplaceClientRoles", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should replace client roles and clear them with empty list", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := SetClientRolesRequest{ClientId: "hr-sync"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ReplaceClientRoles", appContext, "hr-sync", []int64{}).
+			Return(ClientEntity{ClientId: "hr-sync"}, nil).Once()
+
+		got, err := service.SetClientRoles(appContext, request)
+
+		a.Nil(err)
+		a.Equal([]int64{}, got.RoleIds)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when set roles of missing client", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := SetClientRolesRequest{ClientId: "missing"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ReplaceClientRoles", appContext, "missing", []int64{}).Return(ClientEntity{}, sql.ErrNoRows).Once()
+
+		_, err := service.SetClientRoles(appContext, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+
+	t.Run("should return already exists when client id is taken", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+		request := CreateClientRequest{ClientId: "billing"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsClientByClientId", appContext, "billing").Return(true, nil).Once()
+
+		_, err := service.CreateClient(appContext, request)
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+	})
+
+	t.Run("should return not found when delete missing client", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, issuer)
+
+		validator.On("Validate", DeleteClientRequest{ClientId: "billing"}).Return(nil).Once()
+		repo.On("DeleteClientByClientId", appContext, "billing").Return(false, nil).Once()
+
+		err := service.DeleteClient(appContext, "billing")
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+}
+
+func TestTokenIssuer(t *testing.T) {
+	var a = assert.New(t)
+
+	t.Run("should fail without signing key", func(t *testing.T) {
+		_, err := NewTokenIssuer(config.Config{JwtAccessTokenTtl: time.Minute, JwtRefreshTokenTtl: time.Hour})
+		a.Error(err)
+	})
+
+	t.Run("should sign RS256 tokens and publish JWKS", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		privatePem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+		cfg := testConfig()
+		cfg.JwtRs256PrivateKey = string(privatePem)
+
+		issuer, err := NewTokenIssuer(cfg)
+		require.NoError(t, err)
+		token, err := issuer.IssueAccessToken("7", SubjectTypeEmployee)
+		require.NoError(t, err)
+
+		jwks := issuer.Jwks()
+		require.Len(t, jwks.Keys, 1)
+		parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+			a.Equal(jwks.Keys[0].Kid, token.Header["kid"])
+			return &rsaKey.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		require.NoError(t, err)
+		a.True(parsed.Valid)
+		a.Equal("RSA", jwks.Keys[0].Kty)
+		a.Equal("AQAB", jwks.Keys[0].E)
+	})
+
+	t.Run("should not publish HS256 secret", func(t *testing.T) {
+		issuer, err := NewTokenIssuer(testConfig())
+		require.NoError(t, err)
+		a.Empty(issuer.Jwks().Keys)
+	})
+}
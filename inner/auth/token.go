@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"idm/inner/config"
+	"idm/inner/web/middleware"
+	"math/big"
+	"time"
+)
+
+const (
+	ClaimSubjectType = middleware.ClaimSubjectType // тип субъекта токена: employee или client
+
+	refreshTokenBytes = 32
+)
+
+// TokenIssuer - выпуск подписанных access-токенов и refresh-токенов.
+// Если задан приватный ключ RS256 - токены подписываются им, иначе общим секретом HS256
+type TokenIssuer struct {
+	hmacSecret      []byte
+	rsaKey          *rsa.PrivateKey
+	keyId           string
+	issuer          string
+	audience        string
+	accessTokenTtl  time.Duration
+	refreshTokenTtl time.Duration
+	now             func() time.Time
+}
+
+// NewTokenIssuer - функция-конструктор, ключи берутся из конфигурации приложения
+func NewTokenIssuer(cfg config.Config) (*TokenIssuer, error) {
+	var issuer = &TokenIssuer{
+		issuer:          cfg.JwtIssuer,
+		audience:        cfg.JwtAudience,
+		accessTokenTtl:  cfg.JwtAccessTokenTtl,
+		refreshTokenTtl: cfg.JwtRefreshTokenTtl,
+		now:             time.Now,
+	}
+	if cfg.JwtRs256PrivateKey != "" {
+		pemData, err := config.ReadPem(cfg.JwtRs256PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading RS256 private key: %w", err)
+		}
+		rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return nil, fmt.Errorf("error loading RS256 private key: %w", err)
+		}
+		issuer.rsaKey = rsaKey
+		issuer.keyId = thumbprint(&rsaKey.PublicKey)
+	} else if cfg.JwtHs256Secret != "" {
+		issuer.hmacSecret = []byte(cfg.JwtHs256Secret)
+	} else {
+		return nil, errors.New("no token signing key configured: set JWT_RS256_PRIVATE_KEY or JWT_HS256_SECRET")
+	}
+	if issuer.accessTokenTtl <= 0 || issuer.refreshTokenTtl <= 0 {
+		return nil, errors.New("token ttl must be positive")
+	}
+	return issuer, nil
+}
+
+// IssueAccessToken - выпуск подписанного access-токена для субъекта
+func (i *TokenIssuer) IssueAccessToken(subject string, subjectType string) (string, error) {
+	var now = i.now()
+	var claims = jwt.MapClaims{
+		"sub":            subject,
+		ClaimSubjectType: subjectType,
+		"iat":            now.Unix(),
+		"nbf":            now.Unix(),
+		"exp":            now.Add(i.accessTokenTtl).Unix(),
+		"jti":            uuid.New().String(),
+	}
+	if i.issuer != "" {
+		claims["iss"] = i.issuer
+	}
+	if i.audience != "" {
+		claims["aud"] = i.audience
+	}
+
+	if i.rsaKey != nil {
+		var token = jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = i.keyId
+		return token.SignedString(i.rsaKey)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.hmacSecret)
+}
+
+// NewRefreshToken - генерация непрозрачного refresh-токена, возвращает сам токен и его хеш для хранения
+func (i *TokenIssuer) NewRefreshToken() (token string, tokenHash string, err error) {
+	var buf = make([]byte, refreshTokenBytes)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("error generating refresh token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// RefreshTokenExpiresAt - срок действия нового refresh-токена
+func (i *TokenIssuer) RefreshTokenExpiresAt() time.Time {
+	return i.now().Add(i.refreshTokenTtl)
+}
+
+// AccessTokenTtl - время жизни access-токена
+func (i *TokenIssuer) AccessTokenTtl() time.Duration {
+	return i.accessTokenTtl
+}
+
+// Now - текущее время издателя (подменяется в тестах)
+func (i *TokenIssuer) Now() time.Time {
+	return i.now()
+}
+
+// Jwks - набор публичных ключей для проверки токенов. Секрет HS256 не публикуется
+func (i *TokenIssuer) Jwks() JwksResponse {
+	var keys = JwksResponse{Keys: []Jwk{}}
+	if i.rsaKey != nil {
+		keys.Keys = append(keys.Keys, Jwk{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: i.keyId,
+			N:   base64.RawURLEncoding.EncodeToString(i.rsaKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.rsaKey.E)).Bytes()),
+		})
+	}
+	return keys
+}
+
+// HashToken - SHA-256 хеш refresh-токена в hex, в БД хранится только он
+func HashToken(token string) string {
+	var sum = sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// thumbprint - идентификатор ключа (kid) по RFC 7638
+func thumbprint(key *rsa.PublicKey) string {
+	// поля в лексикографическом порядке, как требует RFC 7638
+	data, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+	var sum = sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
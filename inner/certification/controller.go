@@ -0,0 +1,393 @@
+package certification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server               *web.Server
+	certificationService Svc
+	logger               *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	FindAll(ctx context.Context) ([]CampaignResponse, error)
+	FindById(ctx context.Context, id int64) (CampaignResponse, error)
+	Create(ctx context.Context, request CreateRequest) (CampaignResponse, error)
+	FindItems(ctx context.Context, campaignId int64) ([]ItemResponse, error)
+	Export(ctx context.Context, campaignId int64) ([]byte, error)
+	FindAwaitingReview(ctx context.Context) ([]ItemResponse, error)
+	Certify(ctx context.Context, itemId int64, request DecisionRequest) (ItemResponse, error)
+	Revoke(ctx context.Context, itemId int64, request DecisionRequest) (ItemResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	certificationService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:               server,
+		certificationService: certificationService,
+		logger:               logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/certifications"
+	c.server.GroupCertifications.Get("/", c.server.Require(web.PermCertificationsRead), c.FindAll)
+	c.server.GroupCertifications.Post("/", c.server.Require(web.PermCertificationsWrite), c.Create)
+	// проверяющий видит только направленные ему назначения - это проверяет сервис
+	c.server.GroupCertifications.Get("/items/awaiting-review", c.server.Require(web.PermCertificationsReview), c.FindAwaitingReview)
+	c.server.GroupCertifications.Post("/items/:id/certify", c.server.Require(web.PermCertificationsReview), c.Certify)
+	c.server.GroupCertifications.Post("/items/:id/revoke", c.server.Require(web.PermCertificationsReview), c.Revoke)
+	c.server.GroupCertifications.Get("/:id", c.server.Require(web.PermCertificationsRead), c.FindById)
+	// назначения кампании - это и данные сотрудников, поэтому нужно ещё право на их просмотр
+	c.server.GroupCertifications.Get("/:id/items", c.server.Require(web.PermCertificationsRead, web.PermEmployeesRead), c.FindItems)
+	c.server.GroupCertifications.Get("/:id/export", c.server.Require(web.PermCertificationsRead, web.PermEmployeesRead), c.Export)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/certifications" --//
+
+// FindAll   	 godoc
+// @Description  Find all access certification campaigns with review progress
+// @Summary		 get all certification campaigns
+// @Tags 		 certification
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		certification.CampaignResponse	"Certification campaigns"
+// @Failure      500  {object}  	http.Response					"Bad request"
+// @Router 		 /certifications/		[get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.certificationService.FindAll(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Certification campaigns ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find by ID access certification campaign
+// @Summary 	 find by ID certification campaign
+// @Tags 		 certification
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  						"Campaign ID"
+// @Success 	 200  {object}  	certification.CampaignResponse	"Certification campaign"
+// @Failure      400  {object}  	http.Response					"Bad request"
+// @Failure      404  {object}  	http.Response					"Not found"
+// @Failure      500  {object}  	http.Response					"Bad request"
+// @Router 		 /certifications/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	campaignID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.certificationService.FindById(appContext, campaignID)
+	if err != nil {
+		c.logger.Error(
+			"When the get Certification campaign ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", campaignID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Create 		 godoc
+// @Summary      start certification campaign
+// @Description  Start campaign over role assignments in scope (all roles, one role or org unit with subunits);
+// @Description  each assignment is routed to the employee's manager, the role owner or the campaign creator
+// @Tags 		 certification
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	certification.CreateRequest true "Campaign details"
+// @Success 	 201  {object}  certification.CampaignResponse	"Certification campaign"
+// @Failure      400  {object}  http.Response					"Bad request"
+// @Failure      404  {object}  http.Response					"Scope role or org unit not found"
+// @Failure      500  {object}  http.Response					"Bad request"
+// @Router 		 /certifications/ 	[post]
+func (c *Controller) Create(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an Create Certification campaign ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.certificationService.Create(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Certification campaign ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// FindItems 	 godoc
+// @Description  Find role assignments of certification campaign with decisions
+// @Summary 	 find certification campaign items
+// @Tags 		 certification
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  					"Campaign ID"
+// @Success 	 200  {array}  		certification.ItemResponse	"Campaign items"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /certifications/{id}/items 	[get]
+func (c *Controller) FindItems(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	campaignID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.certificationService.FindItems(appContext, campaignID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Certification campaign items ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", campaignID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Export 		 godoc
+// @Description  Export certification campaign result as CSV, one row per role assignment
+// @Summary 	 export certification campaign
+// @Tags 		 certification
+// @Produce 	 text/csv
+// @Param 		 id   path      	int  true  		"Campaign ID"
+// @Success 	 200  {file}  		file			"Campaign result"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /certifications/{id}/export 	[get]
+func (c *Controller) Export(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	campaignID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	body, err := c.certificationService.Export(appContext, campaignID)
+	if err != nil {
+		c.logger.Error(
+			"When the export Certification campaign ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", campaignID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CsvResponse(ctx, fmt.Sprintf("certification-%d.csv", campaignID), body)
+}
+
+// FindAwaitingReview godoc
+// @Description  Find role assignments the calling employee has to review in active campaigns
+// @Summary 	 find assignments awaiting review
+// @Tags 		 certification
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		certification.ItemResponse	"Items awaiting review"
+// @Failure      403  {object}  	http.Response				"Caller is not an employee"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /certifications/items/awaiting-review 	[get]
+func (c *Controller) FindAwaitingReview(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.certificationService.FindAwaitingReview(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find Certification items awaiting review ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Certify 		 godoc
+// @Summary      certify role assignment
+// @Description  Confirm role assignment routed to the caller for review
+// @Tags 		 certification
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  							true  	"Item ID"
+// @Param 		 request 	body 		certification.DecisionRequest 	false 	"Decision comment"
+// @Success 	 200  {object}  	certification.ItemResponse	"Certification item"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      403  {object}  	http.Response	"Caller is not the reviewer"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      409  {object}  	http.Response	"Item already reviewed or campaign closed"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /certifications/items/{id}/certify 	[post]
+func (c *Controller) Certify(ctx *fiber.Ctx) error {
+	return c.decide(ctx, DecisionCertified, c.certificationService.Certify)
+}
+
+// Revoke 		 godoc
+// @Summary      revoke role assignment
+// @Description  Revoke role assignment routed to the caller for review; the role is taken from the employee
+// @Tags 		 certification
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  							true  	"Item ID"
+// @Param 		 request 	body 		certification.DecisionRequest 	false 	"Decision comment"
+// @Success 	 200  {object}  	certification.ItemResponse	"Certification item"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      403  {object}  	http.Response	"Caller is not the reviewer"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      409  {object}  	http.Response	"Item already reviewed or campaign closed"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /certifications/items/{id}/revoke 	[post]
+func (c *Controller) Revoke(ctx *fiber.Ctx) error {
+	return c.decide(ctx, DecisionRevoked, c.certificationService.Revoke)
+}
+
+// decide - общий обработчик решений проверяющего; комментарий в теле запроса необязателен
+func (c *Controller) decide(
+	ctx *fiber.Ctx,
+	decision string,
+	decide func(ctx context.Context, itemId int64, request DecisionRequest) (ItemResponse, error),
+) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	itemID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request DecisionRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&request); err != nil {
+			c.logger.Error(
+				"When the body parse an Certification decision ended with an error:",
+				zap.Error(err),
+				zap.String("decision", decision),
+				zap.String("request_id", requestId),
+			)
+
+			return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+		}
+	}
+
+	response, err := decide(appContext, itemID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the decision on Certification item ended with an error:",
+			zap.Error(err),
+			zap.String("decision", decision),
+			zap.Int64("id", itemID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.ForbiddenError{}):
+		return http.ErrResponse(ctx, fiber.StatusForbidden, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.ConflictError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,148 @@
+package certification
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCertification_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockCertificationService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupCertifications:   app.Group("/api/v1/certifications"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should create campaign", func(t *testing.T) {
+		deadline := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		request := CreateRequest{Name: "Q3 payments", ScopeType: ScopeRole, ScopeId: 5, Deadline: deadline, AutoRevoke: true}
+		campaign := CampaignResponse{Id: 100, Name: "Q3 payments", ScopeType: ScopeRole, Deadline: deadline,
+			AutoRevoke: true, Status: CampaignActive, Progress: ProgressResponse{Total: 2, Pending: 2}}
+		mockService.On("Create", appContext, request).Return(campaign, nil).Once()
+
+		body := `{"name": "Q3 payments", "scopeType": "role", "scopeId": 5, "deadline": "2030-01-01T00:00:00Z", "autoRevoke": true}`
+		req := httptest.NewRequest("POST", "/api/v1/certifications/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should export campaign items as csv", func(t *testing.T) {
+		csv := []byte("item_id,employee_id\n50,1\n")
+		mockService.On("Export", appContext, int64(100)).Return(csv, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/certifications/100/export", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="certification-100.csv"`, resp.Header.Get("Content-Disposition"))
+
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, csv, got)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return items awaiting review", func(t *testing.T) {
+		var reviewerId = int64(2)
+		items := []ItemResponse{{Id: 50, CampaignId: 100, EmployeeId: 1, RoleId: 5, ReviewerId: &reviewerId, Decision: DecisionPending}}
+		mockService.On("FindAwaitingReview", appContext).Return(items, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/certifications/items/awaiting-review", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data []ItemResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, items, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should revoke with comment", func(t *testing.T) {
+		item := ItemResponse{Id: 50, CampaignId: 100, Decision: DecisionRevoked, Comment: "left the team"}
+		mockService.On("Revoke", appContext, int64(50), DecisionRequest{Comment: "left the team"}).Return(item, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/certifications/items/50/revoke", strings.NewReader(`{"comment": "left the team"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 403 when caller is not the reviewer", func(t *testing.T) {
+		forbidden := domain.ForbiddenError{Message: "certification item 51 is not routed to you"}
+		mockService.On("Certify", appContext, int64(51), DecisionRequest{}).Return(ItemResponse{}, forbidden).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/certifications/items/51/certify", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when campaign is closed", func(t *testing.T) {
+		conflict := domain.ConflictError{Message: "certification item 52 is already decided or its campaign is closed"}
+		mockService.On("Certify", appContext, int64(52), DecisionRequest{}).Return(ItemResponse{}, conflict).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/certifications/items/52/certify", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on invalid id", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/certifications/abc", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
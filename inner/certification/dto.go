@@ -0,0 +1,247 @@
+package certification
+
+import (
+	"bytes"
+	"encoding/csv"
+	"idm/inner/export"
+	"strconv"
+	"time"
+)
+
+// Охват кампании
+const (
+	ScopeAll     = "all"      // все назначения ролей
+	ScopeRole    = "role"     // назначения одной роли
+	ScopeOrgUnit = "org_unit" // назначения сотрудников подразделения и его подчинённых подразделений
+)
+
+// Состояния кампании
+const (
+	CampaignActive    = "active"    // идёт проверка
+	CampaignCompleted = "completed" // срок проверки наступил, не проверенные назначения закрыты
+)
+
+// Решения по назначению
+const (
+	DecisionPending   = "pending"   // ждёт проверки
+	DecisionCertified = "certified" // назначение подтверждено
+	DecisionRevoked   = "revoked"   // роль отозвана проверяющим или автоматически по сроку
+	DecisionExpired   = "expired"   // не проверено к сроку, назначение сохранено
+)
+
+// CampaignEntity - кампания вместе с количеством назначений по решениям (заполняются только при выборке)
+type CampaignEntity struct {
+	Id             int64      `db:"id"`
+	Name           string     `db:"name"`
+	ScopeType      string     `db:"scope_type"`
+	ScopeId        *int64     `db:"scope_id"`
+	Deadline       time.Time  `db:"deadline"`
+	AutoRevoke     bool       `db:"auto_revoke"`
+	Status         string     `db:"status"`
+	CreatedBy      *int64     `db:"created_by"`
+	CompletedAt    *time.Time `db:"completed_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	TotalItems     int64      `db:"total_items"`
+	PendingItems   int64      `db:"pending_items"`
+	CertifiedItems int64      `db:"certified_items"`
+	RevokedItems   int64      `db:"revoked_items"`
+	ExpiredItems   int64      `db:"expired_items"`
+}
+
+// ProgressResponse model info
+// @Description Number of campaign review items by decision
+type ProgressResponse struct {
+	Total     int64 `json:"total"`
+	Pending   int64 `json:"pending"`
+	Certified int64 `json:"certified"`
+	Revoked   int64 `json:"revoked"`
+	Expired   int64 `json:"expired"`
+}
+
+// CampaignResponse model info
+// @Description Access certification campaign
+// @Description with scope, deadline, auto revoke flag, status and review progress
+type CampaignResponse struct {
+	Id          int64            `json:"id"`
+	Name        string           `json:"name"`
+	ScopeType   string           `json:"scopeType"`
+	ScopeId     *int64           `json:"scopeId,omitempty"`
+	Deadline    time.Time        `json:"deadline"`
+	AutoRevoke  bool             `json:"autoRevoke"`
+	Status      string           `json:"status"`
+	CreatedBy   *int64           `json:"createdBy,omitempty"`
+	CompletedAt *time.Time       `json:"completedAt,omitempty"`
+	Progress    ProgressResponse `json:"progress"`
+	CreateAt    time.Time        `json:"createAt"`
+	UpdateAt    time.Time        `json:"updateAt"`
+}
+
+func (e *CampaignEntity) ToResponse() CampaignResponse {
+	return CampaignResponse{
+		Id:          e.Id,
+		Name:        e.Name,
+		ScopeType:   e.ScopeType,
+		ScopeId:     e.ScopeId,
+		Deadline:    e.Deadline,
+		AutoRevoke:  e.AutoRevoke,
+		Status:      e.Status,
+		CreatedBy:   e.CreatedBy,
+		CompletedAt: e.CompletedAt,
+		Progress: ProgressResponse{
+			Total:     e.TotalItems,
+			Pending:   e.PendingItems,
+			Certified: e.CertifiedItems,
+			Revoked:   e.RevokedItems,
+			Expired:   e.ExpiredItems,
+		},
+		CreateAt: e.CreatedAt,
+		UpdateAt: e.UpdatedAt,
+	}
+}
+
+// ItemEntity - назначение роли на проверке с именами сотрудника, роли и проверяющего
+// и сроком кампании (заполняются только при выборке)
+type ItemEntity struct {
+	Id             int64      `db:"id"`
+	CampaignId     int64      `db:"campaign_id"`
+	EmployeeId     int64      `db:"employee_id"`
+	RoleId         int64      `db:"role_id"`
+	ReviewerId     *int64     `db:"reviewer_id"`
+	Decision       string     `db:"decision"`
+	Comment        string     `db:"comment"`
+	DecidedBy      *int64     `db:"decided_by"`
+	DecidedAt      *time.Time `db:"decided_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	EmployeeName   string     `db:"employee_name"`
+	RoleName       string     `db:"role_name"`
+	ReviewerName   string     `db:"reviewer_name"`
+	Deadline       time.Time  `db:"deadline"`
+	CampaignStatus string     `db:"campaign_status"`
+}
+
+// ItemResponse model info
+// @Description Role assignment under review in certification campaign
+// @Description with employee, role, reviewer and decision
+type ItemResponse struct {
+	Id           int64      `json:"id"`
+	CampaignId   int64      `json:"campaignId"`
+	EmployeeId   int64      `json:"employeeId"`
+	EmployeeName string     `json:"employeeName,omitempty"`
+	RoleId       int64      `json:"roleId"`
+	RoleName     string     `json:"roleName,omitempty"`
+	ReviewerId   *int64     `json:"reviewerId,omitempty"`
+	ReviewerName string     `json:"reviewerName,omitempty"`
+	Decision     string     `json:"decision"`
+	Comment      string     `json:"comment,omitempty"`
+	DecidedBy    *int64     `json:"decidedBy,omitempty"`
+	DecidedAt    *time.Time `json:"decidedAt,omitempty"`
+	CreateAt     time.Time  `json:"createAt"`
+}
+
+func (e *ItemEntity) ToResponse() ItemResponse {
+	return ItemResponse{
+		Id:           e.Id,
+		CampaignId:   e.CampaignId,
+		EmployeeId:   e.EmployeeId,
+		EmployeeName: e.EmployeeName,
+		RoleId:       e.RoleId,
+		RoleName:     e.RoleName,
+		ReviewerId:   e.ReviewerId,
+		ReviewerName: e.ReviewerName,
+		Decision:     e.Decision,
+		Comment:      e.Comment,
+		DecidedBy:    e.DecidedBy,
+		DecidedAt:    e.DecidedAt,
+		CreateAt:     e.CreatedAt,
+	}
+}
+
+// isClosed - кампания завершена или её срок уже наступил (но планировщик её ещё не закрыл)
+func (e *ItemEntity) isClosed(now time.Time) bool {
+	return e.CampaignStatus != CampaignActive || !e.Deadline.After(now)
+}
+
+// csvHeader - колонки выгрузки результатов кампании
+var csvHeader = []string{
+	"item_id", "employee_id", "employee_name", "role_id", "role_name",
+	"reviewer_id", "reviewer_name", "decision", "comment", "decided_by", "decided_at",
+}
+
+// ToCsv - результаты кампании в CSV: строка заголовка и по строке на назначение.
+// Значения, похожие на формулы (комментарий, имена), экранируются - export.EscapeFormula
+func ToCsv(items []ItemResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	var writer = csv.NewWriter(&buf)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var decidedAt string
+		if item.DecidedAt != nil {
+			decidedAt = item.DecidedAt.UTC().Format(time.RFC3339)
+		}
+		err := writer.Write(export.EscapeFormulas([]string{
+			strconv.FormatInt(item.Id, 10),
+			strconv.FormatInt(item.EmployeeId, 10),
+			item.EmployeeName,
+			strconv.FormatInt(item.RoleId, 10),
+			item.RoleName,
+			formatId(item.ReviewerId),
+			item.ReviewerName,
+			item.Decision,
+			item.Comment,
+			formatId(item.DecidedBy),
+			decidedAt,
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+
+	return buf.Bytes(), writer.Error()
+}
+
+// formatId - необязательный id для CSV: пустая строка вместо nil
+func formatId(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
+
+// CreateRequest model info
+// @Description Request to start certification campaign over role assignments in scope
+type CreateRequest struct {
+	Name       string    `json:"name" validate:"required,min=2,max=155"`
+	ScopeType  string    `json:"scopeType" validate:"required,oneof=all role org_unit"`
+	ScopeId    int64     `json:"scopeId" validate:"required_unless=ScopeType all,excluded_if=ScopeType all,omitempty,min=1"`
+	Deadline   time.Time `json:"deadline" validate:"required"`
+	AutoRevoke bool      `json:"autoRevoke"` // отозвать не проверенные к сроку назначения
+}
+
+func (req *CreateRequest) ToEntity() CampaignEntity {
+	var entity = CampaignEntity{
+		Name:       req.Name,
+		ScopeType:  req.ScopeType,
+		Deadline:   req.Deadline,
+		AutoRevoke: req.AutoRevoke,
+	}
+	if req.ScopeType != ScopeAll {
+		var scopeId = req.ScopeId
+		entity.ScopeId = &scopeId
+	}
+	return entity
+}
+
+// DecisionRequest model info
+// @Description Reviewer decision on role assignment
+type DecisionRequest struct {
+	Id      int64  `json:"-" validate:"required,min=1"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
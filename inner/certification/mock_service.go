@@ -0,0 +1,50 @@
+package certification
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCertificationService struct {
+	mock.Mock
+}
+
+func (m *MockCertificationService) FindAll(ctx context.Context) ([]CampaignResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]CampaignResponse), args.Error(1)
+}
+
+func (m *MockCertificationService) FindById(ctx context.Context, id int64) (CampaignResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(CampaignResponse), args.Error(1)
+}
+
+func (m *MockCertificationService) Create(ctx context.Context, request CreateRequest) (CampaignResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(CampaignResponse), args.Error(1)
+}
+
+func (m *MockCertificationService) FindItems(ctx context.Context, campaignId int64) ([]ItemResponse, error) {
+	args := m.Called(ctx, campaignId)
+	return args.Get(0).([]ItemResponse), args.Error(1)
+}
+
+func (m *MockCertificationService) Export(ctx context.Context, campaignId int64) ([]byte, error) {
+	args := m.Called(ctx, campaignId)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockCertificationService) FindAwaitingReview(ctx context.Context) ([]ItemResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ItemResponse), args.Error(1)
+}
+
+func (m *MockCertificationService) Certify(ctx context.Context, itemId int64, request DecisionRequest) (ItemResponse, error) {
+	args := m.Called(ctx, itemId, request)
+	return args.Get(0).(ItemResponse), args.Error(1)
+}
+
+func (m *MockCertificationService) Revoke(ctx context.Context, itemId int64, request DecisionRequest) (ItemResponse, error) {
+	args := m.Called(ctx, itemId, request)
+	return args.Get(0).(ItemResponse), args.Error(1)
+}
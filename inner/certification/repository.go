@@ -0,0 +1,368 @@
+package certification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"idm/inner/outbox"
+	"time"
+)
+
+// selectCampaigns - выборка кампаний с количеством назначений по решениям
+const selectCampaigns = `
+	SELECT c.*, p.*
+	FROM certification_campaigns c
+	CROSS JOIN LATERAL (
+		SELECT COUNT(*) AS total_items,
+			COUNT(*) FILTER (WHERE i.decision = 'pending') AS pending_items,
+			COUNT(*) FILTER (WHERE i.decision = 'certified') AS certified_items,
+			COUNT(*) FILTER (WHERE i.decision = 'revoked') AS revoked_items,
+			COUNT(*) FILTER (WHERE i.decision = 'expired') AS expired_items
+		FROM certification_items i
+		WHERE i.campaign_id = c.id
+	) p
+`
+
+// selectItems - выборка назначений на проверке с именами и сроком кампании
+const selectItems = `
+	SELECT i.*, e.name AS employee_name, r.name AS role_name, COALESCE(rv.name, '') AS reviewer_name,
+		c.deadline, c.status AS campaign_status
+	FROM certification_items i
+	JOIN certification_campaigns c ON c.id = i.campaign_id
+	JOIN employees e ON e.id = i.employee_id
+	JOIN roles r ON r.id = i.role_id
+	LEFT JOIN employees rv ON rv.id = i.reviewer_id
+`
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindAll - все кампании, новые первыми
+func (r *Repository) FindAll(ctx context.Context) (campaigns []CampaignEntity, err error) {
+	err = r.db.SelectContext(ctx, &campaigns, selectCampaigns+"ORDER BY c.created_at DESC, c.id DESC")
+
+	return campaigns, err
+}
+
+// FindById - найти кампанию по id
+func (r *Repository) FindById(ctx context.Context, id int64) (campaign CampaignEntity, err error) {
+	err = r.db.GetContext(ctx, &campaign, selectCampaigns+"WHERE c.id = $1", id)
+
+	return campaign, err
+}
+
+// ExistsRoleById - проверить наличие не удалённой роли
+func (r *Repository) ExistsRoleById(ctx context.Context, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)",
+		roleId,
+	)
+
+	return isExists, err
+}
+
+// ExistsOrgUnitById - проверить наличие подразделения
+func (r *Repository) ExistsOrgUnitById(ctx context.Context, orgUnitId int64) (isExists bool, err error) {
+	err = r.db.GetContext(ctx, &isExists, "SELECT EXISTS(SELECT 1 FROM org_units WHERE id = $1)", orgUnitId)
+
+	return isExists, err
+}
+
+// CreateCampaign - добавить кампанию и в той же транзакции поставить на проверку все действующие назначения
+// ролей в её охвате. Проверяющий - руководитель сотрудника, без него - владелец роли, без него - создатель
+// кампании; сотрудник не проверяет собственные назначения, а назначения без проверяющего ждут срока кампании
+func (r *Repository) CreateCampaign(ctx context.Context, entity *CampaignEntity) (created CampaignEntity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var now = time.Now()
+		err := tx.GetContext(
+			ctx,
+			&created,
+			`INSERT INTO certification_campaigns
+				(name, scope_type, scope_id, deadline, auto_revoke, status, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, 'active', $6, $7, $7)
+			RETURNING *`,
+			entity.Name, entity.ScopeType, entity.ScopeId, entity.Deadline, entity.AutoRevoke, entity.CreatedBy, now,
+		)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(
+			ctx,
+			`WITH RECURSIVE units AS (
+				SELECT id FROM org_units WHERE $2 = 'org_unit' AND id = $3
+				UNION
+				SELECT o.id FROM org_units o JOIN units u ON o.parent_id = u.id
+			)
+			INSERT INTO certification_items (campaign_id, employee_id, role_id, reviewer_id, created_at)
+			SELECT $1, er.employee_id, er.role_id, COALESCE(m.id, o.id, cr.id), $5
+			FROM employee_roles er
+			JOIN employees e ON e.id = er.employee_id AND e.deleted_at IS NULL AND e.status <> 'terminated'
+			JOIN roles r ON r.id = er.role_id AND r.deleted_at IS NULL
+			LEFT JOIN employees m ON m.id = e.manager_id AND m.deleted_at IS NULL AND m.status = 'active'
+			LEFT JOIN employees o ON o.id = r.owner_id AND o.id <> e.id AND o.deleted_at IS NULL AND o.status = 'active'
+			LEFT JOIN employees cr ON cr.id = $4 AND cr.id <> e.id
+			WHERE er.valid_from <= $5 AND (er.valid_until IS NULL OR er.valid_until > $5)
+				AND ($2 = 'all'
+					OR ($2 = 'role' AND er.role_id = $3)
+					OR ($2 = 'org_unit' AND e.org_unit_id IN (SELECT id FROM units)))`,
+			created.Id, created.ScopeType, created.ScopeId, created.CreatedBy, now,
+		)
+		if err != nil {
+			return err
+		}
+		if created.TotalItems, err = result.RowsAffected(); err != nil {
+			return err
+		}
+		created.PendingItems = created.TotalItems
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityCertificationCampaign,
+			EntityId:   created.Id,
+			After:      created.ToResponse(),
+		})
+	})
+
+	return created, err
+}
+
+// FindItems - назначения кампании, упорядоченные по сотруднику и роли
+func (r *Repository) FindItems(ctx context.Context, campaignId int64) (items []ItemEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&items,
+		selectItems+"WHERE i.campaign_id = $1 ORDER BY e.name, e.id, r.name, i.id",
+		campaignId,
+	)
+
+	return items, err
+}
+
+// FindItemById - найти назначение на проверке по id
+func (r *Repository) FindItemById(ctx context.Context, id int64) (item ItemEntity, err error) {
+	err = r.db.GetContext(ctx, &item, selectItems+"WHERE i.id = $1", id)
+
+	return item, err
+}
+
+// FindPendingByReviewerId - назначения, ждущие проверки сотрудником в активных кампаниях, ближайший срок первым
+func (r *Repository) FindPendingByReviewerId(ctx context.Context, reviewerId int64) (items []ItemEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&items,
+		selectItems+`WHERE i.reviewer_id = $1 AND i.decision = 'pending' AND c.status = 'active' AND c.deadline > NOW()
+		ORDER BY c.deadline, e.name, i.id`,
+		reviewerId,
+	)
+
+	return items, err
+}
+
+// Certify - подтвердить назначение. Возвращает false, если назначение уже проверено или кампания закрыта
+func (r *Repository) Certify(ctx context.Context, id int64, reviewerId int64, comment string) (isDecided bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		decided, err := r.decideTx(ctx, tx, id, DecisionCertified, reviewerId, comment)
+		isDecided = decided != nil
+		return err
+	})
+
+	return isDecided && err == nil, err
+}
+
+// Revoke - отозвать проверяемую роль у сотрудника. Возвращает false, если назначение уже проверено
+// или кампания закрыта
+func (r *Repository) Revoke(ctx context.Context, id int64, reviewerId int64, comment string) (isDecided bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		decided, err := r.decideTx(ctx, tx, id, DecisionRevoked, reviewerId, comment)
+		if err != nil || decided == nil {
+			return err
+		}
+
+		isDecided = true
+		return revokeAssignmentTx(ctx, tx, decided.EmployeeId, decided.RoleId)
+	})
+
+	return isDecided && err == nil, err
+}
+
+// decideTx - записать решение проверяющего; nil, если назначение уже проверено или кампания закрыта
+func (r *Repository) decideTx(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	id int64,
+	decision string,
+	reviewerId int64,
+	comment string,
+) (*ItemEntity, error) {
+	var before ItemEntity
+	err := tx.GetContext(
+		ctx,
+		&before,
+		`SELECT i.* FROM certification_items i
+		JOIN certification_campaigns c ON c.id = i.campaign_id AND c.status = 'active' AND c.deadline > NOW()
+		WHERE i.id = $1 AND i.decision = 'pending'
+		FOR UPDATE OF i`,
+		id,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var after ItemEntity
+	err = tx.GetContext(
+		ctx,
+		&after,
+		`UPDATE certification_items SET decision = $2, comment = $3, decided_by = $4, decided_at = $5
+		WHERE id = $1
+		RETURNING *`,
+		id, decision, comment, reviewerId, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = audit.InsertEventTx(ctx, tx, audit.Event{
+		Action:     audit.ActionStatusChange,
+		EntityType: audit.EntityCertificationItem,
+		EntityId:   id,
+		Before:     before.ToResponse(),
+		After:      after.ToResponse(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &after, nil
+}
+
+// CompleteDue - закрыть активные кампании, срок которых наступил к now: не проверенные назначения
+// отзываются (если задан auto_revoke) или помечаются expired. Кампании, которые в этот момент закрывает
+// другой экземпляр сервиса, пропускаются
+func (r *Repository) CompleteDue(ctx context.Context, now time.Time) (completed []CampaignEntity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var due []CampaignEntity
+		err := tx.SelectContext(
+			ctx,
+			&due,
+			`SELECT * FROM certification_campaigns
+			WHERE status = 'active' AND deadline <= $1
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED`,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, campaign := range due {
+			closed, err := r.completeTx(ctx, tx, campaign, now)
+			if err != nil {
+				return err
+			}
+			completed = append(completed, closed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return completed, nil
+}
+
+// completeTx - закрыть кампанию, заблокированную в CompleteDue
+func (r *Repository) completeTx(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	campaign CampaignEntity,
+	now time.Time,
+) (CampaignEntity, error) {
+	var before CampaignEntity
+	if err := tx.GetContext(ctx, &before, selectCampaigns+"WHERE c.id = $1", campaign.Id); err != nil {
+		return CampaignEntity{}, err
+	}
+
+	var decision = DecisionExpired
+	if campaign.AutoRevoke {
+		decision = DecisionRevoked
+	}
+
+	var unreviewed []ItemEntity
+	err := tx.SelectContext(
+		ctx,
+		&unreviewed,
+		`UPDATE certification_items SET decision = $2, decided_at = $3
+		WHERE campaign_id = $1 AND decision = 'pending'
+		RETURNING *`,
+		campaign.Id, decision, now,
+	)
+	if err != nil {
+		return CampaignEntity{}, err
+	}
+	if campaign.AutoRevoke {
+		for _, item := range unreviewed {
+			if err := revokeAssignmentTx(ctx, tx, item.EmployeeId, item.RoleId); err != nil {
+				return CampaignEntity{}, err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE certification_campaigns SET status = 'completed', completed_at = $2, updated_at = $2 WHERE id = $1",
+		campaign.Id, now,
+	)
+	if err != nil {
+		return CampaignEntity{}, err
+	}
+
+	var after CampaignEntity
+	if err := tx.GetContext(ctx, &after, selectCampaigns+"WHERE c.id = $1", campaign.Id); err != nil {
+		return CampaignEntity{}, err
+	}
+
+	return after, audit.InsertEventTx(ctx, tx, audit.Event{
+		Action:     audit.ActionStatusChange,
+		EntityType: audit.EntityCertificationCampaign,
+		EntityId:   campaign.Id,
+		Before:     before.ToResponse(),
+		After:      after.ToResponse(),
+	})
+}
+
+// revokeAssignmentTx - снять прямое назначение роли (если оно ещё есть) с событием аудита
+func revokeAssignmentTx(ctx context.Context, tx *sqlx.Tx, employeeId int64, roleId int64) error {
+	result, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM employee_roles WHERE employee_id = $1 AND role_id = $2",
+		employeeId, roleId,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return err
+	}
+
+	return outbox.RecordTx(ctx, tx, audit.Event{
+		Action:     audit.ActionRevoke,
+		EntityType: audit.EntityEmployeeRole,
+		EntityId:   employeeId,
+		Before:     audit.EmployeeRoleSnapshot{EmployeeId: employeeId, RoleId: roleId},
+	})
+}
@@ -0,0 +1,291 @@
+package certification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/web/middleware"
+	"strconv"
+	"time"
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+}
+
+type Repo interface {
+	FindAll(ctx context.Context) ([]CampaignEntity, error)
+	FindById(ctx context.Context, id int64) (CampaignEntity, error)
+	ExistsRoleById(ctx context.Context, roleId int64) (bool, error)
+	ExistsOrgUnitById(ctx context.Context, orgUnitId int64) (bool, error)
+	CreateCampaign(ctx context.Context, entity *CampaignEntity) (CampaignEntity, error)
+	FindItems(ctx context.Context, campaignId int64) ([]ItemEntity, error)
+	FindItemById(ctx context.Context, id int64) (ItemEntity, error)
+	FindPendingByReviewerId(ctx context.Context, reviewerId int64) ([]ItemEntity, error)
+	Certify(ctx context.Context, id int64, reviewerId int64, comment string) (bool, error)
+	Revoke(ctx context.Context, id int64, reviewerId int64, comment string) (bool, error)
+	CompleteDue(ctx context.Context, now time.Time) ([]CampaignEntity, error)
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+	}
+}
+
+// FindAll - все кампании с прогрессом проверки
+func (svc *Service) FindAll(ctx context.Context) ([]CampaignResponse, error) {
+	entities, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding certification campaigns: %w", err)
+	}
+
+	responses := make([]CampaignResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) FindById(ctx context.Context, id int64) (CampaignResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return CampaignResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findCampaign(ctx, id)
+	if err != nil {
+		return CampaignResponse{}, err
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// Create - начать кампанию: все действующие назначения ролей в охвате ставятся на проверку.
+// Создатель-сотрудник проверяет назначения, для которых нет ни руководителя, ни владельца роли
+func (svc *Service) Create(ctx context.Context, request CreateRequest) (CampaignResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return CampaignResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if !request.Deadline.After(time.Now()) {
+		return CampaignResponse{}, domain.RequestValidationError{Message: "deadline must be in the future"}
+	}
+
+	if err := svc.checkScope(ctx, request.ScopeType, request.ScopeId); err != nil {
+		return CampaignResponse{}, err
+	}
+
+	entity := request.ToEntity()
+	if creatorId, err := callerEmployeeId(ctx); err == nil {
+		entity.CreatedBy = &creatorId
+	}
+
+	created, err := svc.repo.CreateCampaign(ctx, &entity)
+	if err != nil {
+		return CampaignResponse{}, fmt.Errorf("error creating certification campaign: %w", err)
+	}
+
+	return svc.FindById(ctx, created.Id)
+}
+
+// FindItems - назначения кампании с решениями
+func (svc *Service) FindItems(ctx context.Context, campaignId int64) ([]ItemResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: campaignId}); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findCampaign(ctx, campaignId); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindItems(ctx, campaignId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding items of certification campaign %d: %w", campaignId, err)
+	}
+
+	return toItemResponses(entities), nil
+}
+
+// Export - результаты кампании в CSV
+func (svc *Service) Export(ctx context.Context, campaignId int64) ([]byte, error) {
+	items, err := svc.FindItems(ctx, campaignId)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ToCsv(items)
+	if err != nil {
+		return nil, fmt.Errorf("error exporting certification campaign %d: %w", campaignId, err)
+	}
+
+	return body, nil
+}
+
+// FindAwaitingReview - назначения, которые вызывающий сотрудник должен проверить
+func (svc *Service) FindAwaitingReview(ctx context.Context) ([]ItemResponse, error) {
+	reviewerId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindPendingByReviewerId(ctx, reviewerId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding certification items of reviewer %d: %w", reviewerId, err)
+	}
+
+	return toItemResponses(entities), nil
+}
+
+// Certify - проверяющий подтверждает назначение
+func (svc *Service) Certify(ctx context.Context, itemId int64, request DecisionRequest) (ItemResponse, error) {
+	return svc.decide(ctx, itemId, request, DecisionCertified, svc.repo.Certify)
+}
+
+// Revoke - проверяющий отзывает роль у сотрудника
+func (svc *Service) Revoke(ctx context.Context, itemId int64, request DecisionRequest) (ItemResponse, error) {
+	return svc.decide(ctx, itemId, request, DecisionRevoked, svc.repo.Revoke)
+}
+
+// CompleteDue - закрыть кампании с наступившим сроком (вызывается планировщиком)
+func (svc *Service) CompleteDue(ctx context.Context) ([]CampaignResponse, error) {
+	completed, err := svc.repo.CompleteDue(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("error completing certification campaigns: %w", err)
+	}
+
+	responses := make([]CampaignResponse, 0, len(completed))
+	for _, entity := range completed {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) decide(
+	ctx context.Context,
+	itemId int64,
+	request DecisionRequest,
+	decision string,
+	apply func(ctx context.Context, id int64, reviewerId int64, comment string) (bool, error),
+) (ItemResponse, error) {
+	request.Id = itemId
+	if err := svc.validator.Validate(request); err != nil {
+		return ItemResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	reviewerId, err := callerEmployeeId(ctx)
+	if err != nil {
+		return ItemResponse{}, err
+	}
+
+	item, err := svc.findItem(ctx, itemId)
+	if err != nil {
+		return ItemResponse{}, err
+	}
+	if item.ReviewerId == nil || *item.ReviewerId != reviewerId {
+		return ItemResponse{}, domain.ForbiddenError{
+			Message: fmt.Sprintf("certification item %d is not assigned to employee %d for review", itemId, reviewerId),
+		}
+	}
+	if item.Decision != DecisionPending || item.isClosed(time.Now()) {
+		return ItemResponse{}, notPendingError(itemId)
+	}
+
+	isDecided, err := apply(ctx, itemId, reviewerId, request.Comment)
+	if err != nil {
+		return ItemResponse{}, fmt.Errorf("error setting certification item %d to %s: %w", itemId, decision, err)
+	}
+	if !isDecided {
+		return ItemResponse{}, notPendingError(itemId)
+	}
+
+	decided, err := svc.findItem(ctx, itemId)
+	if err != nil {
+		return ItemResponse{}, err
+	}
+
+	return decided.ToResponse(), nil
+}
+
+// checkScope - роль или подразделение охвата должны существовать
+func (svc *Service) checkScope(ctx context.Context, scopeType string, scopeId int64) error {
+	var isExists = true
+	var err error
+	switch scopeType {
+	case ScopeRole:
+		isExists, err = svc.repo.ExistsRoleById(ctx, scopeId)
+	case ScopeOrgUnit:
+		isExists, err = svc.repo.ExistsOrgUnitById(ctx, scopeId)
+	}
+	if err != nil {
+		return fmt.Errorf("error checking %s with id %d: %w", scopeType, scopeId, err)
+	}
+	if !isExists {
+		return domain.NotFoundError{Message: fmt.Sprintf("%s with id %d not found", scopeType, scopeId)}
+	}
+
+	return nil
+}
+
+func (svc *Service) findCampaign(ctx context.Context, id int64) (CampaignEntity, error) {
+	entity, err := svc.repo.FindById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CampaignEntity{}, domain.NotFoundError{
+			Message: fmt.Sprintf("certification campaign with id %d not found", id),
+		}
+	}
+	if err != nil {
+		return CampaignEntity{}, fmt.Errorf("error finding certification campaign with id %d: %w", id, err)
+	}
+
+	return entity, nil
+}
+
+func (svc *Service) findItem(ctx context.Context, id int64) (ItemEntity, error) {
+	entity, err := svc.repo.FindItemById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ItemEntity{}, domain.NotFoundError{Message: fmt.Sprintf("certification item with id %d not found", id)}
+	}
+	if err != nil {
+		return ItemEntity{}, fmt.Errorf("error finding certification item with id %d: %w", id, err)
+	}
+
+	return entity, nil
+}
+
+func notPendingError(id int64) error {
+	return domain.ConflictError{
+		Message: fmt.Sprintf("certification item %d is already reviewed or its campaign is closed", id),
+	}
+}
+
+// callerEmployeeId - id вызывающего сотрудника; назначения проверяют только сотрудники
+func callerEmployeeId(ctx context.Context) (int64, error) {
+	var caller = common.CallerFromContext(ctx)
+	if caller.SubjectType == "" || caller.SubjectType == middleware.SubjectTypeEmployee {
+		if employeeId, err := strconv.ParseInt(caller.Subject, 10, 64); err == nil {
+			return employeeId, nil
+		}
+	}
+
+	return 0, domain.ForbiddenError{Message: "certification reviews are available to employees only"}
+}
+
+func toItemResponses(entities []ItemEntity) []ItemResponse {
+	responses := make([]ItemResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses
+}
@@ -0,0 +1,301 @@
+package certification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindAll(ctx context.Context) ([]CampaignEntity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]CampaignEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindById(ctx context.Context, id int64) (CampaignEntity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(CampaignEntity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsRoleById(ctx context.Context, roleId int64) (bool, error) {
+	args := m.Called(ctx, roleId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ExistsOrgUnitById(ctx context.Context, orgUnitId int64) (bool, error) {
+	args := m.Called(ctx, orgUnitId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) CreateCampaign(ctx context.Context, entity *CampaignEntity) (CampaignEntity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(CampaignEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindItems(ctx context.Context, campaignId int64) ([]ItemEntity, error) {
+	args := m.Called(ctx, campaignId)
+	return args.Get(0).([]ItemEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindItemById(ctx context.Context, id int64) (ItemEntity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(ItemEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindPendingByReviewerId(ctx context.Context, reviewerId int64) ([]ItemEntity, error) {
+	args := m.Called(ctx, reviewerId)
+	return args.Get(0).([]ItemEntity), args.Error(1)
+}
+
+func (m *MockRepo) Certify(ctx context.Context, id int64, reviewerId int64, comment string) (bool, error) {
+	args := m.Called(ctx, id, reviewerId, comment)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) Revoke(ctx context.Context, id int64, reviewerId int64, comment string) (bool, error) {
+	args := m.Called(ctx, id, reviewerId, comment)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) CompleteDue(ctx context.Context, now time.Time) ([]CampaignEntity, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]CampaignEntity), args.Error(1)
+}
+
+// asEmployee - контекст запроса от имени сотрудника, как его кладёт JwtAuthMiddleware
+func asEmployee(subject string) context.Context {
+	return common.WithCaller(context.Background(), common.Caller{Subject: subject, SubjectType: "employee"})
+}
+
+func TestCertificationService_Create(t *testing.T) {
+	var a = assert.New(t)
+	ctx := asEmployee("7")
+	deadline := time.Now().Add(14 * 24 * time.Hour)
+
+	t.Run("should create campaign for one role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "Q3 payments", ScopeType: ScopeRole, ScopeId: 5, Deadline: deadline, AutoRevoke: true}
+		created := CampaignEntity{Id: 100, Name: "Q3 payments", ScopeType: ScopeRole, Status: CampaignActive,
+			TotalItems: 3, PendingItems: 3}
+
+		validator.On("Validate", request).Return(nil).Once()
+		validator.On("Validate", FindByIDRequest{ID: 100}).Return(nil).Once()
+		repo.On("ExistsRoleById", ctx, int64(5)).Return(true, nil).Once()
+		repo.On("CreateCampaign", ctx, mock.MatchedBy(func(entity *CampaignEntity) bool {
+			return *entity.ScopeId == 5 && *entity.CreatedBy == 7 && entity.AutoRevoke
+		})).Return(created, nil).Once()
+		repo.On("FindById", ctx, int64(100)).Return(created, nil).Once()
+
+		got, err := service.Create(ctx, request)
+
+		a.Nil(err)
+		a.Equal(int64(3), got.Progress.Pending)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when org unit does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "Finance", ScopeType: ScopeOrgUnit, ScopeId: 9, Deadline: deadline}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsOrgUnitById", ctx, int64(9)).Return(false, nil).Once()
+
+		_, err := service.Create(ctx, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "CreateCampaign", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return validation error when deadline passed", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "Everyone", ScopeType: ScopeAll, Deadline: time.Now().Add(-time.Hour)}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		_, err := service.Create(ctx, request)
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "CreateCampaign", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCertificationService_Decisions(t *testing.T) {
+	var a = assert.New(t)
+	reviewerCtx := asEmployee("2")
+	var reviewerId = int64(2)
+	pending := ItemEntity{Id: 50, CampaignId: 100, EmployeeId: 1, RoleId: 5, ReviewerId: &reviewerId,
+		Decision: DecisionPending, Deadline: time.Now().Add(time.Hour), CampaignStatus: CampaignActive}
+
+	t.Run("should revoke assignment routed to caller", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := DecisionRequest{Id: 50, Comment: "no longer on the team"}
+		revoked := pending
+		revoked.Decision = DecisionRevoked
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindItemById", reviewerCtx, int64(50)).Return(pending, nil).Once()
+		repo.On("Revoke", reviewerCtx, int64(50), int64(2), request.Comment).Return(true, nil).Once()
+		repo.On("FindItemById", reviewerCtx, int64(50)).Return(revoked, nil).Once()
+
+		got, err := service.Revoke(reviewerCtx, 50, DecisionRequest{Comment: request.Comment})
+
+		a.Nil(err)
+		a.Equal(DecisionRevoked, got.Decision)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should forbid review by other employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		otherCtx := asEmployee("3")
+
+		validator.On("Validate", DecisionRequest{Id: 50}).Return(nil).Once()
+		repo.On("FindItemById", otherCtx, int64(50)).Return(pending, nil).Once()
+
+		_, err := service.Certify(otherCtx, 50, DecisionRequest{})
+
+		a.True(errors.As(err, &domain.ForbiddenError{}))
+		repo.AssertNotCalled(t, "Certify", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should forbid review by clients", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		clientCtx := common.WithCaller(context.Background(), common.Caller{Subject: "billing", SubjectType: "client"})
+
+		validator.On("Validate", DecisionRequest{Id: 50}).Return(nil).Once()
+
+		_, err := service.Certify(clientCtx, 50, DecisionRequest{})
+
+		a.True(errors.As(err, &domain.ForbiddenError{}))
+		repo.AssertNotCalled(t, "FindItemById", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return conflict when campaign deadline passed", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		overdue := pending
+		overdue.Deadline = time.Now().Add(-time.Minute)
+
+		validator.On("Validate", DecisionRequest{Id: 50}).Return(nil).Once()
+		repo.On("FindItemById", reviewerCtx, int64(50)).Return(overdue, nil).Once()
+
+		_, err := service.Certify(reviewerCtx, 50, DecisionRequest{})
+
+		a.True(errors.As(err, &domain.ConflictError{}))
+		repo.AssertNotCalled(t, "Certify", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return conflict when item decided concurrently", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", DecisionRequest{Id: 50}).Return(nil).Once()
+		repo.On("FindItemById", reviewerCtx, int64(50)).Return(pending, nil).Once()
+		repo.On("Certify", reviewerCtx, int64(50), int64(2), "").Return(false, nil).Once()
+
+		_, err := service.Certify(reviewerCtx, 50, DecisionRequest{})
+
+		a.True(errors.As(err, &domain.ConflictError{}))
+	})
+
+	t.Run("should return not found for missing item", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", DecisionRequest{Id: 50}).Return(nil).Once()
+		repo.On("FindItemById", reviewerCtx, int64(50)).Return(ItemEntity{}, sql.ErrNoRows).Once()
+
+		_, err := service.Certify(reviewerCtx, 50, DecisionRequest{})
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+}
+
+func TestCertificationService_Export(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	t.Run("should export campaign items as csv", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		var reviewerId = int64(2)
+		decidedAt := time.Date(2025, 7, 30, 12, 0, 0, 0, time.UTC)
+		items := []ItemEntity{
+			{Id: 50, EmployeeId: 1, EmployeeName: "Doe, Alice", RoleId: 5, RoleName: "DBA", ReviewerId: &reviewerId,
+				ReviewerName: "Bob", Decision: DecisionCertified, Comment: "ok", DecidedBy: &reviewerId, DecidedAt: &decidedAt},
+			{Id: 51, EmployeeId: 3, EmployeeName: "Carol", RoleId: 6, RoleName: "AUDITOR", Decision: DecisionExpired},
+		}
+
+		validator.On("Validate", FindByIDRequest{ID: 100}).Return(nil).Once()
+		repo.On("FindById", ctx, int64(100)).Return(CampaignEntity{Id: 100}, nil).Once()
+		repo.On("FindItems", ctx, int64(100)).Return(items, nil).Once()
+
+		got, err := service.Export(ctx, 100)
+
+		a.Nil(err)
+		a.Equal(strings.Join([]string{
+			"item_id,employee_id,employee_name,role_id,role_name,reviewer_id,reviewer_name,decision,comment,decided_by,decided_at",
+			`50,1,"Doe, Alice",5,DBA,2,Bob,certified,ok,2,2025-07-30T12:00:00Z`,
+			"51,3,Carol,6,AUDITOR,,,expired,,,",
+			"",
+		}, "\n"), string(got))
+	})
+
+	t.Run("should escape formula-like values in csv", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		items := []ItemEntity{
+			{Id: 50, EmployeeId: 1, EmployeeName: "@Alice", RoleId: 5, RoleName: "DBA", Decision: DecisionRevoked,
+				Comment: "=HYPERLINK(\"http://evil\",\"ok\")"},
+		}
+
+		validator.On("Validate", FindByIDRequest{ID: 100}).Return(nil).Once()
+		repo.On("FindById", ctx, int64(100)).Return(CampaignEntity{Id: 100}, nil).Once()
+		repo.On("FindItems", ctx, int64(100)).Return(items, nil).Once()
+
+		got, err := service.Export(ctx, 100)
+
+		a.Nil(err)
+		a.Contains(string(got), `50,1,'@Alice,5,DBA,,,revoked,"'=HYPERLINK(""http://evil"",""ok"")",,`)
+	})
+
+	t.Run("should return not found for missing campaign", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 100}).Return(nil).Once()
+		repo.On("FindById", ctx, int64(100)).Return(CampaignEntity{}, sql.ErrNoRows).Once()
+
+		_, err := service.Export(ctx, 100)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "FindItems", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,30 @@
+package common
+
+import "context"
+
+const (
+	SystemActor = "system" // исполнитель изменений, выполненных без аутентифицированного вызывающего
+)
+
+// Caller - кто выполняет запрос: subject токена, его тип и request_id
+type Caller struct {
+	Subject     string
+	SubjectType string
+	RequestId   string
+}
+
+type callerKey struct{}
+
+// WithCaller - положить вызывающего в контекст запроса
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext - вызывающий из контекста. Если его нет (фоновые задачи, публичные маршруты),
+// возвращается системный исполнитель
+func CallerFromContext(ctx context.Context) Caller {
+	if caller, ok := ctx.Value(callerKey{}).(Caller); ok && caller.Subject != "" {
+		return caller
+	}
+	return Caller{Subject: SystemActor, SubjectType: SystemActor}
+}
@@ -8,6 +8,22 @@ import (
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAccessTokenTtl        = 15 * time.Minute    // время жизни access token по умолчанию
+	defaultRefreshTokenTtl       = 30 * 24 * time.Hour // время жизни refresh token по умолчанию
+	defaultSoftDeleteTtl         = 30 * 24 * time.Hour // срок хранения мягко удалённых записей по умолчанию
+	defaultPurgeInterval         = time.Hour           // период запуска очистки мягко удалённых записей по умолчанию
+	defaultExpiryInterval        = time.Minute         // период отзыва истёкших назначений ролей по умолчанию
+	defaultAccessRequestTtl      = 7 * 24 * time.Hour  // срок рассмотрения заявки на роль по умолчанию
+	defaultCertificationInterval = 5 * time.Minute     // период закрытия кампаний ресертификации по сроку по умолчанию
+	defaultProvisioningInterval  = 30 * time.Second    // период доставки изменений в целевые системы по умолчанию
+	defaultReconcileInterval     = 24 * time.Hour      // период сверки целевых систем с локальными данными по умолчанию
+	defaultOutboxInterval        = time.Second         // период отправки доменных событий из outbox по умолчанию
+	defaultWebhookInterval       = 5 * time.Second     // период доставки событий подписчикам webhook по умолчанию
 )
 
 // Config - общая конфигурация всего приложения для БД
@@ -18,6 +34,35 @@ type Config struct {
 	AppVersion     string `validate:"required"` // Версия приложения
 	LogLevel       string
 	LogDevelopMode bool
+	// JWT - ключи проверки подписи токенов (должен быть задан хотя бы один, иначе все запросы к /api/v1 получат 401)
+	JwtHs256Secret    string // общий секрет для HS256
+	JwtRs256PublicKey string // публичный ключ для RS256: PEM-текст или путь к PEM-файлу
+	// приватный ключ для выпуска токенов RS256: PEM-текст или путь к PEM-файлу (если не задан - токены подписываются HS256)
+	JwtRs256PrivateKey string
+	JwtIssuer          string // ожидаемый iss (не проверяется, если пусто)
+	JwtAudience        string // ожидаемый aud (не проверяется, если пусто)
+	// время жизни выпускаемых токенов
+	JwtAccessTokenTtl  time.Duration
+	JwtRefreshTokenTtl time.Duration
+	// мягкое удаление: через сколько удалённые сотрудники и роли удаляются окончательно и как часто это проверять
+	SoftDeleteRetention time.Duration
+	PurgeInterval       time.Duration
+	// как часто отзывать назначения ролей с истёкшим сроком действия и закрывать просроченные заявки на роли
+	RoleExpiryInterval time.Duration
+	// сколько заявка на роль ждёт решения согласующего, прежде чем истечь
+	AccessRequestTtl time.Duration
+	// как часто закрывать кампании ресертификации, срок проверки которых наступил
+	CertificationInterval time.Duration
+	// как часто ставить изменения в очередь провижининга и доставлять их, и как часто сверять целевые системы
+	ProvisioningInterval time.Duration
+	ReconcileInterval    time.Duration
+	// как часто отправлять доменные события из outbox подписчикам
+	OutboxInterval time.Duration
+	// как часто доставлять события подписчикам webhook
+	WebhookInterval time.Duration
+	// секрет подписи курсоров keyset-пагинации (если пусто - случайный ключ процесса:
+	// курсоры не переживают перезапуск и не принимаются другими экземплярами сервиса)
+	PageCursorSecret string
 }
 
 //GetConfig
@@ -43,6 +88,27 @@ func GetConfig(envFile string) Config {
 		AppVersion:     os.Getenv("APP_VERSION"), //for example, see = .env file APP_VERSION
 		LogLevel:       os.Getenv("LOG_LEVEL"),
 		LogDevelopMode: os.Getenv("LOG_DEVELOP_MODE") == "true",
+
+		JwtHs256Secret:    os.Getenv("JWT_HS256_SECRET"),
+		JwtRs256PublicKey: os.Getenv("JWT_RS256_PUBLIC_KEY"),
+		JwtIssuer:         os.Getenv("JWT_ISSUER"),
+		JwtAudience:       os.Getenv("JWT_AUDIENCE"),
+
+		JwtRs256PrivateKey: os.Getenv("JWT_RS256_PRIVATE_KEY"),
+		JwtAccessTokenTtl:  getDuration("JWT_ACCESS_TOKEN_TTL", defaultAccessTokenTtl),
+		JwtRefreshTokenTtl: getDuration("JWT_REFRESH_TOKEN_TTL", defaultRefreshTokenTtl),
+
+		SoftDeleteRetention:   getDuration("SOFT_DELETE_RETENTION", defaultSoftDeleteTtl),
+		PurgeInterval:         getDuration("PURGE_INTERVAL", defaultPurgeInterval),
+		RoleExpiryInterval:    getDuration("ROLE_EXPIRY_INTERVAL", defaultExpiryInterval),
+		AccessRequestTtl:      getDuration("ACCESS_REQUEST_TTL", defaultAccessRequestTtl),
+		CertificationInterval: getDuration("CERTIFICATION_INTERVAL", defaultCertificationInterval),
+		ProvisioningInterval:  getDuration("PROVISIONING_INTERVAL", defaultProvisioningInterval),
+		ReconcileInterval:     getDuration("RECONCILE_INTERVAL", defaultReconcileInterval),
+		OutboxInterval:        getDuration("OUTBOX_INTERVAL", defaultOutboxInterval),
+		WebhookInterval:       getDuration("WEBHOOK_INTERVAL", defaultWebhookInterval),
+
+		PageCursorSecret: os.Getenv("PAGE_CURSOR_SECRET"),
 	}
 	log.Infof("GetConfig DB dsn: %v", cfg.Dsn)
 
@@ -56,3 +122,25 @@ func GetConfig(envFile string) Config {
 	}
 	return cfg
 }
+
+// getDuration - чтение длительности (например "15m", "720h") из переменной окружения, при отсутствии - значение по умолчанию
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		// некорректная длительность - ошибка конфигурации, паникуем как при валидации
+		panic(fmt.Sprintf("config validation error: invalid duration %s=%q", key, value))
+	}
+	return duration
+}
+
+// ReadPem - получение PEM-данных ключа: значение может быть PEM-текстом или путём к PEM-файлу
+func ReadPem(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// CursorBatchSize - число строк, выбираемых курсором за один FETCH
+const CursorBatchSize = 500
+
+// StreamCursor - пройти результат query серверным курсором name в отдельной транзакции только для чтения,
+// выбирая по CursorBatchSize строк: в памяти держится одна порция, а не вся таблица.
+// fn вызывается для каждой строки по порядку, её ошибка прерывает обход и возвращается как есть
+func StreamCursor[T any](
+	ctx context.Context,
+	db *sqlx.DB,
+	name string,
+	query string,
+	args []any,
+	fn func(row T) error,
+) error {
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	// изменений нет: завершение транзакции откатом закрывает и курсор
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, "DECLARE "+name+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("error declare cursor: %w", err)
+	}
+
+	var fetch = fmt.Sprintf("FETCH %d FROM %s", CursorBatchSize, name)
+	for {
+		var batch []T
+		if err = tx.SelectContext(ctx, &batch, fetch); err != nil {
+			return fmt.Errorf("error fetch cursor: %w", err)
+		}
+		for _, row := range batch {
+			if err = fn(row); err != nil {
+				return err
+			}
+		}
+		if len(batch) < CursorBatchSize {
+			return nil
+		}
+	}
+}
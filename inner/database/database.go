@@ -63,7 +63,12 @@ func RunMigrations(db *sql.DB) error {
 		}
 	}
 
-	// 3. Проверяем наличие новых миграций
+	// 3. Применяем новые миграции, появившиеся после первоначального запуска
+	if err := goose.Up(db, "./migrations"); err != nil {
+		return fmt.Errorf("failed to apply new migrations: %w", err)
+	}
+
+	// 4. Проверяем статус миграций
 	if err := goose.Status(db, "./migrations"); err != nil {
 		return fmt.Errorf("migration status check failed: %w", err)
 	}
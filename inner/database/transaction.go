@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// WithTx - выполнить fn в транзакции: commit, если fn завершилась без ошибки, иначе rollback.
+// Паника внутри fn откатывает транзакцию и пробрасывается дальше
+func WithTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+		if err != nil {
+			if errTx := tx.Rollback(); errTx != nil {
+				err = fmt.Errorf("rolling back transaction errors: %w, %w", err, errTx)
+			}
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error commit transaction: %w", err)
+	}
+	return nil
+}
+
+// WithSavepoint - выполнить fn внутри точки сохранения транзакции tx: ошибка fn откатывает только
+// сделанное в fn, транзакция остаётся рабочей. Ошибка fn возвращается как есть
+func WithSavepoint(ctx context.Context, tx *sqlx.Tx, name string, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("error create savepoint: %w", err)
+	}
+
+	if err := fn(); err != nil {
+		if _, errTx := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); errTx != nil {
+			return fmt.Errorf("rolling back to savepoint errors: %w, %w", err, errTx)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("error release savepoint: %w", err)
+	}
+	return nil
+}
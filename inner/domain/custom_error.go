@@ -23,6 +23,15 @@ func (err AlreadyExistsError) Error() string {
 	return err.Message
 }
 
+// ConflictError - операция недопустима в текущем состоянии объекта (например, запрещённый переход состояния)
+type ConflictError struct {
+	Message string
+}
+
+func (err ConflictError) Error() string {
+	return err.Message
+}
+
 // Унифицированная структура для API ошибок
 type APIError struct {
 	Code    int    `json:"code"`    // HTTP-статус код
@@ -59,3 +68,30 @@ type NotFoundError struct {
 func (err NotFoundError) Error() string {
 	return err.Message
 }
+
+// UnauthorizedError - ошибка аутентификации (неверные учётные данные или токен)
+type UnauthorizedError struct {
+	Message string
+}
+
+func (err UnauthorizedError) Error() string {
+	return err.Message
+}
+
+// ForbiddenError - вызывающий известен, но не может выполнить операцию над этим объектом
+type ForbiddenError struct {
+	Message string
+}
+
+func (err ForbiddenError) Error() string {
+	return err.Message
+}
+
+// SodViolationError - операция нарушает правило разделения полномочий (segregation of duties)
+type SodViolationError struct {
+	Message string
+}
+
+func (err SodViolationError) Error() string {
+	return err.Message
+}
@@ -1,6 +1,8 @@
 package employee
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -9,19 +11,25 @@ import (
 	"go.uber.org/zap"
 	"idm/inner/common"
 	"idm/inner/domain"
+	"idm/inner/export"
 	"idm/inner/http"
 	"idm/inner/web"
+	"io"
+	"mime"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
 const (
 	invalidRequestFormat    = "Invalid request format"
-	validationFailed        = "validate name error"
 	internalServerError     = "Internal server error"
 	invalidIDFormat         = "Invalid ID format"
 	invalidRequestBody      = "Invalid request body"
 	invalidPageValuesFormat = "Invalid Page Values format"
+	invalidDepthFormat      = "Invalid depth format"
+	invalidDryRunFlag       = "Invalid dryRun flag"
+	invalidImportFile       = "Invalid import file"
 )
 
 // Controller (transport layer):
@@ -33,17 +41,27 @@ type Controller struct {
 
 // Svc - интерфейс сервиса Service class
 type Svc interface {
-	FindAll(ctx context.Context) ([]Response, error)
-	FindById(ctx context.Context, id int64) (Response, error)
-	FindAllByIds(ctx context.Context, ids []int64) ([]Response, error)
+	FindAll(ctx context.Context, includeDeleted bool) ([]Response, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (Response, error)
+	FindAllByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Response, error)
 	GetAllByPage(ctx context.Context, req PageRequest) (PageResponse, error)
+	GetKeysetPage(ctx context.Context, request KeysetRequest) (KeysetPage, error)
 	CreateEmployee(ctx context.Context, request CreateRequest) (Response, error)
 	CreateEmployeeTx(ctx context.Context, request CreateRequest) (int64, error)
 	UpdateEmployee(ctx context.Context, id int64, request UpdateRequest) (Response, error)
 	DeleteById(ctx context.Context, id int64) (Response, error)
 	DeleteByIds(ctx context.Context, ids []int64) (Response, error)
+	Restore(ctx context.Context, id int64) (Response, error)
+	ChangeStatus(ctx context.Context, id int64, action string) (Response, error)
+	FindReports(ctx context.Context, id int64, depth int64) ([]HierarchyResponse, error)
+	FindChainOfCommand(ctx context.Context, id int64) ([]HierarchyResponse, error)
 	FindEmployeeByNameTx(ctx context.Context, name string) (bool, error)
 	CloseTx(*sqlx.Tx, error, string)
+	FindRoles(ctx context.Context, employeeId int64) ([]RoleResponse, error)
+	AssignRole(ctx context.Context, employeeId int64, request AssignRoleRequest) ([]RoleResponse, error)
+	RevokeRole(ctx context.Context, employeeId int64, roleId int64) ([]RoleResponse, error)
+	Import(ctx context.Context, request ImportRequest, body io.Reader) (ImportResponse, error)
+	Export(ctx context.Context, request ExportRequest) (func(w io.Writer) error, error)
 }
 
 // NewController - функция-конструктор
@@ -62,15 +80,29 @@ func NewController(
 // RegisterRoutes - функция для регистрации маршрутов
 func (c *Controller) RegisterRoutes() {
 	// полный маршрут получится "/transport/v1/employees"
-	c.server.GroupEmployees.Post("/", c.CreateEmployee)
-	c.server.GroupEmployees.Get("/", c.FindAll)
-	c.server.GroupEmployees.Get("/ids", c.FindAllByIds)
-	c.server.GroupEmployees.Get("/page", c.GetAllPages)
-	c.server.GroupEmployees.Delete("/ids", c.DeleteByIds)
-	c.server.GroupEmployees.Post("/tx", c.CreateEmployeeTx)
-	c.server.GroupEmployees.Get("/:id", c.FindById)
-	c.server.GroupEmployees.Put("/:id", c.Update)
-	c.server.GroupEmployees.Delete("/:id", c.DeleteById)
+	c.server.GroupEmployees.Post("/", c.server.Require(web.PermEmployeesWrite), c.CreateEmployee)
+	// мягко удалённых сотрудников (?includeDeleted=true) видят только те, кто может удалять
+	var readDeleted = c.server.RequireWhen(web.IncludeDeleted, web.PermEmployeesDelete)
+	c.server.GroupEmployees.Get("/", c.server.Require(web.PermEmployeesRead), readDeleted, c.FindAll)
+	c.server.GroupEmployees.Get("/ids", c.server.Require(web.PermEmployeesRead), readDeleted, c.FindAllByIds)
+	c.server.GroupEmployees.Get("/page", c.server.Require(web.PermEmployeesRead), readDeleted, c.GetAllPages)
+	c.server.GroupEmployees.Get("/export", c.server.Require(web.PermEmployeesRead), readDeleted, c.Export)
+	c.server.GroupEmployees.Delete("/ids", c.server.Require(web.PermEmployeesDelete), c.DeleteByIds)
+	c.server.GroupEmployees.Post("/tx", c.server.Require(web.PermEmployeesWrite), c.CreateEmployeeTx)
+	c.server.GroupEmployees.Post("/import", c.server.Require(web.PermEmployeesWrite), c.Import)
+	c.server.GroupEmployees.Get("/:id", c.server.Require(web.PermEmployeesRead), readDeleted, c.FindById)
+	c.server.GroupEmployees.Put("/:id", c.server.Require(web.PermEmployeesWrite), c.Update)
+	c.server.GroupEmployees.Delete("/:id", c.server.Require(web.PermEmployeesDelete), c.DeleteById)
+	c.server.GroupEmployees.Post("/:id/restore", c.server.Require(web.PermEmployeesDelete), c.Restore)
+	c.server.GroupEmployees.Post("/:id/activate", c.server.Require(web.PermEmployeesLifecycle), c.Activate)
+	c.server.GroupEmployees.Post("/:id/suspend", c.server.Require(web.PermEmployeesLifecycle), c.Suspend)
+	c.server.GroupEmployees.Post("/:id/terminate", c.server.Require(web.PermEmployeesLifecycle), c.Terminate)
+	c.server.GroupEmployees.Post("/:id/rehire", c.server.Require(web.PermEmployeesLifecycle), c.Rehire)
+	c.server.GroupEmployees.Get("/:id/reports", c.server.Require(web.PermEmployeesRead), c.FindReports)
+	c.server.GroupEmployees.Get("/:id/chain-of-command", c.server.Require(web.PermEmployeesRead), c.FindChainOfCommand)
+	c.server.GroupEmployees.Get("/:id/roles", c.server.Require(web.PermEmployeesRead), c.FindRoles)
+	c.server.GroupEmployees.Post("/:id/roles", c.server.Require(web.PermRolesAssign), c.AssignRole)
+	c.server.GroupEmployees.Delete("/:id/roles/:roleId", c.server.Require(web.PermRolesAssign), c.RevokeRole)
 }
 
 // -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/employees" --//
@@ -84,6 +116,7 @@ func (c *Controller) RegisterRoutes() {
 // @Param 		 request body 	employee.CreateRequest true "Employee creation details"
 // @Success 	 200  {object}  employee.Response	"Employee response"
 // @Failure      400  {object}  http.Response		"Bad request"
+// @Failure      409  {object}  http.Response		"Login or email already taken"
 // @Failure      500  {object}  http.Response		"Bad request"
 // @Router 		 /employees/ 	[post]
 func (c *Controller) CreateEmployee(ctx *fiber.Ctx) error {
@@ -121,9 +154,9 @@ func (c *Controller) CreateEmployee(ctx *fiber.Ctx) error {
 
 		switch { // Обработка ошибок с использованием ваших функций
 		case errors.As(err, &domain.RequestValidationError{}):
-			return http.ErrResponse(ctx, fiber.StatusBadRequest, validationFailed)
+			return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
 
-		case errors.Is(err, domain.ErrConflict):
+		case errors.As(err, &domain.AlreadyExistsError{}), errors.Is(err, domain.ErrConflict):
 			return http.ErrResponse(ctx, fiber.StatusConflict, "Employee already exists")
 
 		default:
@@ -142,6 +175,7 @@ func (c *Controller) CreateEmployee(ctx *fiber.Ctx) error {
 // @Accept  	 json
 // @Produce 	 json
 // @Param 		 id   path      	int  true  			"Employee ID"
+// @Param 		 includeDeleted query bool false 	"include soft deleted employee (requires employees:delete)"
 // @Success 	 200  {object}  	employee.Response	"Employee response"
 // @Failure      400  {object}  	http.Response		"Bad request"
 // @Failure      500  {object}  	http.Response		"Bad request"
@@ -165,7 +199,7 @@ func (c *Controller) FindById(ctx *fiber.Ctx) error {
 		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
 	}
 
-	response, err := c.employeeService.FindById(appContext, employeeID)
+	response, err := c.employeeService.FindById(appContext, employeeID, web.IncludeDeleted(ctx))
 	if err != nil {
 		c.logger.Error(
 			"When the get Employee ended with an error:",
@@ -181,12 +215,16 @@ func (c *Controller) FindById(ctx *fiber.Ctx) error {
 		}
 	}
 
-	return http.OkResponse(ctx, map[string]interface{}{
+	var data = map[string]interface{}{
 		"id":       response.Id,
 		"name":     response.Name,
 		"createAt": response.CreateAt,
 		"updateAt": response.UpdateAt,
-	})
+	}
+	if response.DeleteAt != nil {
+		data["deleteAt"] = response.DeleteAt
+	}
+	return http.OkResponse(ctx, data)
 }
 
 // GetAllPages   godoc
@@ -198,6 +236,7 @@ func (c *Controller) FindById(ctx *fiber.Ctx) error {
 // @Param   	 pageNumber 		query	string	false	"string valid"       minlength(1)  maxlength(10)
 // @Param   	 pageSize 			query   string 	false  	"string valid"       minlength(1)  maxlength(155)
 // @Param   	 textFilter 		query   string  false  	"string valid"       minlength(0)  maxlength(10)
+// @Param   	 includeDeleted 	query   bool  	false  	"include soft deleted employees (requires employees:delete)"
 // @Success 	 200  {object} 		employee.Response		"Employee request"
 // @Failure      400  {object}  	http.Response			"Bad request"
 // @Failure      500  {object}  	http.Response			"Bad request"
@@ -221,9 +260,10 @@ func (c *Controller) GetAllPages(ctx *fiber.Ctx) error {
 	}
 
 	req := PageRequest{
-		PageNumber: pageValues[0],
-		PageSize:   pageValues[1],
-		TextFilter: textFilter,
+		PageNumber:     pageValues[0],
+		PageSize:       pageValues[1],
+		TextFilter:     textFilter,
+		IncludeDeleted: web.IncludeDeleted(ctx),
 	}
 
 	response, err := c.employeeService.GetAllByPage(appContext, req)
@@ -245,6 +285,54 @@ func (c *Controller) GetAllPages(ctx *fiber.Ctx) error {
 	return http.OkPageResponse(ctx, response)
 }
 
+// Export   	 godoc
+// @Description  Export Employees with names of directly assigned roles as CSV, NDJSON or XLSX file.
+// @Description  Rows are streamed from a server-side cursor ordered by id; textFilter matches the name like in paged search
+// @Summary		 export employees
+// @Tags 		 employee
+// @Produce 	 text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param   	 format 			query   string  false  	"File format"	Enums(csv, ndjson, xlsx)	default(csv)
+// @Param   	 textFilter 		query   string  false  	"Name substring, at least 3 characters"
+// @Param   	 includeDeleted 	query   bool  	false  	"include soft deleted employees (requires employees:delete)"
+// @Success 	 200  {file} 		file					"Export file"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /employees/export 	[get]
+func (c *Controller) Export(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext()                // получаем контекст приложения из запроса (задаем ранее в App main())
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request = ExportRequest{
+		Format:         ctx.Query("format", export.CSV),
+		TextFilter:     ctx.Query("textFilter", ""),
+		IncludeDeleted: web.IncludeDeleted(ctx),
+	}
+	c.checkForInjectionAttempt(request.TextFilter, requestId)
+
+	write, err := c.employeeService.Export(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the export Employees ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.StreamResponse(ctx, export.Filename("employees", request.Format), export.ContentType(request.Format),
+		func(w *bufio.Writer) {
+			if err := write(w); err != nil {
+				c.logger.Error(
+					"When the stream Employees export ended with an error:",
+					zap.Error(err),
+					zap.String("request_id", requestId),
+				)
+			}
+		},
+	)
+}
+
 // Логирование подозрительных запросов
 func (c *Controller) checkForInjectionAttempt(input string, requestId string) {
 	if strings.ContainsAny(input, ";'\"\\--") {
@@ -333,22 +421,33 @@ func (c *Controller) checkNotNullRequestParam(
 }
 
 // FindAll   	 godoc
-// @Description  Find all Employees
+// @Description  Find all Employees. With any of after, before, limit, sort or withTotal
+// @Description  returns a keyset page (employee.KeysetPageResponse) ordered by (sort, id) instead of the full array
 // @Summary		 get all employees
 // @Tags 		 employee
 // @Accept  	 json
 // @Produce 	 json
+// @Param   	 includeDeleted 	query   bool  	false  	"include soft deleted employees (requires employees:delete)"
+// @Param   	 after 			query   string  false  	"opaque cursor from the next link"
+// @Param   	 before 		query   string  false  	"opaque cursor from the prev link"
+// @Param   	 limit 			query   int  	false  	"page size"	minimum(1)	maximum(500)	default(50)
+// @Param   	 sort 			query   string  false  	"sort key, '-' for descending"	Enums(id, -id, name, -name, createdAt, -createdAt)	default(id)
+// @Param   	 textFilter 	query   string  false  	"name substring (keyset mode only)"	minlength(3)
+// @Param   	 withTotal 		query   bool  	false  	"count employees matching the filters"
 // @Success 	 200  {array}  		employee.Response	"Employee response"
 // @Failure      400  {object}  	http.Response		"Bad request"
 // @Failure      500  {object}  	http.Response		"Bad request"
 // @Router 		 /employees/		[get]
 func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	if web.KeysetRequested(ctx) {
+		return c.findKeysetPage(ctx)
+	}
 	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
 
 	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
 	c.logger.Info("find all employees", zap.String("request_id", requestId))
 
-	response, err := c.employeeService.FindAll(appContext)
+	response, err := c.employeeService.FindAll(appContext, web.IncludeDeleted(ctx))
 	if err != nil {
 		c.logger.Error(
 			"When the find for ALl Employees ended with an error:",
@@ -366,6 +465,56 @@ func (c *Controller) FindAll(ctx *fiber.Ctx) error {
 	return http.OkResponse(ctx, response)
 }
 
+// findKeysetPage - keyset-выдача FindAll: страница сотрудников со ссылками next и prev
+func (c *Controller) findKeysetPage(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext()
+	requestId := ctx.Locals("request_id").(string)
+
+	keyset, err := c.server.ParseKeyset(ctx, web.EmployeesPath)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an employees keyset request ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	}
+	c.logger.Info("find employees keyset page", zap.String("request_id", requestId), zap.Any("keyset", keyset))
+
+	page, err := c.employeeService.GetKeysetPage(appContext, KeysetRequest{
+		Limit:          keyset.Limit,
+		Sort:           keyset.Sort,
+		TextFilter:     keyset.TextFilter,
+		IncludeDeleted: keyset.IncludeDeleted,
+		WithTotal:      keyset.WithTotal,
+		After:          keyset.After,
+		Before:         keyset.Before,
+	})
+	if err != nil {
+		c.logger.Error(
+			"When the find employees keyset page ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		switch {
+		case errors.As(err, &domain.RequestValidationError{}):
+			return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+		default:
+			return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+		}
+	}
+
+	next, prev := c.server.KeysetLinks(ctx, keyset, page.Next, page.Prev)
+	return http.OkResponse(ctx, KeysetPageResponse{
+		Result: page.Result,
+		Limit:  keyset.Limit,
+		Next:   next,
+		Prev:   prev,
+		Total:  page.Total,
+	})
+}
+
 // FindAllByIds  godoc
 // @Description  Find all Employees by IDs
 // @Summary		 get all employees by IDs
@@ -373,6 +522,7 @@ func (c *Controller) FindAll(ctx *fiber.Ctx) error {
 // @Accept  	 json
 // @Produce 	 json
 // @Param   	 ids  query     	string	true  		"Employees ids string values"       minlength(1)
+// @Param   	 includeDeleted 	query   bool  	false  	"include soft deleted employees (requires employees:delete)"
 // @Success 	 200  {array}  		employee.Response	"Employee response"
 // @Failure      400  {object}  	http.Response		"Bad request"
 // @Failure      500  {object}  	http.Response		"Bad request"
@@ -408,7 +558,7 @@ func (c *Controller) FindAllByIds(ctx *fiber.Ctx) error {
 	}
 	c.logger.Info("find by ids", zap.String("request_id", requestId), zap.Any("ids", ids))
 
-	response, err := c.employeeService.FindAllByIds(appContext, ids)
+	response, err := c.employeeService.FindAllByIds(appContext, ids, web.IncludeDeleted(ctx))
 	if err != nil {
 		c.logger.Error(
 			"When the search for all employees by identifiers ended with an error: %s",
@@ -437,6 +587,7 @@ func (c *Controller) FindAllByIds(ctx *fiber.Ctx) error {
 // @Param   	 request 			body     	employee.UpdateRequest	true  	"Employee updated details"
 // @Success 	 200  				{object}  	employee.Response				"Employee response"
 // @Failure      400  				{object}  	http.Response					"Bad request"
+// @Failure      409  				{object}  	http.Response					"Login or email already taken"
 // @Failure      500  				{object}  	http.Response					"Bad request"
 // @Router 		 /employees/{id} 	[put]
 func (c *Controller) Update(ctx *fiber.Ctx) error {
@@ -484,6 +635,8 @@ func (c *Controller) Update(ctx *fiber.Ctx) error {
 		switch {
 		case errors.As(err, &domain.RequestValidationError{}):
 			return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+		case errors.As(err, &domain.AlreadyExistsError{}):
+			return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
 		default:
 			return http.ErrResponse(ctx, fiber.StatusInternalServerError, err.Error())
 		}
@@ -543,6 +696,153 @@ func (c *Controller) DeleteById(ctx *fiber.Ctx) error {
 	return http.OkResponse(ctx, response)
 }
 
+// Restore 	 godoc
+// @Description  Restore soft deleted Employee by ID together with its role assignments
+// @Summary		 restore employee by ID
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  				true	"Employee ID" 		min(1)
+// @Success 	 200  {object} 		employee.Response			"Employee response"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /employees/{id}/restore	[post]
+func (c *Controller) Restore(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	employeeID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Restore Employee request param ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.employeeService.Restore(appContext, employeeID)
+	if err != nil {
+		c.logger.Error(
+			"When the restore Employee ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Activate 	 godoc
+// @Description  Activate pre-hire or suspended Employee
+// @Summary		 activate employee
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  				true	"Employee ID" 		min(1)
+// @Success 	 200  {object} 		employee.Response			"Employee response"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      409  {object}  	http.Response				"Transition is not allowed"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /employees/{id}/activate	[post]
+func (c *Controller) Activate(ctx *fiber.Ctx) error {
+	return c.changeStatus(ctx, LifecycleActivate)
+}
+
+// Suspend 	 godoc
+// @Description  Suspend active Employee, role assignments are kept
+// @Summary		 suspend employee
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  				true	"Employee ID" 		min(1)
+// @Success 	 200  {object} 		employee.Response			"Employee response"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      409  {object}  	http.Response				"Transition is not allowed"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /employees/{id}/suspend	[post]
+func (c *Controller) Suspend(ctx *fiber.Ctx) error {
+	return c.changeStatus(ctx, LifecycleSuspend)
+}
+
+// Terminate 	 godoc
+// @Description  Terminate Employee and revoke all its role assignments
+// @Summary		 terminate employee
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  				true	"Employee ID" 		min(1)
+// @Success 	 200  {object} 		employee.Response			"Employee response"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      409  {object}  	http.Response				"Transition is not allowed"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /employees/{id}/terminate	[post]
+func (c *Controller) Terminate(ctx *fiber.Ctx) error {
+	return c.changeStatus(ctx, LifecycleTerminate)
+}
+
+// Rehire 	 godoc
+// @Description  Rehire terminated Employee back into pre-hire status
+// @Summary		 rehire employee
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  				true	"Employee ID" 		min(1)
+// @Success 	 200  {object} 		employee.Response			"Employee response"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      409  {object}  	http.Response				"Transition is not allowed"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /employees/{id}/rehire	[post]
+func (c *Controller) Rehire(ctx *fiber.Ctx) error {
+	return c.changeStatus(ctx, LifecycleRehire)
+}
+
+// changeStatus - общий обработчик действий жизненного цикла сотрудника
+func (c *Controller) changeStatus(ctx *fiber.Ctx, action string) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	employeeID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an employee lifecycle request param ended with an error:",
+			zap.Error(err),
+			zap.String("action", action),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.employeeService.ChangeStatus(appContext, employeeID, action)
+	if err != nil {
+		c.logger.Error(
+			"When the change Employee status ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", employeeID),
+			zap.String("action", action),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
 // DeleteByIds  godoc
 // @Description  Find all Employees by IDs
 // @Summary		 get all employees by IDs
@@ -653,3 +953,371 @@ func (c *Controller) CreateEmployeeTx(ctx *fiber.Ctx) error {
 
 	return http.OkResponse(ctx, response)
 }
+
+// FindReports 	 godoc
+// @Description  Find direct and indirect reports of employee up to depth levels, nearest first
+// @Summary 	 find employee reports
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   	path      	int  true  					"Employee ID"
+// @Param 		 depth 	query     	int  false  				"Depth (1 - direct reports only)" default(1) minimum(1) maximum(20)
+// @Success 	 200  {array}  		employee.HierarchyResponse	"Employee reports"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /employees/{id}/reports 	[get]
+func (c *Controller) FindReports(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	employeeID, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Find Employee Reports request param ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	depth, err := strconv.ParseInt(ctx.Query("depth", "1"), 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Find Employee Reports depth ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidDepthFormat)
+	}
+
+	response, err := c.employeeService.FindReports(appContext, employeeID, depth)
+	if err != nil {
+		c.logger.Error(
+			"When the find Employee Reports ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindChainOfCommand godoc
+// @Description  Find managers of employee from the direct manager up to the top
+// @Summary 	 find employee chain of command
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  					"Employee ID"
+// @Success 	 200  {array}  		employee.HierarchyResponse	"Employee managers"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /employees/{id}/chain-of-command 	[get]
+func (c *Controller) FindChainOfCommand(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	employeeID, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Find Employee Chain Of Command request param ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.employeeService.FindChainOfCommand(appContext, employeeID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Employee Chain Of Command ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindRoles 	 godoc
+// @Description  Find roles assigned to employee
+// @Summary 	 find employee roles
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  				"Employee ID"
+// @Success 	 200  {array}  		employee.RoleResponse	"Employee roles"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /employees/{id}/roles 	[get]
+func (c *Controller) FindRoles(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	employeeID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Find Employee Roles request param ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.employeeService.FindRoles(appContext, employeeID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Employee Roles ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// AssignRole 	 godoc
+// @Description  Assign role to employee
+// @Summary 	 assign role to employee
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  						true  	"Employee ID"
+// @Param 		 request 	body 		employee.AssignRoleRequest 	true 	"Role assignment details"
+// @Success 	 200  {array}  		employee.RoleResponse	"Employee roles"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      409  {object}  	http.Response			"Employee terminated or segregation of duties violated"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /employees/{id}/roles 	[post]
+func (c *Controller) AssignRole(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	employeeID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Assign Role request param ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request AssignRoleRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the parse an Assign Role request body ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.employeeService.AssignRole(appContext, employeeID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the assign Role to Employee ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", employeeID),
+			zap.Int64("role_id", request.RoleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// RevokeRole 	 godoc
+// @Description  Revoke role from employee
+// @Summary 	 revoke role from employee
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  	true  	"Employee ID"
+// @Param 		 roleId   	path      	int  	true  	"Role ID"
+// @Success 	 200  {array}  		employee.RoleResponse	"Employee roles"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /employees/{id}/roles/{roleId} 	[delete]
+func (c *Controller) RevokeRole(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	employeeID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Revoke Role request param ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	roleIdStr := ctx.Params("roleId")
+	roleID, err := strconv.ParseInt(roleIdStr, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an Revoke Role request param ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.employeeService.RevokeRole(appContext, employeeID, roleID)
+	if err != nil {
+		c.logger.Error(
+			"When the revoke Role from Employee ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", employeeID),
+			zap.Int64("role_id", roleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Import 	 godoc
+// @Description  Import Employees from CSV (header row with column names) or NDJSON file, matched by login:
+// @Description  new logins are created, existing employees are updated with the given attributes only.
+// @Description  Columns: name, login, email, department, title, managerId, startDate, endDate, phone, status
+// @Description  (status applies to new employees only). The file is sent as multipart field "file" or as request body.
+// @Description  In atomic mode a failed row rolls back the whole import, in best_effort mode only that row;
+// @Description  dryRun validates all rows and rolls back. Row errors are returned in the report with status 200.
+// @Description  The request body (file included) is read into memory and limited to 4 MB, larger requests get 413
+// @Summary		 import employees
+// @Tags 		 employee
+// @Accept  	 text/csv,application/x-ndjson,multipart/form-data
+// @Produce 	 json
+// @Param        format	query	string	false	"File format, by default from Content-Type or file extension"	Enums(csv, ndjson)
+// @Param        mode	query	string	false	"Import mode"	Enums(atomic, best_effort)	default(atomic)
+// @Param        dryRun	query	bool	false	"Validate without saving"
+// @Param        file	formData	file	false	"Import file"
+// @Success 	 200  {object} 		employee.ImportResponse		"Import report"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      413  {object}  	http.Response				"Request body exceeds the limit"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /employees/import	[post]
+func (c *Controller) Import(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	dryRun, err := strconv.ParseBool(ctx.Query("dryRun", "false"))
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidDryRunFlag)
+	}
+	var request = ImportRequest{Format: ctx.Query("format"), Mode: ctx.Query("mode", ImportAtomic), DryRun: dryRun}
+
+	var body io.Reader
+	mediaType, _, _ := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	if mediaType == fiber.MIMEMultipartForm {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			c.logger.Error(
+				"When the read an Import Employees file ended with an error:",
+				zap.Error(err),
+				zap.String("request_id", requestId),
+			)
+
+			return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidImportFile)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidImportFile)
+		}
+		defer file.Close()
+		body = file
+		if request.Format == "" {
+			request.Format = importFormat(header.Header.Get(fiber.HeaderContentType), header.Filename)
+		}
+	} else {
+		// тело уже прочитано в память целиком, его размер ограничен web.MaxRequestBodySize
+		body = bytes.NewReader(ctx.Body())
+		if request.Format == "" {
+			request.Format = importFormat(mediaType, "")
+		}
+	}
+
+	response, err := c.employeeService.Import(appContext, request, body)
+	if err != nil {
+		c.logger.Error(
+			"When the import Employees ended with an error:",
+			zap.Error(err),
+			zap.String("format", request.Format),
+			zap.String("mode", request.Mode),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// importFormat - формат файла по Content-Type, иначе по расширению имени файла
+func importFormat(contentType string, filename string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return ImportCsv
+	case "application/x-ndjson", "application/ndjson":
+		return ImportNdjson
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ImportCsv
+	case ".ndjson", ".jsonl":
+		return ImportNdjson
+	}
+	return ""
+}
+
+// assignmentErrResponse - маппинг доменных ошибок назначения ролей, восстановления и смены состояния в HTTP-статусы
+func (c *Controller) assignmentErrResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.ConflictError{}), errors.As(err, &domain.SodViolationError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
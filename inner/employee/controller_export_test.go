@@ -0,0 +1,111 @@
+package employee
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestEmployeeController_Export(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockEmployeeService)
+
+	server := &web.Server{
+		App:            app,
+		GroupEmployees: app.Group("/api/v1/employees"),
+	}
+	ctrl := NewController(server, mockService, logger)
+
+	server.GroupEmployees.Get("/export", ctrl.Export)
+	server.GroupEmployees.Get("/:id", ctrl.FindById)
+
+	t.Run("should stream csv attachment", func(t *testing.T) {
+		var request = ExportRequest{Format: "csv", TextFilter: "John"}
+		var write = func(w io.Writer) error {
+			_, err := io.WriteString(w, "id,name\n1,John Sena\n")
+			return err
+		}
+		mockService.On("Export", appContext, request).Return(write, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/export?textFilter=John", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, `attachment; filename="employees.csv"`, resp.Header.Get(fiber.HeaderContentDisposition))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "id,name\n1,John Sena\n", string(body))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return xlsx content type", func(t *testing.T) {
+		var request = ExportRequest{Format: "xlsx"}
+		mockService.On("Export", appContext, request).Return(func(w io.Writer) error { return nil }, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/export?format=xlsx", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t,
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			resp.Header.Get(fiber.HeaderContentType),
+		)
+		assert.Equal(t, `attachment; filename="employees.xlsx"`, resp.Header.Get(fiber.HeaderContentDisposition))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return bad request before streaming", func(t *testing.T) {
+		var request = ExportRequest{Format: "pdf"}
+		mockService.On("Export", appContext, request).
+			Return(nil, domain.RequestValidationError{Message: "Format must be one of csv ndjson xlsx"}).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/export?format=pdf", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var result struct {
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "Format")
+		mockService.AssertExpectations(t)
+	})
+}
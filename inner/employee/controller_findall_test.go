@@ -67,7 +67,7 @@ LOG_DEVELOP_MODE=true`
 
 	// 1. Успешный запрос с данными
 	t.Run("SuccessWithData", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{testEmployee}, nil).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{testEmployee}, nil).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/employees/", nil)
 		resp, err := app.Test(req)
@@ -97,7 +97,7 @@ LOG_DEVELOP_MODE=true`
 
 	// 2. Успешный запрос без данных
 	t.Run("SuccessEmpty", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{}, nil).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{}, nil).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/employees/", nil)
 		resp, err := app.Test(req)
@@ -127,7 +127,7 @@ LOG_DEVELOP_MODE=true`
 
 	// 3. Ошибка поиска
 	t.Run("FindAllFailed", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{}, domain.ErrFindAllFailed).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{}, domain.ErrFindAllFailed).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/employees/", nil)
 		resp, err := app.Test(req)
@@ -159,7 +159,7 @@ LOG_DEVELOP_MODE=true`
 
 	// 4. Внутренняя ошибка
 	t.Run("InternalError", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{}, errors.New("db error")).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{}, errors.New("db error")).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/employees/", nil)
 		resp, err := app.Test(req)
@@ -0,0 +1,136 @@
+package employee
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestEmployeeController_Hierarchy(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockEmployeeService)
+
+	server := &web.Server{
+		App:            app,
+		GroupEmployees: app.Group("/api/v1/employees"),
+	}
+	ctrl := NewController(server, mockService, logger)
+
+	server.GroupEmployees.Get("/:id/reports", ctrl.FindReports)
+	server.GroupEmployees.Get("/:id/chain-of-command", ctrl.FindChainOfCommand)
+
+	type hierarchyResult struct {
+		Success bool                `json:"success"`
+		Error   string              `json:"error"`
+		Data    []HierarchyResponse `json:"data"`
+	}
+
+	t.Run("should return direct reports by default", func(t *testing.T) {
+		reports := []HierarchyResponse{{Response: Response{Id: 2, Name: "Report", Status: StatusActive}, Level: 1}}
+		mockService.On("FindReports", appContext, int64(1), int64(1)).Return(reports, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/1/reports", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result hierarchyResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, reports, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should pass depth to service", func(t *testing.T) {
+		mockService.On("FindReports", appContext, int64(1), int64(3)).Return([]HierarchyResponse{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/1/reports?depth=3", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when depth is not a number", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/employees/1/reports?depth=all", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var result hierarchyResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, invalidDepthFormat, result.Error)
+	})
+
+	t.Run("should return 400 when depth is out of range", func(t *testing.T) {
+		validationErr := domain.RequestValidationError{Message: "Field Depth must not exceed 20"}
+		mockService.On("FindReports", appContext, int64(1), int64(50)).Return([]HierarchyResponse{}, validationErr).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/1/reports?depth=50", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return chain of command", func(t *testing.T) {
+		chain := []HierarchyResponse{
+			{Response: Response{Id: 2, Name: "Manager", Status: StatusActive}, Level: 1},
+			{Response: Response{Id: 3, Name: "Director", Status: StatusActive}, Level: 2},
+		}
+		mockService.On("FindChainOfCommand", appContext, int64(1)).Return(chain, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/1/chain-of-command", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result hierarchyResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, chain, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when employee not found", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "employee with id 9 not found"}
+		mockService.On("FindChainOfCommand", appContext, int64(9)).Return([]HierarchyResponse{}, notFound).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/9/chain-of-command", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,138 @@
+package employee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEmployeeController_Import(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockEmployeeService)
+
+	server := &web.Server{
+		App:            app,
+		GroupEmployees: app.Group("/api/v1/employees"),
+	}
+	ctrl := NewController(server, mockService, logger)
+
+	server.GroupEmployees.Post("/import", ctrl.Import)
+
+	type importResult struct {
+		Success bool           `json:"success"`
+		Error   string         `json:"error"`
+		Data    ImportResponse `json:"data"`
+	}
+
+	var file = "login,name\njsena,John Sena\n"
+	var report = ImportResponse{
+		Mode: ImportAtomic, Committed: true, Total: 1, Created: 1,
+		Rows: []ImportRowResult{{Line: 2, Login: "jsena", Action: ImportCreated, Id: 1}},
+	}
+
+	t.Run("should import csv body detected by content type", func(t *testing.T) {
+		var request = ImportRequest{Format: ImportCsv, Mode: ImportAtomic}
+		mockService.On("Import", appContext, request, file).Return(report, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/import", strings.NewReader(file))
+		req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result importResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, report, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should import multipart file in dry run best effort mode", func(t *testing.T) {
+		var ndjson = `{"login":"jsena","name":"John Sena"}` + "\n"
+		var request = ImportRequest{Format: ImportNdjson, Mode: ImportBestEffort, DryRun: true}
+		mockService.On("Import", appContext, request, ndjson).Return(ImportResponse{DryRun: true}, nil).Once()
+
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "employees.ndjson")
+		require.NoError(t, err)
+		_, err = part.Write([]byte(ndjson))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/import?mode=best_effort&dryRun=true", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return bad request when file is invalid", func(t *testing.T) {
+		var request = ImportRequest{Format: ImportCsv, Mode: ImportAtomic}
+		mockService.On("Import", appContext, request, "name\n").
+			Return(ImportResponse{}, domain.RequestValidationError{Message: "column login is required"}).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/import?format=csv", strings.NewReader("name\n"))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var result importResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "column login is required")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return bad request when dryRun is invalid", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/employees/import?dryRun=maybe", strings.NewReader(file))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestImportFormat(t *testing.T) {
+	var a = assert.New(t)
+	a.Equal(ImportCsv, importFormat("text/csv", ""))
+	a.Equal(ImportNdjson, importFormat("application/x-ndjson", ""))
+	a.Equal(ImportNdjson, importFormat("application/octet-stream", "employees.jsonl"))
+	a.Equal(ImportCsv, importFormat("", "Employees.CSV"))
+	a.Equal("", importFormat("application/json", "employees.json"))
+}
@@ -0,0 +1,179 @@
+package employee
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/pagination"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestEmployeeController_FindAllKeyset(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockEmployeeService)
+
+	var signer = pagination.NewSigner([]byte("test-secret"))
+	server := &web.Server{
+		App:            app,
+		GroupEmployees: app.Group("/api/v1/employees"),
+		Cursors:        signer,
+	}
+	ctrl := NewController(server, mockService, logger)
+	server.GroupEmployees.Get("/", ctrl.FindAll)
+
+	type pageBody struct {
+		Success bool               `json:"success"`
+		Error   string             `json:"error"`
+		Data    KeysetPageResponse `json:"data"`
+	}
+	var get = func(t *testing.T, target string) (int, pageBody) {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		require.NoError(t, err)
+		var body pageBody
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+	var cursorOf = func(t *testing.T, link string, param string) pagination.Cursor {
+		parsed, err := url.Parse(link)
+		require.NoError(t, err)
+		cursor, err := signer.Decode(parsed.Query().Get(param))
+		require.NoError(t, err)
+		return cursor
+	}
+
+	t.Run("should return full array without keyset parameters", func(t *testing.T) {
+		mockService.On("FindAll", appContext, false).Return([]Response{{Id: 1, Name: "John Sena"}}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/employees/", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var body struct {
+			Data []Response `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Len(t, body.Data, 1)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return first page with signed next link", func(t *testing.T) {
+		var request = KeysetRequest{Limit: 2, Sort: "-name", TextFilter: "Doe"}
+		mockService.On("GetKeysetPage", appContext, request).Return(KeysetPage{
+			Result: []Response{{Id: 5, Name: "John Doe"}, {Id: 3, Name: "Jane Doe"}},
+			Next:   &pagination.Key{Value: "Jane Doe", Id: 3},
+		}, nil).Once()
+
+		status, body := get(t, "/api/v1/employees/?limit=2&sort=-name&textFilter=Doe")
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Len(t, body.Data.Result, 2)
+		assert.Equal(t, 2, body.Data.Limit)
+		assert.Empty(t, body.Data.Prev)
+		assert.Nil(t, body.Data.Total)
+
+		var cursor = cursorOf(t, body.Data.Next, web.AfterParam)
+		assert.Equal(t, pagination.Cursor{
+			Key:      pagination.Key{Value: "Jane Doe", Id: 3},
+			Resource: web.EmployeesPath,
+			Sort:     "-name",
+			Filter:   "Doe",
+		}, cursor)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should follow next link and return total on demand", func(t *testing.T) {
+		var after = pagination.Key{Value: "Jane Doe", Id: 3}
+		var token = signer.Encode(pagination.Cursor{Key: after, Resource: web.EmployeesPath, Sort: "name"})
+		var total = int64(7)
+		var request = KeysetRequest{Limit: pagination.DefaultLimit, Sort: "name", WithTotal: true, After: &after}
+		mockService.On("GetKeysetPage", appContext, request).Return(KeysetPage{
+			Result: []Response{{Id: 4, Name: "Joe"}},
+			Prev:   &pagination.Key{Value: "Joe", Id: 4},
+			Total:  &total,
+		}, nil).Once()
+
+		status, body := get(t, "/api/v1/employees/?sort=name&withTotal=true&after="+token)
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, &total, body.Data.Total)
+		assert.Empty(t, body.Data.Next)
+		assert.Equal(t, int64(4), cursorOf(t, body.Data.Prev, web.BeforeParam).Id)
+		assert.Contains(t, body.Data.Prev, "withTotal=true")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should reject cursor issued for other parameters", func(t *testing.T) {
+		var token = signer.Encode(pagination.Cursor{Key: pagination.Key{Id: 3}, Resource: web.EmployeesPath, Sort: "id"})
+
+		status, body := get(t, "/api/v1/employees/?sort=-name&after="+token)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Contains(t, body.Error, "cursor does not match")
+
+		status, _ = get(t, "/api/v1/employees/?includeDeleted=true&after="+token)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+
+		var roleToken = signer.Encode(pagination.Cursor{Key: pagination.Key{Id: 3}, Resource: web.RolesPath, Sort: "id"})
+		status, _ = get(t, "/api/v1/employees/?after="+roleToken)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+
+	t.Run("should reject forged cursor", func(t *testing.T) {
+		var token = pagination.NewSigner([]byte("other")).Encode(pagination.Cursor{Resource: web.EmployeesPath, Sort: "id"})
+
+		status, body := get(t, "/api/v1/employees/?after="+token)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Equal(t, pagination.ErrInvalidCursor.Error(), body.Error)
+	})
+
+	t.Run("should reject invalid limit and both cursors", func(t *testing.T) {
+		for _, target := range []string{
+			"/api/v1/employees/?limit=0",
+			"/api/v1/employees/?limit=501",
+			"/api/v1/employees/?limit=ten",
+			"/api/v1/employees/?after=a.b&before=c.d",
+		} {
+			status, _ := get(t, target)
+			assert.Equal(t, fiber.StatusBadRequest, status, target)
+		}
+	})
+
+	t.Run("should return bad request on unknown sort", func(t *testing.T) {
+		var request = KeysetRequest{Limit: pagination.DefaultLimit, Sort: "salary"}
+		mockService.On("GetKeysetPage", appContext, request).
+			Return(KeysetPage{}, domain.RequestValidationError{Message: `unknown sort field "salary"`}).Once()
+
+		status, body := get(t, "/api/v1/employees/?sort=salary")
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Contains(t, body.Error, "salary")
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,155 @@
+package employee
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEmployeeController_Lifecycle(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockEmployeeService)
+
+	server := &web.Server{
+		App:            app,
+		GroupEmployees: app.Group("/api/v1/employees"),
+	}
+	ctrl := NewController(server, mockService, logger)
+
+	server.GroupEmployees.Get("/:id", ctrl.FindById)
+	server.GroupEmployees.Post("/:id/restore", ctrl.Restore)
+	server.GroupEmployees.Post("/:id/suspend", ctrl.Suspend)
+	server.GroupEmployees.Post("/:id/terminate", ctrl.Terminate)
+
+	// Тестовые данные
+	testTime := time.Date(2025, 7, 21, 12, 0, 0, 0, time.UTC)
+	deletedAt := testTime.Add(time.Hour)
+
+	type employeeResult struct {
+		Success bool           `json:"success"`
+		Error   string         `json:"error"`
+		Data    map[string]any `json:"data"`
+	}
+
+	t.Run("should return deleted employee when includeDeleted is set", func(t *testing.T) {
+		expected := Response{Id: 1, Name: "John Sena", CreateAt: testTime, UpdateAt: testTime, DeleteAt: &deletedAt}
+		mockService.On("FindById", appContext, int64(1), true).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/1?includeDeleted=true", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result employeeResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, deletedAt.Format(time.RFC3339), result.Data["deleteAt"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should restore deleted employee", func(t *testing.T) {
+		expected := Response{Id: 1, Name: "John Sena", CreateAt: testTime, UpdateAt: testTime}
+		mockService.On("Restore", appContext, int64(1)).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/1/restore", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result employeeResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, "John Sena", result.Data["name"])
+		assert.NotContains(t, result.Data, "deleteAt")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when employee is not deleted", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "deleted employee with id 2 not found"}
+		mockService.On("Restore", appContext, int64(2)).Return(Response{}, notFound).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/2/restore", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		var result employeeResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.Success)
+		assert.Equal(t, notFound.Message, result.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when restore with invalid employee id", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/employees/abc/restore", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "Restore")
+	})
+
+	t.Run("should terminate employee", func(t *testing.T) {
+		expected := Response{Id: 1, Name: "John Sena", Status: StatusTerminated, CreateAt: testTime, UpdateAt: testTime}
+		mockService.On("ChangeStatus", appContext, int64(1), LifecycleTerminate).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/1/terminate", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result employeeResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, StatusTerminated, result.Data["status"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when transition is not allowed", func(t *testing.T) {
+		conflict := domain.ConflictError{Message: "cannot suspend employee in status terminated"}
+		mockService.On("ChangeStatus", appContext, int64(1), LifecycleSuspend).Return(Response{}, conflict).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/1/suspend", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+		var result employeeResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.Success)
+		assert.Equal(t, conflict.Message, result.Error)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,169 @@
+package employee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmployeeController_Roles(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockEmployeeService)
+
+	server := &web.Server{
+		App:            app,
+		GroupEmployees: app.Group("/api/v1/employees"),
+	}
+	ctrl := NewController(server, mockService, logger)
+
+	server.GroupEmployees.Get("/:id/roles", ctrl.FindRoles)
+	server.GroupEmployees.Post("/:id/roles", ctrl.AssignRole)
+	server.GroupEmployees.Delete("/:id/roles/:roleId", ctrl.RevokeRole)
+
+	// Тестовые данные
+	testTime := time.Date(2025, 7, 15, 12, 0, 0, 0, time.UTC)
+	testRoles := []RoleResponse{
+		{Id: 10, Name: "ADMIN", AssignedAt: testTime},
+		{Id: 11, Name: "USER", AssignedAt: testTime},
+	}
+
+	type rolesResult struct {
+		Success bool           `json:"success"`
+		Error   string         `json:"error"`
+		Data    []RoleResponse `json:"data"`
+	}
+
+	t.Run("should return employee roles", func(t *testing.T) {
+		mockService.On("FindRoles", appContext, int64(1)).Return(testRoles, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/1/roles", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result rolesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, testRoles, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when employee not found", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "employee with id 2 not found"}
+		mockService.On("FindRoles", appContext, int64(2)).Return([]RoleResponse{}, notFound).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/employees/2/roles", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		var result rolesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.Success)
+		assert.Equal(t, notFound.Message, result.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should assign role to employee", func(t *testing.T) {
+		request := AssignRoleRequest{RoleID: 10}
+		mockService.On("AssignRole", appContext, int64(1), request).Return(testRoles, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/1/roles", strings.NewReader(`{"roleId": 10}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result rolesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Len(t, result.Data, 2)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when assign with invalid employee id", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/employees/abc/roles", strings.NewReader(`{"roleId": 10}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "AssignRole")
+	})
+
+	t.Run("should return 400 when assign validation failed", func(t *testing.T) {
+		request := AssignRoleRequest{RoleID: 0}
+		validationErr := domain.RequestValidationError{Message: "Field RoleID is required"}
+		mockService.On("AssignRole", appContext, int64(1), request).Return([]RoleResponse{}, validationErr).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/employees/1/roles", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should revoke role from employee", func(t *testing.T) {
+		mockService.On("RevokeRole", appContext, int64(1), int64(11)).Return(testRoles[:1], nil).Once()
+
+		req := httptest.NewRequest("DELETE", "/api/v1/employees/1/roles/11", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result rolesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, testRoles[:1], result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 500 when revoke failed", func(t *testing.T) {
+		mockService.On("RevokeRole", appContext, int64(1), int64(12)).Return([]RoleResponse{}, errors.New("db error")).Once()
+
+		req := httptest.NewRequest("DELETE", "/api/v1/employees/1/roles/12", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+		var result rolesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, internalServerError, result.Error)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -92,7 +92,7 @@ LOG_DEVELOP_MODE=true`
 		}
 
 		// 3. Настройка мока
-		mockService.On("FindById", appContext, testID).Return(expectedData, nil).Once()
+		mockService.On("FindById", appContext, testID, false).Return(expectedData, nil).Once()
 
 		// 4. Выполнение запроса
 		req := httptest.NewRequest("GET", "/api/v1/employees/1", nil)
@@ -233,6 +233,30 @@ LOG_DEVELOP_MODE=true`
 		// 6. Проверка вызовов мока
 		mockService.AssertExpectations(t)
 	})
+	//create error by taken login
+	t.Run("when create employee with taken login then should return conflict", func(t *testing.T) {
+		mockService.ExpectedCalls = nil
+		createRequest := CreateRequest{Name: "John", Profile: Profile{Login: "jdoe"}}
+		expectError := domain.AlreadyExistsError{Message: "employee with this login or email already exists"}
+
+		var body = strings.NewReader("{\"name\": \"John\", \"login\": \"jdoe\"}")
+		var req = httptest.NewRequest(fiber.MethodPost, "/api/v1/employees/", body)
+		req.Header.Set("Content-Type", "application/json")
+
+		mockService.On("CreateEmployee", appContext, createRequest).Return(Response{}, expectError).Once()
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer closeBody(t, resp.Body)
+
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&errorResponse)
+		require.NoError(t, err)
+		require.Contains(t, errorResponse.Error, "already exists")
+		mockService.AssertExpectations(t)
+	})
 	//create error by server error
 	t.Run("when create employee then should return error", func(t *testing.T) {
 		mockService.ExpectedCalls = nil // Сбрасываем моки перед тестом
@@ -483,7 +507,7 @@ LOG_DEVELOP_MODE=true`
 		}
 
 		// 2. Настройка мока
-		mockService.On("FindAllByIds", appContext, requestIDs).Return(expectedData, nil).Once()
+		mockService.On("FindAllByIds", appContext, requestIDs, false).Return(expectedData, nil).Once()
 
 		// 3. Создание запроса
 		req := httptest.NewRequest("GET", "/api/v1/employees/ids?ids="+idParam, nil)
@@ -549,7 +573,7 @@ LOG_DEVELOP_MODE=true`
 		idParam := "1,2,3"
 
 		// 2. Настройка мока
-		mockService.On("FindAllByIds", appContext, requestIDs).Return([]Response{}, nil).Once()
+		mockService.On("FindAllByIds", appContext, requestIDs, false).Return([]Response{}, nil).Once()
 
 		// 3. Создание запроса
 		req := httptest.NewRequest("GET", "/api/v1/employees/ids?ids="+idParam, nil)
@@ -1,24 +1,56 @@
 package employee
 
 import (
+	"github.com/lib/pq"
+	"idm/inner/export"
+	"idm/inner/pagination"
+	"strconv"
 	"time"
 )
 
 type Entity struct {
-	Id        int64     `db:"id"`
-	Name      string    `db:"name"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	Id        int64      `db:"id"`
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at"` // nil - сотрудник не удалён
+	// Status - состояние жизненного цикла (StatusPreHire, StatusActive, StatusSuspended, StatusTerminated)
+	Status          string     `db:"status"`
+	StatusChangedAt *time.Time `db:"status_changed_at"`
+	// атрибуты профиля: login и email уникальны, NULL - не заданы
+	Login      *string    `db:"login"`
+	Email      *string    `db:"email"`
+	Department string     `db:"department"`
+	Title      string     `db:"title"`
+	ManagerId  *int64     `db:"manager_id"`
+	StartDate  *time.Time `db:"start_date"`
+	EndDate    *time.Time `db:"end_date"`
+	Phone      string     `db:"phone"`
+	// подразделение меняется переводом через orgunit, NULL - не распределён
+	OrgUnitId *int64 `db:"org_unit_id"`
 }
 
 // Response model info
 // @Description Employee account information
-// @Description with employee id, name, createAt, updateAt
+// @Description with employee id, name, lifecycle status, profile attributes, createAt, updateAt
+// @Description and deleteAt for soft deleted employees
 type Response struct {
-	Id       int64     `json:"id"`
-	Name     string    `json:"name"`
-	CreateAt time.Time `json:"createAt"`
-	UpdateAt time.Time `json:"updateAt"`
+	Id              int64      `json:"id"`
+	Name            string     `json:"name"`
+	Status          string     `json:"status"`
+	StatusChangedAt *time.Time `json:"statusChangedAt,omitempty"`
+	Login           string     `json:"login,omitempty"`
+	Email           string     `json:"email,omitempty"`
+	Department      string     `json:"department,omitempty"`
+	Title           string     `json:"title,omitempty"`
+	ManagerId       *int64     `json:"managerId,omitempty"`
+	StartDate       *time.Time `json:"startDate,omitempty"`
+	EndDate         *time.Time `json:"endDate,omitempty"`
+	Phone           string     `json:"phone,omitempty"`
+	OrgUnitId       *int64     `json:"orgUnitId,omitempty"`
+	CreateAt        time.Time  `json:"createAt"`
+	UpdateAt        time.Time  `json:"updateAt"`
+	DeleteAt        *time.Time `json:"deleteAt,omitempty"`
 }
 
 // PageResponse model info
@@ -33,10 +65,22 @@ type PageResponse struct {
 
 func (e *Entity) ToResponse() Response {
 	return Response{
-		Id:       e.Id,
-		Name:     e.Name,
-		CreateAt: e.CreatedAt,
-		UpdateAt: e.UpdatedAt,
+		Id:              e.Id,
+		Name:            e.Name,
+		Status:          e.Status,
+		StatusChangedAt: e.StatusChangedAt,
+		Login:           valueOf(e.Login),
+		Email:           valueOf(e.Email),
+		Department:      e.Department,
+		Title:           e.Title,
+		ManagerId:       e.ManagerId,
+		StartDate:       e.StartDate,
+		EndDate:         e.EndDate,
+		Phone:           e.Phone,
+		OrgUnitId:       e.OrgUnitId,
+		CreateAt:        e.CreatedAt,
+		UpdateAt:        e.UpdatedAt,
+		DeleteAt:        e.DeletedAt,
 	}
 }
 func (e *Entity) ToPageResponses(
@@ -70,29 +114,159 @@ func (e *Entity) ToResponseList(entities []*Entity) []Response {
 // @Description with name
 type CreateRequest struct {
 	Name string `json:"name" validate:"required,min=2,max=155"`
+	// Status - начальное состояние: pre_hire для будущих сотрудников, по умолчанию active
+	Status string `json:"status" validate:"omitempty,oneof=pre_hire active"`
+	Profile
 }
 
 func (req *CreateRequest) ToEntity() *Entity {
-	return &Entity{Name: req.Name}
+	var status = req.Status
+	if status == "" {
+		status = StatusActive
+	}
+	var entity = &Entity{Name: req.Name, Status: status}
+	req.Profile.apply(entity)
+	return entity
 }
 
 // UpdateRequest model info
 // @Description Employee account information
-// @Description with employee id, name, createAt, updateAt
+// @Description with employee id, name, profile attributes, createAt, updateAt
 type UpdateRequest struct {
 	Id        int64     `json:"id" validate:"required,min=1"`
 	Name      string    `json:"name" validate:"required,min=2,max=155"`
 	CreatedAt time.Time `json:"createdAt" validate:"required"`
 	UpdatedAt time.Time `json:"updatedAt" validate:"required"`
+	Profile
 }
 
 func (req *UpdateRequest) ToEntity() *Entity {
-	return &Entity{
+	var entity = &Entity{
 		Id:        req.Id,
 		Name:      req.Name,
 		CreatedAt: req.CreatedAt,
 		UpdatedAt: req.UpdatedAt,
 	}
+	req.Profile.apply(entity)
+	return entity
+}
+
+// Profile - атрибуты профиля сотрудника, общие для создания и обновления (пустое значение - не задано).
+// Правила login и phone регистрируются в validator.NewValidator
+type Profile struct {
+	Login      string     `json:"login" validate:"omitempty,login"`
+	Email      string     `json:"email" validate:"omitempty,max=255,email"`
+	Department string     `json:"department" validate:"omitempty,max=155,no_sql_injection"`
+	Title      string     `json:"title" validate:"omitempty,max=155,no_sql_injection"`
+	ManagerId  int64      `json:"managerId" validate:"omitempty,min=1"`
+	StartDate  *time.Time `json:"startDate"`
+	EndDate    *time.Time `json:"endDate"`
+	Phone      string     `json:"phone" validate:"omitempty,phone"`
+}
+
+func (p *Profile) apply(entity *Entity) {
+	entity.Login = nullable(p.Login)
+	entity.Email = nullable(p.Email)
+	entity.Department = p.Department
+	entity.Title = p.Title
+	if p.ManagerId > 0 {
+		entity.ManagerId = &p.ManagerId
+	}
+	entity.StartDate = p.StartDate
+	entity.EndDate = p.EndDate
+	entity.Phone = p.Phone
+}
+
+// nullable - пустая строка хранится как NULL (уникальные login и email)
+func nullable(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func valueOf(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// ChangeStatusRequest - действие жизненного цикла над сотрудником (activate, suspend, terminate, rehire)
+type ChangeStatusRequest struct {
+	ID     int64  `validate:"required,min=1"`
+	Action string `validate:"required,oneof=activate suspend terminate rehire"`
+}
+
+// HierarchyEntity - сотрудник в оргструктуре: Level - расстояние от исходного сотрудника
+// (1 - прямой подчинённый или непосредственный руководитель)
+type HierarchyEntity struct {
+	Entity
+	Level int64 `db:"level"`
+}
+
+// HierarchyResponse model info
+// @Description Employee in org chart
+// @Description with employee fields and level (distance from the requested employee)
+type HierarchyResponse struct {
+	Response
+	Level int64 `json:"level"`
+}
+
+func (e *HierarchyEntity) ToResponse() HierarchyResponse {
+	return HierarchyResponse{
+		Response: e.Entity.ToResponse(),
+		Level:    e.Level,
+	}
+}
+
+// ReportsRequest - подчинённые сотрудника до глубины Depth (1 - только прямые)
+type ReportsRequest struct {
+	ID    int64 `validate:"required,min=1"`
+	Depth int64 `validate:"required,min=1,max=20"`
+}
+
+// RoleEntity - роль, назначенная сотруднику (строка из employee_roles + roles)
+type RoleEntity struct {
+	Id         int64     `db:"id"`
+	Name       string    `db:"name"`
+	AssignedAt time.Time `db:"assigned_at"`
+}
+
+// EmployeeRoleEntity - роль с сотрудником, которому она назначена (выборка ролей нескольких сотрудников)
+type EmployeeRoleEntity struct {
+	EmployeeId int64 `db:"employee_id"`
+	RoleEntity
+}
+
+// RoleResponse model info
+// @Description Role assigned to employee
+// @Description with role id, name, assignedAt
+type RoleResponse struct {
+	Id         int64     `json:"id"`
+	Name       string    `json:"name"`
+	AssignedAt time.Time `json:"assignedAt"`
+}
+
+func (e *RoleEntity) ToResponse() RoleResponse {
+	return RoleResponse{
+		Id:         e.Id,
+		Name:       e.Name,
+		AssignedAt: e.AssignedAt,
+	}
+}
+
+// AssignRoleRequest model info
+// @Description Role assignment information
+// @Description with role id
+type AssignRoleRequest struct {
+	EmployeeID int64 `json:"-" validate:"required,min=1"`
+	RoleID     int64 `json:"roleId" validate:"required,min=1"`
+}
+
+type RevokeRoleRequest struct {
+	EmployeeID int64 `validate:"required,min=1"`
+	RoleID     int64 `validate:"required,min=1"`
 }
 
 type UpdateByIDRequest struct {
@@ -111,6 +285,64 @@ type PageRequest struct {
 	PageSize   int64  `validate:"required,min=1,max=155"` //gt=0,lte=100
 	PageNumber int64  `validate:"required,min=1,max=1000"`
 	TextFilter string `validate:"omitempty,min=1,max=100,no_sql_injection"` // omitempty go tag, empty fields // Необязательное поле // Добавлен тег - no_sql_injection!
+	// IncludeDeleted - включить в выборку мягко удалённых сотрудников
+	IncludeDeleted bool
+}
+
+// KeysetRequest - параметры keyset-выдачи сотрудников: позиция курсора (не больше одной), сортировка и фильтры
+type KeysetRequest struct {
+	Limit          int    `validate:"required,min=1,max=500"`
+	Sort           string `validate:"required,max=50"`
+	TextFilter     string `validate:"omitempty,min=1,max=100,no_sql_injection"`
+	IncludeDeleted bool
+	// WithTotal - посчитать число сотрудников по фильтрам (отдельный запрос COUNT)
+	WithTotal bool
+	After     *pagination.Key
+	Before    *pagination.Key
+}
+
+// KeysetPage - страница сотрудников с позициями соседних страниц (nil - страницы нет)
+type KeysetPage struct {
+	Result []Response
+	Next   *pagination.Key
+	Prev   *pagination.Key
+	Total  *int64 // только при WithTotal
+}
+
+// KeysetPageResponse model info
+// @Description Employees page with opaque links to the next and previous pages
+// @Description total is present only when requested with withTotal=true
+type KeysetPageResponse struct {
+	Result []Response `json:"result"`
+	Limit  int        `json:"limit"`
+	Next   string     `json:"next,omitempty"`
+	Prev   string     `json:"prev,omitempty"`
+	Total  *int64     `json:"total,omitempty"`
+}
+
+// Поля и операторы FilterRequest
+const (
+	FilterFieldId       = "id"
+	FilterFieldUserName = "userName" // login, а если он пуст - email
+	FilterOpEq          = "eq"
+	FilterOpSw          = "sw"
+	FilterOpCo          = "co"
+)
+
+// FilterRequest - страница не удалённых сотрудников по порядку id, отобранных условием на одно поле
+// (Field пуст - все сотрудники). Строки сравниваются без учёта регистра
+type FilterRequest struct {
+	Field  string `validate:"omitempty,oneof=id userName"`
+	Op     string `validate:"required_with=Field,omitempty,oneof=eq sw co"`
+	Value  string `validate:"max=255"`
+	Offset int64  `validate:"min=0"`
+	Limit  int64  `validate:"min=0,max=1000"`
+}
+
+// FilterPage - страница сотрудников и общее число удовлетворяющих фильтру
+type FilterPage struct {
+	Result []Response
+	Total  int64
 }
 
 type DeleteByIdsRequest struct {
@@ -120,3 +352,107 @@ type DeleteByIdsRequest struct {
 type DeleteByIdRequest struct {
 	ID int64 `validate:"required,min=1"`
 }
+
+// ImportRequest - параметры импорта сотрудников из файла
+type ImportRequest struct {
+	Format string `validate:"required,oneof=csv ndjson"`
+	Mode   string `validate:"required,oneof=atomic best_effort"`
+	// DryRun - проверить файл, выполнив все изменения в транзакции, и откатить её
+	DryRun bool
+}
+
+// ImportRowResult model info
+// @Description Outcome of one import row: created, updated or failed with error;
+// @Description id is returned only when the changes are committed
+type ImportRowResult struct {
+	Line   int    `json:"line"` // номер строки файла
+	Login  string `json:"login,omitempty"`
+	Action string `json:"action"`
+	Id     int64  `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportResponse model info
+// @Description Employee import report with per-row results; committed is false for dry run
+// @Description and for atomic import with failed rows
+type ImportResponse struct {
+	DryRun    bool              `json:"dryRun"`
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// ExportRequest - параметры выгрузки сотрудников: фильтр по имени тот же, что у постраничного поиска
+type ExportRequest struct {
+	Format         string `validate:"required,oneof=csv ndjson xlsx"`
+	TextFilter     string `validate:"omitempty,min=1,max=100,no_sql_injection"`
+	IncludeDeleted bool
+}
+
+// ExportEntity - сотрудник в выгрузке с названиями действующих назначенных ролей
+type ExportEntity struct {
+	Entity
+	Roles pq.StringArray `db:"roles"`
+}
+
+// ExportRecord model info
+// @Description Exported employee: employee fields and names of directly assigned roles
+type ExportRecord struct {
+	Response
+	Roles []string `json:"roles"`
+}
+
+// exportColumns - колонки CSV и XLSX в порядке ExportRecord.Values; атрибуты профиля названы как в импорте
+var exportColumns = []string{
+	"id", "name", "status", "login", "email", "department", "title", "managerId", "orgUnitId",
+	"startDate", "endDate", "phone", "createdAt", "updatedAt", "deletedAt", "roles",
+}
+
+func (e *ExportEntity) ToExportRecord() ExportRecord {
+	var roles = []string(e.Roles)
+	if roles == nil {
+		roles = []string{}
+	}
+	return ExportRecord{Response: e.Entity.ToResponse(), Roles: roles}
+}
+
+// Values - даты профиля в формате импорта (YYYY-MM-DD), отметки времени - RFC3339 в UTC
+func (r ExportRecord) Values() []string {
+	return []string{
+		strconv.FormatInt(r.Id, 10),
+		r.Name,
+		r.Status,
+		r.Login,
+		r.Email,
+		r.Department,
+		r.Title,
+		formatId(r.ManagerId),
+		formatId(r.OrgUnitId),
+		formatTime(r.StartDate, time.DateOnly),
+		formatTime(r.EndDate, time.DateOnly),
+		r.Phone,
+		r.CreateAt.UTC().Format(time.RFC3339),
+		r.UpdateAt.UTC().Format(time.RFC3339),
+		formatTime(r.DeleteAt, time.RFC3339),
+		export.List(r.Roles),
+	}
+}
+
+// formatId - необязательный id для выгрузки: пустая строка вместо nil
+func formatId(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
+
+func formatTime(value *time.Time, layout string) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(layout)
+}
@@ -0,0 +1,527 @@
+package employee
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/domain"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы файла импорта
+const (
+	ImportCsv    = "csv"    // первая строка - заголовок с именами колонок
+	ImportNdjson = "ndjson" // по одному JSON-объекту на строку
+)
+
+// Режимы импорта
+const (
+	ImportAtomic     = "atomic"      // ошибка любой строки отменяет весь импорт
+	ImportBestEffort = "best_effort" // строки с ошибками пропускаются, остальные записываются
+)
+
+// Исход строки импорта
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// maxImportRows - ограничение размера файла: весь импорт идёт одной транзакцией
+const maxImportRows = 5000
+
+// importSavepoint - точка сохранения строки: её ошибка откатывает только эту строку
+const importSavepoint = "import_row"
+
+// errImportRollback - откат транзакции импорта без ошибки (dryRun или atomic с ошибками строк)
+var errImportRollback = errors.New("import rolled back")
+
+// importColumns - колонки CSV и ключи NDJSON
+var importColumns = []string{
+	"name", "login", "email", "department", "title", "managerId", "startDate", "endDate", "phone", "status",
+}
+
+// ImportRecord - строка файла импорта. nil - колонки (ключа) нет, у существующего сотрудника
+// атрибут не меняется; пустое значение (managerId 0) очищает атрибут, кроме обязательного name
+type ImportRecord struct {
+	Name       *string `json:"name"`
+	Login      *string `json:"login"`
+	Email      *string `json:"email"`
+	Department *string `json:"department"`
+	Title      *string `json:"title"`
+	ManagerId  *int64  `json:"managerId"`
+	StartDate  *string `json:"startDate"`
+	EndDate    *string `json:"endDate"`
+	Phone      *string `json:"phone"`
+	Status     *string `json:"status"`
+}
+
+// importRow - разобранная строка файла; Err - строку не удалось разобрать
+type importRow struct {
+	Line   int
+	Record ImportRecord
+	Err    error
+}
+
+// Import - загрузить сотрудников из CSV или NDJSON с сопоставлением по login: новые создаются,
+// существующие обновляются (status задаёт только начальное состояние новых сотрудников,
+// состояние существующих меняется действиями жизненного цикла). Мягко удалённого сотрудника
+// с тем же login импорт не меняет. Все строки обрабатываются в одной транзакции, каждая -
+// в своей точке сохранения; ошибки строк возвращаются в отчёте, а не ошибкой метода
+func (svc *Service) Import(ctx context.Context, request ImportRequest, body io.Reader) (ImportResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return ImportResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	rows, err := parseImport(request.Format, body)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+
+	var response = ImportResponse{DryRun: request.DryRun, Mode: request.Mode, Rows: make([]ImportRowResult, 0, len(rows))}
+	err = svc.repo.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var logins = make(map[string]int, len(rows))
+		for _, row := range rows {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result, err := svc.importRow(ctx, tx, row, logins)
+			if err != nil {
+				return err
+			}
+			response.add(result)
+		}
+
+		if request.DryRun || (request.Mode == ImportAtomic && response.Failed > 0) {
+			return errImportRollback
+		}
+		response.Committed = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRollback) {
+		return ImportResponse{}, fmt.Errorf("error importing employees: %w", err)
+	}
+
+	if !response.Committed {
+		// id созданных сотрудников откачены вместе с транзакцией
+		for i := range response.Rows {
+			if response.Rows[i].Action == ImportCreated {
+				response.Rows[i].Id = 0
+			}
+		}
+	}
+	return response, nil
+}
+
+func (r *ImportResponse) add(result ImportRowResult) {
+	r.Total++
+	switch result.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// importRow - обработать строку файла. Доменные ошибки становятся ошибкой строки,
+// остальные (база данных) прерывают импорт. logins - login -> номер строки, уже встреченные в файле
+func (svc *Service) importRow(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	row importRow,
+	logins map[string]int,
+) (ImportRowResult, error) {
+	var result = ImportRowResult{Line: row.Line, Action: ImportFailed}
+	if row.Err != nil {
+		result.Error = row.Err.Error()
+		return result, nil
+	}
+
+	var login = strings.TrimSpace(valueOf(row.Record.Login))
+	result.Login = login
+	if login == "" {
+		result.Error = "login is required: employees are matched by login"
+		return result, nil
+	}
+	var key = strings.ToLower(login)
+	if line, ok := logins[key]; ok {
+		result.Error = fmt.Sprintf("login %s is already imported at line %d", login, line)
+		return result, nil
+	}
+	logins[key] = row.Line
+
+	err := svc.repo.SavepointTx(ctx, tx, importSavepoint, func() error {
+		var err error
+		result.Action, result.Id, err = svc.upsertTx(ctx, tx, login, row.Record)
+		return err
+	})
+	if isDomainError(err) {
+		result.Action = ImportFailed
+		result.Id = 0
+		result.Error = err.Error()
+		return result, nil
+	}
+	if err != nil {
+		return ImportRowResult{}, fmt.Errorf("error importing line %d: %w", row.Line, err)
+	}
+	return result, nil
+}
+
+// upsertTx - создать сотрудника с login или обновить найденного; возвращает исход и id
+func (svc *Service) upsertTx(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	login string,
+	record ImportRecord,
+) (string, int64, error) {
+	existing, err := svc.repo.FindByLoginTx(ctx, tx, login)
+	if errors.Is(err, sql.ErrNoRows) {
+		var request = CreateRequest{Name: valueOf(record.Name), Status: valueOf(record.Status)}
+		request.Profile, err = record.merge(Profile{Login: login})
+		if err != nil {
+			return "", 0, err
+		}
+		if err = svc.validator.Validate(request); err != nil {
+			return "", 0, domain.RequestValidationError{Message: err.Error()}
+		}
+		if err = svc.checkProfileTx(ctx, tx, 0, request.Profile); err != nil {
+			return "", 0, err
+		}
+		id, err := svc.repo.CreateEntityTx(ctx, tx, request.ToEntity())
+		if err != nil {
+			return "", 0, fmt.Errorf("error creating employee with login %s: %w", login, err)
+		}
+		return ImportCreated, id, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("error finding employee with login %s: %w", login, err)
+	}
+	if existing.DeletedAt != nil {
+		return "", 0, domain.ConflictError{
+			Message: fmt.Sprintf("employee %d with login %s is deleted, restore it before import", existing.Id, login),
+		}
+	}
+
+	var current = existing.ToResponse()
+	var request = UpdateRequest{
+		Id:        existing.Id,
+		Name:      existing.Name,
+		CreatedAt: existing.CreatedAt,
+		UpdatedAt: existing.UpdatedAt,
+	}
+	if record.Name != nil && *record.Name != "" {
+		request.Name = *record.Name // имя обязательно: пустое значение его не очищает
+	}
+	request.Profile, err = record.merge(Profile{
+		Login:      current.Login, // регистр login не меняется
+		Email:      current.Email,
+		Department: current.Department,
+		Title:      current.Title,
+		ManagerId:  valueOrZero(current.ManagerId),
+		StartDate:  current.StartDate,
+		EndDate:    current.EndDate,
+		Phone:      current.Phone,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	if err = svc.validator.Validate(request); err != nil {
+		return "", 0, domain.RequestValidationError{Message: err.Error()}
+	}
+	if err = svc.checkProfileTx(ctx, tx, existing.Id, request.Profile); err != nil {
+		return "", 0, err
+	}
+	err = svc.repo.UpdateEntityTx(ctx, tx, request.ToEntity())
+	if errors.Is(err, ErrManagerCycle) {
+		return "", 0, domain.RequestValidationError{
+			Message: fmt.Sprintf("employee %d cannot report to %d: %s", existing.Id, request.ManagerId, err),
+		}
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("error updating employee with login %s: %w", login, err)
+	}
+	return ImportUpdated, existing.Id, nil
+}
+
+// checkProfileTx - проверки checkProfile в транзакции импорта: видны сотрудники, созданные
+// предыдущими строками файла. Уникальность login обеспечивает поиск по нему
+func (svc *Service) checkProfileTx(ctx context.Context, tx *sqlx.Tx, id int64, profile Profile) error {
+	if profile.StartDate != nil && profile.EndDate != nil && profile.EndDate.Before(*profile.StartDate) {
+		return domain.RequestValidationError{Message: "Field EndDate must not be before StartDate"}
+	}
+
+	if profile.ManagerId > 0 {
+		if profile.ManagerId == id {
+			return domain.RequestValidationError{Message: "employee cannot be their own manager"}
+		}
+		_, err := svc.repo.FindByIdTx(ctx, tx, profile.ManagerId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.RequestValidationError{
+				Message: fmt.Sprintf("manager with id %d not found", profile.ManagerId),
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("error finding manager with id %d: %w", profile.ManagerId, err)
+		}
+	}
+
+	if profile.Email == "" {
+		return nil
+	}
+	isExists, err := svc.repo.ExistsByEmailTx(ctx, tx, profile.Email, id)
+	if err != nil {
+		return fmt.Errorf("error checking email uniqueness: %w", err)
+	}
+	if isExists {
+		return domain.AlreadyExistsError{Message: "employee with this email already exists"}
+	}
+	return nil
+}
+
+// merge - наложить заданные в строке атрибуты на профиль
+func (r *ImportRecord) merge(profile Profile) (Profile, error) {
+	if r.Email != nil {
+		profile.Email = strings.TrimSpace(*r.Email)
+	}
+	if r.Department != nil {
+		profile.Department = *r.Department
+	}
+	if r.Title != nil {
+		profile.Title = *r.Title
+	}
+	if r.ManagerId != nil {
+		profile.ManagerId = *r.ManagerId
+	}
+	if r.Phone != nil {
+		profile.Phone = *r.Phone
+	}
+	var err error
+	if r.StartDate != nil {
+		if profile.StartDate, err = parseImportDate("startDate", *r.StartDate); err != nil {
+			return Profile{}, err
+		}
+	}
+	if r.EndDate != nil {
+		if profile.EndDate, err = parseImportDate("endDate", *r.EndDate); err != nil {
+			return Profile{}, err
+		}
+	}
+	return profile, nil
+}
+
+// parseImportDate - дата в формате 2006-01-02 или RFC3339, пустая строка - очистить
+func parseImportDate(field string, value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return &date, nil
+		}
+	}
+	return nil, domain.RequestValidationError{
+		Message: fmt.Sprintf("%s %q must be a date in format YYYY-MM-DD or RFC3339", field, value),
+	}
+}
+
+// parseImport - разобрать весь файл до начала записи: ошибки формата файла целиком -
+// RequestValidationError, ошибки отдельных строк попадают в importRow.Err
+func parseImport(format string, body io.Reader) ([]importRow, error) {
+	var rows []importRow
+	var err error
+	switch format {
+	case ImportCsv:
+		rows, err = parseCsv(body)
+	case ImportNdjson:
+		rows, err = parseNdjson(body)
+	default:
+		return nil, domain.RequestValidationError{Message: fmt.Sprintf("unsupported import format %q", format)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, domain.RequestValidationError{Message: "import file has no rows"}
+	}
+	return rows, nil
+}
+
+func parseCsv(body io.Reader) ([]importRow, error) {
+	var reader = csv.NewReader(body)
+	reader.FieldsPerRecord = -1 // число полей проверяется по заголовку, ошибка - только у строки
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, domain.RequestValidationError{Message: "import file has no header"}
+	}
+	if err != nil {
+		return nil, domain.RequestValidationError{Message: fmt.Sprintf("invalid CSV: %s", err)}
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // BOM от Excel
+	}
+	columns, err := csvColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []importRow
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, domain.RequestValidationError{Message: fmt.Sprintf("invalid CSV: %s", err)}
+		}
+		if len(rows) == maxImportRows {
+			return nil, tooManyRows()
+		}
+
+		line, _ := reader.FieldPos(0)
+		var row = importRow{Line: line}
+		if len(fields) != len(columns) {
+			row.Err = fmt.Errorf("expected %d fields, got %d", len(columns), len(fields))
+		} else {
+			row.Record, row.Err = csvRecord(columns, fields)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// csvColumns - имена колонок заголовка без учёта регистра; неизвестная или повторная колонка - ошибка файла
+func csvColumns(header []string) ([]string, error) {
+	var columns = make([]string, 0, len(header))
+	var seen = make(map[string]bool, len(header))
+	for _, name := range header {
+		var column = importColumn(strings.TrimSpace(name))
+		if column == "" {
+			return nil, domain.RequestValidationError{
+				Message: fmt.Sprintf("unknown column %q, expected: %s", name, strings.Join(importColumns, ", ")),
+			}
+		}
+		if seen[column] {
+			return nil, domain.RequestValidationError{Message: fmt.Sprintf("duplicate column %q", name)}
+		}
+		seen[column] = true
+		columns = append(columns, column)
+	}
+	if !seen["login"] {
+		return nil, domain.RequestValidationError{Message: "column login is required: employees are matched by login"}
+	}
+	return columns, nil
+}
+
+func importColumn(name string) string {
+	for _, column := range importColumns {
+		if strings.EqualFold(column, name) {
+			return column
+		}
+	}
+	return ""
+}
+
+func csvRecord(columns []string, fields []string) (ImportRecord, error) {
+	var record ImportRecord
+	for i, column := range columns {
+		var value = fields[i]
+		switch column {
+		case "name":
+			record.Name = &value
+		case "login":
+			record.Login = &value
+		case "email":
+			record.Email = &value
+		case "department":
+			record.Department = &value
+		case "title":
+			record.Title = &value
+		case "managerId":
+			var managerId int64
+			if value = strings.TrimSpace(value); value != "" {
+				var err error
+				if managerId, err = strconv.ParseInt(value, 10, 64); err != nil {
+					return ImportRecord{}, fmt.Errorf("managerId %q must be an integer", value)
+				}
+			}
+			record.ManagerId = &managerId
+		case "startDate":
+			record.StartDate = &value
+		case "endDate":
+			record.EndDate = &value
+		case "phone":
+			record.Phone = &value
+		case "status":
+			record.Status = &value
+		}
+	}
+	return record, nil
+}
+
+func parseNdjson(body io.Reader) ([]importRow, error) {
+	var scanner = bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var rows []importRow
+	var line int
+	for scanner.Scan() {
+		line++
+		var text = bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, tooManyRows()
+		}
+
+		var row = importRow{Line: line}
+		var decoder = json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.Record); err != nil {
+			row.Record = ImportRecord{}
+			row.Err = fmt.Errorf("invalid JSON: %w", err)
+		} else if decoder.More() {
+			row.Err = errors.New("invalid JSON: one object per line expected")
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, domain.RequestValidationError{Message: fmt.Sprintf("invalid NDJSON at line %d: %s", line+1, err)}
+	}
+	return rows, nil
+}
+
+func tooManyRows() error {
+	return domain.RequestValidationError{
+		Message: fmt.Sprintf("import file exceeds %d rows, split it into several imports", maxImportRows),
+	}
+}
+
+func isDomainError(err error) bool {
+	var validationErr domain.RequestValidationError
+	var alreadyExistsErr domain.AlreadyExistsError
+	var conflictErr domain.ConflictError
+	var notFoundErr domain.NotFoundError
+	return errors.As(err, &validationErr) || errors.As(err, &alreadyExistsErr) ||
+		errors.As(err, &conflictErr) || errors.As(err, &notFoundErr)
+}
+
+func valueOrZero(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,196 @@
+package employee
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImport(t *testing.T) {
+	var a = assert.New(t)
+
+	t.Run("csv with partial columns and row errors", func(t *testing.T) {
+		rows, err := parseImport(ImportCsv, strings.NewReader(
+			"\ufeffLogin,name,managerId,startDate\n"+
+				"jsena,John Sena,,2025-08-01\n"+
+				"\n"+
+				"bad,Bad Row,abc,\n"+
+				"short,Short\n",
+		))
+		a.NoError(err)
+		a.Len(rows, 3)
+
+		a.Equal(2, rows[0].Line) // номер строки файла с учётом заголовка
+		a.NoError(rows[0].Err)
+		a.Equal("jsena", *rows[0].Record.Login)
+		a.Equal(int64(0), *rows[0].Record.ManagerId) // пустое значение очищает руководителя
+		a.Equal("2025-08-01", *rows[0].Record.StartDate)
+		a.Nil(rows[0].Record.Email) // колонки нет - атрибут не меняется
+
+		a.Equal(4, rows[1].Line)
+		a.ErrorContains(rows[1].Err, "managerId")
+		a.ErrorContains(rows[2].Err, "expected 4 fields, got 2")
+	})
+
+	t.Run("csv with unknown or without login column", func(t *testing.T) {
+		_, err := parseImport(ImportCsv, strings.NewReader("login,salary\njsena,100\n"))
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		a.ErrorContains(err, "unknown column")
+
+		_, err = parseImport(ImportCsv, strings.NewReader("name\nJohn Sena\n"))
+		a.ErrorContains(err, "column login is required")
+
+		_, err = parseImport(ImportCsv, strings.NewReader("login\n"))
+		a.ErrorContains(err, "no rows")
+	})
+
+	t.Run("ndjson with row errors", func(t *testing.T) {
+		rows, err := parseImport(ImportNdjson, strings.NewReader(
+			`{"login":"jsena","name":"John Sena","managerId":2}`+"\n"+
+				"\n"+
+				`{"login":"bad","salary":100}`+"\n"+
+				`{"login":`+"\n",
+		))
+		a.NoError(err)
+		a.Len(rows, 3)
+		a.NoError(rows[0].Err)
+		a.Equal(int64(2), *rows[0].Record.ManagerId)
+		a.Nil(rows[0].Record.Email)
+		a.Equal(3, rows[1].Line)
+		a.ErrorContains(rows[1].Err, "unknown field")
+		a.ErrorContains(rows[2].Err, "invalid JSON")
+	})
+
+	t.Run("too many rows", func(t *testing.T) {
+		var file = strings.Repeat(`{"login":"jsena"}`+"\n", maxImportRows+1)
+		_, err := parseImport(ImportNdjson, strings.NewReader(file))
+		a.ErrorContains(err, "exceeds")
+	})
+
+	t.Run("import date", func(t *testing.T) {
+		date, err := parseImportDate("startDate", "2025-08-01")
+		a.NoError(err)
+		a.Equal(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), *date)
+
+		date, err = parseImportDate("startDate", "")
+		a.NoError(err)
+		a.Nil(date)
+
+		_, err = parseImportDate("startDate", "01.08.2025")
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+	})
+}
+
+func TestService_Import(t *testing.T) {
+	var a = assert.New(t)
+	var appContext = context.Background()
+	var now = time.Now()
+	var login = "jsena"
+
+	var existing = Entity{Id: 7, Name: "John Sena", Login: &login, Department: "IT", CreatedAt: now, UpdatedAt: now}
+	var file = "login,name,department\n" +
+		"new.one,New One,Sales\n" +
+		"JSENA,,Finance\n" +
+		"jsena,Again,IT\n"
+
+	var setup = func() (*MockRepo, *Service) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		validator.On("Validate", mock.Anything).Return(nil)
+		return repo, NewService(repo, validator)
+	}
+
+	t.Run("best effort import creates, updates and reports failed rows", func(t *testing.T) {
+		repo, service := setup()
+		repo.On("FindByLoginTx", appContext, mock.Anything, "new.one").Return(Entity{}, sql.ErrNoRows).Once()
+		repo.On("CreateEntityTx", appContext, mock.Anything, mock.MatchedBy(func(e *Entity) bool {
+			return e.Name == "New One" && *e.Login == "new.one" && e.Department == "Sales" && e.Status == StatusActive
+		})).Return(int64(8), nil).Once()
+		repo.On("FindByLoginTx", appContext, mock.Anything, "JSENA").Return(existing, nil).Once()
+		repo.On("UpdateEntityTx", appContext, mock.Anything, mock.MatchedBy(func(e *Entity) bool {
+			// имя не задано в файле и не меняется, регистр login сохраняется
+			return e.Id == 7 && e.Name == "John Sena" && *e.Login == "jsena" && e.Department == "Finance"
+		})).Return(nil).Once()
+
+		got, err := service.Import(appContext, ImportRequest{Format: ImportCsv, Mode: ImportBestEffort}, strings.NewReader(file))
+		a.NoError(err)
+		a.True(got.Committed)
+		a.Equal(3, got.Total)
+		a.Equal(1, got.Created)
+		a.Equal(1, got.Updated)
+		a.Equal(1, got.Failed)
+		a.Equal(ImportRowResult{Line: 2, Login: "new.one", Action: ImportCreated, Id: 8}, got.Rows[0])
+		a.Equal(ImportRowResult{Line: 3, Login: "JSENA", Action: ImportUpdated, Id: 7}, got.Rows[1])
+		a.Equal(ImportFailed, got.Rows[2].Action)
+		a.Contains(got.Rows[2].Error, "already imported at line 3")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("atomic import with failed row is rolled back", func(t *testing.T) {
+		repo, service := setup()
+		repo.On("FindByLoginTx", appContext, mock.Anything, "new.one").Return(Entity{}, sql.ErrNoRows).Once()
+		repo.On("CreateEntityTx", appContext, mock.Anything, mock.Anything).Return(int64(8), nil).Once()
+		repo.On("FindByLoginTx", appContext, mock.Anything, "JSENA").Return(existing, nil).Once()
+		repo.On("UpdateEntityTx", appContext, mock.Anything, mock.Anything).Return(nil).Once()
+
+		got, err := service.Import(appContext, ImportRequest{Format: ImportCsv, Mode: ImportAtomic}, strings.NewReader(file))
+		a.NoError(err)
+		a.False(got.Committed)
+		a.Equal(1, got.Failed)
+		a.Zero(got.Rows[0].Id) // созданный сотрудник откачен
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("dry run is never committed", func(t *testing.T) {
+		repo, service := setup()
+		repo.On("FindByLoginTx", appContext, mock.Anything, "jsena").Return(existing, nil).Once()
+		repo.On("FindByIdTx", appContext, mock.Anything, int64(3)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		got, err := service.Import(
+			appContext,
+			ImportRequest{Format: ImportNdjson, Mode: ImportBestEffort, DryRun: true},
+			strings.NewReader(`{"login":"jsena","managerId":3}`),
+		)
+		a.NoError(err)
+		a.True(got.DryRun)
+		a.False(got.Committed)
+		a.Equal(1, got.Failed)
+		a.Contains(got.Rows[0].Error, "manager with id 3 not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("deleted employee and taken email are row errors", func(t *testing.T) {
+		repo, service := setup()
+		var deleted = existing
+		deleted.DeletedAt = &now
+		repo.On("FindByLoginTx", appContext, mock.Anything, "jsena").Return(deleted, nil).Once()
+		repo.On("FindByLoginTx", appContext, mock.Anything, "other").Return(Entity{}, sql.ErrNoRows).Once()
+		repo.On("ExistsByEmailTx", appContext, mock.Anything, "taken@example.com", int64(0)).Return(true, nil).Once()
+
+		got, err := service.Import(appContext, ImportRequest{Format: ImportCsv, Mode: ImportBestEffort}, strings.NewReader(
+			"login,name,email\njsena,John Sena,\nother,Other One,taken@example.com\n",
+		))
+		a.NoError(err)
+		a.Equal(2, got.Failed)
+		a.Contains(got.Rows[0].Error, "is deleted")
+		a.Contains(got.Rows[1].Error, "email already exists")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repository error aborts import", func(t *testing.T) {
+		repo, service := setup()
+		repo.On("FindByLoginTx", appContext, mock.Anything, "jsena").Return(Entity{}, errors.New("connection reset")).Once()
+
+		_, err := service.Import(appContext, ImportRequest{Format: ImportCsv, Mode: ImportBestEffort}, strings.NewReader(
+			"login\njsena\n",
+		))
+		a.ErrorContains(err, "connection reset")
+		a.False(errors.As(err, &domain.RequestValidationError{}))
+	})
+}
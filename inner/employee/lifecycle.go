@@ -0,0 +1,53 @@
+package employee
+
+import (
+	"fmt"
+	"idm/inner/domain"
+)
+
+// Состояния жизненного цикла сотрудника
+const (
+	StatusPreHire    = "pre_hire"   // принят, но ещё не вышел на работу
+	StatusActive     = "active"     // работает
+	StatusSuspended  = "suspended"  // временно отстранён, назначения ролей сохраняются
+	StatusTerminated = "terminated" // уволен, назначения ролей отозваны
+)
+
+// Действия, меняющие состояние сотрудника
+const (
+	LifecycleActivate  = "activate"
+	LifecycleSuspend   = "suspend"
+	LifecycleTerminate = "terminate"
+	LifecycleRehire    = "rehire"
+)
+
+// transition - из каких состояний допустимо действие и в какое состояние оно переводит
+type transition struct {
+	from []string
+	to   string
+}
+
+// transitions - допустимые переходы. Уволенного сотрудника вернуть можно только повторным приёмом (rehire)
+var transitions = map[string]transition{
+	LifecycleActivate:  {from: []string{StatusPreHire, StatusSuspended}, to: StatusActive},
+	LifecycleSuspend:   {from: []string{StatusActive}, to: StatusSuspended},
+	LifecycleTerminate: {from: []string{StatusPreHire, StatusActive, StatusSuspended}, to: StatusTerminated},
+	LifecycleRehire:    {from: []string{StatusTerminated}, to: StatusPreHire},
+}
+
+// nextStatus - состояние после действия action над сотрудником в состоянии status,
+// ConflictError если переход недопустим
+func nextStatus(action string, status string) (string, error) {
+	var rule, ok = transitions[action]
+	if !ok {
+		return "", domain.RequestValidationError{Message: fmt.Sprintf("unknown lifecycle action %q", action)}
+	}
+	for _, from := range rule.from {
+		if from == status {
+			return rule.to, nil
+		}
+	}
+	return "", domain.ConflictError{
+		Message: fmt.Sprintf("cannot %s employee in status %s", action, status),
+	}
+}
@@ -0,0 +1,51 @@
+package employee
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/domain"
+	"testing"
+)
+
+func TestNextStatus(t *testing.T) {
+	var a = assert.New(t)
+
+	var allowed = []struct {
+		action string
+		from   string
+		to     string
+	}{
+		{LifecycleActivate, StatusPreHire, StatusActive},
+		{LifecycleActivate, StatusSuspended, StatusActive},
+		{LifecycleSuspend, StatusActive, StatusSuspended},
+		{LifecycleTerminate, StatusPreHire, StatusTerminated},
+		{LifecycleTerminate, StatusActive, StatusTerminated},
+		{LifecycleTerminate, StatusSuspended, StatusTerminated},
+		{LifecycleRehire, StatusTerminated, StatusPreHire},
+	}
+	for _, tc := range allowed {
+		got, err := nextStatus(tc.action, tc.from)
+		a.NoError(err, "%s from %s", tc.action, tc.from)
+		a.Equal(tc.to, got, "%s from %s", tc.action, tc.from)
+	}
+
+	var forbidden = []struct {
+		action string
+		from   string
+	}{
+		{LifecycleActivate, StatusActive},
+		{LifecycleActivate, StatusTerminated}, // вернуть уволенного можно только через rehire
+		{LifecycleSuspend, StatusPreHire},
+		{LifecycleSuspend, StatusSuspended},
+		{LifecycleSuspend, StatusTerminated},
+		{LifecycleTerminate, StatusTerminated},
+		{LifecycleRehire, StatusActive},
+	}
+	for _, tc := range forbidden {
+		_, err := nextStatus(tc.action, tc.from)
+		a.True(errors.As(err, &domain.ConflictError{}), "%s from %s", tc.action, tc.from)
+	}
+
+	_, err := nextStatus("promote", StatusActive)
+	a.True(errors.As(err, &domain.RequestValidationError{}))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/mock"
+	"io"
 )
 
 type MockEmployeeService struct {
@@ -15,13 +16,28 @@ func (m *MockEmployeeService) GetAllByPage(ctx context.Context, req PageRequest)
 	return args.Get(0).(PageResponse), args.Error(1) // Важно: правильный тип
 }
 
-func (m *MockEmployeeService) FindAll(ctx context.Context) ([]Response, error) {
-	args := m.Called(ctx)
+func (m *MockEmployeeService) GetKeysetPage(ctx context.Context, request KeysetRequest) (KeysetPage, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(KeysetPage), args.Error(1)
+}
+
+func (m *MockEmployeeService) FindFiltered(ctx context.Context, request FilterRequest) (FilterPage, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(FilterPage), args.Error(1)
+}
+
+func (m *MockEmployeeService) FindRolesByEmployeeIds(ctx context.Context, employeeIds []int64) (map[int64][]RoleResponse, error) {
+	args := m.Called(ctx, employeeIds)
+	return args.Get(0).(map[int64][]RoleResponse), args.Error(1)
+}
+
+func (m *MockEmployeeService) FindAll(ctx context.Context, includeDeleted bool) ([]Response, error) {
+	args := m.Called(ctx, includeDeleted)
 	return args.Get(0).([]Response), args.Error(1) // Важно: правильный тип
 }
 
-func (m *MockEmployeeService) FindAllByIds(ctx context.Context, ids []int64) ([]Response, error) {
-	args := m.Called(ctx, ids)
+func (m *MockEmployeeService) FindAllByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Response, error) {
+	args := m.Called(ctx, ids, includeDeleted)
 	return args.Get(0).([]Response), args.Error(1) // Важно: правильный тип
 }
 
@@ -60,9 +76,62 @@ func (m *MockEmployeeService) CloseTx(tx *sqlx.Tx, err error, s string) {
 	panic("implement me")
 }
 
-func (m *MockEmployeeService) FindById(ctx context.Context, id int64) (Response, error) {
+func (m *MockEmployeeService) ChangeStatus(ctx context.Context, id int64, action string) (Response, error) {
+	args := m.Called(ctx, id, action)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockEmployeeService) FindReports(ctx context.Context, id int64, depth int64) ([]HierarchyResponse, error) {
+	args := m.Called(ctx, id, depth)
+	return args.Get(0).([]HierarchyResponse), args.Error(1)
+}
+
+func (m *MockEmployeeService) FindChainOfCommand(ctx context.Context, id int64) ([]HierarchyResponse, error) {
 	args := m.Called(ctx, id)
+	return args.Get(0).([]HierarchyResponse), args.Error(1)
+}
+
+func (m *MockEmployeeService) Restore(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockEmployeeService) FindById(ctx context.Context, id int64, includeDeleted bool) (Response, error) {
+	args := m.Called(ctx, id, includeDeleted)
 	return args.Get(0).(Response), args.Error(1) // Важно: правильный тип
 }
 
+func (m *MockEmployeeService) FindRoles(ctx context.Context, employeeId int64) ([]RoleResponse, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).([]RoleResponse), args.Error(1) // Важно: правильный тип
+}
+
+func (m *MockEmployeeService) AssignRole(ctx context.Context, employeeId int64, request AssignRoleRequest) ([]RoleResponse, error) {
+	args := m.Called(ctx, employeeId, request)
+	return args.Get(0).([]RoleResponse), args.Error(1) // Важно: правильный тип
+}
+
+func (m *MockEmployeeService) RevokeRole(ctx context.Context, employeeId int64, roleId int64) ([]RoleResponse, error) {
+	args := m.Called(ctx, employeeId, roleId)
+	return args.Get(0).([]RoleResponse), args.Error(1) // Важно: правильный тип
+}
+
+// Import - тело передаётся в ожидание строкой, чтобы тесты проверяли переданный файл
+func (m *MockEmployeeService) Import(ctx context.Context, request ImportRequest, body io.Reader) (ImportResponse, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ImportResponse{}, err
+	}
+	args := m.Called(ctx, request, string(data))
+	return args.Get(0).(ImportResponse), args.Error(1)
+}
+
+func (m *MockEmployeeService) Export(ctx context.Context, request ExportRequest) (func(w io.Writer) error, error) {
+	args := m.Called(ctx, request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(func(w io.Writer) error), args.Error(1)
+}
+
 // Добавьте остальные методы интерфейса
@@ -2,13 +2,27 @@ package employee
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"idm/inner/outbox"
+	"idm/inner/pagination"
+	"idm/inner/sod"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// hierarchyLockKey - ключ pg_advisory_xact_lock, сериализующий смену руководителей
+const hierarchyLockKey int64 = 0x6d616e6167657273 // "managers"
+
+// ErrManagerCycle - новый руководитель уже подчинён сотруднику (напрямую или косвенно)
+var ErrManagerCycle = errors.New("manager is a direct or indirect report of the employee")
+
 // Repository - infra layer
 type Repository struct {
 	db *sqlx.DB
@@ -24,11 +38,11 @@ func (r *Repository) BeginTransaction() (tx *sqlx.Tx, err error) {
 	return r.db.Beginx()
 }
 
-// FindAllEmployees - найти все элементы коллекции
-func (r *Repository) FindAllEmployees(ctx context.Context) (employees []Entity, err error) {
+// FindAllEmployees - найти все элементы коллекции (мягко удалённые - только при includeDeleted)
+func (r *Repository) FindAllEmployees(ctx context.Context, includeDeleted bool) (employees []Entity, err error) {
 	//	err = r.db.Select(&employees, "SELECT * FROM employees")
-	query := `SELECT * FROM employees`
-	err = r.db.SelectContext(ctx, &employees, query)
+	query := `SELECT * FROM employees WHERE ($1 OR deleted_at IS NULL)`
+	err = r.db.SelectContext(ctx, &employees, query, includeDeleted)
 
 	return employees, err
 }
@@ -37,10 +51,12 @@ func (r *Repository) FindAllEmployees(ctx context.Context) (employees []Entity,
 // LIMIT number_of_rows: Определяет максимальное количество строк, которое будет возвращено запросом.
 // OFFSET starting_row: Указывает, сколько строк нужно пропустить в начале набора результатов, прежде чем начать выборку.
 // TextFilter не менее, 3 не пробельных (" ", "\n", "\t" и т.п.) символов.
+// Мягко удалённые сотрудники попадают в выборку только при includeDeleted.
 func (r *Repository) GetPageByValues(
 	ctx context.Context,
 	pageValues []int64, // [pageSize, offset]
 	textFilter string,
+	includeDeleted bool,
 ) ([]Entity, int64, error) {
 	// 1. Валидация pageValues
 	if len(pageValues) != 2 {
@@ -48,19 +64,19 @@ func (r *Repository) GetPageByValues(
 	}
 
 	// 2. Подготовка запросов
-	baseQuery := "SELECT id, name, created_at, updated_at FROM employees"
-	countQuery := "SELECT COUNT(*) FROM employees"
+	baseQuery := "SELECT " + employeeColumns + " FROM employees WHERE ($1 OR deleted_at IS NULL)"
+	countQuery := "SELECT COUNT(*) FROM employees WHERE ($1 OR deleted_at IS NULL)"
 
-	var args []interface{}
-	paramCounter := 1
+	var args = []interface{}{includeDeleted}
+	paramCounter := 2
 
 	// 3. Добавляем фильтр только если он не пустой
 	if textFilter != "" {
 		filteredText := strings.TrimSpace(textFilter)
 		if len(filteredText) >= 3 {
 			safePattern := "%" + strings.ReplaceAll(filteredText, "%", "\\%") + "%"
-			baseQuery += fmt.Sprintf(" WHERE name ILIKE $%d", paramCounter)
-			countQuery += fmt.Sprintf(" WHERE name ILIKE $%d", paramCounter)
+			baseQuery += fmt.Sprintf(" AND name ILIKE $%d", paramCounter)
+			countQuery += fmt.Sprintf(" AND name ILIKE $%d", paramCounter)
 			args = append(args, safePattern)
 			paramCounter++
 		}
@@ -86,12 +102,143 @@ func (r *Repository) GetPageByValues(
 	return employees, total, nil
 }
 
+// Export - пройти сотрудников (мягко удалённых - при includeDeleted) по порядку id серверным курсором,
+// передавая каждого в fn. textFilter - подстрока имени, как в GetPageByValues; роли - действующие прямые назначения
+func (r *Repository) Export(
+	ctx context.Context,
+	textFilter string,
+	includeDeleted bool,
+	fn func(entity ExportEntity) error,
+) error {
+	query := "SELECT " + employeeColumns + `,
+		ARRAY(
+			SELECT r.name FROM employee_roles er
+			JOIN roles r ON r.id = er.role_id
+			WHERE er.employee_id = e.id AND r.deleted_at IS NULL
+				AND er.valid_from <= NOW() AND (er.valid_until IS NULL OR er.valid_until > NOW())
+			ORDER BY r.name
+		) AS roles
+		FROM employees e WHERE ($1 OR deleted_at IS NULL)`
+	var args = []any{includeDeleted}
+
+	if filteredText := strings.TrimSpace(textFilter); len(filteredText) >= 3 {
+		args = append(args, "%"+strings.ReplaceAll(filteredText, "%", "\\%")+"%")
+		query += " AND name ILIKE $2"
+	}
+	query += " ORDER BY id"
+
+	return database.StreamCursor(ctx, r.db, "employees_export", query, args, fn)
+}
+
+// FindKeysetPage - страница сотрудников в порядке query.OrderBy() от позиции курсора query (не больше query.Fetch()).
+// textFilter - подстрока имени, как в GetPageByValues
+func (r *Repository) FindKeysetPage(
+	ctx context.Context,
+	query pagination.Query,
+	textFilter string,
+	includeDeleted bool,
+) (employees []Entity, err error) {
+	selectQuery := "SELECT " + employeeColumns + " FROM employees WHERE ($1 OR deleted_at IS NULL)"
+	var args = []any{includeDeleted}
+
+	if filteredText := strings.TrimSpace(textFilter); len(filteredText) >= 3 {
+		args = append(args, "%"+strings.ReplaceAll(filteredText, "%", "\\%")+"%")
+		selectQuery += fmt.Sprintf(" AND name ILIKE $%d", len(args))
+	}
+	if condition, conditionArgs := query.Condition(len(args) + 1); condition != "" {
+		args = append(args, conditionArgs...)
+		selectQuery += " AND " + condition
+	}
+	args = append(args, query.Fetch())
+	selectQuery += fmt.Sprintf(" ORDER BY %s LIMIT $%d", query.OrderBy(), len(args))
+
+	err = r.db.SelectContext(ctx, &employees, selectQuery, args...)
+	return employees, err
+}
+
+// CountEmployees - число сотрудников по тем же условиям, что и FindKeysetPage, без позиции курсора
+func (r *Repository) CountEmployees(ctx context.Context, textFilter string, includeDeleted bool) (total int64, err error) {
+	query := "SELECT COUNT(*) FROM employees WHERE ($1 OR deleted_at IS NULL)"
+	var args = []any{includeDeleted}
+
+	if filteredText := strings.TrimSpace(textFilter); len(filteredText) >= 3 {
+		args = append(args, "%"+strings.ReplaceAll(filteredText, "%", "\\%")+"%")
+		query += " AND name ILIKE $2"
+	}
+	err = r.db.GetContext(ctx, &total, query, args...)
+	return total, err
+}
+
+// FindFilteredPage - страница не удалённых сотрудников по порядку id, удовлетворяющих фильтру
+// (пустое поле - все), и их общее число
+func (r *Repository) FindFilteredPage(
+	ctx context.Context,
+	field string,
+	op string,
+	value string,
+	offset int64,
+	limit int64,
+) (employees []Entity, total int64, err error) {
+	condition, args := filterCondition(field, op, value)
+	where := " FROM employees WHERE deleted_at IS NULL AND " + condition
+
+	err = r.db.GetContext(ctx, &total, "SELECT COUNT(*)"+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+	if total == 0 || limit == 0 {
+		return []Entity{}, total, nil
+	}
+
+	query := fmt.Sprintf("SELECT %s%s ORDER BY id LIMIT $%d OFFSET $%d", employeeColumns, where, len(args)+1, len(args)+2)
+	err = r.db.SelectContext(ctx, &employees, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get employees: %w", err)
+	}
+
+	return employees, total, nil
+}
+
+// filterCondition - условие FindFilteredPage. Равенство login использует индекс по lower(login),
+// login или email - имя пользователя SCIM: login, а если он пуст - email
+func filterCondition(field string, op string, value string) (string, []any) {
+	switch field {
+	case FilterFieldId:
+		if op == FilterOpEq {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return "FALSE", nil
+			}
+			return "id = $1", []any{id}
+		}
+		return `id::TEXT LIKE $1 ESCAPE '\'`, []any{likePattern(op, value)}
+	case FilterFieldUserName:
+		if op == FilterOpEq {
+			return `(LOWER(login) = $1 OR (COALESCE(login, '') = '' AND LOWER(email) = $1))`,
+				[]any{strings.ToLower(value)}
+		}
+		return `LOWER(COALESCE(NULLIF(login, ''), email, '')) LIKE $1 ESCAPE '\'`,
+			[]any{strings.ToLower(likePattern(op, value))}
+	}
+	return "TRUE", nil
+}
+
+// likePattern - шаблон LIKE для sw (префикс) и co (подстрока) с экранированием спецсимволов
+func likePattern(op string, value string) string {
+	var escaped = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+	if op == FilterOpSw {
+		return escaped + "%"
+	}
+	return "%" + escaped + "%"
+}
+
 // FindAllEmployeesByIds - найти слайс элементов коллекции по слайсу их id
 func (r *Repository) FindAllEmployeesByIds(
 	ctx context.Context,
 	ids []int64,
+	includeDeleted bool,
 ) (employees []Entity, err error) {
-	query, args, err := sqlx.In("SELECT * FROM employees WHERE id IN (?)", ids)
+	query, args, err := sqlx.In("SELECT * FROM employees WHERE id IN (?) AND (? OR deleted_at IS NULL)", ids, includeDeleted)
 
 	if err != nil {
 		return nil, err
@@ -104,13 +251,38 @@ func (r *Repository) FindAllEmployeesByIds(
 }
 
 // FindById - найти элемент коллекции по его id
-func (r *Repository) FindById(ctx context.Context, id int64) (employee Entity, err error) {
+func (r *Repository) FindById(ctx context.Context, id int64, includeDeleted bool) (employee Entity, err error) {
 	//err = r.db.Get(&employee, "SELECT * FROM employees WHERE id = $1", id)
-	err = r.db.GetContext(ctx, &employee, "SELECT * FROM employees WHERE id = $1", id)
+	err = r.db.GetContext(
+		ctx,
+		&employee,
+		"SELECT * FROM employees WHERE id = $1 AND ($2 OR deleted_at IS NULL)",
+		id, includeDeleted,
+	)
 
 	return employee, err
 }
 
+// ExistsByLoginOrEmail - проверить, занят ли login или email другим сотрудником (без учёта регистра).
+// Пустые значения не проверяются, мягко удалённые сотрудники учитываются: при восстановлении
+// их login и email снова станут действующими
+func (r *Repository) ExistsByLoginOrEmail(
+	ctx context.Context,
+	login string,
+	email string,
+	excludeId int64,
+) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		`SELECT EXISTS(SELECT 1 FROM employees WHERE id <> $3
+		AND (($1 <> '' AND lower(login) = lower($1)) OR ($2 <> '' AND lower(email) = lower($2))))`,
+		login, email, excludeId,
+	)
+
+	return isExists, err
+}
+
 // FindByNameTx - Проверить наличие в базе данных сотрудника с заданным именем
 func (r *Repository) FindByNameTx(
 	ctx context.Context,
@@ -125,13 +297,13 @@ func (r *Repository) FindByNameTx(
 	err = tx.GetContext(
 		ctx,
 		&isExists,
-		"select exists(select 1 from employees where name = $1)",
+		"select exists(select 1 from employees where name = $1 and deleted_at is null)",
 		name)
 
 	return isExists, err
 }
 
-// CreateEntityTx - created Employee using DB Transaction
+// CreateEntityTx - created Employee using DB Transaction (событие аудита пишется в той же транзакции)
 func (r *Repository) CreateEntityTx(
 	ctx context.Context,
 	tx *sqlx.Tx,
@@ -143,13 +315,19 @@ func (r *Repository) CreateEntityTx(
 	//	entity.Name, time.Now(), time.Now(),
 	//)
 
+	var created Entity
 	err = tx.GetContext(
 		ctx,
-		&employeeId,
-		"INSERT INTO employees(name, created_at, updated_at) VALUES($1, $2, $3) RETURNING id",
-		entity.Name, time.Now(), time.Now(),
+		&created,
+		insertEmployeeQuery,
+		insertArgs(entity)...,
 	)
-	return employeeId, err
+	if err != nil {
+		return 0, err
+	}
+
+	err = outbox.RecordTx(ctx, tx, createdEvent(created))
+	return created.Id, err
 }
 
 // CreateEmployee - добавить новый элемент в коллекцию
@@ -159,12 +337,15 @@ func (r *Repository) CreateEmployee(
 ) (result Entity, err error) {
 
 	//query, args, err := sqlx.In("INSERT INTO employees(name, created_at, updated_at) VALUES($1, NOW(), NOW()) RETURNING *", entity.Name)
-	query := `
-		INSERT INTO employees(name, created_at, updated_at) VALUES($1, NOW(), NOW()) RETURNING *
-	`
-	args := []interface{}{entity.Name}
+	query := insertEmployeeQuery
+	args := insertArgs(entity)
 
-	err = r.db.GetContext(ctx, &result, query, args...)
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &result, query, args...); err != nil {
+			return err
+		}
+		return outbox.RecordTx(ctx, tx, createdEvent(result))
+	})
 	log.Printf("Result Employee ->> %v", result)
 
 	return result, err
@@ -179,36 +360,538 @@ func (r *Repository) UpdateEmployee(
 	//	"UPDATE employees SET name = $1, updated_at = $2 WHERE id = $3",
 	//	entity.Name, time.Now(), entity.Id)
 
-	_, err := r.db.ExecContext(
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		return r.UpdateEntityTx(ctx, tx, entity)
+	})
+}
+
+// UpdateEntityTx - изменить имя и профиль сотрудника в транзакции tx (событие аудита пишется в ней же).
+// Удалённого или несуществующего сотрудника не меняет и события не пишет
+func (r *Repository) UpdateEntityTx(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	entity *Entity,
+) error {
+	var before Entity
+	err := tx.GetContext(
+		ctx,
+		&before,
+		"SELECT * FROM employees WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+		entity.Id,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // обновлять нечего, событие не пишем
+	}
+	if err != nil {
+		return err
+	}
+
+	if entity.ManagerId != nil {
+		if err = checkManagerCycleTx(ctx, tx, *entity.ManagerId, entity.Id); err != nil {
+			return err
+		}
+	}
+
+	var after Entity
+	err = tx.GetContext(
 		ctx,
-		"UPDATE employees SET name = $1, updated_at = $2 WHERE id = $3",
-		entity.Name, time.Now(), entity.Id)
-	return err
+		&after,
+		`UPDATE employees SET name = $1, login = $2, email = $3, department = $4, title = $5, manager_id = $6,
+		start_date = $7, end_date = $8, phone = $9, updated_at = $10
+		WHERE id = $11 RETURNING *`,
+		entity.Name, entity.Login, entity.Email, entity.Department, entity.Title, entity.ManagerId,
+		entity.StartDate, entity.EndDate, entity.Phone, time.Now(), entity.Id)
+	if err != nil {
+		return err
+	}
+
+	return outbox.RecordTx(ctx, tx, audit.Event{
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityEmployee,
+		EntityId:   after.Id,
+		Before:     before.ToResponse(),
+		After:      after.ToResponse(),
+	})
 }
 
-// DeleteAllEmployeesByIds - удалить элементы по слайсу их id
+// WithTx - выполнить fn в транзакции репозитория (см. database.WithTx)
+func (r *Repository) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+// SavepointTx - выполнить fn в точке сохранения транзакции tx (см. database.WithSavepoint)
+func (r *Repository) SavepointTx(ctx context.Context, tx *sqlx.Tx, name string, fn func() error) error {
+	return database.WithSavepoint(ctx, tx, name, fn)
+}
+
+// FindByLoginTx - найти сотрудника по login без учёта регистра, в том числе мягко удалённого,
+// и заблокировать его строку до конца транзакции
+func (r *Repository) FindByLoginTx(ctx context.Context, tx *sqlx.Tx, login string) (employee Entity, err error) {
+	err = tx.GetContext(
+		ctx,
+		&employee,
+		"SELECT * FROM employees WHERE lower(login) = lower($1) FOR UPDATE",
+		login,
+	)
+
+	return employee, err
+}
+
+// FindByIdTx - найти не удалённого сотрудника по id в транзакции tx: видны и созданные в ней
+func (r *Repository) FindByIdTx(ctx context.Context, tx *sqlx.Tx, id int64) (employee Entity, err error) {
+	err = tx.GetContext(ctx, &employee, "SELECT * FROM employees WHERE id = $1 AND deleted_at IS NULL", id)
+
+	return employee, err
+}
+
+// ExistsByEmailTx - занят ли email другим сотрудником (без учёта регистра, с учётом мягко удалённых)
+func (r *Repository) ExistsByEmailTx(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	email string,
+	excludeId int64,
+) (isExists bool, err error) {
+	err = tx.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM employees WHERE id <> $2 AND lower(email) = lower($1))",
+		email, excludeId,
+	)
+
+	return isExists, err
+}
+
+// DeleteAllEmployeesByIds - мягко удалить элементы по слайсу их id (назначения ролей сохраняются)
 func (r *Repository) DeleteAllEmployeesByIds(
 	ctx context.Context,
 	ids []int64,
 ) error {
 
-	query, args, err := sqlx.In("DELETE FROM employees WHERE id IN (?)", ids)
+	query, args, err := sqlx.In(
+		"UPDATE employees SET deleted_at = ? WHERE id IN (?) AND deleted_at IS NULL RETURNING *",
+		time.Now(), ids,
+	)
 	if err != nil {
 		return err
 	}
 	query = r.db.Rebind(query)
 	//_, err = r.db.Exec(query, args...)
-	_, err = r.db.ExecContext(ctx, query, args...)
-	return err
+	return r.changeTx(ctx, audit.ActionDelete, query, args...)
 }
 
-// DeleteEmployeeById - удалить элемент коллекции по его id
+// DeleteEmployeeById - мягко удалить элемент коллекции по его id (назначения ролей сохраняются)
 func (r *Repository) DeleteEmployeeById(
 	ctx context.Context,
 	id int64,
 ) error {
 	//	_, err := r.db.Exec("DELETE FROM employees WHERE id = $1", id)
-	_, err := r.db.ExecContext(ctx, "DELETE FROM employees WHERE id = $1", id)
+	return r.changeTx(
+		ctx,
+		audit.ActionDelete,
+		"UPDATE employees SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL RETURNING *",
+		id, time.Now(),
+	)
+}
+
+// RestoreEmployee - восстановить мягко удалённого сотрудника, возвращает false если такого нет
+func (r *Repository) RestoreEmployee(ctx context.Context, id int64) (isRestored bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before Entity
+		err := tx.GetContext(
+			ctx,
+			&before,
+			"SELECT * FROM employees WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE",
+			id,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var after Entity
+		err = tx.GetContext(
+			ctx,
+			&after,
+			"UPDATE employees SET deleted_at = NULL, updated_at = $2 WHERE id = $1 RETURNING *",
+			id, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		isRestored = true
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionRestore,
+			EntityType: audit.EntityEmployee,
+			EntityId:   id,
+			Before:     before.ToResponse(),
+			After:      after.ToResponse(),
+		})
+	})
+
+	return isRestored && err == nil, err
+}
+
+// PurgeDeletedEmployees - окончательно удалить сотрудников, мягко удалённых раньше before.
+// Назначения ролей и учётные данные удаляются каскадно. Возвращает число удалённых
+func (r *Repository) PurgeDeletedEmployees(ctx context.Context, before time.Time) (purged int64, err error) {
+	var deleted []Entity
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(
+			ctx,
+			&deleted,
+			"DELETE FROM employees WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING *",
+			before,
+		)
+		if err != nil {
+			return err
+		}
+		return r.recordTx(ctx, tx, audit.ActionPurge, deleted)
+	})
+
+	return int64(len(deleted)), err
+}
+
+// changeTx - изменить сотрудников запросом с RETURNING * и записать по событию аудита
+// на каждого затронутого в одной транзакции
+func (r *Repository) changeTx(ctx context.Context, action string, query string, args ...interface{}) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var changed []Entity
+		if err := tx.SelectContext(ctx, &changed, query, args...); err != nil {
+			return err
+		}
+		return r.recordTx(ctx, tx, action, changed)
+	})
+}
+
+// recordTx - события аудита для удалённых сотрудников. При мягком удалении снимок "до" - без deleteAt,
+// "после" - с ним; при окончательном удалении "до" - удалённая строка как есть
+func (r *Repository) recordTx(ctx context.Context, tx *sqlx.Tx, action string, entities []Entity) error {
+	for _, entity := range entities {
+		var event = audit.Event{
+			Action:     action,
+			EntityType: audit.EntityEmployee,
+			EntityId:   entity.Id,
+			Before:     entity.ToResponse(),
+		}
+		if action == audit.ActionDelete {
+			var before = entity
+			before.DeletedAt = nil
+			event.Before = before.ToResponse()
+			event.After = entity.ToResponse()
+		}
+		if err := outbox.RecordTx(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChangeStatus - перевести сотрудника из состояния from в to. При увольнении в той же транзакции
+// отзываются все его назначения ролей. Возвращает false, если сотрудника нет или он уже не в состоянии from
+func (r *Repository) ChangeStatus(ctx context.Context, id int64, from string, to string) (isChanged bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before Entity
+		err := tx.GetContext(
+			ctx,
+			&before,
+			"SELECT * FROM employees WHERE id = $1 AND status = $2 AND deleted_at IS NULL FOR UPDATE",
+			id, from,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var after Entity
+		err = tx.GetContext(
+			ctx,
+			&after,
+			"UPDATE employees SET status = $2, status_changed_at = $3, updated_at = $3 WHERE id = $1 RETURNING *",
+			id, to, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		err = outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionStatusChange,
+			EntityType: audit.EntityEmployee,
+			EntityId:   id,
+			Before:     before.ToResponse(),
+			After:      after.ToResponse(),
+		})
+		if err != nil {
+			return err
+		}
+
+		isChanged = true
+		if to == StatusTerminated {
+			return r.revokeAllRolesTx(ctx, tx, id)
+		}
+		return nil
+	})
+
+	return isChanged && err == nil, err
+}
+
+// revokeAllRolesTx - отозвать все роли сотрудника, по событию аудита на каждое назначение
+func (r *Repository) revokeAllRolesTx(ctx context.Context, tx *sqlx.Tx, employeeId int64) error {
+	var roleIds []int64
+	err := tx.SelectContext(
+		ctx,
+		&roleIds,
+		"DELETE FROM employee_roles WHERE employee_id = $1 RETURNING role_id",
+		employeeId,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, roleId := range roleIds {
+		err := outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionRevoke,
+			EntityType: audit.EntityEmployeeRole,
+			EntityId:   employeeId,
+			Before:     audit.EmployeeRoleSnapshot{EmployeeId: employeeId, RoleId: roleId},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// employeeColumns - столбцы Entity, для выборок, где SELECT * не подходит
+const employeeColumns = `id, name, status, status_changed_at, login, email, department, title, manager_id,
+	start_date, end_date, phone, org_unit_id, created_at, updated_at, deleted_at`
+
+// checkManagerCycleTx - не даёт сотруднику стать своим руководителем, в том числе косвенным.
+// Смены руководителей сериализуются advisory-блокировкой, иначе два встречных изменения
+// (A -> B и B -> A) проверялись бы параллельно и вместе образовали цикл
+func checkManagerCycleTx(ctx context.Context, tx *sqlx.Tx, managerId int64, employeeId int64) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", hierarchyLockKey); err != nil {
+		return err
+	}
+
+	isCycle, err := isInChainOfCommandTx(ctx, tx, managerId, employeeId)
+	if err != nil {
+		return err
+	}
+	if isCycle {
+		return ErrManagerCycle
+	}
+	return nil
+}
+
+// insertEmployeeQuery - добавление сотрудника вместе с атрибутами профиля, аргументы - insertArgs
+const insertEmployeeQuery = `
+	INSERT INTO employees(name, status, login, email, department, title, manager_id, start_date, end_date, phone,
+	created_at, updated_at)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW()) RETURNING *
+`
+
+func insertArgs(entity *Entity) []interface{} {
+	return []interface{}{
+		entity.Name, initialStatus(entity), entity.Login, entity.Email, entity.Department, entity.Title,
+		entity.ManagerId, entity.StartDate, entity.EndDate, entity.Phone,
+	}
+}
+
+// initialStatus - состояние нового сотрудника, по умолчанию active
+func initialStatus(entity *Entity) string {
+	if entity.Status == "" {
+		return StatusActive
+	}
+	return entity.Status
+}
+
+func createdEvent(entity Entity) audit.Event {
+	return audit.Event{
+		Action:     audit.ActionCreate,
+		EntityType: audit.EntityEmployee,
+		EntityId:   entity.Id,
+		After:      entity.ToResponse(),
+	}
+}
+
+// FindReports - подчинённые сотрудника (прямые и косвенные) до глубины depth, ближние первыми.
+// Мягко удалённые сотрудники и их поддеревья не попадают в выборку. path защищает
+// рекурсию от зацикливания, если цикл всё же оказался в данных
+func (r *Repository) FindReports(ctx context.Context, id int64, depth int64) (reports []HierarchyEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&reports,
+		`WITH RECURSIVE reports AS (
+			SELECT e.*, 1 AS level, ARRAY[$1::BIGINT, e.id] AS path
+			FROM employees e
+			WHERE e.manager_id = $1 AND e.deleted_at IS NULL
+			UNION ALL
+			SELECT e.*, r.level + 1, r.path || e.id
+			FROM employees e
+			JOIN reports r ON e.manager_id = r.id
+			WHERE e.deleted_at IS NULL AND r.level < $2 AND NOT e.id = ANY(r.path)
+		)
+		SELECT `+employeeColumns+`, level FROM reports ORDER BY level, name, id`,
+		id, depth,
+	)
+
+	return reports, err
+}
+
+// FindChainOfCommand - руководители сотрудника снизу вверх: непосредственный руководитель, его руководитель
+// и так далее. Цепочка обрывается на мягко удалённом руководителе
+func (r *Repository) FindChainOfCommand(ctx context.Context, id int64) (chain []HierarchyEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&chain,
+		`WITH RECURSIVE chain AS (
+			SELECT m.*, 1 AS level, ARRAY[$1::BIGINT, m.id] AS path
+			FROM employees e
+			JOIN employees m ON m.id = e.manager_id
+			WHERE e.id = $1 AND m.deleted_at IS NULL
+			UNION ALL
+			SELECT m.*, c.level + 1, c.path || m.id
+			FROM employees m
+			JOIN chain c ON m.id = c.manager_id
+			WHERE m.deleted_at IS NULL AND NOT m.id = ANY(c.path)
+		)
+		SELECT `+employeeColumns+`, level FROM chain ORDER BY level`,
+		id,
+	)
+
+	return chain, err
+}
+
+// isInChainOfCommandTx - встречается ли employeeId среди managerId и всех его руководителей
+// (с учётом мягко удалённых: после восстановления они снова в цепочке)
+func isInChainOfCommandTx(ctx context.Context, tx *sqlx.Tx, managerId int64, employeeId int64) (isExists bool, err error) {
+	err = tx.GetContext(
+		ctx,
+		&isExists,
+		`WITH RECURSIVE chain AS (
+			SELECT id, manager_id, ARRAY[id] AS path FROM employees WHERE id = $1
+			UNION ALL
+			SELECT e.id, e.manager_id, c.path || e.id
+			FROM employees e
+			JOIN chain c ON e.id = c.manager_id
+			WHERE NOT e.id = ANY(c.path)
+		)
+		SELECT EXISTS(SELECT 1 FROM chain WHERE id = $2)`,
+		managerId, employeeId,
+	)
+
+	return isExists, err
+}
+
+// ExistsRoleById - проверить наличие роли с заданным id
+func (r *Repository) ExistsRoleById(ctx context.Context, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)",
+		roleId,
+	)
+
+	return isExists, err
+}
+
+// FindRolesByEmployeeId - найти все роли, назначенные сотруднику (истёкшие и ещё не начавшиеся назначения не учитываются)
+func (r *Repository) FindRolesByEmployeeId(ctx context.Context, employeeId int64) (roles []RoleEntity, err error) {
+	query := `
+		SELECT r.id, r.name, er.created_at AS assigned_at
+		FROM employee_roles er
+		JOIN roles r ON r.id = er.role_id
+		WHERE er.employee_id = $1 AND r.deleted_at IS NULL
+			AND er.valid_from <= NOW() AND (er.valid_until IS NULL OR er.valid_until > NOW())
+		ORDER BY r.id
+	`
+	err = r.db.SelectContext(ctx, &roles, query, employeeId)
+
+	return roles, err
+}
+
+// FindRolesByEmployeeIds - роли нескольких сотрудников одним запросом, по порядку сотрудника и роли
+// (истёкшие и ещё не начавшиеся назначения не учитываются)
+func (r *Repository) FindRolesByEmployeeIds(ctx context.Context, employeeIds []int64) (roles []EmployeeRoleEntity, err error) {
+	query, args, err := sqlx.In(`
+		SELECT er.employee_id, r.id, r.name, er.created_at AS assigned_at
+		FROM employee_roles er
+		JOIN roles r ON r.id = er.role_id
+		WHERE er.employee_id IN (?) AND r.deleted_at IS NULL
+			AND er.valid_from <= NOW() AND (er.valid_until IS NULL OR er.valid_until > NOW())
+		ORDER BY er.employee_id, r.id
+	`, employeeIds)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.SelectContext(ctx, &roles, r.db.Rebind(query), args...)
+
+	return roles, err
+}
+
+// AssignRole - назначить роль сотруднику бессрочно с текущего момента. Повторное назначение игнорируется,
+// назначение с ограниченным сроком становится бессрочным.
+// Назначение, нарушающее правило разделения полномочий, не выполняется - sod.ErrViolation
+func (r *Repository) AssignRole(ctx context.Context, employeeId int64, roleId int64) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := sod.CheckAssignmentTx(ctx, tx, employeeId, roleId); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO employee_roles (employee_id, role_id, created_at, valid_from)
+			VALUES ($1, $2, $3, $3)
+			ON CONFLICT (employee_id, role_id) DO UPDATE
+			SET valid_from = LEAST(employee_roles.valid_from, EXCLUDED.valid_from), valid_until = NULL
+			WHERE employee_roles.valid_until IS NOT NULL OR employee_roles.valid_from > EXCLUDED.valid_from`,
+			employeeId, roleId, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return err
+		}
+
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionAssign,
+			EntityType: audit.EntityEmployeeRole,
+			EntityId:   employeeId,
+			After:      audit.EmployeeRoleSnapshot{EmployeeId: employeeId, RoleId: roleId},
+		})
+	})
+}
+
+// RevokeRole - отозвать роль у сотрудника, возвращает false если назначения не было
+func (r *Repository) RevokeRole(ctx context.Context, employeeId int64, roleId int64) (isRevoked bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			"DELETE FROM employee_roles WHERE employee_id = $1 AND role_id = $2",
+			employeeId, roleId,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		isRevoked = true
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionRevoke,
+			EntityType: audit.EntityEmployeeRole,
+			EntityId:   employeeId,
+			Before:     audit.EmployeeRoleSnapshot{EmployeeId: employeeId, RoleId: roleId},
+		})
+	})
 
-	return err
+	return isRevoked && err == nil, err
 }
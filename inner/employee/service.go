@@ -2,10 +2,17 @@ package employee
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"idm/inner/domain"
+	"idm/inner/export"
+	"idm/inner/pagination"
+	"idm/inner/sod"
+	"io"
 	"log"
+	"time"
 )
 
 type Service struct {
@@ -15,16 +22,37 @@ type Service struct {
 
 type Repo interface {
 	BeginTransaction() (tx *sqlx.Tx, err error)
-	GetPageByValues(ctx context.Context, values []int64, textFilter string) ([]Entity, int64, error)
-	FindById(ctx context.Context, id int64) (Entity, error)
-	FindAllEmployees(ctx context.Context) ([]Entity, error)
-	FindAllEmployeesByIds(ctx context.Context, ids []int64) ([]Entity, error)
+	GetPageByValues(ctx context.Context, values []int64, textFilter string, includeDeleted bool) ([]Entity, int64, error)
+	Export(ctx context.Context, textFilter string, includeDeleted bool, fn func(entity ExportEntity) error) error
+	FindKeysetPage(ctx context.Context, query pagination.Query, textFilter string, includeDeleted bool) ([]Entity, error)
+	CountEmployees(ctx context.Context, textFilter string, includeDeleted bool) (int64, error)
+	FindFilteredPage(ctx context.Context, field string, op string, value string, offset int64, limit int64) ([]Entity, int64, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (Entity, error)
+	FindAllEmployees(ctx context.Context, includeDeleted bool) ([]Entity, error)
+	FindAllEmployeesByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Entity, error)
 	FindByNameTx(ctx context.Context, tx *sqlx.Tx, name string) (bool, error)
+	ExistsByLoginOrEmail(ctx context.Context, login string, email string, excludeId int64) (bool, error)
 	CreateEmployee(ctx context.Context, entity *Entity) (Entity, error)
 	CreateEntityTx(ctx context.Context, tx *sqlx.Tx, entity *Entity) (int64, error)
 	UpdateEmployee(ctx context.Context, entity *Entity) error
+	UpdateEntityTx(ctx context.Context, tx *sqlx.Tx, entity *Entity) error
+	WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error
+	SavepointTx(ctx context.Context, tx *sqlx.Tx, name string, fn func() error) error
+	FindByLoginTx(ctx context.Context, tx *sqlx.Tx, login string) (Entity, error)
+	FindByIdTx(ctx context.Context, tx *sqlx.Tx, id int64) (Entity, error)
+	ExistsByEmailTx(ctx context.Context, tx *sqlx.Tx, email string, excludeId int64) (bool, error)
 	DeleteEmployeeById(ctx context.Context, id int64) error
 	DeleteAllEmployeesByIds(ctx context.Context, ids []int64) error
+	RestoreEmployee(ctx context.Context, id int64) (bool, error)
+	PurgeDeletedEmployees(ctx context.Context, before time.Time) (int64, error)
+	ChangeStatus(ctx context.Context, id int64, from string, to string) (bool, error)
+	FindReports(ctx context.Context, id int64, depth int64) ([]HierarchyEntity, error)
+	FindChainOfCommand(ctx context.Context, id int64) ([]HierarchyEntity, error)
+	ExistsRoleById(ctx context.Context, roleId int64) (bool, error)
+	FindRolesByEmployeeId(ctx context.Context, employeeId int64) ([]RoleEntity, error)
+	FindRolesByEmployeeIds(ctx context.Context, employeeIds []int64) ([]EmployeeRoleEntity, error)
+	AssignRole(ctx context.Context, employeeId int64, roleId int64) error
+	RevokeRole(ctx context.Context, employeeId int64, roleId int64) (bool, error)
 }
 
 type Validator interface {
@@ -39,8 +67,9 @@ func NewService(repo Repo, validator Validator) *Service {
 	}
 }
 
-func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
-	entities, err := svc.repo.FindAllEmployees(ctx)
+// FindAll - найти всех сотрудников, мягко удалённых - только при includeDeleted
+func (svc *Service) FindAll(ctx context.Context, includeDeleted bool) ([]Response, error) {
+	entities, err := svc.repo.FindAllEmployees(ctx, includeDeleted)
 	if err != nil {
 		return nil, domain.ErrFindAllFailed
 	}
@@ -53,7 +82,7 @@ func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
 	return responses, nil
 }
 
-func (svc *Service) FindAllByIds(ctx context.Context, ids []int64) ([]Response, error) {
+func (svc *Service) FindAllByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Response, error) {
 	request := FindAllByIdsRequest{IDs: ids}                // Создаем DTO для валидации
 	if err := svc.validator.Validate(request); err != nil { // Валидируем запрос
 		//return []Response{}, error2.RequestValidationError{Message: err.Error()}
@@ -61,7 +90,7 @@ func (svc *Service) FindAllByIds(ctx context.Context, ids []int64) ([]Response,
 	}
 	log.Printf("ids: %v", ids)
 
-	entities, err := svc.repo.FindAllEmployeesByIds(ctx, ids)
+	entities, err := svc.repo.FindAllEmployeesByIds(ctx, ids, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("error finding employees: %w", err)
 	}
@@ -95,7 +124,7 @@ func (svc *Service) GetAllByPage(
 	var limit = req.PageSize                      //Число записей, которе нужно вернуть по запросу (limit).
 	var pageArr = []int64{limit, offset}
 
-	entities, total, err := svc.repo.GetPageByValues(ctx, pageArr, req.TextFilter)
+	entities, total, err := svc.repo.GetPageByValues(ctx, pageArr, req.TextFilter, req.IncludeDeleted)
 	if err != nil {
 		return PageResponse{}, fmt.Errorf("error featching Employees by Page values %w", err)
 	}
@@ -117,9 +146,107 @@ func (svc *Service) GetAllByPage(
 	return responses, nil
 }
 
+// keysetSorts - поля сортировки keyset-выдачи сотрудников ("name" - по возрастанию, "-name" - по убыванию)
+var keysetSorts = map[string]pagination.SortKey[Entity]{
+	"id": {Column: "id", Key: func(e Entity) pagination.Key {
+		return pagination.Key{Id: e.Id}
+	}},
+	"name": {Column: "name", Key: func(e Entity) pagination.Key {
+		return pagination.Key{Value: e.Name, Id: e.Id}
+	}},
+	"createdAt": {Column: "created_at", Key: func(e Entity) pagination.Key {
+		return pagination.Key{Value: e.CreatedAt.Format(time.RFC3339Nano), Id: e.Id}
+	}},
+}
+
+// GetKeysetPage - страница сотрудников после или до позиции курсора в порядке (request.Sort, id).
+// Число сотрудников считается отдельным запросом только при request.WithTotal
+func (svc *Service) GetKeysetPage(ctx context.Context, request KeysetRequest) (KeysetPage, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return KeysetPage{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.TextFilter != "" && len(request.TextFilter) < 3 {
+		return KeysetPage{}, domain.RequestValidationError{Message: "TextFilter must be at least 3 characters"}
+	}
+	if request.After != nil && request.Before != nil {
+		return KeysetPage{}, domain.RequestValidationError{Message: "after and before cannot be used together"}
+	}
+	sortKey, order, err := pagination.ParseSort(request.Sort, keysetSorts)
+	if err != nil {
+		return KeysetPage{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	var query = pagination.Query{Order: order, Limit: request.Limit, After: request.After, Before: request.Before}
+	entities, err := svc.repo.FindKeysetPage(ctx, query, request.TextFilter, request.IncludeDeleted)
+	if err != nil {
+		return KeysetPage{}, fmt.Errorf("error fetching employees keyset page: %w", err)
+	}
+	var page = pagination.NewPage(entities, query, sortKey)
+
+	var response = KeysetPage{Result: make([]Response, 0, len(page.Rows)), Next: page.Next, Prev: page.Prev}
+	for _, entity := range page.Rows {
+		response.Result = append(response.Result, entity.ToResponse())
+	}
+	if request.WithTotal {
+		total, err := svc.repo.CountEmployees(ctx, request.TextFilter, request.IncludeDeleted)
+		if err != nil {
+			return KeysetPage{}, fmt.Errorf("error counting employees: %w", err)
+		}
+		response.Total = &total
+	}
+	return response, nil
+}
+
+// FindFiltered - страница не удалённых сотрудников по порядку id, отобранных условием на одно поле,
+// и общее число отобранных. Фильтрация и пагинация выполняются в базе
+func (svc *Service) FindFiltered(ctx context.Context, request FilterRequest) (FilterPage, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return FilterPage{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entities, total, err := svc.repo.FindFilteredPage(
+		ctx, request.Field, request.Op, request.Value, request.Offset, request.Limit,
+	)
+	if err != nil {
+		return FilterPage{}, fmt.Errorf("error fetching filtered employees: %w", err)
+	}
+
+	var response = FilterPage{Result: make([]Response, 0, len(entities)), Total: total}
+	for _, entity := range entities {
+		response.Result = append(response.Result, entity.ToResponse())
+	}
+	return response, nil
+}
+
+// Export - проверить запрос выгрузки и вернуть функцию, записывающую сотрудников в w в формате request.Format.
+// Запрос проверяется сразу, чтобы ошибка вернулась до начала потоковой передачи
+func (svc *Service) Export(ctx context.Context, request ExportRequest) (func(w io.Writer) error, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.TextFilter != "" && len(request.TextFilter) < 3 {
+		return nil, domain.RequestValidationError{Message: "TextFilter must be at least 3 characters"}
+	}
+
+	return func(w io.Writer) error {
+		writer, err := export.NewWriter(request.Format, w, exportColumns)
+		if err != nil {
+			return err
+		}
+		err = svc.repo.Export(ctx, request.TextFilter, request.IncludeDeleted, func(entity ExportEntity) error {
+			return writer.Write(entity.ToExportRecord())
+		})
+		if err != nil {
+			return fmt.Errorf("error exporting employees: %w", err)
+		}
+		return writer.Close()
+	}, nil
+}
+
 func (svc *Service) FindById(
 	ctx context.Context,
 	id int64,
+	includeDeleted bool,
 ) (Response, error) {
 	request := FindByIDRequest{ID: id}        // Создаем DTO для валидации
 	var err = svc.validator.Validate(request) // Валидируем запрос
@@ -128,7 +255,7 @@ func (svc *Service) FindById(
 		return Response{}, domain.RequestValidationError{Message: err.Error()}
 	}
 
-	entity, err := svc.repo.FindById(ctx, id)
+	entity, err := svc.repo.FindById(ctx, id, includeDeleted)
 	if err != nil {
 		// в случае ошибки, вернём пустую структуру Response и обёрнутую нами ошибку
 		return Response{}, fmt.Errorf("error finding employee with id %d: %w", id, err)
@@ -146,6 +273,9 @@ func (svc *Service) CreateEmployee(
 	if err := svc.validator.Validate(createRequest); err != nil { // Валидируем запрос
 		return Response{}, domain.RequestValidationError{Message: err.Error()}
 	}
+	if err := svc.checkProfile(ctx, 0, createRequest.Profile); err != nil {
+		return Response{}, err
+	}
 
 	var toEntity = createRequest.ToEntity()
 	var entityRsl, err = svc.repo.CreateEmployee(ctx, toEntity)
@@ -166,9 +296,17 @@ func (svc *Service) UpdateEmployee(
 	if err := svc.validator.Validate(request); err != nil { // Валидируем запрос
 		return Response{}, domain.RequestValidationError{Message: err.Error()}
 	}
+	if err := svc.checkProfile(ctx, id, request.Profile); err != nil {
+		return Response{}, err
+	}
 
 	var employeeEntity = request.ToEntity()
 	var err = svc.repo.UpdateEmployee(ctx, employeeEntity)
+	if errors.Is(err, ErrManagerCycle) {
+		return Response{}, domain.RequestValidationError{
+			Message: fmt.Sprintf("employee %d cannot report to %d: %s", id, request.ManagerId, err),
+		}
+	}
 	if err != nil {
 		return Response{}, fmt.Errorf("error updating employee with name %s: %w", employeeEntity.Name, err)
 	}
@@ -213,6 +351,138 @@ func (svc *Service) DeleteByIds(
 	return Response{}, err
 }
 
+// Restore - восстановить мягко удалённого сотрудника вместе с его назначениями ролей
+func (svc *Service) Restore(
+	ctx context.Context,
+	id int64,
+) (Response, error) {
+	request := FindByIDRequest{ID: id}
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRestored, err := svc.repo.RestoreEmployee(ctx, id)
+	if err != nil {
+		return Response{}, fmt.Errorf("error restoring employee with id %d: %w", id, err)
+	}
+	if !isRestored {
+		return Response{}, domain.NotFoundError{Message: fmt.Sprintf("deleted employee with id %d not found", id)}
+	}
+
+	entity, err := svc.repo.FindById(ctx, id, false)
+	if err != nil {
+		return Response{}, fmt.Errorf("error finding employee with id %d: %w", id, err)
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// ChangeStatus - выполнить действие жизненного цикла (LifecycleActivate, LifecycleSuspend,
+// LifecycleTerminate, LifecycleRehire). Недопустимый из текущего состояния переход - ConflictError.
+// При увольнении все назначения ролей сотрудника отзываются
+func (svc *Service) ChangeStatus(
+	ctx context.Context,
+	id int64,
+	action string,
+) (Response, error) {
+	request := ChangeStatusRequest{ID: id, Action: action}
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	employee, err := svc.findEmployee(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	status, err := nextStatus(action, employee.Status)
+	if err != nil {
+		return Response{}, err
+	}
+
+	isChanged, err := svc.repo.ChangeStatus(ctx, id, employee.Status, status)
+	if err != nil {
+		return Response{}, fmt.Errorf("error changing status of employee %d to %s: %w", id, status, err)
+	}
+	if !isChanged {
+		// состояние успели поменять параллельным запросом
+		return Response{}, domain.ConflictError{
+			Message: fmt.Sprintf("employee with id %d is no longer in status %s", id, employee.Status),
+		}
+	}
+
+	changed, err := svc.findEmployee(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return changed.ToResponse(), nil
+}
+
+// FindReports - подчинённые сотрудника до глубины depth (1 - только прямые), ближние первыми
+func (svc *Service) FindReports(
+	ctx context.Context,
+	id int64,
+	depth int64,
+) ([]HierarchyResponse, error) {
+	request := ReportsRequest{ID: id, Depth: depth}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkEmployeeExists(ctx, id); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindReports(ctx, id, depth)
+	if err != nil {
+		return nil, fmt.Errorf("error finding reports of employee %d: %w", id, err)
+	}
+
+	return toHierarchyResponses(entities), nil
+}
+
+// FindChainOfCommand - руководители сотрудника от непосредственного до верхнего
+func (svc *Service) FindChainOfCommand(
+	ctx context.Context,
+	id int64,
+) ([]HierarchyResponse, error) {
+	request := FindByIDRequest{ID: id}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkEmployeeExists(ctx, id); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindChainOfCommand(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding chain of command of employee %d: %w", id, err)
+	}
+
+	return toHierarchyResponses(entities), nil
+}
+
+func toHierarchyResponses(entities []HierarchyEntity) []HierarchyResponse {
+	responses := make([]HierarchyResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses
+}
+
+// PurgeDeleted - окончательно удалить сотрудников, мягко удалённых более retention назад
+func (svc *Service) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := svc.repo.PurgeDeletedEmployees(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("error purging deleted employees: %w", err)
+	}
+
+	return purged, nil
+}
+
 func (svc *Service) CreateEmployeeTx(
 	ctx context.Context,
 	request CreateRequest,
@@ -222,6 +492,9 @@ func (svc *Service) CreateEmployeeTx(
 		// возвращаем кастомную ошибку в случае, если запрос не прошёл валидацию (про кастомные ошибки - дальше)
 		return 0, domain.RequestValidationError{Message: err.Error()}
 	}
+	if err = svc.checkProfile(ctx, 0, request.Profile); err != nil {
+		return 0, err
+	}
 
 	tx, err := svc.repo.BeginTransaction() // create Tx for using
 
@@ -268,6 +541,174 @@ func (svc *Service) FindEmployeeByNameTx(ctx context.Context, name string) (isEx
 	return isExists, err
 }
 
+// FindRoles - найти все роли, назначенные сотруднику
+func (svc *Service) FindRoles(
+	ctx context.Context,
+	employeeId int64,
+) ([]RoleResponse, error) {
+	request := FindByIDRequest{ID: employeeId}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkEmployeeExists(ctx, employeeId); err != nil {
+		return nil, err
+	}
+
+	return svc.findRoles(ctx, employeeId)
+}
+
+// FindRolesByEmployeeIds - роли нескольких сотрудников одним запросом; сотрудников без ролей в результате нет
+func (svc *Service) FindRolesByEmployeeIds(
+	ctx context.Context,
+	employeeIds []int64,
+) (map[int64][]RoleResponse, error) {
+	var result = make(map[int64][]RoleResponse, len(employeeIds))
+	if len(employeeIds) == 0 {
+		return result, nil
+	}
+
+	entities, err := svc.repo.FindRolesByEmployeeIds(ctx, employeeIds)
+	if err != nil {
+		return nil, fmt.Errorf("error finding roles of employees: %w", err)
+	}
+	for _, entity := range entities {
+		result[entity.EmployeeId] = append(result[entity.EmployeeId], entity.ToResponse())
+	}
+
+	return result, nil
+}
+
+// AssignRole - назначить роль сотруднику, возвращает актуальный список ролей сотрудника
+func (svc *Service) AssignRole(
+	ctx context.Context,
+	employeeId int64,
+	request AssignRoleRequest,
+) ([]RoleResponse, error) {
+	request.EmployeeID = employeeId
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	employee, err := svc.findEmployee(ctx, employeeId)
+	if err != nil {
+		return nil, err
+	}
+	if employee.Status == StatusTerminated {
+		return nil, domain.ConflictError{Message: fmt.Sprintf("employee with id %d is terminated", employeeId)}
+	}
+
+	isExists, err := svc.repo.ExistsRoleById(ctx, request.RoleID)
+	if err != nil {
+		return nil, fmt.Errorf("error checking role with id %d: %w", request.RoleID, err)
+	}
+	if !isExists {
+		return nil, domain.NotFoundError{Message: fmt.Sprintf("role with id %d not found", request.RoleID)}
+	}
+
+	err = svc.repo.AssignRole(ctx, employeeId, request.RoleID)
+	if errors.Is(err, sod.ErrViolation) {
+		return nil, domain.SodViolationError{Message: err.Error()}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error assigning role %d to employee %d: %w", request.RoleID, employeeId, err)
+	}
+
+	return svc.findRoles(ctx, employeeId)
+}
+
+// RevokeRole - отозвать роль у сотрудника, возвращает актуальный список ролей сотрудника
+func (svc *Service) RevokeRole(
+	ctx context.Context,
+	employeeId int64,
+	roleId int64,
+) ([]RoleResponse, error) {
+	request := RevokeRoleRequest{EmployeeID: employeeId, RoleID: roleId}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRevoked, err := svc.repo.RevokeRole(ctx, employeeId, roleId)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking role %d from employee %d: %w", roleId, employeeId, err)
+	}
+	if !isRevoked {
+		return nil, domain.NotFoundError{
+			Message: fmt.Sprintf("role %d is not assigned to employee %d", roleId, employeeId),
+		}
+	}
+
+	return svc.findRoles(ctx, employeeId)
+}
+
+func (svc *Service) findRoles(ctx context.Context, employeeId int64) ([]RoleResponse, error) {
+	entities, err := svc.repo.FindRolesByEmployeeId(ctx, employeeId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding roles of employee %d: %w", employeeId, err)
+	}
+
+	responses := make([]RoleResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// checkProfile - проверки профиля, которые не выразить тегами валидатора: порядок дат,
+// руководитель (существует и не сам сотрудник) и уникальность login и email.
+// id - сотрудник, профиль которого меняется (0 при создании)
+func (svc *Service) checkProfile(ctx context.Context, id int64, profile Profile) error {
+	if profile.StartDate != nil && profile.EndDate != nil && profile.EndDate.Before(*profile.StartDate) {
+		return domain.RequestValidationError{Message: "Field EndDate must not be before StartDate"}
+	}
+
+	if profile.ManagerId > 0 {
+		if profile.ManagerId == id {
+			return domain.RequestValidationError{Message: "employee cannot be their own manager"}
+		}
+		_, err := svc.repo.FindById(ctx, profile.ManagerId, false)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.RequestValidationError{
+				Message: fmt.Sprintf("manager with id %d not found", profile.ManagerId),
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("error finding manager with id %d: %w", profile.ManagerId, err)
+		}
+	}
+
+	if profile.Login == "" && profile.Email == "" {
+		return nil
+	}
+	isExists, err := svc.repo.ExistsByLoginOrEmail(ctx, profile.Login, profile.Email, id)
+	if err != nil {
+		return fmt.Errorf("error checking login and email uniqueness: %w", err)
+	}
+	if isExists {
+		return domain.AlreadyExistsError{Message: "employee with this login or email already exists"}
+	}
+
+	return nil
+}
+
+func (svc *Service) checkEmployeeExists(ctx context.Context, employeeId int64) error {
+	_, err := svc.findEmployee(ctx, employeeId)
+	return err
+}
+
+func (svc *Service) findEmployee(ctx context.Context, employeeId int64) (Entity, error) {
+	entity, err := svc.repo.FindById(ctx, employeeId, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entity{}, domain.NotFoundError{Message: fmt.Sprintf("employee with id %d not found", employeeId)}
+	}
+	if err != nil {
+		return Entity{}, fmt.Errorf("error finding employee with id %d: %w", employeeId, err)
+	}
+
+	return entity, nil
+}
+
 // Отложенная функция завершения транзакции
 func (svc *Service) CloseTx(tx *sqlx.Tx, err error, value string) {
 	// отложенная функция завершения транзакции
@@ -0,0 +1,105 @@
+package employee
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_Export(t *testing.T) {
+	var a = assert.New(t)
+	var appContext = context.Background()
+	var created = time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	var startDate = time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	var login = "jsena"
+	var managerId = int64(3)
+
+	var entities = []ExportEntity{
+		{
+			Entity: Entity{
+				Id: 1, Name: "John Sena", Status: StatusActive, Login: &login, ManagerId: &managerId,
+				StartDate: &startDate, CreatedAt: created, UpdatedAt: created,
+			},
+			Roles: pq.StringArray{"admin", "auditor"},
+		},
+		{Entity: Entity{Id: 2, Name: "Jane Doe", Status: StatusPreHire, CreatedAt: created, UpdatedAt: created}},
+	}
+
+	var setup = func() (*MockRepo, *MockValidator, *Service) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		return repo, validator, NewService(repo, validator)
+	}
+
+	t.Run("should write employees with roles as csv", func(t *testing.T) {
+		repo, validator, service := setup()
+		var request = ExportRequest{Format: "csv", TextFilter: "Doe"}
+		validator.ExpectValidate(request, nil)
+		repo.On("Export", appContext, "Doe", false).Return(entities, nil).Once()
+
+		write, err := service.Export(appContext, request)
+		a.NoError(err)
+		var buf bytes.Buffer
+		a.NoError(write(&buf))
+
+		var lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+		a.Len(lines, 3)
+		a.Equal(strings.Join(exportColumns, ","), lines[0])
+		a.Equal(
+			"1,John Sena,active,jsena,,,,3,,2025-09-01,,,2025-08-01T10:00:00Z,2025-08-01T10:00:00Z,,admin;auditor",
+			lines[1],
+		)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should write employees as ndjson with empty roles array", func(t *testing.T) {
+		repo, validator, service := setup()
+		var request = ExportRequest{Format: "ndjson", IncludeDeleted: true}
+		validator.ExpectValidate(request, nil)
+		repo.On("Export", appContext, "", true).Return(entities[1:], nil).Once()
+
+		write, err := service.Export(appContext, request)
+		a.NoError(err)
+		var buf bytes.Buffer
+		a.NoError(write(&buf))
+		a.Contains(buf.String(), `"name":"Jane Doe"`)
+		a.Contains(buf.String(), `"roles":[]`)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject short text filter before streaming", func(t *testing.T) {
+		_, validator, service := setup()
+		var request = ExportRequest{Format: "xlsx", TextFilter: "Do"}
+		validator.ExpectValidate(request, nil)
+
+		write, err := service.Export(appContext, request)
+		a.Nil(write)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+	})
+
+	t.Run("should reject unknown format", func(t *testing.T) {
+		_, validator, service := setup()
+		var request = ExportRequest{Format: "pdf"}
+		validator.ExpectValidate(request, errors.New("Format must be one of csv ndjson xlsx"))
+
+		_, err := service.Export(appContext, request)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+	})
+
+	t.Run("should return cursor error", func(t *testing.T) {
+		repo, validator, service := setup()
+		var request = ExportRequest{Format: "csv"}
+		validator.ExpectValidate(request, nil)
+		repo.On("Export", appContext, "", false).Return([]ExportEntity{}, errors.New("connection reset")).Once()
+
+		write, err := service.Export(appContext, request)
+		a.NoError(err)
+		a.ErrorContains(write(&bytes.Buffer{}), "connection reset")
+	})
+}
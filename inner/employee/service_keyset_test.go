@@ -0,0 +1,159 @@
+package employee
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"idm/inner/pagination"
+	"testing"
+	"time"
+)
+
+func TestService_GetKeysetPage(t *testing.T) {
+	var a = assert.New(t)
+	var appContext = context.Background()
+	var created = time.Date(2025, 8, 3, 10, 0, 0, 123456000, time.UTC)
+
+	var setup = func() (*MockRepo, *MockValidator, *Service) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		return repo, validator, NewService(repo, validator)
+	}
+
+	t.Run("should fetch one extra row and return next key", func(t *testing.T) {
+		repo, validator, service := setup()
+		var request = KeysetRequest{Limit: 2, Sort: "-createdAt", TextFilter: "Doe"}
+		validator.ExpectValidate(request, nil)
+		var query = pagination.Query{Order: pagination.Order{Column: "created_at", Desc: true}, Limit: 2}
+		repo.On("FindKeysetPage", appContext, query, "Doe", false).Return([]Entity{
+			{Id: 3, Name: "John Doe", CreatedAt: created},
+			{Id: 2, Name: "Jane Doe", CreatedAt: created},
+			{Id: 1, Name: "Jim Doe", CreatedAt: created},
+		}, nil).Once()
+
+		page, err := service.GetKeysetPage(appContext, request)
+		a.NoError(err)
+		a.Len(page.Result, 2)
+		a.Equal(&pagination.Key{Value: "2025-08-03T10:00:00.123456Z", Id: 2}, page.Next)
+		a.Nil(page.Prev)
+		a.Nil(page.Total)
+		repo.AssertNotCalled(t, "CountEmployees", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return page before cursor in sort order with total", func(t *testing.T) {
+		repo, validator, service := setup()
+		var before = &pagination.Key{Value: "Jim", Id: 9}
+		var request = KeysetRequest{Limit: 2, Sort: "name", IncludeDeleted: true, WithTotal: true, Before: before}
+		validator.ExpectValidate(request, nil)
+		var query = pagination.Query{Order: pagination.Order{Column: "name"}, Limit: 2, Before: before}
+		repo.On("FindKeysetPage", appContext, query, "", true).
+			Return([]Entity{{Id: 5, Name: "Jane"}, {Id: 4, Name: "Ivan"}}, nil).Once()
+		repo.On("CountEmployees", appContext, "", true).Return(int64(12), nil).Once()
+
+		page, err := service.GetKeysetPage(appContext, request)
+		a.NoError(err)
+		a.Equal("Ivan", page.Result[0].Name)
+		a.Equal("Jane", page.Result[1].Name)
+		a.Equal(&pagination.Key{Value: "Jane", Id: 5}, page.Next)
+		a.Nil(page.Prev)
+		a.Equal(int64(12), *page.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject unknown sort and short filter", func(t *testing.T) {
+		_, validator, service := setup()
+		var request = KeysetRequest{Limit: 10, Sort: "salary"}
+		validator.ExpectValidate(request, nil)
+		_, err := service.GetKeysetPage(appContext, request)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		a.ErrorContains(err, "salary")
+
+		request = KeysetRequest{Limit: 10, Sort: "id", TextFilter: "Do"}
+		validator.ExpectValidate(request, nil)
+		_, err = service.GetKeysetPage(appContext, request)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+	})
+
+	t.Run("should wrap repository error", func(t *testing.T) {
+		repo, validator, service := setup()
+		var request = KeysetRequest{Limit: 10, Sort: "id"}
+		validator.ExpectValidate(request, nil)
+		var query = pagination.Query{Order: pagination.Order{Column: "id"}, Limit: 10}
+		repo.On("FindKeysetPage", appContext, query, "", false).Return([]Entity{}, errors.New("connection reset")).Once()
+
+		_, err := service.GetKeysetPage(appContext, request)
+		a.ErrorContains(err, "connection reset")
+		a.False(errors.As(err, &domain.RequestValidationError{}))
+	})
+}
+
+func TestService_FindFiltered(t *testing.T) {
+	var a = assert.New(t)
+	var appContext = context.Background()
+
+	var setup = func() (*MockRepo, *MockValidator, *Service) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		return repo, validator, NewService(repo, validator)
+	}
+
+	t.Run("should return filtered page with total", func(t *testing.T) {
+		repo, validator, service := setup()
+		var request = FilterRequest{Field: FilterFieldUserName, Op: FilterOpSw, Value: "jo", Offset: 10, Limit: 2}
+		validator.ExpectValidate(request, nil)
+		repo.On("FindFilteredPage", appContext, FilterFieldUserName, FilterOpSw, "jo", int64(10), int64(2)).
+			Return([]Entity{{Id: 11, Name: "John"}, {Id: 12, Name: "Joan"}}, int64(14), nil).Once()
+
+		page, err := service.FindFiltered(appContext, request)
+		a.NoError(err)
+		a.Equal(int64(14), page.Total)
+		a.Len(page.Result, 2)
+		a.Equal(int64(12), page.Result[1].Id)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should not query repository for invalid request", func(t *testing.T) {
+		repo, validator, service := setup()
+		var request = FilterRequest{Field: "name", Op: FilterOpEq, Limit: 10}
+		validator.ExpectValidate(request, errors.New("Field must be one of [id userName]"))
+
+		_, err := service.FindFiltered(appContext, request)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "FindFilteredPage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_FindRolesByEmployeeIds(t *testing.T) {
+	var a = assert.New(t)
+	var appContext = context.Background()
+
+	t.Run("should group roles by employee in one query", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		repo.On("FindRolesByEmployeeIds", appContext, []int64{1, 2, 3}).Return([]EmployeeRoleEntity{
+			{EmployeeId: 1, RoleEntity: RoleEntity{Id: 5, Name: "AUDITOR"}},
+			{EmployeeId: 1, RoleEntity: RoleEntity{Id: 7, Name: "OPERATOR"}},
+			{EmployeeId: 3, RoleEntity: RoleEntity{Id: 5, Name: "AUDITOR"}},
+		}, nil).Once()
+
+		roles, err := service.FindRolesByEmployeeIds(appContext, []int64{1, 2, 3})
+		a.NoError(err)
+		a.Len(roles[1], 2)
+		a.Empty(roles[2])
+		a.Equal("AUDITOR", roles[3][0].Name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should not query repository for empty ids", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+
+		roles, err := service.FindRolesByEmployeeIds(appContext, nil)
+		a.NoError(err)
+		a.Empty(roles)
+		repo.AssertNotCalled(t, "FindRolesByEmployeeIds", mock.Anything, mock.Anything)
+	})
+}
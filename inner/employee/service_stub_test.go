@@ -6,6 +6,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"idm/inner/pagination"
 	"testing"
 	"time"
 )
@@ -17,7 +18,7 @@ type StubEmployeeRepository struct {
 	err      error
 }
 
-func (s *StubEmployeeRepository) GetPageByValues(ctx context.Context, values []int64, textFilter string) ([]Entity, int64, error) {
+func (s *StubEmployeeRepository) GetPageByValues(ctx context.Context, values []int64, textFilter string, includeDeleted bool) ([]Entity, int64, error) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -32,17 +33,22 @@ func (s *StubEmployeeRepository) FindByNameTx(ctx context.Context, tx *sqlx.Tx,
 	panic("implement me")
 }
 
+func (s *StubEmployeeRepository) ExistsByLoginOrEmail(ctx context.Context, login string, email string, excludeId int64) (bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (s *StubEmployeeRepository) BeginTransaction() (tx *sqlx.Tx, err error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (s *StubEmployeeRepository) FindAllEmployees(ctx context.Context) ([]Entity, error) {
+func (s *StubEmployeeRepository) FindAllEmployees(ctx context.Context, includeDeleted bool) ([]Entity, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (s *StubEmployeeRepository) FindAllEmployeesByIds(ctx context.Context, ids []int64) ([]Entity, error) {
+func (s *StubEmployeeRepository) FindAllEmployeesByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Entity, error) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -61,6 +67,56 @@ func (s *StubEmployeeRepository) UpdateEmployee(ctx context.Context, entity *Ent
 	panic("implement me")
 }
 
+func (s *StubEmployeeRepository) Export(ctx context.Context, textFilter string, includeDeleted bool, fn func(entity ExportEntity) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindKeysetPage(ctx context.Context, query pagination.Query, textFilter string, includeDeleted bool) ([]Entity, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) CountEmployees(ctx context.Context, textFilter string, includeDeleted bool) (int64, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindFilteredPage(ctx context.Context, field string, op string, value string, offset int64, limit int64) ([]Entity, int64, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) UpdateEntityTx(ctx context.Context, tx *sqlx.Tx, entity *Entity) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) SavepointTx(ctx context.Context, tx *sqlx.Tx, name string, fn func() error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindByLoginTx(ctx context.Context, tx *sqlx.Tx, login string) (Entity, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindByIdTx(ctx context.Context, tx *sqlx.Tx, id int64) (Entity, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) ExistsByEmailTx(ctx context.Context, tx *sqlx.Tx, email string, excludeId int64) (bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (s *StubEmployeeRepository) DeleteEmployeeById(ctx context.Context, id int64) error {
 	//TODO implement me
 	panic("implement me")
@@ -71,10 +127,60 @@ func (s *StubEmployeeRepository) DeleteAllEmployeesByIds(ctx context.Context, id
 	panic("implement me")
 }
 
-func (s *StubEmployeeRepository) FindById(ctx context.Context, id int64) (Entity, error) {
+func (s *StubEmployeeRepository) FindById(ctx context.Context, id int64, includeDeleted bool) (Entity, error) {
 	return s.employee, s.err
 }
 
+func (s *StubEmployeeRepository) RestoreEmployee(ctx context.Context, id int64) (bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) PurgeDeletedEmployees(ctx context.Context, before time.Time) (int64, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) ChangeStatus(ctx context.Context, id int64, from string, to string) (bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindReports(ctx context.Context, id int64, depth int64) ([]HierarchyEntity, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindChainOfCommand(ctx context.Context, id int64) ([]HierarchyEntity, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) ExistsRoleById(ctx context.Context, roleId int64) (bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindRolesByEmployeeId(ctx context.Context, employeeId int64) ([]RoleEntity, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) FindRolesByEmployeeIds(ctx context.Context, employeeIds []int64) ([]EmployeeRoleEntity, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) AssignRole(ctx context.Context, employeeId int64, roleId int64) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s *StubEmployeeRepository) RevokeRole(ctx context.Context, employeeId int64, roleId int64) (bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
 func TestEmployeeService_GetEmployeeById(t *testing.T) {
 	// создаём экземпляр объекта с ассерт c функциями
 	var a = assert.New(t)
@@ -103,7 +209,7 @@ func TestEmployeeService_GetEmployeeById(t *testing.T) {
 		service := NewService(repo, validator) // создаём новый экземпляр сервиса (чтобы передать ему новый мок репозитория)
 		validator.On("Validate", mock.Anything).Return(nil)
 		// Вызываем метод сервиса
-		got, err := service.FindById(appContext, (1))
+		got, err := service.FindById(appContext, 1, false)
 
 		// Проверяем результаты
 		a.NoError(err)
@@ -123,7 +229,7 @@ func TestEmployeeService_GetEmployeeById(t *testing.T) {
 
 		validator.On("Validate", mock.Anything).Return(errors.New("error finding employee with id 1: employee not found"))
 		// Вызываем метод сервиса
-		got, err := service.FindById(appContext, 1)
+		got, err := service.FindById(appContext, 1, false)
 
 		// Проверяем результаты
 		a.Error(err)
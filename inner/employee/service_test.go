@@ -2,12 +2,15 @@ package employee
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"idm/inner/domain"
+	"idm/inner/pagination"
+	"idm/inner/sod"
 
 	"testing"
 	"time"
@@ -18,8 +21,34 @@ type MockRepo struct {
 	mock.Mock
 }
 
-func (m *MockRepo) GetPageByValues(ctx context.Context, values []int64, textFilter string) ([]Entity, int64, error) {
-	args := m.Called(ctx, values, textFilter)
+func (m *MockRepo) GetPageByValues(ctx context.Context, values []int64, textFilter string, includeDeleted bool) ([]Entity, int64, error) {
+	args := m.Called(ctx, values, textFilter, includeDeleted)
+	return args.Get(0).([]Entity), args.Get(1).(int64), args.Error(2)
+}
+
+// Export передаёт в fn сотрудников, заданных в ожидании
+func (m *MockRepo) Export(ctx context.Context, textFilter string, includeDeleted bool, fn func(entity ExportEntity) error) error {
+	args := m.Called(ctx, textFilter, includeDeleted)
+	for _, entity := range args.Get(0).([]ExportEntity) {
+		if err := fn(entity); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockRepo) FindKeysetPage(ctx context.Context, query pagination.Query, textFilter string, includeDeleted bool) ([]Entity, error) {
+	args := m.Called(ctx, query, textFilter, includeDeleted)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) CountEmployees(ctx context.Context, textFilter string, includeDeleted bool) (int64, error) {
+	args := m.Called(ctx, textFilter, includeDeleted)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) FindFilteredPage(ctx context.Context, field string, op string, value string, offset int64, limit int64) ([]Entity, int64, error) {
+	args := m.Called(ctx, field, op, value, offset, limit)
 	return args.Get(0).([]Entity), args.Get(1).(int64), args.Error(2)
 }
 
@@ -33,24 +62,29 @@ func (m *MockRepo) FindByNameTx(ctx context.Context, tx *sqlx.Tx, name string) (
 	return args.Get(0).(bool), args.Error(1)
 }
 
+func (m *MockRepo) ExistsByLoginOrEmail(ctx context.Context, login string, email string, excludeId int64) (bool, error) {
+	args := m.Called(ctx, login, email, excludeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
 func (m *MockRepo) CreateEntityTx(ctx context.Context, tx *sqlx.Tx, entity *Entity) (int64, error) {
 	args := m.Called(ctx, tx, entity)
 	return args.Get(0).(int64), args.Error(1)
 }
 
 // Реализация ВСЕХ методов интерфейса для тестов
-func (m *MockRepo) FindById(ctx context.Context, id int64) (Entity, error) {
-	args := m.Called(ctx, id)
+func (m *MockRepo) FindById(ctx context.Context, id int64, includeDeleted bool) (Entity, error) {
+	args := m.Called(ctx, id, includeDeleted)
 	return args.Get(0).(Entity), args.Error(1) // Приведение типов в моках (args.Get(0).(employee.Entity))
 }
 
-func (m *MockRepo) FindAllEmployees(ctx context.Context) ([]Entity, error) {
-	args := m.Called(ctx)
+func (m *MockRepo) FindAllEmployees(ctx context.Context, includeDeleted bool) ([]Entity, error) {
+	args := m.Called(ctx, includeDeleted)
 	return args.Get(0).([]Entity), args.Error(1)
 }
 
-func (m *MockRepo) FindAllEmployeesByIds(ctx context.Context, ids []int64) ([]Entity, error) {
-	args := m.Called(ctx, ids)
+func (m *MockRepo) FindAllEmployeesByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Entity, error) {
+	args := m.Called(ctx, ids, includeDeleted)
 	return args.Get(0).([]Entity), args.Error(1) //
 }
 
@@ -64,6 +98,35 @@ func (m *MockRepo) UpdateEmployee(ctx context.Context, entity *Entity) error {
 	return args.Error(0)
 }
 
+func (m *MockRepo) UpdateEntityTx(ctx context.Context, tx *sqlx.Tx, entity *Entity) error {
+	args := m.Called(ctx, tx, entity)
+	return args.Error(0)
+}
+
+// WithTx и SavepointTx выполняют fn сразу: транзакция в unit-тестах не нужна
+func (m *MockRepo) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return fn(nil)
+}
+
+func (m *MockRepo) SavepointTx(ctx context.Context, tx *sqlx.Tx, name string, fn func() error) error {
+	return fn()
+}
+
+func (m *MockRepo) FindByLoginTx(ctx context.Context, tx *sqlx.Tx, login string) (Entity, error) {
+	args := m.Called(ctx, tx, login)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindByIdTx(ctx context.Context, tx *sqlx.Tx, id int64) (Entity, error) {
+	args := m.Called(ctx, tx, id)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsByEmailTx(ctx context.Context, tx *sqlx.Tx, email string, excludeId int64) (bool, error) {
+	args := m.Called(ctx, tx, email, excludeId)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockRepo) DeleteEmployeeById(ctx context.Context, id int64) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -74,6 +137,56 @@ func (m *MockRepo) DeleteAllEmployeesByIds(ctx context.Context, ids []int64) err
 	return args.Error(0)
 }
 
+func (m *MockRepo) RestoreEmployee(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) PurgeDeletedEmployees(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) ChangeStatus(ctx context.Context, id int64, from string, to string) (bool, error) {
+	args := m.Called(ctx, id, from, to)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindReports(ctx context.Context, id int64, depth int64) ([]HierarchyEntity, error) {
+	args := m.Called(ctx, id, depth)
+	return args.Get(0).([]HierarchyEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindChainOfCommand(ctx context.Context, id int64) ([]HierarchyEntity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]HierarchyEntity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsRoleById(ctx context.Context, roleId int64) (bool, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindRolesByEmployeeId(ctx context.Context, employeeId int64) ([]RoleEntity, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).([]RoleEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindRolesByEmployeeIds(ctx context.Context, employeeIds []int64) ([]EmployeeRoleEntity, error) {
+	args := m.Called(ctx, employeeIds)
+	return args.Get(0).([]EmployeeRoleEntity), args.Error(1)
+}
+
+func (m *MockRepo) AssignRole(ctx context.Context, employeeId int64, roleId int64) error {
+	args := m.Called(ctx, employeeId, roleId)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RevokeRole(ctx context.Context, employeeId int64, roleId int64) (bool, error) {
+	args := m.Called(ctx, employeeId, roleId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
 // https://pkg.go.dev/github.com/stretchr/testify/mock@v1.10.0#Mock.AssertCalled
 func TestEmployeeService(t *testing.T) {
 
@@ -99,9 +212,9 @@ func TestEmployeeService(t *testing.T) {
 			{Id: 3, Name: "Jim", CreateAt: now},
 		}
 		validator.On("Validate", requestIds).Return(nil)
-		repo.On("FindAllEmployeesByIds", appContext, ids).Return(entities, nil).Once()
+		repo.On("FindAllEmployeesByIds", appContext, ids, false).Return(entities, nil).Once()
 
-		responses, err := service.FindAllByIds(appContext, ids)
+		responses, err := service.FindAllByIds(appContext, ids, false)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedResponses, responses)
@@ -118,10 +231,10 @@ func TestEmployeeService(t *testing.T) {
 		var errRsl = fmt.Errorf("error finding employees: %w", expectedErr)
 
 		validator.On("Validate", requestIds).Return(nil).Once()
-		repo.On("FindAllEmployeesByIds", appContext, ids).Return([]Entity{}, expectedErr).Once()
+		repo.On("FindAllEmployeesByIds", appContext, ids, false).Return([]Entity{}, expectedErr).Once()
 
 		// Act - вызываем метод сервиса
-		responses, err := service.FindAllByIds(appContext, ids)
+		responses, err := service.FindAllByIds(appContext, ids, false)
 
 		// Assert - проверяем результаты теста
 		assert.Error(t, err)                         // Должна быть ошибка
@@ -146,10 +259,10 @@ func TestEmployeeService(t *testing.T) {
 			{Id: 2, Name: "Jane", CreateAt: now},
 		}
 
-		repo.On("FindAllEmployees", appContext).Return(entities, nil).Once() // Настройка возврата среза
+		repo.On("FindAllEmployees", appContext, false).Return(entities, nil).Once() // Настройка возврата среза
 
 		// Act - вызываем метод сервиса
-		responses, err := service.FindAll(appContext)
+		responses, err := service.FindAll(appContext, false)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedResponses, responses)
@@ -174,9 +287,9 @@ func TestEmployeeService(t *testing.T) {
 		// конфигурируем поведение мок-репозитория (при вызове метода FindById с аргументом 1 вернуть Entity, созданную нами выше)
 		// Настраиваем ожидание с ТОЧНЫМ типом аргумента
 		validator.On("Validate", request).Return(nil)
-		repo.On("FindById", appContext, int64(1)).Return(entity, nil)
+		repo.On("FindById", appContext, int64(1), false).Return(entity, nil)
 
-		var got, err = service.FindById(appContext, ID) // вызываем сервис с аргументом id = 1
+		var got, err = service.FindById(appContext, ID, false) // вызываем сервис с аргументом id = 1
 
 		a.Nil(err)                                         // проверяем, что сервис не вернул ошибку
 		a.Equal(want, got)                                 // проверяем, что сервис вернул нам тот employee.Response, который мы ожилали получить
@@ -202,9 +315,9 @@ func TestEmployeeService(t *testing.T) {
 		var want = fmt.Errorf("error finding employee with id 1: %w", err)
 
 		validator.On("Validate", request).Return(nil)
-		repo.On("FindById", appContext, int64(1)).Return(entity, err).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(entity, err).Once()
 
-		var response, got = service.FindById(appContext, ID)
+		var response, got = service.FindById(appContext, ID, false)
 
 		// Assert - проверяем результаты теста
 		a.Empty(response)
@@ -388,3 +501,491 @@ func TestEmployeeService(t *testing.T) {
 	})
 
 }
+
+func TestEmployeeService_Roles(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should return roles of employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		entities := []RoleEntity{{Id: 10, Name: "ADMIN", AssignedAt: now}}
+
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindRolesByEmployeeId", appContext, int64(1)).Return(entities, nil).Once()
+
+		got, err := service.FindRoles(appContext, 1)
+
+		a.Nil(err)
+		a.Equal([]RoleResponse{{Id: 10, Name: "ADMIN", AssignedAt: now}}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when employee does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{}, sql.ErrNoRows).Once()
+
+		got, err := service.FindRoles(appContext, 1)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "FindRolesByEmployeeId", appContext, int64(1))
+	})
+
+	t.Run("should assign role to employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignRoleRequest{EmployeeID: 1, RoleID: 10}
+		entities := []RoleEntity{{Id: 10, Name: "ADMIN", AssignedAt: now}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(10)).Return(true, nil).Once()
+		repo.On("AssignRole", appContext, int64(1), int64(10)).Return(nil).Once()
+		repo.On("FindRolesByEmployeeId", appContext, int64(1)).Return(entities, nil).Once()
+
+		got, err := service.AssignRole(appContext, 1, AssignRoleRequest{RoleID: 10})
+
+		a.Nil(err)
+		a.Len(got, 1)
+		a.Equal(int64(10), got[0].Id)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when assigned role does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignRoleRequest{EmployeeID: 1, RoleID: 10}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(10)).Return(false, nil).Once()
+
+		got, err := service.AssignRole(appContext, 1, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "AssignRole", appContext, int64(1), int64(10))
+	})
+
+	t.Run("should return validation error when assign invalid role id", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignRoleRequest{EmployeeID: 1, RoleID: 0}
+
+		validator.On("Validate", request).Return(errors.New("Field RoleID is required")).Once()
+
+		got, err := service.AssignRole(appContext, 1, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should revoke role from employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := RevokeRoleRequest{EmployeeID: 1, RoleID: 10}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("RevokeRole", appContext, int64(1), int64(10)).Return(true, nil).Once()
+		repo.On("FindRolesByEmployeeId", appContext, int64(1)).Return([]RoleEntity{}, nil).Once()
+
+		got, err := service.RevokeRole(appContext, 1, 10)
+
+		a.Nil(err)
+		a.Empty(got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when role is not assigned", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := RevokeRoleRequest{EmployeeID: 1, RoleID: 10}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("RevokeRole", appContext, int64(1), int64(10)).Return(false, nil).Once()
+
+		got, err := service.RevokeRole(appContext, 1, 10)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestEmployeeService_SoftDelete(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+
+	t.Run("should restore deleted employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		entity := Entity{Id: 1, Name: "John Doe"}
+
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("RestoreEmployee", appContext, int64(1)).Return(true, nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(entity, nil).Once()
+
+		got, err := service.Restore(appContext, 1)
+
+		a.Nil(err)
+		a.Equal(entity.ToResponse(), got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when employee is not deleted", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("RestoreEmployee", appContext, int64(1)).Return(false, nil).Once()
+
+		got, err := service.Restore(appContext, 1)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "FindById", appContext, int64(1), false)
+	})
+
+	t.Run("should return validation error when restore invalid id", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 0}).Return(errors.New("Field ID is required")).Once()
+
+		got, err := service.Restore(appContext, 0)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "RestoreEmployee", appContext, int64(0))
+	})
+
+	t.Run("should purge employees deleted before retention", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		var retention = 24 * time.Hour
+		var earliest = time.Now().Add(-retention)
+
+		repo.On("PurgeDeletedEmployees", appContext, mock.MatchedBy(func(before time.Time) bool {
+			return !before.Before(earliest) && before.Before(time.Now().Add(-retention+time.Minute))
+		})).Return(int64(3), nil).Once()
+
+		purged, err := service.PurgeDeleted(appContext, retention)
+
+		a.Nil(err)
+		a.Equal(int64(3), purged)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestEmployeeService_ChangeStatus(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+
+	t.Run("should terminate active employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := ChangeStatusRequest{ID: 1, Action: LifecycleTerminate}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1, Status: StatusActive}, nil).Once()
+		repo.On("ChangeStatus", appContext, int64(1), StatusActive, StatusTerminated).Return(true, nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1, Status: StatusTerminated}, nil).Once()
+
+		got, err := service.ChangeStatus(appContext, 1, LifecycleTerminate)
+
+		a.Nil(err)
+		a.Equal(StatusTerminated, got.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return conflict when transition is not allowed", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := ChangeStatusRequest{ID: 1, Action: LifecycleActivate}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1, Status: StatusTerminated}, nil).Once()
+
+		got, err := service.ChangeStatus(appContext, 1, LifecycleActivate)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.ConflictError{}))
+		repo.AssertNotCalled(t, "ChangeStatus", appContext, int64(1), StatusTerminated, StatusActive)
+	})
+
+	t.Run("should return conflict when status changed concurrently", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := ChangeStatusRequest{ID: 1, Action: LifecycleSuspend}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1, Status: StatusActive}, nil).Once()
+		repo.On("ChangeStatus", appContext, int64(1), StatusActive, StatusSuspended).Return(false, nil).Once()
+
+		got, err := service.ChangeStatus(appContext, 1, LifecycleSuspend)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.ConflictError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when employee does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := ChangeStatusRequest{ID: 1, Action: LifecycleSuspend}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{}, sql.ErrNoRows).Once()
+
+		got, err := service.ChangeStatus(appContext, 1, LifecycleSuspend)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+
+	t.Run("should not assign role to terminated employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignRoleRequest{EmployeeID: 1, RoleID: 10}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1, Status: StatusTerminated}, nil).Once()
+
+		got, err := service.AssignRole(appContext, 1, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.ConflictError{}))
+		repo.AssertNotCalled(t, "AssignRole", appContext, int64(1), int64(10))
+	})
+
+	t.Run("should not assign role conflicting with held roles", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignRoleRequest{EmployeeID: 1, RoleID: 10}
+		violation := fmt.Errorf("%w: roles PAYMENT_CREATOR and PAYMENT_APPROVER must not be held together (rule 3)",
+			sod.ErrViolation)
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(10)).Return(true, nil).Once()
+		repo.On("AssignRole", appContext, int64(1), int64(10)).Return(violation).Once()
+
+		got, err := service.AssignRole(appContext, 1, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.SodViolationError{}))
+		repo.AssertNotCalled(t, "FindRolesByEmployeeId", appContext, int64(1))
+	})
+}
+
+func TestEmployeeService_Profile(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+
+	t.Run("should create employee with profile", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{
+			Name:    "John Doe",
+			Profile: Profile{Login: "jdoe", Email: "jdoe@example.com", Department: "IT", ManagerId: 2},
+		}
+		var login, email, managerId = "jdoe", "jdoe@example.com", int64(2)
+		var created = Entity{Id: 1, Name: "John Doe", Status: StatusActive, Login: &login, Email: &email,
+			Department: "IT", ManagerId: &managerId}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(2), false).Return(Entity{Id: 2}, nil).Once()
+		repo.On("ExistsByLoginOrEmail", appContext, "jdoe", "jdoe@example.com", int64(0)).Return(false, nil).Once()
+		repo.On("CreateEmployee", appContext, request.ToEntity()).Return(created, nil).Once()
+
+		got, err := service.CreateEmployee(appContext, request)
+
+		a.Nil(err)
+		a.Equal("jdoe", got.Login)
+		a.Equal("jdoe@example.com", got.Email)
+		a.Equal(&managerId, got.ManagerId)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return already exists when login or email is taken", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "John Doe", Profile: Profile{Login: "jdoe"}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsByLoginOrEmail", appContext, "jdoe", "", int64(0)).Return(true, nil).Once()
+
+		got, err := service.CreateEmployee(appContext, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "CreateEmployee", appContext, request.ToEntity())
+	})
+
+	t.Run("should return validation error when manager not found", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "John Doe", Profile: Profile{ManagerId: 5}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(5), false).Return(Entity{}, sql.ErrNoRows).Once()
+
+		got, err := service.CreateEmployee(appContext, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "CreateEmployee", appContext, request.ToEntity())
+	})
+
+	t.Run("should not set employee as own manager", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 3, Name: "John Doe", Profile: Profile{ManagerId: 3}}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		got, err := service.UpdateEmployee(appContext, 3, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "UpdateEmployee", appContext, request.ToEntity())
+	})
+
+	t.Run("should not accept end date before start date", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		var start = time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+		var end = start.AddDate(0, 0, -1)
+		request := CreateRequest{Name: "John Doe", Profile: Profile{StartDate: &start, EndDate: &end}}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		got, err := service.CreateEmployee(appContext, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+	})
+
+	t.Run("should exclude updated employee from uniqueness check", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 3, Name: "John Doe", Profile: Profile{Email: "jdoe@example.com"}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsByLoginOrEmail", appContext, "", "jdoe@example.com", int64(3)).Return(false, nil).Once()
+		repo.On("UpdateEmployee", appContext, request.ToEntity()).Return(nil).Once()
+
+		got, err := service.UpdateEmployee(appContext, 3, request)
+
+		a.Nil(err)
+		a.Equal("jdoe@example.com", got.Email)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestEmployeeService_Hierarchy(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+
+	t.Run("should find reports up to depth", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := ReportsRequest{ID: 1, Depth: 2}
+		reports := []HierarchyEntity{
+			{Entity: Entity{Id: 2, Name: "Direct"}, Level: 1},
+			{Entity: Entity{Id: 3, Name: "Indirect"}, Level: 2},
+		}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindReports", appContext, int64(1), int64(2)).Return(reports, nil).Once()
+
+		got, err := service.FindReports(appContext, 1, 2)
+
+		a.Nil(err)
+		a.Len(got, 2)
+		a.Equal(int64(3), got[1].Id)
+		a.Equal(int64(2), got[1].Level)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when employee does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{}, sql.ErrNoRows).Once()
+
+		got, err := service.FindChainOfCommand(appContext, 1)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "FindChainOfCommand", appContext, int64(1))
+	})
+
+	t.Run("should find chain of command", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		chain := []HierarchyEntity{{Entity: Entity{Id: 2, Name: "Manager"}, Level: 1}}
+
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1), false).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindChainOfCommand", appContext, int64(1)).Return(chain, nil).Once()
+
+		got, err := service.FindChainOfCommand(appContext, 1)
+
+		a.Nil(err)
+		a.Equal([]HierarchyResponse{{Response: Response{Id: 2, Name: "Manager"}, Level: 1}}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return validation error on manager cycle", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 1, Name: "John Doe", Profile: Profile{ManagerId: 3}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(3), false).Return(Entity{Id: 3}, nil).Once()
+		repo.On("UpdateEmployee", appContext, request.ToEntity()).Return(ErrManagerCycle).Once()
+
+		got, err := service.UpdateEmployee(appContext, 1, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Форматы выгрузки
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+	XLSX   = "xlsx"
+)
+
+// listSeparator - разделитель значений списка (например, ролей) в одной ячейке CSV и XLSX
+const listSeparator = ";"
+
+// Record - строка выгрузки: Values - значения колонок для CSV и XLSX в порядке заголовка,
+// в NDJSON строка пишется как JSON-объект самой записи
+type Record interface {
+	Values() []string
+}
+
+// Writer - построчная запись выгрузки: заголовок пишется при создании, Close дописывает окончание файла
+type Writer interface {
+	Write(record Record) error
+	Close() error
+}
+
+// NewWriter - писатель выгрузки в формате format с колонками columns
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCsvWriter(w, columns)
+	case NDJSON:
+		var encoder = json.NewEncoder(w)
+		encoder.SetEscapeHTML(false) // файл, а не HTML-страница: значения пишутся как есть
+		return &ndjsonWriter{encoder: encoder}, nil
+	case XLSX:
+		return newXlsxWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ContentType - MIME-тип файла выгрузки
+func ContentType(format string) string {
+	switch format {
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Filename - имя файла-вложения выгрузки name
+func Filename(name string, format string) string {
+	return name + "." + format
+}
+
+// List - значение-список в одной ячейке
+func List(values []string) string {
+	return strings.Join(values, listSeparator)
+}
+
+// EscapeFormula - защита от CSV-инъекции: значение, которое табличный редактор принял бы за формулу
+// (начинается с =, +, -, @, табуляции или перевода строки), предваряется апострофом и открывается как текст
+func EscapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// EscapeFormulas - EscapeFormula для каждого значения строки
+func EscapeFormulas(values []string) []string {
+	var escaped = make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = EscapeFormula(value)
+	}
+	return escaped
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCsvWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	var writer = csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer}, nil
+}
+
+func (w *csvWriter) Write(record Record) error {
+	return w.writer.Write(EscapeFormulas(record.Values()))
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+// Write - Encode дописывает перевод строки после объекта
+func (w *ndjsonWriter) Write(record Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
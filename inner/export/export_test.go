@@ -0,0 +1,141 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	Id    string   `json:"id"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+func (r testRecord) Values() []string {
+	return []string{r.Id, r.Name, List(r.Roles)}
+}
+
+var testColumns = []string{"id", "name", "roles"}
+
+var testRecords = []testRecord{
+	{Id: "1", Name: "John, \"Jr\"", Roles: []string{"admin", "user"}},
+	{Id: "2", Name: "<Jane & Co>", Roles: []string{}},
+}
+
+func write(t *testing.T, format string) []byte {
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf, testColumns)
+	require.NoError(t, err)
+	for _, record := range testRecords {
+		require.NoError(t, writer.Write(record))
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestWriter(t *testing.T) {
+	var a = assert.New(t)
+
+	t.Run("csv with header and quoted values", func(t *testing.T) {
+		a.Equal("id,name,roles\n1,\"John, \"\"Jr\"\"\",admin;user\n2,<Jane & Co>,\n", string(write(t, CSV)))
+	})
+
+	t.Run("ndjson object per line", func(t *testing.T) {
+		a.Equal(
+			`{"id":"1","name":"John, \"Jr\"","roles":["admin","user"]}`+"\n"+`{"id":"2","name":"<Jane & Co>","roles":[]}`+"\n",
+			string(write(t, NDJSON)),
+		)
+	})
+
+	t.Run("xlsx workbook with escaped inline strings", func(t *testing.T) {
+		var data = write(t, XLSX)
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		var parts = map[string]string{}
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			parts[file.Name] = string(content)
+		}
+		a.Contains(parts, "[Content_Types].xml")
+		a.Contains(parts, "_rels/.rels")
+		a.Contains(parts, "xl/workbook.xml")
+		a.Contains(parts, "xl/_rels/workbook.xml.rels")
+
+		var sheet struct {
+			Rows []struct {
+				R     string `xml:"r,attr"`
+				Cells []struct {
+					R    string `xml:"r,attr"`
+					Text string `xml:"is>t"`
+				} `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		require.NoError(t, xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet))
+		require.Len(t, sheet.Rows, 3)
+		a.Equal("roles", sheet.Rows[0].Cells[2].Text)
+		a.Equal("C2", sheet.Rows[1].Cells[2].R)
+		a.Equal("admin;user", sheet.Rows[1].Cells[2].Text)
+		a.Equal("<Jane & Co>", sheet.Rows[2].Cells[1].Text)
+		a.Len(sheet.Rows[2].Cells, 2) // пустая ячейка не пишется
+		a.True(strings.Contains(parts["xl/worksheets/sheet1.xml"], "&lt;Jane &amp; Co&gt;"))
+	})
+
+	t.Run("formula-like values are written as text", func(t *testing.T) {
+		var record = testRecord{Id: "3", Name: "=HYPERLINK(\"http://evil\")", Roles: []string{"@SUM(A1)", "-1+2"}}
+
+		var buf bytes.Buffer
+		writer, err := NewWriter(CSV, &buf, testColumns)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(record))
+		require.NoError(t, writer.Close())
+		a.Equal("id,name,roles\n3,\"'=HYPERLINK(\"\"http://evil\"\")\",'@SUM(A1);-1+2\n", buf.String())
+
+		buf.Reset()
+		writer, err = NewWriter(XLSX, &buf, testColumns)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(record))
+		require.NoError(t, writer.Close())
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+		require.NoError(t, err)
+		content, err := io.ReadAll(sheet)
+		require.NoError(t, err)
+		a.NotContains(string(content), "<f>") // формул в листе нет, только встроенные строки
+		a.Contains(string(content), `t="inlineStr"><is><t xml:space="preserve">=HYPERLINK`)
+	})
+
+	t.Run("escape formula", func(t *testing.T) {
+		for value, expected := range map[string]string{
+			"=1+1": "'=1+1", "+7 999": "'+7 999", "-2": "'-2", "@cmd": "'@cmd", "\tx": "'\tx",
+			"John": "John", "": "", "a=b": "a=b",
+		} {
+			a.Equal(expected, EscapeFormula(value), value)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := NewWriter("pdf", io.Discard, testColumns)
+		a.Error(err)
+	})
+}
+
+func TestColumnName(t *testing.T) {
+	var a = assert.New(t)
+	a.Equal("A", columnName(0))
+	a.Equal("Z", columnName(25))
+	a.Equal("AA", columnName(26))
+	a.Equal("AZ", columnName(51))
+	a.Equal("BA", columnName(52))
+}
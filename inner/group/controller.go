@@ -0,0 +1,699 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server       *web.Server
+	groupService Svc
+	logger       *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	FindAll(ctx context.Context) ([]Response, error)
+	FindById(ctx context.Context, id int64) (Response, error)
+	CreateGroup(ctx context.Context, request CreateRequest) (Response, error)
+	UpdateGroup(ctx context.Context, id int64, request UpdateRequest) (Response, error)
+	DeleteById(ctx context.Context, id int64) (Response, error)
+	FindMembers(ctx context.Context, groupId int64) (MembersResponse, error)
+	AddEmployee(ctx context.Context, groupId int64, request AddEmployeeRequest) (MembersResponse, error)
+	RemoveEmployee(ctx context.Context, groupId int64, employeeId int64) (MembersResponse, error)
+	AddGroup(ctx context.Context, groupId int64, request AddGroupRequest) (MembersResponse, error)
+	RemoveGroup(ctx context.Context, groupId int64, memberGroupId int64) (MembersResponse, error)
+	FindRoles(ctx context.Context, groupId int64) ([]RoleResponse, error)
+	GrantRole(ctx context.Context, groupId int64, request GrantRoleRequest) ([]RoleResponse, error)
+	RevokeRole(ctx context.Context, groupId int64, roleId int64) ([]RoleResponse, error)
+	FindEffectiveRoles(ctx context.Context, employeeId int64) ([]EffectiveRoleResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	groupService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:       server,
+		groupService: groupService,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/groups"
+	c.server.GroupGroups.Get("/", c.server.Require(web.PermGroupsRead), c.FindAll)
+	c.server.GroupGroups.Post("/", c.server.Require(web.PermGroupsWrite), c.CreateGroup)
+	c.server.GroupGroups.Get("/:id", c.server.Require(web.PermGroupsRead), c.FindById)
+	c.server.GroupGroups.Put("/:id", c.server.Require(web.PermGroupsWrite), c.UpdateGroup)
+	c.server.GroupGroups.Delete("/:id", c.server.Require(web.PermGroupsWrite), c.DeleteById)
+	// состав группы - это и данные сотрудников, поэтому нужно ещё право на их просмотр
+	c.server.GroupGroups.Get("/:id/members", c.server.Require(web.PermGroupsRead, web.PermEmployeesRead), c.FindMembers)
+	c.server.GroupGroups.Post("/:id/members/employees", c.server.Require(web.PermGroupsWrite), c.AddEmployee)
+	c.server.GroupGroups.Delete("/:id/members/employees/:employeeId", c.server.Require(web.PermGroupsWrite), c.RemoveEmployee)
+	c.server.GroupGroups.Post("/:id/members/groups", c.server.Require(web.PermGroupsWrite), c.AddGroup)
+	c.server.GroupGroups.Delete("/:id/members/groups/:memberId", c.server.Require(web.PermGroupsWrite), c.RemoveGroup)
+	// выдача роли группе - это назначение роли всем её участникам
+	c.server.GroupGroups.Get("/:id/roles", c.server.Require(web.PermGroupsRead, web.PermRolesRead), c.FindRoles)
+	c.server.GroupGroups.Post("/:id/roles", c.server.Require(web.PermGroupsWrite, web.PermRolesAssign), c.GrantRole)
+	c.server.GroupGroups.Delete("/:id/roles/:roleId", c.server.Require(web.PermGroupsWrite, web.PermRolesAssign), c.RevokeRole)
+
+	// полный маршрут получится "/api/v1/employees/:id/effective-roles"
+	c.server.GroupEmployees.Get("/:id/effective-roles", c.server.Require(web.PermRolesRead), c.FindEffectiveRoles)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/groups" --//
+
+// FindAll   	 godoc
+// @Description  Find all groups
+// @Summary		 get all groups
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		group.Response	"Group response"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /groups/		[get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.groupService.FindAll(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Groups ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find by ID group
+// @Summary 	 find by ID group
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  		"Group ID"
+// @Success 	 200  {object}  	group.Response	"Group response"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /groups/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.FindById(appContext, groupID)
+	if err != nil {
+		c.logger.Error(
+			"When the get Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// CreateGroup 	 godoc
+// @Summary      create a new group
+// @Description  Create a new group of employees
+// @Tags 		 group
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	group.CreateRequest true "Group creation details"
+// @Success 	 201  {object}  group.Response	"Group response"
+// @Failure      400  {object}  http.Response	"Bad request"
+// @Failure      409  {object}  http.Response	"Name already taken"
+// @Failure      500  {object}  http.Response	"Bad request"
+// @Router 		 /groups/ 	[post]
+func (c *Controller) CreateGroup(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an CreateGroup ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.groupService.CreateGroup(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Group ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// UpdateGroup 	 godoc
+// @Summary      update group
+// @Description  Update name and description of group
+// @Tags 		 group
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  				true  	"Group ID"
+// @Param 		 request 	body 		group.UpdateRequest true 	"Group update details"
+// @Success 	 200  {object}  	group.Response	"Group response"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      409  {object}  	http.Response	"Name already taken"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /groups/{id} 	[put]
+func (c *Controller) UpdateGroup(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request UpdateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an UpdateGroup ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.groupService.UpdateGroup(appContext, groupID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the update Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// DeleteById  	 godoc
+// @Description  Delete group with its memberships and role grants
+// @Summary		 delete group by ID
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  			true	"Group ID"
+// @Success 	 200  {object} 		group.Response	"Group response"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      500  {object} 	 	http.Response	"Bad request"
+// @Router 		 /groups/{id}	[delete]
+func (c *Controller) DeleteById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.DeleteById(appContext, groupID)
+	if err != nil {
+		c.logger.Error(
+			"When the delete Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindMembers 	 godoc
+// @Description  Find direct members of group: employees and nested groups
+// @Summary 	 find group members
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  				"Group ID"
+// @Success 	 200  {object}  	group.MembersResponse	"Group members"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /groups/{id}/members 	[get]
+func (c *Controller) FindMembers(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.FindMembers(appContext, groupID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Group members ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// AddEmployee 	 godoc
+// @Description  Add employee to group
+// @Summary 	 add employee to group
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  						true  	"Group ID"
+// @Param 		 request 	body 		group.AddEmployeeRequest 	true 	"Employee to add"
+// @Success 	 200  {object}  	group.MembersResponse	"Group members"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      409  {object}  	http.Response			"Segregation of duties violation"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /groups/{id}/members/employees 	[post]
+func (c *Controller) AddEmployee(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request AddEmployeeRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an AddEmployee ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.groupService.AddEmployee(appContext, groupID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the add Employee to Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.Int64("employee_id", request.EmployeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// RemoveEmployee godoc
+// @Description  Remove employee from group
+// @Summary		 remove employee from group
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   			path     	int  	true	"Group ID"
+// @Param        employeeId   	path     	int  	true	"Employee ID"
+// @Success 	 200  {object} 		group.MembersResponse	"Group members"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object} 	 	http.Response			"Bad request"
+// @Router 		 /groups/{id}/members/employees/{employeeId}	[delete]
+func (c *Controller) RemoveEmployee(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+	employeeID, err := c.parseIdParam(ctx, "employeeId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.RemoveEmployee(appContext, groupID, employeeID)
+	if err != nil {
+		c.logger.Error(
+			"When the remove Employee from Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.Int64("employee_id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// AddGroup 	 godoc
+// @Description  Nest group into group: members of the nested group become members of the outer one
+// @Summary 	 nest group into group
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  					true  	"Group ID"
+// @Param 		 request 	body 		group.AddGroupRequest 	true 	"Group to nest"
+// @Success 	 200  {object}  	group.MembersResponse	"Group members"
+// @Failure      400  {object}  	http.Response			"Bad request or nesting cycle"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      409  {object}  	http.Response			"Segregation of duties violation"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /groups/{id}/members/groups 	[post]
+func (c *Controller) AddGroup(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request AddGroupRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an AddGroup ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.groupService.AddGroup(appContext, groupID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the nest Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.Int64("member_group_id", request.MemberGroupID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// RemoveGroup 	 godoc
+// @Description  Remove nested group from group
+// @Summary		 remove nested group
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   			path     	int  	true	"Group ID"
+// @Param        memberId   	path     	int  	true	"Nested group ID"
+// @Success 	 200  {object} 		group.MembersResponse	"Group members"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object} 	 	http.Response			"Bad request"
+// @Router 		 /groups/{id}/members/groups/{memberId}	[delete]
+func (c *Controller) RemoveGroup(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+	memberGroupID, err := c.parseIdParam(ctx, "memberId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.RemoveGroup(appContext, groupID, memberGroupID)
+	if err != nil {
+		c.logger.Error(
+			"When the remove nested Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.Int64("member_group_id", memberGroupID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindRoles 	 godoc
+// @Description  Find roles granted to group
+// @Summary 	 find group roles
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  				"Group ID"
+// @Success 	 200  {array}  		group.RoleResponse		"Group roles"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /groups/{id}/roles 	[get]
+func (c *Controller) FindRoles(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.FindRoles(appContext, groupID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Group roles ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// GrantRole 	 godoc
+// @Description  Grant role to group: all direct and nested members effectively get the role
+// @Summary 	 grant role to group
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  					true  	"Group ID"
+// @Param 		 request 	body 		group.GrantRoleRequest 	true 	"Role to grant"
+// @Success 	 200  {array}  		group.RoleResponse		"Group roles"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      409  {object}  	http.Response			"Segregation of duties violation"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /groups/{id}/roles 	[post]
+func (c *Controller) GrantRole(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request GrantRoleRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an GrantRole ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.groupService.GrantRole(appContext, groupID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the grant Role to Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.Int64("role_id", request.RoleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// RevokeRole 	 godoc
+// @Description  Revoke role from group
+// @Summary		 revoke role from group
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   		path     	int  	true	"Group ID"
+// @Param        roleId   	path     	int  	true	"Role ID"
+// @Success 	 200  {array} 		group.RoleResponse		"Group roles"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object} 	 	http.Response			"Bad request"
+// @Router 		 /groups/{id}/roles/{roleId}	[delete]
+func (c *Controller) RevokeRole(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	groupID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+	roleID, err := c.parseIdParam(ctx, "roleId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.RevokeRole(appContext, groupID, roleID)
+	if err != nil {
+		c.logger.Error(
+			"When the revoke Role from Group ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", groupID),
+			zap.Int64("role_id", roleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindEffectiveRoles godoc
+// @Description  Find effective roles of employee: assigned directly and granted through (nested) groups
+// @Summary 	 find effective roles of employee
+// @Tags 		 group
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  					"Employee ID"
+// @Success 	 200  {array}  		group.EffectiveRoleResponse	"Effective roles"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /employees/{id}/effective-roles 	[get]
+func (c *Controller) FindEffectiveRoles(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	employeeID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.groupService.FindEffectiveRoles(appContext, employeeID)
+	if err != nil {
+		c.logger.Error(
+			"When the find effective Roles of Employee ended with an error:",
+			zap.Error(err),
+			zap.Int64("employee_id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// parseIdParam - разбор числового path-параметра с логированием ошибки
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}), errors.As(err, &domain.SodViolationError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,154 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGroup_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockGroupService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupEmployees:        app.Group("/api/v1/employees"),
+		GroupGroups:           app.Group("/api/v1/groups"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should create group", func(t *testing.T) {
+		request := CreateRequest{Name: "Admins"}
+		mockService.On("CreateGroup", appContext, request).Return(Response{Id: 1, Name: "Admins"}, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/groups/", strings.NewReader(`{"name": "Admins"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when group name is taken", func(t *testing.T) {
+		request := CreateRequest{Name: "Admins"}
+		conflict := domain.AlreadyExistsError{Message: "group with name Admins already exists"}
+		mockService.On("CreateGroup", appContext, request).Return(Response{}, conflict).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/groups/", strings.NewReader(`{"name": "Admins"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should add employee to group", func(t *testing.T) {
+		request := AddEmployeeRequest{EmployeeID: 10}
+		members := MembersResponse{GroupID: 1, Employees: []MemberResponse{{Id: 10, Name: "Alice"}}, Groups: []MemberResponse{}}
+		mockService.On("AddEmployee", appContext, int64(1), request).Return(members, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/groups/1/members/employees", strings.NewReader(`{"employeeId": 10}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data MembersResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, members, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on nesting cycle", func(t *testing.T) {
+		request := AddGroupRequest{MemberGroupID: 2}
+		cycle := domain.RequestValidationError{Message: "group 2 cannot be nested into group 1"}
+		mockService.On("AddGroup", appContext, int64(1), request).Return(MembersResponse{}, cycle).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/groups/1/members/groups", strings.NewReader(`{"groupId": 2}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, cycle.Message, body.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when revoking role not granted", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "role 5 is not granted to group 1"}
+		mockService.On("RevokeRole", appContext, int64(1), int64(5)).Return([]RoleResponse(nil), notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/groups/1/roles/5", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when member id is invalid", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/groups/1/members/groups/abc", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "RemoveGroup")
+	})
+
+	t.Run("should return effective roles of employee", func(t *testing.T) {
+		effective := []EffectiveRoleResponse{{Id: 5, Name: "ADMIN", Direct: false, Groups: []string{"Admins"}}}
+		mockService.On("FindEffectiveRoles", appContext, int64(10)).Return(effective, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/employees/10/effective-roles", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data []EffectiveRoleResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, effective, data)
+		mockService.AssertExpectations(t)
+	})
+}
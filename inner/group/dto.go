@@ -0,0 +1,219 @@
+package group
+
+import (
+	"time"
+)
+
+type Entity struct {
+	Id          int64     `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// Response model info
+// @Description Group of employees
+// @Description with group id, name, description, createAt, updateAt
+type Response struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreateAt    time.Time `json:"createAt"`
+	UpdateAt    time.Time `json:"updateAt"`
+}
+
+func (e *Entity) ToResponse() Response {
+	return Response{
+		Id:          e.Id,
+		Name:        e.Name,
+		Description: e.Description,
+		CreateAt:    e.CreatedAt,
+		UpdateAt:    e.UpdatedAt,
+	}
+}
+
+// MemberEntity - прямой участник группы (сотрудник или вложенная группа) с датой включения
+type MemberEntity struct {
+	Id      int64     `db:"id"`
+	Name    string    `db:"name"`
+	AddedAt time.Time `db:"added_at"`
+}
+
+// MemberResponse model info
+// @Description Direct member of group (employee or nested group)
+// @Description with member id, name, addedAt
+type MemberResponse struct {
+	Id      int64     `json:"id"`
+	Name    string    `json:"name"`
+	AddedAt time.Time `json:"addedAt"`
+}
+
+func (e *MemberEntity) ToResponse() MemberResponse {
+	return MemberResponse{
+		Id:      e.Id,
+		Name:    e.Name,
+		AddedAt: e.AddedAt,
+	}
+}
+
+// MembersResponse model info
+// @Description Direct members of group
+// @Description with employees and nested groups
+type MembersResponse struct {
+	GroupID   int64            `json:"groupId"`
+	Employees []MemberResponse `json:"employees"`
+	Groups    []MemberResponse `json:"groups"`
+}
+
+// ToMembersResponse - собрать состав группы из сотрудников и вложенных групп
+func ToMembersResponse(groupId int64, employees []MemberEntity, groups []MemberEntity) MembersResponse {
+	var response = MembersResponse{
+		GroupID:   groupId,
+		Employees: make([]MemberResponse, 0, len(employees)),
+		Groups:    make([]MemberResponse, 0, len(groups)),
+	}
+	for _, entity := range employees {
+		response.Employees = append(response.Employees, entity.ToResponse())
+	}
+	for _, entity := range groups {
+		response.Groups = append(response.Groups, entity.ToResponse())
+	}
+
+	return response
+}
+
+// RoleEntity - роль, выданная группе (строка из group_roles + roles)
+type RoleEntity struct {
+	Id        int64     `db:"id"`
+	Name      string    `db:"name"`
+	GrantedAt time.Time `db:"granted_at"`
+}
+
+// RoleResponse model info
+// @Description Role granted to group
+// @Description with role id, name, grantedAt
+type RoleResponse struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+func (e *RoleEntity) ToResponse() RoleResponse {
+	return RoleResponse{
+		Id:        e.Id,
+		Name:      e.Name,
+		GrantedAt: e.GrantedAt,
+	}
+}
+
+// EffectiveRoleEntity - роль сотрудника и группа, через которую она получена (nil - назначена напрямую)
+type EffectiveRoleEntity struct {
+	Id        int64   `db:"id"`
+	Name      string  `db:"name"`
+	GroupName *string `db:"group_name"`
+}
+
+// EffectiveRoleResponse model info
+// @Description Effective role of employee
+// @Description with role id, name, whether it is assigned directly and groups it is granted through
+type EffectiveRoleResponse struct {
+	Id     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Direct bool     `json:"direct"`
+	Groups []string `json:"groups"`
+}
+
+// ToEffectiveRoleResponses - свернуть строки "роль - источник" в список ролей, порядок ролей сохраняется
+func ToEffectiveRoleResponses(entities []EffectiveRoleEntity) []EffectiveRoleResponse {
+	var roles = make([]EffectiveRoleResponse, 0, len(entities))
+	var indexes = make(map[int64]int, len(entities))
+	for _, entity := range entities {
+		idx, ok := indexes[entity.Id]
+		if !ok {
+			roles = append(roles, EffectiveRoleResponse{Id: entity.Id, Name: entity.Name, Groups: []string{}})
+			idx = len(roles) - 1
+			indexes[entity.Id] = idx
+		}
+		if entity.GroupName == nil {
+			roles[idx].Direct = true
+		} else {
+			roles[idx].Groups = append(roles[idx].Groups, *entity.GroupName)
+		}
+	}
+
+	return roles
+}
+
+// MemberSnapshot - снимок членства в группе для журнала аудита (entity_id события - id группы)
+type MemberSnapshot struct {
+	GroupId       int64  `json:"groupId"`
+	EmployeeId    *int64 `json:"employeeId,omitempty"`
+	MemberGroupId *int64 `json:"memberGroupId,omitempty"`
+}
+
+// RoleSnapshot - снимок выдачи роли группе для журнала аудита (entity_id события - id группы)
+type RoleSnapshot struct {
+	GroupId int64 `json:"groupId"`
+	RoleId  int64 `json:"roleId"`
+}
+
+// CreateRequest model info
+// @Description Group creation
+// @Description with name and optional description
+type CreateRequest struct {
+	Name        string `json:"name" validate:"required,min=2,max=155,no_sql_injection"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+}
+
+func (req *CreateRequest) ToEntity() *Entity {
+	return &Entity{Name: req.Name, Description: req.Description}
+}
+
+// UpdateRequest model info
+// @Description Group update
+// @Description with name and optional description
+type UpdateRequest struct {
+	Id          int64  `json:"-" validate:"required,min=1"`
+	Name        string `json:"name" validate:"required,min=2,max=155,no_sql_injection"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+}
+
+func (req *UpdateRequest) ToEntity() *Entity {
+	return &Entity{Id: req.Id, Name: req.Name, Description: req.Description}
+}
+
+// AddEmployeeRequest model info
+// @Description Employee to add to group
+type AddEmployeeRequest struct {
+	GroupID    int64 `json:"-" validate:"required,min=1"`
+	EmployeeID int64 `json:"employeeId" validate:"required,min=1"`
+}
+
+// AddGroupRequest model info
+// @Description Group to nest into group: its members become members of the outer group
+type AddGroupRequest struct {
+	GroupID       int64 `json:"-" validate:"required,min=1"`
+	MemberGroupID int64 `json:"groupId" validate:"required,min=1"`
+}
+
+// GrantRoleRequest model info
+// @Description Role to grant to group members
+type GrantRoleRequest struct {
+	GroupID int64 `json:"-" validate:"required,min=1"`
+	RoleID  int64 `json:"roleId" validate:"required,min=1"`
+}
+
+// RemoveRequest - исключение участника из группы или отзыв роли у группы
+type RemoveRequest struct {
+	GroupID  int64 `validate:"required,min=1"`
+	MemberID int64 `validate:"required,min=1"`
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
+
+type DeleteByIdRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
@@ -0,0 +1,80 @@
+package group
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGroupService struct {
+	mock.Mock
+}
+
+func (m *MockGroupService) FindAll(ctx context.Context) ([]Response, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockGroupService) FindById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockGroupService) CreateGroup(ctx context.Context, request CreateRequest) (Response, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockGroupService) UpdateGroup(ctx context.Context, id int64, request UpdateRequest) (Response, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockGroupService) DeleteById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockGroupService) FindMembers(ctx context.Context, groupId int64) (MembersResponse, error) {
+	args := m.Called(ctx, groupId)
+	return args.Get(0).(MembersResponse), args.Error(1)
+}
+
+func (m *MockGroupService) AddEmployee(ctx context.Context, groupId int64, request AddEmployeeRequest) (MembersResponse, error) {
+	args := m.Called(ctx, groupId, request)
+	return args.Get(0).(MembersResponse), args.Error(1)
+}
+
+func (m *MockGroupService) RemoveEmployee(ctx context.Context, groupId int64, employeeId int64) (MembersResponse, error) {
+	args := m.Called(ctx, groupId, employeeId)
+	return args.Get(0).(MembersResponse), args.Error(1)
+}
+
+func (m *MockGroupService) AddGroup(ctx context.Context, groupId int64, request AddGroupRequest) (MembersResponse, error) {
+	args := m.Called(ctx, groupId, request)
+	return args.Get(0).(MembersResponse), args.Error(1)
+}
+
+func (m *MockGroupService) RemoveGroup(ctx context.Context, groupId int64, memberGroupId int64) (MembersResponse, error) {
+	args := m.Called(ctx, groupId, memberGroupId)
+	return args.Get(0).(MembersResponse), args.Error(1)
+}
+
+func (m *MockGroupService) FindRoles(ctx context.Context, groupId int64) ([]RoleResponse, error) {
+	args := m.Called(ctx, groupId)
+	return args.Get(0).([]RoleResponse), args.Error(1)
+}
+
+func (m *MockGroupService) GrantRole(ctx context.Context, groupId int64, request GrantRoleRequest) ([]RoleResponse, error) {
+	args := m.Called(ctx, groupId, request)
+	return args.Get(0).([]RoleResponse), args.Error(1)
+}
+
+func (m *MockGroupService) RevokeRole(ctx context.Context, groupId int64, roleId int64) ([]RoleResponse, error) {
+	args := m.Called(ctx, groupId, roleId)
+	return args.Get(0).([]RoleResponse), args.Error(1)
+}
+
+func (m *MockGroupService) FindEffectiveRoles(ctx context.Context, employeeId int64) ([]EffectiveRoleResponse, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).([]EffectiveRoleResponse), args.Error(1)
+}
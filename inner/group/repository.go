@@ -0,0 +1,428 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"idm/inner/sod"
+	"time"
+)
+
+// nestingLockKey - ключ pg_advisory_xact_lock, сериализующий вложение групп друг в друга
+const nestingLockKey int64 = 0x67726f7570735f6e // "groups_n"
+
+// ErrGroupCycle - вложенная группа уже содержит (прямо или транзитивно) группу-контейнер
+var ErrGroupCycle = errors.New("group already contains the target group")
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindAll - все группы, упорядоченные по имени
+func (r *Repository) FindAll(ctx context.Context) (groups []Entity, err error) {
+	err = r.db.SelectContext(ctx, &groups, "SELECT * FROM groups ORDER BY name, id")
+
+	return groups, err
+}
+
+// FindById - найти группу по id
+func (r *Repository) FindById(ctx context.Context, id int64) (group Entity, err error) {
+	err = r.db.GetContext(ctx, &group, "SELECT * FROM groups WHERE id = $1", id)
+
+	return group, err
+}
+
+// ExistsByName - есть ли другая группа с таким именем (без учёта регистра)
+func (r *Repository) ExistsByName(ctx context.Context, name string, excludeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM groups WHERE lower(name) = lower($1) AND id <> $2)",
+		name, excludeId,
+	)
+
+	return isExists, err
+}
+
+// CreateGroup - добавить группу
+func (r *Repository) CreateGroup(ctx context.Context, entity *Entity) (created Entity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(
+			ctx,
+			&created,
+			`INSERT INTO groups (name, description, created_at, updated_at)
+			VALUES ($1, $2, $3, $3)
+			RETURNING *`,
+			entity.Name, entity.Description, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityGroup,
+			EntityId:   created.Id,
+			After:      created.ToResponse(),
+		})
+	})
+
+	return created, err
+}
+
+// UpdateGroup - изменить имя и описание группы
+func (r *Repository) UpdateGroup(ctx context.Context, entity *Entity) (updated Entity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before Entity
+		err := tx.GetContext(ctx, &before, "SELECT * FROM groups WHERE id = $1 FOR UPDATE", entity.Id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.GetContext(
+			ctx,
+			&updated,
+			"UPDATE groups SET name = $1, description = $2, updated_at = $3 WHERE id = $4 RETURNING *",
+			entity.Name, entity.Description, time.Now(), entity.Id,
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityGroup,
+			EntityId:   updated.Id,
+			Before:     before.ToResponse(),
+			After:      updated.ToResponse(),
+		})
+	})
+
+	return updated, err
+}
+
+// DeleteGroup - удалить группу вместе с её участниками и выданными ей ролями (ON DELETE CASCADE)
+func (r *Repository) DeleteGroup(ctx context.Context, id int64) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var deleted []Entity
+		err := tx.SelectContext(ctx, &deleted, "DELETE FROM groups WHERE id = $1 RETURNING *", id)
+		if err != nil {
+			return err
+		}
+
+		for _, entity := range deleted {
+			err = audit.InsertEventTx(ctx, tx, audit.Event{
+				Action:     audit.ActionDelete,
+				EntityType: audit.EntityGroup,
+				EntityId:   entity.Id,
+				Before:     entity.ToResponse(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExistsEmployeeById - проверить наличие не удалённого сотрудника
+func (r *Repository) ExistsEmployeeById(ctx context.Context, employeeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL)",
+		employeeId,
+	)
+
+	return isExists, err
+}
+
+// ExistsAssignableEmployeeById - проверить наличие сотрудника, которого можно включать в группы
+// (как и при назначении ролей, мягко удалённые и уволенные не учитываются)
+func (r *Repository) ExistsAssignableEmployeeById(ctx context.Context, employeeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL AND status <> 'terminated')",
+		employeeId,
+	)
+
+	return isExists, err
+}
+
+// ExistsRoleById - проверить наличие не удалённой роли
+func (r *Repository) ExistsRoleById(ctx context.Context, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)",
+		roleId,
+	)
+
+	return isExists, err
+}
+
+// FindEmployeeMembers - сотрудники - прямые участники группы
+func (r *Repository) FindEmployeeMembers(ctx context.Context, groupId int64) (members []MemberEntity, err error) {
+	query := `
+		SELECT e.id, e.name, ge.created_at AS added_at
+		FROM group_employees ge
+		JOIN employees e ON e.id = ge.employee_id
+		WHERE ge.group_id = $1 AND e.deleted_at IS NULL
+		ORDER BY e.id
+	`
+	err = r.db.SelectContext(ctx, &members, query, groupId)
+
+	return members, err
+}
+
+// FindGroupMembers - группы, непосредственно вложенные в группу
+func (r *Repository) FindGroupMembers(ctx context.Context, groupId int64) (members []MemberEntity, err error) {
+	query := `
+		SELECT g.id, g.name, gs.created_at AS added_at
+		FROM group_subgroups gs
+		JOIN groups g ON g.id = gs.member_group_id
+		WHERE gs.group_id = $1
+		ORDER BY g.id
+	`
+	err = r.db.SelectContext(ctx, &members, query, groupId)
+
+	return members, err
+}
+
+// AddEmployee - включить сотрудника в группу (повторное включение игнорируется).
+// Включение, нарушающее правило разделения полномочий, не выполняется - sod.ErrViolation
+func (r *Repository) AddEmployee(ctx context.Context, groupId int64, employeeId int64) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := sod.CheckGroupEmployeeTx(ctx, tx, groupId, employeeId); err != nil {
+			return err
+		}
+
+		return linkTx(
+			ctx,
+			tx,
+			audit.Event{
+				Action:     audit.ActionAssign,
+				EntityType: audit.EntityGroupMember,
+				EntityId:   groupId,
+				After:      MemberSnapshot{GroupId: groupId, EmployeeId: &employeeId},
+			},
+			`INSERT INTO group_employees (group_id, employee_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (group_id, employee_id) DO NOTHING`,
+			groupId, employeeId, time.Now(),
+		)
+	})
+}
+
+// RemoveEmployee - исключить сотрудника из группы, возвращает false если он в ней не состоял
+func (r *Repository) RemoveEmployee(ctx context.Context, groupId int64, employeeId int64) (isRemoved bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		isRemoved, err = unlinkTx(
+			ctx,
+			tx,
+			audit.Event{
+				Action:     audit.ActionRevoke,
+				EntityType: audit.EntityGroupMember,
+				EntityId:   groupId,
+				Before:     MemberSnapshot{GroupId: groupId, EmployeeId: &employeeId},
+			},
+			"DELETE FROM group_employees WHERE group_id = $1 AND employee_id = $2",
+			groupId, employeeId,
+		)
+		return err
+	})
+
+	return isRemoved && err == nil, err
+}
+
+// AddGroup - вложить группу memberGroupId в группу groupId (повторное вложение игнорируется).
+// Если memberGroupId уже содержит groupId - ErrGroupCycle, если вложение нарушает правило разделения
+// полномочий для кого-то из участников - sod.ErrViolation
+func (r *Repository) AddGroup(ctx context.Context, groupId int64, memberGroupId int64) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := checkGroupCycleTx(ctx, tx, groupId, memberGroupId); err != nil {
+			return err
+		}
+		if err := sod.CheckGroupNestingTx(ctx, tx, groupId, memberGroupId); err != nil {
+			return err
+		}
+
+		return linkTx(
+			ctx,
+			tx,
+			audit.Event{
+				Action:     audit.ActionAssign,
+				EntityType: audit.EntityGroupMember,
+				EntityId:   groupId,
+				After:      MemberSnapshot{GroupId: groupId, MemberGroupId: &memberGroupId},
+			},
+			`INSERT INTO group_subgroups (group_id, member_group_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (group_id, member_group_id) DO NOTHING`,
+			groupId, memberGroupId, time.Now(),
+		)
+	})
+}
+
+// checkGroupCycleTx - группу нельзя вложить в группу, которую она сама (транзитивно) содержит.
+// Вложения сериализуются advisory-блокировкой, иначе два встречных вложения
+// проверялись бы параллельно и вместе образовали цикл
+func checkGroupCycleTx(ctx context.Context, tx *sqlx.Tx, groupId int64, memberGroupId int64) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", nestingLockKey); err != nil {
+		return err
+	}
+
+	var isCycle bool
+	err := tx.GetContext(
+		ctx,
+		&isCycle,
+		`WITH RECURSIVE nested AS (
+			SELECT $1::BIGINT AS id
+			UNION
+			SELECT gs.member_group_id FROM group_subgroups gs JOIN nested n ON gs.group_id = n.id
+		)
+		SELECT EXISTS(SELECT 1 FROM nested WHERE id = $2)`,
+		memberGroupId, groupId,
+	)
+	if err != nil {
+		return err
+	}
+	if isCycle {
+		return ErrGroupCycle
+	}
+	return nil
+}
+
+// RemoveGroup - извлечь вложенную группу, возвращает false если она не была вложена
+func (r *Repository) RemoveGroup(ctx context.Context, groupId int64, memberGroupId int64) (isRemoved bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		isRemoved, err = unlinkTx(
+			ctx,
+			tx,
+			audit.Event{
+				Action:     audit.ActionRevoke,
+				EntityType: audit.EntityGroupMember,
+				EntityId:   groupId,
+				Before:     MemberSnapshot{GroupId: groupId, MemberGroupId: &memberGroupId},
+			},
+			"DELETE FROM group_subgroups WHERE group_id = $1 AND member_group_id = $2",
+			groupId, memberGroupId,
+		)
+		return err
+	})
+
+	return isRemoved && err == nil, err
+}
+
+// FindRoles - роли, выданные группе напрямую
+func (r *Repository) FindRoles(ctx context.Context, groupId int64) (roles []RoleEntity, err error) {
+	query := `
+		SELECT r.id, r.name, gr.created_at AS granted_at
+		FROM group_roles gr
+		JOIN roles r ON r.id = gr.role_id
+		WHERE gr.group_id = $1 AND r.deleted_at IS NULL
+		ORDER BY r.id
+	`
+	err = r.db.SelectContext(ctx, &roles, query, groupId)
+
+	return roles, err
+}
+
+// GrantRole - выдать роль группе (повторная выдача игнорируется).
+// Выдача, нарушающая правило разделения полномочий для кого-то из участников, не выполняется - sod.ErrViolation
+func (r *Repository) GrantRole(ctx context.Context, groupId int64, roleId int64) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := sod.CheckGroupRoleTx(ctx, tx, groupId, roleId); err != nil {
+			return err
+		}
+
+		return linkTx(
+			ctx,
+			tx,
+			audit.Event{
+				Action:     audit.ActionAssign,
+				EntityType: audit.EntityGroupRole,
+				EntityId:   groupId,
+				After:      RoleSnapshot{GroupId: groupId, RoleId: roleId},
+			},
+			`INSERT INTO group_roles (group_id, role_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (group_id, role_id) DO NOTHING`,
+			groupId, roleId, time.Now(),
+		)
+	})
+}
+
+// RevokeRole - отозвать роль у группы, возвращает false если она не была выдана
+func (r *Repository) RevokeRole(ctx context.Context, groupId int64, roleId int64) (isRevoked bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		isRevoked, err = unlinkTx(
+			ctx,
+			tx,
+			audit.Event{
+				Action:     audit.ActionRevoke,
+				EntityType: audit.EntityGroupRole,
+				EntityId:   groupId,
+				Before:     RoleSnapshot{GroupId: groupId, RoleId: roleId},
+			},
+			"DELETE FROM group_roles WHERE group_id = $1 AND role_id = $2",
+			groupId, roleId,
+		)
+		return err
+	})
+
+	return isRevoked && err == nil, err
+}
+
+// FindEffectiveRolesByEmployeeId - роли сотрудника с источниками: прямое назначение (group_name IS NULL)
+// и группы, которым роль выдана и в которые сотрудник входит напрямую или через вложенные группы
+func (r *Repository) FindEffectiveRolesByEmployeeId(
+	ctx context.Context,
+	employeeId int64,
+) (roles []EffectiveRoleEntity, err error) {
+	query := `
+		SELECT r.id, r.name, g.name AS group_name
+		FROM employee_effective_roles_of($1) er
+		JOIN roles r ON r.id = er.role_id AND r.deleted_at IS NULL
+		LEFT JOIN groups g ON g.id = er.group_id
+		ORDER BY r.id, g.name NULLS FIRST
+	`
+	err = r.db.SelectContext(ctx, &roles, query, employeeId)
+
+	return roles, err
+}
+
+// linkTx - добавить строку связи и, если она действительно добавлена, записать событие аудита
+func linkTx(ctx context.Context, tx *sqlx.Tx, event audit.Event, query string, args ...interface{}) error {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return err
+	}
+
+	return audit.InsertEventTx(ctx, tx, event)
+}
+
+// unlinkTx - удалить строку связи и, если она была, записать событие аудита
+func unlinkTx(ctx context.Context, tx *sqlx.Tx, event audit.Event, query string, args ...interface{}) (bool, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	return true, audit.InsertEventTx(ctx, tx, event)
+}
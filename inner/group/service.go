@@ -0,0 +1,385 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"idm/inner/domain"
+	"idm/inner/sod"
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+}
+
+type Repo interface {
+	FindAll(ctx context.Context) ([]Entity, error)
+	FindById(ctx context.Context, id int64) (Entity, error)
+	ExistsByName(ctx context.Context, name string, excludeId int64) (bool, error)
+	CreateGroup(ctx context.Context, entity *Entity) (Entity, error)
+	UpdateGroup(ctx context.Context, entity *Entity) (Entity, error)
+	DeleteGroup(ctx context.Context, id int64) error
+	ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error)
+	ExistsAssignableEmployeeById(ctx context.Context, employeeId int64) (bool, error)
+	ExistsRoleById(ctx context.Context, roleId int64) (bool, error)
+	FindEmployeeMembers(ctx context.Context, groupId int64) ([]MemberEntity, error)
+	FindGroupMembers(ctx context.Context, groupId int64) ([]MemberEntity, error)
+	AddEmployee(ctx context.Context, groupId int64, employeeId int64) error
+	RemoveEmployee(ctx context.Context, groupId int64, employeeId int64) (bool, error)
+	AddGroup(ctx context.Context, groupId int64, memberGroupId int64) error
+	RemoveGroup(ctx context.Context, groupId int64, memberGroupId int64) (bool, error)
+	FindRoles(ctx context.Context, groupId int64) ([]RoleEntity, error)
+	GrantRole(ctx context.Context, groupId int64, roleId int64) error
+	RevokeRole(ctx context.Context, groupId int64, roleId int64) (bool, error)
+	FindEffectiveRolesByEmployeeId(ctx context.Context, employeeId int64) ([]EffectiveRoleEntity, error)
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+	}
+}
+
+// FindAll - все группы
+func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
+	entities, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding groups: %w", err)
+	}
+
+	responses := make([]Response, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) FindById(ctx context.Context, id int64) (Response, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findGroup(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// CreateGroup - создать группу с уникальным (без учёта регистра) именем
+func (svc *Service) CreateGroup(ctx context.Context, request CreateRequest) (Response, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkName(ctx, request.Name, 0); err != nil {
+		return Response{}, err
+	}
+
+	created, err := svc.repo.CreateGroup(ctx, request.ToEntity())
+	if err != nil {
+		return Response{}, fmt.Errorf("error creating group with name %s: %w", request.Name, err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+// UpdateGroup - изменить имя и описание группы
+func (svc *Service) UpdateGroup(ctx context.Context, id int64, request UpdateRequest) (Response, error) {
+	request.Id = id
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findGroup(ctx, id); err != nil {
+		return Response{}, err
+	}
+	if err := svc.checkName(ctx, request.Name, id); err != nil {
+		return Response{}, err
+	}
+
+	updated, err := svc.repo.UpdateGroup(ctx, request.ToEntity())
+	if err != nil {
+		return Response{}, fmt.Errorf("error updating group with id %d: %w", id, err)
+	}
+
+	return updated.ToResponse(), nil
+}
+
+// DeleteById - удалить группу; её участники теряют роли, полученные через неё
+func (svc *Service) DeleteById(ctx context.Context, id int64) (Response, error) {
+	if err := svc.validator.Validate(DeleteByIdRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findGroup(ctx, id); err != nil {
+		return Response{}, err
+	}
+
+	if err := svc.repo.DeleteGroup(ctx, id); err != nil {
+		return Response{}, fmt.Errorf("error deleting group %d: %w", id, err)
+	}
+
+	return Response{}, nil
+}
+
+// FindMembers - прямые участники группы: сотрудники и вложенные группы
+func (svc *Service) FindMembers(ctx context.Context, groupId int64) (MembersResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: groupId}); err != nil {
+		return MembersResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findGroup(ctx, groupId); err != nil {
+		return MembersResponse{}, err
+	}
+
+	return svc.findMembers(ctx, groupId)
+}
+
+// AddEmployee - включить сотрудника в группу, возвращает актуальный состав группы.
+// Включение, нарушающее правило разделения полномочий, - SodViolationError
+func (svc *Service) AddEmployee(ctx context.Context, groupId int64, request AddEmployeeRequest) (MembersResponse, error) {
+	request.GroupID = groupId
+	if err := svc.validator.Validate(request); err != nil {
+		return MembersResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findGroup(ctx, groupId); err != nil {
+		return MembersResponse{}, err
+	}
+
+	isExists, err := svc.repo.ExistsAssignableEmployeeById(ctx, request.EmployeeID)
+	if err != nil {
+		return MembersResponse{}, fmt.Errorf("error checking employee with id %d: %w", request.EmployeeID, err)
+	}
+	if !isExists {
+		return MembersResponse{}, domain.NotFoundError{Message: fmt.Sprintf("employee with id %d not found", request.EmployeeID)}
+	}
+
+	err = svc.repo.AddEmployee(ctx, groupId, request.EmployeeID)
+	if errors.Is(err, sod.ErrViolation) {
+		return MembersResponse{}, domain.SodViolationError{Message: err.Error()}
+	}
+	if err != nil {
+		return MembersResponse{}, fmt.Errorf("error adding employee %d to group %d: %w", request.EmployeeID, groupId, err)
+	}
+
+	return svc.findMembers(ctx, groupId)
+}
+
+// RemoveEmployee - исключить сотрудника из группы, возвращает актуальный состав группы
+func (svc *Service) RemoveEmployee(ctx context.Context, groupId int64, employeeId int64) (MembersResponse, error) {
+	if err := svc.validator.Validate(RemoveRequest{GroupID: groupId, MemberID: employeeId}); err != nil {
+		return MembersResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRemoved, err := svc.repo.RemoveEmployee(ctx, groupId, employeeId)
+	if err != nil {
+		return MembersResponse{}, fmt.Errorf("error removing employee %d from group %d: %w", employeeId, groupId, err)
+	}
+	if !isRemoved {
+		return MembersResponse{}, domain.NotFoundError{
+			Message: fmt.Sprintf("employee %d is not a member of group %d", employeeId, groupId),
+		}
+	}
+
+	return svc.findMembers(ctx, groupId)
+}
+
+// AddGroup - вложить группу: её участники (в том числе транзитивные) становятся участниками группы groupId.
+// Вложение, образующее цикл, - RequestValidationError, нарушающее правило разделения полномочий - SodViolationError
+func (svc *Service) AddGroup(ctx context.Context, groupId int64, request AddGroupRequest) (MembersResponse, error) {
+	request.GroupID = groupId
+	if err := svc.validator.Validate(request); err != nil {
+		return MembersResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.MemberGroupID == groupId {
+		return MembersResponse{}, domain.RequestValidationError{Message: "group cannot be a member of itself"}
+	}
+
+	if _, err := svc.findGroup(ctx, groupId); err != nil {
+		return MembersResponse{}, err
+	}
+	if _, err := svc.findGroup(ctx, request.MemberGroupID); err != nil {
+		return MembersResponse{}, err
+	}
+
+	err := svc.repo.AddGroup(ctx, groupId, request.MemberGroupID)
+	if errors.Is(err, ErrGroupCycle) {
+		return MembersResponse{}, domain.RequestValidationError{
+			Message: fmt.Sprintf("group %d cannot be nested into group %d: %s", request.MemberGroupID, groupId, err),
+		}
+	}
+	if errors.Is(err, sod.ErrViolation) {
+		return MembersResponse{}, domain.SodViolationError{Message: err.Error()}
+	}
+	if err != nil {
+		return MembersResponse{}, fmt.Errorf("error nesting group %d into group %d: %w", request.MemberGroupID, groupId, err)
+	}
+
+	return svc.findMembers(ctx, groupId)
+}
+
+// RemoveGroup - извлечь вложенную группу, возвращает актуальный состав группы
+func (svc *Service) RemoveGroup(ctx context.Context, groupId int64, memberGroupId int64) (MembersResponse, error) {
+	if err := svc.validator.Validate(RemoveRequest{GroupID: groupId, MemberID: memberGroupId}); err != nil {
+		return MembersResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRemoved, err := svc.repo.RemoveGroup(ctx, groupId, memberGroupId)
+	if err != nil {
+		return MembersResponse{}, fmt.Errorf("error removing group %d from group %d: %w", memberGroupId, groupId, err)
+	}
+	if !isRemoved {
+		return MembersResponse{}, domain.NotFoundError{
+			Message: fmt.Sprintf("group %d is not a member of group %d", memberGroupId, groupId),
+		}
+	}
+
+	return svc.findMembers(ctx, groupId)
+}
+
+// FindRoles - роли, выданные группе
+func (svc *Service) FindRoles(ctx context.Context, groupId int64) ([]RoleResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: groupId}); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findGroup(ctx, groupId); err != nil {
+		return nil, err
+	}
+
+	return svc.findRoles(ctx, groupId)
+}
+
+// GrantRole - выдать роль группе, возвращает актуальный список ролей группы.
+// Выдача, нарушающая правило разделения полномочий, - SodViolationError
+func (svc *Service) GrantRole(ctx context.Context, groupId int64, request GrantRoleRequest) ([]RoleResponse, error) {
+	request.GroupID = groupId
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findGroup(ctx, groupId); err != nil {
+		return nil, err
+	}
+
+	isExists, err := svc.repo.ExistsRoleById(ctx, request.RoleID)
+	if err != nil {
+		return nil, fmt.Errorf("error checking role with id %d: %w", request.RoleID, err)
+	}
+	if !isExists {
+		return nil, domain.NotFoundError{Message: fmt.Sprintf("role with id %d not found", request.RoleID)}
+	}
+
+	err = svc.repo.GrantRole(ctx, groupId, request.RoleID)
+	if errors.Is(err, sod.ErrViolation) {
+		return nil, domain.SodViolationError{Message: err.Error()}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error granting role %d to group %d: %w", request.RoleID, groupId, err)
+	}
+
+	return svc.findRoles(ctx, groupId)
+}
+
+// RevokeRole - отозвать роль у группы, возвращает актуальный список ролей группы
+func (svc *Service) RevokeRole(ctx context.Context, groupId int64, roleId int64) ([]RoleResponse, error) {
+	if err := svc.validator.Validate(RemoveRequest{GroupID: groupId, MemberID: roleId}); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRevoked, err := svc.repo.RevokeRole(ctx, groupId, roleId)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking role %d from group %d: %w", roleId, groupId, err)
+	}
+	if !isRevoked {
+		return nil, domain.NotFoundError{Message: fmt.Sprintf("role %d is not granted to group %d", roleId, groupId)}
+	}
+
+	return svc.findRoles(ctx, groupId)
+}
+
+// FindEffectiveRoles - роли сотрудника: назначенные напрямую и полученные через группы (с учётом вложенности)
+func (svc *Service) FindEffectiveRoles(ctx context.Context, employeeId int64) ([]EffectiveRoleResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: employeeId}); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isExists, err := svc.repo.ExistsEmployeeById(ctx, employeeId)
+	if err != nil {
+		return nil, fmt.Errorf("error checking employee with id %d: %w", employeeId, err)
+	}
+	if !isExists {
+		return nil, domain.NotFoundError{Message: fmt.Sprintf("employee with id %d not found", employeeId)}
+	}
+
+	entities, err := svc.repo.FindEffectiveRolesByEmployeeId(ctx, employeeId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding effective roles of employee %d: %w", employeeId, err)
+	}
+
+	return ToEffectiveRoleResponses(entities), nil
+}
+
+func (svc *Service) findMembers(ctx context.Context, groupId int64) (MembersResponse, error) {
+	employees, err := svc.repo.FindEmployeeMembers(ctx, groupId)
+	if err != nil {
+		return MembersResponse{}, fmt.Errorf("error finding employees of group %d: %w", groupId, err)
+	}
+	groups, err := svc.repo.FindGroupMembers(ctx, groupId)
+	if err != nil {
+		return MembersResponse{}, fmt.Errorf("error finding nested groups of group %d: %w", groupId, err)
+	}
+
+	return ToMembersResponse(groupId, employees, groups), nil
+}
+
+func (svc *Service) findRoles(ctx context.Context, groupId int64) ([]RoleResponse, error) {
+	entities, err := svc.repo.FindRoles(ctx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding roles of group %d: %w", groupId, err)
+	}
+
+	responses := make([]RoleResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// checkName - имя группы не занято другой группой
+func (svc *Service) checkName(ctx context.Context, name string, excludeId int64) error {
+	isExists, err := svc.repo.ExistsByName(ctx, name, excludeId)
+	if err != nil {
+		return fmt.Errorf("error checking group name %s: %w", name, err)
+	}
+	if isExists {
+		return domain.AlreadyExistsError{Message: fmt.Sprintf("group with name %s already exists", name)}
+	}
+
+	return nil
+}
+
+func (svc *Service) findGroup(ctx context.Context, id int64) (Entity, error) {
+	entity, err := svc.repo.FindById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entity{}, domain.NotFoundError{Message: fmt.Sprintf("group with id %d not found", id)}
+	}
+	if err != nil {
+		return Entity{}, fmt.Errorf("error finding group with id %d: %w", id, err)
+	}
+
+	return entity, nil
+}
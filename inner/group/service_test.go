@@ -0,0 +1,398 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"idm/inner/sod"
+	"testing"
+	"time"
+)
+
+// Объявляем структуру мок-репозитория
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindAll(ctx context.Context) ([]Entity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindById(ctx context.Context, id int64) (Entity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsByName(ctx context.Context, name string, excludeId int64) (bool, error) {
+	args := m.Called(ctx, name, excludeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) CreateGroup(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) UpdateGroup(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) DeleteGroup(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) ExistsAssignableEmployeeById(ctx context.Context, employeeId int64) (bool, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) ExistsRoleById(ctx context.Context, roleId int64) (bool, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindEmployeeMembers(ctx context.Context, groupId int64) ([]MemberEntity, error) {
+	args := m.Called(ctx, groupId)
+	return args.Get(0).([]MemberEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindGroupMembers(ctx context.Context, groupId int64) ([]MemberEntity, error) {
+	args := m.Called(ctx, groupId)
+	return args.Get(0).([]MemberEntity), args.Error(1)
+}
+
+func (m *MockRepo) AddEmployee(ctx context.Context, groupId int64, employeeId int64) error {
+	args := m.Called(ctx, groupId, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RemoveEmployee(ctx context.Context, groupId int64, employeeId int64) (bool, error) {
+	args := m.Called(ctx, groupId, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) AddGroup(ctx context.Context, groupId int64, memberGroupId int64) error {
+	args := m.Called(ctx, groupId, memberGroupId)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RemoveGroup(ctx context.Context, groupId int64, memberGroupId int64) (bool, error) {
+	args := m.Called(ctx, groupId, memberGroupId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindRoles(ctx context.Context, groupId int64) ([]RoleEntity, error) {
+	args := m.Called(ctx, groupId)
+	return args.Get(0).([]RoleEntity), args.Error(1)
+}
+
+func (m *MockRepo) GrantRole(ctx context.Context, groupId int64, roleId int64) error {
+	args := m.Called(ctx, groupId, roleId)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RevokeRole(ctx context.Context, groupId int64, roleId int64) (bool, error) {
+	args := m.Called(ctx, groupId, roleId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindEffectiveRolesByEmployeeId(ctx context.Context, employeeId int64) ([]EffectiveRoleEntity, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).([]EffectiveRoleEntity), args.Error(1)
+}
+
+func TestGroupService(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should create group", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "Admins", Description: "System administrators"}
+		created := Entity{Id: 1, Name: "Admins", Description: "System administrators", CreatedAt: now, UpdatedAt: now}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsByName", appContext, "Admins", int64(0)).Return(false, nil).Once()
+		repo.On("CreateGroup", appContext, request.ToEntity()).Return(created, nil).Once()
+
+		got, err := service.CreateGroup(appContext, request)
+
+		a.Nil(err)
+		a.Equal(created.ToResponse(), got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return already exists when group name is taken", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 2, Name: "Admins"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(2)).Return(Entity{Id: 2, Name: "Ops"}, nil).Once()
+		repo.On("ExistsByName", appContext, "Admins", int64(2)).Return(true, nil).Once()
+
+		_, err := service.UpdateGroup(appContext, 2, UpdateRequest{Name: "Admins"})
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "UpdateGroup", appContext, request.ToEntity())
+	})
+
+	t.Run("should return not found when deleting unknown group", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", DeleteByIdRequest{ID: 3}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(3)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		_, err := service.DeleteById(appContext, 3)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "DeleteGroup", appContext, int64(3))
+	})
+}
+
+func TestGroupService_Members(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should add employee and return members", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AddEmployeeRequest{GroupID: 1, EmployeeID: 10}
+		employees := []MemberEntity{{Id: 10, Name: "Alice Marcus", AddedAt: now}}
+		groups := []MemberEntity{{Id: 2, Name: "Ops", AddedAt: now}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsAssignableEmployeeById", appContext, int64(10)).Return(true, nil).Once()
+		repo.On("AddEmployee", appContext, int64(1), int64(10)).Return(nil).Once()
+		repo.On("FindEmployeeMembers", appContext, int64(1)).Return(employees, nil).Once()
+		repo.On("FindGroupMembers", appContext, int64(1)).Return(groups, nil).Once()
+
+		got, err := service.AddEmployee(appContext, 1, AddEmployeeRequest{EmployeeID: 10})
+
+		a.Nil(err)
+		a.Equal(MembersResponse{
+			GroupID:   1,
+			Employees: []MemberResponse{{Id: 10, Name: "Alice Marcus", AddedAt: now}},
+			Groups:    []MemberResponse{{Id: 2, Name: "Ops", AddedAt: now}},
+		}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when employee cannot be added", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AddEmployeeRequest{GroupID: 1, EmployeeID: 10}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsAssignableEmployeeById", appContext, int64(10)).Return(false, nil).Once()
+
+		_, err := service.AddEmployee(appContext, 1, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "AddEmployee", appContext, int64(1), int64(10))
+	})
+
+	t.Run("should not nest group into itself", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AddGroupRequest{GroupID: 1, MemberGroupID: 1}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		_, err := service.AddGroup(appContext, 1, AddGroupRequest{MemberGroupID: 1})
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "AddGroup", appContext, int64(1), int64(1))
+	})
+
+	t.Run("should return validation error on nesting cycle", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AddGroupRequest{GroupID: 1, MemberGroupID: 2}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindById", appContext, int64(2)).Return(Entity{Id: 2}, nil).Once()
+		repo.On("AddGroup", appContext, int64(1), int64(2)).Return(ErrGroupCycle).Once()
+
+		_, err := service.AddGroup(appContext, 1, request)
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "FindEmployeeMembers", appContext, int64(1))
+	})
+
+	t.Run("should return sod violation when adding employee or nesting group", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		violation := fmt.Errorf("%w: roles CREATOR and APPROVER must not be held together (rule 1)", sod.ErrViolation)
+
+		validator.On("Validate", AddEmployeeRequest{GroupID: 1, EmployeeID: 10}).Return(nil).Once()
+		validator.On("Validate", AddGroupRequest{GroupID: 1, MemberGroupID: 2}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Twice()
+		repo.On("FindById", appContext, int64(2)).Return(Entity{Id: 2}, nil).Once()
+		repo.On("ExistsAssignableEmployeeById", appContext, int64(10)).Return(true, nil).Once()
+		repo.On("AddEmployee", appContext, int64(1), int64(10)).Return(violation).Once()
+		repo.On("AddGroup", appContext, int64(1), int64(2)).Return(violation).Once()
+
+		_, err := service.AddEmployee(appContext, 1, AddEmployeeRequest{EmployeeID: 10})
+		a.True(errors.As(err, &domain.SodViolationError{}))
+		a.ErrorContains(err, "APPROVER")
+
+		_, err = service.AddGroup(appContext, 1, AddGroupRequest{MemberGroupID: 2})
+		a.True(errors.As(err, &domain.SodViolationError{}))
+		repo.AssertNotCalled(t, "FindEmployeeMembers", appContext, int64(1))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when nested group does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AddGroupRequest{GroupID: 1, MemberGroupID: 2}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindById", appContext, int64(2)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		_, err := service.AddGroup(appContext, 1, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "AddGroup", appContext, int64(1), int64(2))
+	})
+
+	t.Run("should return not found when removing non member", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", RemoveRequest{GroupID: 1, MemberID: 10}).Return(nil).Once()
+		repo.On("RemoveEmployee", appContext, int64(1), int64(10)).Return(false, nil).Once()
+
+		_, err := service.RemoveEmployee(appContext, 1, 10)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+}
+
+func TestGroupService_Roles(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should grant role to group", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := GrantRoleRequest{GroupID: 1, RoleID: 5}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(5)).Return(true, nil).Once()
+		repo.On("GrantRole", appContext, int64(1), int64(5)).Return(nil).Once()
+		repo.On("FindRoles", appContext, int64(1)).Return([]RoleEntity{{Id: 5, Name: "ADMIN", GrantedAt: now}}, nil).Once()
+
+		got, err := service.GrantRole(appContext, 1, GrantRoleRequest{RoleID: 5})
+
+		a.Nil(err)
+		a.Equal([]RoleResponse{{Id: 5, Name: "ADMIN", GrantedAt: now}}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return sod violation when granting role to group", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := GrantRoleRequest{GroupID: 1, RoleID: 5}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(5)).Return(true, nil).Once()
+		repo.On("GrantRole", appContext, int64(1), int64(5)).Return(fmt.Errorf("%w: rule 1", sod.ErrViolation)).Once()
+
+		_, err := service.GrantRole(appContext, 1, GrantRoleRequest{RoleID: 5})
+
+		a.True(errors.As(err, &domain.SodViolationError{}))
+		repo.AssertNotCalled(t, "FindRoles", appContext, int64(1))
+	})
+
+	t.Run("should return not found when granting unknown role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := GrantRoleRequest{GroupID: 1, RoleID: 5}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(5)).Return(false, nil).Once()
+
+		_, err := service.GrantRole(appContext, 1, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "GrantRole", appContext, int64(1), int64(5))
+	})
+
+	t.Run("should fold effective roles by source", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		var admins, ops = "Admins", "Ops"
+		entities := []EffectiveRoleEntity{
+			{Id: 5, Name: "ADMIN", GroupName: &admins},
+			{Id: 6, Name: "USER"},
+			{Id: 6, Name: "USER", GroupName: &admins},
+			{Id: 6, Name: "USER", GroupName: &ops},
+		}
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(10)).Return(true, nil).Once()
+		repo.On("FindEffectiveRolesByEmployeeId", appContext, int64(10)).Return(entities, nil).Once()
+
+		got, err := service.FindEffectiveRoles(appContext, 10)
+
+		a.Nil(err)
+		a.Equal([]EffectiveRoleResponse{
+			{Id: 5, Name: "ADMIN", Direct: false, Groups: []string{"Admins"}},
+			{Id: 6, Name: "USER", Direct: true, Groups: []string{"Admins", "Ops"}},
+		}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found for effective roles of unknown employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(10)).Return(false, nil).Once()
+
+		_, err := service.FindEffectiveRoles(appContext, 10)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "FindEffectiveRolesByEmployeeId", appContext, int64(10))
+	})
+}
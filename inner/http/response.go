@@ -1,6 +1,7 @@
 package http
 
 import (
+	"bufio"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -75,3 +76,38 @@ func CreatedResponse(
 		Data:    data,
 	})
 }
+
+// CsvResponse - выгрузка CSV файлом-вложением filename со статусом 200
+func CsvResponse(
+	c *fiber.Ctx,
+	filename string,
+	body []byte,
+) error {
+	c.Attachment(filename)
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return c.Status(fiber.StatusOK).Send(body)
+}
+
+// StreamResponse - выгрузка файлом-вложением filename со статусом 200, тело передаётся частями (chunked):
+// write вызывается после возврата из обработчика, поэтому не должна обращаться к c. Ошибка внутри write
+// уже не меняет статус - клиент получит обрезанный файл
+func StreamResponse(
+	c *fiber.Ctx,
+	filename string,
+	contentType string,
+	write func(w *bufio.Writer),
+) error {
+	c.Attachment(filename)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(write)
+	return nil
+}
+
+// ScimResponse - ресурс или ошибка SCIM без обёртки Response, с типом application/scim+json
+func ScimResponse(
+	c *fiber.Ctx,
+	code int,
+	body interface{},
+) error {
+	return c.Status(code).JSON(body, "application/scim+json")
+}
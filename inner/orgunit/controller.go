@@ -0,0 +1,476 @@
+package orgunit
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/employee"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError     = "Internal server error"
+	invalidIDFormat         = "Invalid ID format"
+	invalidRequestBody      = "Invalid request body"
+	invalidPageValuesFormat = "Invalid Page Values format"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server         *web.Server
+	orgUnitService Svc
+	logger         *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	FindAll(ctx context.Context) ([]Response, error)
+	FindTree(ctx context.Context) ([]TreeResponse, error)
+	FindById(ctx context.Context, id int64) (Response, error)
+	CreateOrgUnit(ctx context.Context, request CreateRequest) (Response, error)
+	UpdateOrgUnit(ctx context.Context, id int64, request UpdateRequest) (Response, error)
+	DeleteById(ctx context.Context, id int64) (Response, error)
+	MoveEmployees(ctx context.Context, orgUnitId int64, request MoveEmployeesRequest) (MoveResponse, error)
+	RemoveMember(ctx context.Context, orgUnitId int64, employeeId int64) error
+	FindMembers(ctx context.Context, request MembersRequest) (employee.PageResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	orgUnitService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:         server,
+		orgUnitService: orgUnitService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/orgunits"
+	c.server.GroupOrgUnits.Get("/", c.server.Require(web.PermOrgUnitsRead), c.FindAll)
+	c.server.GroupOrgUnits.Get("/tree", c.server.Require(web.PermOrgUnitsRead), c.FindTree)
+	c.server.GroupOrgUnits.Post("/", c.server.Require(web.PermOrgUnitsWrite), c.CreateOrgUnit)
+	c.server.GroupOrgUnits.Get("/:id", c.server.Require(web.PermOrgUnitsRead), c.FindById)
+	c.server.GroupOrgUnits.Put("/:id", c.server.Require(web.PermOrgUnitsWrite), c.UpdateOrgUnit)
+	c.server.GroupOrgUnits.Delete("/:id", c.server.Require(web.PermOrgUnitsWrite), c.DeleteById)
+	// состав подразделения - это и данные сотрудников, поэтому нужно ещё право на их просмотр
+	c.server.GroupOrgUnits.Get("/:id/members", c.server.Require(web.PermOrgUnitsRead, web.PermEmployeesRead), c.FindMembers)
+	c.server.GroupOrgUnits.Post("/:id/members", c.server.Require(web.PermOrgUnitsWrite), c.MoveEmployees)
+	c.server.GroupOrgUnits.Delete("/:id/members/:employeeId", c.server.Require(web.PermOrgUnitsWrite), c.RemoveMember)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/orgunits" --//
+
+// FindAll   	 godoc
+// @Description  Find all organizational units as a flat list
+// @Summary		 get all org units
+// @Tags 		 orgunit
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		orgunit.Response	"Org unit response"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /orgunits/		[get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.orgUnitService.FindAll(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Org Units ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindTree   	 godoc
+// @Description  Find all organizational units as a tree starting from root units
+// @Summary		 get org unit tree
+// @Tags 		 orgunit
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		orgunit.TreeResponse	"Org unit tree"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /orgunits/tree		[get]
+func (c *Controller) FindTree(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.orgUnitService.FindTree(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find Org Unit tree ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find by ID organizational unit
+// @Summary 	 find by ID org unit
+// @Tags 		 orgunit
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  			"Org unit ID"
+// @Success 	 200  {object}  	orgunit.Response	"Org unit response"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      404  {object}  	http.Response		"Not found"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /orgunits/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	orgUnitID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.orgUnitService.FindById(appContext, orgUnitID)
+	if err != nil {
+		c.logger.Error(
+			"When the get Org Unit ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", orgUnitID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// CreateOrgUnit godoc
+// @Summary      create a new org unit
+// @Description  Create a new organizational unit, root or under an existing parent
+// @Tags 		 orgunit
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	orgunit.CreateRequest true "Org unit creation details"
+// @Success 	 201  {object}  orgunit.Response	"Org unit response"
+// @Failure      400  {object}  http.Response		"Bad request"
+// @Failure      409  {object}  http.Response		"Name already taken under the parent"
+// @Failure      500  {object}  http.Response		"Bad request"
+// @Router 		 /orgunits/ 	[post]
+func (c *Controller) CreateOrgUnit(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an CreateOrgUnit ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.orgUnitService.CreateOrgUnit(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Org Unit ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// UpdateOrgUnit godoc
+// @Summary      update org unit
+// @Description  Rename organizational unit and/or move it with its subtree under another parent
+// @Tags 		 orgunit
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  					true  	"Org unit ID"
+// @Param 		 request 	body 		orgunit.UpdateRequest 	true 	"Org unit update details"
+// @Success 	 200  {object}  	orgunit.Response	"Org unit response"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      404  {object}  	http.Response		"Not found"
+// @Failure      409  {object}  	http.Response		"Name already taken under the parent"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /orgunits/{id} 	[put]
+func (c *Controller) UpdateOrgUnit(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	orgUnitID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request UpdateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an UpdateOrgUnit ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.orgUnitService.UpdateOrgUnit(appContext, orgUnitID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the update Org Unit ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", orgUnitID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// DeleteById  godoc
+// @Description  Delete organizational unit without child units and employees
+// @Summary		 delete org unit by ID
+// @Tags 		 orgunit
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  				true	"Org unit ID"
+// @Success 	 200  {object} 		orgunit.Response			"Org unit response"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      409  {object}  	http.Response				"Unit has child units or employees"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /orgunits/{id}	[delete]
+func (c *Controller) DeleteById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	orgUnitID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.orgUnitService.DeleteById(appContext, orgUnitID)
+	if err != nil {
+		c.logger.Error(
+			"When the delete Org Unit ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", orgUnitID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindMembers 	 godoc
+// @Description  Find page of employees of organizational unit (with includeSubunits - of the whole subtree)
+// @Summary 	 find org unit members
+// @Tags 		 orgunit
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   				path      	int  	true  	"Org unit ID"
+// @Param 		 pageNumber 		query     	int  	false  	"Page number" 		default(1)
+// @Param 		 pageSize 			query     	int  	false  	"Page size" 		default(10)
+// @Param 		 textFilter 		query     	string  false  	"Employee name filter (at least 3 characters)"
+// @Param 		 includeSubunits 	query     	bool  	false  	"Include employees of child units"
+// @Success 	 200  {object}  	employee.PageResponse	"Employee page"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /orgunits/{id}/members 	[get]
+func (c *Controller) FindMembers(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	orgUnitID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	pageNumber, errNumber := strconv.ParseInt(ctx.Query("pageNumber", "1"), 10, 64)
+	pageSize, errSize := strconv.ParseInt(ctx.Query("pageSize", "10"), 10, 64)
+	if err = errors.Join(errNumber, errSize); err != nil {
+		c.logger.Error(
+			"When the parse an Org Unit members page values ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidPageValuesFormat)
+	}
+
+	var request = MembersRequest{
+		OrgUnitID:       orgUnitID,
+		PageSize:        pageSize,
+		PageNumber:      pageNumber,
+		TextFilter:      ctx.Query("textFilter"),
+		IncludeSubunits: ctx.QueryBool("includeSubunits", false),
+	}
+	response, err := c.orgUnitService.FindMembers(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the find Org Unit members ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", orgUnitID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkPageResponse(ctx, response)
+}
+
+// MoveEmployees godoc
+// @Description  Move employees into organizational unit from their current units
+// @Summary 	 move employees to org unit
+// @Tags 		 orgunit
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  							true  	"Org unit ID"
+// @Param 		 request 	body 		orgunit.MoveEmployeesRequest 	true 	"Employees to move"
+// @Success 	 200  {object}  	orgunit.MoveResponse	"Move result"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /orgunits/{id}/members 	[post]
+func (c *Controller) MoveEmployees(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	orgUnitID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request MoveEmployeesRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an MoveEmployees ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.orgUnitService.MoveEmployees(appContext, orgUnitID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the move Employees to Org Unit ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", orgUnitID),
+			zap.Int64s("employee_ids", request.EmployeeIDs),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// RemoveMember  godoc
+// @Description  Remove employee from organizational unit (employee becomes unassigned)
+// @Summary		 remove employee from org unit
+// @Tags 		 orgunit
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   			path     	int  	true	"Org unit ID"
+// @Param        employeeId   	path     	int  	true	"Employee ID"
+// @Success 	 200  {object} 		http.Response		"Removed"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      404  {object}  	http.Response		"Not found"
+// @Failure      500  {object} 	 	http.Response		"Bad request"
+// @Router 		 /orgunits/{id}/members/{employeeId}	[delete]
+func (c *Controller) RemoveMember(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	orgUnitID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+	employeeID, err := c.parseIdParam(ctx, "employeeId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	if err = c.orgUnitService.RemoveMember(appContext, orgUnitID, employeeID); err != nil {
+		c.logger.Error(
+			"When the remove Employee from Org Unit ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", orgUnitID),
+			zap.Int64("employee_id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, nil)
+}
+
+// parseIdParam - разбор числового path-параметра с логированием ошибки
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}), errors.As(err, &domain.ConflictError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,172 @@
+package orgunit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/employee"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOrgUnit_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockOrgUnitService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupOrgUnits:         app.Group("/api/v1/orgunits"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should return tree of org units", func(t *testing.T) {
+		tree := []TreeResponse{
+			{Id: 1, Name: "Company", Children: []TreeResponse{{Id: 2, Name: "IT", Children: []TreeResponse{}}}},
+		}
+		mockService.On("FindTree", appContext).Return(tree, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orgunits/tree", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data []TreeResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, tree, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should create org unit", func(t *testing.T) {
+		request := CreateRequest{Name: "IT", ParentId: 1}
+		var parentId = int64(1)
+		mockService.On("CreateOrgUnit", appContext, request).
+			Return(Response{Id: 2, ParentId: &parentId, Name: "IT"}, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/orgunits/", strings.NewReader(`{"name": "IT", "parentId": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when moving unit into its subtree", func(t *testing.T) {
+		request := UpdateRequest{Name: "Company", ParentId: 3}
+		cycle := domain.RequestValidationError{Message: "org unit 1 cannot be moved under 3"}
+		mockService.On("UpdateOrgUnit", appContext, int64(1), request).Return(Response{}, cycle).Once()
+
+		req := httptest.NewRequest("PUT", "/api/v1/orgunits/1", strings.NewReader(`{"name": "Company", "parentId": 3}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, cycle.Message, body.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when deleting unit with members", func(t *testing.T) {
+		conflict := domain.ConflictError{Message: "org unit 1 has 0 child units and 2 employees"}
+		mockService.On("DeleteById", appContext, int64(1)).Return(Response{}, conflict).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/orgunits/1", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should move employees to org unit", func(t *testing.T) {
+		request := MoveEmployeesRequest{EmployeeIDs: []int64{10, 11}}
+		mockService.On("MoveEmployees", appContext, int64(2), request).
+			Return(MoveResponse{OrgUnitId: 2, Moved: 2}, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/orgunits/2/members", strings.NewReader(`{"employeeIds": [10, 11]}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data MoveResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, MoveResponse{OrgUnitId: 2, Moved: 2}, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when removing non member", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "employee 10 is not a member of org unit 2"}
+		mockService.On("RemoveMember", appContext, int64(2), int64(10)).Return(notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/orgunits/2/members/10", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return members page with subunits", func(t *testing.T) {
+		request := MembersRequest{OrgUnitID: 1, PageSize: 5, PageNumber: 2, TextFilter: "Ali", IncludeSubunits: true}
+		page := employee.PageResponse{
+			Result:     []employee.Response{{Id: 10, Name: "Alice"}},
+			PageSize:   5,
+			PageNumber: 2,
+			Total:      6,
+		}
+		mockService.On("FindMembers", appContext, request).Return(page, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET",
+			"/api/v1/orgunits/1/members?pageNumber=2&pageSize=5&textFilter=Ali&includeSubunits=true", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when page values are invalid", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orgunits/1/members?pageSize=abc", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "FindMembers")
+	})
+}
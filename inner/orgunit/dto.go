@@ -0,0 +1,170 @@
+package orgunit
+
+import (
+	"time"
+)
+
+type Entity struct {
+	Id        int64     `db:"id"`
+	ParentId  *int64    `db:"parent_id"` // nil - корневое подразделение
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Response model info
+// @Description Organizational unit
+// @Description with unit id, parent id (absent for root units), name, createAt, updateAt
+type Response struct {
+	Id       int64     `json:"id"`
+	ParentId *int64    `json:"parentId,omitempty"`
+	Name     string    `json:"name"`
+	CreateAt time.Time `json:"createAt"`
+	UpdateAt time.Time `json:"updateAt"`
+}
+
+func (e *Entity) ToResponse() Response {
+	return Response{
+		Id:       e.Id,
+		ParentId: e.ParentId,
+		Name:     e.Name,
+		CreateAt: e.CreatedAt,
+		UpdateAt: e.UpdatedAt,
+	}
+}
+
+// TreeResponse model info
+// @Description Organizational unit with nested child units
+type TreeResponse struct {
+	Id       int64          `json:"id"`
+	Name     string         `json:"name"`
+	Children []TreeResponse `json:"children"`
+}
+
+// ToTree - собрать дерево из плоского списка подразделений, порядок внутри уровня сохраняется
+func ToTree(entities []Entity) []TreeResponse {
+	var children = make(map[int64][]Entity, len(entities)) // 0 - корневые
+	for _, entity := range entities {
+		var parentId int64
+		if entity.ParentId != nil {
+			parentId = *entity.ParentId
+		}
+		children[parentId] = append(children[parentId], entity)
+	}
+
+	var build func(parentId int64) []TreeResponse
+	build = func(parentId int64) []TreeResponse {
+		var nodes = make([]TreeResponse, 0, len(children[parentId]))
+		for _, entity := range children[parentId] {
+			nodes = append(nodes, TreeResponse{
+				Id:       entity.Id,
+				Name:     entity.Name,
+				Children: build(entity.Id),
+			})
+		}
+		return nodes
+	}
+
+	return build(0)
+}
+
+// CreateRequest model info
+// @Description Organizational unit creation
+// @Description with name and optional parent unit id
+type CreateRequest struct {
+	Name     string `json:"name" validate:"required,min=2,max=155,no_sql_injection"`
+	ParentId int64  `json:"parentId" validate:"omitempty,min=1"`
+}
+
+func (req *CreateRequest) ToEntity() *Entity {
+	return &Entity{Name: req.Name, ParentId: parentOf(req.ParentId)}
+}
+
+// UpdateRequest model info
+// @Description Organizational unit update: rename and/or move under another parent
+// @Description (parentId omitted or 0 - make the unit a root one)
+type UpdateRequest struct {
+	Id       int64  `json:"-" validate:"required,min=1"`
+	Name     string `json:"name" validate:"required,min=2,max=155,no_sql_injection"`
+	ParentId int64  `json:"parentId" validate:"omitempty,min=1"`
+}
+
+func (req *UpdateRequest) ToEntity() *Entity {
+	return &Entity{Id: req.Id, Name: req.Name, ParentId: parentOf(req.ParentId)}
+}
+
+func parentOf(parentId int64) *int64 {
+	if parentId == 0 {
+		return nil
+	}
+	return &parentId
+}
+
+// MoveEmployeesRequest model info
+// @Description Employees to move into the organizational unit
+// @Description with employee ids
+type MoveEmployeesRequest struct {
+	OrgUnitID   int64   `json:"-" validate:"required,min=1"`
+	EmployeeIDs []int64 `json:"employeeIds" validate:"required,min=1,max=1000,dive,min=1"`
+}
+
+// MoveResponse model info
+// @Description Result of moving employees
+// @Description with unit id and number of employees actually moved (already members are skipped)
+type MoveResponse struct {
+	OrgUnitId int64 `json:"orgUnitId"`
+	Moved     int64 `json:"moved"`
+}
+
+type RemoveMemberRequest struct {
+	OrgUnitID  int64 `validate:"required,min=1"`
+	EmployeeID int64 `validate:"required,min=1"`
+}
+
+// MembersRequest - страница сотрудников подразделения, при IncludeSubunits - вместе с дочерними
+type MembersRequest struct {
+	OrgUnitID       int64  `validate:"required,min=1"`
+	PageSize        int64  `validate:"required,min=1,max=155"`
+	PageNumber      int64  `validate:"required,min=1,max=1000"`
+	TextFilter      string `validate:"omitempty,min=3,max=100,no_sql_injection"`
+	IncludeSubunits bool
+}
+
+// MembersFilter - условия выборки сотрудников подразделения для репозитория
+type MembersFilter struct {
+	OrgUnitId       int64
+	IncludeSubunits bool
+	TextFilter      string
+	Limit           int64
+	Offset          int64
+}
+
+func (req *MembersRequest) ToFilter() MembersFilter {
+	return MembersFilter{
+		OrgUnitId:       req.OrgUnitID,
+		IncludeSubunits: req.IncludeSubunits,
+		TextFilter:      req.TextFilter,
+		Limit:           req.PageSize,
+		Offset:          (req.PageNumber - 1) * req.PageSize,
+	}
+}
+
+// MembershipSnapshot - подразделение сотрудника для журнала аудита (entity_id события - id сотрудника)
+type MembershipSnapshot struct {
+	EmployeeId int64  `json:"employeeId"`
+	OrgUnitId  *int64 `json:"orgUnitId"`
+}
+
+// MemberEntity - текущее подразделение сотрудника, выбранного для перевода
+type MemberEntity struct {
+	Id        int64  `db:"id"`
+	OrgUnitId *int64 `db:"org_unit_id"`
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
+
+type DeleteByIdRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
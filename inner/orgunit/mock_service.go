@@ -0,0 +1,56 @@
+package orgunit
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/employee"
+)
+
+type MockOrgUnitService struct {
+	mock.Mock
+}
+
+func (m *MockOrgUnitService) FindAll(ctx context.Context) ([]Response, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockOrgUnitService) FindTree(ctx context.Context) ([]TreeResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]TreeResponse), args.Error(1)
+}
+
+func (m *MockOrgUnitService) FindById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockOrgUnitService) CreateOrgUnit(ctx context.Context, request CreateRequest) (Response, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockOrgUnitService) UpdateOrgUnit(ctx context.Context, id int64, request UpdateRequest) (Response, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockOrgUnitService) DeleteById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockOrgUnitService) MoveEmployees(ctx context.Context, orgUnitId int64, request MoveEmployeesRequest) (MoveResponse, error) {
+	args := m.Called(ctx, orgUnitId, request)
+	return args.Get(0).(MoveResponse), args.Error(1)
+}
+
+func (m *MockOrgUnitService) RemoveMember(ctx context.Context, orgUnitId int64, employeeId int64) error {
+	args := m.Called(ctx, orgUnitId, employeeId)
+	return args.Error(0)
+}
+
+func (m *MockOrgUnitService) FindMembers(ctx context.Context, request MembersRequest) (employee.PageResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(employee.PageResponse), args.Error(1)
+}
@@ -0,0 +1,315 @@
+package orgunit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"idm/inner/employee"
+	"idm/inner/outbox"
+	"strings"
+	"time"
+)
+
+// treeLockKey - ключ pg_advisory_xact_lock, сериализующий перемещения подразделений в дереве
+const treeLockKey int64 = 0x6f72675f74726565 // "org_tree"
+
+// ErrParentCycle - новый родитель находится внутри перемещаемого подразделения
+var ErrParentCycle = errors.New("parent is the unit itself or one of its descendants")
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindAll - все подразделения, упорядоченные по имени (для построения дерева)
+func (r *Repository) FindAll(ctx context.Context) (units []Entity, err error) {
+	err = r.db.SelectContext(ctx, &units, "SELECT * FROM org_units ORDER BY name, id")
+
+	return units, err
+}
+
+// FindById - найти подразделение по id
+func (r *Repository) FindById(ctx context.Context, id int64) (unit Entity, err error) {
+	err = r.db.GetContext(ctx, &unit, "SELECT * FROM org_units WHERE id = $1", id)
+
+	return unit, err
+}
+
+// ExistsByName - есть ли у родителя (nil - среди корневых) другое подразделение с таким именем
+func (r *Repository) ExistsByName(
+	ctx context.Context,
+	parentId *int64,
+	name string,
+	excludeId int64,
+) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		`SELECT EXISTS(SELECT 1 FROM org_units
+		WHERE COALESCE(parent_id, 0) = COALESCE($1, 0) AND lower(name) = lower($2) AND id <> $3)`,
+		parentId, name, excludeId,
+	)
+
+	return isExists, err
+}
+
+// CreateOrgUnit - добавить подразделение
+func (r *Repository) CreateOrgUnit(ctx context.Context, entity *Entity) (created Entity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(
+			ctx,
+			&created,
+			`INSERT INTO org_units (parent_id, name, created_at, updated_at)
+			VALUES ($1, $2, $3, $3)
+			RETURNING *`,
+			entity.ParentId, entity.Name, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityOrgUnit,
+			EntityId:   created.Id,
+			After:      created.ToResponse(),
+		})
+	})
+
+	return created, err
+}
+
+// UpdateOrgUnit - переименовать подразделение и/или перенести его к другому родителю.
+// Перенос внутрь собственного поддерева - ErrParentCycle
+func (r *Repository) UpdateOrgUnit(ctx context.Context, entity *Entity) (updated Entity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before Entity
+		err := tx.GetContext(ctx, &before, "SELECT * FROM org_units WHERE id = $1 FOR UPDATE", entity.Id)
+		if err != nil {
+			return err
+		}
+
+		if entity.ParentId != nil {
+			if err = checkParentCycleTx(ctx, tx, *entity.ParentId, entity.Id); err != nil {
+				return err
+			}
+		}
+
+		err = tx.GetContext(
+			ctx,
+			&updated,
+			"UPDATE org_units SET name = $1, parent_id = $2, updated_at = $3 WHERE id = $4 RETURNING *",
+			entity.Name, entity.ParentId, time.Now(), entity.Id,
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityOrgUnit,
+			EntityId:   updated.Id,
+			Before:     before.ToResponse(),
+			After:      updated.ToResponse(),
+		})
+	})
+
+	return updated, err
+}
+
+// checkParentCycleTx - подразделение нельзя перенести в само себя или в своё поддерево.
+// Перемещения сериализуются advisory-блокировкой, иначе два встречных переноса
+// проверялись бы параллельно и вместе образовали цикл
+func checkParentCycleTx(ctx context.Context, tx *sqlx.Tx, parentId int64, id int64) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", treeLockKey); err != nil {
+		return err
+	}
+
+	var isCycle bool
+	err := tx.GetContext(
+		ctx,
+		&isCycle,
+		`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM org_units WHERE id = $1
+			UNION
+			SELECT o.id, o.parent_id FROM org_units o JOIN ancestors a ON o.id = a.parent_id
+		)
+		SELECT EXISTS(SELECT 1 FROM ancestors WHERE id = $2)`,
+		parentId, id,
+	)
+	if err != nil {
+		return err
+	}
+	if isCycle {
+		return ErrParentCycle
+	}
+	return nil
+}
+
+// FindUsage - число дочерних подразделений и сотрудников (включая мягко удалённых), мешающих удалению
+func (r *Repository) FindUsage(ctx context.Context, id int64) (children int64, members int64, err error) {
+	var usage struct {
+		Children int64 `db:"children"`
+		Members  int64 `db:"members"`
+	}
+	err = r.db.GetContext(
+		ctx,
+		&usage,
+		`SELECT (SELECT COUNT(*) FROM org_units WHERE parent_id = $1) AS children,
+		(SELECT COUNT(*) FROM employees WHERE org_unit_id = $1) AS members`,
+		id,
+	)
+
+	return usage.Children, usage.Members, err
+}
+
+// DeleteOrgUnit - удалить подразделение без дочерних подразделений и сотрудников
+func (r *Repository) DeleteOrgUnit(ctx context.Context, id int64) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var deleted []Entity
+		err := tx.SelectContext(ctx, &deleted, "DELETE FROM org_units WHERE id = $1 RETURNING *", id)
+		if err != nil {
+			return err
+		}
+
+		for _, entity := range deleted {
+			err = audit.InsertEventTx(ctx, tx, audit.Event{
+				Action:     audit.ActionDelete,
+				EntityType: audit.EntityOrgUnit,
+				EntityId:   entity.Id,
+				Before:     entity.ToResponse(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindExistingEmployeeIds - какие из ids принадлежат не удалённым сотрудникам
+func (r *Repository) FindExistingEmployeeIds(ctx context.Context, ids []int64) (existing []int64, err error) {
+	query, args, err := sqlx.In("SELECT id FROM employees WHERE id IN (?) AND deleted_at IS NULL", ids)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.SelectContext(ctx, &existing, r.db.Rebind(query), args...)
+
+	return existing, err
+}
+
+// MoveEmployees - перевести сотрудников в подразделение. Сотрудники, которые уже в нём,
+// пропускаются. Возвращает число переведённых
+func (r *Repository) MoveEmployees(ctx context.Context, orgUnitId int64, employeeIds []int64) (int64, error) {
+	query, args, err := sqlx.In(
+		`SELECT id, org_unit_id FROM employees
+		WHERE id IN (?) AND deleted_at IS NULL AND org_unit_id IS DISTINCT FROM ?
+		ORDER BY id FOR UPDATE`,
+		employeeIds, orgUnitId,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.moveTx(ctx, &orgUnitId, r.db.Rebind(query), args...)
+}
+
+// RemoveMember - вывести сотрудника из подразделения, возвращает false если он в нём не состоял
+func (r *Repository) RemoveMember(ctx context.Context, orgUnitId int64, employeeId int64) (bool, error) {
+	moved, err := r.moveTx(
+		ctx,
+		nil,
+		"SELECT id, org_unit_id FROM employees WHERE id = $1 AND org_unit_id = $2 FOR UPDATE",
+		employeeId, orgUnitId,
+	)
+
+	return moved > 0, err
+}
+
+// moveTx - перевести в подразделение to (nil - вывести из подразделения) сотрудников, выбранных
+// запросом с FOR UPDATE, и записать по событию аудита на каждого в одной транзакции
+func (r *Repository) moveTx(ctx context.Context, to *int64, query string, args ...interface{}) (moved int64, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var members []MemberEntity
+		if err := tx.SelectContext(ctx, &members, query, args...); err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+
+		var ids = make([]int64, 0, len(members))
+		for _, member := range members {
+			ids = append(ids, member.Id)
+		}
+		update, updateArgs, err := sqlx.In(
+			"UPDATE employees SET org_unit_id = ?, updated_at = ? WHERE id IN (?)",
+			to, time.Now(), ids,
+		)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, tx.Rebind(update), updateArgs...); err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			err = outbox.RecordTx(ctx, tx, audit.Event{
+				Action:     audit.ActionMove,
+				EntityType: audit.EntityEmployee,
+				EntityId:   member.Id,
+				Before:     MembershipSnapshot{EmployeeId: member.Id, OrgUnitId: member.OrgUnitId},
+				After:      MembershipSnapshot{EmployeeId: member.Id, OrgUnitId: to},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		moved = int64(len(members))
+		return nil
+	})
+
+	return moved, err
+}
+
+// FindMembersPage - страница не удалённых сотрудников подразделения (при IncludeSubunits - и всех
+// его дочерних), упорядоченных по имени. Возвращает также общее число сотрудников
+func (r *Repository) FindMembersPage(ctx context.Context, filter MembersFilter) ([]employee.Entity, int64, error) {
+	// UNION (а не UNION ALL) не даст рекурсии зациклиться
+	const units = `WITH RECURSIVE units AS (
+		SELECT id FROM org_units WHERE id = $1
+		UNION
+		SELECT o.id FROM org_units o JOIN units u ON o.parent_id = u.id WHERE $2
+	)`
+	var where = " WHERE e.org_unit_id IN (SELECT id FROM units) AND e.deleted_at IS NULL"
+	var args = []interface{}{filter.OrgUnitId, filter.IncludeSubunits}
+	if filter.TextFilter != "" {
+		args = append(args, "%"+strings.ReplaceAll(filter.TextFilter, "%", "\\%")+"%")
+		where += fmt.Sprintf(" AND e.name ILIKE $%d", len(args))
+	}
+
+	var total int64
+	err := r.db.GetContext(ctx, &total, units+" SELECT COUNT(*) FROM employees e"+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		"%s SELECT e.* FROM employees e%s ORDER BY e.name, e.id LIMIT $%d OFFSET $%d",
+		units, where, len(args)+1, len(args)+2,
+	)
+	var members []employee.Entity
+	err = r.db.SelectContext(ctx, &members, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get org unit members: %w", err)
+	}
+
+	return members, total, nil
+}
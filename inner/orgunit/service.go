@@ -0,0 +1,285 @@
+package orgunit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"idm/inner/domain"
+	"idm/inner/employee"
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+}
+
+type Repo interface {
+	FindAll(ctx context.Context) ([]Entity, error)
+	FindById(ctx context.Context, id int64) (Entity, error)
+	ExistsByName(ctx context.Context, parentId *int64, name string, excludeId int64) (bool, error)
+	CreateOrgUnit(ctx context.Context, entity *Entity) (Entity, error)
+	UpdateOrgUnit(ctx context.Context, entity *Entity) (Entity, error)
+	FindUsage(ctx context.Context, id int64) (int64, int64, error)
+	DeleteOrgUnit(ctx context.Context, id int64) error
+	FindExistingEmployeeIds(ctx context.Context, ids []int64) ([]int64, error)
+	MoveEmployees(ctx context.Context, orgUnitId int64, employeeIds []int64) (int64, error)
+	RemoveMember(ctx context.Context, orgUnitId int64, employeeId int64) (bool, error)
+	FindMembersPage(ctx context.Context, filter MembersFilter) ([]employee.Entity, int64, error)
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+	}
+}
+
+// FindAll - все подразделения плоским списком
+func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
+	entities, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding org units: %w", err)
+	}
+
+	responses := make([]Response, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// FindTree - все подразделения деревом, начиная с корневых
+func (svc *Service) FindTree(ctx context.Context) ([]TreeResponse, error) {
+	entities, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding org units: %w", err)
+	}
+
+	return ToTree(entities), nil
+}
+
+func (svc *Service) FindById(ctx context.Context, id int64) (Response, error) {
+	request := FindByIDRequest{ID: id}
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findOrgUnit(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// CreateOrgUnit - создать подразделение, родитель (если задан) должен существовать
+func (svc *Service) CreateOrgUnit(ctx context.Context, request CreateRequest) (Response, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	var entity = request.ToEntity()
+	if err := svc.checkParentAndName(ctx, entity); err != nil {
+		return Response{}, err
+	}
+
+	created, err := svc.repo.CreateOrgUnit(ctx, entity)
+	if err != nil {
+		return Response{}, fmt.Errorf("error creating org unit with name %s: %w", request.Name, err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+// UpdateOrgUnit - переименовать подразделение и/или перенести его вместе с поддеревом к другому родителю
+func (svc *Service) UpdateOrgUnit(ctx context.Context, id int64, request UpdateRequest) (Response, error) {
+	request.Id = id
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.ParentId == id {
+		return Response{}, domain.RequestValidationError{Message: "org unit cannot be its own parent"}
+	}
+
+	if _, err := svc.findOrgUnit(ctx, id); err != nil {
+		return Response{}, err
+	}
+	var entity = request.ToEntity()
+	if err := svc.checkParentAndName(ctx, entity); err != nil {
+		return Response{}, err
+	}
+
+	updated, err := svc.repo.UpdateOrgUnit(ctx, entity)
+	if errors.Is(err, ErrParentCycle) {
+		return Response{}, domain.RequestValidationError{
+			Message: fmt.Sprintf("org unit %d cannot be moved under %d: %s", id, request.ParentId, err),
+		}
+	}
+	if err != nil {
+		return Response{}, fmt.Errorf("error updating org unit with id %d: %w", id, err)
+	}
+
+	return updated.ToResponse(), nil
+}
+
+// DeleteById - удалить подразделение. Подразделение с дочерними или сотрудниками - ConflictError
+func (svc *Service) DeleteById(ctx context.Context, id int64) (Response, error) {
+	request := DeleteByIdRequest{ID: id}
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findOrgUnit(ctx, id); err != nil {
+		return Response{}, err
+	}
+
+	children, members, err := svc.repo.FindUsage(ctx, id)
+	if err != nil {
+		return Response{}, fmt.Errorf("error checking usage of org unit %d: %w", id, err)
+	}
+	if children > 0 || members > 0 {
+		return Response{}, domain.ConflictError{
+			Message: fmt.Sprintf("org unit %d has %d child units and %d employees", id, children, members),
+		}
+	}
+
+	if err = svc.repo.DeleteOrgUnit(ctx, id); err != nil {
+		return Response{}, fmt.Errorf("error deleting org unit %d: %w", id, err)
+	}
+
+	return Response{}, nil
+}
+
+// MoveEmployees - перевести сотрудников в подразделение (из прежних подразделений или нераспределённых)
+func (svc *Service) MoveEmployees(ctx context.Context, orgUnitId int64, request MoveEmployeesRequest) (MoveResponse, error) {
+	request.OrgUnitID = orgUnitId
+	if err := svc.validator.Validate(request); err != nil {
+		return MoveResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findOrgUnit(ctx, orgUnitId); err != nil {
+		return MoveResponse{}, err
+	}
+
+	existing, err := svc.repo.FindExistingEmployeeIds(ctx, request.EmployeeIDs)
+	if err != nil {
+		return MoveResponse{}, fmt.Errorf("error checking employees %v: %w", request.EmployeeIDs, err)
+	}
+	if missing := missingIds(request.EmployeeIDs, existing); len(missing) > 0 {
+		return MoveResponse{}, domain.NotFoundError{Message: fmt.Sprintf("employees with ids %v not found", missing)}
+	}
+
+	moved, err := svc.repo.MoveEmployees(ctx, orgUnitId, request.EmployeeIDs)
+	if err != nil {
+		return MoveResponse{}, fmt.Errorf("error moving employees to org unit %d: %w", orgUnitId, err)
+	}
+
+	return MoveResponse{OrgUnitId: orgUnitId, Moved: moved}, nil
+}
+
+// RemoveMember - вывести сотрудника из подразделения (сотрудник становится нераспределённым)
+func (svc *Service) RemoveMember(ctx context.Context, orgUnitId int64, employeeId int64) error {
+	request := RemoveMemberRequest{OrgUnitID: orgUnitId, EmployeeID: employeeId}
+	if err := svc.validator.Validate(request); err != nil {
+		return domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRemoved, err := svc.repo.RemoveMember(ctx, orgUnitId, employeeId)
+	if err != nil {
+		return fmt.Errorf("error removing employee %d from org unit %d: %w", employeeId, orgUnitId, err)
+	}
+	if !isRemoved {
+		return domain.NotFoundError{
+			Message: fmt.Sprintf("employee %d is not a member of org unit %d", employeeId, orgUnitId),
+		}
+	}
+
+	return nil
+}
+
+// FindMembers - страница сотрудников подразделения в формате employee.PageResponse
+func (svc *Service) FindMembers(ctx context.Context, request MembersRequest) (employee.PageResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return employee.PageResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if _, err := svc.findOrgUnit(ctx, request.OrgUnitID); err != nil {
+		return employee.PageResponse{}, err
+	}
+
+	entities, total, err := svc.repo.FindMembersPage(ctx, request.ToFilter())
+	if err != nil {
+		return employee.PageResponse{}, fmt.Errorf("error finding members of org unit %d: %w", request.OrgUnitID, err)
+	}
+
+	responses := make([]employee.Response, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return employee.PageResponse{
+		Result:     responses,
+		PageSize:   request.PageSize,
+		PageNumber: request.PageNumber,
+		Total:      total,
+	}, nil
+}
+
+// checkParentAndName - родитель существует, имя не занято другим подразделением того же родителя
+func (svc *Service) checkParentAndName(ctx context.Context, entity *Entity) error {
+	if entity.ParentId != nil {
+		_, err := svc.repo.FindById(ctx, *entity.ParentId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.RequestValidationError{Message: fmt.Sprintf("parent org unit with id %d not found", *entity.ParentId)}
+		}
+		if err != nil {
+			return fmt.Errorf("error finding parent org unit with id %d: %w", *entity.ParentId, err)
+		}
+	}
+
+	isExists, err := svc.repo.ExistsByName(ctx, entity.ParentId, entity.Name, entity.Id)
+	if err != nil {
+		return fmt.Errorf("error checking org unit name %s: %w", entity.Name, err)
+	}
+	if isExists {
+		return domain.AlreadyExistsError{Message: fmt.Sprintf("org unit with name %s already exists", entity.Name)}
+	}
+
+	return nil
+}
+
+func (svc *Service) findOrgUnit(ctx context.Context, id int64) (Entity, error) {
+	entity, err := svc.repo.FindById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entity{}, domain.NotFoundError{Message: fmt.Sprintf("org unit with id %d not found", id)}
+	}
+	if err != nil {
+		return Entity{}, fmt.Errorf("error finding org unit with id %d: %w", id, err)
+	}
+
+	return entity, nil
+}
+
+// missingIds - запрошенные id, которых нет среди найденных (в порядке запроса, без повторов)
+func missingIds(requested []int64, found []int64) []int64 {
+	var seen = make(map[int64]bool, len(found))
+	for _, id := range found {
+		seen[id] = true
+	}
+
+	var missing []int64
+	for _, id := range requested {
+		if !seen[id] {
+			missing = append(missing, id)
+			seen[id] = true
+		}
+	}
+	return missing
+}
@@ -0,0 +1,308 @@
+package orgunit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"idm/inner/employee"
+	"testing"
+	"time"
+)
+
+// Объявляем структуру мок-репозитория
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindAll(ctx context.Context) ([]Entity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindById(ctx context.Context, id int64) (Entity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsByName(ctx context.Context, parentId *int64, name string, excludeId int64) (bool, error) {
+	args := m.Called(ctx, parentId, name, excludeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) CreateOrgUnit(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) UpdateOrgUnit(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindUsage(ctx context.Context, id int64) (int64, int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepo) DeleteOrgUnit(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) FindExistingEmployeeIds(ctx context.Context, ids []int64) ([]int64, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockRepo) MoveEmployees(ctx context.Context, orgUnitId int64, employeeIds []int64) (int64, error) {
+	args := m.Called(ctx, orgUnitId, employeeIds)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) RemoveMember(ctx context.Context, orgUnitId int64, employeeId int64) (bool, error) {
+	args := m.Called(ctx, orgUnitId, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindMembersPage(ctx context.Context, filter MembersFilter) ([]employee.Entity, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]employee.Entity), args.Get(1).(int64), args.Error(2)
+}
+
+func TestOrgUnitService(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+	var rootId = int64(1)
+
+	t.Run("should return tree of org units", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		var itId = int64(2)
+		entities := []Entity{
+			{Id: 3, ParentId: &itId, Name: "Backend"},
+			{Id: 1, Name: "Company"},
+			{Id: 2, ParentId: &rootId, Name: "IT"},
+			{Id: 4, Name: "Subsidiary"},
+		}
+
+		repo.On("FindAll", appContext).Return(entities, nil).Once()
+
+		got, err := service.FindTree(appContext)
+
+		a.Nil(err)
+		a.Equal([]TreeResponse{
+			{Id: 1, Name: "Company", Children: []TreeResponse{
+				{Id: 2, Name: "IT", Children: []TreeResponse{
+					{Id: 3, Name: "Backend", Children: []TreeResponse{}},
+				}},
+			}},
+			{Id: 4, Name: "Subsidiary", Children: []TreeResponse{}},
+		}, got)
+	})
+
+	t.Run("should create org unit under parent", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "IT", ParentId: rootId}
+		created := Entity{Id: 2, ParentId: &rootId, Name: "IT", CreatedAt: now, UpdatedAt: now}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, rootId).Return(Entity{Id: rootId}, nil).Once()
+		repo.On("ExistsByName", appContext, &rootId, "IT", int64(0)).Return(false, nil).Once()
+		repo.On("CreateOrgUnit", appContext, request.ToEntity()).Return(created, nil).Once()
+
+		got, err := service.CreateOrgUnit(appContext, request)
+
+		a.Nil(err)
+		a.Equal(created.ToResponse(), got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return validation error when parent not found", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "IT", ParentId: 9}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(9)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		_, err := service.CreateOrgUnit(appContext, request)
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "CreateOrgUnit", appContext, request.ToEntity())
+	})
+
+	t.Run("should return already exists when name is taken under parent", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "Company"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsByName", appContext, (*int64)(nil), "Company", int64(0)).Return(true, nil).Once()
+
+		_, err := service.CreateOrgUnit(appContext, request)
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "CreateOrgUnit", appContext, request.ToEntity())
+	})
+
+	t.Run("should return validation error when moving unit into its subtree", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 1, Name: "Company", ParentId: 3}
+		var parentId = int64(3)
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1, Name: "Company"}, nil).Once()
+		repo.On("FindById", appContext, int64(3)).Return(Entity{Id: 3}, nil).Once()
+		repo.On("ExistsByName", appContext, &parentId, "Company", int64(1)).Return(false, nil).Once()
+		repo.On("UpdateOrgUnit", appContext, request.ToEntity()).Return(Entity{}, ErrParentCycle).Once()
+
+		_, err := service.UpdateOrgUnit(appContext, 1, UpdateRequest{Name: "Company", ParentId: 3})
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should not make unit its own parent", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 1, Name: "Company", ParentId: 1}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		_, err := service.UpdateOrgUnit(appContext, 1, UpdateRequest{Name: "Company", ParentId: 1})
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "UpdateOrgUnit", appContext, request.ToEntity())
+	})
+
+	t.Run("should return conflict when deleting unit with members", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", DeleteByIdRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindUsage", appContext, int64(1)).Return(int64(0), int64(2), nil).Once()
+
+		_, err := service.DeleteById(appContext, 1)
+
+		a.True(errors.As(err, &domain.ConflictError{}))
+		repo.AssertNotCalled(t, "DeleteOrgUnit", appContext, int64(1))
+	})
+
+	t.Run("should delete empty unit", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", DeleteByIdRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindUsage", appContext, int64(1)).Return(int64(0), int64(0), nil).Once()
+		repo.On("DeleteOrgUnit", appContext, int64(1)).Return(nil).Once()
+
+		_, err := service.DeleteById(appContext, 1)
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestOrgUnitService_Members(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+
+	t.Run("should move employees to org unit", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := MoveEmployeesRequest{OrgUnitID: 1, EmployeeIDs: []int64{10, 11}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindExistingEmployeeIds", appContext, []int64{10, 11}).Return([]int64{10, 11}, nil).Once()
+		repo.On("MoveEmployees", appContext, int64(1), []int64{10, 11}).Return(int64(1), nil).Once()
+
+		got, err := service.MoveEmployees(appContext, 1, MoveEmployeesRequest{EmployeeIDs: []int64{10, 11}})
+
+		a.Nil(err)
+		a.Equal(MoveResponse{OrgUnitId: 1, Moved: 1}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found with missing employee ids", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := MoveEmployeesRequest{OrgUnitID: 1, EmployeeIDs: []int64{10, 12, 12}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindExistingEmployeeIds", appContext, request.EmployeeIDs).Return([]int64{10}, nil).Once()
+
+		_, err := service.MoveEmployees(appContext, 1, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		a.Contains(err.Error(), "[12]")
+		repo.AssertNotCalled(t, "MoveEmployees", appContext, int64(1), request.EmployeeIDs)
+	})
+
+	t.Run("should return not found when employee is not a member", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", RemoveMemberRequest{OrgUnitID: 1, EmployeeID: 10}).Return(nil).Once()
+		repo.On("RemoveMember", appContext, int64(1), int64(10)).Return(false, nil).Once()
+
+		err := service.RemoveMember(appContext, 1, 10)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+
+	t.Run("should return members page in employee page format", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := MembersRequest{OrgUnitID: 1, PageSize: 2, PageNumber: 2, IncludeSubunits: true}
+		members := []employee.Entity{{Id: 10, Name: "Alice"}, {Id: 11, Name: "Bob"}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{Id: 1}, nil).Once()
+		repo.On("FindMembersPage", appContext, MembersFilter{OrgUnitId: 1, IncludeSubunits: true, Limit: 2, Offset: 2}).
+			Return(members, int64(5), nil).Once()
+
+		got, err := service.FindMembers(appContext, request)
+
+		a.Nil(err)
+		a.Equal(int64(5), got.Total)
+		a.Equal(int64(2), got.PageNumber)
+		a.Equal(int64(2), got.PageSize)
+		a.Equal("Bob", got.Result[1].Name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when org unit does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := MembersRequest{OrgUnitID: 1, PageSize: 10, PageNumber: 1}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		_, err := service.FindMembers(appContext, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+}
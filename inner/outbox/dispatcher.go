@@ -0,0 +1,207 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/retry"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Отправка событий
+const (
+	dispatchBatch  = 100              // событий за один проход
+	dispatchLease  = time.Minute      // на столько проход захватывает события
+	retryBaseDelay = time.Second      // пауза перед второй попыткой, далее удваивается
+	retryMaxDelay  = 10 * time.Minute // события не теряются: после исчерпания удвоений попытки идут с этим периодом
+)
+
+// Sink - подписчик на доменные события. Доставка не реже одного раза: событие, которое подписчик
+// получил, а отметка об этом не успела сохраниться, придёт повторно с тем же Id.
+// Name должно быть постоянным - по нему запоминается, кто уже получил событие
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, message Message) error
+}
+
+type Repo interface {
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Entity, error)
+	MarkPublished(ctx context.Context, event *Entity) error
+	MarkRetry(ctx context.Context, event *Entity) error
+	PurgePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Dispatcher - отправка событий из outbox подписчикам в фоновой горутине раз в interval.
+// Событие считается отправленным, когда его получили все подписчики; при ошибке подписчика
+// событие повторяется с удвоением паузы только для тех, кто его ещё не получил
+type Dispatcher struct {
+	repo     Repo
+	sinks    []Sink
+	interval time.Duration
+	logger   *common.Logger
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewDispatcher - функция-конструктор
+func NewDispatcher(repo Repo, interval time.Duration, logger *common.Logger, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{
+		repo:     repo,
+		sinks:    sinks,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start - запустить отправку. Она останавливается по Drain или при отмене ctx
+func (d *Dispatcher) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.wg.Add(1)
+	go d.loop(ctx)
+}
+
+// Drain - остановить фоновую отправку и отправить события, накопившиеся к этому моменту,
+// пока они не кончатся или не истечёт ctx. Неотправленное остаётся в outbox до следующего запуска
+func (d *Dispatcher) Drain(ctx context.Context) error {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+
+	for ctx.Err() == nil {
+		claimed, err := d.Dispatch(ctx)
+		if err != nil {
+			return err
+		}
+		if claimed < dispatchBatch {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (d *Dispatcher) loop(ctx context.Context) {
+	defer d.wg.Done()
+
+	var ticker = time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// полный проход - сразу следующий, чтобы накопившиеся события не ждали interval
+			for ctx.Err() == nil {
+				claimed, err := d.Dispatch(ctx)
+				if err != nil {
+					d.logger.Error("outbox dispatch failed", zap.Error(err))
+					break
+				}
+				if claimed < dispatchBatch {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Dispatch - один проход: захватить события, срок которых наступил, и отправить их подписчикам.
+// Возвращает число захваченных событий; ошибки подписчиков не возвращаются, а откладывают событие
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	events, err := d.repo.ClaimDue(ctx, time.Now(), dispatchLease, dispatchBatch)
+	if err != nil {
+		return 0, fmt.Errorf("error claiming outbox events: %w", err)
+	}
+
+	var errs []error
+	for _, event := range events {
+		if ctx.Err() != nil {
+			// незавершённые события отправит следующий проход, когда истечёт захват
+			break
+		}
+		if err := d.publish(ctx, &event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(events), errors.Join(errs...)
+}
+
+// publish - отправить событие подписчикам, которые его ещё не получили, и записать исход
+func (d *Dispatcher) publish(ctx context.Context, event *Entity) error {
+	var message = event.ToMessage()
+	var errs []error
+	for _, sink := range d.sinks {
+		if slices.Contains(event.DeliveredTo, sink.Name()) {
+			continue
+		}
+		if err := sink.Publish(ctx, message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+		event.DeliveredTo = append(event.DeliveredTo, sink.Name())
+	}
+
+	if len(errs) == 0 {
+		var now = time.Now()
+		event.PublishedAt = &now
+		if err := d.repo.MarkPublished(ctx, event); err != nil {
+			return fmt.Errorf("error marking outbox event %d published: %w", event.Id, err)
+		}
+		return nil
+	}
+
+	var publishErr = errors.Join(errs...)
+	event.Attempts++
+	event.NextAttemptAt = time.Now().Add(retry.Delay(event.Attempts, retryBaseDelay, retryMaxDelay))
+	event.LastError = publishErr.Error()
+	d.logger.Warn(
+		"outbox event publish failed",
+		zap.Int64("id", event.Id),
+		zap.String("type", event.EventType),
+		zap.Int("attempts", event.Attempts),
+		zap.Time("next_attempt_at", event.NextAttemptAt),
+		zap.Error(publishErr),
+	)
+	if err := d.repo.MarkRetry(ctx, event); err != nil {
+		return fmt.Errorf("error saving outbox event %d attempt: %w", event.Id, err)
+	}
+	return nil
+}
+
+// PurgePublished - удалить события, отправленные раньше, чем retention назад
+func (d *Dispatcher) PurgePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	return d.repo.PurgePublished(ctx, time.Now().Add(-retention))
+}
+
+// LogSink - подписчик, который пишет события в лог приложения
+type LogSink struct {
+	logger *common.Logger
+}
+
+// NewLogSink - функция-конструктор
+func NewLogSink(logger *common.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(_ context.Context, message Message) error {
+	s.logger.Info(
+		"domain event",
+		zap.Int64("id", message.Id),
+		zap.String("type", message.Type),
+		zap.String("aggregate_type", message.AggregateType),
+		zap.Int64("aggregate_id", message.AggregateId),
+		zap.String("actor", message.Actor),
+		zap.String("request_id", message.RequestId),
+	)
+	return nil
+}
@@ -0,0 +1,226 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"idm/inner/audit"
+	"idm/inner/common"
+	"idm/inner/retry"
+	"sync"
+	"testing"
+	"time"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Entity, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) MarkPublished(ctx context.Context, event *Entity) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MarkRetry(ctx context.Context, event *Entity) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockRepo) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// recordingSink - подписчик, запоминающий полученные события; err - ошибка на каждую отправку
+type recordingSink struct {
+	name     string
+	err      error
+	mu       sync.Mutex
+	received []Message
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Publish(_ context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.received = append(s.received, message)
+	return nil
+}
+
+func (s *recordingSink) ids() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int64
+	for _, message := range s.received {
+		ids = append(ids, message.Id)
+	}
+	return ids
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+	var logger = &common.Logger{Logger: zap.NewNop()}
+
+	created := Entity{Id: 1, EventType: EmployeeCreated, AggregateType: audit.EntityEmployee, AggregateId: 7,
+		Payload: []byte(`{"id":7}`), Status: StatusPending}
+
+	t.Run("should publish event to all sinks", func(t *testing.T) {
+		repo := new(MockRepo)
+		webhooks := &recordingSink{name: "webhooks"}
+		search := &recordingSink{name: "search"}
+		dispatcher := NewDispatcher(repo, time.Second, logger, webhooks, search)
+
+		repo.On("ClaimDue", ctx, mock.Anything, dispatchLease, dispatchBatch).Return([]Entity{created}, nil).Once()
+		repo.On("MarkPublished", ctx, mock.MatchedBy(func(event *Entity) bool {
+			return event.Id == 1 && event.PublishedAt != nil &&
+				assert.ObjectsAreEqual([]string{"webhooks", "search"}, []string(event.DeliveredTo))
+		})).Return(nil).Once()
+
+		claimed, err := dispatcher.Dispatch(ctx)
+
+		a.Nil(err)
+		a.Equal(1, claimed)
+		a.Equal([]int64{1}, webhooks.ids())
+		a.Equal(EmployeeCreated, webhooks.received[0].Type)
+		a.JSONEq(`{"id":7}`, string(webhooks.received[0].Payload))
+		a.Equal([]int64{1}, search.ids())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should retry only sinks that did not receive event", func(t *testing.T) {
+		repo := new(MockRepo)
+		webhooks := &recordingSink{name: "webhooks"}
+		search := &recordingSink{name: "search", err: errors.New("index unavailable")}
+		dispatcher := NewDispatcher(repo, time.Second, logger, webhooks, search)
+		retried := created
+		retried.DeliveredTo = []string{"webhooks"}
+		retried.Attempts = 2
+
+		repo.On("ClaimDue", ctx, mock.Anything, dispatchLease, dispatchBatch).Return([]Entity{retried}, nil).Once()
+		repo.On("MarkRetry", ctx, mock.MatchedBy(func(event *Entity) bool {
+			var delay = time.Until(event.NextAttemptAt)
+			return event.Attempts == 3 && delay > 3*time.Second && delay <= 4*time.Second &&
+				event.LastError == "search: index unavailable" &&
+				assert.ObjectsAreEqual([]string{"webhooks"}, []string(event.DeliveredTo))
+		})).Return(nil).Once()
+
+		_, err := dispatcher.Dispatch(ctx)
+
+		a.Nil(err)
+		a.Empty(webhooks.ids())
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return error when outcome is not saved", func(t *testing.T) {
+		repo := new(MockRepo)
+		dispatcher := NewDispatcher(repo, time.Second, logger, &recordingSink{name: "webhooks"})
+
+		repo.On("ClaimDue", ctx, mock.Anything, dispatchLease, dispatchBatch).Return([]Entity{created}, nil).Once()
+		repo.On("MarkPublished", ctx, mock.Anything).Return(errors.New("connection reset")).Once()
+
+		_, err := dispatcher.Dispatch(ctx)
+
+		a.ErrorContains(err, "connection reset")
+	})
+}
+
+func TestDispatcher_Drain(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+	var logger = &common.Logger{Logger: zap.NewNop()}
+
+	t.Run("should stop loop and publish remaining events", func(t *testing.T) {
+		repo := new(MockRepo)
+		sink := &recordingSink{name: "webhooks"}
+		// интервал больше времени теста: до Drain фоновая отправка не запускается
+		dispatcher := NewDispatcher(repo, time.Hour, logger, sink)
+		var full = make([]Entity, dispatchBatch)
+		for i := range full {
+			full[i] = Entity{Id: int64(i + 1), EventType: RoleAssigned}
+		}
+
+		repo.On("ClaimDue", mock.Anything, mock.Anything, dispatchLease, dispatchBatch).Return(full, nil).Once()
+		repo.On("ClaimDue", mock.Anything, mock.Anything, dispatchLease, dispatchBatch).
+			Return([]Entity{{Id: 101, EventType: RoleRevoked}}, nil).Once()
+		repo.On("MarkPublished", mock.Anything, mock.Anything).Return(nil)
+
+		dispatcher.Start(ctx)
+		err := dispatcher.Drain(ctx)
+
+		a.Nil(err)
+		a.Len(sink.ids(), dispatchBatch+1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should leave events in outbox when deadline passed", func(t *testing.T) {
+		repo := new(MockRepo)
+		dispatcher := NewDispatcher(repo, time.Hour, logger, &recordingSink{name: "webhooks"})
+		expired, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := dispatcher.Drain(expired)
+
+		a.ErrorIs(err, context.Canceled)
+		repo.AssertNotCalled(t, "ClaimDue", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	var a = assert.New(t)
+
+	a.Equal(time.Second, retry.Delay(1, retryBaseDelay, retryMaxDelay))
+	a.Equal(4*time.Second, retry.Delay(3, retryBaseDelay, retryMaxDelay))
+	a.Equal(10*time.Minute, retry.Delay(20, retryBaseDelay, retryMaxDelay))
+	a.Equal(10*time.Minute, retry.Delay(1000, retryBaseDelay, retryMaxDelay))
+}
+
+func TestFromAudit(t *testing.T) {
+	var a = assert.New(t)
+
+	t.Run("should use snapshot after change", func(t *testing.T) {
+		event, ok := FromAudit(audit.Event{
+			Action:     audit.ActionAssign,
+			EntityType: audit.EntityEmployeeRole,
+			EntityId:   7,
+			After:      audit.EmployeeRoleSnapshot{EmployeeId: 7, RoleId: 5},
+		})
+
+		a.True(ok)
+		a.Equal(Event{Type: RoleAssigned, AggregateType: audit.EntityEmployeeRole, AggregateId: 7,
+			Payload: audit.EmployeeRoleSnapshot{EmployeeId: 7, RoleId: 5}}, event)
+	})
+
+	t.Run("should use snapshot before removal", func(t *testing.T) {
+		event, ok := FromAudit(audit.Event{
+			Action:     audit.ActionPurge,
+			EntityType: audit.EntityRole,
+			EntityId:   5,
+			Before:     map[string]any{"id": 5},
+		})
+
+		a.True(ok)
+		a.Equal(RolePurged, event.Type)
+		a.Equal(map[string]any{"id": 5}, event.Payload)
+	})
+
+	t.Run("should skip changes not published to integrations", func(t *testing.T) {
+		_, ok := FromAudit(audit.Event{Action: audit.ActionCreate, EntityType: audit.EntitySodRule, EntityId: 1})
+
+		a.False(ok)
+	})
+}
@@ -0,0 +1,100 @@
+package outbox
+
+import (
+	"encoding/json"
+	"github.com/lib/pq"
+	"time"
+)
+
+// Типы доменных событий
+const (
+	EmployeeCreated        = "EmployeeCreated"
+	EmployeeUpdated        = "EmployeeUpdated"
+	EmployeeMoved          = "EmployeeMoved" // перевод в другое подразделение
+	EmployeeStatusChanged  = "EmployeeStatusChanged"
+	EmployeeDeleted        = "EmployeeDeleted" // мягкое удаление
+	EmployeeRestored       = "EmployeeRestored"
+	EmployeePurged         = "EmployeePurged" // окончательное удаление
+	RoleCreated            = "RoleCreated"
+	RoleUpdated            = "RoleUpdated"
+	RoleInheritanceChanged = "RoleInheritanceChanged"
+	RoleDeleted            = "RoleDeleted"
+	RoleRestored           = "RoleRestored"
+	RolePurged             = "RolePurged"
+	RoleAssigned           = "RoleAssigned"
+	RoleRevoked            = "RoleRevoked"
+)
+
+// EventTypes - все типы доменных событий, на которые можно подписаться
+var EventTypes = []string{
+	EmployeeCreated, EmployeeUpdated, EmployeeMoved, EmployeeStatusChanged,
+	EmployeeDeleted, EmployeeRestored, EmployeePurged,
+	RoleCreated, RoleUpdated, RoleInheritanceChanged, RoleDeleted, RoleRestored, RolePurged,
+	RoleAssigned, RoleRevoked,
+}
+
+// Состояния события
+const (
+	StatusPending   = "pending"   // ждёт отправки или повторной попытки
+	StatusPublished = "published" // получено всеми подписчиками
+)
+
+// Event - доменное событие, которое записывается в outbox в транзакции самого изменения.
+// Payload сериализуется в JSON
+type Event struct {
+	Type          string
+	AggregateType string
+	AggregateId   int64
+	Payload       any
+}
+
+// Entity - запись outbox
+type Entity struct {
+	Id            int64          `db:"id"`
+	EventType     string         `db:"event_type"`
+	AggregateType string         `db:"aggregate_type"`
+	AggregateId   int64          `db:"aggregate_id"`
+	Payload       []byte         `db:"payload"`
+	OccurredAt    time.Time      `db:"occurred_at"`
+	Actor         string         `db:"actor"`
+	ActorType     string         `db:"actor_type"`
+	RequestId     string         `db:"request_id"`
+	Status        string         `db:"status"`
+	DeliveredTo   pq.StringArray `db:"delivered_to"`
+	Attempts      int            `db:"attempts"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	LastError     string         `db:"last_error"`
+	PublishedAt   *time.Time     `db:"published_at"`
+}
+
+// Message - событие в том виде, в котором его получают подписчики. Доставка не реже одного раза:
+// повтор того же события приходит с тем же Id
+type Message struct {
+	Id            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateId   int64           `json:"aggregateId"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Actor         string          `json:"actor"`
+	ActorType     string          `json:"actorType"`
+	RequestId     string          `json:"requestId"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func (e *Entity) ToMessage() Message {
+	var payload = json.RawMessage("null")
+	if len(e.Payload) > 0 {
+		payload = e.Payload
+	}
+	return Message{
+		Id:            e.Id,
+		Type:          e.EventType,
+		AggregateType: e.AggregateType,
+		AggregateId:   e.AggregateId,
+		OccurredAt:    e.OccurredAt,
+		Actor:         e.Actor,
+		ActorType:     e.ActorType,
+		RequestId:     e.RequestId,
+		Payload:       payload,
+	}
+}
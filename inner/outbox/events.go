@@ -0,0 +1,67 @@
+package outbox
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+)
+
+// eventTypes - доменное событие для изменения из журнала аудита: тип сущности -> действие -> тип события.
+// Изменения других сущностей интеграциям не публикуются
+var eventTypes = map[string]map[string]string{
+	audit.EntityEmployee: {
+		audit.ActionCreate:       EmployeeCreated,
+		audit.ActionUpdate:       EmployeeUpdated,
+		audit.ActionMove:         EmployeeMoved,
+		audit.ActionStatusChange: EmployeeStatusChanged,
+		audit.ActionDelete:       EmployeeDeleted,
+		audit.ActionRestore:      EmployeeRestored,
+		audit.ActionPurge:        EmployeePurged,
+	},
+	audit.EntityRole: {
+		audit.ActionCreate:  RoleCreated,
+		audit.ActionUpdate:  RoleUpdated,
+		audit.ActionDelete:  RoleDeleted,
+		audit.ActionRestore: RoleRestored,
+		audit.ActionPurge:   RolePurged,
+	},
+	audit.EntityRoleInheritance: {
+		audit.ActionUpdate: RoleInheritanceChanged,
+	},
+	audit.EntityEmployeeRole: {
+		audit.ActionAssign: RoleAssigned,
+		audit.ActionRevoke: RoleRevoked,
+	},
+}
+
+// FromAudit - доменное событие изменения, false - изменение не публикуется.
+// Payload - снимок после изменения, для удаления и отзыва - снимок до него
+func FromAudit(event audit.Event) (Event, bool) {
+	eventType, ok := eventTypes[event.EntityType][event.Action]
+	if !ok {
+		return Event{}, false
+	}
+
+	var payload = event.After
+	if payload == nil {
+		payload = event.Before
+	}
+	return Event{
+		Type:          eventType,
+		AggregateType: event.EntityType,
+		AggregateId:   event.EntityId,
+		Payload:       payload,
+	}, true
+}
+
+// RecordTx - записать событие аудита и, если изменение публикуется интеграциям, доменное событие
+// в outbox - всё в транзакции изменения: событие уходит подписчикам, только если изменение зафиксировано
+func RecordTx(ctx context.Context, tx *sqlx.Tx, event audit.Event) error {
+	if err := audit.InsertEventTx(ctx, tx, event); err != nil {
+		return err
+	}
+	if domainEvent, ok := FromAudit(event); ok {
+		return InsertTx(ctx, tx, domainEvent)
+	}
+	return nil
+}
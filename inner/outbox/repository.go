@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/common"
+	"slices"
+	"time"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// InsertTx - записать доменное событие в outbox в транзакции изменения.
+// Исполнитель и request_id берутся из контекста запроса (common.CallerFromContext)
+func InsertTx(ctx context.Context, tx *sqlx.Tx, event Event) error {
+	var payload sql.NullString
+	if event.Payload != nil {
+		data, err := json.Marshal(event.Payload)
+		if err != nil {
+			return fmt.Errorf("error marshal %s payload: %w", event.Type, err)
+		}
+		payload = sql.NullString{String: string(data), Valid: true}
+	}
+
+	var caller = common.CallerFromContext(ctx)
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO outbox (event_type, aggregate_type, aggregate_id, payload, occurred_at, actor, actor_type, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		event.Type, event.AggregateType, event.AggregateId, payload, time.Now().UTC(),
+		caller.Subject, caller.SubjectType, caller.RequestId,
+	)
+	if err != nil {
+		return fmt.Errorf("error insert outbox event %s %d: %w", event.Type, event.AggregateId, err)
+	}
+	return nil
+}
+
+// ClaimDue - захватить до limit неотправленных событий, срок попытки которых наступил, до now + lease.
+// Событие, захват которого истёк (отправка прервалась), можно захватить снова. События отдаются
+// в порядке записи: номера выдаются под блокировкой журнала аудита, то есть в порядке фиксации транзакций
+func (r *Repository) ClaimDue(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) (events []Entity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&events,
+		`UPDATE outbox o
+		SET next_attempt_at = $2
+		FROM (
+			SELECT id FROM outbox
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE o.id = due.id
+		RETURNING o.*`,
+		now, now.Add(lease), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// UPDATE ... RETURNING не сохраняет порядок
+	slices.SortFunc(events, func(a, b Entity) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+
+	return events, nil
+}
+
+// MarkPublished - событие получено всеми подписчиками
+func (r *Repository) MarkPublished(ctx context.Context, event *Entity) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE outbox SET status = 'published', delivered_to = $2, last_error = '', published_at = $3
+		WHERE id = $1`,
+		event.Id, event.DeliveredTo, event.PublishedAt,
+	)
+
+	return err
+}
+
+// MarkRetry - записать неудачную попытку: подписчиков, уже получивших событие, время следующей попытки и ошибку
+func (r *Repository) MarkRetry(ctx context.Context, event *Entity) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE outbox SET delivered_to = $2, attempts = $3, next_attempt_at = $4, last_error = $5
+		WHERE id = $1`,
+		event.Id, event.DeliveredTo, event.Attempts, event.NextAttemptAt, truncate(event.LastError),
+	)
+
+	return err
+}
+
+// PurgePublished - удалить события, отправленные раньше before. Возвращает число удалённых
+func (r *Repository) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM outbox WHERE status = 'published' AND published_at < $1",
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// maxErrorLength - длина колонки last_error
+const maxErrorLength = 1000
+
+// truncate - текст ошибки в пределах колонки, по границе символа
+func truncate(message string) string {
+	var runes = []rune(message)
+	if len(runes) <= maxErrorLength {
+		return message
+	}
+	return string(runes[:maxErrorLength])
+}
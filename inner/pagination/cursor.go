@@ -0,0 +1,69 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCursor - курсор повреждён, подделан или подписан другим ключом
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor - содержимое курсора: позиция и параметры выдачи, для которой он выпущен.
+// Курсор действителен только с теми же ресурсом, сортировкой и фильтрами
+type Cursor struct {
+	Key
+	Resource string `json:"r"`
+	Sort     string `json:"s"`
+	Filter   string `json:"f,omitempty"`
+	Deleted  bool   `json:"d,omitempty"`
+}
+
+// Signer - кодирование курсоров в непрозрачные строки с подписью HMAC-SHA256:
+// клиент не может изменить позицию или параметры курсора
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner - функция-конструктор
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret}
+}
+
+// Encode - курсор в виде base64url(JSON).base64url(подпись)
+func (s *Signer) Encode(cursor Cursor) string {
+	payload, _ := json.Marshal(cursor) // структура из строк и чисел сериализуется без ошибок
+	var encoded = base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+}
+
+// Decode - проверить подпись и разобрать курсор
+func (s *Signer) Decode(token string) (Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err = json.Unmarshal(payload, &cursor); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func (s *Signer) sign(encoded string) []byte {
+	var mac = hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
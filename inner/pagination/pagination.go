@@ -0,0 +1,131 @@
+package pagination
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Размер страницы keyset-выдачи
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Key - позиция строки в упорядоченной выдаче: значение ключа сортировки (пусто при сортировке по id) и id
+type Key struct {
+	Value string `json:"v,omitempty"`
+	Id    int64  `json:"i"`
+}
+
+// SortKey - поле сортировки выдачи строк T: колонка и позиция строки. Колонка не должна содержать NULL,
+// id - второй ключ сортировки, делающий порядок однозначным
+type SortKey[T any] struct {
+	Column string
+	Key    func(row T) Key
+}
+
+// Order - разобранная сортировка: колонка ключа и направление (id сортируется в том же направлении)
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// ParseSort - сортировка "field" (по возрастанию) или "-field" (по убыванию) по одному из полей keys
+func ParseSort[T any](sort string, keys map[string]SortKey[T]) (SortKey[T], Order, error) {
+	var field, desc = strings.CutPrefix(sort, "-")
+	key, ok := keys[field]
+	if !ok {
+		var fields = make([]string, 0, len(keys))
+		for name := range keys {
+			fields = append(fields, name)
+		}
+		slices.Sort(fields)
+		return SortKey[T]{}, Order{}, fmt.Errorf("unknown sort field %q, expected one of: %s", field, strings.Join(fields, ", "))
+	}
+	return key, Order{Column: key.Column, Desc: desc}, nil
+}
+
+// Query - запрос страницы: Limit строк после позиции After или до позиции Before (задаётся не больше одной)
+type Query struct {
+	Order  Order
+	Limit  int
+	After  *Key
+	Before *Key
+}
+
+// Condition - условие на позицию курсора с параметрами, нумерация которых начинается с $param;
+// пустая строка - первая страница. Сравнение кортежей (column, id) использует индекс по ним
+func (q Query) Condition(param int) (string, []any) {
+	var key = q.After
+	var greater = !q.Order.Desc
+	if q.Before != nil {
+		key = q.Before
+		greater = !greater
+	}
+	if key == nil {
+		return "", nil
+	}
+
+	var operator = "<"
+	if greater {
+		operator = ">"
+	}
+	if q.Order.Column == "id" {
+		return fmt.Sprintf("id %s $%d", operator, param), []any{key.Id}
+	}
+	return fmt.Sprintf("(%s, id) %s ($%d, $%d)", q.Order.Column, operator, param, param+1), []any{key.Value, key.Id}
+}
+
+// OrderBy - порядок выборки: страница до курсора выбирается в обратном порядке и разворачивается в NewPage
+func (q Query) OrderBy() string {
+	var direction = "ASC"
+	if q.Order.Desc != (q.Before != nil) {
+		direction = "DESC"
+	}
+	if q.Order.Column == "id" {
+		return "id " + direction
+	}
+	return q.Order.Column + " " + direction + ", id " + direction
+}
+
+// Fetch - сколько строк выбирать: строка сверх Limit показывает, что за страницей есть ещё строки
+func (q Query) Fetch() int {
+	return q.Limit + 1
+}
+
+// Page - страница выдачи: строки и позиции для ссылок на следующую и предыдущую страницы (nil - ссылки нет)
+type Page[T any] struct {
+	Rows []T
+	Next *Key
+	Prev *Key
+}
+
+// NewPage - страница из строк, выбранных запросом q (не больше q.Fetch() строк в порядке q.OrderBy())
+func NewPage[T any](rows []T, q Query, sortKey SortKey[T]) Page[T] {
+	var more = len(rows) > q.Limit
+	if more {
+		rows = rows[:q.Limit]
+	}
+	if q.Before != nil {
+		slices.Reverse(rows)
+	}
+
+	var page = Page[T]{Rows: rows}
+	if len(rows) == 0 {
+		// за позицией курсора строк не осталось: можно только вернуться назад от неё
+		page.Prev = q.After
+		page.Next = q.Before
+		return page
+	}
+
+	var first, last = sortKey.Key(rows[0]), sortKey.Key(rows[len(rows)-1])
+	// страница до курсора: следующая за ней - сам курсор и дальше; после курсора - перед ней есть строки
+	if (q.Before == nil && more) || q.Before != nil {
+		page.Next = &last
+	}
+	if (q.Before != nil && more) || q.After != nil {
+		page.Prev = &first
+	}
+	return page
+}
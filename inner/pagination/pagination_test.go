@@ -0,0 +1,172 @@
+package pagination
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRow struct {
+	Id   int64
+	Name string
+}
+
+var testSorts = map[string]SortKey[testRow]{
+	"id":   {Column: "id", Key: func(r testRow) Key { return Key{Id: r.Id} }},
+	"name": {Column: "name", Key: func(r testRow) Key { return Key{Value: r.Name, Id: r.Id} }},
+}
+
+func rows(ids ...int64) []testRow {
+	var result = make([]testRow, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, testRow{Id: id, Name: "n" + strconv.FormatInt(id, 10)})
+	}
+	return result
+}
+
+func ids(rows []testRow) []int64 {
+	var result = make([]int64, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row.Id)
+	}
+	return result
+}
+
+func TestParseSort(t *testing.T) {
+	var a = assert.New(t)
+
+	key, order, err := ParseSort("-name", testSorts)
+	a.NoError(err)
+	a.Equal(Order{Column: "name", Desc: true}, order)
+	a.Equal(Key{Value: "x", Id: 1}, key.Key(testRow{Id: 1, Name: "x"}))
+
+	_, order, err = ParseSort("id", testSorts)
+	a.NoError(err)
+	a.Equal(Order{Column: "id"}, order)
+
+	_, _, err = ParseSort("name; DROP TABLE employees", testSorts)
+	a.ErrorContains(err, "expected one of: id, name")
+}
+
+func TestQuery_Sql(t *testing.T) {
+	var a = assert.New(t)
+	var key = &Key{Value: "Bob", Id: 7}
+
+	var tests = []struct {
+		name      string
+		query     Query
+		condition string
+		args      []any
+		orderBy   string
+	}{
+		{"first page", Query{Order: Order{Column: "name"}}, "", nil, "name ASC, id ASC"},
+		{"after asc", Query{Order: Order{Column: "name"}, After: key}, "(name, id) > ($3, $4)", []any{"Bob", int64(7)}, "name ASC, id ASC"},
+		{"after desc", Query{Order: Order{Column: "name", Desc: true}, After: key}, "(name, id) < ($3, $4)", []any{"Bob", int64(7)}, "name DESC, id DESC"},
+		{"before asc", Query{Order: Order{Column: "name"}, Before: key}, "(name, id) < ($3, $4)", []any{"Bob", int64(7)}, "name DESC, id DESC"},
+		{"before desc", Query{Order: Order{Column: "name", Desc: true}, Before: key}, "(name, id) > ($3, $4)", []any{"Bob", int64(7)}, "name ASC, id ASC"},
+		{"after by id", Query{Order: Order{Column: "id"}, After: key}, "id > $3", []any{int64(7)}, "id ASC"},
+		{"before by id desc", Query{Order: Order{Column: "id", Desc: true}, Before: key}, "id > $3", []any{int64(7)}, "id ASC"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			condition, args := test.query.Condition(3)
+			a.Equal(test.condition, condition)
+			a.Equal(test.args, args)
+			a.Equal(test.orderBy, test.query.OrderBy())
+		})
+	}
+	a.Equal(11, Query{Limit: 10}.Fetch())
+}
+
+func TestNewPage(t *testing.T) {
+	var a = assert.New(t)
+	var sortKey = testSorts["id"]
+	var order = Order{Column: "id"}
+
+	t.Run("first page with more rows has only next", func(t *testing.T) {
+		var page = NewPage(rows(1, 2, 3), Query{Order: order, Limit: 2}, sortKey)
+		a.Equal([]int64{1, 2}, ids(page.Rows))
+		a.Equal(&Key{Id: 2}, page.Next)
+		a.Nil(page.Prev)
+	})
+
+	t.Run("single page has no links", func(t *testing.T) {
+		var page = NewPage(rows(1, 2), Query{Order: order, Limit: 2}, sortKey)
+		a.Equal([]int64{1, 2}, ids(page.Rows))
+		a.Nil(page.Next)
+		a.Nil(page.Prev)
+	})
+
+	t.Run("page after cursor has prev and next while rows remain", func(t *testing.T) {
+		var page = NewPage(rows(3, 4, 5), Query{Order: order, Limit: 2, After: &Key{Id: 2}}, sortKey)
+		a.Equal([]int64{3, 4}, ids(page.Rows))
+		a.Equal(&Key{Id: 4}, page.Next)
+		a.Equal(&Key{Id: 3}, page.Prev)
+	})
+
+	t.Run("last page after cursor has only prev", func(t *testing.T) {
+		var page = NewPage(rows(5), Query{Order: order, Limit: 2, After: &Key{Id: 4}}, sortKey)
+		a.Nil(page.Next)
+		a.Equal(&Key{Id: 5}, page.Prev)
+	})
+
+	t.Run("page before cursor is reversed to sort order", func(t *testing.T) {
+		// выбрано в обратном порядке: 4, 3 и лишняя строка 2
+		var page = NewPage(rows(4, 3, 2), Query{Order: order, Limit: 2, Before: &Key{Id: 5}}, sortKey)
+		a.Equal([]int64{3, 4}, ids(page.Rows))
+		a.Equal(&Key{Id: 4}, page.Next)
+		a.Equal(&Key{Id: 3}, page.Prev)
+	})
+
+	t.Run("first page reached backwards has only next", func(t *testing.T) {
+		var page = NewPage(rows(2, 1), Query{Order: order, Limit: 2, Before: &Key{Id: 3}}, sortKey)
+		a.Equal([]int64{1, 2}, ids(page.Rows))
+		a.Equal(&Key{Id: 2}, page.Next)
+		a.Nil(page.Prev)
+	})
+
+	t.Run("empty page after cursor links back to cursor", func(t *testing.T) {
+		var after = &Key{Id: 9}
+		var page = NewPage([]testRow{}, Query{Order: order, Limit: 2, After: after}, sortKey)
+		a.Empty(page.Rows)
+		a.Nil(page.Next)
+		a.Equal(after, page.Prev)
+	})
+}
+
+func TestSigner(t *testing.T) {
+	var a = assert.New(t)
+	var signer = NewSigner([]byte("secret"))
+	var cursor = Cursor{Key: Key{Value: "Doe", Id: 42}, Resource: "/employees", Sort: "-name", Filter: "Jo", Deleted: true}
+
+	t.Run("round trip", func(t *testing.T) {
+		var token = signer.Encode(cursor)
+		a.NotContains(token, "Doe") // курсор непрозрачен: base64url, а не открытый текст
+		decoded, err := signer.Decode(token)
+		require.NoError(t, err)
+		a.Equal(cursor, decoded)
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		var other = signer.Encode(Cursor{Key: Key{Id: 1}, Resource: "/employees", Sort: "-name"})
+		payload, _, _ := strings.Cut(other, ".")
+		_, signature, _ := strings.Cut(signer.Encode(cursor), ".")
+		_, err := signer.Decode(payload + "." + signature)
+		a.ErrorIs(err, ErrInvalidCursor)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		_, err := NewSigner([]byte("other")).Decode(signer.Encode(cursor))
+		a.ErrorIs(err, ErrInvalidCursor)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, token := range []string{"", "abc", "abc.def", "!!.!!"} {
+			_, err := signer.Decode(token)
+			a.ErrorIs(err, ErrInvalidCursor, token)
+		}
+	})
+}
@@ -0,0 +1,462 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server            *web.Server
+	permissionService Svc
+	logger            *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	FindAll(ctx context.Context) ([]Response, error)
+	FindById(ctx context.Context, id int64) (Response, error)
+	CreatePermission(ctx context.Context, request CreateRequest) (Response, error)
+	UpdatePermission(ctx context.Context, id int64, request UpdateRequest) (Response, error)
+	DeleteById(ctx context.Context, id int64) (Response, error)
+	FindByRole(ctx context.Context, roleId int64) ([]Response, error)
+	GrantToRole(ctx context.Context, roleId int64, request GrantRequest) ([]Response, error)
+	RevokeFromRole(ctx context.Context, roleId int64, permissionId int64) ([]Response, error)
+	FindEffective(ctx context.Context, employeeId int64) (EffectiveResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	permissionService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:            server,
+		permissionService: permissionService,
+		logger:            logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/permissions"
+	c.server.GroupPermissions.Get("/", c.server.Require(web.PermPermissionsRead), c.FindAll)
+	c.server.GroupPermissions.Post("/", c.server.Require(web.PermPermissionsWrite), c.CreatePermission)
+	c.server.GroupPermissions.Get("/roles/:roleId", c.server.Require(web.PermPermissionsRead), c.FindByRole)
+	c.server.GroupPermissions.Post("/roles/:roleId", c.server.Require(web.PermPermissionsWrite), c.GrantToRole)
+	c.server.GroupPermissions.Delete("/roles/:roleId/:permissionId", c.server.Require(web.PermPermissionsWrite), c.RevokeFromRole)
+	c.server.GroupPermissions.Get("/:id", c.server.Require(web.PermPermissionsRead), c.FindById)
+	c.server.GroupPermissions.Put("/:id", c.server.Require(web.PermPermissionsWrite), c.UpdatePermission)
+	c.server.GroupPermissions.Delete("/:id", c.server.Require(web.PermPermissionsWrite), c.DeleteById)
+
+	// полный маршрут получится "/api/v1/employees/:id/effective-permissions"
+	c.server.GroupEmployees.Get("/:id/effective-permissions", c.server.Require(web.PermPermissionsRead), c.FindEffective)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/permissions" --//
+
+// FindAll   	 godoc
+// @Description  Find all Permissions
+// @Summary		 get all permissions
+// @Tags 		 permission
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		permission.Response	"Permission response"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /permissions/		[get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.permissionService.FindAll(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Permissions ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find by ID permission
+// @Summary 	 find by ID permission
+// @Tags 		 permission
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  			"Permission ID"
+// @Success 	 200  {object}  	permission.Response	"Permission response"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      404  {object}  	http.Response		"Not found"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /permissions/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	permissionID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.permissionService.FindById(appContext, permissionID)
+	if err != nil {
+		c.logger.Error(
+			"When the get Permission ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", permissionID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// CreatePermission godoc
+// @Summary      create a new permission
+// @Description  Create a new permission
+// @Tags 		 permission
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	permission.CreateRequest true "Permission creation details"
+// @Success 	 201  {object}  permission.Response	"Permission response"
+// @Failure      400  {object}  http.Response		"Bad request"
+// @Failure      409  {object}  http.Response		"Conflict"
+// @Failure      500  {object}  http.Response		"Bad request"
+// @Router 		 /permissions/ 	[post]
+func (c *Controller) CreatePermission(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an CreatePermission ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.permissionService.CreatePermission(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Permission ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// UpdatePermission godoc
+// @Summary		 update permission by ID
+// @Description  Update Permission by ID
+// @Tags 		 permission
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  						true  	"Permission ID"
+// @Param   	 request 	body     	permission.UpdateRequest	true  	"Permission updated details"
+// @Success 	 200  {object}  permission.Response	"Permission response"
+// @Failure      400  {object}  http.Response		"Bad request"
+// @Failure      404  {object}  http.Response		"Not found"
+// @Failure      409  {object}  http.Response		"Conflict"
+// @Failure      500  {object}  http.Response		"Bad request"
+// @Router 		 /permissions/{id} 	[put]
+func (c *Controller) UpdatePermission(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	permissionID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request UpdateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an UpdatePermission ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.permissionService.UpdatePermission(appContext, permissionID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the update Permission ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", permissionID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// DeleteById  godoc
+// @Description  Delete Permission by ID
+// @Summary		 delete permission by ID
+// @Tags 		 permission
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  				true	"Permission ID"
+// @Success 	 200  {object} 		permission.Response			"Permission response"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /permissions/{id}	[delete]
+func (c *Controller) DeleteById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	permissionID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.permissionService.DeleteById(appContext, permissionID)
+	if err != nil {
+		c.logger.Error(
+			"When the delete Permission ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", permissionID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindByRole 	 godoc
+// @Description  Find permissions granted to role
+// @Summary 	 find role permissions
+// @Tags 		 permission
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 roleId   path      	int  true  			"Role ID"
+// @Success 	 200  {array}  		permission.Response	"Permission response"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      404  {object}  	http.Response		"Not found"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /permissions/roles/{roleId} 	[get]
+func (c *Controller) FindByRole(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	roleID, err := c.parseIdParam(ctx, "roleId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.permissionService.FindByRole(appContext, roleID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Role Permissions ended with an error:",
+			zap.Error(err),
+			zap.Int64("role_id", roleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// GrantToRole 	 godoc
+// @Description  Grant permission to role
+// @Summary 	 grant permission to role
+// @Tags 		 permission
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 roleId   	path      	int  						true  	"Role ID"
+// @Param 		 request 	body 		permission.GrantRequest 	true 	"Permission grant details"
+// @Success 	 200  {array}  		permission.Response	"Permission response"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      404  {object}  	http.Response		"Not found"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /permissions/roles/{roleId} 	[post]
+func (c *Controller) GrantToRole(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	roleID, err := c.parseIdParam(ctx, "roleId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request GrantRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an GrantToRole ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.permissionService.GrantToRole(appContext, roleID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the grant Permission to Role ended with an error:",
+			zap.Error(err),
+			zap.Int64("role_id", roleID),
+			zap.Int64("permission_id", request.PermissionID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// RevokeFromRole godoc
+// @Description  Revoke permission from role
+// @Summary 	 revoke permission from role
+// @Tags 		 permission
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 roleId   		path      	int  	true  	"Role ID"
+// @Param 		 permissionId   path      	int  	true  	"Permission ID"
+// @Success 	 200  {array}  		permission.Response	"Permission response"
+// @Failure      400  {object}  	http.Response		"Bad request"
+// @Failure      404  {object}  	http.Response		"Not found"
+// @Failure      500  {object}  	http.Response		"Bad request"
+// @Router 		 /permissions/roles/{roleId}/{permissionId} 	[delete]
+func (c *Controller) RevokeFromRole(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	roleID, err := c.parseIdParam(ctx, "roleId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	permissionID, err := c.parseIdParam(ctx, "permissionId", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.permissionService.RevokeFromRole(appContext, roleID, permissionID)
+	if err != nil {
+		c.logger.Error(
+			"When the revoke Permission from Role ended with an error:",
+			zap.Error(err),
+			zap.Int64("role_id", roleID),
+			zap.Int64("permission_id", permissionID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindEffective godoc
+// @Description  Find effective permissions of employee resolved through employee roles
+// @Summary 	 find employee effective permissions
+// @Tags 		 employee
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  					"Employee ID"
+// @Success 	 200  {object}  	permission.EffectiveResponse	"Effective permissions"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /employees/{id}/effective-permissions 	[get]
+func (c *Controller) FindEffective(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	employeeID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.permissionService.FindEffective(appContext, employeeID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Employee effective Permissions ended with an error:",
+			zap.Error(err),
+			zap.Int64("employee_id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// parseIdParam - разбор числового path-параметра с логированием ошибки
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
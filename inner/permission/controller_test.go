@@ -0,0 +1,182 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPermission_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockPermissionService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupEmployees:        app.Group("/api/v1/employees"),
+		GroupPermissions:      app.Group("/api/v1/permissions"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	testPermission := Response{Id: 1, Name: "employees:read", Description: "read employees", CreateAt: now, UpdateAt: now}
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should return all permissions", func(t *testing.T) {
+		mockService.On("FindAll", appContext).Return([]Response{testPermission}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/permissions/", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data []Response
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, []Response{testPermission}, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when permission not found", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "permission with id 2 not found"}
+		mockService.On("FindById", appContext, int64(2)).Return(Response{}, notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/permissions/2", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, notFound.Message, body.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should create permission", func(t *testing.T) {
+		request := CreateRequest{Name: "employees:read", Description: "read employees"}
+		mockService.On("CreatePermission", appContext, request).Return(testPermission, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/permissions/",
+			strings.NewReader(`{"name": "employees:read", "description": "read employees"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when permission already exists", func(t *testing.T) {
+		request := CreateRequest{Name: "employees:read"}
+		conflict := domain.AlreadyExistsError{Message: "permission with name employees:read already exists"}
+		mockService.On("CreatePermission", appContext, request).Return(Response{}, conflict).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/permissions/", strings.NewReader(`{"name": "employees:read"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should grant permission to role", func(t *testing.T) {
+		request := GrantRequest{PermissionID: 1}
+		mockService.On("GrantToRole", appContext, int64(10), request).Return([]Response{testPermission}, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/permissions/roles/10", strings.NewReader(`{"permissionId": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should revoke permission from role", func(t *testing.T) {
+		mockService.On("RevokeFromRole", appContext, int64(10), int64(1)).Return([]Response{}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/permissions/roles/10/1", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when role id is invalid", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/permissions/roles/abc", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "FindByRole")
+	})
+
+	t.Run("should return effective permissions of employee", func(t *testing.T) {
+		effective := EffectiveResponse{
+			EmployeeID: 5,
+			Permissions: []EffectivePermissionResponse{
+				{Id: 1, Name: "employees:read", Roles: []string{"ADMIN", "USER"}},
+			},
+		}
+		mockService.On("FindEffective", appContext, int64(5)).Return(effective, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/employees/5/effective-permissions", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data EffectiveResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, effective, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 500 without internal details", func(t *testing.T) {
+		mockService.On("FindEffective", appContext, int64(6)).Return(EffectiveResponse{}, errors.New("db error")).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/employees/6/effective-permissions", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, internalServerError, body.Error)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,143 @@
+package permission
+
+import (
+	"time"
+)
+
+type Entity struct {
+	Id          int64     `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// Response model info
+// @Description Permission information
+// @Description with permission id, name, description, createAt, updateAt
+type Response struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreateAt    time.Time `json:"createAt"`
+	UpdateAt    time.Time `json:"updateAt"`
+}
+
+func (e *Entity) ToResponse() Response {
+	return Response{
+		Id:          e.Id,
+		Name:        e.Name,
+		Description: e.Description,
+		CreateAt:    e.CreatedAt,
+		UpdateAt:    e.UpdatedAt,
+	}
+}
+
+// GrantEntity - разрешение сотрудника вместе с ролью, через которую оно получено
+type GrantEntity struct {
+	Id          int64  `db:"id"`
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	RoleName    string `db:"role_name"`
+}
+
+// EffectivePermissionResponse model info
+// @Description Permission resolved through employee roles
+// @Description with permission id, name, description and granting roles
+type EffectivePermissionResponse struct {
+	Id          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Roles       []string `json:"roles"`
+}
+
+// EffectiveResponse model info
+// @Description Effective permissions of employee
+// @Description with employee id and permissions
+type EffectiveResponse struct {
+	EmployeeID  int64                         `json:"employeeId"`
+	Permissions []EffectivePermissionResponse `json:"permissions"`
+}
+
+// ToEffectiveResponse - группирует строки выдачи по разрешению, сохраняя порядок
+func ToEffectiveResponse(employeeId int64, grants []GrantEntity) EffectiveResponse {
+	var permissions = make([]EffectivePermissionResponse, 0, len(grants))
+	var indexes = make(map[int64]int, len(grants))
+	for _, grant := range grants {
+		idx, ok := indexes[grant.Id]
+		if !ok {
+			permissions = append(permissions, EffectivePermissionResponse{
+				Id:          grant.Id,
+				Name:        grant.Name,
+				Description: grant.Description,
+				Roles:       []string{},
+			})
+			idx = len(permissions) - 1
+			indexes[grant.Id] = idx
+		}
+		permissions[idx].Roles = append(permissions[idx].Roles, grant.RoleName)
+	}
+
+	return EffectiveResponse{
+		EmployeeID:  employeeId,
+		Permissions: permissions,
+	}
+}
+
+// CreateRequest model info
+// @Description Permission information
+// @Description with name, description
+type CreateRequest struct {
+	Name        string `json:"name" validate:"required,min=3,max=155,no_sql_injection"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+}
+
+func (req *CreateRequest) ToEntity() *Entity {
+	return &Entity{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+}
+
+// UpdateRequest model info
+// @Description Permission information
+// @Description with permission id, name, description
+type UpdateRequest struct {
+	Id          int64  `json:"id" validate:"required,min=1"`
+	Name        string `json:"name" validate:"required,min=3,max=155,no_sql_injection"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+}
+
+func (req *UpdateRequest) ToEntity() *Entity {
+	return &Entity{
+		Id:          req.Id,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+}
+
+// GrantRequest model info
+// @Description Role permission grant information
+// @Description with permission id
+type GrantRequest struct {
+	RoleID       int64 `json:"-" validate:"required,min=1"`
+	PermissionID int64 `json:"permissionId" validate:"required,min=1"`
+}
+
+type RevokeRequest struct {
+	RoleID       int64 `validate:"required,min=1"`
+	PermissionID int64 `validate:"required,min=1"`
+}
+
+type CheckRequest struct {
+	EmployeeID int64  `validate:"required,min=1"`
+	Name       string `validate:"required,min=3,max=155,no_sql_injection"`
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
+
+type DeleteByIdRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
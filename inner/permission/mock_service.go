@@ -0,0 +1,55 @@
+package permission
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPermissionService struct {
+	mock.Mock
+}
+
+func (m *MockPermissionService) FindAll(ctx context.Context) ([]Response, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Response), args.Error(1) // Важно: правильный тип
+}
+
+func (m *MockPermissionService) FindById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockPermissionService) CreatePermission(ctx context.Context, request CreateRequest) (Response, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockPermissionService) UpdatePermission(ctx context.Context, id int64, request UpdateRequest) (Response, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockPermissionService) DeleteById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockPermissionService) FindByRole(ctx context.Context, roleId int64) ([]Response, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockPermissionService) GrantToRole(ctx context.Context, roleId int64, request GrantRequest) ([]Response, error) {
+	args := m.Called(ctx, roleId, request)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockPermissionService) RevokeFromRole(ctx context.Context, roleId int64, permissionId int64) ([]Response, error) {
+	args := m.Called(ctx, roleId, permissionId)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockPermissionService) FindEffective(ctx context.Context, employeeId int64) (EffectiveResponse, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).(EffectiveResponse), args.Error(1)
+}
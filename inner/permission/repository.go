@@ -0,0 +1,219 @@
+package permission
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindAllPermissions - найти все элементы коллекции
+func (r *Repository) FindAllPermissions(ctx context.Context) (permissions []Entity, err error) {
+	query := `SELECT id, name, description, created_at, updated_at FROM permissions ORDER BY id`
+	err = r.db.SelectContext(ctx, &permissions, query)
+
+	return permissions, err
+}
+
+// FindById - найти элемент коллекции по его id
+func (r *Repository) FindById(ctx context.Context, id int64) (entity Entity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT id, name, description, created_at, updated_at FROM permissions WHERE id = $1",
+		id,
+	)
+
+	return entity, err
+}
+
+// ExistsByName - проверить наличие разрешения с заданным именем (кроме разрешения с excludeId)
+func (r *Repository) ExistsByName(ctx context.Context, name string, excludeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM permissions WHERE name = $1 AND id <> $2)",
+		name, excludeId,
+	)
+
+	return isExists, err
+}
+
+// CreatePermission - добавить новый элемент в коллекцию
+func (r *Repository) CreatePermission(ctx context.Context, entity *Entity) (result Entity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&result,
+		`INSERT INTO permissions (name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, name, description, created_at, updated_at`,
+		entity.Name, entity.Description, time.Now(), time.Now(),
+	)
+
+	return result, err
+}
+
+// UpdatePermission - обновить элемент коллекции, возвращает обновлённую сущность
+func (r *Repository) UpdatePermission(ctx context.Context, entity *Entity) (result Entity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&result,
+		`UPDATE permissions SET name = $1, description = $2, updated_at = $3
+		WHERE id = $4
+		RETURNING id, name, description, created_at, updated_at`,
+		entity.Name, entity.Description, time.Now(), entity.Id,
+	)
+
+	return result, err
+}
+
+// DeletePermissionById - удалить элемент коллекции по его id, возвращает false если элемента не было
+func (r *Repository) DeletePermissionById(ctx context.Context, id int64) (isDeleted bool, err error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM permissions WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+
+	return affected > 0, err
+}
+
+// ExistsRoleById - проверить наличие роли с заданным id (мягко удалённые не учитываются)
+func (r *Repository) ExistsRoleById(ctx context.Context, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)",
+		roleId,
+	)
+
+	return isExists, err
+}
+
+// ExistsEmployeeById - проверить наличие сотрудника с заданным id (мягко удалённые не учитываются)
+func (r *Repository) ExistsEmployeeById(ctx context.Context, employeeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL)",
+		employeeId,
+	)
+
+	return isExists, err
+}
+
+// FindPermissionsByRoleId - найти все разрешения, выданные роли
+func (r *Repository) FindPermissionsByRoleId(ctx context.Context, roleId int64) (permissions []Entity, err error) {
+	query := `
+		SELECT p.id, p.name, p.description, p.created_at, p.updated_at
+		FROM role_permissions rp
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE rp.role_id = $1
+		ORDER BY p.id
+	`
+	err = r.db.SelectContext(ctx, &permissions, query, roleId)
+
+	return permissions, err
+}
+
+// GrantToRole - выдать разрешение роли (повторная выдача игнорируется)
+func (r *Repository) GrantToRole(ctx context.Context, roleId int64, permissionId int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO role_permissions (role_id, permission_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (role_id, permission_id) DO NOTHING`,
+		roleId, permissionId, time.Now(),
+	)
+
+	return err
+}
+
+// RevokeFromRole - отозвать разрешение у роли, возвращает false если выдачи не было
+func (r *Repository) RevokeFromRole(ctx context.Context, roleId int64, permissionId int64) (isRevoked bool, err error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM role_permissions WHERE role_id = $1 AND permission_id = $2",
+		roleId, permissionId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+
+	return affected > 0, err
+}
+
+// FindEffectiveByEmployeeId - найти разрешения сотрудника через его роли (прямые и полученные через группы).
+// Мягко удалённые сотрудник или роль, а также неактивный сотрудник разрешений не дают
+func (r *Repository) FindEffectiveByEmployeeId(ctx context.Context, employeeId int64) (grants []GrantEntity, err error) {
+	query := `
+		SELECT DISTINCT p.id, p.name, p.description, r.name AS role_name
+		FROM employee_effective_roles_of($1) er
+		JOIN employees e ON e.id = er.employee_id AND e.deleted_at IS NULL AND e.status = 'active'
+		JOIN roles r ON r.id = er.role_id AND r.deleted_at IS NULL
+		JOIN role_permissions rp ON rp.role_id = er.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		ORDER BY p.id, r.name
+	`
+	err = r.db.SelectContext(ctx, &grants, query, employeeId)
+
+	return grants, err
+}
+
+// ExistsEmployeePermission - проверить, есть ли у активного сотрудника разрешение хотя бы через одну (неудалённую) роль,
+// назначенную напрямую или через группы
+func (r *Repository) ExistsEmployeePermission(ctx context.Context, employeeId int64, name string) (isExists bool, err error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM employee_effective_roles_of($1) er
+			JOIN employees e ON e.id = er.employee_id AND e.deleted_at IS NULL AND e.status = 'active'
+			JOIN roles r ON r.id = er.role_id AND r.deleted_at IS NULL
+			JOIN role_permissions rp ON rp.role_id = er.role_id
+			JOIN permissions p ON p.id = rp.permission_id
+			WHERE p.name = $2
+		)
+	`
+	err = r.db.GetContext(ctx, &isExists, query, employeeId, name)
+
+	return isExists, err
+}
+
+// FindClientPermissionNames - имена разрешений клиента client_credentials через его неудалённые роли
+// и роли, которые они подразумевают по наследованию (рекурсия начинается с ролей клиента)
+func (r *Repository) FindClientPermissionNames(ctx context.Context, clientId string) (names []string, err error) {
+	query := `
+		WITH RECURSIVE implied AS (
+			SELECT r.id AS role_id, ARRAY[r.id] AS path
+			FROM oauth_clients c
+			JOIN oauth_client_roles cr ON cr.client_id = c.id
+			JOIN roles r ON r.id = cr.role_id AND r.deleted_at IS NULL
+			WHERE c.client_id = $1
+			UNION ALL
+			SELECT ri.inherited_role_id, i.path || ri.inherited_role_id
+			FROM implied i
+			JOIN role_inheritance ri ON ri.role_id = i.role_id
+			JOIN roles r ON r.id = ri.inherited_role_id AND r.deleted_at IS NULL
+			WHERE NOT ri.inherited_role_id = ANY(i.path)
+		)
+		SELECT DISTINCT p.name
+		FROM implied i
+		JOIN role_permissions rp ON rp.role_id = i.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		ORDER BY p.name
+	`
+	err = r.db.SelectContext(ctx, &names, query, clientId)
+
+	return names, err
+}
@@ -0,0 +1,320 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"idm/inner/domain"
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+}
+
+type Repo interface {
+	FindAllPermissions(ctx context.Context) ([]Entity, error)
+	FindById(ctx context.Context, id int64) (Entity, error)
+	ExistsByName(ctx context.Context, name string, excludeId int64) (bool, error)
+	CreatePermission(ctx context.Context, entity *Entity) (Entity, error)
+	UpdatePermission(ctx context.Context, entity *Entity) (Entity, error)
+	DeletePermissionById(ctx context.Context, id int64) (bool, error)
+	ExistsRoleById(ctx context.Context, roleId int64) (bool, error)
+	ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error)
+	FindPermissionsByRoleId(ctx context.Context, roleId int64) ([]Entity, error)
+	GrantToRole(ctx context.Context, roleId int64, permissionId int64) error
+	RevokeFromRole(ctx context.Context, roleId int64, permissionId int64) (bool, error)
+	FindEffectiveByEmployeeId(ctx context.Context, employeeId int64) ([]GrantEntity, error)
+	ExistsEmployeePermission(ctx context.Context, employeeId int64, name string) (bool, error)
+	FindClientPermissionNames(ctx context.Context, clientId string) ([]string, error)
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+	}
+}
+
+// FindAll - найти все элементы коллекции
+func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
+	entities, err := svc.repo.FindAllPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding Permissions: %w", err)
+	}
+
+	return toResponses(entities), nil
+}
+
+func (svc *Service) FindById(
+	ctx context.Context,
+	id int64,
+) (Response, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.repo.FindById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Response{}, domain.NotFoundError{Message: fmt.Sprintf("permission with id %d not found", id)}
+	}
+	if err != nil {
+		return Response{}, fmt.Errorf("error finding permission with id %d: %w", id, err)
+	}
+
+	return entity.ToResponse(), nil
+}
+
+func (svc *Service) CreatePermission(
+	ctx context.Context,
+	request CreateRequest,
+) (Response, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isExists, err := svc.repo.ExistsByName(ctx, request.Name, 0)
+	if err != nil {
+		return Response{}, fmt.Errorf("error checking permission with name %s: %w", request.Name, err)
+	}
+	if isExists {
+		return Response{}, domain.AlreadyExistsError{
+			Message: fmt.Sprintf("permission with name %s already exists", request.Name),
+		}
+	}
+
+	entity, err := svc.repo.CreatePermission(ctx, request.ToEntity())
+	if err != nil {
+		return Response{}, fmt.Errorf("error creating Permission with name %s: %w", request.Name, err)
+	}
+
+	return entity.ToResponse(), nil
+}
+
+func (svc *Service) UpdatePermission(
+	ctx context.Context,
+	id int64,
+	request UpdateRequest,
+) (Response, error) {
+	request.Id = id
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isExists, err := svc.repo.ExistsByName(ctx, request.Name, id)
+	if err != nil {
+		return Response{}, fmt.Errorf("error checking permission with name %s: %w", request.Name, err)
+	}
+	if isExists {
+		return Response{}, domain.AlreadyExistsError{
+			Message: fmt.Sprintf("permission with name %s already exists", request.Name),
+		}
+	}
+
+	entity, err := svc.repo.UpdatePermission(ctx, request.ToEntity())
+	if errors.Is(err, sql.ErrNoRows) {
+		return Response{}, domain.NotFoundError{Message: fmt.Sprintf("permission with id %d not found", id)}
+	}
+	if err != nil {
+		return Response{}, fmt.Errorf("error updating Permission with name %s: %w", request.Name, err)
+	}
+
+	return entity.ToResponse(), nil
+}
+
+func (svc *Service) DeleteById(
+	ctx context.Context,
+	id int64,
+) (Response, error) {
+	if err := svc.validator.Validate(DeleteByIdRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isDeleted, err := svc.repo.DeletePermissionById(ctx, id)
+	if err != nil {
+		return Response{}, fmt.Errorf("error delete Permission by ID: %d, %w", id, err)
+	}
+	if !isDeleted {
+		return Response{}, domain.NotFoundError{Message: fmt.Sprintf("permission with id %d not found", id)}
+	}
+
+	return Response{}, nil
+}
+
+// FindByRole - найти все разрешения, выданные роли
+func (svc *Service) FindByRole(
+	ctx context.Context,
+	roleId int64,
+) ([]Response, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: roleId}); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkRoleExists(ctx, roleId); err != nil {
+		return nil, err
+	}
+
+	return svc.findByRole(ctx, roleId)
+}
+
+// GrantToRole - выдать разрешение роли, возвращает актуальный список разрешений роли
+func (svc *Service) GrantToRole(
+	ctx context.Context,
+	roleId int64,
+	request GrantRequest,
+) ([]Response, error) {
+	request.RoleID = roleId
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkRoleExists(ctx, roleId); err != nil {
+		return nil, err
+	}
+
+	_, err := svc.repo.FindById(ctx, request.PermissionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.NotFoundError{Message: fmt.Sprintf("permission with id %d not found", request.PermissionID)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding permission with id %d: %w", request.PermissionID, err)
+	}
+
+	err = svc.repo.GrantToRole(ctx, roleId, request.PermissionID)
+	if err != nil {
+		return nil, fmt.Errorf("error granting permission %d to role %d: %w", request.PermissionID, roleId, err)
+	}
+
+	return svc.findByRole(ctx, roleId)
+}
+
+// RevokeFromRole - отозвать разрешение у роли, возвращает актуальный список разрешений роли
+func (svc *Service) RevokeFromRole(
+	ctx context.Context,
+	roleId int64,
+	permissionId int64,
+) ([]Response, error) {
+	request := RevokeRequest{RoleID: roleId, PermissionID: permissionId}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRevoked, err := svc.repo.RevokeFromRole(ctx, roleId, permissionId)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking permission %d from role %d: %w", permissionId, roleId, err)
+	}
+	if !isRevoked {
+		return nil, domain.NotFoundError{
+			Message: fmt.Sprintf("permission %d is not granted to role %d", permissionId, roleId),
+		}
+	}
+
+	return svc.findByRole(ctx, roleId)
+}
+
+// FindEffective - найти разрешения сотрудника, полученные через его роли
+func (svc *Service) FindEffective(
+	ctx context.Context,
+	employeeId int64,
+) (EffectiveResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: employeeId}); err != nil {
+		return EffectiveResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isExists, err := svc.repo.ExistsEmployeeById(ctx, employeeId)
+	if err != nil {
+		return EffectiveResponse{}, fmt.Errorf("error checking employee with id %d: %w", employeeId, err)
+	}
+	if !isExists {
+		return EffectiveResponse{}, domain.NotFoundError{Message: fmt.Sprintf("employee with id %d not found", employeeId)}
+	}
+
+	grants, err := svc.repo.FindEffectiveByEmployeeId(ctx, employeeId)
+	if err != nil {
+		return EffectiveResponse{}, fmt.Errorf("error finding effective permissions of employee %d: %w", employeeId, err)
+	}
+
+	return ToEffectiveResponse(employeeId, grants), nil
+}
+
+// HasPermission - ответить на вопрос "может ли сотрудник X выполнить действие Y"
+func (svc *Service) HasPermission(
+	ctx context.Context,
+	employeeId int64,
+	name string,
+) (bool, error) {
+	if err := svc.validator.Validate(CheckRequest{EmployeeID: employeeId, Name: name}); err != nil {
+		return false, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isGranted, err := svc.repo.ExistsEmployeePermission(ctx, employeeId, name)
+	if err != nil {
+		return false, fmt.Errorf("error checking permission %s of employee %d: %w", name, employeeId, err)
+	}
+
+	return isGranted, nil
+}
+
+// FindPermissionNames - имена разрешений сотрудника через его роли (используется авторизацией маршрутов)
+func (svc *Service) FindPermissionNames(
+	ctx context.Context,
+	employeeId int64,
+) ([]string, error) {
+	grants, err := svc.repo.FindEffectiveByEmployeeId(ctx, employeeId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding permissions of employee %d: %w", employeeId, err)
+	}
+
+	var effective = ToEffectiveResponse(employeeId, grants)
+	var names = make([]string, 0, len(effective.Permissions))
+	for _, permission := range effective.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names, nil
+}
+
+// FindClientPermissionNames - имена разрешений клиента client_credentials через его роли
+// (используется авторизацией маршрутов)
+func (svc *Service) FindClientPermissionNames(ctx context.Context, clientId string) ([]string, error) {
+	names, err := svc.repo.FindClientPermissionNames(ctx, clientId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding permissions of client %s: %w", clientId, err)
+	}
+	return names, nil
+}
+
+func (svc *Service) findByRole(ctx context.Context, roleId int64) ([]Response, error) {
+	entities, err := svc.repo.FindPermissionsByRoleId(ctx, roleId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding permissions of role %d: %w", roleId, err)
+	}
+
+	return toResponses(entities), nil
+}
+
+func (svc *Service) checkRoleExists(ctx context.Context, roleId int64) error {
+	isExists, err := svc.repo.ExistsRoleById(ctx, roleId)
+	if err != nil {
+		return fmt.Errorf("error checking role with id %d: %w", roleId, err)
+	}
+	if !isExists {
+		return domain.NotFoundError{Message: fmt.Sprintf("role with id %d not found", roleId)}
+	}
+
+	return nil
+}
+
+func toResponses(entities []Entity) []Response {
+	responses := make([]Response, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses
+}
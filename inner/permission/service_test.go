@@ -0,0 +1,326 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"testing"
+	"time"
+)
+
+// Объявляем структуру мок-репозитория
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindAllPermissions(ctx context.Context) ([]Entity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindById(ctx context.Context, id int64) (Entity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsByName(ctx context.Context, name string, excludeId int64) (bool, error) {
+	args := m.Called(ctx, name, excludeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) CreatePermission(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) UpdatePermission(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) DeletePermissionById(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) ExistsRoleById(ctx context.Context, roleId int64) (bool, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindPermissionsByRoleId(ctx context.Context, roleId int64) ([]Entity, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) GrantToRole(ctx context.Context, roleId int64, permissionId int64) error {
+	args := m.Called(ctx, roleId, permissionId)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RevokeFromRole(ctx context.Context, roleId int64, permissionId int64) (bool, error) {
+	args := m.Called(ctx, roleId, permissionId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindEffectiveByEmployeeId(ctx context.Context, employeeId int64) ([]GrantEntity, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).([]GrantEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindClientPermissionNames(ctx context.Context, clientId string) ([]string, error) {
+	args := m.Called(ctx, clientId)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepo) ExistsEmployeePermission(ctx context.Context, employeeId int64, name string) (bool, error) {
+	args := m.Called(ctx, employeeId, name)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func TestPermissionService(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should return all permissions", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		entities := []Entity{
+			{Id: 1, Name: "employees:read", CreatedAt: now, UpdatedAt: now},
+			{Id: 2, Name: "employees:delete", CreatedAt: now, UpdatedAt: now},
+		}
+
+		repo.On("FindAllPermissions", appContext).Return(entities, nil).Once()
+
+		got, err := service.FindAll(appContext)
+
+		a.Nil(err)
+		a.Len(got, 2)
+		a.Equal("employees:delete", got[1].Name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when permission does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		_, err := service.FindById(appContext, 1)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should create permission", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "employees:read", Description: "read employees"}
+		created := Entity{Id: 1, Name: request.Name, Description: request.Description, CreatedAt: now, UpdatedAt: now}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsByName", appContext, request.Name, int64(0)).Return(false, nil).Once()
+		repo.On("CreatePermission", appContext, request.ToEntity()).Return(created, nil).Once()
+
+		got, err := service.CreatePermission(appContext, request)
+
+		a.Nil(err)
+		a.Equal(created.ToResponse(), got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return already exists when permission name is taken", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CreateRequest{Name: "employees:read"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsByName", appContext, request.Name, int64(0)).Return(true, nil).Once()
+
+		_, err := service.CreatePermission(appContext, request)
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "CreatePermission", appContext, request.ToEntity())
+	})
+
+	t.Run("should update permission", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 1, Name: "employees:write"}
+		updated := Entity{Id: 1, Name: request.Name, CreatedAt: now, UpdatedAt: now}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsByName", appContext, request.Name, int64(1)).Return(false, nil).Once()
+		repo.On("UpdatePermission", appContext, request.ToEntity()).Return(updated, nil).Once()
+
+		got, err := service.UpdatePermission(appContext, 1, UpdateRequest{Name: "employees:write"})
+
+		a.Nil(err)
+		a.Equal("employees:write", got.Name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when delete missing permission", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", DeleteByIdRequest{ID: 1}).Return(nil).Once()
+		repo.On("DeletePermissionById", appContext, int64(1)).Return(false, nil).Once()
+
+		_, err := service.DeleteById(appContext, 1)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should grant permission to role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := GrantRequest{RoleID: 10, PermissionID: 1}
+		entity := Entity{Id: 1, Name: "employees:read"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(10)).Return(true, nil).Once()
+		repo.On("FindById", appContext, int64(1)).Return(entity, nil).Once()
+		repo.On("GrantToRole", appContext, int64(10), int64(1)).Return(nil).Once()
+		repo.On("FindPermissionsByRoleId", appContext, int64(10)).Return([]Entity{entity}, nil).Once()
+
+		got, err := service.GrantToRole(appContext, 10, GrantRequest{PermissionID: 1})
+
+		a.Nil(err)
+		a.Equal([]Response{entity.ToResponse()}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when grant to missing role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := GrantRequest{RoleID: 10, PermissionID: 1}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(10)).Return(false, nil).Once()
+
+		got, err := service.GrantToRole(appContext, 10, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when revoke not granted permission", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", RevokeRequest{RoleID: 10, PermissionID: 1}).Return(nil).Once()
+		repo.On("RevokeFromRole", appContext, int64(10), int64(1)).Return(false, nil).Once()
+
+		got, err := service.RevokeFromRole(appContext, 10, 1)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should resolve effective permissions through roles", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		grants := []GrantEntity{
+			{Id: 1, Name: "employees:read", RoleName: "ADMIN"},
+			{Id: 1, Name: "employees:read", RoleName: "USER"},
+			{Id: 2, Name: "employees:delete", RoleName: "ADMIN"},
+		}
+
+		validator.On("Validate", FindByIDRequest{ID: 5}).Return(nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(5)).Return(true, nil).Once()
+		repo.On("FindEffectiveByEmployeeId", appContext, int64(5)).Return(grants, nil).Once()
+
+		got, err := service.FindEffective(appContext, 5)
+
+		a.Nil(err)
+		a.Equal(int64(5), got.EmployeeID)
+		a.Len(got.Permissions, 2)
+		a.Equal([]string{"ADMIN", "USER"}, got.Permissions[0].Roles)
+		a.Equal([]string{"ADMIN"}, got.Permissions[1].Roles)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when employee does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 5}).Return(nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(5)).Return(false, nil).Once()
+
+		_, err := service.FindEffective(appContext, 5)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should answer whether employee has permission", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := CheckRequest{EmployeeID: 5, Name: "employees:delete"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsEmployeePermission", appContext, int64(5), "employees:delete").Return(true, nil).Once()
+
+		got, err := service.HasPermission(appContext, 5, "employees:delete")
+
+		a.Nil(err)
+		a.True(got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return unique permission names of employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+		grants := []GrantEntity{
+			{Id: 1, Name: "employees:read", RoleName: "ADMIN"},
+			{Id: 1, Name: "employees:read", RoleName: "USER"},
+			{Id: 2, Name: "employees:delete", RoleName: "ADMIN"},
+		}
+
+		repo.On("FindEffectiveByEmployeeId", appContext, int64(5)).Return(grants, nil).Once()
+
+		got, err := service.FindPermissionNames(appContext, 5)
+
+		a.Nil(err)
+		a.Equal([]string{"employees:read", "employees:delete"}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return permission names of client", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator))
+
+		repo.On("FindClientPermissionNames", appContext, "hr-sync").Return([]string{"employees:read"}, nil).Once()
+
+		got, err := service.FindClientPermissionNames(appContext, "hr-sync")
+
+		a.Nil(err)
+		a.Equal([]string{"employees:read"}, got)
+		repo.AssertExpectations(t)
+	})
+}
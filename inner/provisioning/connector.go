@@ -0,0 +1,255 @@
+package provisioning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"idm/inner/scim"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Connector - доступ к целевой системе. Ресурсы передаются в представлении SCIM независимо от типа
+// коннектора; externalId - id ресурса в целевой системе, пустой - ресурс там ещё не создан
+type Connector interface {
+	// UpsertUser - создать или заменить пользователя, возвращает его id в целевой системе
+	UpsertUser(ctx context.Context, externalId string, user scim.User) (string, error)
+	// DeleteUser - удалить пользователя; если его уже нет - не ошибка
+	DeleteUser(ctx context.Context, externalId string) error
+	UpsertGroup(ctx context.Context, externalId string, group scim.Group) (string, error)
+	DeleteGroup(ctx context.Context, externalId string) error
+	// ListUsers, ListGroups - все ресурсы целевой системы для сверки
+	ListUsers(ctx context.Context) ([]scim.User, error)
+	ListGroups(ctx context.Context) ([]scim.Group, error)
+}
+
+// ConnectorFactory - коннектор к целевой системе по её типу
+type ConnectorFactory func(target TargetEntity) (Connector, error)
+
+// NewConnector - коннектор по умолчанию для всех поддерживаемых типов целевых систем
+func NewConnector(target TargetEntity) (Connector, error) {
+	switch target.Type {
+	case TargetScim:
+		return NewScimConnector(target.BaseUrl, target.Token, http.DefaultClient), nil
+	default:
+		return nil, fmt.Errorf("unsupported provisioning target type %q", target.Type)
+	}
+}
+
+// ErrNotRetryable - ошибка, которую повтор доставки не исправит
+var ErrNotRetryable = errors.New("not retryable")
+
+// TargetError - целевая система ответила ошибкой
+type TargetError struct {
+	StatusCode int
+	Detail     string
+}
+
+func (err TargetError) Error() string {
+	if err.Detail == "" {
+		return fmt.Sprintf("target responded with status %d", err.StatusCode)
+	}
+	return fmt.Sprintf("target responded with status %d: %s", err.StatusCode, err.Detail)
+}
+
+// IsRetryable - повтор может помочь: сеть, таймаут, перегрузка или ошибка на стороне целевой системы.
+// Остальные ответы 4xx означают, что данные или настройки надо исправить
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrNotRetryable) {
+		return false
+	}
+	var targetErr TargetError
+	if !errors.As(err, &targetErr) {
+		return true
+	}
+	return targetErr.StatusCode >= http.StatusInternalServerError ||
+		targetErr.StatusCode == http.StatusRequestTimeout ||
+		targetErr.StatusCode == http.StatusTooManyRequests
+}
+
+func isStatus(err error, statusCode int) bool {
+	var targetErr TargetError
+	return errors.As(err, &targetErr) && targetErr.StatusCode == statusCode
+}
+
+// requestTimeout - предел одного запроса к целевой системе
+const requestTimeout = 30 * time.Second
+
+// listPageSize - размер страницы при выгрузке ресурсов целевой системы
+const listPageSize = 100
+
+// ScimConnector - клиент SCIM 2.0 (RFC 7644)
+type ScimConnector struct {
+	baseUrl string
+	token   string
+	client  *http.Client
+}
+
+// NewScimConnector - функция-конструктор; baseUrl - корень SCIM, например https://host/scim/v2
+func NewScimConnector(baseUrl string, token string, client *http.Client) *ScimConnector {
+	return &ScimConnector{
+		baseUrl: strings.TrimRight(baseUrl, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+func (c *ScimConnector) UpsertUser(ctx context.Context, externalId string, user scim.User) (string, error) {
+	var created scim.User
+	err := c.upsert(ctx, "/Users", externalId, user, &created, func() (string, error) {
+		return c.findId(ctx, "/Users", fmt.Sprintf("userName eq %q", user.UserName))
+	})
+	return created.Id, err
+}
+
+func (c *ScimConnector) DeleteUser(ctx context.Context, externalId string) error {
+	return c.delete(ctx, "/Users", externalId)
+}
+
+func (c *ScimConnector) UpsertGroup(ctx context.Context, externalId string, group scim.Group) (string, error) {
+	var created scim.Group
+	err := c.upsert(ctx, "/Groups", externalId, group, &created, func() (string, error) {
+		return c.findId(ctx, "/Groups", fmt.Sprintf("displayName eq %q", group.DisplayName))
+	})
+	return created.Id, err
+}
+
+func (c *ScimConnector) DeleteGroup(ctx context.Context, externalId string) error {
+	return c.delete(ctx, "/Groups", externalId)
+}
+
+func (c *ScimConnector) ListUsers(ctx context.Context) ([]scim.User, error) {
+	return listAll[scim.User](ctx, c, "/Users")
+}
+
+func (c *ScimConnector) ListGroups(ctx context.Context) ([]scim.Group, error) {
+	return listAll[scim.Group](ctx, c, "/Groups")
+}
+
+// upsert - PUT известного ресурса; если его там уже нет или id не известен - POST.
+// Конфликт уникальности при создании значит, что ресурс заведён раньше: он находится по имени и заменяется
+func (c *ScimConnector) upsert(
+	ctx context.Context,
+	path string,
+	externalId string,
+	resource any,
+	result any,
+	findExisting func() (string, error),
+) error {
+	if externalId != "" {
+		err := c.do(ctx, http.MethodPut, path+"/"+url.PathEscape(externalId), resource, result)
+		if !isStatus(err, http.StatusNotFound) {
+			return err
+		}
+	}
+
+	err := c.do(ctx, http.MethodPost, path, resource, result)
+	if !isStatus(err, http.StatusConflict) {
+		return err
+	}
+	existingId, findErr := findExisting()
+	if findErr != nil {
+		return errors.Join(err, findErr)
+	}
+	if existingId == "" {
+		return err
+	}
+	return c.do(ctx, http.MethodPut, path+"/"+url.PathEscape(existingId), resource, result)
+}
+
+func (c *ScimConnector) delete(ctx context.Context, path string, externalId string) error {
+	err := c.do(ctx, http.MethodDelete, path+"/"+url.PathEscape(externalId), nil, nil)
+	if isStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+// findId - id первого ресурса, удовлетворяющего фильтру, или пустая строка
+func (c *ScimConnector) findId(ctx context.Context, path string, filter string) (string, error) {
+	var page struct {
+		Resources []struct {
+			Id string `json:"id"`
+		} `json:"Resources"`
+	}
+	var query = url.Values{"filter": {filter}, "count": {"1"}}
+	if err := c.do(ctx, http.MethodGet, path+"?"+query.Encode(), nil, &page); err != nil {
+		return "", err
+	}
+	if len(page.Resources) == 0 {
+		return "", nil
+	}
+	return page.Resources[0].Id, nil
+}
+
+// listAll - все ресурсы постранично: startIndex с 1, пока не получены totalResults
+func listAll[T any](ctx context.Context, c *ScimConnector, path string) ([]T, error) {
+	var resources []T
+	for startIndex := 1; ; {
+		var page struct {
+			TotalResults int `json:"totalResults"`
+			Resources    []T `json:"Resources"`
+		}
+		var query = url.Values{"startIndex": {strconv.Itoa(startIndex)}, "count": {strconv.Itoa(listPageSize)}}
+		if err := c.do(ctx, http.MethodGet, path+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Resources...)
+		startIndex += len(page.Resources)
+		if len(page.Resources) == 0 || len(resources) >= page.TotalResults {
+			return resources, nil
+		}
+	}
+}
+
+// do - запрос к целевой системе; ответ не 2xx - TargetError с detail из тела ошибки SCIM
+func (c *ScimConnector) do(ctx context.Context, method string, path string, body any, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshal %s %s request: %w", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", scim.ContentType)
+	if body != nil {
+		request.Header.Set("Content-Type", scim.ContentType)
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error %s %s: %w", method, path, err)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var scimErr scim.ErrorResponse
+		_ = json.NewDecoder(io.LimitReader(response.Body, 64*1024)).Decode(&scimErr)
+		return TargetError{StatusCode: response.StatusCode, Detail: scimErr.Detail}
+	}
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,419 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+	invalidApplyFlag    = "Invalid apply flag"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server              *web.Server
+	provisioningService Svc
+	logger              *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	FindAll(ctx context.Context) ([]TargetResponse, error)
+	FindById(ctx context.Context, id int64) (TargetResponse, error)
+	Create(ctx context.Context, request CreateRequest) (TargetResponse, error)
+	Update(ctx context.Context, id int64, request UpdateRequest) (TargetResponse, error)
+	DeleteById(ctx context.Context, id int64) (TargetResponse, error)
+	FindOperations(ctx context.Context, request FindOperationsRequest) ([]OperationResponse, error)
+	FindSyncState(ctx context.Context, targetId int64) ([]SyncStateResponse, error)
+	Reconcile(ctx context.Context, targetId int64, apply bool) (ReconcileResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	provisioningService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:              server,
+		provisioningService: provisioningService,
+		logger:              logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/provisioning/targets"
+	c.server.GroupProvisioning.Get("/targets", c.server.Require(web.PermProvisioningRead), c.FindAll)
+	c.server.GroupProvisioning.Post("/targets", c.server.Require(web.PermProvisioningWrite), c.Create)
+	c.server.GroupProvisioning.Get("/targets/:id", c.server.Require(web.PermProvisioningRead), c.FindById)
+	c.server.GroupProvisioning.Put("/targets/:id", c.server.Require(web.PermProvisioningWrite), c.Update)
+	c.server.GroupProvisioning.Delete("/targets/:id", c.server.Require(web.PermProvisioningWrite), c.DeleteById)
+	c.server.GroupProvisioning.Get("/targets/:id/operations", c.server.Require(web.PermProvisioningRead), c.FindOperations)
+	c.server.GroupProvisioning.Get("/targets/:id/state", c.server.Require(web.PermProvisioningRead), c.FindSyncState)
+	c.server.GroupProvisioning.Post("/targets/:id/reconcile", c.server.Require(web.PermProvisioningWrite), c.Reconcile)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/provisioning" --//
+
+// FindAll   	 godoc
+// @Description  Find all provisioning target systems
+// @Summary		 get all provisioning targets
+// @Tags 		 provisioning
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		provisioning.TargetResponse	"Provisioning targets"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /provisioning/targets		[get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.provisioningService.FindAll(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Provisioning targets ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find by ID provisioning target system
+// @Summary 	 find by ID provisioning target
+// @Tags 		 provisioning
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  					"Target ID"
+// @Success 	 200  {object}  	provisioning.TargetResponse	"Provisioning target"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /provisioning/targets/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	targetID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.provisioningService.FindById(appContext, targetID)
+	if err != nil {
+		c.logger.Error(
+			"When the get Provisioning target ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", targetID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Create 		 godoc
+// @Summary      register provisioning target
+// @Description  Register SCIM 2.0 target system; changes of employees, roles and role assignments made
+// @Description  after registration are delivered automatically, earlier state is delivered by reconciliation
+// @Tags 		 provisioning
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	provisioning.CreateRequest true "Target details"
+// @Success 	 201  {object}  provisioning.TargetResponse	"Provisioning target"
+// @Failure      400  {object}  http.Response				"Bad request"
+// @Failure      409  {object}  http.Response				"Target name already exists"
+// @Failure      500  {object}  http.Response				"Bad request"
+// @Router 		 /provisioning/targets 	[post]
+func (c *Controller) Create(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an Create Provisioning target ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.provisioningService.Create(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Provisioning target ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// Update 		 godoc
+// @Summary      update provisioning target
+// @Description  Update name, URL, token and enabled flag of provisioning target; empty token keeps the current one.
+// @Description  Changes made while the target is disabled are delivered after it is enabled again
+// @Tags 		 provisioning
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  						true  	"Target ID"
+// @Param 		 request 	body 		provisioning.UpdateRequest 	true 	"Target details"
+// @Success 	 200  {object}  	provisioning.TargetResponse	"Provisioning target"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      409  {object}  	http.Response				"Target name already exists"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /provisioning/targets/{id} 	[put]
+func (c *Controller) Update(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	targetID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request UpdateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an Update Provisioning target ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.provisioningService.Update(appContext, targetID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the update Provisioning target ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", targetID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// DeleteById  	 godoc
+// @Description  Delete provisioning target with its queue and sync state; resources in the target are kept
+// @Summary		 delete provisioning target by ID
+// @Tags 		 provisioning
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  						true	"Target ID"
+// @Success 	 200  {object} 		provisioning.TargetResponse	"Deleted provisioning target"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object} 	 	http.Response				"Bad request"
+// @Router 		 /provisioning/targets/{id}	[delete]
+func (c *Controller) DeleteById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	targetID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.provisioningService.DeleteById(appContext, targetID)
+	if err != nil {
+		c.logger.Error(
+			"When the delete Provisioning target ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", targetID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindOperations godoc
+// @Description  Find queued and delivered provisioning operations of target, newest first
+// @Summary 	 find provisioning operations
+// @Tags 		 provisioning
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  	true  	"Target ID"
+// @Param 		 status   	query      	string  false  	"pending, delivering, delivered or failed"
+// @Success 	 200  {array}  		provisioning.OperationResponse	"Provisioning operations"
+// @Failure      400  {object}  	http.Response					"Bad request"
+// @Failure      404  {object}  	http.Response					"Not found"
+// @Failure      500  {object}  	http.Response					"Bad request"
+// @Router 		 /provisioning/targets/{id}/operations 	[get]
+func (c *Controller) FindOperations(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	targetID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.provisioningService.FindOperations(appContext, FindOperationsRequest{
+		TargetId: targetID,
+		Status:   ctx.Query("status"),
+	})
+	if err != nil {
+		c.logger.Error(
+			"When the find Provisioning operations ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", targetID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindSyncState godoc
+// @Description  Find sync state of employees (User) and roles (Group) in provisioning target
+// @Summary 	 find provisioning sync state
+// @Tags 		 provisioning
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  						"Target ID"
+// @Success 	 200  {array}  		provisioning.SyncStateResponse	"Sync state"
+// @Failure      400  {object}  	http.Response					"Bad request"
+// @Failure      404  {object}  	http.Response					"Not found"
+// @Failure      500  {object}  	http.Response					"Bad request"
+// @Router 		 /provisioning/targets/{id}/state 	[get]
+func (c *Controller) FindSyncState(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	targetID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.provisioningService.FindSyncState(appContext, targetID)
+	if err != nil {
+		c.logger.Error(
+			"When the find Provisioning sync state ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", targetID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Reconcile 	 godoc
+// @Summary      reconcile provisioning target
+// @Description  Compare users and groups of target with local employees and roles; with apply=true
+// @Description  matches are remembered and differences are queued for delivery. Resources created in the target
+// @Description  by someone else are only reported as orphaned
+// @Tags 		 provisioning
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   	path      	int  	true  	"Target ID"
+// @Param        apply  query      	bool  	false  	"Queue differences for delivery"
+// @Success 	 200  {object}  	provisioning.ReconcileResponse	"Reconciliation result"
+// @Failure      400  {object}  	http.Response					"Bad request"
+// @Failure      404  {object}  	http.Response					"Not found"
+// @Failure      409  {object}  	http.Response					"Target type not supported"
+// @Failure      500  {object}  	http.Response					"Bad request"
+// @Failure      502  {object}  	http.Response					"Target responded with an error"
+// @Router 		 /provisioning/targets/{id}/reconcile 	[post]
+func (c *Controller) Reconcile(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	targetID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+	apply, err := strconv.ParseBool(ctx.Query("apply", "false"))
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidApplyFlag)
+	}
+
+	response, err := c.provisioningService.Reconcile(appContext, targetID, apply)
+	if err != nil {
+		c.logger.Error(
+			"When the reconcile Provisioning target ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", targetID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы; недоступность целевой системы при сверке - 502
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	var targetErr TargetError
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}), errors.As(err, &domain.ConflictError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	case errors.As(err, &targetErr):
+		return http.ErrResponse(ctx, fiber.StatusBadGateway, targetErr.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
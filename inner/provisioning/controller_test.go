@@ -0,0 +1,151 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProvisioning_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockProvisioningService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupProvisioning:     app.Group("/api/v1/provisioning"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should create target", func(t *testing.T) {
+		request := CreateRequest{Name: "Slack", BaseUrl: "https://api.slack.com/scim/v2", Token: "secret"}
+		target := TargetResponse{Id: 1, Name: "Slack", Type: TargetScim, BaseUrl: request.BaseUrl, HasToken: true, Enabled: true}
+		mockService.On("Create", appContext, request).Return(target, nil).Once()
+
+		body := `{"name": "Slack", "baseUrl": "https://api.slack.com/scim/v2", "token": "secret"}`
+		req := httptest.NewRequest("POST", "/api/v1/provisioning/targets", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var got result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.NotContains(t, string(got.Data), "secret")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when target name exists", func(t *testing.T) {
+		request := UpdateRequest{Name: "Slack", BaseUrl: "https://api.slack.com/scim/v2", Enabled: true}
+		exists := domain.AlreadyExistsError{Message: `provisioning target "Slack" already exists`}
+		mockService.On("Update", appContext, int64(2), request).Return(TargetResponse{}, exists).Once()
+
+		body := `{"name": "Slack", "baseUrl": "https://api.slack.com/scim/v2", "enabled": true}`
+		req := httptest.NewRequest("PUT", "/api/v1/provisioning/targets/2", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return failed operations", func(t *testing.T) {
+		operations := []OperationResponse{{Id: 10, TargetId: 1, ResourceType: "User", ResourceId: 7, Status: OperationFailed,
+			Attempts: 1, LastError: "target responded with status 400"}}
+		request := FindOperationsRequest{TargetId: 1, Status: OperationFailed}
+		mockService.On("FindOperations", appContext, request).Return(operations, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/provisioning/targets/1/operations?status=failed", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var got result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		var data []OperationResponse
+		require.NoError(t, json.Unmarshal(got.Data, &data))
+		assert.Equal(t, operations[0].LastError, data[0].LastError)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should reconcile with apply", func(t *testing.T) {
+		response := ReconcileResponse{TargetId: 1, Applied: true, Users: DiffResponse{Create: []int64{3}}}
+		mockService.On("Reconcile", appContext, int64(1), true).Return(response, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/provisioning/targets/1/reconcile?apply=true", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 502 when target fails during reconcile", func(t *testing.T) {
+		mockService.On("Reconcile", appContext, int64(4), false).
+			Return(ReconcileResponse{}, TargetError{StatusCode: 503}).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/provisioning/targets/4/reconcile", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadGateway, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on invalid apply flag", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/provisioning/targets/1/reconcile?apply=maybe", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("should return 404 when target not found", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "provisioning target with id 9 not found"}
+		mockService.On("DeleteById", appContext, int64(9)).Return(TargetResponse{}, notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/provisioning/targets/9", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on invalid id", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/provisioning/targets/abc/state", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
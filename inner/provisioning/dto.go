@@ -0,0 +1,228 @@
+package provisioning
+
+import (
+	"time"
+)
+
+// Типы коннекторов целевых систем
+const (
+	TargetScim = "scim" // SCIM 2.0 (RFC 7644)
+)
+
+// Состояния операции провижининга
+const (
+	OperationPending    = "pending"    // ждёт доставки или повторной попытки
+	OperationDelivering = "delivering" // захвачена доставкой до next_attempt_at
+	OperationDelivered  = "delivered"  // состояние ресурса передано
+	OperationFailed     = "failed"     // попытки исчерпаны или ошибка не исправится повтором
+)
+
+// Состояния синхронизации ресурса с целевой системой
+const (
+	SyncSynced = "synced" // последняя доставка успешна
+	SyncFailed = "failed" // последняя операция завершилась ошибкой
+)
+
+// TargetEntity - целевая система; ChangeSeq - последняя запись audit_chain, изменения по которой
+// уже поставлены в очередь
+type TargetEntity struct {
+	Id        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Type      string    `db:"type"`
+	BaseUrl   string    `db:"base_url"`
+	Token     string    `db:"token"`
+	Enabled   bool      `db:"enabled"`
+	ChangeSeq int64     `db:"change_seq"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// TargetResponse model info
+// @Description Provisioning target system; the access token is never returned
+type TargetResponse struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	BaseUrl   string    `json:"baseUrl"`
+	HasToken  bool      `json:"hasToken"`
+	Enabled   bool      `json:"enabled"`
+	ChangeSeq int64     `json:"changeSeq"`
+	CreateAt  time.Time `json:"createAt"`
+	UpdateAt  time.Time `json:"updateAt"`
+}
+
+func (e *TargetEntity) ToResponse() TargetResponse {
+	return TargetResponse{
+		Id:        e.Id,
+		Name:      e.Name,
+		Type:      e.Type,
+		BaseUrl:   e.BaseUrl,
+		HasToken:  e.Token != "",
+		Enabled:   e.Enabled,
+		ChangeSeq: e.ChangeSeq,
+		CreateAt:  e.CreatedAt,
+		UpdateAt:  e.UpdatedAt,
+	}
+}
+
+// TargetSnapshot - снимок целевой системы для аудита, без токена
+type TargetSnapshot struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	BaseUrl string `json:"baseUrl"`
+	Enabled bool   `json:"enabled"`
+}
+
+func (e *TargetEntity) ToSnapshot() TargetSnapshot {
+	return TargetSnapshot{
+		Name:    e.Name,
+		Type:    e.Type,
+		BaseUrl: e.BaseUrl,
+		Enabled: e.Enabled,
+	}
+}
+
+// OperationEntity - операция провижининга: передать текущее состояние сотрудника (User) или роли (Group)
+type OperationEntity struct {
+	Id            int64     `db:"id"`
+	TargetId      int64     `db:"target_id"`
+	ResourceType  string    `db:"resource_type"`
+	ResourceId    int64     `db:"resource_id"`
+	Status        string    `db:"status"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	LastError     string    `db:"last_error"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// OperationResponse model info
+// @Description Queued provisioning operation with delivery attempts
+type OperationResponse struct {
+	Id            int64     `json:"id"`
+	TargetId      int64     `json:"targetId"`
+	ResourceType  string    `json:"resourceType"`
+	ResourceId    int64     `json:"resourceId"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	LastError     string    `json:"lastError,omitempty"`
+	CreateAt      time.Time `json:"createAt"`
+	UpdateAt      time.Time `json:"updateAt"`
+}
+
+func (e *OperationEntity) ToResponse() OperationResponse {
+	return OperationResponse{
+		Id:            e.Id,
+		TargetId:      e.TargetId,
+		ResourceType:  e.ResourceType,
+		ResourceId:    e.ResourceId,
+		Status:        e.Status,
+		Attempts:      e.Attempts,
+		NextAttemptAt: e.NextAttemptAt,
+		LastError:     e.LastError,
+		CreateAt:      e.CreatedAt,
+		UpdateAt:      e.UpdatedAt,
+	}
+}
+
+// SyncStateEntity - состояние синхронизации ресурса с целевой системой
+type SyncStateEntity struct {
+	TargetId     int64      `db:"target_id"`
+	ResourceType string     `db:"resource_type"`
+	ResourceId   int64      `db:"resource_id"`
+	ExternalId   string     `db:"external_id"`
+	Status       string     `db:"status"`
+	LastError    string     `db:"last_error"`
+	SyncedAt     *time.Time `db:"synced_at"`
+	UpdatedAt    time.Time  `db:"updated_at"`
+}
+
+// SyncStateResponse model info
+// @Description Sync state of employee (User) or role (Group) in provisioning target
+type SyncStateResponse struct {
+	ResourceType string     `json:"resourceType"`
+	ResourceId   int64      `json:"resourceId"`
+	ExternalId   string     `json:"externalId,omitempty"`
+	Status       string     `json:"status"`
+	LastError    string     `json:"lastError,omitempty"`
+	SyncedAt     *time.Time `json:"syncedAt,omitempty"`
+	UpdateAt     time.Time  `json:"updateAt"`
+}
+
+func (e *SyncStateEntity) ToResponse() SyncStateResponse {
+	return SyncStateResponse{
+		ResourceType: e.ResourceType,
+		ResourceId:   e.ResourceId,
+		ExternalId:   e.ExternalId,
+		Status:       e.Status,
+		LastError:    e.LastError,
+		SyncedAt:     e.SyncedAt,
+		UpdateAt:     e.UpdatedAt,
+	}
+}
+
+// DiffResponse model info
+// @Description Reconciliation result for one resource type: local ids to create, update or delete
+// @Description in the target and ids of target resources unknown to this service
+type DiffResponse struct {
+	InSync   int      `json:"inSync"`
+	Create   []int64  `json:"create"`
+	Update   []int64  `json:"update"`
+	Delete   []int64  `json:"delete"`
+	Orphaned []string `json:"orphaned"` // id в целевой системе: ресурс создан не нами, не удаляется
+}
+
+// ReconcileResponse model info
+// @Description Difference between provisioning target and local employees and roles;
+// @Description with apply=true the differences are queued for delivery
+type ReconcileResponse struct {
+	TargetId int64        `json:"targetId"`
+	Applied  bool         `json:"applied"`
+	Users    DiffResponse `json:"users"`
+	Groups   DiffResponse `json:"groups"`
+}
+
+// CreateRequest model info
+// @Description Request to register provisioning target
+type CreateRequest struct {
+	Name    string `json:"name" validate:"required,min=2,max=155"`
+	Type    string `json:"type" validate:"omitempty,oneof=scim"` // по умолчанию scim
+	BaseUrl string `json:"baseUrl" validate:"required,url,max=500"`
+	Token   string `json:"token" validate:"max=2000"`
+	Enabled *bool  `json:"enabled"` // по умолчанию true
+}
+
+func (req *CreateRequest) ToEntity() TargetEntity {
+	var entity = TargetEntity{
+		Name:    req.Name,
+		Type:    req.Type,
+		BaseUrl: req.BaseUrl,
+		Token:   req.Token,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if entity.Type == "" {
+		entity.Type = TargetScim
+	}
+	return entity
+}
+
+// UpdateRequest model info
+// @Description Request to update provisioning target; empty token keeps the current one
+type UpdateRequest struct {
+	Id      int64  `json:"-" validate:"required,min=1"`
+	Name    string `json:"name" validate:"required,min=2,max=155"`
+	BaseUrl string `json:"baseUrl" validate:"required,url,max=500"`
+	Token   string `json:"token" validate:"max=2000"`
+	Enabled bool   `json:"enabled"`
+}
+
+// FindOperationsRequest - отбор операций целевой системы по состоянию
+type FindOperationsRequest struct {
+	TargetId int64  `validate:"required,min=1"`
+	Status   string `validate:"omitempty,oneof=pending delivering delivered failed"`
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
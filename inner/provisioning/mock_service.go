@@ -0,0 +1,50 @@
+package provisioning
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProvisioningService struct {
+	mock.Mock
+}
+
+func (m *MockProvisioningService) FindAll(ctx context.Context) ([]TargetResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]TargetResponse), args.Error(1)
+}
+
+func (m *MockProvisioningService) FindById(ctx context.Context, id int64) (TargetResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(TargetResponse), args.Error(1)
+}
+
+func (m *MockProvisioningService) Create(ctx context.Context, request CreateRequest) (TargetResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(TargetResponse), args.Error(1)
+}
+
+func (m *MockProvisioningService) Update(ctx context.Context, id int64, request UpdateRequest) (TargetResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(TargetResponse), args.Error(1)
+}
+
+func (m *MockProvisioningService) DeleteById(ctx context.Context, id int64) (TargetResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(TargetResponse), args.Error(1)
+}
+
+func (m *MockProvisioningService) FindOperations(ctx context.Context, request FindOperationsRequest) ([]OperationResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).([]OperationResponse), args.Error(1)
+}
+
+func (m *MockProvisioningService) FindSyncState(ctx context.Context, targetId int64) ([]SyncStateResponse, error) {
+	args := m.Called(ctx, targetId)
+	return args.Get(0).([]SyncStateResponse), args.Error(1)
+}
+
+func (m *MockProvisioningService) Reconcile(ctx context.Context, targetId int64, apply bool) (ReconcileResponse, error) {
+	args := m.Called(ctx, targetId, apply)
+	return args.Get(0).(ReconcileResponse), args.Error(1)
+}
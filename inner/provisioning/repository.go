@@ -0,0 +1,352 @@
+package provisioning
+
+import (
+	"cmp"
+	"context"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"slices"
+	"time"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindAllTargets - все целевые системы по имени
+func (r *Repository) FindAllTargets(ctx context.Context) (targets []TargetEntity, err error) {
+	err = r.db.SelectContext(ctx, &targets, "SELECT * FROM provisioning_targets ORDER BY name, id")
+
+	return targets, err
+}
+
+// FindTargetById - найти целевую систему по id
+func (r *Repository) FindTargetById(ctx context.Context, id int64) (target TargetEntity, err error) {
+	err = r.db.GetContext(ctx, &target, "SELECT * FROM provisioning_targets WHERE id = $1", id)
+
+	return target, err
+}
+
+// ExistsTargetByName - проверить, занято ли имя другой целевой системой
+func (r *Repository) ExistsTargetByName(ctx context.Context, name string, exceptId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM provisioning_targets WHERE name = $1 AND id <> $2)",
+		name, exceptId,
+	)
+
+	return isExists, err
+}
+
+// CreateTarget - добавить целевую систему. Очередь для неё начинается с текущего конца audit_chain:
+// то, что изменилось раньше, передаёт сверка
+func (r *Repository) CreateTarget(ctx context.Context, entity *TargetEntity) (created TargetEntity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(
+			ctx,
+			&created,
+			`INSERT INTO provisioning_targets (name, type, base_url, token, enabled, change_seq, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, (SELECT COALESCE(MAX(seq), 0) FROM audit_chain), NOW(), NOW())
+			RETURNING *`,
+			entity.Name, entity.Type, entity.BaseUrl, entity.Token, entity.Enabled,
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityProvisioningTarget,
+			EntityId:   created.Id,
+			After:      created.ToSnapshot(),
+		})
+	})
+
+	return created, err
+}
+
+// UpdateTarget - изменить целевую систему; пустой токен сохраняет текущий
+func (r *Repository) UpdateTarget(ctx context.Context, entity *TargetEntity) (updated TargetEntity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before TargetEntity
+		err := tx.GetContext(ctx, &before, "SELECT * FROM provisioning_targets WHERE id = $1 FOR UPDATE", entity.Id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.GetContext(
+			ctx,
+			&updated,
+			`UPDATE provisioning_targets
+			SET name = $2, base_url = $3, token = COALESCE(NULLIF($4, ''), token), enabled = $5, updated_at = NOW()
+			WHERE id = $1
+			RETURNING *`,
+			entity.Id, entity.Name, entity.BaseUrl, entity.Token, entity.Enabled,
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityProvisioningTarget,
+			EntityId:   updated.Id,
+			Before:     before.ToSnapshot(),
+			After:      updated.ToSnapshot(),
+		})
+	})
+
+	return updated, err
+}
+
+// DeleteTarget - удалить целевую систему вместе с очередью и состоянием синхронизации,
+// возвращает false если её не было
+func (r *Repository) DeleteTarget(ctx context.Context, id int64) (isDeleted bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var deleted TargetEntity
+		err := tx.GetContext(ctx, &deleted, "DELETE FROM provisioning_targets WHERE id = $1 RETURNING *", id)
+		if err != nil {
+			return err
+		}
+
+		isDeleted = true
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityProvisioningTarget,
+			EntityId:   id,
+			Before:     deleted.ToSnapshot(),
+		})
+	})
+
+	return isDeleted && err == nil, err
+}
+
+// CaptureChanges - поставить в очередь включённых целевых систем изменения сотрудников, ролей и назначений
+// из audit_chain после их change_seq. Номера audit_chain выдаются под блокировкой до конца транзакции,
+// поэтому все записи до прочитанного конца цепочки уже видны и ни одна не пропускается.
+// Операция на ресурс, который уже ждёт доставки, не дублируется: доставка передаёт текущее состояние
+func (r *Repository) CaptureChanges(ctx context.Context) (captured int64, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var head int64
+		err := tx.GetContext(ctx, &head, "SELECT COALESCE(MAX(seq), 0) FROM audit_chain")
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(
+			ctx,
+			`WITH targets AS (
+				SELECT id, change_seq FROM provisioning_targets
+				WHERE enabled AND change_seq < $1
+				FOR UPDATE
+			), changes AS (
+				SELECT DISTINCT t.id AS target_id,
+					CASE WHEN c.entity_type = 'employee' THEN 'User' ELSE 'Group' END AS resource_type,
+					CASE WHEN c.entity_type = 'employee_role'
+						THEN (COALESCE(c.after_data, c.before_data)::jsonb ->> 'roleId')::bigint
+						ELSE c.entity_id
+					END AS resource_id
+				FROM targets t
+				JOIN audit_chain c ON c.seq > t.change_seq AND c.seq <= $1
+				WHERE c.entity_type IN ('employee', 'role', 'employee_role')
+			)
+			INSERT INTO provisioning_operations (target_id, resource_type, resource_id, status, next_attempt_at, created_at, updated_at)
+			SELECT ch.target_id, ch.resource_type, ch.resource_id, 'pending', NOW(), NOW(), NOW()
+			FROM changes ch
+			WHERE ch.resource_id IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM provisioning_operations o
+				WHERE o.target_id = ch.target_id AND o.resource_type = ch.resource_type
+					AND o.resource_id = ch.resource_id AND o.status = 'pending'
+			)`,
+			head,
+		)
+		if err != nil {
+			return err
+		}
+		if captured, err = result.RowsAffected(); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE provisioning_targets SET change_seq = $1 WHERE enabled AND change_seq < $1",
+			head,
+		)
+		return err
+	})
+
+	return captured, err
+}
+
+// Enqueue - поставить операцию в очередь, если такой же ещё не ждёт доставки
+func (r *Repository) Enqueue(ctx context.Context, targetId int64, resourceType string, resourceId int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO provisioning_operations (target_id, resource_type, resource_id, status, next_attempt_at, created_at, updated_at)
+		SELECT $1::bigint, $2::varchar, $3::bigint, 'pending', NOW(), NOW(), NOW()
+		WHERE NOT EXISTS (
+			SELECT 1 FROM provisioning_operations
+			WHERE target_id = $1 AND resource_type = $2 AND resource_id = $3 AND status = 'pending'
+		)`,
+		targetId, resourceType, resourceId,
+	)
+
+	return err
+}
+
+// ClaimDue - захватить до limit операций, срок попытки которых наступил, до now + lease.
+// Операцию, захват которой истёк (доставка прервалась), можно захватить снова
+func (r *Repository) ClaimDue(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) (operations []OperationEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&operations,
+		`UPDATE provisioning_operations o
+		SET status = 'delivering', next_attempt_at = $2, updated_at = $1
+		FROM (
+			SELECT op.id FROM provisioning_operations op
+			JOIN provisioning_targets t ON t.id = op.target_id AND t.enabled
+			WHERE op.status IN ('pending', 'delivering') AND op.next_attempt_at <= $1
+			ORDER BY op.id
+			LIMIT $3
+			FOR UPDATE OF op SKIP LOCKED
+		) due
+		WHERE o.id = due.id
+		RETURNING o.*`,
+		now, now.Add(lease), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sortOperations(operations)
+
+	return operations, nil
+}
+
+// FinishOperation - записать исход попытки доставки: состояние, число попыток, время следующей и ошибку
+func (r *Repository) FinishOperation(ctx context.Context, operation *OperationEntity) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE provisioning_operations
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, updated_at = NOW()
+		WHERE id = $1`,
+		operation.Id, operation.Status, operation.Attempts, operation.NextAttemptAt, truncate(operation.LastError),
+	)
+
+	return err
+}
+
+// FindOperations - операции целевой системы, новые первыми; пустой status - все
+func (r *Repository) FindOperations(
+	ctx context.Context,
+	targetId int64,
+	status string,
+) (operations []OperationEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&operations,
+		`SELECT * FROM provisioning_operations
+		WHERE target_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC`,
+		targetId, status,
+	)
+
+	return operations, err
+}
+
+// FindSyncState - состояние синхронизации ресурса
+func (r *Repository) FindSyncState(
+	ctx context.Context,
+	targetId int64,
+	resourceType string,
+	resourceId int64,
+) (state SyncStateEntity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&state,
+		"SELECT * FROM provisioning_sync_state WHERE target_id = $1 AND resource_type = $2 AND resource_id = $3",
+		targetId, resourceType, resourceId,
+	)
+
+	return state, err
+}
+
+// FindSyncStates - состояние синхронизации ресурсов целевой системы; пустой resourceType - все типы
+func (r *Repository) FindSyncStates(
+	ctx context.Context,
+	targetId int64,
+	resourceType string,
+) (states []SyncStateEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&states,
+		`SELECT * FROM provisioning_sync_state
+		WHERE target_id = $1 AND ($2 = '' OR resource_type = $2)
+		ORDER BY resource_type DESC, resource_id`,
+		targetId, resourceType,
+	)
+
+	return states, err
+}
+
+// SaveSyncState - записать состояние синхронизации ресурса. Пустой external_id не затирает известный:
+// ошибка доставки не делает ресурс в целевой системе неизвестным
+func (r *Repository) SaveSyncState(ctx context.Context, state *SyncStateEntity) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO provisioning_sync_state
+			(target_id, resource_type, resource_id, external_id, status, last_error, synced_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (target_id, resource_type, resource_id) DO UPDATE
+		SET external_id = COALESCE(NULLIF(EXCLUDED.external_id, ''), provisioning_sync_state.external_id),
+			status = EXCLUDED.status,
+			last_error = EXCLUDED.last_error,
+			synced_at = COALESCE(EXCLUDED.synced_at, provisioning_sync_state.synced_at),
+			updated_at = NOW()`,
+		state.TargetId, state.ResourceType, state.ResourceId, state.ExternalId, state.Status,
+		truncate(state.LastError), state.SyncedAt,
+	)
+
+	return err
+}
+
+// DeleteSyncState - забыть ресурс, удалённый из целевой системы
+func (r *Repository) DeleteSyncState(ctx context.Context, targetId int64, resourceType string, resourceId int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM provisioning_sync_state WHERE target_id = $1 AND resource_type = $2 AND resource_id = $3",
+		targetId, resourceType, resourceId,
+	)
+
+	return err
+}
+
+// maxErrorLength - длина колонок last_error
+const maxErrorLength = 1000
+
+// truncate - текст ошибки в пределах колонки, по границе символа
+func truncate(message string) string {
+	var runes = []rune(message)
+	if len(runes) <= maxErrorLength {
+		return message
+	}
+	return string(runes[:maxErrorLength])
+}
+
+// sortOperations - UPDATE ... RETURNING не сохраняет порядок: доставка идёт в порядке постановки в очередь
+func sortOperations(operations []OperationEntity) {
+	slices.SortFunc(operations, func(a, b OperationEntity) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+}
@@ -0,0 +1,814 @@
+package provisioning
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"idm/inner/domain"
+	"idm/inner/retry"
+	"idm/inner/scim"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Доставка операций
+const (
+	MaxAttempts    = 8                // после стольких неудачных попыток операция завершается с ошибкой
+	retryBaseDelay = 30 * time.Second // пауза перед второй попыткой, далее удваивается
+	retryMaxDelay  = time.Hour
+	deliveryBatch  = 100             // операций за один запуск доставки
+	deliveryLease  = 5 * time.Minute // на столько доставка захватывает операцию
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+	source    Source
+	connect   ConnectorFactory
+}
+
+type Repo interface {
+	FindAllTargets(ctx context.Context) ([]TargetEntity, error)
+	FindTargetById(ctx context.Context, id int64) (TargetEntity, error)
+	ExistsTargetByName(ctx context.Context, name string, exceptId int64) (bool, error)
+	CreateTarget(ctx context.Context, entity *TargetEntity) (TargetEntity, error)
+	UpdateTarget(ctx context.Context, entity *TargetEntity) (TargetEntity, error)
+	DeleteTarget(ctx context.Context, id int64) (bool, error)
+	CaptureChanges(ctx context.Context) (int64, error)
+	Enqueue(ctx context.Context, targetId int64, resourceType string, resourceId int64) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]OperationEntity, error)
+	FinishOperation(ctx context.Context, operation *OperationEntity) error
+	FindOperations(ctx context.Context, targetId int64, status string) ([]OperationEntity, error)
+	FindSyncState(ctx context.Context, targetId int64, resourceType string, resourceId int64) (SyncStateEntity, error)
+	FindSyncStates(ctx context.Context, targetId int64, resourceType string) ([]SyncStateEntity, error)
+	SaveSyncState(ctx context.Context, state *SyncStateEntity) error
+	DeleteSyncState(ctx context.Context, targetId int64, resourceType string, resourceId int64) error
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// Source - локальные сотрудники и роли в представлении SCIM (scim.Service)
+type Source interface {
+	ListUsers(ctx context.Context, request scim.ListRequest) (scim.ListResponse, error)
+	GetUser(ctx context.Context, id string) (scim.User, error)
+	ListGroups(ctx context.Context, request scim.ListRequest) (scim.ListResponse, error)
+	GetGroup(ctx context.Context, id string) (scim.Group, error)
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator, source Source, connect ConnectorFactory) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+		source:    source,
+		connect:   connect,
+	}
+}
+
+// FindAll - все целевые системы
+func (svc *Service) FindAll(ctx context.Context) ([]TargetResponse, error) {
+	entities, err := svc.repo.FindAllTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding provisioning targets: %w", err)
+	}
+
+	responses := make([]TargetResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) FindById(ctx context.Context, id int64) (TargetResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return TargetResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findTarget(ctx, id)
+	if err != nil {
+		return TargetResponse{}, err
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// Create - зарегистрировать целевую систему. Изменения, сделанные до регистрации, передаёт сверка
+func (svc *Service) Create(ctx context.Context, request CreateRequest) (TargetResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return TargetResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if err := svc.checkName(ctx, request.Name, 0); err != nil {
+		return TargetResponse{}, err
+	}
+
+	entity := request.ToEntity()
+	created, err := svc.repo.CreateTarget(ctx, &entity)
+	if err != nil {
+		return TargetResponse{}, fmt.Errorf("error creating provisioning target: %w", err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+// Update - изменить адрес, токен и включение целевой системы
+func (svc *Service) Update(ctx context.Context, id int64, request UpdateRequest) (TargetResponse, error) {
+	request.Id = id
+	if err := svc.validator.Validate(request); err != nil {
+		return TargetResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if _, err := svc.findTarget(ctx, id); err != nil {
+		return TargetResponse{}, err
+	}
+	if err := svc.checkName(ctx, request.Name, id); err != nil {
+		return TargetResponse{}, err
+	}
+
+	var entity = TargetEntity{
+		Id:      id,
+		Name:    request.Name,
+		BaseUrl: request.BaseUrl,
+		Token:   request.Token,
+		Enabled: request.Enabled,
+	}
+	updated, err := svc.repo.UpdateTarget(ctx, &entity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TargetResponse{}, targetNotFound(id)
+	}
+	if err != nil {
+		return TargetResponse{}, fmt.Errorf("error updating provisioning target %d: %w", id, err)
+	}
+
+	return updated.ToResponse(), nil
+}
+
+// DeleteById - удалить целевую систему вместе с очередью; ресурсы в ней остаются как есть
+func (svc *Service) DeleteById(ctx context.Context, id int64) (TargetResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return TargetResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	target, err := svc.findTarget(ctx, id)
+	if err != nil {
+		return TargetResponse{}, err
+	}
+
+	isDeleted, err := svc.repo.DeleteTarget(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TargetResponse{}, targetNotFound(id)
+	}
+	if err != nil {
+		return TargetResponse{}, fmt.Errorf("error deleting provisioning target %d: %w", id, err)
+	}
+	if !isDeleted {
+		return TargetResponse{}, targetNotFound(id)
+	}
+
+	return target.ToResponse(), nil
+}
+
+// FindOperations - очередь операций целевой системы
+func (svc *Service) FindOperations(ctx context.Context, request FindOperationsRequest) ([]OperationResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+	if _, err := svc.findTarget(ctx, request.TargetId); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindOperations(ctx, request.TargetId, request.Status)
+	if err != nil {
+		return nil, fmt.Errorf("error finding operations of provisioning target %d: %w", request.TargetId, err)
+	}
+
+	responses := make([]OperationResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// FindSyncState - состояние синхронизации сотрудников и ролей с целевой системой
+func (svc *Service) FindSyncState(ctx context.Context, targetId int64) ([]SyncStateResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: targetId}); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+	if _, err := svc.findTarget(ctx, targetId); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindSyncStates(ctx, targetId, "")
+	if err != nil {
+		return nil, fmt.Errorf("error finding sync state of provisioning target %d: %w", targetId, err)
+	}
+
+	responses := make([]SyncStateResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// CaptureChanges - поставить в очередь изменения сотрудников, ролей и назначений из журнала аудита
+func (svc *Service) CaptureChanges(ctx context.Context) (int64, error) {
+	captured, err := svc.repo.CaptureChanges(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error capturing changes for provisioning: %w", err)
+	}
+
+	return captured, nil
+}
+
+// Deliver - доставить операции, срок которых наступил. Неудачная попытка откладывается с удвоением паузы;
+// после MaxAttempts попыток или ошибки, которую повтор не исправит, операция завершается с ошибкой.
+// Возвращает операции с исходом попытки
+func (svc *Service) Deliver(ctx context.Context) ([]OperationResponse, error) {
+	operations, err := svc.repo.ClaimDue(ctx, time.Now(), deliveryLease, deliveryBatch)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming provisioning operations: %w", err)
+	}
+
+	var deliveries = map[int64]*delivery{}
+	var results = make([]OperationResponse, 0, len(operations))
+	var errs []error
+	for _, operation := range operations {
+		if ctx.Err() != nil {
+			// незавершённые операции доставит следующий запуск, когда истечёт захват
+			break
+		}
+
+		d, err := svc.deliveryTo(ctx, deliveries, operation.TargetId)
+		if err == nil {
+			err = d.sync(ctx, operation.ResourceType, operation.ResourceId)
+		}
+		if err = svc.finish(ctx, &operation, err); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, operation.ToResponse())
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// Reconcile - сравнить пользователей и группы целевой системы с сотрудниками и ролями. Ресурсы сопоставляются
+// по сохранённому id, затем по externalId, затем по userName (displayName для групп). С apply=true найденные
+// соответствия запоминаются, а расхождения ставятся в очередь; ресурсы, созданные в целевой системе не нами,
+// только перечисляются
+func (svc *Service) Reconcile(ctx context.Context, targetId int64, apply bool) (ReconcileResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: targetId}); err != nil {
+		return ReconcileResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	target, err := svc.findTarget(ctx, targetId)
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	connector, err := svc.connect(target)
+	if err != nil {
+		return ReconcileResponse{}, domain.ConflictError{Message: err.Error()}
+	}
+
+	users, err := svc.reconcileUsers(ctx, target, connector)
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	groups, err := svc.reconcileGroups(ctx, target, connector, users.externalIds)
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+
+	var response = ReconcileResponse{
+		TargetId: target.Id,
+		Applied:  apply,
+		Users:    users.DiffResponse,
+		Groups:   groups.DiffResponse,
+	}
+	if !apply {
+		return response, nil
+	}
+	for _, result := range []reconcileResult{users, groups} {
+		if err := svc.applyReconcile(ctx, target.Id, result); err != nil {
+			return ReconcileResponse{}, err
+		}
+	}
+
+	return response, nil
+}
+
+// ReconcileAll - сверка всех включённых целевых систем с постановкой расхождений в очередь.
+// Ошибка одной целевой системы не останавливает сверку остальных
+func (svc *Service) ReconcileAll(ctx context.Context) ([]ReconcileResponse, error) {
+	targets, err := svc.repo.FindAllTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding provisioning targets: %w", err)
+	}
+
+	var responses []ReconcileResponse
+	var errs []error
+	for _, target := range targets {
+		if !target.Enabled {
+			continue
+		}
+		response, err := svc.Reconcile(ctx, target.Id, true)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error reconciling provisioning target %d: %w", target.Id, err))
+			continue
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, errors.Join(errs...)
+}
+
+// finish - записать исход попытки доставки; ошибка доставки записывается и в состояние синхронизации
+func (svc *Service) finish(ctx context.Context, operation *OperationEntity, deliveryErr error) error {
+	operation.Attempts++
+	operation.LastError = ""
+	switch {
+	case deliveryErr == nil:
+		operation.Status = OperationDelivered
+	case !IsRetryable(deliveryErr) || operation.Attempts >= MaxAttempts:
+		operation.Status = OperationFailed
+		operation.LastError = deliveryErr.Error()
+	default:
+		operation.Status = OperationPending
+		operation.NextAttemptAt = time.Now().Add(retry.Delay(operation.Attempts, retryBaseDelay, retryMaxDelay))
+		operation.LastError = deliveryErr.Error()
+	}
+
+	if err := svc.repo.FinishOperation(ctx, operation); err != nil {
+		return fmt.Errorf("error finishing provisioning operation %d: %w", operation.Id, err)
+	}
+	if deliveryErr == nil {
+		return nil
+	}
+
+	err := svc.repo.SaveSyncState(ctx, &SyncStateEntity{
+		TargetId:     operation.TargetId,
+		ResourceType: operation.ResourceType,
+		ResourceId:   operation.ResourceId,
+		Status:       SyncFailed,
+		LastError:    deliveryErr.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("error saving sync state of provisioning operation %d: %w", operation.Id, err)
+	}
+	return nil
+}
+
+// deliveryTo - доставка в целевую систему, одна на запуск
+func (svc *Service) deliveryTo(ctx context.Context, deliveries map[int64]*delivery, targetId int64) (*delivery, error) {
+	if d, ok := deliveries[targetId]; ok {
+		return d, nil
+	}
+
+	target, err := svc.findTarget(ctx, targetId)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := svc.connect(target)
+	if err != nil {
+		// тип целевой системы не поддерживается - повтор не поможет
+		return nil, fmt.Errorf("%w: %w", ErrNotRetryable, err)
+	}
+
+	var d = &delivery{svc: svc, target: target, connector: connector}
+	deliveries[targetId] = d
+	return d, nil
+}
+
+// delivery - передача текущего состояния сотрудников и ролей в одну целевую систему
+type delivery struct {
+	svc       *Service
+	target    TargetEntity
+	connector Connector
+}
+
+func (d *delivery) sync(ctx context.Context, resourceType string, resourceId int64) error {
+	switch resourceType {
+	case scim.ResourceUser:
+		_, err := d.syncUser(ctx, resourceId)
+		return err
+	case scim.ResourceGroup:
+		return d.syncGroup(ctx, resourceId)
+	default:
+		return fmt.Errorf("%w: unknown resource type %q", ErrNotRetryable, resourceType)
+	}
+}
+
+// syncUser - создать или заменить пользователя; удалённого сотрудника - удалить.
+// Возвращает id пользователя в целевой системе
+func (d *delivery) syncUser(ctx context.Context, employeeId int64) (string, error) {
+	state, err := d.syncState(ctx, scim.ResourceUser, employeeId)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := d.svc.source.GetUser(ctx, formatId(employeeId))
+	if errors.As(err, &domain.NotFoundError{}) {
+		return "", d.remove(ctx, scim.ResourceUser, employeeId, state.ExternalId, d.connector.DeleteUser)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting employee %d: %w", employeeId, err)
+	}
+
+	externalId, err := d.connector.UpsertUser(ctx, state.ExternalId, outboundUser(user))
+	if err != nil {
+		return "", err
+	}
+
+	return externalId, d.synced(ctx, scim.ResourceUser, employeeId, externalId)
+}
+
+// syncGroup - создать или заменить группу; участники, ещё не переданные в целевую систему, передаются первыми
+func (d *delivery) syncGroup(ctx context.Context, roleId int64) error {
+	state, err := d.syncState(ctx, scim.ResourceGroup, roleId)
+	if err != nil {
+		return err
+	}
+
+	group, err := d.svc.source.GetGroup(ctx, formatId(roleId))
+	if errors.As(err, &domain.NotFoundError{}) {
+		return d.remove(ctx, scim.ResourceGroup, roleId, state.ExternalId, d.connector.DeleteGroup)
+	}
+	if err != nil {
+		return fmt.Errorf("error getting role %d: %w", roleId, err)
+	}
+
+	var members = make([]scim.Member, 0, len(group.Members))
+	for _, member := range group.Members {
+		externalId, err := d.userExternalId(ctx, member.Value)
+		if err != nil {
+			return fmt.Errorf("error provisioning member %s of role %d: %w", member.Value, roleId, err)
+		}
+		members = append(members, scim.Member{Value: externalId})
+	}
+
+	externalId, err := d.connector.UpsertGroup(ctx, state.ExternalId, outboundGroup(group, members))
+	if err != nil {
+		return err
+	}
+
+	return d.synced(ctx, scim.ResourceGroup, roleId, externalId)
+}
+
+// userExternalId - id участника группы в целевой системе
+func (d *delivery) userExternalId(ctx context.Context, memberId string) (string, error) {
+	employeeId, err := strconv.ParseInt(memberId, 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := d.syncState(ctx, scim.ResourceUser, employeeId)
+	if err != nil {
+		return "", err
+	}
+	if state.Status == SyncSynced && state.ExternalId != "" {
+		return state.ExternalId, nil
+	}
+
+	return d.syncUser(ctx, employeeId)
+}
+
+// syncState - состояние синхронизации ресурса; ресурс, который ещё не передавался, - пустое состояние
+func (d *delivery) syncState(ctx context.Context, resourceType string, resourceId int64) (SyncStateEntity, error) {
+	state, err := d.svc.repo.FindSyncState(ctx, d.target.Id, resourceType, resourceId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SyncStateEntity{}, nil
+	}
+	if err != nil {
+		return SyncStateEntity{}, fmt.Errorf("error finding sync state of %s %d: %w", resourceType, resourceId, err)
+	}
+	return state, nil
+}
+
+func (d *delivery) synced(ctx context.Context, resourceType string, resourceId int64, externalId string) error {
+	var now = time.Now()
+	err := d.svc.repo.SaveSyncState(ctx, &SyncStateEntity{
+		TargetId:     d.target.Id,
+		ResourceType: resourceType,
+		ResourceId:   resourceId,
+		ExternalId:   externalId,
+		Status:       SyncSynced,
+		SyncedAt:     &now,
+	})
+	if err != nil {
+		return fmt.Errorf("error saving sync state of %s %d: %w", resourceType, resourceId, err)
+	}
+	return nil
+}
+
+// remove - удалить ресурс из целевой системы, если он там был, и забыть его
+func (d *delivery) remove(
+	ctx context.Context,
+	resourceType string,
+	resourceId int64,
+	externalId string,
+	remove func(ctx context.Context, externalId string) error,
+) error {
+	if externalId != "" {
+		if err := remove(ctx, externalId); err != nil {
+			return err
+		}
+	}
+	if err := d.svc.repo.DeleteSyncState(ctx, d.target.Id, resourceType, resourceId); err != nil {
+		return fmt.Errorf("error deleting sync state of %s %d: %w", resourceType, resourceId, err)
+	}
+	return nil
+}
+
+// outboundUser - пользователь для целевой системы: наш id передаётся как externalId,
+// группы целевая система выводит из участников групп
+func outboundUser(user scim.User) scim.User {
+	user.ExternalId = user.Id
+	user.Id = ""
+	user.Groups = nil
+	user.Meta = nil
+	return user
+}
+
+// outboundGroup - группа для целевой системы с участниками по их id в целевой системе
+func outboundGroup(group scim.Group, members []scim.Member) scim.Group {
+	group.ExternalId = group.Id
+	group.Id = ""
+	group.Members = members
+	group.Meta = nil
+	return group
+}
+
+// reconcileResult - расхождения одного типа ресурсов и соответствия, найденные сверкой
+type reconcileResult struct {
+	DiffResponse
+	resourceType string
+	adopted      []SyncStateEntity
+	externalIds  map[string]string // id сотрудника -> id пользователя в целевой системе
+}
+
+// reconciled - ресурс в общем для сверки виде: id, имя для сопоставления и отпечаток сравниваемых атрибутов
+type reconciled struct {
+	id          string
+	externalId  string
+	key         string
+	fingerprint string
+}
+
+func (svc *Service) reconcileUsers(ctx context.Context, target TargetEntity, connector Connector) (reconcileResult, error) {
+	locals, err := listLocal[scim.User](ctx, svc.source.ListUsers)
+	if err != nil {
+		return reconcileResult{}, fmt.Errorf("error listing local users: %w", err)
+	}
+	remotes, err := connector.ListUsers(ctx)
+	if err != nil {
+		return reconcileResult{}, fmt.Errorf("error listing users of provisioning target %d: %w", target.Id, err)
+	}
+
+	var local = make([]reconciled, 0, len(locals))
+	for _, user := range locals {
+		local = append(local, reconciled{id: user.Id, key: user.UserName, fingerprint: userFingerprint(user)})
+	}
+	var remote = make([]reconciled, 0, len(remotes))
+	for _, user := range remotes {
+		remote = append(remote, reconciled{
+			id:          user.Id,
+			externalId:  user.ExternalId,
+			key:         user.UserName,
+			fingerprint: userFingerprint(user),
+		})
+	}
+
+	return svc.diff(ctx, target.Id, scim.ResourceUser, local, remote)
+}
+
+// reconcileGroups - участники сравниваются по id в целевой системе; участник, которого там ещё нет,
+// даёт расхождение, и доставка группы передаст его
+func (svc *Service) reconcileGroups(
+	ctx context.Context,
+	target TargetEntity,
+	connector Connector,
+	userIds map[string]string,
+) (reconcileResult, error) {
+	locals, err := listLocal[scim.Group](ctx, svc.source.ListGroups)
+	if err != nil {
+		return reconcileResult{}, fmt.Errorf("error listing local groups: %w", err)
+	}
+	remotes, err := connector.ListGroups(ctx)
+	if err != nil {
+		return reconcileResult{}, fmt.Errorf("error listing groups of provisioning target %d: %w", target.Id, err)
+	}
+
+	var local = make([]reconciled, 0, len(locals))
+	for _, group := range locals {
+		var members = make([]string, 0, len(group.Members))
+		for _, member := range group.Members {
+			externalId, ok := userIds[member.Value]
+			if !ok {
+				externalId = "local:" + member.Value
+			}
+			members = append(members, externalId)
+		}
+		local = append(local, reconciled{id: group.Id, key: group.DisplayName, fingerprint: groupFingerprint(group, members)})
+	}
+	var remote = make([]reconciled, 0, len(remotes))
+	for _, group := range remotes {
+		var members = make([]string, 0, len(group.Members))
+		for _, member := range group.Members {
+			members = append(members, member.Value)
+		}
+		remote = append(remote, reconciled{
+			id:          group.Id,
+			externalId:  group.ExternalId,
+			key:         group.DisplayName,
+			fingerprint: groupFingerprint(group, members),
+		})
+	}
+
+	return svc.diff(ctx, target.Id, scim.ResourceGroup, local, remote)
+}
+
+// diff - сопоставить локальные ресурсы с ресурсами целевой системы
+func (svc *Service) diff(
+	ctx context.Context,
+	targetId int64,
+	resourceType string,
+	local []reconciled,
+	remote []reconciled,
+) (reconcileResult, error) {
+	states, err := svc.repo.FindSyncStates(ctx, targetId, resourceType)
+	if err != nil {
+		return reconcileResult{}, fmt.Errorf("error finding sync state of provisioning target %d: %w", targetId, err)
+	}
+	var stateByResource = map[string]SyncStateEntity{}
+	var stateByExternal = map[string]SyncStateEntity{}
+	for _, state := range states {
+		stateByResource[formatId(state.ResourceId)] = state
+		if state.ExternalId != "" {
+			stateByExternal[state.ExternalId] = state
+		}
+	}
+
+	var remoteById = map[string]reconciled{}
+	var remoteByExternalId = map[string]reconciled{}
+	var remoteByKey = map[string]reconciled{}
+	for _, r := range remote {
+		remoteById[r.id] = r
+		if r.externalId != "" {
+			remoteByExternalId[r.externalId] = r
+		}
+		remoteByKey[strings.ToLower(r.key)] = r
+	}
+
+	var result = reconcileResult{
+		DiffResponse: DiffResponse{Create: []int64{}, Update: []int64{}, Delete: []int64{}, Orphaned: []string{}},
+		resourceType: resourceType,
+		externalIds:  map[string]string{},
+	}
+	var matched = map[string]bool{}
+	for _, l := range local {
+		resourceId, err := strconv.ParseInt(l.id, 10, 64)
+		if err != nil {
+			return reconcileResult{}, fmt.Errorf("error parsing %s id %q: %w", resourceType, l.id, err)
+		}
+
+		state, hasState := stateByResource[l.id]
+		r, ok := remoteById[state.ExternalId]
+		if !hasState || !ok {
+			if r, ok = remoteByExternalId[l.id]; !ok {
+				r, ok = remoteByKey[strings.ToLower(l.key)]
+			}
+		}
+		if !ok || matched[r.id] {
+			result.Create = append(result.Create, resourceId)
+			continue
+		}
+
+		matched[r.id] = true
+		result.externalIds[l.id] = r.id
+		if !hasState || state.ExternalId != r.id {
+			result.adopted = append(result.adopted, SyncStateEntity{
+				TargetId:     targetId,
+				ResourceType: resourceType,
+				ResourceId:   resourceId,
+				ExternalId:   r.id,
+				Status:       SyncSynced,
+			})
+		}
+		if l.fingerprint != r.fingerprint {
+			result.Update = append(result.Update, resourceId)
+		} else {
+			result.InSync++
+		}
+	}
+
+	for _, r := range remote {
+		if matched[r.id] {
+			continue
+		}
+		if state, ok := stateByExternal[r.id]; ok {
+			// передан нами, а локально ресурса уже нет
+			result.Delete = append(result.Delete, state.ResourceId)
+			continue
+		}
+		result.Orphaned = append(result.Orphaned, r.id)
+	}
+
+	return result, nil
+}
+
+// applyReconcile - запомнить найденные соответствия и поставить расхождения в очередь
+func (svc *Service) applyReconcile(ctx context.Context, targetId int64, result reconcileResult) error {
+	for _, state := range result.adopted {
+		if err := svc.repo.SaveSyncState(ctx, &state); err != nil {
+			return fmt.Errorf("error saving sync state of %s %d: %w", state.ResourceType, state.ResourceId, err)
+		}
+	}
+	for _, resourceId := range slices.Concat(result.Create, result.Update, result.Delete) {
+		if err := svc.repo.Enqueue(ctx, targetId, result.resourceType, resourceId); err != nil {
+			return fmt.Errorf("error enqueuing %s %d: %w", result.resourceType, resourceId, err)
+		}
+	}
+	return nil
+}
+
+// listLocal - все локальные ресурсы постранично
+func listLocal[T any](
+	ctx context.Context,
+	list func(ctx context.Context, request scim.ListRequest) (scim.ListResponse, error),
+) ([]T, error) {
+	var resources []T
+	for startIndex := 1; ; {
+		page, err := list(ctx, scim.ListRequest{StartIndex: startIndex, Count: scim.MaxResults})
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range page.Resources {
+			typed, ok := resource.(T)
+			if !ok {
+				return nil, fmt.Errorf("unexpected resource %T", resource)
+			}
+			resources = append(resources, typed)
+		}
+		startIndex += len(page.Resources)
+		if len(page.Resources) == 0 || len(resources) >= page.TotalResults {
+			return resources, nil
+		}
+	}
+}
+
+// userFingerprint - сравниваемые при сверке атрибуты пользователя; active по умолчанию true
+func userFingerprint(user scim.User) string {
+	var active = user.Active == nil || *user.Active
+	var email string
+	for _, e := range user.Emails {
+		if e.Primary || email == "" {
+			email = e.Value
+		}
+	}
+	return strings.Join([]string{
+		strings.ToLower(user.UserName),
+		user.DisplayName,
+		user.Title,
+		strings.ToLower(email),
+		strconv.FormatBool(active),
+	}, "\x1f")
+}
+
+func groupFingerprint(group scim.Group, members []string) string {
+	slices.Sort(members)
+	return group.DisplayName + "\x1f" + strings.Join(members, ",")
+}
+
+func (svc *Service) findTarget(ctx context.Context, id int64) (TargetEntity, error) {
+	target, err := svc.repo.FindTargetById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TargetEntity{}, targetNotFound(id)
+	}
+	if err != nil {
+		return TargetEntity{}, fmt.Errorf("error finding provisioning target %d: %w", id, err)
+	}
+	return target, nil
+}
+
+func (svc *Service) checkName(ctx context.Context, name string, exceptId int64) error {
+	isExists, err := svc.repo.ExistsTargetByName(ctx, name, exceptId)
+	if err != nil {
+		return fmt.Errorf("error checking provisioning target name: %w", err)
+	}
+	if isExists {
+		return domain.AlreadyExistsError{Message: fmt.Sprintf("provisioning target %q already exists", name)}
+	}
+	return nil
+}
+
+func targetNotFound(id int64) error {
+	return domain.NotFoundError{Message: fmt.Sprintf("provisioning target with id %d not found", id)}
+}
+
+func formatId(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
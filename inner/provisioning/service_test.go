@@ -0,0 +1,644 @@
+package provisioning
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"idm/inner/domain"
+	"idm/inner/retry"
+	"idm/inner/scim"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindAllTargets(ctx context.Context) ([]TargetEntity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]TargetEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindTargetById(ctx context.Context, id int64) (TargetEntity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(TargetEntity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsTargetByName(ctx context.Context, name string, exceptId int64) (bool, error) {
+	args := m.Called(ctx, name, exceptId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) CreateTarget(ctx context.Context, entity *TargetEntity) (TargetEntity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(TargetEntity), args.Error(1)
+}
+
+func (m *MockRepo) UpdateTarget(ctx context.Context, entity *TargetEntity) (TargetEntity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(TargetEntity), args.Error(1)
+}
+
+func (m *MockRepo) DeleteTarget(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) CaptureChanges(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) Enqueue(ctx context.Context, targetId int64, resourceType string, resourceId int64) error {
+	args := m.Called(ctx, targetId, resourceType, resourceId)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]OperationEntity, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]OperationEntity), args.Error(1)
+}
+
+func (m *MockRepo) FinishOperation(ctx context.Context, operation *OperationEntity) error {
+	args := m.Called(ctx, operation)
+	return args.Error(0)
+}
+
+func (m *MockRepo) FindOperations(ctx context.Context, targetId int64, status string) ([]OperationEntity, error) {
+	args := m.Called(ctx, targetId, status)
+	return args.Get(0).([]OperationEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindSyncState(
+	ctx context.Context,
+	targetId int64,
+	resourceType string,
+	resourceId int64,
+) (SyncStateEntity, error) {
+	args := m.Called(ctx, targetId, resourceType, resourceId)
+	return args.Get(0).(SyncStateEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindSyncStates(ctx context.Context, targetId int64, resourceType string) ([]SyncStateEntity, error) {
+	args := m.Called(ctx, targetId, resourceType)
+	return args.Get(0).([]SyncStateEntity), args.Error(1)
+}
+
+func (m *MockRepo) SaveSyncState(ctx context.Context, state *SyncStateEntity) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteSyncState(ctx context.Context, targetId int64, resourceType string, resourceId int64) error {
+	args := m.Called(ctx, targetId, resourceType, resourceId)
+	return args.Error(0)
+}
+
+// stubSource - локальные сотрудники и роли
+type stubSource struct {
+	users  []scim.User
+	groups []scim.Group
+}
+
+func (s *stubSource) ListUsers(_ context.Context, _ scim.ListRequest) (scim.ListResponse, error) {
+	var resources = make([]any, 0, len(s.users))
+	for _, user := range s.users {
+		resources = append(resources, user)
+	}
+	return scim.ListResponse{TotalResults: len(resources), StartIndex: 1, Resources: resources}, nil
+}
+
+func (s *stubSource) GetUser(_ context.Context, id string) (scim.User, error) {
+	for _, user := range s.users {
+		if user.Id == id {
+			return user, nil
+		}
+	}
+	return scim.User{}, domain.NotFoundError{Message: "employee " + id + " not found"}
+}
+
+func (s *stubSource) ListGroups(_ context.Context, _ scim.ListRequest) (scim.ListResponse, error) {
+	var resources = make([]any, 0, len(s.groups))
+	for _, group := range s.groups {
+		resources = append(resources, group)
+	}
+	return scim.ListResponse{TotalResults: len(resources), StartIndex: 1, Resources: resources}, nil
+}
+
+func (s *stubSource) GetGroup(_ context.Context, id string) (scim.Group, error) {
+	for _, group := range s.groups {
+		if group.Id == id {
+			return group, nil
+		}
+	}
+	return scim.Group{}, domain.NotFoundError{Message: "role " + id + " not found"}
+}
+
+// fakeTarget - целевая система SCIM в памяти; failStatus != 0 - отвечать этим статусом на всё
+type fakeTarget struct {
+	mu         sync.Mutex
+	resources  map[string]map[string]map[string]any // "Users"/"Groups" -> id -> ресурс
+	lastId     int
+	failStatus int
+	requests   []string
+	token      string
+}
+
+func newFakeTarget(t *testing.T) (*fakeTarget, *httptest.Server) {
+	var target = &fakeTarget{resources: map[string]map[string]map[string]any{"Users": {}, "Groups": {}}}
+	server := httptest.NewServer(target)
+	t.Cleanup(server.Close)
+	return target, server
+}
+
+func (f *fakeTarget) put(kind string, id string, resource map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resource["id"] = id
+	f.resources[kind][id] = resource
+}
+
+func (f *fakeTarget) get(kind string, id string) (map[string]any, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resource, ok := f.resources[kind][id]
+	return resource, ok
+}
+
+func (f *fakeTarget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if f.failStatus != 0 {
+		writeScim(w, f.failStatus, scim.ErrorResponse{Detail: "target is unavailable"})
+		return
+	}
+
+	var parts = strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var kind = parts[0]
+	var key = "userName"
+	if kind == "Groups" {
+		key = "displayName"
+	}
+	var resources = f.resources[kind]
+
+	var body map[string]any
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1:
+		var list = make([]map[string]any, 0, len(resources))
+		var filter = r.URL.Query().Get("filter")
+		for _, resource := range resources {
+			// userName и displayName сравниваются без учёта регистра, как в SCIM
+			if filter != "" && !strings.EqualFold(filter, fmt.Sprintf("%s eq %q", key, resource[key])) {
+				continue
+			}
+			list = append(list, resource)
+		}
+		slices.SortFunc(list, func(a, b map[string]any) int { return strings.Compare(a["id"].(string), b["id"].(string)) })
+		writeScim(w, http.StatusOK, map[string]any{"totalResults": len(list), "Resources": list})
+	case r.Method == http.MethodPost && len(parts) == 1:
+		for _, resource := range resources {
+			if strings.EqualFold(resource[key].(string), body[key].(string)) {
+				writeScim(w, http.StatusConflict, scim.ErrorResponse{Detail: "already exists"})
+				return
+			}
+		}
+		f.lastId++
+		body["id"] = fmt.Sprintf("%s-%d", strings.ToLower(kind[:1]), f.lastId)
+		resources[body["id"].(string)] = body
+		writeScim(w, http.StatusCreated, body)
+	case r.Method == http.MethodPut && len(parts) == 2:
+		if _, ok := resources[parts[1]]; !ok {
+			writeScim(w, http.StatusNotFound, scim.ErrorResponse{Detail: "not found"})
+			return
+		}
+		body["id"] = parts[1]
+		resources[parts[1]] = body
+		writeScim(w, http.StatusOK, body)
+	case r.Method == http.MethodDelete && len(parts) == 2:
+		if _, ok := resources[parts[1]]; !ok {
+			writeScim(w, http.StatusNotFound, scim.ErrorResponse{Detail: "not found"})
+			return
+		}
+		delete(resources, parts[1])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeScim(w, http.StatusBadRequest, scim.ErrorResponse{Detail: "unsupported request"})
+	}
+}
+
+func writeScim(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", scim.ContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func activeUser(id string, userName string, title string) scim.User {
+	var active = true
+	return scim.User{
+		Schemas:  []string{scim.SchemaUser},
+		Id:       id,
+		UserName: userName,
+		Title:    title,
+		Active:   &active,
+		Meta:     &scim.Meta{ResourceType: scim.ResourceUser},
+	}
+}
+
+func TestProvisioningService_Deliver(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	setup := func(t *testing.T, source *stubSource) (*MockRepo, *Service, *fakeTarget) {
+		fake, server := newFakeTarget(t)
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator), source, NewConnector)
+		repo.On("FindTargetById", ctx, int64(1)).
+			Return(TargetEntity{Id: 1, Type: TargetScim, BaseUrl: server.URL + "/", Token: "secret", Enabled: true}, nil)
+		return repo, service, fake
+	}
+	claim := func(repo *MockRepo, operations ...OperationEntity) {
+		repo.On("ClaimDue", ctx, mock.Anything, deliveryLease, deliveryBatch).Return(operations, nil).Once()
+	}
+	noState := func(repo *MockRepo, resourceType string, resourceId int64) {
+		repo.On("FindSyncState", ctx, int64(1), resourceType, resourceId).Return(SyncStateEntity{}, sql.ErrNoRows).Once()
+	}
+
+	t.Run("should create user and remember its id in target", func(t *testing.T) {
+		repo, service, fake := setup(t, &stubSource{users: []scim.User{activeUser("7", "alice", "Engineer")}})
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceUser, ResourceId: 7})
+		noState(repo, scim.ResourceUser, 7)
+		repo.On("SaveSyncState", ctx, mock.MatchedBy(func(state *SyncStateEntity) bool {
+			return state.ResourceId == 7 && state.ExternalId == "u-1" && state.Status == SyncSynced && state.SyncedAt != nil
+		})).Return(nil).Once()
+		repo.On("FinishOperation", ctx, mock.MatchedBy(func(operation *OperationEntity) bool {
+			return operation.Status == OperationDelivered && operation.Attempts == 1 && operation.LastError == ""
+		})).Return(nil).Once()
+
+		got, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		a.Len(got, 1)
+		a.Equal(OperationDelivered, got[0].Status)
+		created, ok := fake.get("Users", "u-1")
+		require.True(t, ok)
+		a.Equal("7", created["externalId"])
+		a.Equal("alice", created["userName"])
+		a.Nil(created["meta"])
+		a.Equal("secret", fake.token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should replace user created earlier by someone else", func(t *testing.T) {
+		repo, service, fake := setup(t, &stubSource{users: []scim.User{activeUser("7", "alice", "Lead")}})
+		fake.put("Users", "u-40", map[string]any{"userName": "Alice", "title": "Engineer"})
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceUser, ResourceId: 7})
+		noState(repo, scim.ResourceUser, 7)
+		repo.On("SaveSyncState", ctx, mock.MatchedBy(func(state *SyncStateEntity) bool {
+			return state.ExternalId == "u-40"
+		})).Return(nil).Once()
+		repo.On("FinishOperation", ctx, mock.Anything).Return(nil).Once()
+
+		_, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		replaced, _ := fake.get("Users", "u-40")
+		a.Equal("Lead", replaced["title"])
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should retry with backoff when target fails", func(t *testing.T) {
+		repo, service, fake := setup(t, &stubSource{users: []scim.User{activeUser("7", "alice", "")}})
+		fake.failStatus = http.StatusServiceUnavailable
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceUser, ResourceId: 7, Attempts: 1})
+		noState(repo, scim.ResourceUser, 7)
+		repo.On("FinishOperation", ctx, mock.MatchedBy(func(operation *OperationEntity) bool {
+			var delay = time.Until(operation.NextAttemptAt)
+			return operation.Status == OperationPending && operation.Attempts == 2 &&
+				delay > 50*time.Second && delay <= time.Minute &&
+				strings.Contains(operation.LastError, "503")
+		})).Return(nil).Once()
+		repo.On("SaveSyncState", ctx, mock.MatchedBy(func(state *SyncStateEntity) bool {
+			return state.Status == SyncFailed && strings.Contains(state.LastError, "target is unavailable")
+		})).Return(nil).Once()
+
+		got, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		a.Equal(OperationPending, got[0].Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should fail after last attempt", func(t *testing.T) {
+		repo, service, fake := setup(t, &stubSource{users: []scim.User{activeUser("7", "alice", "")}})
+		fake.failStatus = http.StatusInternalServerError
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceUser, ResourceId: 7, Attempts: MaxAttempts - 1})
+		noState(repo, scim.ResourceUser, 7)
+		repo.On("FinishOperation", ctx, mock.MatchedBy(func(operation *OperationEntity) bool {
+			return operation.Status == OperationFailed && operation.Attempts == MaxAttempts
+		})).Return(nil).Once()
+		repo.On("SaveSyncState", ctx, mock.Anything).Return(nil).Once()
+
+		_, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should fail without retry when target rejects data", func(t *testing.T) {
+		repo, service, fake := setup(t, &stubSource{users: []scim.User{activeUser("7", "alice", "")}})
+		fake.failStatus = http.StatusBadRequest
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceUser, ResourceId: 7})
+		noState(repo, scim.ResourceUser, 7)
+		repo.On("FinishOperation", ctx, mock.MatchedBy(func(operation *OperationEntity) bool {
+			return operation.Status == OperationFailed && operation.Attempts == 1
+		})).Return(nil).Once()
+		repo.On("SaveSyncState", ctx, mock.Anything).Return(nil).Once()
+
+		_, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should delete user of deleted employee", func(t *testing.T) {
+		repo, service, fake := setup(t, &stubSource{})
+		fake.put("Users", "u-9", map[string]any{"userName": "bob"})
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceUser, ResourceId: 7})
+		repo.On("FindSyncState", ctx, int64(1), scim.ResourceUser, int64(7)).
+			Return(SyncStateEntity{ExternalId: "u-9", Status: SyncSynced}, nil).Once()
+		repo.On("DeleteSyncState", ctx, int64(1), scim.ResourceUser, int64(7)).Return(nil).Once()
+		repo.On("FinishOperation", ctx, mock.MatchedBy(func(operation *OperationEntity) bool {
+			return operation.Status == OperationDelivered
+		})).Return(nil).Once()
+
+		_, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		_, ok := fake.get("Users", "u-9")
+		a.False(ok)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should provision members before group", func(t *testing.T) {
+		group := scim.Group{Id: "5", DisplayName: "Payments", Members: []scim.Member{{Value: "7"}, {Value: "8"}}}
+		repo, service, fake := setup(t, &stubSource{
+			users:  []scim.User{activeUser("7", "alice", ""), activeUser("8", "bob", "")},
+			groups: []scim.Group{group},
+		})
+		fake.put("Users", "u-80", map[string]any{"userName": "bob"})
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceGroup, ResourceId: 5})
+		noState(repo, scim.ResourceGroup, 5)
+		// alice ещё не передавалась: поиск состояния участника и затем самой доставки пользователя
+		noState(repo, scim.ResourceUser, 7)
+		noState(repo, scim.ResourceUser, 7)
+		repo.On("FindSyncState", ctx, int64(1), scim.ResourceUser, int64(8)).
+			Return(SyncStateEntity{ExternalId: "u-80", Status: SyncSynced}, nil).Once()
+		repo.On("SaveSyncState", ctx, mock.MatchedBy(func(state *SyncStateEntity) bool {
+			return state.ResourceType == scim.ResourceUser && state.ResourceId == 7 && state.ExternalId == "u-1"
+		})).Return(nil).Once()
+		repo.On("SaveSyncState", ctx, mock.MatchedBy(func(state *SyncStateEntity) bool {
+			return state.ResourceType == scim.ResourceGroup && state.ResourceId == 5 && state.ExternalId == "g-2"
+		})).Return(nil).Once()
+		repo.On("FinishOperation", ctx, mock.Anything).Return(nil).Once()
+
+		_, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		created, ok := fake.get("Groups", "g-2")
+		require.True(t, ok)
+		a.Equal("5", created["externalId"])
+		a.Equal([]any{map[string]any{"value": "u-1"}, map[string]any{"value": "u-80"}}, created["members"])
+		a.Equal([]string{"POST /Users", "POST /Groups"}, fake.requests)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should fail operation of unsupported target type", func(t *testing.T) {
+		repo := new(MockRepo)
+		service := NewService(repo, new(MockValidator), &stubSource{}, NewConnector)
+		repo.On("FindTargetById", ctx, int64(2)).Return(TargetEntity{Id: 2, Type: "ldap"}, nil).Once()
+		claim(repo, OperationEntity{Id: 1, TargetId: 2, ResourceType: scim.ResourceUser, ResourceId: 7})
+		repo.On("FinishOperation", ctx, mock.MatchedBy(func(operation *OperationEntity) bool {
+			return operation.Status == OperationFailed && strings.Contains(operation.LastError, "ldap")
+		})).Return(nil).Once()
+		repo.On("SaveSyncState", ctx, mock.Anything).Return(nil).Once()
+
+		_, err := service.Deliver(ctx)
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return error when operation outcome is not saved", func(t *testing.T) {
+		repo, service, _ := setup(t, &stubSource{users: []scim.User{activeUser("7", "alice", "")}})
+		claim(repo, OperationEntity{Id: 1, TargetId: 1, ResourceType: scim.ResourceUser, ResourceId: 7})
+		noState(repo, scim.ResourceUser, 7)
+		repo.On("SaveSyncState", ctx, mock.Anything).Return(nil).Once()
+		repo.On("FinishOperation", ctx, mock.Anything).Return(errors.New("connection reset")).Once()
+
+		got, err := service.Deliver(ctx)
+
+		a.ErrorContains(err, "connection reset")
+		a.Empty(got)
+	})
+}
+
+func TestProvisioningService_Reconcile(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	source := &stubSource{
+		users: []scim.User{
+			activeUser("1", "alice", "Engineer"),
+			activeUser("2", "bob", "Lead"),
+			activeUser("3", "carol", ""),
+		},
+		groups: []scim.Group{{Id: "5", DisplayName: "Payments", Members: []scim.Member{{Value: "1"}}}},
+	}
+	setup := func(t *testing.T) (*MockRepo, *MockValidator, *Service) {
+		fake, server := newFakeTarget(t)
+		fake.put("Users", "u-1", map[string]any{"userName": "alice", "title": "Engineer", "active": true})
+		fake.put("Users", "u-2", map[string]any{"userName": "BOB", "title": "Engineer", "active": true})
+		fake.put("Users", "u-8", map[string]any{"userName": "mallory", "active": true})
+		fake.put("Users", "u-9", map[string]any{"userName": "dave", "active": true})
+		fake.put("Groups", "g-1", map[string]any{"displayName": "Payments", "externalId": "5",
+			"members": []any{map[string]any{"value": "u-1"}}})
+
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, source, NewConnector)
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil)
+		repo.On("FindTargetById", ctx, int64(1)).Return(TargetEntity{Id: 1, Type: TargetScim, BaseUrl: server.URL}, nil)
+		repo.On("FindSyncStates", ctx, int64(1), scim.ResourceUser).Return([]SyncStateEntity{
+			{ResourceType: scim.ResourceUser, ResourceId: 1, ExternalId: "u-1", Status: SyncSynced},
+			{ResourceType: scim.ResourceUser, ResourceId: 4, ExternalId: "u-9", Status: SyncSynced},
+		}, nil)
+		repo.On("FindSyncStates", ctx, int64(1), scim.ResourceGroup).Return([]SyncStateEntity{}, nil)
+		return repo, validator, service
+	}
+
+	t.Run("should report differences without queuing them", func(t *testing.T) {
+		repo, _, service := setup(t)
+
+		got, err := service.Reconcile(ctx, 1, false)
+
+		a.Nil(err)
+		a.False(got.Applied)
+		a.Equal(DiffResponse{InSync: 1, Create: []int64{3}, Update: []int64{2}, Delete: []int64{4}, Orphaned: []string{"u-8"}}, got.Users)
+		a.Equal(DiffResponse{InSync: 1, Create: []int64{}, Update: []int64{}, Delete: []int64{}, Orphaned: []string{}}, got.Groups)
+		repo.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "SaveSyncState", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should remember matches and queue differences", func(t *testing.T) {
+		repo, _, service := setup(t)
+		repo.On("SaveSyncState", ctx, mock.MatchedBy(func(state *SyncStateEntity) bool {
+			return state.ResourceType == scim.ResourceUser && state.ResourceId == 2 && state.ExternalId == "u-2"
+		})).Return(nil).Once()
+		repo.On("SaveSyncState", ctx, mock.MatchedBy(func(state *SyncStateEntity) bool {
+			return state.ResourceType == scim.ResourceGroup && state.ResourceId == 5 && state.ExternalId == "g-1"
+		})).Return(nil).Once()
+		for _, resourceId := range []int64{3, 2, 4} {
+			repo.On("Enqueue", ctx, int64(1), scim.ResourceUser, resourceId).Return(nil).Once()
+		}
+
+		got, err := service.Reconcile(ctx, 1, true)
+
+		a.Nil(err)
+		a.True(got.Applied)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when target does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, source, NewConnector)
+		validator.On("Validate", FindByIDRequest{ID: 2}).Return(nil).Once()
+		repo.On("FindTargetById", ctx, int64(2)).Return(TargetEntity{}, sql.ErrNoRows).Once()
+
+		_, err := service.Reconcile(ctx, 2, false)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+
+	t.Run("should return target error when target is unavailable", func(t *testing.T) {
+		fake, server := newFakeTarget(t)
+		fake.failStatus = http.StatusBadGateway
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, source, NewConnector)
+		validator.On("Validate", FindByIDRequest{ID: 1}).Return(nil).Once()
+		repo.On("FindTargetById", ctx, int64(1)).Return(TargetEntity{Id: 1, Type: TargetScim, BaseUrl: server.URL}, nil).Once()
+
+		_, err := service.Reconcile(ctx, 1, false)
+
+		var targetErr TargetError
+		require.True(t, errors.As(err, &targetErr))
+		a.Equal(http.StatusBadGateway, targetErr.StatusCode)
+	})
+}
+
+func TestProvisioningService_Targets(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	t.Run("should create enabled scim target by default", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, &stubSource{}, NewConnector)
+		request := CreateRequest{Name: "Slack", BaseUrl: "https://api.slack.com/scim/v2", Token: "secret"}
+		created := TargetEntity{Id: 1, Name: "Slack", Type: TargetScim, BaseUrl: request.BaseUrl, Token: "secret", Enabled: true}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsTargetByName", ctx, "Slack", int64(0)).Return(false, nil).Once()
+		repo.On("CreateTarget", ctx, mock.MatchedBy(func(entity *TargetEntity) bool {
+			return entity.Type == TargetScim && entity.Enabled && entity.Token == "secret"
+		})).Return(created, nil).Once()
+
+		got, err := service.Create(ctx, request)
+
+		a.Nil(err)
+		a.True(got.HasToken)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return already exists when name is taken", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, &stubSource{}, NewConnector)
+		request := UpdateRequest{Id: 1, Name: "Slack", BaseUrl: "https://api.slack.com/scim/v2"}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindTargetById", ctx, int64(1)).Return(TargetEntity{Id: 1}, nil).Once()
+		repo.On("ExistsTargetByName", ctx, "Slack", int64(1)).Return(true, nil).Once()
+
+		_, err := service.Update(ctx, 1, UpdateRequest{Name: "Slack", BaseUrl: "https://api.slack.com/scim/v2"})
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "UpdateTarget", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return validation error", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, &stubSource{}, NewConnector)
+		request := CreateRequest{Name: "Slack", BaseUrl: "not a url"}
+
+		validator.On("Validate", request).Return(errors.New("baseUrl must be a valid URL")).Once()
+
+		_, err := service.Create(ctx, request)
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "CreateTarget", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found when deleting missing target", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator, &stubSource{}, NewConnector)
+
+		validator.On("Validate", FindByIDRequest{ID: 3}).Return(nil).Once()
+		repo.On("FindTargetById", ctx, int64(3)).Return(TargetEntity{}, sql.ErrNoRows).Once()
+
+		_, err := service.DeleteById(ctx, 3)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "DeleteTarget", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	var a = assert.New(t)
+
+	a.Equal(30*time.Second, retry.Delay(1, retryBaseDelay, retryMaxDelay))
+	a.Equal(time.Minute, retry.Delay(2, retryBaseDelay, retryMaxDelay))
+	a.Equal(4*time.Minute, retry.Delay(4, retryBaseDelay, retryMaxDelay))
+	a.Equal(time.Hour, retry.Delay(MaxAttempts, retryBaseDelay, retryMaxDelay))
+	a.Equal(time.Hour, retry.Delay(100, retryBaseDelay, retryMaxDelay))
+}
+
+func TestIsRetryable(t *testing.T) {
+	var a = assert.New(t)
+
+	a.True(IsRetryable(errors.New("connection refused")))
+	a.True(IsRetryable(TargetError{StatusCode: http.StatusServiceUnavailable}))
+	a.True(IsRetryable(fmt.Errorf("wrapped: %w", TargetError{StatusCode: http.StatusTooManyRequests})))
+	a.False(IsRetryable(TargetError{StatusCode: http.StatusBadRequest}))
+	a.False(IsRetryable(fmt.Errorf("%w: unknown resource type", ErrNotRetryable)))
+}
@@ -0,0 +1,12 @@
+package retry
+
+import "time"
+
+// Delay - пауза перед повтором после attempts неудачных попыток: base, 2*base, 4*base ... но не больше max
+func Delay(attempts int, base time.Duration, max time.Duration) time.Duration {
+	var delay = base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
@@ -0,0 +1,18 @@
+package retry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	var a = assert.New(t)
+
+	a.Equal(time.Second, Delay(0, time.Second, time.Minute))
+	a.Equal(time.Second, Delay(1, time.Second, time.Minute))
+	a.Equal(2*time.Second, Delay(2, time.Second, time.Minute))
+	a.Equal(32*time.Second, Delay(6, time.Second, time.Minute))
+	a.Equal(time.Minute, Delay(7, time.Second, time.Minute))
+	a.Equal(time.Minute, Delay(1000, time.Second, time.Minute)) // без переполнения
+}
@@ -1,15 +1,18 @@
 package role
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 	"idm/inner/common"
 	"idm/inner/domain"
+	"idm/inner/export"
 
 	"idm/inner/http"
 	"idm/inner/web"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -43,35 +46,57 @@ func NewController(
 }
 
 type Svc interface {
-	FindById(ctx context.Context, id int64) (Response, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (Response, error)
 	CreateRole(ctx context.Context, request CreateRequest) (Response, error)
 	UpdateRole(ctx context.Context, id int64, request UpdateRequest) (Response, error)
-	FindAll(ctx context.Context) ([]Response, error)
-	FindAllByIds(ctx context.Context, ids []int64) ([]Response, error)
+	FindAll(ctx context.Context, includeDeleted bool) ([]Response, error)
+	GetKeysetPage(ctx context.Context, request KeysetRequest) (KeysetPage, error)
+	FindAllByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Response, error)
 	DeleteById(ctx context.Context, id int64) (Response, error)
 	DeleteByIds(ctx context.Context, ids []int64) (Response, error)
+	Restore(ctx context.Context, id int64) (Response, error)
+	FindEmployees(ctx context.Context, roleId int64) ([]EmployeeResponse, error)
+	FindEffectiveEmployees(ctx context.Context, roleId int64) ([]EffectiveEmployeeResponse, error)
+	AssignEmployee(ctx context.Context, roleId int64, request AssignEmployeeRequest) ([]EmployeeResponse, error)
+	RevokeEmployee(ctx context.Context, roleId int64, employeeId int64) ([]EmployeeResponse, error)
+	FindImpliedRoles(ctx context.Context, roleId int64) ([]ImpliedRoleResponse, error)
+	Export(ctx context.Context, request ExportRequest) (func(w io.Writer) error, error)
 }
 
 // RegisterRoutes - функция для регистрации маршрутов
 func (c *Controller) RegisterRoutes() {
 	// полный маршрут получится "/api/v1/roles"
-	c.server.GroupRoles.Get("/", c.FindAll)
-	c.server.GroupRoles.Get("/ids", c.FindAllByIds)
-	c.server.GroupRoles.Get("/:id", c.FindById)
-	c.server.GroupRoles.Post("/", c.CreateRole)
-	c.server.GroupRoles.Put("/:id", c.UpdateRole)
-	c.server.GroupRoles.Delete("/ids", c.DeleteByIds)
-	c.server.GroupRoles.Delete("/:id", c.DeleteById)
+	// мягко удалённые роли (?includeDeleted=true) видны только с правом на удаление
+	var readDeleted = c.server.RequireWhen(web.IncludeDeleted, web.PermRolesDelete)
+	c.server.GroupRoles.Get("/", c.server.Require(web.PermRolesRead), readDeleted, c.FindAll)
+	c.server.GroupRoles.Get("/ids", c.server.Require(web.PermRolesRead), readDeleted, c.FindAllByIds)
+	c.server.GroupRoles.Get("/export", c.server.Require(web.PermRolesRead), readDeleted, c.Export)
+	c.server.GroupRoles.Get("/:id", c.server.Require(web.PermRolesRead), readDeleted, c.FindById)
+	c.server.GroupRoles.Post("/", c.server.Require(web.PermRolesWrite), c.CreateRole)
+	c.server.GroupRoles.Put("/:id", c.server.Require(web.PermRolesWrite), c.UpdateRole)
+	c.server.GroupRoles.Delete("/ids", c.server.Require(web.PermRolesDelete), c.DeleteByIds)
+	c.server.GroupRoles.Delete("/:id", c.server.Require(web.PermRolesDelete), c.DeleteById)
+	c.server.GroupRoles.Post("/:id/restore", c.server.Require(web.PermRolesDelete), c.Restore)
+	c.server.GroupRoles.Get("/:id/employees", c.server.Require(web.PermRolesRead), c.FindEmployees)
+	c.server.GroupRoles.Get("/:id/effective", c.server.Require(web.PermRolesRead), c.FindImpliedRoles)
+	c.server.GroupRoles.Get("/:id/effective-employees", c.server.Require(web.PermRolesRead), c.FindEffectiveEmployees)
+	c.server.GroupRoles.Post("/:id/employees", c.server.Require(web.PermRolesAssign), c.AssignEmployee)
+	c.server.GroupRoles.Delete("/:id/employees/:employeeId", c.server.Require(web.PermRolesAssign), c.RevokeEmployee)
 }
 
 // -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/transport/v1/employees" --//
 
+// FindAll - все роли массивом, а с любым из параметров after, before, limit, sort или withTotal -
+// keyset-страница ролей в порядке (sort, id) со ссылками next и prev (KeysetPageResponse)
 func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	if web.KeysetRequested(ctx) {
+		return c.findKeysetPage(ctx)
+	}
 	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
 
 	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
 
-	response, err := c.roleService.FindAll(appContext)
+	response, err := c.roleService.FindAll(appContext, web.IncludeDeleted(ctx))
 	if err != nil {
 		c.logger.Error(
 			"FindAll ended with error",           // Сообщение без форматирования - zap сам обработает
@@ -96,6 +121,103 @@ func (c *Controller) FindAll(ctx *fiber.Ctx) error {
 	return http.OkResponse(ctx, response)
 }
 
+// findKeysetPage - keyset-выдача FindAll: страница ролей со ссылками next и prev
+func (c *Controller) findKeysetPage(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext()
+	requestId := ctx.Locals("request_id").(string)
+
+	keyset, err := c.server.ParseKeyset(ctx, web.RolesPath)
+	if err != nil {
+		c.logger.Error(
+			"When the parse a roles keyset request ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	}
+
+	page, err := c.roleService.GetKeysetPage(appContext, KeysetRequest{
+		Limit:          keyset.Limit,
+		Sort:           keyset.Sort,
+		TextFilter:     keyset.TextFilter,
+		IncludeDeleted: keyset.IncludeDeleted,
+		WithTotal:      keyset.WithTotal,
+		After:          keyset.After,
+		Before:         keyset.Before,
+	})
+	if err != nil {
+		c.logger.Error(
+			"Find roles keyset page ended with error",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		switch {
+		case errors.As(err, &domain.RequestValidationError{}):
+			return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+		default:
+			return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+		}
+	}
+
+	next, prev := c.server.KeysetLinks(ctx, keyset, page.Next, page.Prev)
+	return http.OkResponse(ctx, KeysetPageResponse{
+		Result: page.Result,
+		Limit:  keyset.Limit,
+		Next:   next,
+		Prev:   prev,
+		Total:  page.Total,
+	})
+}
+
+// Export   	 godoc
+// @Description  Export Roles with ids of directly assigned employees as CSV, NDJSON or XLSX file.
+// @Description  Rows are streamed from a server-side cursor ordered by id; textFilter matches the role name
+// @Summary		 export roles
+// @Tags 		 role
+// @Produce 	 text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param   	 format 			query   string  false  	"File format"	Enums(csv, ndjson, xlsx)	default(csv)
+// @Param   	 textFilter 		query   string  false  	"Name substring, at least 3 characters"
+// @Param   	 includeDeleted 	query   bool  	false  	"include soft deleted roles (requires roles:delete)"
+// @Success 	 200  {file} 		file					"Export file"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /roles/export 	[get]
+func (c *Controller) Export(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request = ExportRequest{
+		Format:         ctx.Query("format", export.CSV),
+		TextFilter:     ctx.Query("textFilter", ""),
+		IncludeDeleted: web.IncludeDeleted(ctx),
+	}
+
+	write, err := c.roleService.Export(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the export Roles ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.StreamResponse(ctx, export.Filename("roles", request.Format), export.ContentType(request.Format),
+		func(w *bufio.Writer) {
+			if err := write(w); err != nil {
+				c.logger.Error(
+					"When the stream Roles export ended with an error:",
+					zap.Error(err),
+					zap.String("request_id", requestId),
+				)
+			}
+		},
+	)
+}
+
 func (c *Controller) FindById(ctx *fiber.Ctx) error {
 	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
 
@@ -114,7 +236,7 @@ func (c *Controller) FindById(ctx *fiber.Ctx) error {
 		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
 	}
 
-	response, err := c.roleService.FindById(appContext, roleID)
+	response, err := c.roleService.FindById(appContext, roleID, web.IncludeDeleted(ctx))
 	if err != nil {
 		c.logger.Error(
 			"Failed to get roles By ID",
@@ -163,7 +285,7 @@ func (c *Controller) FindAllByIds(ctx *fiber.Ctx) error {
 		ids = append(ids, id)
 	}
 
-	response, err := c.roleService.FindAllByIds(appContext, ids)
+	response, err := c.roleService.FindAllByIds(appContext, ids, web.IncludeDeleted(ctx))
 	if err != nil {
 		c.logger.Error("When the parse request parameter an FindAll Role By IDs ended with an error",
 			zap.Int("ids", len(idsParam)),
@@ -254,6 +376,8 @@ func (c *Controller) UpdateRole(ctx *fiber.Ctx) error {
 		switch {
 		case errors.As(err, &domain.RequestValidationError{}):
 			return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+		case errors.As(err, &domain.SodViolationError{}):
+			return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
 		default:
 			return http.ErrResponse(ctx, fiber.StatusInternalServerError, err.Error())
 		}
@@ -343,3 +467,270 @@ func (c *Controller) DeleteByIds(ctx *fiber.Ctx) error {
 
 	return http.OkResponse(ctx, response)
 }
+
+// FindEmployees 	 godoc
+// @Description  Find employees the role is directly assigned to, with validity window of each assignment
+// @Summary 	 find role employees
+// @Tags 		 role
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  				"Role ID"
+// @Success 	 200  {array}  		role.EmployeeResponse	"Role employees"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /roles/{id}/employees 	[get]
+func (c *Controller) FindEmployees(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	roleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error("ID parse error when find Role employees",
+			zap.Error(err),
+			zap.String("id", idStr),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.roleService.FindEmployees(appContext, roleID)
+	if err != nil {
+		c.logger.Error("When the find Role employees ended with an error",
+			zap.Error(err),
+			zap.Int64("id", roleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindEffectiveEmployees - сотрудники, имеющие роль напрямую или через группы
+func (c *Controller) FindEffectiveEmployees(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	roleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error("ID parse error when find Role effective employees",
+			zap.Error(err),
+			zap.String("id", idStr),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.roleService.FindEffectiveEmployees(appContext, roleID)
+	if err != nil {
+		c.logger.Error("When the find Role effective employees ended with an error",
+			zap.Error(err),
+			zap.Int64("id", roleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindImpliedRoles - роль и все роли, которые она подразумевает через наследование
+func (c *Controller) FindImpliedRoles(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	roleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error("ID parse error when find Role implied roles",
+			zap.Error(err),
+			zap.String("id", idStr),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.roleService.FindImpliedRoles(appContext, roleID)
+	if err != nil {
+		c.logger.Error("When the find Role implied roles ended with an error",
+			zap.Error(err),
+			zap.Int64("id", roleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// AssignEmployee 	 godoc
+// @Description  Assign role to employee, optionally for a limited validity window
+// @Summary 	 assign role to employee
+// @Tags 		 role
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  						true  	"Role ID"
+// @Param 		 request 	body 		role.AssignEmployeeRequest 	true 	"Assignment details"
+// @Success 	 200  {array}  		role.EmployeeResponse	"Role employees"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      409  {object}  	http.Response			"Segregation of duties violated"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /roles/{id}/employees 	[post]
+func (c *Controller) AssignEmployee(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	roleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error("ID parse error when assign Role",
+			zap.Error(err),
+			zap.String("id", idStr),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request AssignEmployeeRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"body parse error when assign role",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.roleService.AssignEmployee(appContext, roleID, request)
+	if err != nil {
+		c.logger.Error("When the assign Role ended with an error",
+			zap.Error(err),
+			zap.Int64("id", roleID),
+			zap.Int64("employee_id", request.EmployeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// RevokeEmployee 	 godoc
+// @Description  Revoke role from employee
+// @Summary 	 revoke role from employee
+// @Tags 		 role
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   			path      	int  	true  	"Role ID"
+// @Param 		 employeeId   	path      	int  	true  	"Employee ID"
+// @Success 	 200  {array}  		role.EmployeeResponse	"Role employees"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /roles/{id}/employees/{employeeId} 	[delete]
+func (c *Controller) RevokeEmployee(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	roleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error("ID parse error when revoke Role",
+			zap.Error(err),
+			zap.String("id", idStr),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	employeeIdStr := ctx.Params("employeeId")
+	employeeID, err := strconv.ParseInt(employeeIdStr, 10, 64)
+	if err != nil {
+		c.logger.Error("employee ID parse error when revoke Role",
+			zap.Error(err),
+			zap.String("employeeId", employeeIdStr),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.roleService.RevokeEmployee(appContext, roleID, employeeID)
+	if err != nil {
+		c.logger.Error("When the revoke Role ended with an error",
+			zap.Error(err),
+			zap.Int64("id", roleID),
+			zap.Int64("employee_id", employeeID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Restore - восстановить мягко удалённую роль
+func (c *Controller) Restore(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	idStr := ctx.Params("id")
+	roleID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		c.logger.Error("ID parse error when restore Role",
+			zap.Error(err),
+			zap.String("id", idStr),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.roleService.Restore(appContext, roleID)
+	if err != nil {
+		c.logger.Error("When the restore Role ended with an error",
+			zap.Error(err),
+			zap.Int64("id", roleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.assignmentErrResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// assignmentErrResponse - маппинг доменных ошибок назначения и восстановления ролей в HTTP-статусы
+func (c *Controller) assignmentErrResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}), errors.As(err, &domain.SodViolationError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,186 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoleController_Employees(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockRoleService)
+
+	server := &web.Server{
+		App:        app,
+		GroupRoles: app.Group("/api/v1/roles"),
+	}
+	ctrl := NewController(server, mockService, logger)
+
+	server.GroupRoles.Get("/:id/employees", ctrl.FindEmployees)
+	server.GroupRoles.Get("/:id/effective", ctrl.FindImpliedRoles)
+	server.GroupRoles.Get("/:id/effective-employees", ctrl.FindEffectiveEmployees)
+	server.GroupRoles.Post("/:id/employees", ctrl.AssignEmployee)
+	server.GroupRoles.Delete("/:id/employees/:employeeId", ctrl.RevokeEmployee)
+
+	// Тестовые данные
+	testTime := time.Date(2025, 7, 15, 12, 0, 0, 0, time.UTC)
+	testEmployees := []EmployeeResponse{
+		{Id: 1, Name: "Alice Marcus", AssignedAt: testTime},
+		{Id: 2, Name: "Jill Valentine", AssignedAt: testTime},
+	}
+
+	type employeesResult struct {
+		Success bool               `json:"success"`
+		Error   string             `json:"error"`
+		Data    []EmployeeResponse `json:"data"`
+	}
+
+	t.Run("should return employees of role", func(t *testing.T) {
+		mockService.On("FindEmployees", appContext, int64(10)).Return(testEmployees, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/roles/10/employees", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result employeesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, testEmployees, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when role not found", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "role with id 11 not found"}
+		mockService.On("FindEmployees", appContext, int64(11)).Return([]EmployeeResponse{}, notFound).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/roles/11/employees", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		var result employeesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, notFound.Message, result.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should assign role to employee", func(t *testing.T) {
+		request := AssignEmployeeRequest{EmployeeID: 2}
+		mockService.On("AssignEmployee", appContext, int64(10), request).Return(testEmployees, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/roles/10/employees", strings.NewReader(`{"employeeId": 2}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result employeesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Len(t, result.Data, 2)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when revoke with invalid employee id", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/v1/roles/10/employees/abc", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "RevokeEmployee")
+	})
+
+	t.Run("should revoke role from employee", func(t *testing.T) {
+		mockService.On("RevokeEmployee", appContext, int64(10), int64(2)).Return(testEmployees[:1], nil).Once()
+
+		req := httptest.NewRequest("DELETE", "/api/v1/roles/10/employees/2", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result employeesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, testEmployees[:1], result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return effective employees of role", func(t *testing.T) {
+		effective := []EffectiveEmployeeResponse{{Id: 1, Name: "Alice Marcus", Direct: true, Groups: []string{"Admins"}}}
+		mockService.On("FindEffectiveEmployees", appContext, int64(10)).Return(effective, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/roles/10/effective-employees", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Data []EffectiveEmployeeResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, effective, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 for effective employees of unknown role", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "role with id 11 not found"}
+		mockService.On("FindEffectiveEmployees", appContext, int64(11)).Return([]EffectiveEmployeeResponse(nil), notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/roles/11/effective-employees", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return implied roles of role", func(t *testing.T) {
+		implied := []ImpliedRoleResponse{
+			{Response: Response{Id: 10, Name: "ADMIN"}, Level: 0},
+			{Response: Response{Id: 5, Name: "USER"}, Level: 1},
+		}
+		mockService.On("FindImpliedRoles", appContext, int64(10)).Return(implied, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/roles/10/effective", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Data []ImpliedRoleResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.Len(t, result.Data, 2)
+		assert.Equal(t, "USER", result.Data[1].Name)
+		assert.Equal(t, int64(1), result.Data[1].Level)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -59,16 +59,15 @@ LOG_DEVELOP_MODE=true`
 	// Тестовые данные
 	testTime := time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC)
 	testRole := Response{
-		Id:         123,
-		Name:       "ADMIN",
-		EmployeeID: nil,
-		CreateAt:   testTime,
-		UpdateAt:   testTime,
+		Id:       123,
+		Name:     "ADMIN",
+		CreateAt: testTime,
+		UpdateAt: testTime,
 	}
 
 	// 1. Успешный запрос с данными
 	t.Run("SuccessWithData", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{testRole}, nil).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{testRole}, nil).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/roles/", nil)
 		resp, err := app.Test(req)
@@ -98,7 +97,7 @@ LOG_DEVELOP_MODE=true`
 	})
 	// 2. Успешный запрос без данных
 	t.Run("SuccessEmpty", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{}, nil).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{}, nil).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/roles/", nil)
 		resp, err := app.Test(req)
@@ -128,7 +127,7 @@ LOG_DEVELOP_MODE=true`
 	})
 	// 3. Ошибка поиска - status 500
 	t.Run("FindAllFailed", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{}, domain.ErrFindAllFailed).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{}, domain.ErrFindAllFailed).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/roles/", nil)
 		resp, err := app.Test(req)
@@ -159,7 +158,7 @@ LOG_DEVELOP_MODE=true`
 	})
 	// 4. Внутренняя ошибка
 	t.Run("InternalError", func(t *testing.T) {
-		mockService.On("FindAll", appContext).Return([]Response{}, errors.New("db error")).Once()
+		mockService.On("FindAll", appContext, false).Return([]Response{}, errors.New("db error")).Once()
 
 		req := httptest.NewRequest("GET", "/api/v1/roles/", nil)
 		resp, err := app.Test(req)
@@ -0,0 +1,112 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/pagination"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestRoleController_FindAllKeyset(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockRoleService)
+
+	var signer = pagination.NewSigner([]byte("test-secret"))
+	server := &web.Server{
+		App:        app,
+		GroupRoles: app.Group("/api/v1/roles"),
+		Cursors:    signer,
+	}
+	ctrl := NewController(server, mockService, logger)
+	server.GroupRoles.Get("/", ctrl.FindAll)
+
+	type pageBody struct {
+		Error string             `json:"error"`
+		Data  KeysetPageResponse `json:"data"`
+	}
+	var get = func(t *testing.T, target string) (int, pageBody) {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		require.NoError(t, err)
+		var body pageBody
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	t.Run("should page forward and back through links", func(t *testing.T) {
+		var first = KeysetRequest{Limit: 1, Sort: web.DefaultSort}
+		mockService.On("GetKeysetPage", appContext, first).Return(KeysetPage{
+			Result: []Response{{Id: 1, Name: "ADMIN"}},
+			Next:   &pagination.Key{Id: 1},
+		}, nil).Once()
+
+		status, body := get(t, "/api/v1/roles/?limit=1")
+		require.Equal(t, fiber.StatusOK, status)
+		require.NotEmpty(t, body.Data.Next)
+		assert.Empty(t, body.Data.Prev)
+
+		// ссылка next ведёт на тот же путь с теми же параметрами и курсором after
+		next, err := url.Parse(body.Data.Next)
+		require.NoError(t, err)
+		assert.Equal(t, "/api/v1/roles/", next.Path)
+		assert.Equal(t, "1", next.Query().Get(web.LimitParam))
+
+		var second = KeysetRequest{Limit: 1, Sort: web.DefaultSort, After: &pagination.Key{Id: 1}}
+		mockService.On("GetKeysetPage", appContext, second).Return(KeysetPage{
+			Result: []Response{{Id: 2, Name: "USER"}},
+			Prev:   &pagination.Key{Id: 2},
+		}, nil).Once()
+
+		status, body = get(t, body.Data.Next)
+		require.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "USER", body.Data.Result[0].Name)
+		assert.Empty(t, body.Data.Next)
+
+		prev, err := url.Parse(body.Data.Prev)
+		require.NoError(t, err)
+		cursor, err := signer.Decode(prev.Query().Get(web.BeforeParam))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), cursor.Id)
+		assert.Equal(t, web.RolesPath, cursor.Resource)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should reject employees cursor", func(t *testing.T) {
+		var token = signer.Encode(pagination.Cursor{Key: pagination.Key{Id: 1}, Resource: web.EmployeesPath, Sort: "id"})
+
+		status, body := get(t, "/api/v1/roles/?after="+token)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Contains(t, body.Error, "cursor does not match")
+	})
+}
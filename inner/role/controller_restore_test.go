@@ -0,0 +1,139 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRoleController_SoftDelete(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockRoleService)
+
+	server := &web.Server{
+		App:        app,
+		GroupRoles: app.Group("/api/v1/roles"),
+	}
+	ctrl := NewController(server, mockService, logger)
+
+	server.GroupRoles.Get("/", ctrl.FindAll)
+	server.GroupRoles.Post("/:id/restore", ctrl.Restore)
+
+	// Тестовые данные
+	testTime := time.Date(2025, 7, 21, 12, 0, 0, 0, time.UTC)
+	deletedAt := testTime.Add(time.Hour)
+
+	type rolesResult struct {
+		Success bool       `json:"success"`
+		Error   string     `json:"error"`
+		Data    []Response `json:"data"`
+	}
+	type roleResult struct {
+		Success bool     `json:"success"`
+		Error   string   `json:"error"`
+		Data    Response `json:"data"`
+	}
+
+	t.Run("should return deleted roles when includeDeleted is set", func(t *testing.T) {
+		expected := []Response{
+			{Id: 10, Name: "ADMIN", CreateAt: testTime, UpdateAt: testTime},
+			{Id: 11, Name: "USER", CreateAt: testTime, UpdateAt: testTime, DeleteAt: &deletedAt},
+		}
+		mockService.On("FindAll", appContext, true).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/roles/?includeDeleted=true", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result rolesResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, expected, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should restore deleted role", func(t *testing.T) {
+		expected := Response{Id: 11, Name: "USER", CreateAt: testTime, UpdateAt: testTime}
+		mockService.On("Restore", appContext, int64(11)).Return(expected, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/roles/11/restore", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var result roleResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.Success)
+		assert.Equal(t, expected, result.Data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when role is not deleted", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "deleted role with id 12 not found"}
+		mockService.On("Restore", appContext, int64(12)).Return(Response{}, notFound).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/roles/12/restore", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		var result roleResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.Success)
+		assert.Equal(t, notFound.Message, result.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when role name is taken", func(t *testing.T) {
+		conflict := domain.AlreadyExistsError{Message: "role with name USER already exists"}
+		mockService.On("Restore", appContext, int64(13)).Return(Response{}, conflict).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/roles/13/restore", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 when restore with invalid role id", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/roles/abc/restore", nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "Restore")
+	})
+}
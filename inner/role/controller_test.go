@@ -68,6 +68,7 @@ LOG_DEVELOP_MODE=true`
 	server.GroupEmployees.Put("/:id", ctrl.UpdateRole)
 	server.GroupEmployees.Delete("/ids", ctrl.DeleteByIds) // Сначала специфичный маршрут
 	server.GroupEmployees.Delete("/:id", ctrl.DeleteById)  // Потом общий
+	server.GroupEmployees.Post("/:id/employees", ctrl.AssignEmployee)
 
 	testID := int64(1)
 	testName := "ADMIN"
@@ -82,15 +83,14 @@ LOG_DEVELOP_MODE=true`
 		mockService.ExpectedCalls = nil // Сбрасываем моки перед тестом
 
 		expectedData := Response{
-			Id:         testID,
-			Name:       testName,
-			EmployeeID: nil,
-			CreateAt:   now,
-			UpdateAt:   now,
+			Id:       testID,
+			Name:     testName,
+			CreateAt: now,
+			UpdateAt: now,
 		}
 
 		// 3. Настройка мока
-		mockService.On("FindById", appContext, testID).Return(expectedData, nil).Once()
+		mockService.On("FindById", appContext, testID, false).Return(expectedData, nil).Once()
 
 		// 4. Выполнение запроса
 		req := httptest.NewRequest("GET", "/api/v1/roles/1", nil)
@@ -145,11 +145,10 @@ LOG_DEVELOP_MODE=true`
 		mockService.ExpectedCalls = nil // Сбрасываем моки перед тестом
 
 		expectedData := Response{
-			Id:         testID,
-			Name:       testName,
-			EmployeeID: nil,
-			CreateAt:   now,
-			UpdateAt:   now,
+			Id:       testID,
+			Name:     testName,
+			CreateAt: now,
+			UpdateAt: now,
 		}
 
 		createRequest := CreateRequest{
@@ -240,21 +239,18 @@ LOG_DEVELOP_MODE=true`
 	t.Run("should return role when update by ID", func(t *testing.T) {
 		mockService.ExpectedCalls = nil // Сбрасываем моки перед тестом
 
-		ID := int64(1)
 		expectedData := Response{
-			Id:         testID,
-			Name:       testName,
-			EmployeeID: &ID,
-			CreateAt:   now,
-			UpdateAt:   now,
+			Id:       testID,
+			Name:     testName,
+			CreateAt: now,
+			UpdateAt: now,
 		}
 
 		requestEmployee := UpdateRequest{
-			Id:         int64(0),
-			EmployeeID: &ID,
-			Name:       testName,
-			CreatedAt:  now,
-			UpdatedAt:  now,
+			Id:        int64(0),
+			Name:      testName,
+			CreatedAt: now,
+			UpdatedAt: now,
 		}
 
 		// 1. Сериализуем структуру в JSON
@@ -324,14 +320,12 @@ LOG_DEVELOP_MODE=true`
 	// update by id error
 	t.Run("should return error when update by ID role", func(t *testing.T) {
 		mockService.ExpectedCalls = nil // Сбрасываем моки перед тестом
-		ID := int64(1)
 
 		requestEmployee := UpdateRequest{
-			Id:         int64(0),
-			EmployeeID: &ID,
-			Name:       testName,
-			CreatedAt:  now,
-			UpdatedAt:  now,
+			Id:        int64(0),
+			Name:      testName,
+			CreatedAt: now,
+			UpdatedAt: now,
 		}
 		idParam := "abc"
 		// 1. Сериализуем структуру в JSON
@@ -407,7 +401,7 @@ LOG_DEVELOP_MODE=true`
 		}
 
 		// 2. Настройка мока
-		mockService.On("FindAllByIds", appContext, requestIDs).Return(expectedData, nil).Once()
+		mockService.On("FindAllByIds", appContext, requestIDs, false).Return(expectedData, nil).Once()
 
 		// 3. Создание запроса
 		req := httptest.NewRequest("GET", "/api/v1/roles/ids?ids="+idParam, nil)
@@ -469,7 +463,7 @@ LOG_DEVELOP_MODE=true`
 		idParam := "1,2,3"
 
 		// 2. Настройка мока
-		mockService.On("FindAllByIds", appContext, requestIDs).Return([]Response{}, nil).Once()
+		mockService.On("FindAllByIds", appContext, requestIDs, false).Return([]Response{}, nil).Once()
 
 		// 3. Создание запроса
 		req := httptest.NewRequest("GET", "/api/v1/roles/ids?ids="+idParam, nil)
@@ -765,6 +759,36 @@ LOG_DEVELOP_MODE=true`
 		assert.False(t, response.Success)
 		assert.Equal(t, expectedError.Error(), response.Error)
 	})
+
+	t.Run("should map assign employee errors without leaking internal details", func(t *testing.T) {
+		mockService.ExpectedCalls = nil
+		var request = AssignEmployeeRequest{EmployeeID: 7}
+		var assign = func() (int, string) {
+			req := httptest.NewRequest("POST", "/api/v1/roles/1/employees", strings.NewReader(`{"employeeId":7}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer closeBody(t, resp.Body)
+
+			var response struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+			return resp.StatusCode, response.Error
+		}
+
+		mockService.On("AssignEmployee", appContext, testID, request).
+			Return([]EmployeeResponse(nil), domain.SodViolationError{Message: "rule 3 violated"}).Once()
+		status, message := assign()
+		a.Equal(fiber.StatusConflict, status)
+		a.Equal("rule 3 violated", message)
+
+		mockService.On("AssignEmployee", appContext, testID, request).
+			Return([]EmployeeResponse(nil), errors.New("pq: connection refused")).Once()
+		status, message = assign()
+		a.Equal(fiber.StatusInternalServerError, status)
+		a.Equal(internalServerError, message)
+	})
 }
 func closeBody(t *testing.T, body io.ReadCloser) {
 	if err := body.Close(); err != nil {
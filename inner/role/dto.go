@@ -1,61 +1,240 @@
 package role
 
 import (
+	"github.com/lib/pq"
+	"idm/inner/audit"
+	"idm/inner/export"
+	"idm/inner/pagination"
+	"strconv"
 	"time"
 )
 
 type Entity struct {
-	Id         int64     `db:"id"`
-	Name       string    `db:"name"`
-	EmployeeID *int64    `db:"employee_id"` // Nullable, указатель
-	CreatedAt  time.Time `db:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at"`
+	Id        int64      `db:"id"`
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at"` // nil - роль не удалена
+	OwnerId   *int64     `db:"owner_id"`   // владелец роли, согласующий заявки на неё (nil - не назначен)
+	// Inherits - роли, которые подразумевает данная (хранятся в role_inheritance);
+	// при обновлении nil - наследование не меняется, пустой слайс - снять всё наследование
+	Inherits []int64 `db:"-"`
 }
 
 type Response struct {
-	Id         int64     `json:"id"`
-	Name       string    `json:"name"`
-	EmployeeID *int64    `json:"employeeID"`
-	CreateAt   time.Time `json:"createAt"`
-	UpdateAt   time.Time `json:"updateAt"`
+	Id       int64      `json:"id"`
+	Name     string     `json:"name"`
+	CreateAt time.Time  `json:"createAt"`
+	UpdateAt time.Time  `json:"updateAt"`
+	DeleteAt *time.Time `json:"deleteAt,omitempty"`
+	OwnerId  *int64     `json:"ownerId,omitempty"`
 }
 
 func (e *Entity) ToResponse() Response {
 	return Response{
+		Id:       e.Id,
+		Name:     e.Name,
+		CreateAt: e.CreatedAt,
+		UpdateAt: e.UpdatedAt,
+		DeleteAt: e.DeletedAt,
+		OwnerId:  e.OwnerId,
+	}
+}
+
+// ImpliedRoleEntity - роль, подразумеваемая заданной, и длина кратчайшей цепочки наследования до неё
+type ImpliedRoleEntity struct {
+	Entity
+	Level int64 `db:"level"`
+}
+
+// ImpliedRoleResponse model info
+// @Description Role implied through inheritance
+// @Description with role fields and level (0 - the role itself, 1 - inherited directly, ...)
+type ImpliedRoleResponse struct {
+	Response
+	Level int64 `json:"level"`
+}
+
+func (e *ImpliedRoleEntity) ToResponse() ImpliedRoleResponse {
+	return ImpliedRoleResponse{
+		Response: e.Entity.ToResponse(),
+		Level:    e.Level,
+	}
+}
+
+// InheritanceSnapshot - снимок наследования роли для журнала аудита (entity_id события - id роли)
+type InheritanceSnapshot struct {
+	RoleId   int64   `json:"roleId"`
+	Inherits []int64 `json:"inherits"`
+}
+
+// EmployeeEntity - сотрудник, которому назначена роль (строка из employee_roles + employees)
+type EmployeeEntity struct {
+	Id         int64      `db:"id"`
+	Name       string     `db:"name"`
+	AssignedAt time.Time  `db:"assigned_at"`
+	ValidFrom  time.Time  `db:"valid_from"`
+	ValidUntil *time.Time `db:"valid_until"` // nil - бессрочное назначение
+}
+
+// EmployeeResponse model info
+// @Description Employee assigned to role
+// @Description with employee id, name, assignedAt and validity window of the assignment
+type EmployeeResponse struct {
+	Id         int64      `json:"id"`
+	Name       string     `json:"name"`
+	AssignedAt time.Time  `json:"assignedAt"`
+	ValidFrom  time.Time  `json:"validFrom"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+}
+
+func (e *EmployeeEntity) ToResponse() EmployeeResponse {
+	return EmployeeResponse{
 		Id:         e.Id,
 		Name:       e.Name,
-		EmployeeID: e.EmployeeID,
-		CreateAt:   e.CreatedAt,
-		UpdateAt:   e.UpdatedAt,
+		AssignedAt: e.AssignedAt,
+		ValidFrom:  e.ValidFrom,
+		ValidUntil: e.ValidUntil,
+	}
+}
+
+// AssignmentEntity - назначение роли сотруднику со сроком действия
+type AssignmentEntity struct {
+	RoleId     int64      `db:"role_id"`
+	EmployeeId int64      `db:"employee_id"`
+	ValidFrom  *time.Time `db:"valid_from"`  // nil при назначении - с текущего момента
+	ValidUntil *time.Time `db:"valid_until"` // nil - бессрочно
+}
+
+// AssignmentResponse model info
+// @Description Role assignment of employee
+// @Description with role id, employee id and validity window
+type AssignmentResponse struct {
+	RoleId     int64      `json:"roleId"`
+	EmployeeId int64      `json:"employeeId"`
+	ValidFrom  *time.Time `json:"validFrom,omitempty"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+}
+
+func (e *AssignmentEntity) ToResponse() AssignmentResponse {
+	return AssignmentResponse{
+		RoleId:     e.RoleId,
+		EmployeeId: e.EmployeeId,
+		ValidFrom:  e.ValidFrom,
+		ValidUntil: e.ValidUntil,
+	}
+}
+
+// ToSnapshot - снимок назначения для журнала аудита
+func (e *AssignmentEntity) ToSnapshot() audit.EmployeeRoleSnapshot {
+	return audit.EmployeeRoleSnapshot{
+		EmployeeId: e.EmployeeId,
+		RoleId:     e.RoleId,
+		ValidFrom:  e.ValidFrom,
+		ValidUntil: e.ValidUntil,
+	}
+}
+
+// EffectiveEmployeeEntity - сотрудник, имеющий роль, и группа, через которую он её получил (nil - назначена напрямую)
+type EffectiveEmployeeEntity struct {
+	Id        int64   `db:"id"`
+	Name      string  `db:"name"`
+	GroupName *string `db:"group_name"`
+}
+
+// EffectiveEmployeeResponse model info
+// @Description Employee effectively having role
+// @Description with employee id, name, whether the role is assigned directly and groups it is granted through
+type EffectiveEmployeeResponse struct {
+	Id     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Direct bool     `json:"direct"`
+	Groups []string `json:"groups"`
+}
+
+// ToEffectiveEmployeeResponses - свернуть строки "сотрудник - источник" в список сотрудников, порядок сохраняется
+func ToEffectiveEmployeeResponses(entities []EffectiveEmployeeEntity) []EffectiveEmployeeResponse {
+	var employees = make([]EffectiveEmployeeResponse, 0, len(entities))
+	var indexes = make(map[int64]int, len(entities))
+	for _, entity := range entities {
+		idx, ok := indexes[entity.Id]
+		if !ok {
+			employees = append(employees, EffectiveEmployeeResponse{Id: entity.Id, Name: entity.Name, Groups: []string{}})
+			idx = len(employees) - 1
+			indexes[entity.Id] = idx
+		}
+		if entity.GroupName == nil {
+			employees[idx].Direct = true
+		} else {
+			employees[idx].Groups = append(employees[idx].Groups, *entity.GroupName)
+		}
 	}
+
+	return employees
 }
 
 type CreateRequest struct {
-	Name string `json:"name" validate:"required,min=2,max=155"`
+	Name    string `json:"name" validate:"required,min=2,max=155"`
+	OwnerId int64  `json:"ownerId" validate:"omitempty,min=1"` // 0 - без владельца
 }
 
 func (req *CreateRequest) ToEntity() *Entity {
-	return &Entity{Name: req.Name}
+	return &Entity{Name: req.Name, OwnerId: ownerIdOrNil(req.OwnerId)}
 }
 
 type UpdateRequest struct {
-	Id         int64     `json:"id" validate:"required,min=1,max=2147483647"`
-	EmployeeID *int64    `json:"employeeID" validate:"required,min=1,max=2147483647"`
-	Name       string    `json:"name" validate:"required,min=2,max=155"`
-	CreatedAt  time.Time `json:"createdAt" validate:"required"`
-	UpdatedAt  time.Time `json:"updatedAt" validate:"required"`
+	Id        int64     `json:"id" validate:"required,min=1,max=2147483647"`
+	Name      string    `json:"name" validate:"required,min=2,max=155"`
+	CreatedAt time.Time `json:"createdAt" validate:"required"`
+	UpdatedAt time.Time `json:"updatedAt" validate:"required"`
+	OwnerId   int64     `json:"ownerId" validate:"omitempty,min=1"` // 0 - снять владельца
+	// Inherits - роли, которые подразумевает данная (не передано - наследование не меняется)
+	Inherits []int64 `json:"inherits" validate:"omitempty,max=100,dive,min=1"`
 }
 
 func (req *UpdateRequest) ToEntity() *Entity {
 	return &Entity{
-		Id:         req.Id,
-		EmployeeID: req.EmployeeID,
-		Name:       req.Name,
-		CreatedAt:  req.CreatedAt,
-		UpdatedAt:  req.UpdatedAt,
+		Id:        req.Id,
+		Name:      req.Name,
+		CreatedAt: req.CreatedAt,
+		UpdatedAt: req.UpdatedAt,
+		OwnerId:   ownerIdOrNil(req.OwnerId),
+		Inherits:  req.Inherits,
+	}
+}
+
+// ownerIdOrNil - id владельца из запроса, 0 - владельца нет
+func ownerIdOrNil(ownerId int64) *int64 {
+	if ownerId == 0 {
+		return nil
+	}
+	return &ownerId
+}
+
+// AssignEmployeeRequest - назначение роли сотруднику
+// ValidFrom и ValidUntil ограничивают срок действия назначения (не заданы - с текущего момента и бессрочно)
+type AssignEmployeeRequest struct {
+	RoleID     int64      `json:"-" validate:"required,min=1"`
+	EmployeeID int64      `json:"employeeId" validate:"required,min=1"`
+	ValidFrom  *time.Time `json:"validFrom"`
+	ValidUntil *time.Time `json:"validUntil"`
+}
+
+func (req *AssignEmployeeRequest) ToEntity() AssignmentEntity {
+	return AssignmentEntity{
+		RoleId:     req.RoleID,
+		EmployeeId: req.EmployeeID,
+		ValidFrom:  req.ValidFrom,
+		ValidUntil: req.ValidUntil,
 	}
 }
 
+// RevokeEmployeeRequest - отзыв роли у сотрудника
+type RevokeEmployeeRequest struct {
+	RoleID     int64 `validate:"required,min=1"`
+	EmployeeID int64 `validate:"required,min=1"`
+}
+
 type UpdateByIDRequest struct {
 	ID int64 `validate:"required,min=1"`
 }
@@ -75,3 +254,89 @@ type FindAllByIdsRequest struct {
 type DeleteByIdRequest struct {
 	ID int64 `validate:"required,min=1"`
 }
+
+// KeysetRequest - параметры keyset-выдачи ролей: позиция курсора (не больше одной), сортировка и фильтры
+type KeysetRequest struct {
+	Limit          int    `validate:"required,min=1,max=500"`
+	Sort           string `validate:"required,max=50"`
+	TextFilter     string `validate:"omitempty,min=1,max=100,no_sql_injection"`
+	IncludeDeleted bool
+	// WithTotal - посчитать число ролей по фильтрам (отдельный запрос COUNT)
+	WithTotal bool
+	After     *pagination.Key
+	Before    *pagination.Key
+}
+
+// KeysetPage - страница ролей с позициями соседних страниц (nil - страницы нет)
+type KeysetPage struct {
+	Result []Response
+	Next   *pagination.Key
+	Prev   *pagination.Key
+	Total  *int64 // только при WithTotal
+}
+
+// KeysetPageResponse model info
+// @Description Roles page with opaque links to the next and previous pages
+// @Description total is present only when requested with withTotal=true
+type KeysetPageResponse struct {
+	Result []Response `json:"result"`
+	Limit  int        `json:"limit"`
+	Next   string     `json:"next,omitempty"`
+	Prev   string     `json:"prev,omitempty"`
+	Total  *int64     `json:"total,omitempty"`
+}
+
+// ExportRequest - параметры выгрузки ролей: фильтр по подстроке названия, как у постраничного поиска сотрудников
+type ExportRequest struct {
+	Format         string `validate:"required,oneof=csv ndjson xlsx"`
+	TextFilter     string `validate:"omitempty,min=1,max=100,no_sql_injection"`
+	IncludeDeleted bool
+}
+
+// ExportEntity - роль в выгрузке с id сотрудников, которым она действующе назначена напрямую
+type ExportEntity struct {
+	Entity
+	Employees pq.Int64Array `db:"employees"`
+}
+
+// ExportRecord model info
+// @Description Exported role: role fields and ids of employees it is directly assigned to
+type ExportRecord struct {
+	Response
+	Employees []int64 `json:"employees"`
+}
+
+// exportColumns - колонки CSV и XLSX в порядке ExportRecord.Values
+var exportColumns = []string{"id", "name", "ownerId", "createdAt", "updatedAt", "deletedAt", "employees"}
+
+func (e *ExportEntity) ToExportRecord() ExportRecord {
+	var employees = []int64(e.Employees)
+	if employees == nil {
+		employees = []int64{}
+	}
+	return ExportRecord{Response: e.Entity.ToResponse(), Employees: employees}
+}
+
+// Values - отметки времени в RFC3339 в UTC
+func (r ExportRecord) Values() []string {
+	var ownerId, deletedAt string
+	if r.OwnerId != nil {
+		ownerId = strconv.FormatInt(*r.OwnerId, 10)
+	}
+	if r.DeleteAt != nil {
+		deletedAt = r.DeleteAt.UTC().Format(time.RFC3339)
+	}
+	var employees = make([]string, 0, len(r.Employees))
+	for _, id := range r.Employees {
+		employees = append(employees, strconv.FormatInt(id, 10))
+	}
+	return []string{
+		strconv.FormatInt(r.Id, 10),
+		r.Name,
+		ownerId,
+		r.CreateAt.UTC().Format(time.RFC3339),
+		r.UpdateAt.UTC().Format(time.RFC3339),
+		deletedAt,
+		export.List(employees),
+	}
+}
@@ -3,24 +3,38 @@ package role
 import (
 	"context"
 	"github.com/stretchr/testify/mock"
+	"io"
 )
 
 type MockRoleService struct {
 	mock.Mock
 }
 
-func (m *MockRoleService) FindAll(ctx context.Context) ([]Response, error) {
-	args := m.Called(ctx)
+func (m *MockRoleService) FindAll(ctx context.Context, includeDeleted bool) ([]Response, error) {
+	args := m.Called(ctx, includeDeleted)
 	return args.Get(0).([]Response), args.Error(1) // Важно: правильный тип
 }
 
-func (m *MockRoleService) FindAllByIds(ctx context.Context, ids []int64) ([]Response, error) {
-	args := m.Called(ctx, ids)
+func (m *MockRoleService) GetKeysetPage(ctx context.Context, request KeysetRequest) (KeysetPage, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(KeysetPage), args.Error(1)
+}
+
+func (m *MockRoleService) Export(ctx context.Context, request ExportRequest) (func(w io.Writer) error, error) {
+	args := m.Called(ctx, request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(func(w io.Writer) error), args.Error(1)
+}
+
+func (m *MockRoleService) FindAllByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Response, error) {
+	args := m.Called(ctx, ids, includeDeleted)
 	return args.Get(0).([]Response), args.Error(1) // Важно: правильный тип
 }
 
-func (m *MockRoleService) FindById(ctx context.Context, id int64) (Response, error) {
-	args := m.Called(ctx, id)
+func (m *MockRoleService) FindById(ctx context.Context, id int64, includeDeleted bool) (Response, error) {
+	args := m.Called(ctx, id, includeDeleted)
 	return args.Get(0).(Response), args.Error(1) // Важно: правильный тип
 }
 
@@ -44,4 +58,34 @@ func (m *MockRoleService) DeleteByIds(ctx context.Context, ids []int64) (Respons
 	return args.Get(0).(Response), args.Error(1) // Важно: правильный тип
 }
 
+func (m *MockRoleService) Restore(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockRoleService) FindEffectiveEmployees(ctx context.Context, roleId int64) ([]EffectiveEmployeeResponse, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]EffectiveEmployeeResponse), args.Error(1)
+}
+
+func (m *MockRoleService) FindImpliedRoles(ctx context.Context, roleId int64) ([]ImpliedRoleResponse, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]ImpliedRoleResponse), args.Error(1)
+}
+
+func (m *MockRoleService) FindEmployees(ctx context.Context, roleId int64) ([]EmployeeResponse, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]EmployeeResponse), args.Error(1) // Важно: правильный тип
+}
+
+func (m *MockRoleService) AssignEmployee(ctx context.Context, roleId int64, request AssignEmployeeRequest) ([]EmployeeResponse, error) {
+	args := m.Called(ctx, roleId, request)
+	return args.Get(0).([]EmployeeResponse), args.Error(1) // Важно: правильный тип
+}
+
+func (m *MockRoleService) RevokeEmployee(ctx context.Context, roleId int64, employeeId int64) ([]EmployeeResponse, error) {
+	args := m.Called(ctx, roleId, employeeId)
+	return args.Get(0).([]EmployeeResponse), args.Error(1) // Важно: правильный тип
+}
+
 // Добавьте остальные методы интерфейса
@@ -2,10 +2,27 @@ package role
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"idm/inner/outbox"
+	"idm/inner/pagination"
+	"idm/inner/sod"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
+// inheritanceLockKey - ключ pg_advisory_xact_lock, сериализующий изменения наследования ролей
+const inheritanceLockKey int64 = 0x726f6c655f696e68 // "role_inh"
+
+// ErrRoleCycle - унаследованная роль сама (прямо или транзитивно) подразумевает обновляемую
+var ErrRoleCycle = errors.New("inherited role already implies the role")
+
 type Repository struct {
 	db *sqlx.DB
 }
@@ -15,18 +32,89 @@ func NewRepository(databese *sqlx.DB) *Repository {
 	return &Repository{db: databese}
 }
 
-// FindAllRoles - найти все элементы коллекции
-func (r *Repository) FindAllRoles(ctx context.Context) (roleEntities []Entity, err error) {
+// FindAllRoles - найти все элементы коллекции (мягко удалённые - только при includeDeleted)
+func (r *Repository) FindAllRoles(ctx context.Context, includeDeleted bool) (roleEntities []Entity, err error) {
 	//	err = r.db.Select(&roleEntities, "SELECT * FROM roles")
-	query := `SELECT * FROM roles`
-	err = r.db.SelectContext(ctx, &roleEntities, query)
+	query := `SELECT * FROM roles WHERE ($1 OR deleted_at IS NULL)`
+	err = r.db.SelectContext(ctx, &roleEntities, query, includeDeleted)
 
 	return roleEntities, err
 }
 
+// Export - пройти роли (мягко удалённые - при includeDeleted) по порядку id серверным курсором, передавая
+// каждую в fn. textFilter - подстрока названия от 3 символов; сотрудники - действующие прямые назначения
+func (r *Repository) Export(
+	ctx context.Context,
+	textFilter string,
+	includeDeleted bool,
+	fn func(entity ExportEntity) error,
+) error {
+	query := `SELECT id, name, owner_id, created_at, updated_at, deleted_at,
+		ARRAY(
+			SELECT e.id FROM employee_roles er
+			JOIN employees e ON e.id = er.employee_id
+			WHERE er.role_id = r.id AND e.deleted_at IS NULL
+				AND er.valid_from <= NOW() AND (er.valid_until IS NULL OR er.valid_until > NOW())
+			ORDER BY e.id
+		) AS employees
+		FROM roles r WHERE ($1 OR deleted_at IS NULL)`
+	var args = []any{includeDeleted}
+
+	if filteredText := strings.TrimSpace(textFilter); len(filteredText) >= 3 {
+		args = append(args, "%"+strings.ReplaceAll(filteredText, "%", "\\%")+"%")
+		query += " AND name ILIKE $2"
+	}
+	query += " ORDER BY id"
+
+	return database.StreamCursor(ctx, r.db, "roles_export", query, args, fn)
+}
+
+// FindKeysetPage - страница ролей в порядке query.OrderBy() от позиции курсора query (не больше query.Fetch()).
+// textFilter - подстрока названия от 3 символов, как в Export
+func (r *Repository) FindKeysetPage(
+	ctx context.Context,
+	query pagination.Query,
+	textFilter string,
+	includeDeleted bool,
+) (roleEntities []Entity, err error) {
+	selectQuery := `SELECT id, name, owner_id, created_at, updated_at, deleted_at FROM roles WHERE ($1 OR deleted_at IS NULL)`
+	var args = []any{includeDeleted}
+
+	if filteredText := strings.TrimSpace(textFilter); len(filteredText) >= 3 {
+		args = append(args, "%"+strings.ReplaceAll(filteredText, "%", "\\%")+"%")
+		selectQuery += fmt.Sprintf(" AND name ILIKE $%d", len(args))
+	}
+	if condition, conditionArgs := query.Condition(len(args) + 1); condition != "" {
+		args = append(args, conditionArgs...)
+		selectQuery += " AND " + condition
+	}
+	args = append(args, query.Fetch())
+	selectQuery += fmt.Sprintf(" ORDER BY %s LIMIT $%d", query.OrderBy(), len(args))
+
+	err = r.db.SelectContext(ctx, &roleEntities, selectQuery, args...)
+	return roleEntities, err
+}
+
+// CountRoles - число ролей по тем же условиям, что и FindKeysetPage, без позиции курсора
+func (r *Repository) CountRoles(ctx context.Context, textFilter string, includeDeleted bool) (total int64, err error) {
+	query := "SELECT COUNT(*) FROM roles WHERE ($1 OR deleted_at IS NULL)"
+	var args = []any{includeDeleted}
+
+	if filteredText := strings.TrimSpace(textFilter); len(filteredText) >= 3 {
+		args = append(args, "%"+strings.ReplaceAll(filteredText, "%", "\\%")+"%")
+		query += " AND name ILIKE $2"
+	}
+	err = r.db.GetContext(ctx, &total, query, args...)
+	return total, err
+}
+
 // FindAllRolesByIds - найти слайс элементов коллекции по слайсу их id
-func (r *Repository) FindAllRolesByIds(ctx context.Context, ids []int64) (roleEntities []Entity, err error) {
-	query, args, err := sqlx.In("SELECT * FROM roles WHERE id IN (?)", ids)
+func (r *Repository) FindAllRolesByIds(
+	ctx context.Context,
+	ids []int64,
+	includeDeleted bool,
+) (roleEntities []Entity, err error) {
+	query, args, err := sqlx.In("SELECT * FROM roles WHERE id IN (?) AND (? OR deleted_at IS NULL)", ids, includeDeleted)
 
 	if err != nil {
 		return nil, err
@@ -40,53 +128,463 @@ func (r *Repository) FindAllRolesByIds(ctx context.Context, ids []int64) (roleEn
 
 // CreateRole - добавить новый элемент в коллекцию
 func (r *Repository) CreateRole(ctx context.Context, entity *Entity) (roleEntity Entity, err error) {
-	err = r.db.GetContext(
-		ctx,
-		&roleEntity,
-		`INSERT INTO roles (name, employee_id, created_at, updated_at) 
-        VALUES ($1, $2, $3, $4)
-        RETURNING id, name, employee_id, created_at, updated_at`,
-		entity.Name, entity.EmployeeID, time.Now(), time.Now(),
-	)
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(
+			ctx,
+			&roleEntity,
+			`INSERT INTO roles (name, owner_id, created_at, updated_at) 
+			VALUES ($1, $2, $3, $4)
+			RETURNING *`,
+			entity.Name, entity.OwnerId, time.Now(), time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityRole,
+			EntityId:   roleEntity.Id,
+			After:      roleEntity.ToResponse(),
+		})
+	})
 
 	return roleEntity, err
 }
 
 // FindById - найти элемент коллекции по его id (этот метод мы реализовали на уроке)
-func (r *Repository) FindById(ctx context.Context, id int64) (entity Entity, err error) {
+func (r *Repository) FindById(ctx context.Context, id int64, includeDeleted bool) (entity Entity, err error) {
 	//err = r.db.Get(&entity, "SELECT * FROM roles WHERE id = $1", id)
-	err = r.db.GetContext(ctx, &entity, "SELECT * FROM roles WHERE id = $1", id)
+	err = r.db.GetContext(
+		ctx,
+		&entity,
+		"SELECT * FROM roles WHERE id = $1 AND ($2 OR deleted_at IS NULL)",
+		id, includeDeleted,
+	)
 
 	return entity, err
 }
 
 // UpdateEmployee - UPDATE / Для Update лучше принимать указатель, так как мы модифицируем сущность: -> *
 func (r *Repository) UpdateRole(ctx context.Context, entity *Entity) error {
-	_, err := r.db.ExecContext(
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before Entity
+		err := tx.GetContext(
+			ctx,
+			&before,
+			"SELECT * FROM roles WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+			entity.Id,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // обновлять нечего, событие не пишем
+		}
+		if err != nil {
+			return err
+		}
+
+		var after Entity
+		err = tx.GetContext(
+			ctx,
+			&after,
+			"UPDATE roles SET name = $1, owner_id = $2, updated_at = $3 WHERE id = $4 RETURNING *",
+			entity.Name, entity.OwnerId, time.Now(), entity.Id,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityRole,
+			EntityId:   after.Id,
+			Before:     before.ToResponse(),
+			After:      after.ToResponse(),
+		})
+		if err != nil || entity.Inherits == nil {
+			return err
+		}
+
+		return replaceInheritanceTx(ctx, tx, entity.Id, entity.Inherits)
+	})
+}
+
+// replaceInheritanceTx - заменить набор ролей, которые подразумевает роль. Если одна из них сама
+// (прямо или транзитивно) подразумевает роль - ErrRoleCycle, если наследование нарушает правило разделения
+// полномочий для кого-то из обладателей роли - sod.ErrViolation. Изменения сериализуются advisory-блокировкой,
+// иначе два встречных наследования проверялись бы параллельно и вместе образовали цикл
+func replaceInheritanceTx(ctx context.Context, tx *sqlx.Tx, roleId int64, inherits []int64) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", inheritanceLockKey); err != nil {
+		return err
+	}
+
+	var before []int64
+	err := tx.SelectContext(
+		ctx,
+		&before,
+		"DELETE FROM role_inheritance WHERE role_id = $1 RETURNING inherited_role_id",
+		roleId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(inherits) > 0 {
+		query, args, err := sqlx.In(
+			`WITH RECURSIVE implied AS (
+				SELECT id FROM roles WHERE id IN (?)
+				UNION
+				SELECT ri.inherited_role_id FROM role_inheritance ri JOIN implied i ON ri.role_id = i.id
+			)
+			SELECT EXISTS(SELECT 1 FROM implied WHERE id = ?)`,
+			inherits, roleId,
+		)
+		if err != nil {
+			return err
+		}
+		var isCycle bool
+		if err = tx.GetContext(ctx, &isCycle, tx.Rebind(query), args...); err != nil {
+			return err
+		}
+		if isCycle {
+			return ErrRoleCycle
+		}
+		if err = sod.CheckInheritanceTx(ctx, tx, roleId, inherits); err != nil {
+			return err
+		}
+	}
+
+	var now = time.Now()
+	for _, inheritedId := range inherits {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO role_inheritance (role_id, inherited_role_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (role_id, inherited_role_id) DO NOTHING`,
+			roleId, inheritedId, now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	var after []int64
+	err = tx.SelectContext(
 		ctx,
-		"UPDATE roles SET name = $1, updated_at = $2 WHERE id = $3",
-		entity.Name, time.Now(), entity.Id,
+		&after,
+		"SELECT inherited_role_id FROM role_inheritance WHERE role_id = $1 ORDER BY inherited_role_id",
+		roleId,
 	)
+	if err != nil {
+		return err
+	}
+	sort.Slice(before, func(i, j int) bool { return before[i] < before[j] })
+	if slices.Equal(before, after) {
+		return nil // набор не изменился, событие не пишем
+	}
+
+	return outbox.RecordTx(ctx, tx, audit.Event{
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityRoleInheritance,
+		EntityId:   roleId,
+		Before:     InheritanceSnapshot{RoleId: roleId, Inherits: before},
+		After:      InheritanceSnapshot{RoleId: roleId, Inherits: after},
+	})
+}
 
-	return err
+// FindImpliedRoles - роль и все роли, которые она подразумевает прямо или транзитивно
+func (r *Repository) FindImpliedRoles(ctx context.Context, roleId int64) (roles []ImpliedRoleEntity, err error) {
+	query := `
+		SELECT r.*, ir.level
+		FROM role_implied_roles ir
+		JOIN roles r ON r.id = ir.implied_role_id
+		WHERE ir.role_id = $1
+		ORDER BY ir.level, r.id
+	`
+	err = r.db.SelectContext(ctx, &roles, query, roleId)
+
+	return roles, err
 }
 
-// DeleteAllRolesByIds - удалить элементы по слайсу их id
+// DeleteAllRolesByIds - мягко удалить элементы по слайсу их id (назначения сотрудникам сохраняются)
 func (r *Repository) DeleteAllRolesByIds(ctx context.Context, ids []int64) (err error) {
-	query, args, err := sqlx.In("DELETE FROM roles WHERE id IN (?)", ids)
+	query, args, err := sqlx.In(
+		"UPDATE roles SET deleted_at = ? WHERE id IN (?) AND deleted_at IS NULL RETURNING *",
+		time.Now(), ids,
+	)
 	if err != nil {
 		return err
 	}
 
 	query = r.db.Rebind(query)
-	_, err = r.db.ExecContext(ctx, query, args...)
 
-	return err
+	return r.changeTx(ctx, audit.ActionDelete, query, args...)
 }
 
-// DeleteRoleById - удалить элемент коллекции по его id
+// DeleteRoleById - мягко удалить элемент коллекции по его id (назначения сотрудникам сохраняются)
 func (r *Repository) DeleteRoleById(ctx context.Context, id int64) (err error) {
-	_, err = r.db.ExecContext(ctx, "DELETE FROM roles WHERE id = $1", id)
+	return r.changeTx(
+		ctx,
+		audit.ActionDelete,
+		"UPDATE roles SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL RETURNING *",
+		id, time.Now(),
+	)
+}
+
+// ExistsByName - есть ли не удалённая роль с таким именем
+func (r *Repository) ExistsByName(ctx context.Context, name string) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1 AND deleted_at IS NULL)",
+		name,
+	)
+
+	return isExists, err
+}
+
+// RestoreRole - восстановить мягко удалённую роль, возвращает false если такой нет
+func (r *Repository) RestoreRole(ctx context.Context, id int64) (isRestored bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before Entity
+		err := tx.GetContext(
+			ctx,
+			&before,
+			"SELECT * FROM roles WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE",
+			id,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var after Entity
+		err = tx.GetContext(
+			ctx,
+			&after,
+			"UPDATE roles SET deleted_at = NULL, updated_at = $2 WHERE id = $1 RETURNING *",
+			id, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		isRestored = true
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionRestore,
+			EntityType: audit.EntityRole,
+			EntityId:   id,
+			Before:     before.ToResponse(),
+			After:      after.ToResponse(),
+		})
+	})
+
+	return isRestored && err == nil, err
+}
+
+// PurgeDeletedRoles - окончательно удалить роли, мягко удалённые раньше before.
+// Назначения сотрудникам и разрешения роли удаляются каскадно. Возвращает число удалённых
+func (r *Repository) PurgeDeletedRoles(ctx context.Context, before time.Time) (purged int64, err error) {
+	var deleted []Entity
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(
+			ctx,
+			&deleted,
+			"DELETE FROM roles WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING *",
+			before,
+		)
+		if err != nil {
+			return err
+		}
+		return r.recordTx(ctx, tx, audit.ActionPurge, deleted)
+	})
+
+	return int64(len(deleted)), err
+}
+
+// changeTx - изменить роли запросом с RETURNING * и записать по событию аудита
+// на каждую затронутую в одной транзакции
+func (r *Repository) changeTx(ctx context.Context, action string, query string, args ...interface{}) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var changed []Entity
+		if err := tx.SelectContext(ctx, &changed, query, args...); err != nil {
+			return err
+		}
+		return r.recordTx(ctx, tx, action, changed)
+	})
+}
+
+// recordTx - события аудита для удалённых ролей. При мягком удалении снимок "до" - без deleteAt,
+// "после" - с ним; при окончательном удалении "до" - удалённая строка как есть
+func (r *Repository) recordTx(ctx context.Context, tx *sqlx.Tx, action string, entities []Entity) error {
+	for _, entity := range entities {
+		var event = audit.Event{
+			Action:     action,
+			EntityType: audit.EntityRole,
+			EntityId:   entity.Id,
+			Before:     entity.ToResponse(),
+		}
+		if action == audit.ActionDelete {
+			var before = entity
+			before.DeletedAt = nil
+			event.Before = before.ToResponse()
+			event.After = entity.ToResponse()
+		}
+		if err := outbox.RecordTx(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExistsEmployeeById - проверить наличие сотрудника с заданным id, которому можно назначать роли
+// (мягко удалённые и уволенные не учитываются)
+func (r *Repository) ExistsEmployeeById(ctx context.Context, employeeId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL AND status <> 'terminated')",
+		employeeId,
+	)
+
+	return isExists, err
+}
+
+// FindEmployeesByRoleId - найти всех сотрудников, которым назначена роль (истёкшие и ещё не начавшиеся назначения не учитываются)
+func (r *Repository) FindEmployeesByRoleId(ctx context.Context, roleId int64) (employees []EmployeeEntity, err error) {
+	query := `
+		SELECT e.id, e.name, er.created_at AS assigned_at, er.valid_from, er.valid_until
+		FROM employee_roles er
+		JOIN employees e ON e.id = er.employee_id
+		WHERE er.role_id = $1 AND e.deleted_at IS NULL
+			AND er.valid_from <= NOW() AND (er.valid_until IS NULL OR er.valid_until > NOW())
+		ORDER BY e.id
+	`
+	err = r.db.SelectContext(ctx, &employees, query, roleId)
+
+	return employees, err
+}
+
+// FindEffectiveEmployeesByRoleId - сотрудники, имеющие роль напрямую (group_name IS NULL) или через группы,
+// в которые они входят напрямую или через вложенные группы
+func (r *Repository) FindEffectiveEmployeesByRoleId(
+	ctx context.Context,
+	roleId int64,
+) (employees []EffectiveEmployeeEntity, err error) {
+	query := `
+		SELECT e.id, e.name, g.name AS group_name
+		FROM employee_effective_roles er
+		JOIN employees e ON e.id = er.employee_id AND e.deleted_at IS NULL
+		LEFT JOIN groups g ON g.id = er.group_id
+		WHERE er.role_id = $1
+		ORDER BY e.id, g.name NULLS FIRST
+	`
+	err = r.db.SelectContext(ctx, &employees, query, roleId)
+
+	return employees, err
+}
+
+// AssignEmployee - назначить роль сотруднику. Повторное назначение с тем же сроком игнорируется,
+// с другим - заменяет срок действия (не заданное начало - с текущего момента, если назначение ещё не началось).
+// Назначение, нарушающее правило разделения полномочий, не выполняется - sod.ErrViolation
+func (r *Repository) AssignEmployee(ctx context.Context, assignment AssignmentEntity) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := sod.CheckAssignmentTx(ctx, tx, assignment.EmployeeId, assignment.RoleId); err != nil {
+			return err
+		}
+
+		var after AssignmentEntity
+		err := tx.GetContext(
+			ctx,
+			&after,
+			`INSERT INTO employee_roles (employee_id, role_id, created_at, valid_from, valid_until)
+			VALUES ($1, $2, $3, COALESCE($4::TIMESTAMPTZ, $3), $5)
+			ON CONFLICT (employee_id, role_id) DO UPDATE
+			SET valid_from = COALESCE($4::TIMESTAMPTZ, LEAST(employee_roles.valid_from, $3)), valid_until = $5
+			WHERE (COALESCE($4::TIMESTAMPTZ, LEAST(employee_roles.valid_from, $3)), $5::TIMESTAMPTZ)
+				IS DISTINCT FROM (employee_roles.valid_from, employee_roles.valid_until)
+			RETURNING role_id, employee_id, valid_from, valid_until`,
+			assignment.EmployeeId, assignment.RoleId, time.Now(), assignment.ValidFrom, assignment.ValidUntil,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // назначение с тем же сроком уже есть
+		}
+		if err != nil {
+			return err
+		}
+
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionAssign,
+			EntityType: audit.EntityEmployeeRole,
+			EntityId:   after.EmployeeId,
+			After:      after.ToSnapshot(),
+		})
+	})
+}
+
+// RevokeEmployee - отозвать роль у сотрудника, возвращает false если назначения не было
+func (r *Repository) RevokeEmployee(ctx context.Context, roleId int64, employeeId int64) (isRevoked bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			"DELETE FROM employee_roles WHERE role_id = $1 AND employee_id = $2",
+			roleId, employeeId,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		isRevoked = true
+		return outbox.RecordTx(ctx, tx, audit.Event{
+			Action:     audit.ActionRevoke,
+			EntityType: audit.EntityEmployeeRole,
+			EntityId:   employeeId,
+			Before:     audit.EmployeeRoleSnapshot{EmployeeId: employeeId, RoleId: roleId},
+		})
+	})
+
+	return isRevoked && err == nil, err
+}
+
+// RevokeExpiredAssignments - отозвать назначения, срок действия которых истёк к моменту now,
+// по событию аудита на каждое назначение
+func (r *Repository) RevokeExpiredAssignments(
+	ctx context.Context,
+	now time.Time,
+) (expired []AssignmentEntity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(
+			ctx,
+			&expired,
+			`DELETE FROM employee_roles WHERE valid_until <= $1
+			RETURNING role_id, employee_id, valid_from, valid_until`,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, assignment := range expired {
+			err := outbox.RecordTx(ctx, tx, audit.Event{
+				Action:     audit.ActionRevoke,
+				EntityType: audit.EntityEmployeeRole,
+				EntityId:   assignment.EmployeeId,
+				Before:     assignment.ToSnapshot(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	return expired, nil
 }
@@ -2,8 +2,16 @@ package role
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"idm/inner/domain"
+	"idm/inner/export"
+	"idm/inner/pagination"
+	"idm/inner/sod"
+	"io"
+	"slices"
+	"time"
 )
 
 type Service struct {
@@ -12,13 +20,26 @@ type Service struct {
 }
 
 type Repo interface {
-	FindAllRoles(ctx context.Context) ([]Entity, error)
-	FindAllRolesByIds(ctx context.Context, ids []int64) ([]Entity, error)
-	FindById(ctx context.Context, id int64) (Entity, error)
+	FindAllRoles(ctx context.Context, includeDeleted bool) ([]Entity, error)
+	FindAllRolesByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Entity, error)
+	Export(ctx context.Context, textFilter string, includeDeleted bool, fn func(entity ExportEntity) error) error
+	FindKeysetPage(ctx context.Context, query pagination.Query, textFilter string, includeDeleted bool) ([]Entity, error)
+	CountRoles(ctx context.Context, textFilter string, includeDeleted bool) (int64, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (Entity, error)
+	ExistsByName(ctx context.Context, name string) (bool, error)
 	CreateRole(ctx context.Context, entity *Entity) (Entity, error)
 	UpdateRole(ctx context.Context, entity *Entity) error
 	DeleteRoleById(ctx context.Context, id int64) error
 	DeleteAllRolesByIds(ctx context.Context, ids []int64) error
+	RestoreRole(ctx context.Context, id int64) (bool, error)
+	PurgeDeletedRoles(ctx context.Context, before time.Time) (int64, error)
+	ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error)
+	FindEmployeesByRoleId(ctx context.Context, roleId int64) ([]EmployeeEntity, error)
+	FindEffectiveEmployeesByRoleId(ctx context.Context, roleId int64) ([]EffectiveEmployeeEntity, error)
+	AssignEmployee(ctx context.Context, assignment AssignmentEntity) error
+	RevokeEmployee(ctx context.Context, roleId int64, employeeId int64) (bool, error)
+	FindImpliedRoles(ctx context.Context, roleId int64) ([]ImpliedRoleEntity, error)
+	RevokeExpiredAssignments(ctx context.Context, now time.Time) ([]AssignmentEntity, error)
 }
 type Validator interface {
 	Validate(request any) error
@@ -32,9 +53,9 @@ func NewService(repo Repo, validator Validator) *Service {
 	}
 }
 
-// FindAll - найти все элементы коллекции
-func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
-	var roles, err = svc.repo.FindAllRoles(ctx)
+// FindAll - найти все элементы коллекции, мягко удалённые - только при includeDeleted
+func (svc *Service) FindAll(ctx context.Context, includeDeleted bool) ([]Response, error) {
+	var roles, err = svc.repo.FindAllRoles(ctx, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("error finding Roles : %w", err)
 	}
@@ -46,17 +67,94 @@ func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
 	return responses, err
 }
 
+// keysetSorts - поля сортировки keyset-выдачи ролей ("name" - по возрастанию, "-name" - по убыванию)
+var keysetSorts = map[string]pagination.SortKey[Entity]{
+	"id": {Column: "id", Key: func(e Entity) pagination.Key {
+		return pagination.Key{Id: e.Id}
+	}},
+	"name": {Column: "name", Key: func(e Entity) pagination.Key {
+		return pagination.Key{Value: e.Name, Id: e.Id}
+	}},
+	"createdAt": {Column: "created_at", Key: func(e Entity) pagination.Key {
+		return pagination.Key{Value: e.CreatedAt.Format(time.RFC3339Nano), Id: e.Id}
+	}},
+}
+
+// GetKeysetPage - страница ролей после или до позиции курсора в порядке (request.Sort, id).
+// Число ролей считается отдельным запросом только при request.WithTotal
+func (svc *Service) GetKeysetPage(ctx context.Context, request KeysetRequest) (KeysetPage, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return KeysetPage{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.TextFilter != "" && len(request.TextFilter) < 3 {
+		return KeysetPage{}, domain.RequestValidationError{Message: "TextFilter must be at least 3 characters"}
+	}
+	if request.After != nil && request.Before != nil {
+		return KeysetPage{}, domain.RequestValidationError{Message: "after and before cannot be used together"}
+	}
+	sortKey, order, err := pagination.ParseSort(request.Sort, keysetSorts)
+	if err != nil {
+		return KeysetPage{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	var query = pagination.Query{Order: order, Limit: request.Limit, After: request.After, Before: request.Before}
+	roles, err := svc.repo.FindKeysetPage(ctx, query, request.TextFilter, request.IncludeDeleted)
+	if err != nil {
+		return KeysetPage{}, fmt.Errorf("error fetching roles keyset page: %w", err)
+	}
+	var page = pagination.NewPage(roles, query, sortKey)
+
+	var response = KeysetPage{Result: make([]Response, 0, len(page.Rows)), Next: page.Next, Prev: page.Prev}
+	for _, entity := range page.Rows {
+		response.Result = append(response.Result, entity.ToResponse())
+	}
+	if request.WithTotal {
+		total, err := svc.repo.CountRoles(ctx, request.TextFilter, request.IncludeDeleted)
+		if err != nil {
+			return KeysetPage{}, fmt.Errorf("error counting roles: %w", err)
+		}
+		response.Total = &total
+	}
+	return response, nil
+}
+
+// Export - проверить запрос выгрузки и вернуть функцию, записывающую роли в w в формате request.Format.
+// Запрос проверяется сразу, чтобы ошибка вернулась до начала потоковой передачи
+func (svc *Service) Export(ctx context.Context, request ExportRequest) (func(w io.Writer) error, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+	if request.TextFilter != "" && len(request.TextFilter) < 3 {
+		return nil, domain.RequestValidationError{Message: "TextFilter must be at least 3 characters"}
+	}
+
+	return func(w io.Writer) error {
+		writer, err := export.NewWriter(request.Format, w, exportColumns)
+		if err != nil {
+			return err
+		}
+		err = svc.repo.Export(ctx, request.TextFilter, request.IncludeDeleted, func(entity ExportEntity) error {
+			return writer.Write(entity.ToExportRecord())
+		})
+		if err != nil {
+			return fmt.Errorf("error exporting roles: %w", err)
+		}
+		return writer.Close()
+	}, nil
+}
+
 // FindAllByIds - найти слайс элементов коллекции по слайсу их id
 func (svc *Service) FindAllByIds(
 	ctx context.Context,
 	ids []int64,
+	includeDeleted bool,
 ) ([]Response, error) {
 	request := FindAllByIdsRequest{IDs: ids}                // Создаем DTO для валидации
 	if err := svc.validator.Validate(request); err != nil { // Валидируем запрос
 		return []Response{}, domain.RequestValidationError{Message: err.Error()}
 	}
 
-	var roles, err = svc.repo.FindAllRolesByIds(ctx, ids)
+	var roles, err = svc.repo.FindAllRolesByIds(ctx, ids, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("error find all Roles with IDs: %d %w", ids, err)
 	}
@@ -72,6 +170,7 @@ func (svc *Service) FindAllByIds(
 func (svc *Service) FindById(
 	ctx context.Context,
 	id int64,
+	includeDeleted bool,
 ) (Response, error) {
 	request := FindByIDRequest{ID: id}        // Создаем DTO для валидации
 	var err = svc.validator.Validate(request) // Валидируем запрос
@@ -80,7 +179,7 @@ func (svc *Service) FindById(
 		return Response{}, domain.RequestValidationError{Message: err.Error()}
 	}
 
-	entity, err := svc.repo.FindById(ctx, id)
+	entity, err := svc.repo.FindById(ctx, id, includeDeleted)
 	if err != nil {
 		// в случае ошибки, вернём пустую структуру Response и обёрнутую нами ошибку
 		return Response{}, fmt.Errorf("error finding role with id %d: %w", id, err)
@@ -103,6 +202,9 @@ func (svc *Service) CreateRole(
 
 	//save
 	entityRole := request.ToEntity()
+	if err = svc.checkOwner(ctx, entityRole.OwnerId); err != nil {
+		return Response{}, err
+	}
 	entityRsl, err := svc.repo.CreateRole(ctx, entityRole)
 	if err != nil {
 		return Response{}, fmt.Errorf("error creating Role with name %s: %w", entityRole.Name, err)
@@ -122,8 +224,25 @@ func (svc *Service) UpdateRole(
 		return Response{}, domain.RequestValidationError{Message: err.Error()}
 	}
 
+	if request.Inherits != nil {
+		if err = svc.checkInherits(ctx, id, request.Inherits); err != nil {
+			return Response{}, err
+		}
+	}
+
 	entity := request.ToEntity()
+	if err = svc.checkOwner(ctx, entity.OwnerId); err != nil {
+		return Response{}, err
+	}
 	err = svc.repo.UpdateRole(ctx, entity)
+	if errors.Is(err, ErrRoleCycle) {
+		return Response{}, domain.RequestValidationError{
+			Message: fmt.Sprintf("inheriting roles %v would make role %d imply itself", request.Inherits, id),
+		}
+	}
+	if errors.Is(err, sod.ErrViolation) {
+		return Response{}, domain.SodViolationError{Message: err.Error()}
+	}
 	if err != nil {
 		return Response{}, fmt.Errorf("error updating Role with name %s: %w", entity.Name, err)
 	}
@@ -165,3 +284,282 @@ func (svc *Service) DeleteByIds(
 
 	return Response{}, err
 }
+
+// Restore - восстановить мягко удалённую роль вместе с её назначениями сотрудникам.
+// Если имя роли уже занято другой (неудалённой) ролью - AlreadyExistsError
+func (svc *Service) Restore(
+	ctx context.Context,
+	id int64,
+) (Response, error) {
+	request := FindByIDRequest{ID: id}
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	var notFound = domain.NotFoundError{Message: fmt.Sprintf("deleted role with id %d not found", id)}
+	deleted, err := svc.repo.FindById(ctx, id, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Response{}, notFound
+	}
+	if err != nil {
+		return Response{}, fmt.Errorf("error finding role with id %d: %w", id, err)
+	}
+	if deleted.DeletedAt == nil {
+		return Response{}, notFound
+	}
+
+	isExists, err := svc.repo.ExistsByName(ctx, deleted.Name)
+	if err != nil {
+		return Response{}, fmt.Errorf("error checking role name %s: %w", deleted.Name, err)
+	}
+	if isExists {
+		return Response{}, domain.AlreadyExistsError{Message: fmt.Sprintf("role with name %s already exists", deleted.Name)}
+	}
+
+	isRestored, err := svc.repo.RestoreRole(ctx, id)
+	if err != nil {
+		return Response{}, fmt.Errorf("error restoring role with id %d: %w", id, err)
+	}
+	if !isRestored {
+		return Response{}, notFound
+	}
+
+	entity, err := svc.repo.FindById(ctx, id, false)
+	if err != nil {
+		return Response{}, fmt.Errorf("error finding role with id %d: %w", id, err)
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// PurgeDeleted - окончательно удалить роли, мягко удалённые более retention назад
+func (svc *Service) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := svc.repo.PurgeDeletedRoles(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("error purging deleted roles: %w", err)
+	}
+
+	return purged, nil
+}
+
+// RevokeExpired - отозвать назначения ролей с истёкшим сроком действия, возвращает отозванные назначения
+func (svc *Service) RevokeExpired(ctx context.Context) ([]AssignmentResponse, error) {
+	expired, err := svc.repo.RevokeExpiredAssignments(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("error revoking expired role assignments: %w", err)
+	}
+
+	responses := make([]AssignmentResponse, 0, len(expired))
+	for _, assignment := range expired {
+		responses = append(responses, assignment.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// FindEmployees - найти всех сотрудников, которым назначена роль
+func (svc *Service) FindEmployees(
+	ctx context.Context,
+	roleId int64,
+) ([]EmployeeResponse, error) {
+	request := FindByIDRequest{ID: roleId}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkRoleExists(ctx, roleId); err != nil {
+		return nil, err
+	}
+
+	return svc.findEmployees(ctx, roleId)
+}
+
+// FindEffectiveEmployees - найти всех, кто фактически имеет роль: назначенных напрямую
+// и получивших её через группы (с учётом вложенности групп)
+func (svc *Service) FindEffectiveEmployees(
+	ctx context.Context,
+	roleId int64,
+) ([]EffectiveEmployeeResponse, error) {
+	request := FindByIDRequest{ID: roleId}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkRoleExists(ctx, roleId); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindEffectiveEmployeesByRoleId(ctx, roleId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding effective employees of Role %d: %w", roleId, err)
+	}
+
+	return ToEffectiveEmployeeResponses(entities), nil
+}
+
+// FindImpliedRoles - найти роль и все роли, которые она подразумевает через наследование
+func (svc *Service) FindImpliedRoles(
+	ctx context.Context,
+	roleId int64,
+) ([]ImpliedRoleResponse, error) {
+	request := FindByIDRequest{ID: roleId}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if err := svc.checkRoleExists(ctx, roleId); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindImpliedRoles(ctx, roleId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding implied roles of Role %d: %w", roleId, err)
+	}
+
+	responses := make([]ImpliedRoleResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// AssignEmployee - назначить роль сотруднику, возвращает актуальный список сотрудников роли
+func (svc *Service) AssignEmployee(
+	ctx context.Context,
+	roleId int64,
+	request AssignEmployeeRequest,
+) ([]EmployeeResponse, error) {
+	request.RoleID = roleId
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	if request.ValidUntil != nil {
+		if !request.ValidUntil.After(time.Now()) {
+			return nil, domain.RequestValidationError{Message: "validUntil must be in the future"}
+		}
+		if request.ValidFrom != nil && !request.ValidUntil.After(*request.ValidFrom) {
+			return nil, domain.RequestValidationError{Message: "validUntil must be after validFrom"}
+		}
+	}
+
+	if err := svc.checkRoleExists(ctx, roleId); err != nil {
+		return nil, err
+	}
+
+	isExists, err := svc.repo.ExistsEmployeeById(ctx, request.EmployeeID)
+	if err != nil {
+		return nil, fmt.Errorf("error checking employee with id %d: %w", request.EmployeeID, err)
+	}
+	if !isExists {
+		return nil, domain.NotFoundError{Message: fmt.Sprintf("employee with id %d not found", request.EmployeeID)}
+	}
+
+	err = svc.repo.AssignEmployee(ctx, request.ToEntity())
+	if errors.Is(err, sod.ErrViolation) {
+		return nil, domain.SodViolationError{Message: err.Error()}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error assigning Role %d to employee %d: %w", roleId, request.EmployeeID, err)
+	}
+
+	return svc.findEmployees(ctx, roleId)
+}
+
+// RevokeEmployee - отозвать роль у сотрудника, возвращает актуальный список сотрудников роли
+func (svc *Service) RevokeEmployee(
+	ctx context.Context,
+	roleId int64,
+	employeeId int64,
+) ([]EmployeeResponse, error) {
+	request := RevokeEmployeeRequest{RoleID: roleId, EmployeeID: employeeId}
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	isRevoked, err := svc.repo.RevokeEmployee(ctx, roleId, employeeId)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking Role %d from employee %d: %w", roleId, employeeId, err)
+	}
+	if !isRevoked {
+		return nil, domain.NotFoundError{
+			Message: fmt.Sprintf("role %d is not assigned to employee %d", roleId, employeeId),
+		}
+	}
+
+	return svc.findEmployees(ctx, roleId)
+}
+
+func (svc *Service) findEmployees(ctx context.Context, roleId int64) ([]EmployeeResponse, error) {
+	entities, err := svc.repo.FindEmployeesByRoleId(ctx, roleId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding employees of Role %d: %w", roleId, err)
+	}
+
+	responses := make([]EmployeeResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) checkRoleExists(ctx context.Context, roleId int64) error {
+	_, err := svc.repo.FindById(ctx, roleId, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NotFoundError{Message: fmt.Sprintf("role with id %d not found", roleId)}
+	}
+	if err != nil {
+		return fmt.Errorf("error finding role with id %d: %w", roleId, err)
+	}
+
+	return nil
+}
+
+// checkInherits - роль не может наследовать саму себя, все наследуемые роли должны существовать
+func (svc *Service) checkInherits(ctx context.Context, roleId int64, inherits []int64) error {
+	if slices.Contains(inherits, roleId) {
+		return domain.RequestValidationError{Message: fmt.Sprintf("role %d cannot inherit itself", roleId)}
+	}
+	if len(inherits) == 0 {
+		return nil
+	}
+
+	roles, err := svc.repo.FindAllRolesByIds(ctx, inherits, false)
+	if err != nil {
+		return fmt.Errorf("error finding roles with ids %v: %w", inherits, err)
+	}
+	found := make(map[int64]bool, len(roles))
+	for _, role := range roles {
+		found[role.Id] = true
+	}
+	var missing []int64
+	for _, inheritedId := range inherits {
+		if !found[inheritedId] {
+			missing = append(missing, inheritedId)
+		}
+	}
+	if len(missing) > 0 {
+		return domain.RequestValidationError{Message: fmt.Sprintf("roles with ids %v not found", missing)}
+	}
+
+	return nil
+}
+
+// checkOwner - владельцем роли может быть только действующий сотрудник
+func (svc *Service) checkOwner(ctx context.Context, ownerId *int64) error {
+	if ownerId == nil {
+		return nil
+	}
+
+	isExists, err := svc.repo.ExistsEmployeeById(ctx, *ownerId)
+	if err != nil {
+		return fmt.Errorf("error checking employee with id %d: %w", *ownerId, err)
+	}
+	if !isExists {
+		return domain.RequestValidationError{Message: fmt.Sprintf("owner employee with id %d not found", *ownerId)}
+	}
+
+	return nil
+}
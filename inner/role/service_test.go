@@ -1,10 +1,16 @@
 package role
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"idm/inner/pagination"
+	"idm/inner/sod"
 	"testing"
 	"time"
 )
@@ -15,8 +21,8 @@ type MockRepo struct {
 }
 
 // Реализация ВСЕХ методов репозитория интерфейса для тестов
-func (m *MockRepo) FindById(ctx context.Context, id int64) (Entity, error) {
-	args := m.Called(ctx, id) // обращаемся в Mock
+func (m *MockRepo) FindById(ctx context.Context, id int64, includeDeleted bool) (Entity, error) {
+	args := m.Called(ctx, id, includeDeleted) // обращаемся в Mock
 	return args.Get(0).(Entity), args.Error(1)
 }
 
@@ -30,13 +36,34 @@ func (m *MockRepo) UpdateRole(ctx context.Context, entity *Entity) error {
 	return args.Error(0)
 }
 
-func (m *MockRepo) FindAllRoles(ctx context.Context) ([]Entity, error) {
-	args := m.Called(ctx)
+func (m *MockRepo) FindAllRoles(ctx context.Context, includeDeleted bool) ([]Entity, error) {
+	args := m.Called(ctx, includeDeleted)
 	return args.Get(0).([]Entity), args.Error(1)
 }
 
-func (m *MockRepo) FindAllRolesByIds(ctx context.Context, ids []int64) ([]Entity, error) {
-	args := m.Called(ctx, ids)
+func (m *MockRepo) FindKeysetPage(ctx context.Context, query pagination.Query, textFilter string, includeDeleted bool) ([]Entity, error) {
+	args := m.Called(ctx, query, textFilter, includeDeleted)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) CountRoles(ctx context.Context, textFilter string, includeDeleted bool) (int64, error) {
+	args := m.Called(ctx, textFilter, includeDeleted)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Export передаёт в fn роли, заданные в ожидании
+func (m *MockRepo) Export(ctx context.Context, textFilter string, includeDeleted bool, fn func(entity ExportEntity) error) error {
+	args := m.Called(ctx, textFilter, includeDeleted)
+	for _, entity := range args.Get(0).([]ExportEntity) {
+		if err := fn(entity); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockRepo) FindAllRolesByIds(ctx context.Context, ids []int64, includeDeleted bool) ([]Entity, error) {
+	args := m.Called(ctx, ids, includeDeleted)
 	return args.Get(0).([]Entity), args.Error(1)
 }
 
@@ -50,6 +77,56 @@ func (m *MockRepo) DeleteAllRolesByIds(ctx context.Context, ids []int64) error {
 	return args.Error(0)
 }
 
+func (m *MockRepo) ExistsByName(ctx context.Context, name string) (bool, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) RestoreRole(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) PurgeDeletedRoles(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) ExistsEmployeeById(ctx context.Context, employeeId int64) (bool, error) {
+	args := m.Called(ctx, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockRepo) FindEffectiveEmployeesByRoleId(ctx context.Context, roleId int64) ([]EffectiveEmployeeEntity, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]EffectiveEmployeeEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindImpliedRoles(ctx context.Context, roleId int64) ([]ImpliedRoleEntity, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]ImpliedRoleEntity), args.Error(1)
+}
+
+func (m *MockRepo) RevokeExpiredAssignments(ctx context.Context, now time.Time) ([]AssignmentEntity, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]AssignmentEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindEmployeesByRoleId(ctx context.Context, roleId int64) ([]EmployeeEntity, error) {
+	args := m.Called(ctx, roleId)
+	return args.Get(0).([]EmployeeEntity), args.Error(1)
+}
+
+func (m *MockRepo) AssignEmployee(ctx context.Context, assignment AssignmentEntity) error {
+	args := m.Called(ctx, assignment)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RevokeEmployee(ctx context.Context, roleId int64, employeeId int64) (bool, error) {
+	args := m.Called(ctx, roleId, employeeId)
+	return args.Get(0).(bool), args.Error(1)
+}
+
 func TestRoleService(t *testing.T) {
 	appContext := context.Background() //— если нужно проверить таймауты
 	var a = assert.New(t)
@@ -63,22 +140,22 @@ func TestRoleService(t *testing.T) {
 		var validateR = FindAllByIdsRequest{IDs: roleIDs}
 
 		roles := []Entity{ // Создаем список ролей
-			{Id: 1, Name: "Admin", EmployeeID: nil, CreatedAt: now, UpdatedAt: now},
-			{Id: 2, Name: "User", EmployeeID: nil, CreatedAt: now, UpdatedAt: now},
-			{Id: 3, Name: "Guest", EmployeeID: nil, CreatedAt: now, UpdatedAt: now},
+			{Id: 1, Name: "Admin", CreatedAt: now, UpdatedAt: now},
+			{Id: 2, Name: "User", CreatedAt: now, UpdatedAt: now},
+			{Id: 3, Name: "Guest", CreatedAt: now, UpdatedAt: now},
 		}
 		expectedResponses := []Response{ // Создаем список ответов
-			{Id: 1, Name: "Admin", EmployeeID: nil, CreateAt: now, UpdateAt: now},
-			{Id: 2, Name: "User", EmployeeID: nil, CreateAt: now, UpdateAt: now},
-			{Id: 3, Name: "Guest", EmployeeID: nil, CreateAt: now, UpdateAt: now},
+			{Id: 1, Name: "Admin", CreateAt: now, UpdateAt: now},
+			{Id: 2, Name: "User", CreateAt: now, UpdateAt: now},
+			{Id: 3, Name: "Guest", CreateAt: now, UpdateAt: now},
 		}
 
 		// Задаем ожидаемое поведение мок-репозитория
 		validator.On("Validate", validateR).Return(nil).Once()
-		mockRepo.On("FindAllRolesByIds", appContext, roleIDs).Return(roles, nil).Once() // Задаем ожидаемое поведение мок-репозитория
+		mockRepo.On("FindAllRolesByIds", appContext, roleIDs, false).Return(roles, nil).Once() // Задаем ожидаемое поведение мок-репозитория
 
 		// Act - вызываем метод сервиса
-		result, err := service.FindAllByIds(appContext, roleIDs)
+		result, err := service.FindAllByIds(appContext, roleIDs, false)
 
 		// Assert - проверяем результаты теста
 		a.NoError(err)
@@ -97,10 +174,10 @@ func TestRoleService(t *testing.T) {
 		var expectedErr = errors.New("database error") // ошибка, которую вернёт репозиторий
 
 		validator.On("Validate", validateR).Return(nil).Once()
-		mockRepo.On("FindAllRolesByIds", appContext, roleIDs).Return([]Entity{}, expectedErr).Once() // Задаем ожидаемое поведение мок-репозитория
+		mockRepo.On("FindAllRolesByIds", appContext, roleIDs, false).Return([]Entity{}, expectedErr).Once() // Задаем ожидаемое поведение мок-репозитория
 
 		// Act - вызываем метод сервиса
-		_, err := service.FindAllByIds(appContext, roleIDs)
+		_, err := service.FindAllByIds(appContext, roleIDs, false)
 
 		// Assert - проверяем результаты теста
 		assert.Error(t, err)                                                               // Должна быть ошибка
@@ -123,10 +200,10 @@ func TestRoleService(t *testing.T) {
 			{Id: 3, Name: "Guest"},
 		}
 
-		mockRepo.On("FindAllRoles", appContext).Return(roles, nil).Once() // Задаем ожидаемое поведение мок-репозитория
+		mockRepo.On("FindAllRoles", appContext, false).Return(roles, nil).Once() // Задаем ожидаемое поведение мок-репозитория
 
 		// Act - вызываем метод сервиса
-		result, err := service.FindAll(appContext)
+		result, err := service.FindAll(appContext, false)
 
 		// Assert - проверяем результат
 		a.Nil(err)
@@ -136,14 +213,14 @@ func TestRoleService(t *testing.T) {
 		mockRepo.AssertExpectations(t) // проверяем что были вызваны все объявленные ожидания
 	})
 	t.Run("when should return error when failed to get roles", func(t *testing.T) {
-		mockRepo := new(MockRepo)                                                      // Создаем мок-репозиторий
-		validator := new(MockValidator)                                                //
-		service := NewService(mockRepo, validator)                                     // создаём новый экземпляр сервиса (чтобы передать ему новый мок репозитория)
-		want := errors.New("failed to get roles")                                      // Создаем ошибку
-		mockRepo.On("FindAllRoles", appContext).Return(make([]Entity, 0), want).Once() // Задаем ожидаемое поведение мок-репозитория
+		mockRepo := new(MockRepo)                                                             // Создаем мок-репозиторий
+		validator := new(MockValidator)                                                       //
+		service := NewService(mockRepo, validator)                                            // создаём новый экземпляр сервиса (чтобы передать ему новый мок репозитория)
+		want := errors.New("failed to get roles")                                             // Создаем ошибку
+		mockRepo.On("FindAllRoles", appContext, false).Return(make([]Entity, 0), want).Once() // Задаем ожидаемое поведение мок-репозитория
 
 		// Act - вызываем метод сервиса
-		_, err := service.FindAll(appContext)
+		_, err := service.FindAll(appContext, false)
 
 		// Assert - проверяем результат
 		a.Error(err)
@@ -163,11 +240,10 @@ func TestRoleService(t *testing.T) {
 		}
 
 		expectedRole := Entity{ // Создаем роль
-			Id:         1,
-			Name:       "Admin",
-			EmployeeID: nil,
-			CreatedAt:  now,
-			UpdatedAt:  now,
+			Id:        1,
+			Name:      "Admin",
+			CreatedAt: now,
+			UpdatedAt: now,
 		}
 
 		expectedEntity := entityRequest.ToEntity()
@@ -194,11 +270,10 @@ func TestRoleService(t *testing.T) {
 
 		var empID = int64(1)
 		entityRequest := UpdateRequest{ // request
-			Id:         1,
-			Name:       "Admin",
-			EmployeeID: &empID,
-			CreatedAt:  now,
-			UpdatedAt:  now,
+			Id:        1,
+			Name:      "Admin",
+			CreatedAt: now,
+			UpdatedAt: now,
 		}
 		//want := errors.New("failed to get roles")
 		//	errR := fmt.Errorf("error updating Role with name %s: %w", entityRequest.Name, want)
@@ -241,3 +316,523 @@ func TestRoleService(t *testing.T) {
 		repo.AssertExpectations(t) // проверяем что были вызваны все объявленные ожидания
 	})
 }
+
+func TestRoleService_Employees(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should return employees of role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		entities := []EmployeeEntity{{Id: 1, Name: "Alice Marcus", AssignedAt: now}}
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{Id: 10}, nil).Once()
+		repo.On("FindEmployeesByRoleId", appContext, int64(10)).Return(entities, nil).Once()
+
+		got, err := service.FindEmployees(appContext, 10)
+
+		a.Nil(err)
+		a.Equal([]EmployeeResponse{{Id: 1, Name: "Alice Marcus", AssignedAt: now}}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when role does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{}, sql.ErrNoRows).Once()
+
+		got, err := service.FindEmployees(appContext, 10)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+	})
+
+	t.Run("should return effective employees of role with their sources", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		var admins, ops = "Admins", "Ops"
+		entities := []EffectiveEmployeeEntity{
+			{Id: 1, Name: "Alice Marcus"},
+			{Id: 1, Name: "Alice Marcus", GroupName: &admins},
+			{Id: 2, Name: "Jill Valentine", GroupName: &admins},
+			{Id: 2, Name: "Jill Valentine", GroupName: &ops},
+		}
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{Id: 10}, nil).Once()
+		repo.On("FindEffectiveEmployeesByRoleId", appContext, int64(10)).Return(entities, nil).Once()
+
+		got, err := service.FindEffectiveEmployees(appContext, 10)
+
+		a.Nil(err)
+		a.Equal([]EffectiveEmployeeResponse{
+			{Id: 1, Name: "Alice Marcus", Direct: true, Groups: []string{"Admins"}},
+			{Id: 2, Name: "Jill Valentine", Direct: false, Groups: []string{"Admins", "Ops"}},
+		}, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should assign role to employee", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignEmployeeRequest{RoleID: 10, EmployeeID: 1}
+		entities := []EmployeeEntity{{Id: 1, Name: "Alice Marcus", AssignedAt: now}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{Id: 10}, nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(1)).Return(true, nil).Once()
+		repo.On("AssignEmployee", appContext, AssignmentEntity{RoleId: 10, EmployeeId: 1}).Return(nil).Once()
+		repo.On("FindEmployeesByRoleId", appContext, int64(10)).Return(entities, nil).Once()
+
+		got, err := service.AssignEmployee(appContext, 10, AssignEmployeeRequest{EmployeeID: 1})
+
+		a.Nil(err)
+		a.Len(got, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return sod violation when roles conflict", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignEmployeeRequest{RoleID: 10, EmployeeID: 1}
+		violation := fmt.Errorf("%w: roles PAYMENT_CREATOR and PAYMENT_APPROVER must not be held together (rule 3)",
+			sod.ErrViolation)
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{Id: 10}, nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(1)).Return(true, nil).Once()
+		repo.On("AssignEmployee", appContext, AssignmentEntity{RoleId: 10, EmployeeId: 1}).Return(violation).Once()
+
+		got, err := service.AssignEmployee(appContext, 10, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.SodViolationError{}))
+		a.Contains(err.Error(), "PAYMENT_APPROVER")
+		repo.AssertNotCalled(t, "FindEmployeesByRoleId", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found when employee does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := AssignEmployeeRequest{RoleID: 10, EmployeeID: 1}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{Id: 10}, nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(1)).Return(false, nil).Once()
+
+		got, err := service.AssignEmployee(appContext, 10, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "AssignEmployee", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return wrapped error when revoke failed", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := RevokeEmployeeRequest{RoleID: 10, EmployeeID: 1}
+		dbErr := errors.New("database error")
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("RevokeEmployee", appContext, int64(10), int64(1)).Return(false, dbErr).Once()
+
+		got, err := service.RevokeEmployee(appContext, 10, 1)
+
+		a.Nil(got)
+		a.ErrorIs(err, dbErr)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRoleService_SoftDelete(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	deletedAt := time.Now()
+
+	t.Run("should restore deleted role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		deleted := Entity{Id: 10, Name: "ADMIN", DeletedAt: &deletedAt}
+		restored := Entity{Id: 10, Name: "ADMIN"}
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), true).Return(deleted, nil).Once()
+		repo.On("ExistsByName", appContext, "ADMIN").Return(false, nil).Once()
+		repo.On("RestoreRole", appContext, int64(10)).Return(true, nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(restored, nil).Once()
+
+		got, err := service.Restore(appContext, 10)
+
+		a.Nil(err)
+		a.Equal(restored.ToResponse(), got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when role is not deleted", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), true).Return(Entity{Id: 10, Name: "ADMIN"}, nil).Once()
+
+		got, err := service.Restore(appContext, 10)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "RestoreRole", appContext, int64(10))
+	})
+
+	t.Run("should return not found when role does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), true).Return(Entity{}, sql.ErrNoRows).Once()
+
+		got, err := service.Restore(appContext, 10)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "RestoreRole", appContext, int64(10))
+	})
+
+	t.Run("should return already exists when name is taken by active role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		deleted := Entity{Id: 10, Name: "ADMIN", DeletedAt: &deletedAt}
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), true).Return(deleted, nil).Once()
+		repo.On("ExistsByName", appContext, "ADMIN").Return(true, nil).Once()
+
+		got, err := service.Restore(appContext, 10)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "RestoreRole", appContext, int64(10))
+	})
+
+	t.Run("should purge roles deleted before retention", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		var retention = 24 * time.Hour
+		var earliest = time.Now().Add(-retention)
+
+		repo.On("PurgeDeletedRoles", appContext, mock.MatchedBy(func(before time.Time) bool {
+			return !before.Before(earliest) && before.Before(time.Now().Add(-retention+time.Minute))
+		})).Return(int64(2), nil).Once()
+
+		purged, err := service.PurgeDeleted(appContext, retention)
+
+		a.Nil(err)
+		a.Equal(int64(2), purged)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRoleService_Inheritance(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	now := time.Now()
+
+	t.Run("should reject role inheriting itself", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 10, Name: "ADMIN", CreatedAt: now, UpdatedAt: now, Inherits: []int64{5, 10}}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		got, err := service.UpdateRole(appContext, 10, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject inheriting missing roles", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 10, Name: "ADMIN", CreatedAt: now, UpdatedAt: now, Inherits: []int64{5, 6}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindAllRolesByIds", appContext, []int64{5, 6}, false).Return([]Entity{{Id: 5}}, nil).Once()
+
+		got, err := service.UpdateRole(appContext, 10, request)
+
+		a.Empty(got)
+		var validationErr domain.RequestValidationError
+		a.True(errors.As(err, &validationErr))
+		a.Equal("roles with ids [6] not found", validationErr.Message)
+		repo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return validation error on inheritance cycle", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 10, Name: "USER", CreatedAt: now, UpdatedAt: now, Inherits: []int64{5}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindAllRolesByIds", appContext, []int64{5}, false).Return([]Entity{{Id: 5}}, nil).Once()
+		repo.On("UpdateRole", appContext, request.ToEntity()).Return(ErrRoleCycle).Once()
+
+		got, err := service.UpdateRole(appContext, 10, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return sod violation when inherited role conflicts for holders", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 10, Name: "ACCOUNTANT", CreatedAt: now, UpdatedAt: now, Inherits: []int64{5}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindAllRolesByIds", appContext, []int64{5}, false).Return([]Entity{{Id: 5}}, nil).Once()
+		repo.On("UpdateRole", appContext, request.ToEntity()).Return(fmt.Errorf("%w: rule 1", sod.ErrViolation)).Once()
+
+		got, err := service.UpdateRole(appContext, 10, request)
+
+		a.Empty(got)
+		a.True(errors.As(err, &domain.SodViolationError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should clear inheritance without checking roles", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := UpdateRequest{Id: 10, Name: "ADMIN", CreatedAt: now, UpdatedAt: now, Inherits: []int64{}}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("UpdateRole", appContext, request.ToEntity()).Return(nil).Once()
+
+		got, err := service.UpdateRole(appContext, 10, request)
+
+		a.Nil(err)
+		a.Equal(int64(10), got.Id)
+		repo.AssertNotCalled(t, "FindAllRolesByIds", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return implied roles of role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		entities := []ImpliedRoleEntity{
+			{Entity: Entity{Id: 10, Name: "ADMIN"}, Level: 0},
+			{Entity: Entity{Id: 5, Name: "USER"}, Level: 1},
+		}
+
+		validator.On("Validate", FindByIDRequest{ID: 10}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{Id: 10}, nil).Once()
+		repo.On("FindImpliedRoles", appContext, int64(10)).Return(entities, nil).Once()
+
+		got, err := service.FindImpliedRoles(appContext, 10)
+
+		a.Nil(err)
+		a.Len(got, 2)
+		a.Equal("USER", got[1].Name)
+		a.Equal(int64(1), got[1].Level)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRoleService_Expiry(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+
+	t.Run("should assign role for a limited time", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		validUntil := time.Now().Add(24 * time.Hour)
+		request := AssignEmployeeRequest{RoleID: 10, EmployeeID: 1, ValidUntil: &validUntil}
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("FindById", appContext, int64(10), false).Return(Entity{Id: 10}, nil).Once()
+		repo.On("ExistsEmployeeById", appContext, int64(1)).Return(true, nil).Once()
+		repo.On("AssignEmployee", appContext, AssignmentEntity{RoleId: 10, EmployeeId: 1, ValidUntil: &validUntil}).
+			Return(nil).Once()
+		repo.On("FindEmployeesByRoleId", appContext, int64(10)).Return([]EmployeeEntity{}, nil).Once()
+
+		_, err := service.AssignEmployee(appContext, 10, request)
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject assignment already expired", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		validUntil := time.Now().Add(-time.Minute)
+		request := AssignEmployeeRequest{RoleID: 10, EmployeeID: 1, ValidUntil: &validUntil}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		got, err := service.AssignEmployee(appContext, 10, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "AssignEmployee", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject assignment ending before it starts", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		validFrom := time.Now().Add(48 * time.Hour)
+		validUntil := time.Now().Add(24 * time.Hour)
+		request := AssignEmployeeRequest{RoleID: 10, EmployeeID: 1, ValidFrom: &validFrom, ValidUntil: &validUntil}
+
+		validator.On("Validate", request).Return(nil).Once()
+
+		got, err := service.AssignEmployee(appContext, 10, request)
+
+		a.Nil(got)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "AssignEmployee", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should revoke expired assignments", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		validUntil := time.Now().Add(-time.Minute)
+		expired := []AssignmentEntity{{RoleId: 10, EmployeeId: 1, ValidUntil: &validUntil}}
+
+		repo.On("RevokeExpiredAssignments", appContext, mock.MatchedBy(func(now time.Time) bool {
+			return !now.After(time.Now())
+		})).Return(expired, nil).Once()
+
+		got, err := service.RevokeExpired(appContext)
+
+		a.Nil(err)
+		a.Equal([]AssignmentResponse{{RoleId: 10, EmployeeId: 1, ValidUntil: &validUntil}}, got)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRoleService_Export(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	var created = time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	var ownerId = int64(5)
+
+	t.Run("should write roles with assigned employees as csv", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := ExportRequest{Format: "csv", TextFilter: "admin"}
+
+		validator.ExpectValidate(request, nil)
+		repo.On("Export", appContext, "admin", false).Return([]ExportEntity{
+			{Entity: Entity{Id: 1, Name: "admin", OwnerId: &ownerId, CreatedAt: created, UpdatedAt: created}, Employees: []int64{2, 7}},
+			{Entity: Entity{Id: 3, Name: "sysadmin", CreatedAt: created, UpdatedAt: created}},
+		}, nil).Once()
+
+		write, err := service.Export(appContext, request)
+		a.Nil(err)
+		var buf bytes.Buffer
+		a.Nil(write(&buf))
+		a.Equal(
+			"id,name,ownerId,createdAt,updatedAt,deletedAt,employees\n"+
+				"1,admin,5,2025-08-01T10:00:00Z,2025-08-01T10:00:00Z,,2;7\n"+
+				"3,sysadmin,,2025-08-01T10:00:00Z,2025-08-01T10:00:00Z,,\n",
+			buf.String(),
+		)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject short text filter", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := ExportRequest{Format: "ndjson", TextFilter: "ad"}
+
+		validator.ExpectValidate(request, nil)
+
+		write, err := service.Export(appContext, request)
+		a.Nil(write)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "Export", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRoleService_GetKeysetPage(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+
+	t.Run("should return page after cursor with prev and next keys", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		var after = &pagination.Key{Value: "admin", Id: 1}
+		request := KeysetRequest{Limit: 2, Sort: "name", TextFilter: "adm", After: after}
+
+		validator.ExpectValidate(request, nil)
+		var query = pagination.Query{Order: pagination.Order{Column: "name"}, Limit: 2, After: after}
+		repo.On("FindKeysetPage", appContext, query, "adm", false).Return([]Entity{
+			{Id: 4, Name: "admin"}, {Id: 2, Name: "auditadm"}, {Id: 3, Name: "sysadmin"},
+		}, nil).Once()
+
+		page, err := service.GetKeysetPage(appContext, request)
+		a.Nil(err)
+		a.Len(page.Result, 2)
+		a.Equal(&pagination.Key{Value: "admin", Id: 4}, page.Prev)
+		a.Equal(&pagination.Key{Value: "auditadm", Id: 2}, page.Next)
+		a.Nil(page.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should count roles only with total", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := KeysetRequest{Limit: 5, Sort: "-id", IncludeDeleted: true, WithTotal: true}
+
+		validator.ExpectValidate(request, nil)
+		var query = pagination.Query{Order: pagination.Order{Column: "id", Desc: true}, Limit: 5}
+		repo.On("FindKeysetPage", appContext, query, "", true).Return([]Entity{{Id: 2}, {Id: 1}}, nil).Once()
+		repo.On("CountRoles", appContext, "", true).Return(int64(2), nil).Once()
+
+		page, err := service.GetKeysetPage(appContext, request)
+		a.Nil(err)
+		a.Nil(page.Next)
+		a.Nil(page.Prev)
+		a.Equal(int64(2), *page.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject unknown sort", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		request := KeysetRequest{Limit: 5, Sort: "ownerId"}
+
+		validator.ExpectValidate(request, nil)
+		_, err := service.GetKeysetPage(appContext, request)
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertNotCalled(t, "FindKeysetPage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
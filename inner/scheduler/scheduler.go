@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"sync"
+	"time"
+)
+
+// Job - периодическая фоновая задача
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler - запуск фоновых задач по расписанию. Каждая задача выполняется в своей горутине
+// раз в Interval; ошибка задачи логируется и не останавливает следующие запуски.
+// Задачи выполняются без вызывающего в контексте, поэтому в аудит они попадают от имени system
+type Scheduler struct {
+	jobs   []Job
+	logger *common.Logger
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler - функция-конструктор
+func NewScheduler(logger *common.Logger, jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs:   jobs,
+		logger: logger,
+	}
+}
+
+// Start - запустить все задачи. Задачи останавливаются по Stop или при отмене ctx
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop - остановить задачи и дождаться завершения уже идущих запусков
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	var ticker = time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	var started = time.Now()
+	if err := job.Run(ctx); err != nil {
+		s.logger.Error("scheduled job failed", zap.String("job", job.Name), zap.Error(err))
+		return
+	}
+	s.logger.Debug(
+		"scheduled job completed",
+		zap.String("job", job.Name),
+		zap.Duration("duration", time.Since(started)),
+	)
+}
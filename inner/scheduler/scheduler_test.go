@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	var a = assert.New(t)
+	var logger = &common.Logger{Logger: zap.NewNop()}
+
+	t.Run("should run job periodically until stopped", func(t *testing.T) {
+		var runs atomic.Int64
+		var s = NewScheduler(logger, Job{
+			Name:     "counter",
+			Interval: 5 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			},
+		})
+
+		s.Start(context.Background())
+		a.Eventually(func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+		s.Stop()
+
+		var stopped = runs.Load()
+		time.Sleep(20 * time.Millisecond)
+		a.Equal(stopped, runs.Load())
+	})
+
+	t.Run("should keep running job after error", func(t *testing.T) {
+		var runs atomic.Int64
+		var s = NewScheduler(logger, Job{
+			Name:     "failing",
+			Interval: 5 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return errors.New("database error")
+			},
+		})
+
+		s.Start(context.Background())
+		a.Eventually(func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+		s.Stop()
+	})
+
+	t.Run("should stop jobs when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var s = NewScheduler(logger, Job{
+			Name:     "idle",
+			Interval: time.Hour,
+			Run:      func(ctx context.Context) error { return nil },
+		})
+
+		s.Start(ctx)
+		cancel()
+
+		var done = make(chan struct{})
+		go func() {
+			s.Stop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("scheduler did not stop after context cancel")
+		}
+	})
+}
@@ -0,0 +1,528 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidRequestBody  = "Request body is not a valid SCIM resource"
+	invalidPageValues   = "startIndex and count must be integers"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server      *web.Server
+	scimService Svc
+	logger      *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	ListUsers(ctx context.Context, request ListRequest) (ListResponse, error)
+	GetUser(ctx context.Context, id string) (User, error)
+	CreateUser(ctx context.Context, user User) (User, error)
+	ReplaceUser(ctx context.Context, id string, user User) (User, error)
+	PatchUser(ctx context.Context, id string, request PatchRequest) (User, error)
+	DeleteUser(ctx context.Context, id string) error
+	ListGroups(ctx context.Context, request ListRequest) (ListResponse, error)
+	GetGroup(ctx context.Context, id string) (Group, error)
+	CreateGroup(ctx context.Context, group Group) (Group, error)
+	ReplaceGroup(ctx context.Context, id string, group Group) (Group, error)
+	PatchGroup(ctx context.Context, id string, request PatchRequest) (Group, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	scimService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:      server,
+		scimService: scimService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/scim/v2"; метаданные сервера доступны любому клиенту с токеном
+	c.server.GroupScim.Get("/ServiceProviderConfig", c.GetServiceProviderConfig)
+	c.server.GroupScim.Get("/ResourceTypes", c.GetResourceTypes)
+	c.server.GroupScim.Get("/ResourceTypes/:id", c.GetResourceType)
+	c.server.GroupScim.Get("/Schemas", c.GetSchemas)
+	c.server.GroupScim.Get("/Schemas/:id", c.GetSchema)
+	// замена пользователя может менять active - это действие жизненного цикла
+	c.server.GroupScim.Get("/Users", c.server.Require(web.PermEmployeesRead), c.ListUsers)
+	c.server.GroupScim.Post("/Users", c.server.Require(web.PermEmployeesWrite), c.CreateUser)
+	c.server.GroupScim.Get("/Users/:id", c.server.Require(web.PermEmployeesRead), c.GetUser)
+	c.server.GroupScim.Put("/Users/:id", c.server.Require(web.PermEmployeesWrite, web.PermEmployeesLifecycle), c.ReplaceUser)
+	c.server.GroupScim.Patch("/Users/:id", c.server.Require(web.PermEmployeesWrite, web.PermEmployeesLifecycle), c.PatchUser)
+	c.server.GroupScim.Delete("/Users/:id", c.server.Require(web.PermEmployeesDelete), c.DeleteUser)
+	// участники группы - назначения роли, поэтому изменение групп требует ещё права на назначение
+	c.server.GroupScim.Get("/Groups", c.server.Require(web.PermRolesRead), c.ListGroups)
+	c.server.GroupScim.Post("/Groups", c.server.Require(web.PermRolesWrite, web.PermRolesAssign), c.CreateGroup)
+	c.server.GroupScim.Get("/Groups/:id", c.server.Require(web.PermRolesRead), c.GetGroup)
+	c.server.GroupScim.Put("/Groups/:id", c.server.Require(web.PermRolesWrite, web.PermRolesAssign), c.ReplaceGroup)
+	c.server.GroupScim.Patch("/Groups/:id", c.server.Require(web.PermRolesWrite, web.PermRolesAssign), c.PatchGroup)
+	c.server.GroupScim.Delete("/Groups/:id", c.server.Require(web.PermRolesDelete), c.DeleteGroup)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/scim/v2" --//
+
+// GetServiceProviderConfig godoc
+// @Description  SCIM service provider capabilities: patch and filter are supported, sort, etag and bulk are not
+// @Summary		 get SCIM service provider config
+// @Tags 		 scim
+// @Produce 	 json
+// @Success 	 200  {object}  	scim.ServiceProviderConfig	"Service provider config"
+// @Router 		 /scim/v2/ServiceProviderConfig	[get]
+func (c *Controller) GetServiceProviderConfig(ctx *fiber.Ctx) error {
+	return http.ScimResponse(ctx, fiber.StatusOK, NewServiceProviderConfig())
+}
+
+// GetResourceTypes godoc
+// @Description  SCIM resource types: User backed by employees and Group backed by roles
+// @Summary		 get SCIM resource types
+// @Tags 		 scim
+// @Produce 	 json
+// @Success 	 200  {object}  	scim.ListResponse	"Resource types"
+// @Router 		 /scim/v2/ResourceTypes	[get]
+func (c *Controller) GetResourceTypes(ctx *fiber.Ctx) error {
+	var resourceTypes = NewResourceTypes()
+	var resources = make([]any, 0, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		resources = append(resources, resourceType)
+	}
+	return http.ScimResponse(ctx, fiber.StatusOK, newListResponse(len(resources), 0, resources))
+}
+
+// GetResourceType godoc
+// @Description  SCIM resource type by id (User or Group)
+// @Summary		 get SCIM resource type
+// @Tags 		 scim
+// @Produce 	 json
+// @Param 		 id   path      	string  true  		"Resource type id"
+// @Success 	 200  {object}  	scim.ResourceType	"Resource type"
+// @Failure      404  {object}  	scim.ErrorResponse	"Resource type not found"
+// @Router 		 /scim/v2/ResourceTypes/{id}	[get]
+func (c *Controller) GetResourceType(ctx *fiber.Ctx) error {
+	for _, resourceType := range NewResourceTypes() {
+		if resourceType.Id == ctx.Params("id") {
+			return http.ScimResponse(ctx, fiber.StatusOK, resourceType)
+		}
+	}
+	return c.scimError(ctx, fiber.StatusNotFound, "", "resource type "+ctx.Params("id")+" not found")
+}
+
+// GetSchemas godoc
+// @Description  SCIM schemas of User, Group and enterprise User extension
+// @Summary		 get SCIM schemas
+// @Tags 		 scim
+// @Produce 	 json
+// @Success 	 200  {object}  	scim.ListResponse	"Schemas"
+// @Router 		 /scim/v2/Schemas	[get]
+func (c *Controller) GetSchemas(ctx *fiber.Ctx) error {
+	var schemas = NewSchemas()
+	var resources = make([]any, 0, len(schemas))
+	for _, schema := range schemas {
+		resources = append(resources, schema)
+	}
+	return http.ScimResponse(ctx, fiber.StatusOK, newListResponse(len(resources), 0, resources))
+}
+
+// GetSchema godoc
+// @Description  SCIM schema by URN
+// @Summary		 get SCIM schema
+// @Tags 		 scim
+// @Produce 	 json
+// @Param 		 id   path      	string  true  		"Schema URN"
+// @Success 	 200  {object}  	scim.Schema			"Schema"
+// @Failure      404  {object}  	scim.ErrorResponse	"Schema not found"
+// @Router 		 /scim/v2/Schemas/{id}	[get]
+func (c *Controller) GetSchema(ctx *fiber.Ctx) error {
+	for _, schema := range NewSchemas() {
+		if schema.Id == ctx.Params("id") {
+			return http.ScimResponse(ctx, fiber.StatusOK, schema)
+		}
+	}
+	return c.scimError(ctx, fiber.StatusNotFound, "", "schema "+ctx.Params("id")+" not found")
+}
+
+// ListUsers godoc
+// @Description  List employees as SCIM Users with filter (e.g. userName eq "jdoe") and startIndex/count paging
+// @Summary		 list SCIM users
+// @Tags 		 scim
+// @Produce 	 json
+// @Param 		 filter   		query   string  false  	"SCIM filter"
+// @Param 		 startIndex		query   int  	false  	"1-based index of the first result"
+// @Param 		 count   		query   int  	false  	"page size, at most 1000"
+// @Success 	 200  {object}  	scim.ListResponse	"Users"
+// @Failure      400  {object}  	scim.ErrorResponse	"Invalid filter"
+// @Failure      500  {object}  	scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Users	[get]
+func (c *Controller) ListUsers(ctx *fiber.Ctx) error {
+	return c.list(ctx, ResourceUser, c.scimService.ListUsers)
+}
+
+// GetUser godoc
+// @Description  Find employee by id as SCIM User
+// @Summary		 get SCIM user
+// @Tags 		 scim
+// @Produce 	 json
+// @Param 		 id   path      	string  true  		"User id"
+// @Success 	 200  {object}  	scim.User			"User"
+// @Failure      404  {object}  	scim.ErrorResponse	"User not found"
+// @Failure      500  {object}  	scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Users/{id}	[get]
+func (c *Controller) GetUser(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.scimService.GetUser(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return c.errResponse(ctx, err, "When the get SCIM User ended with an error:", requestId)
+	}
+
+	return http.ScimResponse(ctx, fiber.StatusOK, response)
+}
+
+// CreateUser godoc
+// @Description  Create employee from SCIM User; userName is a login or the primary email, active=false creates pre-hire
+// @Summary		 create SCIM user
+// @Tags 		 scim
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	scim.User true 		"User"
+// @Success 	 201  {object}  scim.User			"Created user"
+// @Failure      400  {object}  scim.ErrorResponse	"Invalid value"
+// @Failure      409  {object}  scim.ErrorResponse	"userName or email already taken"
+// @Failure      500  {object}  scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Users	[post]
+func (c *Controller) CreateUser(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request User
+	if err := json.Unmarshal(ctx.Body(), &request); err != nil {
+		return c.bodyErrResponse(ctx, err, requestId)
+	}
+
+	response, err := c.scimService.CreateUser(ctx.UserContext(), request)
+	if err != nil {
+		return c.errResponse(ctx, err, "When the create SCIM User ended with an error:", requestId)
+	}
+
+	ctx.Location(response.Meta.Location)
+	return http.ScimResponse(ctx, fiber.StatusCreated, response)
+}
+
+// ReplaceUser godoc
+// @Description  Replace employee attributes from SCIM User, active switches lifecycle status
+// @Summary		 replace SCIM user
+// @Tags 		 scim
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 id   path      	string  true  		"User id"
+// @Param 		 request body 	scim.User true 		"User"
+// @Success 	 200  {object}  scim.User			"Replaced user"
+// @Failure      400  {object}  scim.ErrorResponse	"Invalid value"
+// @Failure      404  {object}  scim.ErrorResponse	"User not found"
+// @Failure      409  {object}  scim.ErrorResponse	"userName or email already taken or status change not allowed"
+// @Failure      500  {object}  scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Users/{id}	[put]
+func (c *Controller) ReplaceUser(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request User
+	if err := json.Unmarshal(ctx.Body(), &request); err != nil {
+		return c.bodyErrResponse(ctx, err, requestId)
+	}
+
+	response, err := c.scimService.ReplaceUser(ctx.UserContext(), ctx.Params("id"), request)
+	if err != nil {
+		return c.errResponse(ctx, err, "When the replace SCIM User ended with an error:", requestId)
+	}
+
+	return http.ScimResponse(ctx, fiber.StatusOK, response)
+}
+
+// PatchUser godoc
+// @Description  Apply SCIM PatchOp operations (add, replace, remove) to employee
+// @Summary		 patch SCIM user
+// @Tags 		 scim
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 id   path      	string  true  			"User id"
+// @Param 		 request body 	scim.PatchRequest true 	"Patch operations"
+// @Success 	 200  {object}  scim.User				"Patched user"
+// @Failure      400  {object}  scim.ErrorResponse		"Invalid path, value or syntax"
+// @Failure      404  {object}  scim.ErrorResponse		"User not found"
+// @Failure      409  {object}  scim.ErrorResponse		"userName or email already taken or status change not allowed"
+// @Failure      500  {object}  scim.ErrorResponse		"Internal server error"
+// @Router 		 /scim/v2/Users/{id}	[patch]
+func (c *Controller) PatchUser(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request PatchRequest
+	if err := json.Unmarshal(ctx.Body(), &request); err != nil {
+		return c.bodyErrResponse(ctx, err, requestId)
+	}
+
+	response, err := c.scimService.PatchUser(ctx.UserContext(), ctx.Params("id"), request)
+	if err != nil {
+		return c.errResponse(ctx, err, "When the patch SCIM User ended with an error:", requestId)
+	}
+
+	return http.ScimResponse(ctx, fiber.StatusOK, response)
+}
+
+// DeleteUser godoc
+// @Description  Soft delete employee
+// @Summary		 delete SCIM user
+// @Tags 		 scim
+// @Param 		 id   path      	string  true  		"User id"
+// @Success 	 204
+// @Failure      404  {object}  	scim.ErrorResponse	"User not found"
+// @Failure      500  {object}  	scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Users/{id}	[delete]
+func (c *Controller) DeleteUser(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	if err := c.scimService.DeleteUser(ctx.UserContext(), ctx.Params("id")); err != nil {
+		return c.errResponse(ctx, err, "When the delete SCIM User ended with an error:", requestId)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// ListGroups godoc
+// @Description  List roles as SCIM Groups with filter (e.g. displayName eq "ADMIN") and startIndex/count paging
+// @Summary		 list SCIM groups
+// @Tags 		 scim
+// @Produce 	 json
+// @Param 		 filter   		query   string  false  	"SCIM filter"
+// @Param 		 startIndex		query   int  	false  	"1-based index of the first result"
+// @Param 		 count   		query   int  	false  	"page size, at most 1000"
+// @Success 	 200  {object}  	scim.ListResponse	"Groups"
+// @Failure      400  {object}  	scim.ErrorResponse	"Invalid filter"
+// @Failure      500  {object}  	scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Groups	[get]
+func (c *Controller) ListGroups(ctx *fiber.Ctx) error {
+	return c.list(ctx, ResourceGroup, c.scimService.ListGroups)
+}
+
+// GetGroup godoc
+// @Description  Find role by id as SCIM Group with directly assigned employees as members
+// @Summary		 get SCIM group
+// @Tags 		 scim
+// @Produce 	 json
+// @Param 		 id   path      	string  true  		"Group id"
+// @Success 	 200  {object}  	scim.Group			"Group"
+// @Failure      404  {object}  	scim.ErrorResponse	"Group not found"
+// @Failure      500  {object}  	scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Groups/{id}	[get]
+func (c *Controller) GetGroup(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.scimService.GetGroup(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return c.errResponse(ctx, err, "When the get SCIM Group ended with an error:", requestId)
+	}
+
+	return http.ScimResponse(ctx, fiber.StatusOK, response)
+}
+
+// CreateGroup godoc
+// @Description  Create role from SCIM Group and assign it to members
+// @Summary		 create SCIM group
+// @Tags 		 scim
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	scim.Group true 	"Group"
+// @Success 	 201  {object}  scim.Group			"Created group"
+// @Failure      400  {object}  scim.ErrorResponse	"Invalid value"
+// @Failure      409  {object}  scim.ErrorResponse	"displayName already taken or segregation of duties violation"
+// @Failure      500  {object}  scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Groups	[post]
+func (c *Controller) CreateGroup(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request Group
+	if err := json.Unmarshal(ctx.Body(), &request); err != nil {
+		return c.bodyErrResponse(ctx, err, requestId)
+	}
+
+	response, err := c.scimService.CreateGroup(ctx.UserContext(), request)
+	if err != nil {
+		return c.errResponse(ctx, err, "When the create SCIM Group ended with an error:", requestId)
+	}
+
+	ctx.Location(response.Meta.Location)
+	return http.ScimResponse(ctx, fiber.StatusCreated, response)
+}
+
+// ReplaceGroup godoc
+// @Description  Rename role and make its direct assignments match group members
+// @Summary		 replace SCIM group
+// @Tags 		 scim
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 id   path      	string  true  		"Group id"
+// @Param 		 request body 	scim.Group true 	"Group"
+// @Success 	 200  {object}  scim.Group			"Replaced group"
+// @Failure      400  {object}  scim.ErrorResponse	"Invalid value"
+// @Failure      404  {object}  scim.ErrorResponse	"Group not found"
+// @Failure      409  {object}  scim.ErrorResponse	"displayName already taken or segregation of duties violation"
+// @Failure      500  {object}  scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Groups/{id}	[put]
+func (c *Controller) ReplaceGroup(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request Group
+	if err := json.Unmarshal(ctx.Body(), &request); err != nil {
+		return c.bodyErrResponse(ctx, err, requestId)
+	}
+
+	response, err := c.scimService.ReplaceGroup(ctx.UserContext(), ctx.Params("id"), request)
+	if err != nil {
+		return c.errResponse(ctx, err, "When the replace SCIM Group ended with an error:", requestId)
+	}
+
+	return http.ScimResponse(ctx, fiber.StatusOK, response)
+}
+
+// PatchGroup godoc
+// @Description  Apply SCIM PatchOp operations to role: rename, add or remove members
+// @Summary		 patch SCIM group
+// @Tags 		 scim
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 id   path      	string  true  			"Group id"
+// @Param 		 request body 	scim.PatchRequest true 	"Patch operations"
+// @Success 	 200  {object}  scim.Group				"Patched group"
+// @Failure      400  {object}  scim.ErrorResponse		"Invalid path, value or syntax"
+// @Failure      404  {object}  scim.ErrorResponse		"Group not found"
+// @Failure      409  {object}  scim.ErrorResponse		"displayName already taken or segregation of duties violation"
+// @Failure      500  {object}  scim.ErrorResponse		"Internal server error"
+// @Router 		 /scim/v2/Groups/{id}	[patch]
+func (c *Controller) PatchGroup(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request PatchRequest
+	if err := json.Unmarshal(ctx.Body(), &request); err != nil {
+		return c.bodyErrResponse(ctx, err, requestId)
+	}
+
+	response, err := c.scimService.PatchGroup(ctx.UserContext(), ctx.Params("id"), request)
+	if err != nil {
+		return c.errResponse(ctx, err, "When the patch SCIM Group ended with an error:", requestId)
+	}
+
+	return http.ScimResponse(ctx, fiber.StatusOK, response)
+}
+
+// DeleteGroup godoc
+// @Description  Soft delete role
+// @Summary		 delete SCIM group
+// @Tags 		 scim
+// @Param 		 id   path      	string  true  		"Group id"
+// @Success 	 204
+// @Failure      404  {object}  	scim.ErrorResponse	"Group not found"
+// @Failure      500  {object}  	scim.ErrorResponse	"Internal server error"
+// @Router 		 /scim/v2/Groups/{id}	[delete]
+func (c *Controller) DeleteGroup(ctx *fiber.Ctx) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	if err := c.scimService.DeleteGroup(ctx.UserContext(), ctx.Params("id")); err != nil {
+		return c.errResponse(ctx, err, "When the delete SCIM Group ended with an error:", requestId)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// list - общий обработчик выборки ресурсов: filter, startIndex и count из query
+func (c *Controller) list(
+	ctx *fiber.Ctx,
+	resourceType string,
+	find func(ctx context.Context, request ListRequest) (ListResponse, error),
+) error {
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	startIndex, err := strconv.Atoi(ctx.Query("startIndex", "1"))
+	if err != nil {
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeInvalidValue, invalidPageValues)
+	}
+	count, err := strconv.Atoi(ctx.Query("count", strconv.Itoa(DefaultCount)))
+	if err != nil {
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeInvalidValue, invalidPageValues)
+	}
+
+	response, err := find(ctx.UserContext(), ListRequest{
+		Filter:     ctx.Query("filter"),
+		StartIndex: startIndex,
+		Count:      count,
+	})
+	if err != nil {
+		return c.errResponse(ctx, err, "When the list SCIM "+resourceType+"s ended with an error:", requestId)
+	}
+
+	return http.ScimResponse(ctx, fiber.StatusOK, response)
+}
+
+// bodyErrResponse - тело запроса SCIM (application/scim+json или application/json) не разобрано.
+// BodyParser не подходит: он не знает тип содержимого application/scim+json
+func (c *Controller) bodyErrResponse(ctx *fiber.Ctx, err error, requestId string) error {
+	c.logger.Error(
+		"When the body parse an SCIM request ended with an error:",
+		zap.Error(err),
+		zap.String("request_id", requestId),
+	)
+
+	return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeInvalidSyntax, invalidRequestBody)
+}
+
+// errResponse - маппинг ошибок в статусы и тело ошибки SCIM
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error, message string, requestId string) error {
+	c.logger.Error(
+		message,
+		zap.Error(err),
+		zap.String("request_id", requestId),
+	)
+
+	switch {
+	case errors.Is(err, ErrInvalidFilter) && !errors.Is(err, ErrInvalidPath):
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeInvalidFilter, err.Error())
+	case errors.Is(err, ErrInvalidPath):
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeInvalidPath, err.Error())
+	case errors.Is(err, ErrNoTarget):
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeNoTarget, err.Error())
+	case errors.Is(err, ErrInvalidSyntax):
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeInvalidSyntax, err.Error())
+	case errors.Is(err, ErrTooMany):
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeTooMany, err.Error())
+	case errors.As(err, &domain.RequestValidationError{}):
+		return c.scimError(ctx, fiber.StatusBadRequest, ScimTypeInvalidValue, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return c.scimError(ctx, fiber.StatusNotFound, "", err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}):
+		return c.scimError(ctx, fiber.StatusConflict, ScimTypeUniqueness, err.Error())
+	case errors.As(err, &domain.ConflictError{}), errors.As(err, &domain.SodViolationError{}):
+		return c.scimError(ctx, fiber.StatusConflict, "", err.Error())
+	default:
+		return c.scimError(ctx, fiber.StatusInternalServerError, "", internalServerError)
+	}
+}
+
+func (c *Controller) scimError(ctx *fiber.Ctx, status int, scimType string, detail string) error {
+	return http.ScimResponse(ctx, status, NewErrorResponse(status, scimType, detail))
+}
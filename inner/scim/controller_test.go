@@ -0,0 +1,237 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScim_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockScimService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupScim:             app.Group("/scim/v2"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	var active = true
+	alice := User{
+		Schemas:  []string{SchemaUser},
+		Id:       "1",
+		UserName: "alice",
+		Active:   &active,
+		Meta:     &Meta{ResourceType: ResourceUser, Created: time.Now(), LastModified: time.Now(), Location: "/scim/v2/Users/1"},
+	}
+
+	t.Run("should list users with paging", func(t *testing.T) {
+		request := ListRequest{Filter: `userName eq "alice"`, StartIndex: 1, Count: 10}
+		list := ListResponse{Schemas: []string{SchemaListResponse}, TotalResults: 1, StartIndex: 1, ItemsPerPage: 1,
+			Resources: []any{alice}}
+		mockService.On("ListUsers", appContext, request).Return(list, nil).Once()
+
+		req := httptest.NewRequest("GET", `/scim/v2/Users?filter=userName%20eq%20%22alice%22&count=10`, nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+
+		var body struct {
+			TotalResults int               `json:"totalResults"`
+			Resources    []json.RawMessage `json:"Resources"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, 1, body.TotalResults)
+		assert.Len(t, body.Resources, 1)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return scim error for invalid filter", func(t *testing.T) {
+		request := ListRequest{Filter: `userName like "a"`, StartIndex: 1, Count: DefaultCount}
+		_, parseErr := ParseFilter(request.Filter)
+		mockService.On("ListUsers", appContext, request).Return(ListResponse{}, parseErr).Once()
+
+		req := httptest.NewRequest("GET", `/scim/v2/Users?filter=userName%20like%20%22a%22`, nil)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, []string{SchemaError}, body.Schemas)
+		assert.Equal(t, "400", body.Status)
+		assert.Equal(t, ScimTypeInvalidFilter, body.ScimType)
+	})
+
+	t.Run("should return too many error for filter over in-memory limit", func(t *testing.T) {
+		request := ListRequest{Filter: `title co "a"`, StartIndex: 1, Count: DefaultCount}
+		mockService.On("ListUsers", appContext, request).Return(ListResponse{}, fmt.Errorf("%w: narrow it down", ErrTooMany)).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", `/scim/v2/Users?filter=title%20co%20%22a%22`, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, ScimTypeTooMany, body.ScimType)
+	})
+
+	t.Run("should return invalid value for non-numeric count", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/scim/v2/Groups?count=ten", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, ScimTypeInvalidValue, body.ScimType)
+	})
+
+	t.Run("should create user", func(t *testing.T) {
+		request := User{Schemas: []string{SchemaUser}, UserName: "alice", DisplayName: "Alice Doe"}
+		mockService.On("CreateUser", appContext, request).Return(alice, nil).Once()
+
+		body := `{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "alice", "displayName": "Alice Doe"}`
+		req := httptest.NewRequest("POST", "/scim/v2/Users", strings.NewReader(body))
+		req.Header.Set("Content-Type", ContentType)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/scim/v2/Users/1", resp.Header.Get("Location"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return uniqueness error for taken userName", func(t *testing.T) {
+		request := User{UserName: "alice"}
+		taken := domain.AlreadyExistsError{Message: "employee with this login or email already exists"}
+		mockService.On("CreateUser", appContext, request).Return(User{}, taken).Once()
+
+		req := httptest.NewRequest("POST", "/scim/v2/Users", strings.NewReader(`{"userName": "alice"}`))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, ScimTypeUniqueness, body.ScimType)
+	})
+
+	t.Run("should return invalid syntax for malformed body", func(t *testing.T) {
+		req := httptest.NewRequest("PATCH", "/scim/v2/Users/1", strings.NewReader(`{"Operations": [`))
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, ScimTypeInvalidSyntax, body.ScimType)
+	})
+
+	t.Run("should patch group members", func(t *testing.T) {
+		request := PatchRequest{
+			Schemas:    []string{SchemaPatchOp},
+			Operations: []PatchOperation{{Op: "remove", Path: `members[value eq "2"]`}},
+		}
+		group := Group{Schemas: []string{SchemaGroup}, Id: "5", DisplayName: "AUDITOR"}
+		mockService.On("PatchGroup", appContext, "5", request).Return(group, nil).Once()
+
+		body := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "remove", "path": "members[value eq \"2\"]"}]}`
+		resp, err := app.Test(httptest.NewRequest("PATCH", "/scim/v2/Groups/5", strings.NewReader(body)))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 for missing group", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "Group 9 not found"}
+		mockService.On("DeleteGroup", appContext, "9").Return(notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/scim/v2/Groups/9", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "404", body.Status)
+		assert.Equal(t, "Group 9 not found", body.Detail)
+	})
+
+	t.Run("should delete user", func(t *testing.T) {
+		mockService.On("DeleteUser", appContext, "1").Return(nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/scim/v2/Users/1", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return service provider config", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/scim/v2/ServiceProviderConfig", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body ServiceProviderConfig
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.True(t, body.Patch.Supported)
+		assert.True(t, body.Filter.Supported)
+		assert.False(t, body.Bulk.Supported)
+	})
+
+	t.Run("should return schema by urn", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/scim/v2/Schemas/"+SchemaGroup, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body Schema
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, ResourceGroup, body.Name)
+	})
+
+	t.Run("should list resource types", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/scim/v2/ResourceTypes", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body ListResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, 2, body.TotalResults)
+	})
+}
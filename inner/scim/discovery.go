@@ -0,0 +1,220 @@
+package scim
+
+import "idm/inner/web"
+
+// Метаданные сервера для клиентов SCIM: возможности (ServiceProviderConfig), типы ресурсов и схемы
+
+// supported - признак поддержки возможности в ServiceProviderConfig
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+// filterConfig - поддержка фильтрации и максимальный размер страницы
+type filterConfig struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+// bulkConfig - пакетные операции не поддерживаются
+type bulkConfig struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+// ServiceProviderConfig model info
+// @Description SCIM service provider capabilities
+type ServiceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkConfig             `json:"bulk"`
+	Filter                filterConfig           `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	Etag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+	Meta                  map[string]string      `json:"meta"`
+}
+
+type schemaExtension struct {
+	Schema   string `json:"schema"`
+	Required bool   `json:"required"`
+}
+
+// ResourceType model info
+// @Description SCIM resource type with endpoint and schema
+type ResourceType struct {
+	Schemas          []string          `json:"schemas"`
+	Id               string            `json:"id"`
+	Name             string            `json:"name"`
+	Endpoint         string            `json:"endpoint"`
+	Description      string            `json:"description"`
+	Schema           string            `json:"schema"`
+	SchemaExtensions []schemaExtension `json:"schemaExtensions,omitempty"`
+	Meta             map[string]string `json:"meta"`
+}
+
+// Attribute - описание атрибута схемы (RFC 7643, раздел 7)
+type Attribute struct {
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	MultiValued   bool        `json:"multiValued"`
+	Required      bool        `json:"required"`
+	CaseExact     bool        `json:"caseExact"`
+	Mutability    string      `json:"mutability"`
+	Returned      string      `json:"returned"`
+	Uniqueness    string      `json:"uniqueness"`
+	SubAttributes []Attribute `json:"subAttributes,omitempty"`
+}
+
+// Schema model info
+// @Description SCIM schema definition with attributes
+type Schema struct {
+	Schemas     []string          `json:"schemas"`
+	Id          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Attributes  []Attribute       `json:"attributes"`
+	Meta        map[string]string `json:"meta"`
+}
+
+func metaOf(resourceType string, path string) map[string]string {
+	return map[string]string{"resourceType": resourceType, "location": web.ScimPath + path}
+}
+
+// attribute - атрибут по умолчанию: строковый, изменяемый, возвращается всегда, без учёта регистра
+func attribute(name string, options ...func(*Attribute)) Attribute {
+	var attr = Attribute{Name: name, Type: "string", Mutability: "readWrite", Returned: "default", Uniqueness: "none"}
+	for _, option := range options {
+		option(&attr)
+	}
+	return attr
+}
+
+func ofType(attrType string) func(*Attribute) {
+	return func(a *Attribute) { a.Type = attrType }
+}
+
+func multiValued(a *Attribute) { a.MultiValued = true }
+
+func required(a *Attribute) { a.Required = true }
+
+func readOnly(a *Attribute) { a.Mutability = "readOnly" }
+
+func uniqueOnServer(a *Attribute) { a.Uniqueness = "server" }
+
+func withSub(sub ...Attribute) func(*Attribute) {
+	return func(a *Attribute) { a.Type = "complex"; a.SubAttributes = sub }
+}
+
+// NewServiceProviderConfig - поддерживаются PATCH и фильтрация, сортировка, ETag и пакетные операции - нет
+func NewServiceProviderConfig() ServiceProviderConfig {
+	return ServiceProviderConfig{
+		Schemas:        []string{SchemaServiceProviderConfig},
+		Patch:          supported{Supported: true},
+		Bulk:           bulkConfig{},
+		Filter:         filterConfig{Supported: true, MaxResults: MaxResults},
+		ChangePassword: supported{},
+		Sort:           supported{},
+		Etag:           supported{},
+		AuthenticationSchemes: []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "OAuth Bearer Token",
+			Description: "Access token issued by /api/v1/auth/token",
+			Primary:     true,
+		}},
+		Meta: metaOf("ServiceProviderConfig", "/ServiceProviderConfig"),
+	}
+}
+
+// NewResourceTypes - типы ресурсов User и Group
+func NewResourceTypes() []ResourceType {
+	return []ResourceType{
+		{
+			Schemas:          []string{SchemaResourceType},
+			Id:               ResourceUser,
+			Name:             ResourceUser,
+			Endpoint:         "/Users",
+			Description:      "Employee account",
+			Schema:           SchemaUser,
+			SchemaExtensions: []schemaExtension{{Schema: SchemaEnterpriseUser}},
+			Meta:             metaOf("ResourceType", "/ResourceTypes/"+ResourceUser),
+		},
+		{
+			Schemas:     []string{SchemaResourceType},
+			Id:          ResourceGroup,
+			Name:        ResourceGroup,
+			Endpoint:    "/Groups",
+			Description: "Role, members are employees the role is assigned to directly",
+			Schema:      SchemaGroup,
+			Meta:        metaOf("ResourceType", "/ResourceTypes/"+ResourceGroup),
+		},
+	}
+}
+
+// NewSchemas - схемы User, Group и расширения enterprise в поддерживаемом объёме атрибутов
+func NewSchemas() []Schema {
+	var multiValue = withSub(
+		attribute("value"),
+		attribute("type"),
+		attribute("primary", ofType("boolean")),
+	)
+	var reference = withSub(
+		attribute("value", readOnly),
+		attribute("display", readOnly),
+		attribute("$ref", ofType("reference"), readOnly),
+	)
+
+	return []Schema{
+		{
+			Schemas:     []string{SchemaSchema},
+			Id:          SchemaUser,
+			Name:        ResourceUser,
+			Description: "User Account",
+			Attributes: []Attribute{
+				attribute("userName", required, uniqueOnServer),
+				attribute("name", withSub(attribute("formatted"), attribute("givenName"), attribute("familyName"))),
+				attribute("displayName"),
+				attribute("title"),
+				attribute("active", ofType("boolean")),
+				attribute("emails", multiValue, multiValued),
+				attribute("phoneNumbers", multiValue, multiValued),
+				attribute("groups", reference, multiValued, readOnly),
+			},
+			Meta: metaOf("Schema", "/Schemas/"+SchemaUser),
+		},
+		{
+			Schemas:     []string{SchemaSchema},
+			Id:          SchemaGroup,
+			Name:        ResourceGroup,
+			Description: "Group",
+			Attributes: []Attribute{
+				attribute("displayName", required, uniqueOnServer),
+				attribute("members", withSub(
+					attribute("value"),
+					attribute("display", readOnly),
+					attribute("$ref", ofType("reference"), readOnly),
+				), multiValued),
+			},
+			Meta: metaOf("Schema", "/Schemas/"+SchemaGroup),
+		},
+		{
+			Schemas:     []string{SchemaSchema},
+			Id:          SchemaEnterpriseUser,
+			Name:        "EnterpriseUser",
+			Description: "Enterprise User",
+			Attributes: []Attribute{
+				attribute("department"),
+				attribute("manager", withSub(attribute("value"), attribute("displayName", readOnly))),
+			},
+			Meta: metaOf("Schema", "/Schemas/"+SchemaEnterpriseUser),
+		},
+	}
+}
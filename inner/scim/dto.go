@@ -0,0 +1,316 @@
+package scim
+
+import (
+	"idm/inner/employee"
+	"idm/inner/role"
+	"idm/inner/web"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Идентификаторы схем SCIM 2.0 (RFC 7643, RFC 7644)
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaEnterpriseUser        = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Типы ресурсов SCIM: User - сотрудник, Group - роль, участники группы - сотрудники с прямым назначением роли
+const (
+	ResourceUser  = "User"
+	ResourceGroup = "Group"
+)
+
+// Значения scimType в теле ошибки (RFC 7644, раздел 3.12)
+const (
+	ScimTypeInvalidFilter = "invalidFilter"
+	ScimTypeInvalidSyntax = "invalidSyntax"
+	ScimTypeInvalidPath   = "invalidPath"
+	ScimTypeInvalidValue  = "invalidValue"
+	ScimTypeNoTarget      = "noTarget"
+	ScimTypeUniqueness    = "uniqueness"
+	ScimTypeTooMany       = "tooMany"
+)
+
+// ContentType - тип содержимого ответов SCIM
+const ContentType = "application/scim+json"
+
+// Пагинация списков: startIndex считается с 1, count по умолчанию и не больше MaxResults
+const (
+	DefaultCount = 100
+	MaxResults   = 1000
+)
+
+// Name - составное имя пользователя, в сотруднике хранится только полное имя
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue - элемент многозначного атрибута (emails, phoneNumbers)
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Member - ссылка на ресурс: участник группы или группа пользователя
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// Manager - руководитель в расширении enterprise
+type Manager struct {
+	Value       string `json:"value"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// EnterpriseUser - атрибуты расширения urn:ietf:params:scim:schemas:extension:enterprise:2.0:User
+type EnterpriseUser struct {
+	Department string   `json:"department,omitempty"`
+	Manager    *Manager `json:"manager,omitempty"`
+}
+
+// Meta - служебные атрибуты ресурса
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+// User model info
+// @Description SCIM User resource backed by employee
+type User struct {
+	Schemas      []string        `json:"schemas"`
+	Id           string          `json:"id,omitempty"`
+	ExternalId   string          `json:"externalId,omitempty"` // id в системе-источнике: сервис его не хранит, заполняет при исходящем провижининге
+	UserName     string          `json:"userName"`
+	Name         *Name           `json:"name,omitempty"`
+	DisplayName  string          `json:"displayName,omitempty"`
+	Title        string          `json:"title,omitempty"`
+	Active       *bool           `json:"active,omitempty"` // не передано - при создании true, при замене не меняется
+	Emails       []MultiValue    `json:"emails,omitempty"`
+	PhoneNumbers []MultiValue    `json:"phoneNumbers,omitempty"`
+	Groups       []Member        `json:"groups,omitempty"` // только чтение: роли, назначенные напрямую
+	Enterprise   *EnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta         *Meta           `json:"meta,omitempty"`
+}
+
+// Group model info
+// @Description SCIM Group resource backed by role
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	Id          string   `json:"id,omitempty"`
+	ExternalId  string   `json:"externalId,omitempty"` // как у User
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// ListResponse model info
+// @Description SCIM list response with totalResults, startIndex, itemsPerPage and Resources
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// ErrorResponse model info
+// @Description SCIM error body, status is a string by RFC 7644
+type ErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// NewErrorResponse - тело ошибки SCIM для HTTP-статуса status
+func NewErrorResponse(status int, scimType string, detail string) ErrorResponse {
+	return ErrorResponse{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// PatchOperation - операция PATCH: op add, replace или remove, path необязателен для add и replace
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// PatchRequest model info
+// @Description SCIM PatchOp message with Operations
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// ListRequest - параметры выборки: filter по RFC 7644, startIndex с 1, count - размер страницы
+type ListRequest struct {
+	Filter     string
+	StartIndex int
+	Count      int
+}
+
+// location - адрес ресурса относительно корня сервера
+func location(resourceType string, id string) string {
+	return web.ScimPath + "/" + resourceType + "s/" + id
+}
+
+func formatId(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// userFromEmployee - сотрудник как ресурс User. userName - логин, а если его нет - email
+func userFromEmployee(e employee.Response, roles []employee.RoleResponse) User {
+	var id = formatId(e.Id)
+	var active = e.Status == employee.StatusActive
+	var user = User{
+		Schemas:     []string{SchemaUser},
+		Id:          id,
+		UserName:    e.Login,
+		Name:        &Name{Formatted: e.Name},
+		DisplayName: e.Name,
+		Title:       e.Title,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: ResourceUser,
+			Created:      e.CreateAt,
+			LastModified: e.UpdateAt,
+			Location:     location(ResourceUser, id),
+		},
+	}
+	if user.UserName == "" {
+		user.UserName = e.Email
+	}
+	if e.Email != "" {
+		user.Emails = []MultiValue{{Value: e.Email, Type: "work", Primary: true}}
+	}
+	if e.Phone != "" {
+		user.PhoneNumbers = []MultiValue{{Value: e.Phone, Type: "work", Primary: true}}
+	}
+	if e.Department != "" || e.ManagerId != nil {
+		user.Schemas = append(user.Schemas, SchemaEnterpriseUser)
+		user.Enterprise = &EnterpriseUser{Department: e.Department}
+		if e.ManagerId != nil {
+			user.Enterprise.Manager = &Manager{Value: formatId(*e.ManagerId)}
+		}
+	}
+	user.Groups = groupsOf(roles)
+
+	return user
+}
+
+// groupsOf - роли сотрудника как ссылки на группы
+func groupsOf(roles []employee.RoleResponse) []Member {
+	var groups []Member
+	for _, r := range roles {
+		var roleId = formatId(r.Id)
+		groups = append(groups, Member{Value: roleId, Display: r.Name, Ref: location(ResourceGroup, roleId)})
+	}
+	return groups
+}
+
+// groupFromRole - роль как ресурс Group
+func groupFromRole(r role.Response, members []role.EmployeeResponse) Group {
+	var id = formatId(r.Id)
+	var group = Group{
+		Schemas:     []string{SchemaGroup},
+		Id:          id,
+		DisplayName: r.Name,
+		Meta: &Meta{
+			ResourceType: ResourceGroup,
+			Created:      r.CreateAt,
+			LastModified: r.UpdateAt,
+			Location:     location(ResourceGroup, id),
+		},
+		Members: membersOf(members),
+	}
+
+	return group
+}
+
+// membersOf - сотрудники с ролью как ссылки на пользователей
+func membersOf(members []role.EmployeeResponse) []Member {
+	var users []Member
+	for _, m := range members {
+		var employeeId = formatId(m.Id)
+		users = append(users, Member{Value: employeeId, Display: m.Name, Ref: location(ResourceUser, employeeId)})
+	}
+	return users
+}
+
+// fullName - имя сотрудника: displayName, name.formatted, имя и фамилия, иначе userName
+func (u *User) fullName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		if name := strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName); name != "" {
+			return name
+		}
+	}
+	return u.UserName
+}
+
+// primary - основное значение многозначного атрибута, иначе первое
+func primary(values []MultiValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// profile - атрибуты профиля сотрудника из ресурса User. userName с "@" считается email,
+// иначе это логин; email из userName и из emails должны совпадать
+func (u *User) profile() (employee.Profile, error) {
+	var profile = employee.Profile{
+		Email: primary(u.Emails),
+		Title: u.Title,
+		Phone: primary(u.PhoneNumbers),
+	}
+	if strings.Contains(u.UserName, "@") {
+		if profile.Email != "" && !strings.EqualFold(profile.Email, u.UserName) {
+			return employee.Profile{}, invalidValueError("userName %q must be a login or the primary email", u.UserName)
+		}
+		profile.Email = u.UserName
+	} else {
+		profile.Login = u.UserName
+	}
+	if u.Enterprise != nil {
+		profile.Department = u.Enterprise.Department
+		if u.Enterprise.Manager != nil && u.Enterprise.Manager.Value != "" {
+			managerId, err := strconv.ParseInt(u.Enterprise.Manager.Value, 10, 64)
+			if err != nil || managerId < 1 {
+				return employee.Profile{}, invalidValueError("manager %q is not a User id", u.Enterprise.Manager.Value)
+			}
+			profile.ManagerId = managerId
+		}
+	}
+
+	return profile, nil
+}
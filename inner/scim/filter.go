@@ -0,0 +1,520 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidFilter - фильтр или путь не соответствует грамматике RFC 7644 (раздел 3.4.2.2)
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// ErrTooMany - фильтр нельзя выполнить запросом к базе, а ресурсов больше, чем допустимо просмотреть в памяти
+var ErrTooMany = errors.New("too many resources to filter")
+
+// Операторы сравнения фильтра
+const (
+	opEq = "eq"
+	opNe = "ne"
+	opCo = "co"
+	opSw = "sw"
+	opEw = "ew"
+	opGt = "gt"
+	opGe = "ge"
+	opLt = "lt"
+	opLe = "le"
+	opPr = "pr"
+)
+
+var comparisonOps = map[string]bool{
+	opEq: true, opNe: true, opCo: true, opSw: true, opEw: true,
+	opGt: true, opGe: true, opLt: true, opLe: true,
+}
+
+// Filter - разобранное выражение фильтра, применяется к JSON-представлению ресурса
+type Filter interface {
+	// Match - удовлетворяет ли ресурс (или элемент многозначного атрибута) фильтру
+	Match(resource map[string]any) bool
+	// Uses - упоминает ли фильтр атрибут верхнего уровня name (без учёта регистра)
+	Uses(name string) bool
+}
+
+// logicalFilter - "and" / "or" двух выражений
+type logicalFilter struct {
+	op          string
+	left, right Filter
+}
+
+func (f logicalFilter) Match(resource map[string]any) bool {
+	if f.op == "and" {
+		return f.left.Match(resource) && f.right.Match(resource)
+	}
+	return f.left.Match(resource) || f.right.Match(resource)
+}
+
+func (f logicalFilter) Uses(name string) bool {
+	return f.left.Uses(name) || f.right.Uses(name)
+}
+
+// notFilter - "not (...)"
+type notFilter struct {
+	inner Filter
+}
+
+func (f notFilter) Match(resource map[string]any) bool {
+	return !f.inner.Match(resource)
+}
+
+func (f notFilter) Uses(name string) bool {
+	return f.inner.Uses(name)
+}
+
+// compareFilter - "attrPath op value" или "attrPath pr"
+type compareFilter struct {
+	path  attrPath
+	op    string
+	value any
+}
+
+func (f compareFilter) Match(resource map[string]any) bool {
+	var values = f.path.values(resource)
+	if f.op == opPr {
+		for _, v := range values {
+			if !isEmpty(v) {
+				return true
+			}
+		}
+		return false
+	}
+	if f.op == opNe {
+		for _, v := range values {
+			if compare(opEq, v, f.value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if compare(f.op, v, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f compareFilter) Uses(name string) bool {
+	return f.path.uses(name)
+}
+
+// valuePathFilter - "emails[type eq "work"]": хотя бы один элемент атрибута удовлетворяет фильтру
+type valuePathFilter struct {
+	path   attrPath
+	filter Filter
+}
+
+func (f valuePathFilter) Match(resource map[string]any) bool {
+	for _, v := range f.path.values(resource) {
+		if element, ok := v.(map[string]any); ok && f.filter.Match(element) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f valuePathFilter) Uses(name string) bool {
+	return f.path.uses(name)
+}
+
+// attrPath - путь к атрибуту: необязательный URN схемы, имя атрибута и податрибут
+type attrPath struct {
+	schema string
+	attr   string
+	sub    string
+}
+
+// parseAttrPath - "userName", "name.givenName",
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department"
+func parseAttrPath(path string) (attrPath, error) {
+	var result attrPath
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		idx := strings.LastIndex(path, ":")
+		result.schema, path = path[:idx], path[idx+1:]
+	}
+	result.attr, result.sub, _ = strings.Cut(path, ".")
+	if !isAttrName(result.attr) || (result.sub != "" && !isAttrName(result.sub)) {
+		return attrPath{}, fmt.Errorf("%w: invalid attribute path %q", ErrInvalidFilter, path)
+	}
+	return result, nil
+}
+
+func isAttrName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !(unicode.IsLetter(r) || r == '$' || (i > 0 && (unicode.IsDigit(r) || r == '_' || r == '-'))) {
+			return false
+		}
+	}
+	return true
+}
+
+// container - объект, в котором лежит атрибут: сам ресурс или расширение схемы
+func (p attrPath) container(resource map[string]any) (map[string]any, bool) {
+	if p.schema == "" || strings.EqualFold(p.schema, SchemaUser) || strings.EqualFold(p.schema, SchemaGroup) {
+		return resource, true
+	}
+	extension, ok := lookup(resource, p.schema).(map[string]any)
+	return extension, ok
+}
+
+// values - значения атрибута; элементы многозначных атрибутов разворачиваются
+func (p attrPath) values(resource map[string]any) []any {
+	container, ok := p.container(resource)
+	if !ok {
+		return nil
+	}
+	var values = flatten(lookup(container, p.attr))
+	if p.sub == "" {
+		return values
+	}
+	var subValues []any
+	for _, v := range values {
+		if element, ok := v.(map[string]any); ok {
+			subValues = append(subValues, flatten(lookup(element, p.sub))...)
+		}
+	}
+	return subValues
+}
+
+func (p attrPath) uses(name string) bool {
+	return strings.EqualFold(p.attr, name)
+}
+
+// lookup - значение атрибута без учёта регистра имени (RFC 7643, раздел 2.1)
+func lookup(object map[string]any, name string) any {
+	if value, ok := object[name]; ok {
+		return value
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return nil
+}
+
+func flatten(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// compare - сравнение значения атрибута с литералом фильтра. Строки сравниваются без учёта регистра,
+// даты в формате RFC 3339 - как строки
+func compare(op string, actual any, expected any) bool {
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		a, e = strings.ToLower(a), strings.ToLower(e)
+		switch op {
+		case opEq:
+			return a == e
+		case opCo:
+			return strings.Contains(a, e)
+		case opSw:
+			return strings.HasPrefix(a, e)
+		case opEw:
+			return strings.HasSuffix(a, e)
+		case opGt:
+			return a > e
+		case opGe:
+			return a >= e
+		case opLt:
+			return a < e
+		case opLe:
+			return a <= e
+		}
+	case bool:
+		e, ok := expected.(bool)
+		return ok && op == opEq && a == e
+	case float64:
+		e, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case opEq:
+			return a == e
+		case opGt:
+			return a > e
+		case opGe:
+			return a >= e
+		case opLt:
+			return a < e
+		case opLe:
+			return a <= e
+		}
+	case nil:
+		return op == opEq && expected == nil
+	}
+	return false
+}
+
+// ParseFilter - разобрать выражение фильтра, например `userName eq "jdoe" and emails[type eq "work"]`
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidFilter, p.tokens[p.pos].text)
+	}
+	return result, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpen
+	tokenClose
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(filter string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenOpenBracket, text: "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenCloseBracket, text: "]"})
+			i++
+		case c == '"':
+			// строка в формате JSON, экранирование через "\"
+			j := i + 1
+			for ; j < len(filter) && filter[j] != '"'; j++ {
+				if filter[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(filter) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalidFilter)
+			}
+			var value string
+			if err := json.Unmarshal([]byte(filter[i:j+1]), &value); err != nil {
+				return nil, fmt.Errorf("%w: invalid string %s", ErrInvalidFilter, filter[i:j+1])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value})
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(filter) && !strings.ContainsRune(" \t()[]\"", rune(filter[j])); j++ {
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: filter[i:j]})
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty filter", ErrInvalidFilter)
+	}
+	return tokens, nil
+}
+
+// parser - рекурсивный спуск: or < and < not, скобки, сравнение
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t, ok := p.peek()
+	return ok && t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t, ok := p.peek()
+	if !ok || t.kind != kind {
+		return fmt.Errorf("%w: expected %q", ErrInvalidFilter, text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseFactor() (Filter, error) {
+	if p.isKeyword("not") {
+		p.pos++
+		if err := p.expect(tokenOpen, "("); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{inner: inner}, nil
+	}
+
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected end of filter", ErrInvalidFilter)
+	}
+	if t.kind == tokenOpen {
+		p.pos++
+		return p.parseGroup()
+	}
+	if t.kind != tokenWord {
+		return nil, fmt.Errorf("%w: expected attribute, got %q", ErrInvalidFilter, t.text)
+	}
+	p.pos++
+
+	path, err := parseAttrPath(t.text)
+	if err != nil {
+		return nil, err
+	}
+	if next, ok := p.peek(); ok && next.kind == tokenOpenBracket {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		return valuePathFilter{path: path, filter: inner}, nil
+	}
+
+	return p.parseComparison(path)
+}
+
+// parseGroup - выражение после "(" до парной ")"
+func (p *parser) parseGroup() (Filter, error) {
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(tokenClose, ")"); err != nil {
+		return nil, err
+	}
+	return inner, nil
+}
+
+func (p *parser) parseComparison(path attrPath) (Filter, error) {
+	t, ok := p.peek()
+	if !ok || t.kind != tokenWord {
+		return nil, fmt.Errorf("%w: expected operator after %q", ErrInvalidFilter, path.attr)
+	}
+	var op = strings.ToLower(t.text)
+	p.pos++
+	if op == opPr {
+		return compareFilter{path: path, op: op}, nil
+	}
+	if !comparisonOps[op] {
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, t.text)
+	}
+
+	t, ok = p.peek()
+	if !ok {
+		return nil, fmt.Errorf("%w: expected value after %q", ErrInvalidFilter, op)
+	}
+	p.pos++
+	if t.kind == tokenString {
+		return compareFilter{path: path, op: op, value: t.text}, nil
+	}
+	if t.kind != tokenWord {
+		return nil, fmt.Errorf("%w: expected value, got %q", ErrInvalidFilter, t.text)
+	}
+	// true, false, null и числа - литералы JSON
+	var value any
+	if err := json.Unmarshal([]byte(strings.ToLower(t.text)), &value); err != nil {
+		return nil, fmt.Errorf("%w: invalid value %q", ErrInvalidFilter, t.text)
+	}
+	if _, isObject := value.(map[string]any); isObject {
+		return nil, fmt.Errorf("%w: invalid value %q", ErrInvalidFilter, t.text)
+	}
+	return compareFilter{path: path, op: op, value: value}, nil
+}
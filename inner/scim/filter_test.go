@@ -0,0 +1,82 @@
+package scim
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	var user = map[string]any{
+		"id":          "7",
+		"userName":    "jdoe",
+		"displayName": "John Doe",
+		"active":      true,
+		"name":        map[string]any{"formatted": "John Doe"},
+		"emails": []any{
+			map[string]any{"value": "john@corp.example", "type": "work", "primary": true},
+		},
+		"meta":               map[string]any{"created": "2025-07-01T10:00:00Z"},
+		SchemaEnterpriseUser: map[string]any{"department": "Finance"},
+	}
+
+	var tests = []struct {
+		name      string
+		filter    string
+		isMatched bool
+	}{
+		{"eq is case insensitive", `userName eq "JDoe"`, true},
+		{"attribute name is case insensitive", `USERNAME eq "jdoe"`, true},
+		{"ne", `userName ne "jdoe"`, false},
+		{"co", `displayName co "n D"`, true},
+		{"sw", `displayName sw "john"`, true},
+		{"ew", `displayName ew "smith"`, false},
+		{"pr", `title pr`, false},
+		{"boolean", `active eq true`, true},
+		{"sub attribute", `name.formatted eq "John Doe"`, true},
+		{"multi valued sub attribute", `emails.value ew "@corp.example"`, true},
+		{"value path", `emails[type eq "work" and value co "john"]`, true},
+		{"value path without match", `emails[type eq "home"]`, false},
+		{"date comparison", `meta.created gt "2025-06-30T00:00:00Z"`, true},
+		{"extension attribute", `urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department eq "finance"`, true},
+		{"and binds tighter than or", `userName eq "x" and active eq true or id eq "7"`, true},
+		{"grouping", `userName eq "x" and (active eq true or id eq "7")`, false},
+		{"not", `not (userName eq "x")`, true},
+		{"escaped quote", `displayName eq "John \"JD\" Doe"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.isMatched, filter.Match(user))
+		})
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName like "x"`,
+		`userName eq`,
+		`userName eq "x" and`,
+		`(userName eq "x"`,
+		`emails[type eq "work"`,
+		`userName eq "unterminated`,
+		`userName eq {}`,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			_, err := ParseFilter(filter)
+			assert.True(t, errors.Is(err, ErrInvalidFilter), "filter %q: %v", filter, err)
+		})
+	}
+}
+
+func TestParseFilter_Uses(t *testing.T) {
+	filter, err := ParseFilter(`displayName eq "ADMIN" and members[value eq "5"]`)
+	require.NoError(t, err)
+	assert.True(t, filter.Uses("members"))
+	assert.False(t, filter.Uses("groups"))
+}
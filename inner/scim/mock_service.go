@@ -0,0 +1,70 @@
+package scim
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockScimService struct {
+	mock.Mock
+}
+
+func (m *MockScimService) ListUsers(ctx context.Context, request ListRequest) (ListResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(ListResponse), args.Error(1)
+}
+
+func (m *MockScimService) GetUser(ctx context.Context, id string) (User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (m *MockScimService) CreateUser(ctx context.Context, user User) (User, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (m *MockScimService) ReplaceUser(ctx context.Context, id string, user User) (User, error) {
+	args := m.Called(ctx, id, user)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (m *MockScimService) PatchUser(ctx context.Context, id string, request PatchRequest) (User, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(User), args.Error(1)
+}
+
+func (m *MockScimService) DeleteUser(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockScimService) ListGroups(ctx context.Context, request ListRequest) (ListResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(ListResponse), args.Error(1)
+}
+
+func (m *MockScimService) GetGroup(ctx context.Context, id string) (Group, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Group), args.Error(1)
+}
+
+func (m *MockScimService) CreateGroup(ctx context.Context, group Group) (Group, error) {
+	args := m.Called(ctx, group)
+	return args.Get(0).(Group), args.Error(1)
+}
+
+func (m *MockScimService) ReplaceGroup(ctx context.Context, id string, group Group) (Group, error) {
+	args := m.Called(ctx, id, group)
+	return args.Get(0).(Group), args.Error(1)
+}
+
+func (m *MockScimService) PatchGroup(ctx context.Context, id string, request PatchRequest) (Group, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(Group), args.Error(1)
+}
+
+func (m *MockScimService) DeleteGroup(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
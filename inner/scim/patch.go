@@ -0,0 +1,280 @@
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidPath - путь операции PATCH не разобран
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrNoTarget - путь операции не указывает ни на одно значение
+	ErrNoTarget = errors.New("no target")
+	// ErrInvalidSyntax - тело запроса не соответствует протоколу SCIM
+	ErrInvalidSyntax = errors.New("invalid syntax")
+)
+
+// Операции PATCH (RFC 7644, раздел 3.5.2)
+const (
+	patchAdd     = "add"
+	patchReplace = "replace"
+	patchRemove  = "remove"
+)
+
+// patchPath - путь операции: атрибут, необязательный отбор элементов "[...]" и податрибут после него
+type patchPath struct {
+	attrPath
+	filter Filter
+}
+
+// parsePatchPath - `displayName`, `name.givenName`, `emails[type eq "work"].value`, `members[value eq "2"]`
+func parsePatchPath(path string) (patchPath, error) {
+	open := strings.Index(path, "[")
+	if open < 0 {
+		parsed, err := parseAttrPath(path)
+		if err != nil {
+			return patchPath{}, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+		return patchPath{attrPath: parsed}, nil
+	}
+
+	end := strings.LastIndex(path, "]")
+	if end < open {
+		return patchPath{}, fmt.Errorf("%w: unbalanced brackets in %q", ErrInvalidPath, path)
+	}
+	parsed, err := parseAttrPath(path[:open])
+	if err != nil || parsed.sub != "" {
+		return patchPath{}, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+	}
+	filter, err := ParseFilter(path[open+1 : end])
+	if err != nil {
+		return patchPath{}, fmt.Errorf("%w: %q: %w", ErrInvalidPath, path, err)
+	}
+	if rest := path[end+1:]; rest != "" {
+		if !strings.HasPrefix(rest, ".") || !isAttrName(rest[1:]) {
+			return patchPath{}, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+		parsed.sub = rest[1:]
+	}
+
+	return patchPath{attrPath: parsed, filter: filter}, nil
+}
+
+// applyPatch - применить операции к JSON-представлению ресурса
+func applyPatch(resource map[string]any, operations []PatchOperation) error {
+	for _, operation := range operations {
+		var op = strings.ToLower(operation.Op)
+		switch op {
+		case patchAdd, patchReplace:
+			if operation.Path == "" {
+				if err := patchValues(resource, op, operation.Value); err != nil {
+					return err
+				}
+				continue
+			}
+			path, err := parsePatchPath(operation.Path)
+			if err != nil {
+				return err
+			}
+			if err = setValue(resource, path, op, operation.Value); err != nil {
+				return err
+			}
+
+		case patchRemove:
+			if operation.Path == "" {
+				return fmt.Errorf("%w: remove operation requires path", ErrNoTarget)
+			}
+			path, err := parsePatchPath(operation.Path)
+			if err != nil {
+				return err
+			}
+			removeValue(resource, path, operation.Value)
+
+		default:
+			return fmt.Errorf("%w: unknown patch operation %q", ErrInvalidSyntax, operation.Op)
+		}
+	}
+
+	return nil
+}
+
+// patchValues - add или replace без path: value - объект атрибутов, ключом может быть URN расширения
+func patchValues(resource map[string]any, op string, value any) error {
+	values, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%w: value must be an object when path is omitted", ErrInvalidSyntax)
+	}
+	for key, attrValue := range values {
+		if extension, isObject := attrValue.(map[string]any); isObject && strings.HasPrefix(strings.ToLower(key), "urn:") {
+			for subKey, subValue := range extension {
+				if err := patchValues(resource, op, map[string]any{key + ":" + subKey: subValue}); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		path, err := parsePatchPath(key)
+		if err != nil {
+			return err
+		}
+		if err = setValue(resource, path, op, attrValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// containerFor - объект, в который пишется атрибут; объект расширения создаётся при первой записи
+func containerFor(resource map[string]any, path attrPath) map[string]any {
+	if container, ok := path.container(resource); ok {
+		return container
+	}
+	var extension = map[string]any{}
+	resource[path.schema] = extension
+	return extension
+}
+
+// keyOf - существующий ключ атрибута с учётом регистра или name, если атрибута нет
+func keyOf(object map[string]any, name string) string {
+	if _, ok := object[name]; ok {
+		return name
+	}
+	for key := range object {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
+
+func setValue(resource map[string]any, path patchPath, op string, value any) error {
+	var container = containerFor(resource, path.attrPath)
+	var key = keyOf(container, path.attr)
+
+	if path.filter == nil {
+		if path.sub == "" {
+			container[key] = merge(container[key], op, value)
+			return nil
+		}
+		object, _ := container[key].(map[string]any)
+		if object == nil {
+			object = map[string]any{}
+		}
+		var subKey = keyOf(object, path.sub)
+		object[subKey] = merge(object[subKey], op, value)
+		container[key] = object
+		return nil
+	}
+
+	var elements = flatten(container[key])
+	var isMatched bool
+	for i, element := range elements {
+		object, ok := element.(map[string]any)
+		if !ok || !path.filter.Match(object) {
+			continue
+		}
+		isMatched = true
+		elements[i] = setElement(object, path.sub, op, value)
+	}
+	if !isMatched {
+		// `emails[type eq "work"].value` без рабочего email - добавляем элемент с type = "work"
+		seed, ok := path.filter.(compareFilter)
+		if !ok || seed.op != opEq || seed.path.sub != "" {
+			return fmt.Errorf("%w: no values of %q match the filter", ErrNoTarget, path.attr)
+		}
+		elements = append(elements, setElement(map[string]any{seed.path.attr: seed.value}, path.sub, op, value))
+	}
+	container[key] = elements
+
+	return nil
+}
+
+func setElement(element map[string]any, sub string, op string, value any) any {
+	if sub == "" {
+		return merge(element, op, value)
+	}
+	var subKey = keyOf(element, sub)
+	element[subKey] = merge(element[subKey], op, value)
+	return element
+}
+
+// merge - add дописывает значения к многозначному атрибуту, у составного атрибута
+// add и replace меняют только переданные податрибуты, остальное заменяется целиком
+func merge(existing any, op string, value any) any {
+	if elements, ok := existing.([]any); ok && op == patchAdd {
+		return append(elements, flatten(value)...)
+	}
+	existingObject, isObject := existing.(map[string]any)
+	valueObject, isValueObject := value.(map[string]any)
+	if isObject && isValueObject {
+		var merged = make(map[string]any, len(existingObject)+len(valueObject))
+		for k, v := range existingObject {
+			merged[k] = v
+		}
+		for k, v := range valueObject {
+			merged[keyOf(existingObject, k)] = v
+		}
+		return merged
+	}
+	return value
+}
+
+// removeValue - удалить атрибут, отобранные элементы или (как шлют некоторые клиенты)
+// элементы, перечисленные в value по полю "value"
+func removeValue(resource map[string]any, path patchPath, value any) {
+	container, ok := path.container(resource)
+	if !ok {
+		return
+	}
+	var key = keyOf(container, path.attr)
+
+	if path.filter == nil {
+		if path.sub != "" {
+			if object, ok := container[key].(map[string]any); ok {
+				delete(object, keyOf(object, path.sub))
+			}
+			return
+		}
+		elements, isList := container[key].([]any)
+		if !isList || value == nil {
+			delete(container, key)
+			return
+		}
+		var removed = map[string]bool{}
+		for _, v := range flatten(value) {
+			if object, ok := v.(map[string]any); ok {
+				removed[fmt.Sprint(lookup(object, "value"))] = true
+			}
+		}
+		container[key] = keep(elements, func(object map[string]any) bool {
+			return !removed[fmt.Sprint(lookup(object, "value"))]
+		})
+		return
+	}
+
+	elements := flatten(container[key])
+	if path.sub != "" {
+		for _, element := range elements {
+			if object, ok := element.(map[string]any); ok && path.filter.Match(object) {
+				delete(object, keyOf(object, path.sub))
+			}
+		}
+		return
+	}
+	container[key] = keep(elements, func(object map[string]any) bool {
+		return !path.filter.Match(object)
+	})
+}
+
+// keep - элементы многозначного атрибута, для которых isKept истинно
+func keep(elements []any, isKept func(object map[string]any) bool) []any {
+	var kept = make([]any, 0, len(elements))
+	for _, element := range elements {
+		if object, ok := element.(map[string]any); !ok || isKept(object) {
+			kept = append(kept, element)
+		}
+	}
+	return kept
+}
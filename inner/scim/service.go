@@ -0,0 +1,718 @@
+package scim
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"idm/inner/domain"
+	"idm/inner/employee"
+	"idm/inner/role"
+	"strconv"
+	"strings"
+)
+
+// Service - ресурсы SCIM поверх сервисов сотрудников и ролей: все проверки, аудит и
+// разделение полномочий выполняются в них, здесь только отображение на протокол
+type Service struct {
+	employees EmployeeSvc
+	roles     RoleSvc
+}
+
+// Фильтры пользователей, не сводимые к запросу, применяются в памяти к не больше чем maxFilterScan
+// сотрудникам, которые читаются порциями по scanBatchSize
+const (
+	maxFilterScan = 10000
+	scanBatchSize = 500
+)
+
+// userQueryOps - операторы фильтра, которые выполняются запросом к базе
+var userQueryOps = map[string]string{
+	opEq: employee.FilterOpEq,
+	opSw: employee.FilterOpSw,
+	opCo: employee.FilterOpCo,
+}
+
+// EmployeeSvc - используемая часть employee.Service
+type EmployeeSvc interface {
+	FindFiltered(ctx context.Context, request employee.FilterRequest) (employee.FilterPage, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (employee.Response, error)
+	CreateEmployee(ctx context.Context, request employee.CreateRequest) (employee.Response, error)
+	UpdateEmployee(ctx context.Context, id int64, request employee.UpdateRequest) (employee.Response, error)
+	DeleteById(ctx context.Context, id int64) (employee.Response, error)
+	ChangeStatus(ctx context.Context, id int64, action string) (employee.Response, error)
+	FindRoles(ctx context.Context, employeeId int64) ([]employee.RoleResponse, error)
+	FindRolesByEmployeeIds(ctx context.Context, employeeIds []int64) (map[int64][]employee.RoleResponse, error)
+}
+
+// RoleSvc - используемая часть role.Service
+type RoleSvc interface {
+	FindAll(ctx context.Context, includeDeleted bool) ([]role.Response, error)
+	FindById(ctx context.Context, id int64, includeDeleted bool) (role.Response, error)
+	CreateRole(ctx context.Context, request role.CreateRequest) (role.Response, error)
+	UpdateRole(ctx context.Context, id int64, request role.UpdateRequest) (role.Response, error)
+	DeleteById(ctx context.Context, id int64) (role.Response, error)
+	FindEmployees(ctx context.Context, roleId int64) ([]role.EmployeeResponse, error)
+	AssignEmployee(ctx context.Context, roleId int64, request role.AssignEmployeeRequest) ([]role.EmployeeResponse, error)
+	RevokeEmployee(ctx context.Context, roleId int64, employeeId int64) ([]role.EmployeeResponse, error)
+}
+
+// NewService - функция-конструктор
+func NewService(employees EmployeeSvc, roles RoleSvc) *Service {
+	return &Service{
+		employees: employees,
+		roles:     roles,
+	}
+}
+
+// ListUsers - страница сотрудников, удовлетворяющих фильтру. Без фильтра и для одного сравнения
+// eq/sw/co атрибутов userName, id или externalId отбор и пагинация выполняются в базе,
+// остальные фильтры применяются в памяти (см. scanUsers)
+func (svc *Service) ListUsers(ctx context.Context, request ListRequest) (ListResponse, error) {
+	filter, err := parseListFilter(request.Filter)
+	if err != nil {
+		return ListResponse{}, err
+	}
+
+	query, isEmpty, ok := userQuery(filter)
+	if !ok {
+		return svc.scanUsers(ctx, filter, request)
+	}
+	var start = max(request.StartIndex, 1) - 1
+	if isEmpty {
+		return newListResponse(0, start, []any{}), nil
+	}
+
+	query.Offset, query.Limit = int64(start), int64(min(max(request.Count, 0), MaxResults))
+	page, err := svc.employees.FindFiltered(ctx, query)
+	if err != nil {
+		return ListResponse{}, fmt.Errorf("error finding employees: %w", err)
+	}
+	resources, err := svc.usersOf(ctx, page.Result, nil)
+	if err != nil {
+		return ListResponse{}, err
+	}
+
+	return newListResponse(int(page.Total), start, resources), nil
+}
+
+// scanUsers - отбор сотрудников фильтром в памяти: сотрудники читаются порциями по scanBatchSize,
+// роли - одним запросом на порцию и только если фильтр их упоминает.
+// Просматривается не больше maxFilterScan сотрудников, иначе ErrTooMany
+func (svc *Service) scanUsers(ctx context.Context, filter Filter, request ListRequest) (ListResponse, error) {
+	var withGroups = filter.Uses("groups")
+	var matched []employee.Response
+	var matchedRoles map[int64][]employee.RoleResponse
+	if withGroups {
+		matchedRoles = make(map[int64][]employee.RoleResponse)
+	}
+
+	for offset := int64(0); ; offset += scanBatchSize {
+		batch, err := svc.employees.FindFiltered(ctx, employee.FilterRequest{Offset: offset, Limit: scanBatchSize})
+		if err != nil {
+			return ListResponse{}, fmt.Errorf("error finding employees: %w", err)
+		}
+		if batch.Total > maxFilterScan {
+			return ListResponse{}, fmt.Errorf(
+				"%w: filter %q is applied in memory to at most %d users, narrow it down to userName, id or externalId",
+				ErrTooMany, request.Filter, maxFilterScan,
+			)
+		}
+
+		var roles map[int64][]employee.RoleResponse
+		if withGroups {
+			if roles, err = svc.employees.FindRolesByEmployeeIds(ctx, employeeIds(batch.Result)); err != nil {
+				return ListResponse{}, fmt.Errorf("error finding roles of employees: %w", err)
+			}
+		}
+		for _, e := range batch.Result {
+			isMatched, err := matches(filter, userFromEmployee(e, roles[e.Id]))
+			if err != nil {
+				return ListResponse{}, err
+			}
+			if isMatched {
+				matched = append(matched, e)
+				if withGroups {
+					matchedRoles[e.Id] = roles[e.Id]
+				}
+			}
+		}
+		if len(batch.Result) < scanBatchSize {
+			break
+		}
+	}
+
+	start, end := pageBounds(len(matched), request)
+	resources, err := svc.usersOf(ctx, matched[start:end], matchedRoles)
+	if err != nil {
+		return ListResponse{}, err
+	}
+
+	return newListResponse(len(matched), start, resources), nil
+}
+
+// usersOf - пользователи SCIM по сотрудникам; roles = nil - роли загружаются одним запросом
+func (svc *Service) usersOf(
+	ctx context.Context,
+	employees []employee.Response,
+	roles map[int64][]employee.RoleResponse,
+) ([]any, error) {
+	if roles == nil {
+		var err error
+		if roles, err = svc.employees.FindRolesByEmployeeIds(ctx, employeeIds(employees)); err != nil {
+			return nil, fmt.Errorf("error finding roles of employees: %w", err)
+		}
+	}
+
+	var resources = make([]any, 0, len(employees))
+	for _, e := range employees {
+		resources = append(resources, userFromEmployee(e, roles[e.Id]))
+	}
+	return resources, nil
+}
+
+// GetUser - сотрудник с ролями, назначенными напрямую
+func (svc *Service) GetUser(ctx context.Context, id string) (User, error) {
+	current, err := svc.findEmployee(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+
+	roles, err := svc.employees.FindRoles(ctx, current.Id)
+	if err != nil {
+		return User{}, fmt.Errorf("error finding roles of employee %d: %w", current.Id, err)
+	}
+
+	return userFromEmployee(current, roles), nil
+}
+
+// CreateUser - создать сотрудника; active=false создаёт ещё не вышедшего на работу (pre_hire)
+func (svc *Service) CreateUser(ctx context.Context, user User) (User, error) {
+	if user.UserName == "" {
+		return User{}, invalidValueError("userName is required")
+	}
+	profile, err := user.profile()
+	if err != nil {
+		return User{}, err
+	}
+
+	var status = employee.StatusActive
+	if user.Active != nil && !*user.Active {
+		status = employee.StatusPreHire
+	}
+	created, err := svc.employees.CreateEmployee(ctx, employee.CreateRequest{
+		Name:    user.fullName(),
+		Status:  status,
+		Profile: profile,
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return svc.GetUser(ctx, formatId(created.Id))
+}
+
+// ReplaceUser - заменить атрибуты сотрудника (PUT); даты начала и окончания работы в SCIM не передаются
+// и сохраняются. Смена active выполняется действиями жизненного цикла
+func (svc *Service) ReplaceUser(ctx context.Context, id string, user User) (User, error) {
+	current, err := svc.findEmployee(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+	if user.UserName == "" {
+		return User{}, invalidValueError("userName is required")
+	}
+	profile, err := user.profile()
+	if err != nil {
+		return User{}, err
+	}
+	profile.StartDate = current.StartDate
+	profile.EndDate = current.EndDate
+
+	_, err = svc.employees.UpdateEmployee(ctx, current.Id, employee.UpdateRequest{
+		Name:      user.fullName(),
+		CreatedAt: current.CreateAt,
+		UpdatedAt: current.UpdateAt,
+		Profile:   profile,
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	if err = svc.setActive(ctx, current, user.Active); err != nil {
+		return User{}, err
+	}
+
+	return svc.GetUser(ctx, id)
+}
+
+// PatchUser - применить операции PATCH к текущему состоянию сотрудника и сохранить результат как PUT
+func (svc *Service) PatchUser(ctx context.Context, id string, request PatchRequest) (User, error) {
+	if err := checkPatchRequest(request); err != nil {
+		return User{}, err
+	}
+	current, err := svc.GetUser(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+
+	var patched User
+	if err = patchResource(current, request.Operations, normalizeUser, &patched); err != nil {
+		return User{}, err
+	}
+
+	return svc.ReplaceUser(ctx, id, patched)
+}
+
+// DeleteUser - мягко удалить сотрудника
+func (svc *Service) DeleteUser(ctx context.Context, id string) error {
+	current, err := svc.findEmployee(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err = svc.employees.DeleteById(ctx, current.Id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListGroups - страница ролей, удовлетворяющих фильтру
+func (svc *Service) ListGroups(ctx context.Context, request ListRequest) (ListResponse, error) {
+	filter, err := parseListFilter(request.Filter)
+	if err != nil {
+		return ListResponse{}, err
+	}
+
+	roles, err := svc.roles.FindAll(ctx, false)
+	if err != nil {
+		return ListResponse{}, fmt.Errorf("error finding roles: %w", err)
+	}
+
+	// участники нужны до фильтрации, только если по ним фильтруют
+	var withMembers = filter != nil && filter.Uses("members")
+	var groups = make([]Group, 0, len(roles))
+	for _, r := range roles {
+		var members []role.EmployeeResponse
+		if withMembers {
+			if members, err = svc.roles.FindEmployees(ctx, r.Id); err != nil {
+				return ListResponse{}, fmt.Errorf("error finding employees of role %d: %w", r.Id, err)
+			}
+		}
+		group := groupFromRole(r, members)
+		isMatched, err := matches(filter, group)
+		if err != nil {
+			return ListResponse{}, err
+		}
+		if isMatched {
+			groups = append(groups, group)
+		}
+	}
+
+	start, end := pageBounds(len(groups), request)
+	var resources = make([]any, 0, end-start)
+	for _, group := range groups[start:end] {
+		if !withMembers {
+			roleId, _ := strconv.ParseInt(group.Id, 10, 64)
+			members, err := svc.roles.FindEmployees(ctx, roleId)
+			if err != nil {
+				return ListResponse{}, fmt.Errorf("error finding employees of role %d: %w", roleId, err)
+			}
+			group.Members = membersOf(members)
+		}
+		resources = append(resources, group)
+	}
+
+	return newListResponse(len(groups), start, resources), nil
+}
+
+// GetGroup - роль с сотрудниками, которым она назначена напрямую
+func (svc *Service) GetGroup(ctx context.Context, id string) (Group, error) {
+	current, err := svc.findRole(ctx, id)
+	if err != nil {
+		return Group{}, err
+	}
+
+	members, err := svc.roles.FindEmployees(ctx, current.Id)
+	if err != nil {
+		return Group{}, fmt.Errorf("error finding employees of role %d: %w", current.Id, err)
+	}
+
+	return groupFromRole(current, members), nil
+}
+
+// CreateGroup - создать роль и назначить её участникам группы
+func (svc *Service) CreateGroup(ctx context.Context, group Group) (Group, error) {
+	if err := svc.checkDisplayName(ctx, 0, group.DisplayName); err != nil {
+		return Group{}, err
+	}
+	memberIds, err := parseMemberIds(group.Members)
+	if err != nil {
+		return Group{}, err
+	}
+
+	created, err := svc.roles.CreateRole(ctx, role.CreateRequest{Name: group.DisplayName})
+	if err != nil {
+		return Group{}, err
+	}
+	if err = svc.syncMembers(ctx, created.Id, memberIds); err != nil {
+		return Group{}, err
+	}
+
+	return svc.GetGroup(ctx, formatId(created.Id))
+}
+
+// ReplaceGroup - переименовать роль и привести её прямые назначения к списку участников
+func (svc *Service) ReplaceGroup(ctx context.Context, id string, group Group) (Group, error) {
+	current, err := svc.findRole(ctx, id)
+	if err != nil {
+		return Group{}, err
+	}
+	memberIds, err := parseMemberIds(group.Members)
+	if err != nil {
+		return Group{}, err
+	}
+
+	if group.DisplayName != current.Name {
+		if err = svc.checkDisplayName(ctx, current.Id, group.DisplayName); err != nil {
+			return Group{}, err
+		}
+		var ownerId int64
+		if current.OwnerId != nil {
+			ownerId = *current.OwnerId
+		}
+		_, err = svc.roles.UpdateRole(ctx, current.Id, role.UpdateRequest{
+			Name:      group.DisplayName,
+			CreatedAt: current.CreateAt,
+			UpdatedAt: current.UpdateAt,
+			OwnerId:   ownerId,
+		})
+		if err != nil {
+			return Group{}, err
+		}
+	}
+
+	if err = svc.syncMembers(ctx, current.Id, memberIds); err != nil {
+		return Group{}, err
+	}
+
+	return svc.GetGroup(ctx, id)
+}
+
+// PatchGroup - применить операции PATCH к текущему состоянию роли и сохранить результат как PUT
+func (svc *Service) PatchGroup(ctx context.Context, id string, request PatchRequest) (Group, error) {
+	if err := checkPatchRequest(request); err != nil {
+		return Group{}, err
+	}
+	current, err := svc.GetGroup(ctx, id)
+	if err != nil {
+		return Group{}, err
+	}
+
+	var patched Group
+	if err = patchResource(current, request.Operations, nil, &patched); err != nil {
+		return Group{}, err
+	}
+
+	return svc.ReplaceGroup(ctx, id, patched)
+}
+
+// DeleteGroup - мягко удалить роль
+func (svc *Service) DeleteGroup(ctx context.Context, id string) error {
+	current, err := svc.findRole(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err = svc.roles.DeleteById(ctx, current.Id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setActive - привести состояние сотрудника к active: false отстраняет работающего,
+// true активирует, уволенного - через повторный приём
+func (svc *Service) setActive(ctx context.Context, current employee.Response, active *bool) error {
+	var actions []string
+	switch {
+	case active == nil:
+	case *active && current.Status == employee.StatusTerminated:
+		actions = []string{employee.LifecycleRehire, employee.LifecycleActivate}
+	case *active && current.Status != employee.StatusActive:
+		actions = []string{employee.LifecycleActivate}
+	case !*active && current.Status == employee.StatusActive:
+		actions = []string{employee.LifecycleSuspend}
+	}
+
+	for _, action := range actions {
+		if _, err := svc.employees.ChangeStatus(ctx, current.Id, action); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncMembers - назначить роль недостающим участникам и отозвать у лишних
+func (svc *Service) syncMembers(ctx context.Context, roleId int64, memberIds []int64) error {
+	members, err := svc.roles.FindEmployees(ctx, roleId)
+	if err != nil {
+		return fmt.Errorf("error finding employees of role %d: %w", roleId, err)
+	}
+
+	var isCurrent = make(map[int64]bool, len(members))
+	for _, member := range members {
+		isCurrent[member.Id] = true
+	}
+	var isDesired = make(map[int64]bool, len(memberIds))
+	for _, employeeId := range memberIds {
+		isDesired[employeeId] = true
+		if isCurrent[employeeId] {
+			continue
+		}
+		_, err = svc.roles.AssignEmployee(ctx, roleId, role.AssignEmployeeRequest{EmployeeID: employeeId})
+		if errors.As(err, &domain.NotFoundError{}) {
+			return invalidValueError("member %d is not an existing User", employeeId)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, member := range members {
+		if isDesired[member.Id] {
+			continue
+		}
+		if _, err = svc.roles.RevokeEmployee(ctx, roleId, member.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkDisplayName - имя роли обязательно и не занято другой ролью (id - переименовываемая роль, 0 при создании)
+func (svc *Service) checkDisplayName(ctx context.Context, id int64, displayName string) error {
+	if displayName == "" {
+		return invalidValueError("displayName is required")
+	}
+
+	roles, err := svc.roles.FindAll(ctx, false)
+	if err != nil {
+		return fmt.Errorf("error finding roles: %w", err)
+	}
+	for _, r := range roles {
+		if r.Id != id && strings.EqualFold(r.Name, displayName) {
+			return domain.AlreadyExistsError{Message: fmt.Sprintf("group with displayName %q already exists", displayName)}
+		}
+	}
+
+	return nil
+}
+
+func (svc *Service) findEmployee(ctx context.Context, id string) (employee.Response, error) {
+	employeeId, err := parseId(ResourceUser, id)
+	if err != nil {
+		return employee.Response{}, err
+	}
+
+	current, err := svc.employees.FindById(ctx, employeeId, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return employee.Response{}, notFoundError(ResourceUser, id)
+	}
+	if err != nil {
+		return employee.Response{}, err
+	}
+
+	return current, nil
+}
+
+func (svc *Service) findRole(ctx context.Context, id string) (role.Response, error) {
+	roleId, err := parseId(ResourceGroup, id)
+	if err != nil {
+		return role.Response{}, err
+	}
+
+	current, err := svc.roles.FindById(ctx, roleId, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return role.Response{}, notFoundError(ResourceGroup, id)
+	}
+	if err != nil {
+		return role.Response{}, err
+	}
+
+	return current, nil
+}
+
+// parseId - id ресурса SCIM; не число - такого ресурса нет
+func parseId(resourceType string, id string) (int64, error) {
+	value, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || value < 1 {
+		return 0, notFoundError(resourceType, id)
+	}
+	return value, nil
+}
+
+// parseMemberIds - id сотрудников-участников без повторов, в порядке передачи
+func parseMemberIds(members []Member) ([]int64, error) {
+	var ids = make([]int64, 0, len(members))
+	var isSeen = make(map[int64]bool, len(members))
+	for _, member := range members {
+		employeeId, err := strconv.ParseInt(member.Value, 10, 64)
+		if err != nil || employeeId < 1 {
+			return nil, invalidValueError("member %q is not a User id", member.Value)
+		}
+		if !isSeen[employeeId] {
+			isSeen[employeeId] = true
+			ids = append(ids, employeeId)
+		}
+	}
+	return ids, nil
+}
+
+func parseListFilter(filter string) (Filter, error) {
+	if filter == "" {
+		return nil, nil
+	}
+	return ParseFilter(filter)
+}
+
+// userQuery - фильтр пользователей как запрос к базе: без фильтра или одно сравнение eq/sw/co
+// атрибута userName, id или externalId (ok = false - фильтр применяется в памяти).
+// externalId не хранится, такой фильтр ничему не соответствует - isEmpty
+func userQuery(filter Filter) (query employee.FilterRequest, isEmpty bool, ok bool) {
+	if filter == nil {
+		return employee.FilterRequest{}, false, true
+	}
+	compared, isCompare := filter.(compareFilter)
+	if !isCompare || compared.path.sub != "" ||
+		(compared.path.schema != "" && !strings.EqualFold(compared.path.schema, SchemaUser)) {
+		return employee.FilterRequest{}, false, false
+	}
+	value, isString := compared.value.(string)
+	op, isPushable := userQueryOps[compared.op]
+	if !isString || !isPushable {
+		return employee.FilterRequest{}, false, false
+	}
+
+	switch strings.ToLower(compared.path.attr) {
+	case "username":
+		return employee.FilterRequest{Field: employee.FilterFieldUserName, Op: op, Value: value}, false, true
+	case "id":
+		return employee.FilterRequest{Field: employee.FilterFieldId, Op: op, Value: value}, false, true
+	case "externalid":
+		return employee.FilterRequest{}, true, true
+	}
+	return employee.FilterRequest{}, false, false
+}
+
+func employeeIds(employees []employee.Response) []int64 {
+	var ids = make([]int64, 0, len(employees))
+	for _, e := range employees {
+		ids = append(ids, e.Id)
+	}
+	return ids
+}
+
+// matches - удовлетворяет ли ресурс фильтру (nil - без фильтра)
+func matches(filter Filter, resource any) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	object, err := toObject(resource)
+	if err != nil {
+		return false, err
+	}
+	return filter.Match(object), nil
+}
+
+// pageBounds - границы страницы в отфильтрованном списке: startIndex с 1, count не больше MaxResults
+func pageBounds(total int, request ListRequest) (int, int) {
+	var start = max(request.StartIndex, 1) - 1
+	var count = min(max(request.Count, 0), MaxResults)
+	start = min(start, total)
+	return start, min(start+count, total)
+}
+
+func newListResponse(total int, start int, resources []any) ListResponse {
+	return ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   start + 1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func checkPatchRequest(request PatchRequest) error {
+	if len(request.Operations) == 0 {
+		return fmt.Errorf("%w: Operations are required", ErrInvalidSyntax)
+	}
+	return nil
+}
+
+// patchResource - ресурс -> JSON-объект -> операции PATCH -> ресурс target
+func patchResource(resource any, operations []PatchOperation, normalize func(map[string]any), target any) error {
+	object, err := toObject(resource)
+	if err != nil {
+		return err
+	}
+	if err = applyPatch(object, operations); err != nil {
+		return err
+	}
+	if normalize != nil {
+		normalize(object)
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("error encoding patched resource: %w", err)
+	}
+	if err = json.Unmarshal(data, target); err != nil {
+		return invalidValueError("patched resource is invalid: %s", err)
+	}
+
+	return nil
+}
+
+// normalizeUser - значения, которые клиенты шлют не по схеме: active строкой ("False"),
+// manager - id без объекта
+func normalizeUser(object map[string]any) {
+	var activeKey = keyOf(object, "active")
+	if value, ok := object[activeKey].(string); ok {
+		if active, err := strconv.ParseBool(value); err == nil {
+			object[activeKey] = active
+		}
+	}
+
+	enterprise, ok := lookup(object, SchemaEnterpriseUser).(map[string]any)
+	if !ok {
+		return
+	}
+	var managerKey = keyOf(enterprise, "manager")
+	if value, ok := enterprise[managerKey].(string); ok {
+		enterprise[managerKey] = map[string]any{"value": value}
+	}
+}
+
+func toObject(resource any) (map[string]any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding resource: %w", err)
+	}
+	var object map[string]any
+	if err = json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("error decoding resource: %w", err)
+	}
+	return object, nil
+}
+
+func invalidValueError(format string, args ...any) error {
+	return domain.RequestValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+func notFoundError(resourceType string, id string) error {
+	return domain.NotFoundError{Message: fmt.Sprintf("%s %s not found", resourceType, id)}
+}
@@ -0,0 +1,354 @@
+package scim
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"idm/inner/employee"
+	"idm/inner/role"
+	"testing"
+	"time"
+)
+
+func TestScimService_Users(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+	created := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	alice := employee.Response{Id: 1, Name: "Alice Doe", Status: employee.StatusActive, Login: "alice",
+		Email: "alice@corp.example", CreateAt: created, UpdateAt: created}
+	bob := employee.Response{Id: 2, Name: "Bob Doe", Status: employee.StatusSuspended, Email: "bob@corp.example",
+		Department: "Finance", CreateAt: created, UpdateAt: created}
+	carol := employee.Response{Id: 3, Name: "Carol Doe", Status: employee.StatusPreHire, Login: "carol",
+		CreateAt: created, UpdateAt: created}
+
+	t.Run("should push simple filter and paging down to employee service", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+		query := employee.FilterRequest{
+			Field: employee.FilterFieldUserName, Op: employee.FilterOpEq, Value: "BOB@corp.example", Offset: 0, Limit: 10,
+		}
+
+		employees.On("FindFiltered", ctx, query).Return(employee.FilterPage{Result: []employee.Response{bob}, Total: 1}, nil).Once()
+		employees.On("FindRolesByEmployeeIds", ctx, []int64{2}).
+			Return(map[int64][]employee.RoleResponse{2: {{Id: 5, Name: "AUDITOR"}}}, nil).Once()
+
+		got, err := service.ListUsers(ctx, ListRequest{Filter: `userName eq "BOB@corp.example"`, StartIndex: 1, Count: 10})
+
+		a.Nil(err)
+		a.Equal(1, got.TotalResults)
+		a.Equal(1, got.ItemsPerPage)
+		user := got.Resources[0].(User)
+		a.Equal("2", user.Id)
+		a.False(*user.Active)
+		a.Equal("Finance", user.Enterprise.Department)
+		a.Equal([]Member{{Value: "5", Display: "AUDITOR", Ref: "/scim/v2/Groups/5"}}, user.Groups)
+		employees.AssertExpectations(t)
+	})
+
+	t.Run("should page users by startIndex and count", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+
+		employees.On("FindFiltered", ctx, employee.FilterRequest{Offset: 1, Limit: 1}).
+			Return(employee.FilterPage{Result: []employee.Response{bob}, Total: 3}, nil).Once()
+		employees.On("FindRolesByEmployeeIds", ctx, []int64{2}).Return(map[int64][]employee.RoleResponse{}, nil).Once()
+
+		got, err := service.ListUsers(ctx, ListRequest{StartIndex: 2, Count: 1})
+
+		a.Nil(err)
+		a.Equal(3, got.TotalResults)
+		a.Equal(2, got.StartIndex)
+		a.Equal(1, got.ItemsPerPage)
+		a.Equal("2", got.Resources[0].(User).Id)
+		employees.AssertExpectations(t)
+	})
+
+	t.Run("should return empty list for externalId filter", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+
+		got, err := service.ListUsers(ctx, ListRequest{Filter: `externalId eq "42"`, StartIndex: 1, Count: 10})
+
+		a.Nil(err)
+		a.Equal(0, got.TotalResults)
+		a.Empty(got.Resources)
+		employees.AssertNotCalled(t, "FindFiltered", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should filter users by groups in memory with roles loaded per batch", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+		auditor := []employee.RoleResponse{{Id: 5, Name: "AUDITOR"}}
+
+		employees.On("FindFiltered", ctx, employee.FilterRequest{Offset: 0, Limit: scanBatchSize}).
+			Return(employee.FilterPage{Result: []employee.Response{alice, bob, carol}, Total: 3}, nil).Once()
+		employees.On("FindRolesByEmployeeIds", ctx, []int64{1, 2, 3}).
+			Return(map[int64][]employee.RoleResponse{2: auditor, 3: auditor}, nil).Once()
+
+		got, err := service.ListUsers(ctx, ListRequest{Filter: `groups.display eq "auditor"`, StartIndex: 2, Count: 10})
+
+		a.Nil(err)
+		a.Equal(2, got.TotalResults)
+		a.Equal(1, got.ItemsPerPage)
+		user := got.Resources[0].(User)
+		a.Equal("3", user.Id)
+		a.Equal([]Member{{Value: "5", Display: "AUDITOR", Ref: "/scim/v2/Groups/5"}}, user.Groups)
+		employees.AssertExpectations(t)
+	})
+
+	t.Run("should return too many error when filter cannot be pushed down", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+
+		employees.On("FindFiltered", ctx, employee.FilterRequest{Offset: 0, Limit: scanBatchSize}).
+			Return(employee.FilterPage{Result: []employee.Response{alice}, Total: maxFilterScan + 1}, nil).Once()
+
+		_, err := service.ListUsers(ctx, ListRequest{Filter: `title co "engineer"`, StartIndex: 1, Count: 10})
+
+		a.True(errors.Is(err, ErrTooMany))
+		employees.AssertNotCalled(t, "FindRolesByEmployeeIds", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return invalid filter error", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+
+		_, err := service.ListUsers(ctx, ListRequest{Filter: `userName like "x"`, StartIndex: 1, Count: 10})
+
+		a.True(errors.Is(err, ErrInvalidFilter))
+		employees.AssertNotCalled(t, "FindFiltered", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should create user with email as userName", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+		request := employee.CreateRequest{
+			Name:    "Dave Doe",
+			Status:  employee.StatusPreHire,
+			Profile: employee.Profile{Email: "dave@corp.example", Title: "Analyst", ManagerId: 1},
+		}
+		dave := employee.Response{Id: 4, Name: "Dave Doe", Status: employee.StatusPreHire, Email: "dave@corp.example",
+			Title: "Analyst", ManagerId: &alice.Id}
+
+		employees.On("CreateEmployee", ctx, request).Return(dave, nil).Once()
+		employees.On("FindById", ctx, int64(4), false).Return(dave, nil).Once()
+		employees.On("FindRoles", ctx, int64(4)).Return([]employee.RoleResponse{}, nil).Once()
+
+		var inactive = false
+		got, err := service.CreateUser(ctx, User{
+			UserName:   "dave@corp.example",
+			Name:       &Name{GivenName: "Dave", FamilyName: "Doe"},
+			Title:      "Analyst",
+			Active:     &inactive,
+			Enterprise: &EnterpriseUser{Manager: &Manager{Value: "1"}},
+		})
+
+		a.Nil(err)
+		a.Equal("dave@corp.example", got.UserName)
+		a.Equal("1", got.Enterprise.Manager.Value)
+		employees.AssertExpectations(t)
+	})
+
+	t.Run("should reject userName that differs from primary email", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+
+		_, err := service.CreateUser(ctx, User{
+			UserName: "dave@corp.example",
+			Emails:   []MultiValue{{Value: "d.doe@corp.example", Primary: true}},
+		})
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		employees.AssertNotCalled(t, "CreateEmployee", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should patch user and suspend when deactivated", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+		update := employee.UpdateRequest{
+			Name:      "Alice Doe",
+			CreatedAt: created,
+			UpdatedAt: created,
+			Profile:   employee.Profile{Login: "alice", Email: "alice.doe@corp.example", Title: "CFO"},
+		}
+		suspended := alice
+		suspended.Status = employee.StatusSuspended
+
+		employees.On("FindById", ctx, int64(1), false).Return(alice, nil).Twice()
+		employees.On("FindRoles", ctx, int64(1)).Return([]employee.RoleResponse{}, nil).Twice()
+		employees.On("UpdateEmployee", ctx, int64(1), update).Return(alice, nil).Once()
+		employees.On("ChangeStatus", ctx, int64(1), employee.LifecycleSuspend).Return(suspended, nil).Once()
+		employees.On("FindById", ctx, int64(1), false).Return(suspended, nil).Once()
+
+		// так шлёт изменения Microsoft Entra ID: active строкой, email через отбор по type
+		got, err := service.PatchUser(ctx, "1", PatchRequest{
+			Schemas: []string{SchemaPatchOp},
+			Operations: []PatchOperation{
+				{Op: "Replace", Path: "active", Value: "False"},
+				{Op: "replace", Path: `emails[type eq "work"].value`, Value: "alice.doe@corp.example"},
+				{Op: "add", Value: map[string]any{"title": "CFO"}},
+			},
+		})
+
+		a.Nil(err)
+		a.False(*got.Active)
+		employees.AssertExpectations(t)
+	})
+
+	t.Run("should return conflict when terminated employee cannot be suspended", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+		conflict := domain.ConflictError{Message: "cannot rehire employee in status active"}
+		terminated := carol
+		terminated.Status = employee.StatusTerminated
+
+		employees.On("FindById", ctx, int64(3), false).Return(terminated, nil).Once()
+		employees.On("UpdateEmployee", ctx, int64(3), mock.Anything).Return(terminated, nil).Once()
+		employees.On("ChangeStatus", ctx, int64(3), employee.LifecycleRehire).Return(employee.Response{}, conflict).Once()
+
+		var active = true
+		_, err := service.ReplaceUser(ctx, "3", User{UserName: "carol", Active: &active})
+
+		a.True(errors.As(err, &domain.ConflictError{}))
+	})
+
+	t.Run("should return not found for non numeric id", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+
+		_, err := service.GetUser(ctx, "alice")
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		employees.AssertNotCalled(t, "FindById", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found for missing user", func(t *testing.T) {
+		employees := new(employee.MockEmployeeService)
+		service := NewService(employees, new(role.MockRoleService))
+
+		employees.On("FindById", ctx, int64(9), false).
+			Return(employee.Response{}, fmt.Errorf("error finding employee with id 9: %w", sql.ErrNoRows)).Once()
+
+		err := service.DeleteUser(ctx, "9")
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		employees.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything)
+	})
+}
+
+func TestScimService_Groups(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+	admin := role.Response{Id: 1, Name: "ADMIN"}
+	auditor := role.Response{Id: 5, Name: "AUDITOR"}
+
+	t.Run("should add and remove members by patch", func(t *testing.T) {
+		roles := new(role.MockRoleService)
+		service := NewService(new(employee.MockEmployeeService), roles)
+		members := []role.EmployeeResponse{{Id: 1, Name: "Alice Doe"}, {Id: 2, Name: "Bob Doe"}}
+		patched := []role.EmployeeResponse{{Id: 1, Name: "Alice Doe"}, {Id: 3, Name: "Carol Doe"}}
+
+		roles.On("FindById", ctx, int64(5), false).Return(auditor, nil)
+		roles.On("FindEmployees", ctx, int64(5)).Return(members, nil).Twice()
+		roles.On("AssignEmployee", ctx, int64(5), role.AssignEmployeeRequest{EmployeeID: 3}).Return(patched, nil).Once()
+		roles.On("RevokeEmployee", ctx, int64(5), int64(2)).Return(patched, nil).Once()
+		roles.On("FindEmployees", ctx, int64(5)).Return(patched, nil).Once()
+
+		got, err := service.PatchGroup(ctx, "5", PatchRequest{Operations: []PatchOperation{
+			{Op: "add", Path: "members", Value: []any{map[string]any{"value": "3"}}},
+			{Op: "remove", Path: `members[value eq "2"]`},
+		}})
+
+		a.Nil(err)
+		a.Len(got.Members, 2)
+		a.Equal("3", got.Members[1].Value)
+		roles.AssertExpectations(t)
+		roles.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should rename group keeping owner", func(t *testing.T) {
+		roles := new(role.MockRoleService)
+		service := NewService(new(employee.MockEmployeeService), roles)
+		var ownerId = int64(7)
+		owned := auditor
+		owned.OwnerId = &ownerId
+		renamed := owned
+		renamed.Name = "AUDITORS"
+
+		roles.On("FindById", ctx, int64(5), false).Return(owned, nil).Twice()
+		roles.On("FindAll", ctx, false).Return([]role.Response{admin, owned}, nil).Once()
+		roles.On("UpdateRole", ctx, int64(5), role.UpdateRequest{Name: "AUDITORS", OwnerId: 7}).Return(renamed, nil).Once()
+		roles.On("FindEmployees", ctx, int64(5)).Return([]role.EmployeeResponse{}, nil).Times(3)
+		roles.On("FindById", ctx, int64(5), false).Return(renamed, nil).Once()
+
+		got, err := service.PatchGroup(ctx, "5", PatchRequest{Operations: []PatchOperation{
+			{Op: "replace", Value: map[string]any{"displayName": "AUDITORS"}},
+		}})
+
+		a.Nil(err)
+		a.Equal("AUDITORS", got.DisplayName)
+		roles.AssertExpectations(t)
+	})
+
+	t.Run("should return uniqueness error for taken displayName", func(t *testing.T) {
+		roles := new(role.MockRoleService)
+		service := NewService(new(employee.MockEmployeeService), roles)
+
+		roles.On("FindAll", ctx, false).Return([]role.Response{admin, auditor}, nil).Once()
+
+		_, err := service.CreateGroup(ctx, Group{DisplayName: "admin"})
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		roles.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return invalid value for unknown member", func(t *testing.T) {
+		roles := new(role.MockRoleService)
+		service := NewService(new(employee.MockEmployeeService), roles)
+		notFound := domain.NotFoundError{Message: "employee with id 9 not found"}
+
+		roles.On("FindAll", ctx, false).Return([]role.Response{admin}, nil).Once()
+		roles.On("CreateRole", ctx, role.CreateRequest{Name: "AUDITOR"}).Return(auditor, nil).Once()
+		roles.On("FindEmployees", ctx, int64(5)).Return([]role.EmployeeResponse{}, nil).Once()
+		roles.On("AssignEmployee", ctx, int64(5), role.AssignEmployeeRequest{EmployeeID: 9}).
+			Return([]role.EmployeeResponse(nil), notFound).Once()
+
+		_, err := service.CreateGroup(ctx, Group{DisplayName: "AUDITOR", Members: []Member{{Value: "9"}}})
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+	})
+
+	t.Run("should return invalid path error", func(t *testing.T) {
+		roles := new(role.MockRoleService)
+		service := NewService(new(employee.MockEmployeeService), roles)
+
+		roles.On("FindById", ctx, int64(5), false).Return(auditor, nil).Once()
+		roles.On("FindEmployees", ctx, int64(5)).Return([]role.EmployeeResponse{}, nil).Once()
+
+		_, err := service.PatchGroup(ctx, "5", PatchRequest{Operations: []PatchOperation{
+			{Op: "remove", Path: `members[value eq "2"`},
+		}})
+
+		a.True(errors.Is(err, ErrInvalidPath))
+	})
+
+	t.Run("should filter groups by member", func(t *testing.T) {
+		roles := new(role.MockRoleService)
+		service := NewService(new(employee.MockEmployeeService), roles)
+
+		roles.On("FindAll", ctx, false).Return([]role.Response{admin, auditor}, nil).Once()
+		roles.On("FindEmployees", ctx, int64(1)).Return([]role.EmployeeResponse{{Id: 1}}, nil).Once()
+		roles.On("FindEmployees", ctx, int64(5)).Return([]role.EmployeeResponse{{Id: 2}}, nil).Once()
+
+		got, err := service.ListGroups(ctx, ListRequest{Filter: `members[value eq "2"]`, StartIndex: 1, Count: 10})
+
+		a.Nil(err)
+		a.Equal(1, got.TotalResults)
+		a.Equal("5", got.Resources[0].(Group).Id)
+		roles.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,263 @@
+package sod
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server     *web.Server
+	sodService Svc
+	logger     *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	FindAll(ctx context.Context) ([]Response, error)
+	FindById(ctx context.Context, id int64) (Response, error)
+	CreateRule(ctx context.Context, request CreateRequest) (Response, error)
+	DeleteById(ctx context.Context, id int64) (Response, error)
+	FindViolations(ctx context.Context) ([]ViolationResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	sodService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:     server,
+		sodService: sodService,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/sod-rules"
+	c.server.GroupSodRules.Get("/", c.server.Require(web.PermSodRead), c.FindAll)
+	c.server.GroupSodRules.Post("/", c.server.Require(web.PermSodWrite), c.CreateRule)
+	// отчёт называет сотрудников, поэтому нужно ещё право на их просмотр; регистрируется до "/:id"
+	c.server.GroupSodRules.Get("/violations", c.server.Require(web.PermSodRead, web.PermEmployeesRead), c.FindViolations)
+	c.server.GroupSodRules.Get("/:id", c.server.Require(web.PermSodRead), c.FindById)
+	c.server.GroupSodRules.Delete("/:id", c.server.Require(web.PermSodWrite), c.DeleteById)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/sod-rules" --//
+
+// FindAll   	 godoc
+// @Description  Find all segregation of duties rules
+// @Summary		 get all sod rules
+// @Tags 		 sod
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		sod.Response	"Sod rule response"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /sod-rules/		[get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.sodService.FindAll(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Sod rules ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find by ID segregation of duties rule
+// @Summary 	 find by ID sod rule
+// @Tags 		 sod
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  		"Sod rule ID"
+// @Success 	 200  {object}  	sod.Response	"Sod rule response"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      500  {object}  	http.Response	"Bad request"
+// @Router 		 /sod-rules/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	ruleID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.sodService.FindById(appContext, ruleID)
+	if err != nil {
+		c.logger.Error(
+			"When the get Sod rule ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", ruleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// CreateRule 	 godoc
+// @Summary      create a new sod rule
+// @Description  Forbid holding two roles together. Existing violations are not revoked, see /sod-rules/violations
+// @Tags 		 sod
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	sod.CreateRequest true "Sod rule creation details"
+// @Success 	 201  {object}  sod.Response	"Sod rule response"
+// @Failure      400  {object}  http.Response	"Bad request"
+// @Failure      404  {object}  http.Response	"Role not found"
+// @Failure      409  {object}  http.Response	"Rule for the pair already exists"
+// @Failure      500  {object}  http.Response	"Bad request"
+// @Router 		 /sod-rules/ 	[post]
+func (c *Controller) CreateRule(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an CreateRule ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.sodService.CreateRule(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Sod rule ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// DeleteById  	 godoc
+// @Description  Delete segregation of duties rule
+// @Summary		 delete sod rule by ID
+// @Tags 		 sod
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  			true	"Sod rule ID"
+// @Success 	 200  {object} 		sod.Response	"Deleted sod rule"
+// @Failure      400  {object}  	http.Response	"Bad request"
+// @Failure      404  {object}  	http.Response	"Not found"
+// @Failure      500  {object} 	 	http.Response	"Bad request"
+// @Router 		 /sod-rules/{id}	[delete]
+func (c *Controller) DeleteById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	ruleID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.sodService.DeleteById(appContext, ruleID)
+	if err != nil {
+		c.logger.Error(
+			"When the delete Sod rule ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", ruleID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindViolations godoc
+// @Description  Report employees holding both roles of any sod rule, directly, via groups or role inheritance
+// @Summary 	 find sod violations
+// @Tags 		 sod
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		sod.ViolationResponse	"Sod violations"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /sod-rules/violations 	[get]
+func (c *Controller) FindViolations(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.sodService.FindViolations(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find Sod violations ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.AlreadyExistsError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,120 @@
+package sod
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSod_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockSodService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupSodRules:         app.Group("/api/v1/sod-rules"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should create sod rule", func(t *testing.T) {
+		request := CreateRequest{RoleId: 1, ConflictingRoleId: 2}
+		rule := Response{Id: 7, RoleId: 1, RoleName: "PAYMENT_CREATOR", ConflictingRoleId: 2, ConflictingRoleName: "PAYMENT_APPROVER"}
+		mockService.On("CreateRule", appContext, request).Return(rule, nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/sod-rules/", strings.NewReader(`{"roleId": 1, "conflictingRoleId": 2}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when rule for the pair exists", func(t *testing.T) {
+		request := CreateRequest{RoleId: 2, ConflictingRoleId: 1}
+		conflict := domain.AlreadyExistsError{Message: "sod rule for roles 2 and 1 already exists"}
+		mockService.On("CreateRule", appContext, request).Return(Response{}, conflict).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/sod-rules/", strings.NewReader(`{"roleId": 2, "conflictingRoleId": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return violations report", func(t *testing.T) {
+		violations := []ViolationResponse{{
+			RuleId: 7, EmployeeId: 10, EmployeeName: "Alice",
+			RoleId: 1, RoleName: "PAYMENT_CREATOR", ConflictingRoleId: 2, ConflictingRoleName: "PAYMENT_APPROVER",
+		}}
+		mockService.On("FindViolations", appContext).Return(violations, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/sod-rules/violations", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		var data []ViolationResponse
+		require.NoError(t, json.Unmarshal(body.Data, &data))
+		assert.Equal(t, violations, data)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when deleting missing rule", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "sod rule with id 8 not found"}
+		mockService.On("DeleteById", appContext, int64(8)).Return(Response{}, notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/sod-rules/8", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on invalid id", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/sod-rules/abc", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,97 @@
+package sod
+
+import (
+	"time"
+)
+
+// Entity - правило разделения полномочий вместе с именами ролей (заполняются только при выборке)
+type Entity struct {
+	Id                  int64     `db:"id"`
+	RoleId              int64     `db:"role_id"`
+	ConflictingRoleId   int64     `db:"conflicting_role_id"`
+	Description         string    `db:"description"`
+	CreatedAt           time.Time `db:"created_at"`
+	RoleName            string    `db:"role_name"`
+	ConflictingRoleName string    `db:"conflicting_role_name"`
+}
+
+// Response model info
+// @Description Segregation of duties rule: pair of roles that must not be held together
+// @Description with rule id, both roles, description, createAt
+type Response struct {
+	Id                  int64     `json:"id"`
+	RoleId              int64     `json:"roleId"`
+	RoleName            string    `json:"roleName,omitempty"`
+	ConflictingRoleId   int64     `json:"conflictingRoleId"`
+	ConflictingRoleName string    `json:"conflictingRoleName,omitempty"`
+	Description         string    `json:"description,omitempty"`
+	CreateAt            time.Time `json:"createAt"`
+}
+
+func (e *Entity) ToResponse() Response {
+	return Response{
+		Id:                  e.Id,
+		RoleId:              e.RoleId,
+		RoleName:            e.RoleName,
+		ConflictingRoleId:   e.ConflictingRoleId,
+		ConflictingRoleName: e.ConflictingRoleName,
+		Description:         e.Description,
+		CreateAt:            e.CreatedAt,
+	}
+}
+
+// ViolationEntity - сотрудник, у которого есть обе роли правила (напрямую, через группы или наследование)
+type ViolationEntity struct {
+	RuleId              int64  `db:"rule_id"`
+	EmployeeId          int64  `db:"employee_id"`
+	EmployeeName        string `db:"employee_name"`
+	RoleId              int64  `db:"role_id"`
+	RoleName            string `db:"role_name"`
+	ConflictingRoleId   int64  `db:"conflicting_role_id"`
+	ConflictingRoleName string `db:"conflicting_role_name"`
+}
+
+// ViolationResponse model info
+// @Description Employee holding both roles of segregation of duties rule
+// @Description with rule id, employee and both roles
+type ViolationResponse struct {
+	RuleId              int64  `json:"ruleId"`
+	EmployeeId          int64  `json:"employeeId"`
+	EmployeeName        string `json:"employeeName"`
+	RoleId              int64  `json:"roleId"`
+	RoleName            string `json:"roleName"`
+	ConflictingRoleId   int64  `json:"conflictingRoleId"`
+	ConflictingRoleName string `json:"conflictingRoleName"`
+}
+
+func (e *ViolationEntity) ToResponse() ViolationResponse {
+	return ViolationResponse{
+		RuleId:              e.RuleId,
+		EmployeeId:          e.EmployeeId,
+		EmployeeName:        e.EmployeeName,
+		RoleId:              e.RoleId,
+		RoleName:            e.RoleName,
+		ConflictingRoleId:   e.ConflictingRoleId,
+		ConflictingRoleName: e.ConflictingRoleName,
+	}
+}
+
+// CreateRequest model info
+// @Description Request to forbid holding two roles together
+type CreateRequest struct {
+	RoleId            int64  `json:"roleId" validate:"required,min=1"`
+	ConflictingRoleId int64  `json:"conflictingRoleId" validate:"required,min=1,nefield=RoleId"`
+	Description       string `json:"description" validate:"max=255"`
+}
+
+func (req *CreateRequest) ToEntity() Entity {
+	return Entity{
+		RoleId:            req.RoleId,
+		ConflictingRoleId: req.ConflictingRoleId,
+		Description:       req.Description,
+	}
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
@@ -0,0 +1,35 @@
+package sod
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSodService struct {
+	mock.Mock
+}
+
+func (m *MockSodService) FindAll(ctx context.Context) ([]Response, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Response), args.Error(1)
+}
+
+func (m *MockSodService) FindById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockSodService) CreateRule(ctx context.Context, request CreateRequest) (Response, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockSodService) DeleteById(ctx context.Context, id int64) (Response, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Response), args.Error(1)
+}
+
+func (m *MockSodService) FindViolations(ctx context.Context) ([]ViolationResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ViolationResponse), args.Error(1)
+}
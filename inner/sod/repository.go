@@ -0,0 +1,291 @@
+package sod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"time"
+)
+
+// assignmentLockKey - ключ pg_advisory_xact_lock, сериализующий проверку и запись назначений ролей
+// (прямых, через группы и наследование), чтобы две конфликтующие роли нельзя было выдать сотруднику
+// параллельными запросами
+const assignmentLockKey int64 = 0x736f645f61737367 // "sod_assg"
+
+// ErrViolation - назначение роли нарушает правило разделения полномочий
+var ErrViolation = errors.New("segregation of duties violation")
+
+// selectRules - выборка правил с именами обеих ролей
+const selectRules = `
+	SELECT s.*, r.name AS role_name, cr.name AS conflicting_role_name
+	FROM sod_rules s
+	JOIN roles r ON r.id = s.role_id
+	JOIN roles cr ON cr.id = s.conflicting_role_id
+`
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindAll - все правила в порядке создания
+func (r *Repository) FindAll(ctx context.Context) (rules []Entity, err error) {
+	err = r.db.SelectContext(ctx, &rules, selectRules+"ORDER BY s.id")
+
+	return rules, err
+}
+
+// FindById - найти правило по id
+func (r *Repository) FindById(ctx context.Context, id int64) (rule Entity, err error) {
+	err = r.db.GetContext(ctx, &rule, selectRules+"WHERE s.id = $1", id)
+
+	return rule, err
+}
+
+// ExistsRoleById - проверить наличие не удалённой роли
+func (r *Repository) ExistsRoleById(ctx context.Context, roleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		"SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)",
+		roleId,
+	)
+
+	return isExists, err
+}
+
+// ExistsRule - есть ли правило для пары ролей (в любом порядке)
+func (r *Repository) ExistsRule(ctx context.Context, roleId int64, conflictingRoleId int64) (isExists bool, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&isExists,
+		`SELECT EXISTS(
+			SELECT 1 FROM sod_rules
+			WHERE (role_id = $1 AND conflicting_role_id = $2) OR (role_id = $2 AND conflicting_role_id = $1)
+		)`,
+		roleId, conflictingRoleId,
+	)
+
+	return isExists, err
+}
+
+// CreateRule - добавить правило. Уже существующие нарушения правило не отменяет, их показывает FindViolations
+func (r *Repository) CreateRule(ctx context.Context, entity *Entity) (created Entity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(
+			ctx,
+			&created,
+			`INSERT INTO sod_rules (role_id, conflicting_role_id, description, created_at)
+			VALUES ($1, $2, $3, $4)
+			RETURNING *`,
+			entity.RoleId, entity.ConflictingRoleId, entity.Description, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntitySodRule,
+			EntityId:   created.Id,
+			After:      created.ToResponse(),
+		})
+	})
+
+	return created, err
+}
+
+// DeleteRule - удалить правило
+func (r *Repository) DeleteRule(ctx context.Context, id int64) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var deleted Entity
+		err := tx.GetContext(ctx, &deleted, "DELETE FROM sod_rules WHERE id = $1 RETURNING *", id)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntitySodRule,
+			EntityId:   id,
+			Before:     deleted.ToResponse(),
+		})
+	})
+}
+
+// FindViolations - сотрудники, у которых уже есть обе роли какого-либо правила,
+// с учётом групп и наследования ролей (например, назначенные до появления правила)
+func (r *Repository) FindViolations(ctx context.Context) (violations []ViolationEntity, err error) {
+	query := `
+		WITH held AS (
+			SELECT DISTINCT employee_id, role_id FROM employee_effective_roles
+		)
+		SELECT s.id AS rule_id, e.id AS employee_id, e.name AS employee_name,
+			s.role_id, r.name AS role_name, s.conflicting_role_id, cr.name AS conflicting_role_name
+		FROM sod_rules s
+		JOIN roles r ON r.id = s.role_id
+		JOIN roles cr ON cr.id = s.conflicting_role_id
+		JOIN held h ON h.role_id = s.role_id
+		JOIN held ch ON ch.role_id = s.conflicting_role_id AND ch.employee_id = h.employee_id
+		JOIN employees e ON e.id = h.employee_id AND e.deleted_at IS NULL
+		ORDER BY s.id, e.id
+	`
+	err = r.db.SelectContext(ctx, &violations, query)
+
+	return violations, err
+}
+
+// CheckAssignmentTx - проверить в транзакции tx, что прямое назначение роли roleId сотруднику не нарушает
+// ни одно правило: ни роль, ни подразумеваемые ею роли не должны конфликтовать с ролями, которые у сотрудника
+// уже есть (в том числе через группы, наследование и назначения с отложенным началом) или которые
+// подразумевает сама назначаемая роль. Нарушение возвращается как ErrViolation.
+// Блокировка держится до конца транзакции, поэтому назначение нужно записывать в той же tx
+func CheckAssignmentTx(ctx context.Context, tx *sqlx.Tx, employeeId int64, roleId int64) error {
+	return checkGrantTx(
+		ctx,
+		tx,
+		`affected AS (SELECT $1::BIGINT AS employee_id),
+		granted AS (SELECT $2::BIGINT AS role_id)`,
+		employeeId, roleId,
+	)
+}
+
+// CheckGroupEmployeeTx - то же для включения сотрудника в группу: он получает роли группы
+// и всех групп, в которые она вложена
+func CheckGroupEmployeeTx(ctx context.Context, tx *sqlx.Tx, groupId int64, employeeId int64) error {
+	return checkGrantTx(
+		ctx,
+		tx,
+		containersCte("$1")+`,
+		affected AS (SELECT $2::BIGINT AS employee_id),
+		granted AS (SELECT gr.role_id FROM group_roles gr JOIN containers c ON c.group_id = gr.group_id)`,
+		groupId, employeeId,
+	)
+}
+
+// CheckGroupRoleTx - то же для выдачи роли группе: роль получают все участники группы,
+// в том числе через вложенные группы
+func CheckGroupRoleTx(ctx context.Context, tx *sqlx.Tx, groupId int64, roleId int64) error {
+	return checkGrantTx(
+		ctx,
+		tx,
+		nestedCte("$1")+`,
+		affected AS (SELECT ge.employee_id FROM group_employees ge JOIN nested n ON n.group_id = ge.group_id),
+		granted AS (SELECT $2::BIGINT AS role_id)`,
+		groupId, roleId,
+	)
+}
+
+// CheckGroupNestingTx - то же для вложения группы memberGroupId в groupId: участники вложенной группы
+// получают роли groupId и всех групп, в которые она вложена
+func CheckGroupNestingTx(ctx context.Context, tx *sqlx.Tx, groupId int64, memberGroupId int64) error {
+	return checkGrantTx(
+		ctx,
+		tx,
+		containersCte("$1")+`,
+		`+nestedCte("$2")+`,
+		affected AS (SELECT ge.employee_id FROM group_employees ge JOIN nested n ON n.group_id = ge.group_id),
+		granted AS (SELECT gr.role_id FROM group_roles gr JOIN containers c ON c.group_id = gr.group_id)`,
+		groupId, memberGroupId,
+	)
+}
+
+// CheckInheritanceTx - то же для наследования: роли inherits (и подразумеваемые ими) получают все,
+// у кого есть роль roleId, - через группы, наследование или прямое назначение, в том числе отложенное
+func CheckInheritanceTx(ctx context.Context, tx *sqlx.Tx, roleId int64, inherits []int64) error {
+	return checkGrantTx(
+		ctx,
+		tx,
+		`affected AS (
+			SELECT employee_id FROM employee_effective_roles WHERE role_id = $1
+			UNION
+			SELECT er.employee_id
+			FROM employee_roles er
+			JOIN role_implied_roles ir ON ir.role_id = er.role_id
+			WHERE ir.implied_role_id = $1 AND (er.valid_until IS NULL OR er.valid_until > NOW())
+		),
+		granted AS (SELECT unnest($2::BIGINT[]) AS role_id)`,
+		roleId, pq.Int64Array(inherits),
+	)
+}
+
+// containersCte - группа из параметра param и все группы, в которые она вложена прямо или транзитивно
+func containersCte(param string) string {
+	return `containers AS (
+			SELECT ` + param + `::BIGINT AS group_id
+			UNION
+			SELECT gs.group_id FROM group_subgroups gs JOIN containers c ON gs.member_group_id = c.group_id
+		)`
+}
+
+// nestedCte - группа из параметра param и все группы, вложенные в неё прямо или транзитивно
+func nestedCte(param string) string {
+	return `nested AS (
+			SELECT ` + param + `::BIGINT AS group_id
+			UNION
+			SELECT gs.member_group_id FROM group_subgroups gs JOIN nested n ON gs.group_id = n.group_id
+		)`
+}
+
+// checkGrantTx - общая проверка: ctes задаёт сотрудников affected(employee_id), которые получают роли
+// granted(role_id). Для каждого из них роли granted вместе с подразумеваемыми (candidate) не должны
+// образовать пару правила с ролями, которые у сотрудника уже есть или появятся из candidate
+func checkGrantTx(ctx context.Context, tx *sqlx.Tx, ctes string, args ...interface{}) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", assignmentLockKey); err != nil {
+		return err
+	}
+
+	var violated []Entity
+	err := tx.SelectContext(
+		ctx,
+		&violated,
+		`WITH RECURSIVE `+ctes+`,
+		candidate AS (
+			SELECT DISTINCT ir.implied_role_id AS role_id
+			FROM role_implied_roles ir
+			WHERE ir.role_id IN (SELECT role_id FROM granted)
+		),
+		held AS (
+			SELECT a.employee_id, er.role_id
+			FROM affected a
+			CROSS JOIN LATERAL employee_effective_roles_of(a.employee_id) er
+			UNION
+			SELECT er.employee_id, ir.implied_role_id
+			FROM employee_roles er
+			JOIN role_implied_roles ir ON ir.role_id = er.role_id
+			WHERE er.employee_id IN (SELECT employee_id FROM affected)
+				AND (er.valid_until IS NULL OR er.valid_until > NOW())
+			UNION
+			SELECT a.employee_id, c.role_id FROM affected a CROSS JOIN candidate c
+		)`+selectRules+`
+		WHERE (s.role_id IN (SELECT role_id FROM candidate) OR s.conflicting_role_id IN (SELECT role_id FROM candidate))
+			AND EXISTS(
+				SELECT 1 FROM held h
+				JOIN held ch ON ch.employee_id = h.employee_id
+				WHERE h.role_id = s.role_id AND ch.role_id = s.conflicting_role_id
+			)
+		ORDER BY s.id
+		LIMIT 1`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	if len(violated) == 0 {
+		return nil
+	}
+
+	var rule = violated[0]
+	return fmt.Errorf(
+		"%w: roles %s and %s must not be held together (rule %d)",
+		ErrViolation, rule.RoleName, rule.ConflictingRoleName, rule.Id,
+	)
+}
@@ -0,0 +1,145 @@
+package sod
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"idm/inner/domain"
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+}
+
+type Repo interface {
+	FindAll(ctx context.Context) ([]Entity, error)
+	FindById(ctx context.Context, id int64) (Entity, error)
+	ExistsRoleById(ctx context.Context, roleId int64) (bool, error)
+	ExistsRule(ctx context.Context, roleId int64, conflictingRoleId int64) (bool, error)
+	CreateRule(ctx context.Context, entity *Entity) (Entity, error)
+	DeleteRule(ctx context.Context, id int64) error
+	FindViolations(ctx context.Context) ([]ViolationEntity, error)
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор
+func NewService(repo Repo, validator Validator) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+	}
+}
+
+// FindAll - все правила разделения полномочий
+func (svc *Service) FindAll(ctx context.Context) ([]Response, error) {
+	entities, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding sod rules: %w", err)
+	}
+
+	responses := make([]Response, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) FindById(ctx context.Context, id int64) (Response, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findRule(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// CreateRule - запретить одновременное владение двумя ролями. Правило симметрично,
+// поэтому пара, уже заданная в любом порядке, считается существующей
+func (svc *Service) CreateRule(ctx context.Context, request CreateRequest) (Response, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	for _, roleId := range []int64{request.RoleId, request.ConflictingRoleId} {
+		isExists, err := svc.repo.ExistsRoleById(ctx, roleId)
+		if err != nil {
+			return Response{}, fmt.Errorf("error checking role with id %d: %w", roleId, err)
+		}
+		if !isExists {
+			return Response{}, domain.NotFoundError{Message: fmt.Sprintf("role with id %d not found", roleId)}
+		}
+	}
+
+	isExists, err := svc.repo.ExistsRule(ctx, request.RoleId, request.ConflictingRoleId)
+	if err != nil {
+		return Response{}, fmt.Errorf("error checking sod rule: %w", err)
+	}
+	if isExists {
+		return Response{}, domain.AlreadyExistsError{
+			Message: fmt.Sprintf("sod rule for roles %d and %d already exists", request.RoleId, request.ConflictingRoleId),
+		}
+	}
+
+	entity := request.ToEntity()
+	created, err := svc.repo.CreateRule(ctx, &entity)
+	if err != nil {
+		return Response{}, fmt.Errorf("error creating sod rule: %w", err)
+	}
+
+	return svc.FindById(ctx, created.Id)
+}
+
+// DeleteById - удалить правило, возвращает удалённое правило
+func (svc *Service) DeleteById(ctx context.Context, id int64) (Response, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return Response{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findRule(ctx, id)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err := svc.repo.DeleteRule(ctx, id); err != nil {
+		return Response{}, fmt.Errorf("error deleting sod rule %d: %w", id, err)
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// FindViolations - отчёт о сотрудниках, которые уже нарушают правила
+func (svc *Service) FindViolations(ctx context.Context) ([]ViolationResponse, error) {
+	entities, err := svc.repo.FindViolations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding sod violations: %w", err)
+	}
+
+	responses := make([]ViolationResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) findRule(ctx context.Context, id int64) (Entity, error) {
+	entity, err := svc.repo.FindById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entity{}, domain.NotFoundError{Message: fmt.Sprintf("sod rule with id %d not found", id)}
+	}
+	if err != nil {
+		return Entity{}, fmt.Errorf("error finding sod rule with id %d: %w", id, err)
+	}
+
+	return entity, nil
+}
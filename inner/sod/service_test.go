@@ -0,0 +1,169 @@
+package sod
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"idm/inner/domain"
+	"testing"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindAll(ctx context.Context) ([]Entity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Entity), args.Error(1)
+}
+
+func (m *MockRepo) FindById(ctx context.Context, id int64) (Entity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) ExistsRoleById(ctx context.Context, roleId int64) (bool, error) {
+	args := m.Called(ctx, roleId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) ExistsRule(ctx context.Context, roleId int64, conflictingRoleId int64) (bool, error) {
+	args := m.Called(ctx, roleId, conflictingRoleId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) CreateRule(ctx context.Context, entity *Entity) (Entity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(Entity), args.Error(1)
+}
+
+func (m *MockRepo) DeleteRule(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) FindViolations(ctx context.Context) ([]ViolationEntity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]ViolationEntity), args.Error(1)
+}
+
+func TestSodService(t *testing.T) {
+	appContext := context.Background()
+	var a = assert.New(t)
+	request := CreateRequest{RoleId: 1, ConflictingRoleId: 2, Description: "four eyes on payments"}
+
+	t.Run("should create rule", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		created := Entity{Id: 7, RoleId: 1, ConflictingRoleId: 2, Description: request.Description}
+		found := created
+		found.RoleName, found.ConflictingRoleName = "PAYMENT_CREATOR", "PAYMENT_APPROVER"
+
+		validator.On("Validate", request).Return(nil).Once()
+		validator.On("Validate", FindByIDRequest{ID: 7}).Return(nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(1)).Return(true, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(2)).Return(true, nil).Once()
+		repo.On("ExistsRule", appContext, int64(1), int64(2)).Return(false, nil).Once()
+		repo.On("CreateRule", appContext, &Entity{RoleId: 1, ConflictingRoleId: 2, Description: request.Description}).
+			Return(created, nil).Once()
+		repo.On("FindById", appContext, int64(7)).Return(found, nil).Once()
+
+		got, err := service.CreateRule(appContext, request)
+
+		a.Nil(err)
+		a.Equal("PAYMENT_APPROVER", got.ConflictingRoleName)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return already exists for reversed pair", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsRoleById", appContext, mock.Anything).Return(true, nil).Twice()
+		repo.On("ExistsRule", appContext, int64(1), int64(2)).Return(true, nil).Once()
+
+		_, err := service.CreateRule(appContext, request)
+
+		a.True(errors.As(err, &domain.AlreadyExistsError{}))
+		repo.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found when role does not exist", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", request).Return(nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(1)).Return(true, nil).Once()
+		repo.On("ExistsRoleById", appContext, int64(2)).Return(false, nil).Once()
+
+		_, err := service.CreateRule(appContext, request)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "ExistsRule", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return validation error for rule on the same role", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		same := CreateRequest{RoleId: 1, ConflictingRoleId: 1}
+
+		validator.On("Validate", same).Return(errors.New("ConflictingRoleId must not equal RoleId")).Once()
+
+		_, err := service.CreateRule(appContext, same)
+
+		a.True(errors.As(err, &domain.RequestValidationError{}))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should delete rule", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		rule := Entity{Id: 7, RoleId: 1, ConflictingRoleId: 2}
+
+		validator.On("Validate", FindByIDRequest{ID: 7}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(7)).Return(rule, nil).Once()
+		repo.On("DeleteRule", appContext, int64(7)).Return(nil).Once()
+
+		got, err := service.DeleteById(appContext, 7)
+
+		a.Nil(err)
+		a.Equal(int64(7), got.Id)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when deleting missing rule", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+
+		validator.On("Validate", FindByIDRequest{ID: 8}).Return(nil).Once()
+		repo.On("FindById", appContext, int64(8)).Return(Entity{}, sql.ErrNoRows).Once()
+
+		_, err := service.DeleteById(appContext, 8)
+
+		a.True(errors.As(err, &domain.NotFoundError{}))
+		repo.AssertNotCalled(t, "DeleteRule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return violations", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		service := NewService(repo, validator)
+		violations := []ViolationEntity{{RuleId: 7, EmployeeId: 10, EmployeeName: "Alice", RoleId: 1, ConflictingRoleId: 2}}
+
+		repo.On("FindViolations", appContext).Return(violations, nil).Once()
+
+		got, err := service.FindViolations(appContext)
+
+		a.Nil(err)
+		a.Equal([]ViolationResponse{violations[0].ToResponse()}, got)
+	})
+}
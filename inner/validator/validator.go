@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"idm/inner/domain"
+	"regexp"
 	"strings"
 )
 
@@ -30,9 +31,28 @@ func NewValidator() *Validator {
 			return true
 		},
 	)
+	// "login" - латинские буквы, цифры, точка, дефис и подчёркивание, начинается с буквы, 3-64 символа
+	_ = validate.RegisterValidation(
+		"login",
+		func(fl validator.FieldLevel) bool {
+			return loginPattern.MatchString(fl.Field().String())
+		},
+	)
+	// "phone" - необязательный "+", затем цифры, пробелы, скобки и дефисы (5-32 символа)
+	_ = validate.RegisterValidation(
+		"phone",
+		func(fl validator.FieldLevel) bool {
+			return phonePattern.MatchString(fl.Field().String())
+		},
+	)
 	return &Validator{validate: validate}
 }
 
+var (
+	loginPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]{2,63}$`)
+	phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{3,30}[0-9]$`)
+)
+
 func (v *Validator) Validate(request any) error {
 	err := v.validate.Struct(request)
 	if err != nil {
@@ -49,6 +69,12 @@ func (v *Validator) Validate(request any) error {
 					return domain.RequestValidationError{Message: fmt.Sprintf("Field %s must not exceed %s", e.Field(), e.Param())}
 				case "no_sql_injection": // Обработка нового тега
 					return domain.RequestValidationError{Message: fmt.Sprintf("Field %s contains forbidden SQL characters", e.Field())}
+				case "email":
+					return domain.RequestValidationError{Message: fmt.Sprintf("Field %s must be a valid email", e.Field())}
+				case "login":
+					return domain.RequestValidationError{Message: fmt.Sprintf("Field %s must be 3-64 latin letters, digits, '.', '_' or '-' starting with a letter", e.Field())}
+				case "phone":
+					return domain.RequestValidationError{Message: fmt.Sprintf("Field %s must be a valid phone number", e.Field())}
 				default:
 					return domain.RequestValidationError{Message: fmt.Sprintf("Field %s is invalid", e.Field())} // Обработка других ошибок
 				}
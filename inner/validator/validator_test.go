@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/domain"
+	"testing"
+)
+
+type profileRequest struct {
+	Login string `validate:"omitempty,login"`
+	Email string `validate:"omitempty,max=255,email"`
+	Phone string `validate:"omitempty,phone"`
+}
+
+func TestValidator_Profile(t *testing.T) {
+	var a = assert.New(t)
+	var v = NewValidator()
+
+	t.Run("should accept valid profile", func(t *testing.T) {
+		err := v.Validate(profileRequest{Login: "j.doe-1_x", Email: "jdoe@example.com", Phone: "+7 (495) 123-45-67"})
+		a.Nil(err)
+	})
+
+	t.Run("should accept empty profile", func(t *testing.T) {
+		a.Nil(v.Validate(profileRequest{}))
+	})
+
+	tests := []struct {
+		name    string
+		request profileRequest
+		message string
+	}{
+		{"login starts with digit", profileRequest{Login: "1jdoe"}, "Field Login must be 3-64"},
+		{"login too short", profileRequest{Login: "jd"}, "Field Login must be 3-64"},
+		{"login with forbidden chars", profileRequest{Login: "j doe'"}, "Field Login must be 3-64"},
+		{"invalid email", profileRequest{Email: "jdoe@"}, "Field Email must be a valid email"},
+		{"phone with letters", profileRequest{Phone: "+7 abc 123"}, "Field Phone must be a valid phone number"},
+		{"phone too short", profileRequest{Phone: "123"}, "Field Phone must be a valid phone number"},
+	}
+	for _, tt := range tests {
+		t.Run("should reject "+tt.name, func(t *testing.T) {
+			err := v.Validate(tt.request)
+			a.True(errors.As(err, &domain.RequestValidationError{}))
+			a.Contains(err.Error(), tt.message)
+		})
+	}
+}
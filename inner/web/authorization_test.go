@@ -0,0 +1,31 @@
+package web
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_Require(t *testing.T) {
+	var newApp = func(server *Server) *fiber.App {
+		app := fiber.New()
+		app.Get("/", server.Require(PermEmployeesRead), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+		return app
+	}
+
+	t.Run("server without authorizer rejects request", func(t *testing.T) {
+		resp, err := newApp(&Server{}).Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	})
+
+	t.Run("explicitly disabled authorization passes request", func(t *testing.T) {
+		resp, err := newApp(&Server{AuthorizationDisabled: true}).Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/http"
+	"strings"
+)
+
+const (
+	LocalsSubject = "subject" // ключ ctx.Locals для subject (claim "sub") токена
+	LocalsClaims  = "claims"  // ключ ctx.Locals для всех claims токена (jwt.MapClaims)
+
+	ClaimSubjectType    = "sub_type" // claim с типом субъекта токена
+	SubjectTypeEmployee = "employee" // sub - id сотрудника (по умолчанию, если claim отсутствует)
+	SubjectTypeClient   = "client"   // sub - client_id сервиса
+
+	bearerPrefix         = "Bearer "
+	missingTokenMessage  = "missing or malformed authorization token"
+	invalidTokenMessage  = "invalid or expired authorization token"
+	authenticateResponse = `Bearer realm="idm"`
+)
+
+// JwtKeys - ключи проверки подписи JWT
+type JwtKeys struct {
+	HmacSecret   []byte         // ключ для HS256
+	RsaPublicKey *rsa.PublicKey // ключ для RS256
+	Issuer       string         // ожидаемый iss (если пусто - не проверяется)
+	Audience     string         // ожидаемый aud (если пусто - не проверяется)
+}
+
+// NewJwtKeys - загрузка ключей проверки JWT из конфигурации приложения
+func NewJwtKeys(cfg config.Config) (JwtKeys, error) {
+	var keys = JwtKeys{
+		Issuer:   cfg.JwtIssuer,
+		Audience: cfg.JwtAudience,
+	}
+	if cfg.JwtHs256Secret != "" {
+		keys.HmacSecret = []byte(cfg.JwtHs256Secret)
+	}
+	switch {
+	case cfg.JwtRs256PublicKey != "":
+		pemData, err := config.ReadPem(cfg.JwtRs256PublicKey)
+		if err != nil {
+			return JwtKeys{}, fmt.Errorf("error loading RS256 public key: %w", err)
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemData)
+		if err != nil {
+			return JwtKeys{}, fmt.Errorf("error loading RS256 public key: %w", err)
+		}
+		keys.RsaPublicKey = publicKey
+	case cfg.JwtRs256PrivateKey != "":
+		// публичный ключ выводим из приватного ключа, которым сервис сам подписывает токены
+		pemData, err := config.ReadPem(cfg.JwtRs256PrivateKey)
+		if err != nil {
+			return JwtKeys{}, fmt.Errorf("error loading RS256 private key: %w", err)
+		}
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemData)
+		if err != nil {
+			return JwtKeys{}, fmt.Errorf("error loading RS256 private key: %w", err)
+		}
+		keys.RsaPublicKey = &privateKey.PublicKey
+	}
+	return keys, nil
+}
+
+// validMethods - список алгоритмов, для которых настроены ключи
+func (k JwtKeys) validMethods() []string {
+	var methods []string
+	if len(k.HmacSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if k.RsaPublicKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	return methods
+}
+
+// keyFunc - выбор ключа по алгоритму из заголовка токена
+func (k JwtKeys) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if len(k.HmacSecret) > 0 {
+			return k.HmacSecret, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if k.RsaPublicKey != nil {
+			return k.RsaPublicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+}
+
+// JwtAuthMiddleware - middleware проверки подписанного JWT из заголовка Authorization.
+// При успехе кладёт subject и claims в ctx.Locals, а вызывающего - в UserContext, иначе отвечает 401
+func JwtAuthMiddleware(keys JwtKeys, logger *common.Logger) fiber.Handler {
+	var options = []jwt.ParserOption{
+		jwt.WithValidMethods(keys.validMethods()),
+		jwt.WithExpirationRequired(),
+	}
+	if keys.Issuer != "" {
+		options = append(options, jwt.WithIssuer(keys.Issuer))
+	}
+	if keys.Audience != "" {
+		options = append(options, jwt.WithAudience(keys.Audience))
+	}
+	var parser = jwt.NewParser(options...)
+
+	return func(c *fiber.Ctx) error {
+		requestID, _ := c.Locals("request_id").(string)
+
+		var header = c.Get(fiber.HeaderAuthorization)
+		if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			logger.Error("authorization token is missing",
+				zap.String("path", c.Path()),
+				zap.String("request_id", requestID),
+			)
+			return unauthorized(c, missingTokenMessage)
+		}
+
+		var claims = jwt.MapClaims{}
+		_, err := parser.ParseWithClaims(strings.TrimSpace(header[len(bearerPrefix):]), claims, keys.keyFunc)
+		if err != nil {
+			logger.Error("authorization token is invalid",
+				zap.String("path", c.Path()),
+				zap.String("request_id", requestID),
+				zap.Error(err),
+			)
+			return unauthorized(c, invalidTokenMessage)
+		}
+
+		subject, err := claims.GetSubject()
+		if err != nil || subject == "" {
+			logger.Error("authorization token has no subject",
+				zap.String("path", c.Path()),
+				zap.String("request_id", requestID),
+				zap.Error(err),
+			)
+			return unauthorized(c, invalidTokenMessage)
+		}
+
+		c.Locals(LocalsSubject, subject)
+		c.Locals(LocalsClaims, claims)
+		// вызывающий нужен сервисам (аудит изменений), которые видят только context
+		subjectType, _ := claims[ClaimSubjectType].(string)
+		c.SetUserContext(common.WithCaller(c.UserContext(), common.Caller{
+			Subject:     subject,
+			SubjectType: subjectType,
+			RequestId:   requestID,
+		}))
+		return c.Next()
+	}
+}
+
+// unauthorized - единый ответ 401
+func unauthorized(c *fiber.Ctx, message string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, authenticateResponse)
+	return http.ErrResponse(c, fiber.StatusUnauthorized, message)
+}
+
+// SkipPaths - обёртка над middleware: перечисленные пути (например, выдача токена) пропускаются без проверки
+func SkipPaths(handler fiber.Handler, paths ...string) fiber.Handler {
+	var skip = make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		skip[path] = struct{}{}
+	}
+	return func(c *fiber.Ctx) error {
+		if _, ok := skip[strings.TrimSuffix(c.Path(), "/")]; ok {
+			return c.Next()
+		}
+		return handler(c)
+	}
+}
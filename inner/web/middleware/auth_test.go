@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJwtAuthMiddleware(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg)
+
+	// Локально сгенерированные ключи
+	var secret = "test-hs256-secret"
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicDer, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	publicPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})
+	otherRsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	cfg.JwtHs256Secret = secret
+	cfg.JwtRs256PublicKey = string(publicPem)
+	cfg.JwtIssuer = "idm"
+	keys, err := NewJwtKeys(cfg)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	RegisterMiddleware(app, logger)
+	app.Get("/protected", JwtAuthMiddleware(keys, logger), func(c *fiber.Ctx) error {
+		claims := c.Locals(LocalsClaims).(jwt.MapClaims)
+		return c.JSON(fiber.Map{
+			"subject": c.Locals(LocalsSubject),
+			"role":    claims["role"],
+			"caller":  common.CallerFromContext(c.UserContext()).Subject,
+		})
+	})
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":  "42",
+			"iss":  "idm",
+			"role": "ADMIN",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	signHs := func(claims jwt.MapClaims, key []byte) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+	signRs := func(claims jwt.MapClaims, key *rsa.PrivateKey) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+	doRequest := func(t *testing.T, authorization string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Errorf("Error closing response body: %v", err)
+			}
+		}()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &result))
+		return resp.StatusCode, result
+	}
+	assertUnauthorized := func(t *testing.T, status int, body map[string]interface{}) {
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+		assert.Equal(t, false, body["success"])
+		assert.NotEmpty(t, body["error"])
+		assert.Nil(t, body["data"])
+	}
+
+	t.Run("valid HS256 token puts subject and claims into Locals", func(t *testing.T) {
+		status, body := doRequest(t, "Bearer "+signHs(validClaims(), []byte(secret)))
+
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "42", body["subject"])
+		assert.Equal(t, "ADMIN", body["role"])
+		assert.Equal(t, "42", body["caller"])
+	})
+
+	t.Run("valid RS256 token", func(t *testing.T) {
+		status, body := doRequest(t, "Bearer "+signRs(validClaims(), rsaKey))
+
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "42", body["subject"])
+	})
+
+	t.Run("missing header returns 401", func(t *testing.T) {
+		status, body := doRequest(t, "")
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("non bearer header returns 401", func(t *testing.T) {
+		status, body := doRequest(t, "Basic dXNlcjpwYXNz")
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("wrong HS256 secret returns 401", func(t *testing.T) {
+		status, body := doRequest(t, "Bearer "+signHs(validClaims(), []byte("other-secret")))
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("foreign RS256 key returns 401", func(t *testing.T) {
+		status, body := doRequest(t, "Bearer "+signRs(validClaims(), otherRsaKey))
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("expired token returns 401", func(t *testing.T) {
+		claims := validClaims()
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		status, body := doRequest(t, "Bearer "+signHs(claims, []byte(secret)))
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("token without exp returns 401", func(t *testing.T) {
+		claims := validClaims()
+		delete(claims, "exp")
+		status, body := doRequest(t, "Bearer "+signHs(claims, []byte(secret)))
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("wrong issuer returns 401", func(t *testing.T) {
+		claims := validClaims()
+		claims["iss"] = "someone-else"
+		status, body := doRequest(t, "Bearer "+signHs(claims, []byte(secret)))
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("token without subject returns 401", func(t *testing.T) {
+		claims := validClaims()
+		delete(claims, "sub")
+		status, body := doRequest(t, "Bearer "+signHs(claims, []byte(secret)))
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("unsigned token returns 401", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).
+			SignedString(jwt.UnsafeAllowNoneSignatureType)
+		require.NoError(t, err)
+		status, body := doRequest(t, "Bearer "+token)
+		assertUnauthorized(t, status, body)
+	})
+
+	t.Run("HS256 token is rejected when only RS256 is configured", func(t *testing.T) {
+		rsOnly := JwtAuthMiddleware(JwtKeys{RsaPublicKey: &rsaKey.PublicKey}, logger)
+		rsApp := fiber.New()
+		RegisterMiddleware(rsApp, logger)
+		rsApp.Get("/protected", rsOnly, func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+signHs(validClaims(), []byte(secret)))
+		resp, err := rsApp.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("public key can be loaded from a PEM file", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "public.pem")
+		require.NoError(t, os.WriteFile(keyFile, publicPem, 0600))
+
+		fileKeys, err := NewJwtKeys(config.Config{JwtRs256PublicKey: keyFile})
+
+		require.NoError(t, err)
+		assert.True(t, rsaKey.PublicKey.Equal(fileKeys.RsaPublicKey))
+	})
+
+	t.Run("invalid public key returns error", func(t *testing.T) {
+		_, err := NewJwtKeys(config.Config{JwtRs256PublicKey: "-----BEGIN PUBLIC KEY-----\nbroken\n-----END PUBLIC KEY-----"})
+		assert.Error(t, err)
+	})
+}
+
+func TestSkipPaths(t *testing.T) {
+	app := fiber.New()
+	var guard = func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	app.Use(SkipPaths(guard, "/api/v1/auth/token"))
+	app.All("/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for path, status := range map[string]int{
+		"/api/v1/auth/token":   fiber.StatusOK,
+		"/api/v1/auth/token/":  fiber.StatusOK,
+		"/api/v1/auth/clients": fiber.StatusUnauthorized,
+		"/api/v1/employees":    fiber.StatusUnauthorized,
+	} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode, path)
+	}
+}
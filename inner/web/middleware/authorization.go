@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	LocalsPermissions = "permissions" // ключ ctx.Locals для разрешений вызывающего (map[string]struct{})
+
+	authorizationErrorMessage = "Internal server error"
+)
+
+// PermissionResolver - источник разрешений сотрудника и клиента client_credentials (через их роли)
+type PermissionResolver interface {
+	FindPermissionNames(ctx context.Context, employeeId int64) ([]string, error)
+	FindClientPermissionNames(ctx context.Context, clientId string) ([]string, error)
+}
+
+// Authorizer - проверка разрешений вызывающего, определённого JwtAuthMiddleware
+type Authorizer struct {
+	resolver PermissionResolver
+	logger   *common.Logger
+}
+
+// NewAuthorizer - функция-конструктор
+func NewAuthorizer(resolver PermissionResolver, logger *common.Logger) *Authorizer {
+	return &Authorizer{
+		resolver: resolver,
+		logger:   logger,
+	}
+}
+
+// RequirePermissions - middleware: вызывающий должен иметь все перечисленные разрешения, иначе 403
+// со списком недостающих. Разрешения сотрудника вычисляются через его роли, клиента (client_credentials) -
+// через роли, выданные клиенту
+func (a *Authorizer) RequirePermissions(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID, _ := c.Locals("request_id").(string)
+
+		subject, ok := c.Locals(LocalsSubject).(string)
+		if !ok || subject == "" {
+			// маршрут не прошёл JwtAuthMiddleware - вызывающий неизвестен
+			return unauthorized(c, missingTokenMessage)
+		}
+
+		granted, err := a.callerPermissions(c, subject)
+		if err != nil {
+			a.logger.Error("When the resolve caller permissions ended with an error:",
+				zap.Error(err),
+				zap.String("subject", subject),
+				zap.String("request_id", requestID),
+			)
+			return http.ErrResponse(c, fiber.StatusInternalServerError, authorizationErrorMessage)
+		}
+
+		var missing []string
+		for _, permission := range permissions {
+			if _, ok := granted[permission]; !ok {
+				missing = append(missing, permission)
+			}
+		}
+		if len(missing) > 0 {
+			a.logger.Error("access denied",
+				zap.String("subject", subject),
+				zap.Strings("missing_permissions", missing),
+				zap.String("path", c.Path()),
+				zap.String("request_id", requestID),
+			)
+			return http.ErrResponse(c, fiber.StatusForbidden,
+				fmt.Sprintf("access denied, missing permissions: %s", strings.Join(missing, ", ")))
+		}
+
+		return c.Next()
+	}
+}
+
+// callerPermissions - разрешения вызывающего, вычисляются один раз на запрос
+func (a *Authorizer) callerPermissions(c *fiber.Ctx, subject string) (map[string]struct{}, error) {
+	if granted, ok := c.Locals(LocalsPermissions).(map[string]struct{}); ok {
+		return granted, nil
+	}
+
+	var granted = map[string]struct{}{}
+	var names []string
+	if claims, ok := c.Locals(LocalsClaims).(jwt.MapClaims); ok && claims[ClaimSubjectType] == SubjectTypeClient {
+		// subject клиента - его client_id
+		clientNames, err := a.resolver.FindClientPermissionNames(c.UserContext(), subject)
+		if err != nil {
+			return nil, err
+		}
+		names = clientNames
+	} else {
+		employeeId, err := strconv.ParseInt(subject, 10, 64)
+		if err != nil {
+			// subject не является id сотрудника - разрешений нет
+			c.Locals(LocalsPermissions, granted)
+			return granted, nil
+		}
+		if names, err = a.resolver.FindPermissionNames(c.UserContext(), employeeId); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range names {
+		granted[name] = struct{}{}
+	}
+	c.Locals(LocalsPermissions, granted)
+	return granted, nil
+}
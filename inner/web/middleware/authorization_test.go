@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// stubResolver - разрешения сотрудников и клиентов для тестов
+type stubResolver struct {
+	permissions map[int64][]string
+	clients     map[string][]string
+	err         error
+	calls       int
+}
+
+func (r *stubResolver) FindPermissionNames(_ context.Context, employeeId int64) ([]string, error) {
+	r.calls++
+	return r.permissions[employeeId], r.err
+}
+
+func (r *stubResolver) FindClientPermissionNames(_ context.Context, clientId string) ([]string, error) {
+	r.calls++
+	return r.clients[clientId], r.err
+}
+
+func TestAuthorizer(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg)
+
+	resolver := &stubResolver{
+		permissions: map[int64][]string{
+			1: {"employees:read", "employees:delete"},
+			2: {"employees:read"},
+		},
+		clients: map[string][]string{
+			"hr-sync": {"employees:read", "employees:delete"},
+			"1":       {"employees:read"},
+		},
+	}
+	authorizer := NewAuthorizer(resolver, logger)
+
+	// newApp - вместо JwtAuthMiddleware кладём subject и claims напрямую
+	newApp := func(subject string, claims jwt.MapClaims) *fiber.App {
+		app := fiber.New()
+		RegisterMiddleware(app, logger)
+		app.Use(func(c *fiber.Ctx) error {
+			if subject != "" {
+				c.Locals(LocalsSubject, subject)
+				c.Locals(LocalsClaims, claims)
+			}
+			return c.Next()
+		})
+		app.Delete("/employees/:id",
+			authorizer.RequirePermissions("employees:read"),
+			authorizer.RequirePermissions("employees:read", "employees:delete"),
+			func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+		return app
+	}
+	doRequest := func(t *testing.T, app *fiber.App) (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/employees/5", nil))
+		require.NoError(t, err)
+		if resp.StatusCode == fiber.StatusOK {
+			return resp.StatusCode, nil
+		}
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	t.Run("employee with all permissions is allowed and permissions are resolved once", func(t *testing.T) {
+		resolver.calls = 0
+		status, _ := doRequest(t, newApp("1", jwt.MapClaims{"sub": "1"}))
+
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, 1, resolver.calls)
+	})
+
+	t.Run("missing permission returns 403 listing it", func(t *testing.T) {
+		status, body := doRequest(t, newApp("2", jwt.MapClaims{"sub": "2", ClaimSubjectType: SubjectTypeEmployee}))
+
+		assert.Equal(t, fiber.StatusForbidden, status)
+		assert.Equal(t, false, body["success"])
+		assert.Equal(t, "access denied, missing permissions: employees:delete", body["error"])
+	})
+
+	t.Run("client permissions are resolved by client id, not as employee id", func(t *testing.T) {
+		status, _ := doRequest(t, newApp("hr-sync", jwt.MapClaims{"sub": "hr-sync", ClaimSubjectType: SubjectTypeClient}))
+		assert.Equal(t, fiber.StatusOK, status)
+
+		// client_id "1" - это клиент, а не сотрудник 1 со всеми разрешениями
+		status, body := doRequest(t, newApp("1", jwt.MapClaims{"sub": "1", ClaimSubjectType: SubjectTypeClient}))
+		assert.Equal(t, fiber.StatusForbidden, status)
+		assert.Equal(t, "access denied, missing permissions: employees:delete", body["error"])
+	})
+
+	t.Run("client_credentials token passes protected route", func(t *testing.T) {
+		var secret = "test-hs256-secret"
+		keys, err := NewJwtKeys(config.Config{JwtHs256Secret: secret})
+		require.NoError(t, err)
+
+		app := fiber.New()
+		RegisterMiddleware(app, logger)
+		app.Get("/scim/v2/Users", JwtAuthMiddleware(keys, logger), authorizer.RequirePermissions("employees:read"),
+			func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+		var request = func(clientId string) int {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"sub":            clientId,
+				ClaimSubjectType: SubjectTypeClient,
+				"exp":            time.Now().Add(time.Minute).Unix(),
+			}).SignedString([]byte(secret))
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			return resp.StatusCode
+		}
+
+		assert.Equal(t, fiber.StatusOK, request("hr-sync"))
+		assert.Equal(t, fiber.StatusForbidden, request("unknown-client")) // клиент без ролей
+	})
+
+	t.Run("unauthenticated caller returns 401", func(t *testing.T) {
+		status, _ := doRequest(t, newApp("", nil))
+
+		assert.Equal(t, fiber.StatusUnauthorized, status)
+	})
+
+	t.Run("resolver error returns 500", func(t *testing.T) {
+		failing := NewAuthorizer(&stubResolver{err: errors.New("db is down")}, logger)
+		app := fiber.New()
+		RegisterMiddleware(app, logger)
+		app.Get("/", func(c *fiber.Ctx) error {
+			c.Locals(LocalsSubject, "1")
+			return c.Next()
+		}, failing.RequirePermissions("employees:read"), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	})
+}
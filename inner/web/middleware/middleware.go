@@ -9,8 +9,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// MaxRequestIdLength - максимальная длина X-Request-Id клиента: идентификатор пишется в request_id VARCHAR(155)
+// журнала аудита, и слишком длинный заголовок ронял бы каждое изменение с 500
+const MaxRequestIdLength = 128
+
 // RegisterMiddleware - функция регистрации middleware
 func RegisterMiddleware(app *fiber.App, logger *common.Logger) {
+	app.Use(func(c *fiber.Ctx) error {
+		// некорректный X-Request-Id клиента отбрасываем - requestid сгенерирует новый
+		if !validRequestId(c.Get(fiber.HeaderXRequestID)) {
+			c.Request().Header.Del(fiber.HeaderXRequestID)
+		}
+		return c.Next()
+	})
 	app.Use(requestid.New(requestid.Config{ // Middleware для генерации requestId
 		Header: "X-Request-Id", // Заголовок для request_id
 		Generator: func() string {
@@ -93,3 +104,16 @@ func CustomRecoverMiddleware(logger *common.Logger) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// validRequestId - пустой или короткий идентификатор из видимых ASCII-символов
+func validRequestId(requestId string) bool {
+	if len(requestId) > MaxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] < '!' || requestId[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
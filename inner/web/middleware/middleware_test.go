@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -124,4 +125,33 @@ LOG_DEVELOP_MODE=true`
 		require.NoError(t, err, "expected no error decoding response")
 		assert.Equal(t, requestId, response.RequestID, "expected request ID in response to match header")
 	})
+
+	t.Run("should replace oversized or invalid client request ID", func(t *testing.T) {
+		app := fiber.New()
+		RegisterMiddleware(app, logger)
+		app.Get("/test", func(c *fiber.Ctx) error {
+			return c.SendString(c.Locals("request_id").(string))
+		})
+
+		var get = func(requestId string) string {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-Request-Id", requestId)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer closeBody(t, resp.Body)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			a.Equal(resp.Header.Get("X-Request-Id"), string(body))
+			return string(body)
+		}
+
+		a.Equal("trace-42", get("trace-42"))
+
+		var oversized = get(strings.Repeat("x", 1000))
+		a.NotEmpty(oversized)
+		a.LessOrEqual(len(oversized), MaxRequestIdLength)
+		a.NotContains(oversized, "xxx")
+
+		a.NotEqual("bad id", get("bad id"))
+	})
 }
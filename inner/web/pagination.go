@@ -0,0 +1,142 @@
+package web
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/pagination"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Параметры keyset-выдачи в строке запроса: ?after=...&limit=...&sort=-name&withTotal=true
+const (
+	AfterParam      = "after"
+	BeforeParam     = "before"
+	LimitParam      = "limit"
+	SortParam       = "sort"
+	WithTotalParam  = "withTotal"
+	TextFilterParam = "textFilter"
+)
+
+// DefaultSort - сортировка keyset-выдачи по умолчанию
+const DefaultSort = "id"
+
+// Keyset - параметры keyset-выдачи с проверенными курсорами
+type Keyset struct {
+	Resource       string
+	Limit          int
+	Sort           string
+	TextFilter     string
+	IncludeDeleted bool
+	WithTotal      bool
+	After          *pagination.Key
+	Before         *pagination.Key
+}
+
+// KeysetRequested - запрошена ли keyset-выдача: без её параметров списки отдаются целиком, как раньше
+func KeysetRequested(c *fiber.Ctx) bool {
+	for _, param := range []string{AfterParam, BeforeParam, LimitParam, SortParam, WithTotalParam} {
+		if c.Query(param) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKeyset - разобрать параметры keyset-выдачи ресурса resource. Курсор принимается, только если он
+// выпущен для того же ресурса с теми же сортировкой и фильтрами; ошибка - некорректный запрос (400)
+func (s *Server) ParseKeyset(c *fiber.Ctx, resource string) (Keyset, error) {
+	var keyset = Keyset{
+		Resource:       resource,
+		Limit:          pagination.DefaultLimit,
+		Sort:           c.Query(SortParam, DefaultSort),
+		TextFilter:     strings.TrimSpace(c.Query(TextFilterParam)),
+		IncludeDeleted: IncludeDeleted(c),
+		WithTotal:      c.QueryBool(WithTotalParam, false),
+	}
+	if value := c.Query(LimitParam); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > pagination.MaxLimit {
+			return Keyset{}, fmt.Errorf("limit must be between 1 and %d", pagination.MaxLimit)
+		}
+		keyset.Limit = limit
+	}
+
+	var after, before = c.Query(AfterParam), c.Query(BeforeParam)
+	if after != "" && before != "" {
+		return Keyset{}, errors.New("after and before cannot be used together")
+	}
+	var err error
+	if after != "" {
+		keyset.After, err = s.decodeCursor(after, keyset)
+	}
+	if before != "" {
+		keyset.Before, err = s.decodeCursor(before, keyset)
+	}
+	if err != nil {
+		return Keyset{}, err
+	}
+	return keyset, nil
+}
+
+// KeysetLinks - ссылки на следующую и предыдущую страницы с теми же параметрами (пусто - страницы нет)
+func (s *Server) KeysetLinks(c *fiber.Ctx, keyset Keyset, next, prev *pagination.Key) (string, string) {
+	return s.keysetLink(c, keyset, AfterParam, next), s.keysetLink(c, keyset, BeforeParam, prev)
+}
+
+func (s *Server) keysetLink(c *fiber.Ctx, keyset Keyset, param string, key *pagination.Key) string {
+	if key == nil {
+		return ""
+	}
+	var query = url.Values{}
+	query.Set(LimitParam, strconv.Itoa(keyset.Limit))
+	query.Set(SortParam, keyset.Sort)
+	if keyset.TextFilter != "" {
+		query.Set(TextFilterParam, keyset.TextFilter)
+	}
+	if keyset.IncludeDeleted {
+		query.Set(IncludeDeletedParam, "true")
+	}
+	if keyset.WithTotal {
+		query.Set(WithTotalParam, "true")
+	}
+	query.Set(param, s.Cursors.Encode(pagination.Cursor{
+		Key:      *key,
+		Resource: keyset.Resource,
+		Sort:     keyset.Sort,
+		Filter:   keyset.TextFilter,
+		Deleted:  keyset.IncludeDeleted,
+	}))
+	return c.Path() + "?" + query.Encode()
+}
+
+func (s *Server) decodeCursor(token string, keyset Keyset) (*pagination.Key, error) {
+	cursor, err := s.Cursors.Decode(token)
+	if err != nil {
+		return nil, err
+	}
+	// includeDeleted проверяется по строке запроса (RequireWhen), поэтому курсор не может его подменить
+	if cursor.Resource != keyset.Resource ||
+		cursor.Sort != keyset.Sort ||
+		cursor.Filter != keyset.TextFilter ||
+		cursor.Deleted != keyset.IncludeDeleted {
+		return nil, errors.New("cursor does not match sort and filter parameters of the request")
+	}
+	return &cursor.Key, nil
+}
+
+// newCursorSigner - подпись курсоров секретом из конфигурации, а без него - случайным ключом процесса
+func newCursorSigner(cfg config.Config, logger *common.Logger) *pagination.Signer {
+	if cfg.PageCursorSecret != "" {
+		return pagination.NewSigner([]byte(cfg.PageCursorSecret))
+	}
+	logger.Warn("PAGE_CURSOR_SECRET is not set: page cursors are signed with a random key and expire on restart")
+	var secret = make([]byte, 32)
+	_, _ = rand.Read(secret) // crypto/rand.Read не возвращает ошибок
+	return pagination.NewSigner(secret)
+}
@@ -1,53 +1,156 @@
 package web
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger" // swagger middleware
 	_ "idm/docs"
 	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/http"
+	"idm/inner/pagination"
 	"idm/inner/web/middleware"
 )
 
 const (
-	APIPrefix     = "/api"
-	APIVersion    = "/v1"
-	EmployeesPath = "/employees"
-	RolesPath     = "/roles"
-	InternalPath  = "/internal"
-	SwaggerURL    = "/swagger/*" // URL для доступа к swagger
+	APIPrefix          = "/api"
+	APIVersion         = "/v1"
+	EmployeesPath      = "/employees"
+	RolesPath          = "/roles"
+	PermissionsPath    = "/permissions"
+	AuthPath           = "/auth"
+	AuditPath          = "/audit"
+	OrgUnitsPath       = "/orgunits"
+	GroupsPath         = "/groups"
+	AccessRequestsPath = "/access-requests"
+	SodRulesPath       = "/sod-rules"
+	CertificationsPath = "/certifications"
+	ProvisioningPath   = "/provisioning"
+	WebhooksPath       = "/webhooks"
+	AuthTokenPath      = "/token"  // публичный: выдача токенов
+	AuthRevokePath     = "/revoke" // публичный: отзыв refresh-токена
+	WellKnownPath      = "/.well-known"
+	InternalPath       = "/internal"
+	ScimPath           = "/scim/v2"   // SCIM 2.0 для провижининга из внешних систем, вне "/api/v1"
+	SwaggerURL         = "/swagger/*" // URL для доступа к swagger
 )
 
+// MaxRequestBodySize - наибольший размер тела запроса, в том числе файла импорта (multipart/form-data или
+// тело целиком): тело читается в память до вызова обработчика, больший запрос отклоняется со статусом 413
+const MaxRequestBodySize = 4 * 1024 * 1024
+
 // Server - Cтруктура веб-сервера
 type Server struct {
-	App            *fiber.App
-	GroupSwagger   fiber.Router // Группа для swagger
-	GroupApiV1     fiber.Router
-	GroupEmployees fiber.Router
-	GroupRoles     fiber.Router
-	GroupInternal  fiber.Router // Группа непубличного API
+	App                 *fiber.App
+	GroupSwagger        fiber.Router // Группа для swagger
+	GroupApiV1          fiber.Router
+	GroupEmployees      fiber.Router
+	GroupRoles          fiber.Router
+	GroupPermissions    fiber.Router
+	GroupAuth           fiber.Router
+	GroupAudit          fiber.Router
+	GroupOrgUnits       fiber.Router
+	GroupGroups         fiber.Router
+	GroupAccessRequests fiber.Router
+	GroupSodRules       fiber.Router
+	GroupCertifications fiber.Router
+	GroupProvisioning   fiber.Router
+	GroupWebhooks       fiber.Router
+	GroupWellKnown      fiber.Router // Группа публичных метаданных (JWKS)
+	GroupInternal       fiber.Router // Группа непубличного API
+	GroupInternalAudit  fiber.Router // Группа "/internal/audit", доступная только с валидным JWT
+	GroupScim           fiber.Router // Группа SCIM 2.0 "/scim/v2"
+	// Authorizer - проверка разрешений для маршрутов, зарегистрированных с Require (задаётся в main)
+	Authorizer *middleware.Authorizer
+	// AuthorizationDisabled - явно отключить проверку разрешений Require (только unit-тесты контроллеров)
+	AuthorizationDisabled bool
+	// Cursors - подпись курсоров keyset-пагинации списков
+	Cursors *pagination.Signer
 }
 
 // NewServer - функция-конструктор
-func NewServer(logger *common.Logger) *Server {
+func NewServer(cfg config.Config, logger *common.Logger) *Server {
 	// создаём новый web-сервер
-	app := fiber.New()
+	app := newApp()
 
 	// регистрация middleware, передаем logger
 	middleware.RegisterMiddleware(app, logger)
 
+	// ключи проверки JWT для защиты "/api/v1"
+	jwtKeys, err := middleware.NewJwtKeys(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("jwt config error: %v", err))
+	}
+	// выдача и отзыв токенов доступны без токена
+	jwtAuth := middleware.SkipPaths(
+		middleware.JwtAuthMiddleware(jwtKeys, logger),
+		APIPrefix+APIVersion+AuthPath+AuthTokenPath,
+		APIPrefix+APIVersion+AuthPath+AuthRevokePath,
+	)
+
 	groupSwagger := app.Group(SwaggerURL, swagger.HandlerDefault) // создаём группу "/swagger/"
 	groupInternal := app.Group(InternalPath)                      // Группа непубличного API "/internal"
+	groupWellKnown := app.Group(WellKnownPath)                    // создаём группу "/.well-known"
 	groupApi := app.Group(APIPrefix)                              // создаём группу "/api" - Group is used for Routes
-	groupApiV1 := groupApi.Group(APIVersion)                      // создаём подгруппу "api/v1"
+	groupApiV1 := groupApi.Group(APIVersion, jwtAuth)             // создаём подгруппу "api/v1", доступную только с валидным JWT
 	groupEmployees := groupApiV1.Group(EmployeesPath)             // создаём подгруппу "/employees"
 	groupRoles := groupApiV1.Group(RolesPath)                     // создаём подгруппу "/roles"
+	groupPermissions := groupApiV1.Group(PermissionsPath)         // создаём подгруппу "/permissions"
+	groupAuth := groupApiV1.Group(AuthPath)                       // создаём подгруппу "/auth"
+	groupAudit := groupApiV1.Group(AuditPath)                     // создаём подгруппу "/audit"
+	groupOrgUnits := groupApiV1.Group(OrgUnitsPath)               // создаём подгруппу "/orgunits"
+	groupGroups := groupApiV1.Group(GroupsPath)                   // создаём подгруппу "/groups"
+	groupAccessRequests := groupApiV1.Group(AccessRequestsPath)   // создаём подгруппу "/access-requests"
+	groupSodRules := groupApiV1.Group(SodRulesPath)               // создаём подгруппу "/sod-rules"
+	groupCertifications := groupApiV1.Group(CertificationsPath)   // создаём подгруппу "/certifications"
+	groupProvisioning := groupApiV1.Group(ProvisioningPath)       // создаём подгруппу "/provisioning"
+	groupWebhooks := groupApiV1.Group(WebhooksPath)               // создаём подгруппу "/webhooks"
+	groupScim := app.Group(ScimPath, jwtAuth)                     // создаём группу "/scim/v2", доступную только с валидным JWT
+	groupInternalAudit := groupInternal.Group(AuditPath, jwtAuth) // создаём подгруппу "/internal/audit", доступную только с валидным JWT
 
 	return &Server{
-		App:            app,
-		GroupSwagger:   groupSwagger,
-		GroupApiV1:     groupApiV1,
-		GroupEmployees: groupEmployees,
-		GroupRoles:     groupRoles,
-		GroupInternal:  groupInternal,
+		App:                 app,
+		GroupSwagger:        groupSwagger,
+		GroupApiV1:          groupApiV1,
+		GroupEmployees:      groupEmployees,
+		GroupRoles:          groupRoles,
+		GroupPermissions:    groupPermissions,
+		GroupAuth:           groupAuth,
+		GroupAudit:          groupAudit,
+		GroupOrgUnits:       groupOrgUnits,
+		GroupGroups:         groupGroups,
+		GroupAccessRequests: groupAccessRequests,
+		GroupSodRules:       groupSodRules,
+		GroupCertifications: groupCertifications,
+		GroupProvisioning:   groupProvisioning,
+		GroupWebhooks:       groupWebhooks,
+		GroupWellKnown:      groupWellKnown,
+		GroupInternal:       groupInternal,
+		GroupInternalAudit:  groupInternalAudit,
+		GroupScim:           groupScim,
+		Cursors:             newCursorSigner(cfg, logger),
+	}
+}
+
+// newApp - fiber с ограничением размера тела запроса MaxRequestBodySize
+func newApp() *fiber.App {
+	return fiber.New(fiber.Config{
+		BodyLimit:    MaxRequestBodySize,
+		ErrorHandler: errorHandler,
+	})
+}
+
+// errorHandler - ошибки, не обработанные в обработчиках: превышение MaxRequestBodySize возвращается
+// в общем формате ответа с понятным сообщением, остальные - как в fiber по умолчанию
+func errorHandler(ctx *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusRequestEntityTooLarge {
+		return http.ErrResponse(
+			ctx,
+			fiber.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Request body exceeds the limit of %d MB", MaxRequestBodySize>>20),
+		)
 	}
+	return fiber.DefaultErrorHandler(ctx, err)
 }
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_BodyLimit(t *testing.T) {
+	app := newApp()
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/too-large", func(c *fiber.Ctx) error {
+		return fiber.ErrRequestEntityTooLarge
+	})
+
+	t.Run("body within limit reaches handler", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("POST", "/", bytes.NewReader(make([]byte, MaxRequestBodySize))))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("body over limit is rejected before handler", func(t *testing.T) {
+		// app.Test возвращает ошибку чтения запроса, ответ 413 формирует errorHandler
+		_, err := app.Test(httptest.NewRequest("POST", "/", bytes.NewReader(make([]byte, MaxRequestBodySize+1))))
+		assert.ErrorContains(t, err, "body size exceeds the given limit")
+	})
+
+	t.Run("too large error is returned as 413 with message", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/too-large", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		var body http.Response
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.False(t, body.Success)
+		assert.Equal(t, "Request body exceeds the limit of 4 MB", body.Message)
+	})
+
+	t.Run("other fiber errors keep default handling", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/missing", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,432 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/domain"
+	"idm/inner/http"
+	"idm/inner/web"
+	"strconv"
+)
+
+const (
+	internalServerError = "Internal server error"
+	invalidIDFormat     = "Invalid ID format"
+	invalidRequestBody  = "Invalid request body"
+)
+
+// Controller (transport layer):
+type Controller struct {
+	server         *web.Server
+	webhookService Svc
+	logger         *common.Logger
+}
+
+// Svc - интерфейс сервиса Service class
+type Svc interface {
+	FindAll(ctx context.Context) ([]WebhookResponse, error)
+	FindById(ctx context.Context, id int64) (WebhookResponse, error)
+	Create(ctx context.Context, request CreateRequest) (WebhookResponse, error)
+	Update(ctx context.Context, id int64, request UpdateRequest) (WebhookResponse, error)
+	DeleteById(ctx context.Context, id int64) (WebhookResponse, error)
+	FindDeliveries(ctx context.Context, request FindDeliveriesRequest) ([]DeliveryResponse, error)
+	FindAttempts(ctx context.Context, request DeliveryRequest) ([]AttemptResponse, error)
+	Redeliver(ctx context.Context, request DeliveryRequest) (DeliveryResponse, error)
+}
+
+// NewController - функция-конструктор
+func NewController(
+	server *web.Server,
+	webhookService Svc,
+	logger *common.Logger,
+) *Controller {
+	return &Controller{
+		server:         server,
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes - функция для регистрации маршрутов
+func (c *Controller) RegisterRoutes() {
+	// полный маршрут получится "/api/v1/webhooks"
+	c.server.GroupWebhooks.Get("/", c.server.Require(web.PermWebhooksRead), c.FindAll)
+	c.server.GroupWebhooks.Post("/", c.server.Require(web.PermWebhooksWrite), c.Create)
+	c.server.GroupWebhooks.Get("/:id", c.server.Require(web.PermWebhooksRead), c.FindById)
+	c.server.GroupWebhooks.Put("/:id", c.server.Require(web.PermWebhooksWrite), c.Update)
+	c.server.GroupWebhooks.Delete("/:id", c.server.Require(web.PermWebhooksWrite), c.DeleteById)
+	c.server.GroupWebhooks.Get("/:id/deliveries", c.server.Require(web.PermWebhooksRead), c.FindDeliveries)
+	c.server.GroupWebhooks.Get(
+		"/:id/deliveries/:deliveryId/attempts",
+		c.server.Require(web.PermWebhooksRead),
+		c.FindAttempts,
+	)
+	c.server.GroupWebhooks.Post(
+		"/:id/deliveries/:deliveryId/redeliver",
+		c.server.Require(web.PermWebhooksWrite),
+		c.Redeliver,
+	)
+}
+
+// -- функции-хендлеры, которые будут вызываться при POST\GET... запросе по маршруту "/api/v1/webhooks" --//
+
+// FindAll   	 godoc
+// @Description  Find all webhook subscriptions
+// @Summary		 get all webhooks
+// @Tags 		 webhook
+// @Accept  	 json
+// @Produce 	 json
+// @Success 	 200  {array}  		webhook.WebhookResponse	"Webhooks"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /webhooks		[get]
+func (c *Controller) FindAll(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	response, err := c.webhookService.FindAll(appContext)
+	if err != nil {
+		c.logger.Error(
+			"When the find for All Webhooks ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindById 	 godoc
+// @Description  Find by ID webhook subscription
+// @Summary 	 find by ID webhook
+// @Tags 		 webhook
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   path      	int  true  				"Webhook ID"
+// @Success 	 200  {object}  	webhook.WebhookResponse	"Webhook"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /webhooks/{id} 	[get]
+func (c *Controller) FindById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	webhookID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.webhookService.FindById(appContext, webhookID)
+	if err != nil {
+		c.logger.Error(
+			"When the get Webhook ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", webhookID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Create 		 godoc
+// @Summary      subscribe to domain events
+// @Description  Subscribe URL to domain events of employees, roles and role assignments; empty eventTypes
+// @Description  subscribes to all events. Each event is sent as POST with headers X-Idm-Event, X-Idm-Delivery,
+// @Description  X-Idm-Timestamp and X-Idm-Signature = sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+// @Tags 		 webhook
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 request body 	webhook.CreateRequest true "Webhook details"
+// @Success 	 201  {object}  webhook.WebhookResponse	"Webhook"
+// @Failure      400  {object}  http.Response			"Bad request"
+// @Failure      500  {object}  http.Response			"Bad request"
+// @Router 		 /webhooks 	[post]
+func (c *Controller) Create(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	var request CreateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an Create Webhook ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.webhookService.Create(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the create Webhook ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.CreatedResponse(ctx, response)
+}
+
+// Update 		 godoc
+// @Summary      update webhook
+// @Description  Update URL, event types, secret and enabled flag of webhook; empty secret keeps the current one.
+// @Description  Deliveries already queued are sent to the new URL signed with the new secret
+// @Tags 		 webhook
+// @Accept 		 json
+// @Produce 	 json
+// @Param        id   		path      	int  					true  	"Webhook ID"
+// @Param 		 request 	body 		webhook.UpdateRequest 	true 	"Webhook details"
+// @Success 	 200  {object}  	webhook.WebhookResponse	"Webhook"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /webhooks/{id} 	[put]
+func (c *Controller) Update(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	webhookID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	var request UpdateRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		c.logger.Error(
+			"When the body parse an Update Webhook ended with an error:",
+			zap.Error(err),
+			zap.String("request_id", requestId),
+		)
+
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidRequestBody)
+	}
+
+	response, err := c.webhookService.Update(appContext, webhookID, request)
+	if err != nil {
+		c.logger.Error(
+			"When the update Webhook ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", webhookID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// DeleteById  	 godoc
+// @Description  Delete webhook with its deliveries and attempt history
+// @Summary		 delete webhook by ID
+// @Tags 		 webhook
+// @Accept  	 json
+// @Produce 	 json
+// @Param        id   path     		int  					true	"Webhook ID"
+// @Success 	 200  {object} 		webhook.WebhookResponse	"Deleted webhook"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object} 	 	http.Response			"Bad request"
+// @Router 		 /webhooks/{id}	[delete]
+func (c *Controller) DeleteById(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	webhookID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.webhookService.DeleteById(appContext, webhookID)
+	if err != nil {
+		c.logger.Error(
+			"When the delete Webhook ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", webhookID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindDeliveries godoc
+// @Description  Find deliveries of domain events to webhook, newest first
+// @Summary 	 find webhook deliveries
+// @Tags 		 webhook
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   		path      	int  	true  	"Webhook ID"
+// @Param 		 status   	query      	string  false  	"pending, delivering, delivered or dead"
+// @Success 	 200  {array}  		webhook.DeliveryResponse	"Webhook deliveries"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /webhooks/{id}/deliveries 	[get]
+func (c *Controller) FindDeliveries(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	webhookID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.webhookService.FindDeliveries(appContext, FindDeliveriesRequest{
+		WebhookId: webhookID,
+		Status:    ctx.Query("status"),
+	})
+	if err != nil {
+		c.logger.Error(
+			"When the find Webhook deliveries ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", webhookID),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// FindAttempts godoc
+// @Description  Find delivery attempts with response status, error and duration, oldest first
+// @Summary 	 find webhook delivery attempts
+// @Tags 		 webhook
+// @Accept  	 json
+// @Produce 	 json
+// @Param 		 id   			path      	int  	true  	"Webhook ID"
+// @Param 		 deliveryId   	path      	int  	true  	"Delivery ID"
+// @Success 	 200  {array}  		webhook.AttemptResponse	"Delivery attempts"
+// @Failure      400  {object}  	http.Response			"Bad request"
+// @Failure      404  {object}  	http.Response			"Not found"
+// @Failure      500  {object}  	http.Response			"Bad request"
+// @Router 		 /webhooks/{id}/deliveries/{deliveryId}/attempts 	[get]
+func (c *Controller) FindAttempts(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	request, err := c.parseDeliveryParams(ctx, requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.webhookService.FindAttempts(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the find Webhook delivery attempts ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", request.WebhookId),
+			zap.Int64("delivery_id", request.DeliveryId),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+// Redeliver 	 godoc
+// @Summary      redeliver webhook event
+// @Description  Send event to subscriber again right now, including delivered and dead deliveries. Attempt counter
+// @Description  is reset: if this attempt fails too, the delivery is retried on schedule again
+// @Tags 		 webhook
+// @Accept 		 json
+// @Produce 	 json
+// @Param 		 id   			path      	int  	true  	"Webhook ID"
+// @Param 		 deliveryId   	path      	int  	true  	"Delivery ID"
+// @Success 	 200  {object}  	webhook.DeliveryResponse	"Delivery with attempt outcome"
+// @Failure      400  {object}  	http.Response				"Bad request"
+// @Failure      404  {object}  	http.Response				"Not found"
+// @Failure      409  {object}  	http.Response				"Webhook is disabled or delivery is in progress"
+// @Failure      500  {object}  	http.Response				"Bad request"
+// @Router 		 /webhooks/{id}/deliveries/{deliveryId}/redeliver 	[post]
+func (c *Controller) Redeliver(ctx *fiber.Ctx) error {
+	appContext := ctx.UserContext() // получаем контекст приложения из запроса (задаем ранее в App main())
+
+	requestId := ctx.Locals("request_id").(string) // Получаем request_id благодаря middleware func
+
+	request, err := c.parseDeliveryParams(ctx, requestId)
+	if err != nil {
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, invalidIDFormat)
+	}
+
+	response, err := c.webhookService.Redeliver(appContext, request)
+	if err != nil {
+		c.logger.Error(
+			"When the redeliver Webhook event ended with an error:",
+			zap.Error(err),
+			zap.Int64("id", request.WebhookId),
+			zap.Int64("delivery_id", request.DeliveryId),
+			zap.String("request_id", requestId),
+		)
+
+		return c.errResponse(ctx, err)
+	}
+
+	return http.OkResponse(ctx, response)
+}
+
+func (c *Controller) parseDeliveryParams(ctx *fiber.Ctx, requestId string) (DeliveryRequest, error) {
+	webhookID, err := c.parseIdParam(ctx, "id", requestId)
+	if err != nil {
+		return DeliveryRequest{}, err
+	}
+	deliveryID, err := c.parseIdParam(ctx, "deliveryId", requestId)
+	if err != nil {
+		return DeliveryRequest{}, err
+	}
+
+	return DeliveryRequest{WebhookId: webhookID, DeliveryId: deliveryID}, nil
+}
+
+func (c *Controller) parseIdParam(ctx *fiber.Ctx, name string, requestId string) (int64, error) {
+	value := ctx.Params(name)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.logger.Error(
+			"When the parse an path param ended with an error:",
+			zap.Error(err),
+			zap.String(name, value),
+			zap.String("request_id", requestId),
+		)
+	}
+
+	return id, err
+}
+
+// errResponse - маппинг доменных ошибок в HTTP-статусы
+func (c *Controller) errResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.As(err, &domain.RequestValidationError{}):
+		return http.ErrResponse(ctx, fiber.StatusBadRequest, err.Error())
+	case errors.As(err, &domain.NotFoundError{}):
+		return http.ErrResponse(ctx, fiber.StatusNotFound, err.Error())
+	case errors.As(err, &domain.ConflictError{}):
+		return http.ErrResponse(ctx, fiber.StatusConflict, err.Error())
+	default:
+		return http.ErrResponse(ctx, fiber.StatusInternalServerError, internalServerError)
+	}
+}
@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"idm/inner/common"
+	"idm/inner/config"
+	"idm/inner/domain"
+	"idm/inner/web"
+	"idm/inner/web/middleware"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWebhook_Controller(t *testing.T) {
+	// Подготовка тестового .env файла
+	envContent := `DB_DRIVER_NAME=postgres
+DB_DSN=host=127.0.0.1 user=test dbname=idm_tests
+APP_NAME=TestIdm
+APP_VERSION=1.0.0
+LOG_LEVEL=DEBUG
+LOG_DEVELOP_MODE=true`
+	envFile := ".test.env"
+	err := os.WriteFile(envFile, []byte(envContent), 0644)
+	require.NoError(t, err)
+	defer func() {
+		err := os.Remove(envFile)
+		if err != nil {
+			t.Errorf("failed to remove test env file: %v", err)
+		}
+	}()
+
+	cfg := config.GetConfig(envFile)
+	var logger = common.NewLogger(cfg) // Создаем логгер
+	appContext := context.Background() // Создаем контекст
+
+	app := fiber.New()
+	middleware.RegisterMiddleware(app, logger) // middleware func
+	mockService := new(MockWebhookService)
+
+	server := &web.Server{
+		App:                   app,
+		GroupWebhooks:         app.Group("/api/v1/webhooks"),
+		AuthorizationDisabled: true, // разрешения проверяются в тестах middleware
+	}
+	ctrl := NewController(server, mockService, logger)
+	ctrl.RegisterRoutes()
+
+	type result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+
+	t.Run("should create webhook without returning secret", func(t *testing.T) {
+		request := CreateRequest{Url: "https://hooks.example.com/idm", EventTypes: []string{"RoleAssigned"},
+			Secret: "0123456789abcdef"}
+		webhook := WebhookResponse{Id: 1, Url: request.Url, EventTypes: request.EventTypes, HasSecret: true, Enabled: true}
+		mockService.On("Create", appContext, request).Return(webhook, nil).Once()
+
+		body := `{"url": "https://hooks.example.com/idm", "eventTypes": ["RoleAssigned"], "secret": "0123456789abcdef"}`
+		req := httptest.NewRequest("POST", "/api/v1/webhooks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var got result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.NotContains(t, string(got.Data), "0123456789abcdef")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on unknown event type", func(t *testing.T) {
+		request := UpdateRequest{Url: "https://hooks.example.com/idm", EventTypes: []string{"RoleExploded"}, Enabled: true}
+		invalid := domain.RequestValidationError{Message: `unknown event type "RoleExploded"`}
+		mockService.On("Update", appContext, int64(2), request).Return(WebhookResponse{}, invalid).Once()
+
+		body := `{"url": "https://hooks.example.com/idm", "eventTypes": ["RoleExploded"], "enabled": true}`
+		req := httptest.NewRequest("PUT", "/api/v1/webhooks/2", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return dead deliveries", func(t *testing.T) {
+		deliveries := []DeliveryResponse{{Id: 10, WebhookId: 1, EventId: 42, EventType: "RoleAssigned", Status: DeliveryDead,
+			Attempts: MaxAttempts, LastStatusCode: 500, LastError: "subscriber responded with status 500"}}
+		request := FindDeliveriesRequest{WebhookId: 1, Status: DeliveryDead}
+		mockService.On("FindDeliveries", appContext, request).Return(deliveries, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/webhooks/1/deliveries?status=dead", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var got result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		var data []DeliveryResponse
+		require.NoError(t, json.Unmarshal(got.Data, &data))
+		assert.Equal(t, deliveries[0].LastError, data[0].LastError)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return delivery attempts", func(t *testing.T) {
+		attempts := []AttemptResponse{{Id: 1, StatusCode: 503, Error: "subscriber responded with status 503"}, {Id: 2, StatusCode: 200}}
+		request := DeliveryRequest{WebhookId: 1, DeliveryId: 10}
+		mockService.On("FindAttempts", appContext, request).Return(attempts, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/webhooks/1/deliveries/10/attempts", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should redeliver event", func(t *testing.T) {
+		request := DeliveryRequest{WebhookId: 1, DeliveryId: 10}
+		delivery := DeliveryResponse{Id: 10, WebhookId: 1, Status: DeliveryDelivered, Attempts: 1, LastStatusCode: 204}
+		mockService.On("Redeliver", appContext, request).Return(delivery, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/webhooks/1/deliveries/10/redeliver", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 409 when redelivering to disabled webhook", func(t *testing.T) {
+		request := DeliveryRequest{WebhookId: 3, DeliveryId: 10}
+		disabled := domain.ConflictError{Message: "webhook 3 is disabled"}
+		mockService.On("Redeliver", appContext, request).Return(DeliveryResponse{}, disabled).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/webhooks/3/deliveries/10/redeliver", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 404 when webhook not found", func(t *testing.T) {
+		notFound := domain.NotFoundError{Message: "webhook with id 9 not found"}
+		mockService.On("DeleteById", appContext, int64(9)).Return(WebhookResponse{}, notFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/webhooks/9", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return 400 on invalid delivery id", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/webhooks/1/deliveries/abc/attempts", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// Состояния доставки события подписчику
+const (
+	DeliveryPending    = "pending"    // ждёт отправки или повторной попытки
+	DeliveryDelivering = "delivering" // захвачена доставкой до next_attempt_at
+	DeliveryDelivered  = "delivered"  // подписчик ответил 2xx
+	DeliveryDead       = "dead"       // попытки исчерпаны, повторить можно только вручную
+)
+
+// Заголовки запроса к подписчику
+const (
+	HeaderEvent     = "X-Idm-Event"     // тип доменного события
+	HeaderDelivery  = "X-Idm-Delivery"  // id доставки: повтор той же доставки приходит с тем же id
+	HeaderTimestamp = "X-Idm-Timestamp" // время отправки, unix-секунды; входит в подпись
+	HeaderSignature = "X-Idm-Signature" // sha256=<hex HMAC-SHA256(secret, timestamp + "." + body)>
+)
+
+// WebhookEntity - подписка на доменные события; пустой EventTypes - все события
+type WebhookEntity struct {
+	Id         int64          `db:"id"`
+	Url        string         `db:"url"`
+	EventTypes pq.StringArray `db:"event_types"`
+	Secret     string         `db:"secret"`
+	Enabled    bool           `db:"enabled"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+// WebhookResponse model info
+// @Description Webhook subscription; the signing secret is never returned
+type WebhookResponse struct {
+	Id         int64     `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	HasSecret  bool      `json:"hasSecret"`
+	Enabled    bool      `json:"enabled"`
+	CreateAt   time.Time `json:"createAt"`
+	UpdateAt   time.Time `json:"updateAt"`
+}
+
+func (e *WebhookEntity) ToResponse() WebhookResponse {
+	return WebhookResponse{
+		Id:         e.Id,
+		Url:        e.Url,
+		EventTypes: eventTypesOrEmpty(e.EventTypes),
+		HasSecret:  e.Secret != "",
+		Enabled:    e.Enabled,
+		CreateAt:   e.CreatedAt,
+		UpdateAt:   e.UpdatedAt,
+	}
+}
+
+// WebhookSnapshot - снимок подписки для аудита, без секрета
+type WebhookSnapshot struct {
+	Url        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Enabled    bool     `json:"enabled"`
+}
+
+func (e *WebhookEntity) ToSnapshot() WebhookSnapshot {
+	return WebhookSnapshot{
+		Url:        e.Url,
+		EventTypes: eventTypesOrEmpty(e.EventTypes),
+		Enabled:    e.Enabled,
+	}
+}
+
+// DeliveryEntity - доставка доменного события подписчику. Payload - тело запроса, которое подписывается:
+// повторные попытки отправляют его без изменений
+type DeliveryEntity struct {
+	Id             int64      `db:"id"`
+	WebhookId      int64      `db:"webhook_id"`
+	EventId        int64      `db:"event_id"`
+	EventType      string     `db:"event_type"`
+	Payload        []byte     `db:"payload"`
+	Status         string     `db:"status"`
+	Attempts       int        `db:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at"`
+	LastStatusCode int        `db:"last_status_code"`
+	LastError      string     `db:"last_error"`
+	DeliveredAt    *time.Time `db:"delivered_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// DeliveryResponse model info
+// @Description Delivery of domain event to webhook subscriber
+type DeliveryResponse struct {
+	Id             int64      `json:"id"`
+	WebhookId      int64      `json:"webhookId"`
+	EventId        int64      `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	LastStatusCode int        `json:"lastStatusCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	CreateAt       time.Time  `json:"createAt"`
+	UpdateAt       time.Time  `json:"updateAt"`
+}
+
+func (e *DeliveryEntity) ToResponse() DeliveryResponse {
+	return DeliveryResponse{
+		Id:             e.Id,
+		WebhookId:      e.WebhookId,
+		EventId:        e.EventId,
+		EventType:      e.EventType,
+		Status:         e.Status,
+		Attempts:       e.Attempts,
+		NextAttemptAt:  e.NextAttemptAt,
+		LastStatusCode: e.LastStatusCode,
+		LastError:      e.LastError,
+		DeliveredAt:    e.DeliveredAt,
+		CreateAt:       e.CreatedAt,
+		UpdateAt:       e.UpdatedAt,
+	}
+}
+
+// AttemptEntity - попытка доставки; StatusCode 0 - ответа не было
+type AttemptEntity struct {
+	Id          int64     `db:"id"`
+	DeliveryId  int64     `db:"delivery_id"`
+	AttemptedAt time.Time `db:"attempted_at"`
+	StatusCode  int       `db:"status_code"`
+	Error       string    `db:"error"`
+	DurationMs  int64     `db:"duration_ms"`
+}
+
+// AttemptResponse model info
+// @Description Attempt to deliver domain event to webhook subscriber
+type AttemptResponse struct {
+	Id          int64     `json:"id"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"durationMs"`
+}
+
+func (e *AttemptEntity) ToResponse() AttemptResponse {
+	return AttemptResponse{
+		Id:          e.Id,
+		AttemptedAt: e.AttemptedAt,
+		StatusCode:  e.StatusCode,
+		Error:       e.Error,
+		DurationMs:  e.DurationMs,
+	}
+}
+
+// CreateRequest model info
+// @Description Request to subscribe to domain events; empty eventTypes subscribes to all events
+type CreateRequest struct {
+	Url        string   `json:"url" validate:"required,http_url,max=500"`
+	EventTypes []string `json:"eventTypes" validate:"max=50"`
+	Secret     string   `json:"secret" validate:"required,min=16,max=255"`
+	Enabled    *bool    `json:"enabled"` // по умолчанию true
+}
+
+func (req *CreateRequest) ToEntity() WebhookEntity {
+	return WebhookEntity{
+		Url:        req.Url,
+		EventTypes: eventTypesOrEmpty(req.EventTypes),
+		Secret:     req.Secret,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+}
+
+// UpdateRequest model info
+// @Description Request to update webhook subscription; empty secret keeps the current one
+type UpdateRequest struct {
+	Id         int64    `json:"-" validate:"required,min=1"`
+	Url        string   `json:"url" validate:"required,http_url,max=500"`
+	EventTypes []string `json:"eventTypes" validate:"max=50"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	Enabled    bool     `json:"enabled"`
+}
+
+// FindDeliveriesRequest - отбор доставок подписки по состоянию
+type FindDeliveriesRequest struct {
+	WebhookId int64  `validate:"required,min=1"`
+	Status    string `validate:"omitempty,oneof=pending delivering delivered dead"`
+}
+
+// DeliveryRequest - доставка подписки
+type DeliveryRequest struct {
+	WebhookId  int64 `validate:"required,min=1"`
+	DeliveryId int64 `validate:"required,min=1"`
+}
+
+type FindByIDRequest struct {
+	ID int64 `validate:"required,min=1"`
+}
+
+// eventTypesOrEmpty - пустой список вместо nil: в JSON [], в базе '{}'
+func eventTypesOrEmpty(eventTypes []string) []string {
+	if eventTypes == nil {
+		return []string{}
+	}
+	return eventTypes
+}
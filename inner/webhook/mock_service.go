@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) FindAll(ctx context.Context) ([]WebhookResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]WebhookResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) FindById(ctx context.Context, id int64) (WebhookResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(WebhookResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) Create(ctx context.Context, request CreateRequest) (WebhookResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(WebhookResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) Update(ctx context.Context, id int64, request UpdateRequest) (WebhookResponse, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(WebhookResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteById(ctx context.Context, id int64) (WebhookResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(WebhookResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) FindDeliveries(ctx context.Context, request FindDeliveriesRequest) ([]DeliveryResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).([]DeliveryResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) FindAttempts(ctx context.Context, request DeliveryRequest) ([]AttemptResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).([]AttemptResponse), args.Error(1)
+}
+
+func (m *MockWebhookService) Redeliver(ctx context.Context, request DeliveryRequest) (DeliveryResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(DeliveryResponse), args.Error(1)
+}
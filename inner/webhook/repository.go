@@ -0,0 +1,286 @@
+package webhook
+
+import (
+	"cmp"
+	"context"
+	"github.com/jmoiron/sqlx"
+	"idm/inner/audit"
+	"idm/inner/database"
+	"slices"
+	"time"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository - функция-конструктор
+func NewRepository(database *sqlx.DB) *Repository {
+	return &Repository{db: database}
+}
+
+// FindAll - все подписки в порядке создания
+func (r *Repository) FindAll(ctx context.Context) (webhooks []WebhookEntity, err error) {
+	err = r.db.SelectContext(ctx, &webhooks, "SELECT * FROM webhooks ORDER BY id")
+
+	return webhooks, err
+}
+
+// FindById - найти подписку по id
+func (r *Repository) FindById(ctx context.Context, id int64) (webhook WebhookEntity, err error) {
+	err = r.db.GetContext(ctx, &webhook, "SELECT * FROM webhooks WHERE id = $1", id)
+
+	return webhook, err
+}
+
+// Create - добавить подписку. Она получает события, записанные в outbox после её создания
+func (r *Repository) Create(ctx context.Context, entity *WebhookEntity) (created WebhookEntity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(
+			ctx,
+			&created,
+			`INSERT INTO webhooks (url, event_types, secret, enabled, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+			RETURNING *`,
+			entity.Url, entity.EventTypes, entity.Secret, entity.Enabled,
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityWebhook,
+			EntityId:   created.Id,
+			After:      created.ToSnapshot(),
+		})
+	})
+
+	return created, err
+}
+
+// Update - изменить подписку; пустой секрет сохраняет текущий
+func (r *Repository) Update(ctx context.Context, entity *WebhookEntity) (updated WebhookEntity, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var before WebhookEntity
+		err := tx.GetContext(ctx, &before, "SELECT * FROM webhooks WHERE id = $1 FOR UPDATE", entity.Id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.GetContext(
+			ctx,
+			&updated,
+			`UPDATE webhooks
+			SET url = $2, event_types = $3, secret = COALESCE(NULLIF($4, ''), secret), enabled = $5, updated_at = NOW()
+			WHERE id = $1
+			RETURNING *`,
+			entity.Id, entity.Url, entity.EventTypes, entity.Secret, entity.Enabled,
+		)
+		if err != nil {
+			return err
+		}
+
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityWebhook,
+			EntityId:   updated.Id,
+			Before:     before.ToSnapshot(),
+			After:      updated.ToSnapshot(),
+		})
+	})
+
+	return updated, err
+}
+
+// Delete - удалить подписку вместе с доставками и историей попыток, возвращает false если её не было
+func (r *Repository) Delete(ctx context.Context, id int64) (isDeleted bool, err error) {
+	err = database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var deleted WebhookEntity
+		err := tx.GetContext(ctx, &deleted, "DELETE FROM webhooks WHERE id = $1 RETURNING *", id)
+		if err != nil {
+			return err
+		}
+
+		isDeleted = true
+		return audit.InsertEventTx(ctx, tx, audit.Event{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityWebhook,
+			EntityId:   id,
+			Before:     deleted.ToSnapshot(),
+		})
+	})
+
+	return isDeleted && err == nil, err
+}
+
+// Enqueue - поставить событие в очередь доставки всех включённых подписок на его тип.
+// Повтор того же события из outbox не создаёт вторую доставку. Возвращает число новых доставок
+func (r *Repository) Enqueue(ctx context.Context, eventId int64, eventType string, payload []byte) (int64, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		SELECT id, $1, $2, $3, 'pending', NOW(), NOW(), NOW()
+		FROM webhooks
+		WHERE enabled AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		eventId, eventType, string(payload),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ClaimDue - захватить до limit доставок включённых подписок, срок попытки которых наступил, до now + lease.
+// Доставку, захват которой истёк (отправка прервалась), можно захватить снова
+func (r *Repository) ClaimDue(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) (deliveries []DeliveryEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&deliveries,
+		`UPDATE webhook_deliveries d
+		SET status = 'delivering', next_attempt_at = $2, updated_at = $1
+		FROM (
+			SELECT wd.id FROM webhook_deliveries wd
+			JOIN webhooks w ON w.id = wd.webhook_id AND w.enabled
+			WHERE wd.status IN ('pending', 'delivering') AND wd.next_attempt_at <= $1
+			ORDER BY wd.id
+			LIMIT $3
+			FOR UPDATE OF wd SKIP LOCKED
+		) due
+		WHERE d.id = due.id
+		RETURNING d.*`,
+		now, now.Add(lease), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// UPDATE ... RETURNING не сохраняет порядок: события доставляются в порядке записи
+	slices.SortFunc(deliveries, func(a, b DeliveryEntity) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+
+	return deliveries, nil
+}
+
+// ClaimDelivery - захватить одну доставку подписки до now + lease для повторной отправки вне очереди.
+// Доставку, которую сейчас отправляет планировщик (захват не истёк), захватить нельзя - sql.ErrNoRows
+func (r *Repository) ClaimDelivery(
+	ctx context.Context,
+	webhookId int64,
+	deliveryId int64,
+	now time.Time,
+	lease time.Duration,
+) (delivery DeliveryEntity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&delivery,
+		`UPDATE webhook_deliveries
+		SET status = 'delivering', next_attempt_at = $4, updated_at = $3
+		WHERE id = $1 AND webhook_id = $2 AND (status <> 'delivering' OR next_attempt_at <= $3)
+		RETURNING *`,
+		deliveryId, webhookId, now, now.Add(lease),
+	)
+
+	return delivery, err
+}
+
+// FinishDelivery - записать попытку и её исход: состояние доставки, число попыток, время следующей и ошибку
+func (r *Repository) FinishDelivery(ctx context.Context, delivery *DeliveryEntity, attempt *AttemptEntity) error {
+	return database.WithTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+			VALUES ($1, $2, $3, $4, $5)`,
+			delivery.Id, attempt.AttemptedAt, attempt.StatusCode, truncate(attempt.Error), attempt.DurationMs,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.GetContext(
+			ctx,
+			delivery,
+			`UPDATE webhook_deliveries
+			SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6,
+				delivered_at = $7, updated_at = NOW()
+			WHERE id = $1
+			RETURNING *`,
+			delivery.Id, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode,
+			truncate(delivery.LastError), delivery.DeliveredAt,
+		)
+	})
+}
+
+// FindDelivery - доставка подписки
+func (r *Repository) FindDelivery(ctx context.Context, webhookId int64, deliveryId int64) (delivery DeliveryEntity, err error) {
+	err = r.db.GetContext(
+		ctx,
+		&delivery,
+		"SELECT * FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2",
+		deliveryId, webhookId,
+	)
+
+	return delivery, err
+}
+
+// FindDeliveries - доставки подписки, новые первыми; пустой status - все
+func (r *Repository) FindDeliveries(
+	ctx context.Context,
+	webhookId int64,
+	status string,
+) (deliveries []DeliveryEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&deliveries,
+		`SELECT * FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC`,
+		webhookId, status,
+	)
+
+	return deliveries, err
+}
+
+// FindAttempts - история попыток доставки по порядку
+func (r *Repository) FindAttempts(ctx context.Context, deliveryId int64) (attempts []AttemptEntity, err error) {
+	err = r.db.SelectContext(
+		ctx,
+		&attempts,
+		"SELECT * FROM webhook_attempts WHERE delivery_id = $1 ORDER BY attempted_at, id",
+		deliveryId,
+	)
+
+	return attempts, err
+}
+
+// PurgeDelivered - удалить доставленные раньше before события вместе с историей попыток.
+// Возвращает число удалённых доставок
+func (r *Repository) PurgeDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM webhook_deliveries WHERE status = 'delivered' AND delivered_at < $1",
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// maxErrorLength - длина колонок last_error и error
+const maxErrorLength = 1000
+
+// truncate - текст ошибки в пределах колонки, по границе символа
+func truncate(message string) string {
+	var runes = []rune(message)
+	if len(runes) <= maxErrorLength {
+		return message
+	}
+	return string(runes[:maxErrorLength])
+}
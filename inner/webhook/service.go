@@ -0,0 +1,397 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"idm/inner/domain"
+	"idm/inner/outbox"
+	"idm/inner/retry"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Доставка событий подписчикам
+const (
+	MaxAttempts    = 10               // после стольких неудачных попыток доставка переходит в dead
+	retryBaseDelay = 10 * time.Second // пауза перед второй попыткой, далее удваивается
+	retryMaxDelay  = time.Hour
+	deliveryBatch  = 100             // доставок за один запуск
+	deliveryLease  = 5 * time.Minute // на столько запуск захватывает доставку
+)
+
+type Service struct {
+	repo      Repo
+	validator Validator
+	client    *http.Client
+}
+
+type Repo interface {
+	FindAll(ctx context.Context) ([]WebhookEntity, error)
+	FindById(ctx context.Context, id int64) (WebhookEntity, error)
+	Create(ctx context.Context, entity *WebhookEntity) (WebhookEntity, error)
+	Update(ctx context.Context, entity *WebhookEntity) (WebhookEntity, error)
+	Delete(ctx context.Context, id int64) (bool, error)
+	Enqueue(ctx context.Context, eventId int64, eventType string, payload []byte) (int64, error)
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]DeliveryEntity, error)
+	ClaimDelivery(ctx context.Context, webhookId int64, deliveryId int64, now time.Time, lease time.Duration) (DeliveryEntity, error)
+	FinishDelivery(ctx context.Context, delivery *DeliveryEntity, attempt *AttemptEntity) error
+	FindDelivery(ctx context.Context, webhookId int64, deliveryId int64) (DeliveryEntity, error)
+	FindDeliveries(ctx context.Context, webhookId int64, status string) ([]DeliveryEntity, error)
+	FindAttempts(ctx context.Context, deliveryId int64) ([]AttemptEntity, error)
+	PurgeDelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Validator interface {
+	Validate(request any) error
+}
+
+// NewService - функция-конструктор; client выполняет запросы к подписчикам и должен иметь таймаут
+func NewService(repo Repo, validator Validator, client *http.Client) *Service {
+	return &Service{
+		repo:      repo,
+		validator: validator,
+		client:    client,
+	}
+}
+
+// FindAll - все подписки
+func (svc *Service) FindAll(ctx context.Context) ([]WebhookResponse, error) {
+	entities, err := svc.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhooks: %w", err)
+	}
+
+	responses := make([]WebhookResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (svc *Service) FindById(ctx context.Context, id int64) (WebhookResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return WebhookResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+
+	entity, err := svc.findWebhook(ctx, id)
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+
+	return entity.ToResponse(), nil
+}
+
+// Create - подписаться на доменные события. Подписка получает события, записанные после её создания
+func (svc *Service) Create(ctx context.Context, request CreateRequest) (WebhookResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return WebhookResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if err := checkEventTypes(request.EventTypes); err != nil {
+		return WebhookResponse{}, err
+	}
+
+	entity := request.ToEntity()
+	created, err := svc.repo.Create(ctx, &entity)
+	if err != nil {
+		return WebhookResponse{}, fmt.Errorf("error creating webhook: %w", err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+// Update - изменить адрес, типы событий, секрет и включение подписки. Доставки, уже поставленные
+// в очередь, отправляются на новый адрес с новым секретом
+func (svc *Service) Update(ctx context.Context, id int64, request UpdateRequest) (WebhookResponse, error) {
+	request.Id = id
+	if err := svc.validator.Validate(request); err != nil {
+		return WebhookResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	if err := checkEventTypes(request.EventTypes); err != nil {
+		return WebhookResponse{}, err
+	}
+	if _, err := svc.findWebhook(ctx, id); err != nil {
+		return WebhookResponse{}, err
+	}
+
+	var entity = WebhookEntity{
+		Id:         id,
+		Url:        request.Url,
+		EventTypes: eventTypesOrEmpty(request.EventTypes),
+		Secret:     request.Secret,
+		Enabled:    request.Enabled,
+	}
+	updated, err := svc.repo.Update(ctx, &entity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WebhookResponse{}, webhookNotFound(id)
+	}
+	if err != nil {
+		return WebhookResponse{}, fmt.Errorf("error updating webhook %d: %w", id, err)
+	}
+
+	return updated.ToResponse(), nil
+}
+
+// DeleteById - удалить подписку вместе с неотправленными доставками и историей
+func (svc *Service) DeleteById(ctx context.Context, id int64) (WebhookResponse, error) {
+	if err := svc.validator.Validate(FindByIDRequest{ID: id}); err != nil {
+		return WebhookResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	webhook, err := svc.findWebhook(ctx, id)
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+
+	isDeleted, err := svc.repo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WebhookResponse{}, webhookNotFound(id)
+	}
+	if err != nil {
+		return WebhookResponse{}, fmt.Errorf("error deleting webhook %d: %w", id, err)
+	}
+	if !isDeleted {
+		return WebhookResponse{}, webhookNotFound(id)
+	}
+
+	return webhook.ToResponse(), nil
+}
+
+// FindDeliveries - доставки подписки, новые первыми
+func (svc *Service) FindDeliveries(ctx context.Context, request FindDeliveriesRequest) ([]DeliveryResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+	if _, err := svc.findWebhook(ctx, request.WebhookId); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindDeliveries(ctx, request.WebhookId, request.Status)
+	if err != nil {
+		return nil, fmt.Errorf("error finding deliveries of webhook %d: %w", request.WebhookId, err)
+	}
+
+	responses := make([]DeliveryResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// FindAttempts - история попыток доставки
+func (svc *Service) FindAttempts(ctx context.Context, request DeliveryRequest) ([]AttemptResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return nil, domain.RequestValidationError{Message: err.Error()}
+	}
+	if _, err := svc.findDelivery(ctx, request); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.FindAttempts(ctx, request.DeliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding attempts of webhook delivery %d: %w", request.DeliveryId, err)
+	}
+
+	responses := make([]AttemptResponse, 0, len(entities))
+	for _, entity := range entities {
+		responses = append(responses, entity.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// Redeliver - отправить событие повторно сейчас, в том числе доставленное или dead. Счётчик попыток
+// сбрасывается: если и эта попытка неудачна, доставка снова повторяется по расписанию до MaxAttempts.
+// Доставка, которую сейчас отправляет планировщик, - ConflictError
+func (svc *Service) Redeliver(ctx context.Context, request DeliveryRequest) (DeliveryResponse, error) {
+	if err := svc.validator.Validate(request); err != nil {
+		return DeliveryResponse{}, domain.RequestValidationError{Message: err.Error()}
+	}
+	webhook, err := svc.findWebhook(ctx, request.WebhookId)
+	if err != nil {
+		return DeliveryResponse{}, err
+	}
+	if !webhook.Enabled {
+		return DeliveryResponse{}, domain.ConflictError{Message: fmt.Sprintf("webhook %d is disabled", webhook.Id)}
+	}
+	if _, err = svc.findDelivery(ctx, request); err != nil {
+		return DeliveryResponse{}, err
+	}
+
+	// захват как у планировщика: иначе событие могло бы уйти дважды параллельно с Deliver
+	delivery, err := svc.repo.ClaimDelivery(ctx, request.WebhookId, request.DeliveryId, time.Now(), deliveryLease)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DeliveryResponse{}, domain.ConflictError{
+			Message: fmt.Sprintf("delivery with id %d is being delivered right now", request.DeliveryId),
+		}
+	}
+	if err != nil {
+		return DeliveryResponse{}, fmt.Errorf("error claiming webhook delivery %d: %w", request.DeliveryId, err)
+	}
+
+	delivery.Attempts = 0
+	if err := svc.attempt(ctx, webhook, &delivery); err != nil {
+		return DeliveryResponse{}, err
+	}
+
+	return delivery.ToResponse(), nil
+}
+
+// Deliver - отправить события, срок доставки которых наступил. Неудачная попытка откладывается с удвоением
+// паузы; после MaxAttempts попыток доставка переходит в dead. Возвращает доставки с исходом попытки
+func (svc *Service) Deliver(ctx context.Context) ([]DeliveryResponse, error) {
+	deliveries, err := svc.repo.ClaimDue(ctx, time.Now(), deliveryLease, deliveryBatch)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+
+	var webhooks = map[int64]WebhookEntity{}
+	var results = make([]DeliveryResponse, 0, len(deliveries))
+	var errs []error
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			// незавершённые доставки отправит следующий запуск, когда истечёт захват
+			break
+		}
+
+		webhook, ok := webhooks[delivery.WebhookId]
+		if !ok {
+			if webhook, err = svc.findWebhook(ctx, delivery.WebhookId); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			webhooks[webhook.Id] = webhook
+		}
+
+		if err := svc.attempt(ctx, webhook, &delivery); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, delivery.ToResponse())
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// PurgeDelivered - удалить доставки, выполненные раньше, чем retention назад
+func (svc *Service) PurgeDelivered(ctx context.Context, retention time.Duration) (int64, error) {
+	return svc.repo.PurgeDelivered(ctx, time.Now().Add(-retention))
+}
+
+// attempt - отправить событие подписчику и записать попытку и её исход
+func (svc *Service) attempt(ctx context.Context, webhook WebhookEntity, delivery *DeliveryEntity) error {
+	var started = time.Now()
+	statusCode, sendErr := svc.send(ctx, webhook, delivery)
+	var attempt = AttemptEntity{
+		AttemptedAt: started,
+		StatusCode:  statusCode,
+		DurationMs:  time.Since(started).Milliseconds(),
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	switch {
+	case sendErr == nil:
+		var now = time.Now()
+		delivery.Status = DeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = DeliveryDead
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.Status = DeliveryPending
+		delivery.NextAttemptAt = time.Now().Add(retry.Delay(delivery.Attempts, retryBaseDelay, retryMaxDelay))
+		delivery.LastError = sendErr.Error()
+	}
+	attempt.Error = delivery.LastError
+
+	if err := svc.repo.FinishDelivery(ctx, delivery, &attempt); err != nil {
+		return fmt.Errorf("error finishing webhook delivery %d: %w", delivery.Id, err)
+	}
+	return nil
+}
+
+// send - POST события на адрес подписки с подписью. Успех - ответ 2xx; возвращает статус ответа, 0 - ответа не было
+func (svc *Service) send(ctx context.Context, webhook WebhookEntity, delivery *DeliveryEntity) (int, error) {
+	var timestamp = time.Now().Unix()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := svc.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = response.Body.Close() }()
+	// тело ответа не нужно, но дочитывается, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return response.StatusCode, fmt.Errorf("subscriber responded with status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// Sign - подпись запроса к подписчику: "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Подписчик проверяет её тем же секретом и отклоняет запросы со старым timestamp, чтобы их нельзя было повторить
+func Sign(secret string, timestamp int64, body []byte) string {
+	var mac = hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkEventTypes - подписаться можно только на известные типы доменных событий
+func checkEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !slices.Contains(outbox.EventTypes, eventType) {
+			return domain.RequestValidationError{Message: fmt.Sprintf("unknown event type %q", eventType)}
+		}
+	}
+	return nil
+}
+
+func (svc *Service) findWebhook(ctx context.Context, id int64) (WebhookEntity, error) {
+	webhook, err := svc.repo.FindById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WebhookEntity{}, webhookNotFound(id)
+	}
+	if err != nil {
+		return WebhookEntity{}, fmt.Errorf("error finding webhook %d: %w", id, err)
+	}
+	return webhook, nil
+}
+
+func (svc *Service) findDelivery(ctx context.Context, request DeliveryRequest) (DeliveryEntity, error) {
+	delivery, err := svc.repo.FindDelivery(ctx, request.WebhookId, request.DeliveryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DeliveryEntity{}, domain.NotFoundError{
+			Message: fmt.Sprintf("delivery with id %d of webhook %d not found", request.DeliveryId, request.WebhookId),
+		}
+	}
+	if err != nil {
+		return DeliveryEntity{}, fmt.Errorf("error finding webhook delivery %d: %w", request.DeliveryId, err)
+	}
+	return delivery, nil
+}
+
+func webhookNotFound(id int64) error {
+	return domain.NotFoundError{Message: fmt.Sprintf("webhook with id %d not found", id)}
+}
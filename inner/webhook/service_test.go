@@ -0,0 +1,444 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"idm/inner/domain"
+	"idm/inner/outbox"
+	"idm/inner/retry"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type MockRepo struct {
+	mock.Mock
+}
+
+func (m *MockRepo) FindAll(ctx context.Context) ([]WebhookEntity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]WebhookEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindById(ctx context.Context, id int64) (WebhookEntity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(WebhookEntity), args.Error(1)
+}
+
+func (m *MockRepo) Create(ctx context.Context, entity *WebhookEntity) (WebhookEntity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(WebhookEntity), args.Error(1)
+}
+
+func (m *MockRepo) Update(ctx context.Context, entity *WebhookEntity) (WebhookEntity, error) {
+	args := m.Called(ctx, entity)
+	return args.Get(0).(WebhookEntity), args.Error(1)
+}
+
+func (m *MockRepo) Delete(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) Enqueue(ctx context.Context, eventId int64, eventType string, payload []byte) (int64, error) {
+	args := m.Called(ctx, eventId, eventType, payload)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]DeliveryEntity, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]DeliveryEntity), args.Error(1)
+}
+
+func (m *MockRepo) ClaimDelivery(
+	ctx context.Context,
+	webhookId int64,
+	deliveryId int64,
+	now time.Time,
+	lease time.Duration,
+) (DeliveryEntity, error) {
+	args := m.Called(ctx, webhookId, deliveryId, now, lease)
+	return args.Get(0).(DeliveryEntity), args.Error(1)
+}
+
+func (m *MockRepo) FinishDelivery(ctx context.Context, delivery *DeliveryEntity, attempt *AttemptEntity) error {
+	args := m.Called(ctx, delivery, attempt)
+	return args.Error(0)
+}
+
+func (m *MockRepo) FindDelivery(ctx context.Context, webhookId int64, deliveryId int64) (DeliveryEntity, error) {
+	args := m.Called(ctx, webhookId, deliveryId)
+	return args.Get(0).(DeliveryEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindDeliveries(ctx context.Context, webhookId int64, status string) ([]DeliveryEntity, error) {
+	args := m.Called(ctx, webhookId, status)
+	return args.Get(0).([]DeliveryEntity), args.Error(1)
+}
+
+func (m *MockRepo) FindAttempts(ctx context.Context, deliveryId int64) ([]AttemptEntity, error) {
+	args := m.Called(ctx, deliveryId)
+	return args.Get(0).([]AttemptEntity), args.Error(1)
+}
+
+func (m *MockRepo) PurgeDelivered(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// receiver - подписчик webhook, который проверяет подпись как это сделала бы внешняя система
+// и отвечает status (по умолчанию 204)
+type receiver struct {
+	*httptest.Server
+	secret   string
+	mu       sync.Mutex
+	status   int
+	received []receivedRequest
+}
+
+type receivedRequest struct {
+	event       string
+	delivery    string
+	body        []byte
+	signatureOk bool
+}
+
+func newReceiver(t *testing.T, secret string) *receiver {
+	var r = &receiver{secret: secret, status: http.StatusNoContent}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body, _ = io.ReadAll(req.Body)
+		timestamp, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+		var signatureOk = err == nil &&
+			req.Header.Get(HeaderSignature) == Sign(r.secret, timestamp, body) &&
+			time.Since(time.Unix(timestamp, 0)) < time.Minute
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.received = append(r.received, receivedRequest{
+			event:       req.Header.Get(HeaderEvent),
+			delivery:    req.Header.Get(HeaderDelivery),
+			body:        body,
+			signatureOk: signatureOk,
+		})
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) respond(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) requests() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.received...)
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	const secret = "0123456789abcdef"
+	var payload = []byte(`{"id":42,"type":"RoleAssigned","payload":{"employeeId":7,"roleId":5}}`)
+
+	var setup = func(t *testing.T) (*MockRepo, *Service, *receiver) {
+		repo := new(MockRepo)
+		hook := newReceiver(t, secret)
+		webhook := WebhookEntity{Id: 1, Url: hook.URL, Secret: secret, Enabled: true}
+		repo.On("FindById", ctx, int64(1)).Return(webhook, nil)
+		return repo, NewService(repo, new(MockValidator), hook.Client()), hook
+	}
+	var due = func(attempts int) DeliveryEntity {
+		return DeliveryEntity{Id: 100, WebhookId: 1, EventId: 42, EventType: outbox.RoleAssigned, Payload: payload,
+			Status: DeliveryDelivering, Attempts: attempts}
+	}
+
+	t.Run("should send signed event and mark it delivered", func(t *testing.T) {
+		repo, svc, hook := setup(t)
+		repo.On("ClaimDue", ctx, mock.Anything, deliveryLease, deliveryBatch).Return([]DeliveryEntity{due(0)}, nil).Once()
+		repo.On("FinishDelivery", ctx, mock.MatchedBy(func(d *DeliveryEntity) bool {
+			return d.Status == DeliveryDelivered && d.Attempts == 1 && d.LastStatusCode == http.StatusNoContent &&
+				d.DeliveredAt != nil && d.LastError == ""
+		}), mock.MatchedBy(func(attempt *AttemptEntity) bool {
+			return attempt.StatusCode == http.StatusNoContent && attempt.Error == ""
+		})).Return(nil).Once()
+
+		results, err := svc.Deliver(ctx)
+
+		a.Nil(err)
+		a.Len(results, 1)
+		a.Equal(DeliveryDelivered, results[0].Status)
+		var received = hook.requests()
+		require.Len(t, received, 1)
+		a.True(received[0].signatureOk)
+		a.Equal(outbox.RoleAssigned, received[0].event)
+		a.Equal("100", received[0].delivery)
+		a.JSONEq(string(payload), string(received[0].body))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should retry with backoff when subscriber fails", func(t *testing.T) {
+		repo, svc, hook := setup(t)
+		hook.respond(http.StatusServiceUnavailable)
+		repo.On("ClaimDue", ctx, mock.Anything, deliveryLease, deliveryBatch).Return([]DeliveryEntity{due(2)}, nil).Once()
+		repo.On("FinishDelivery", ctx, mock.MatchedBy(func(d *DeliveryEntity) bool {
+			var delay = time.Until(d.NextAttemptAt)
+			return d.Status == DeliveryPending && d.Attempts == 3 && d.LastStatusCode == http.StatusServiceUnavailable &&
+				delay > 39*time.Second && delay <= 40*time.Second &&
+				d.LastError == "subscriber responded with status 503"
+		}), mock.MatchedBy(func(attempt *AttemptEntity) bool {
+			return attempt.StatusCode == http.StatusServiceUnavailable && attempt.Error != ""
+		})).Return(nil).Once()
+
+		results, err := svc.Deliver(ctx)
+
+		a.Nil(err)
+		a.Equal(DeliveryPending, results[0].Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should move delivery to dead after last attempt", func(t *testing.T) {
+		repo, svc, hook := setup(t)
+		hook.respond(http.StatusInternalServerError)
+		repo.On("ClaimDue", ctx, mock.Anything, deliveryLease, deliveryBatch).
+			Return([]DeliveryEntity{due(MaxAttempts - 1)}, nil).Once()
+		repo.On("FinishDelivery", ctx, mock.MatchedBy(func(d *DeliveryEntity) bool {
+			return d.Status == DeliveryDead && d.Attempts == MaxAttempts
+		}), mock.Anything).Return(nil).Once()
+
+		results, err := svc.Deliver(ctx)
+
+		a.Nil(err)
+		a.Equal(DeliveryDead, results[0].Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should retry when subscriber is unreachable", func(t *testing.T) {
+		repo, svc, hook := setup(t)
+		hook.Close()
+		repo.On("ClaimDue", ctx, mock.Anything, deliveryLease, deliveryBatch).Return([]DeliveryEntity{due(0)}, nil).Once()
+		repo.On("FinishDelivery", ctx, mock.MatchedBy(func(d *DeliveryEntity) bool {
+			return d.Status == DeliveryPending && d.LastStatusCode == 0 && d.LastError != ""
+		}), mock.Anything).Return(nil).Once()
+
+		_, err := svc.Deliver(ctx)
+
+		a.Nil(err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return error when outcome is not saved", func(t *testing.T) {
+		repo, svc, _ := setup(t)
+		repo.On("ClaimDue", ctx, mock.Anything, deliveryLease, deliveryBatch).Return([]DeliveryEntity{due(0)}, nil).Once()
+		repo.On("FinishDelivery", ctx, mock.Anything, mock.Anything).Return(errors.New("connection reset")).Once()
+
+		results, err := svc.Deliver(ctx)
+
+		a.ErrorContains(err, "connection reset")
+		a.Empty(results)
+	})
+}
+
+func TestWebhookService_Redeliver(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	const secret = "0123456789abcdef"
+	var request = DeliveryRequest{WebhookId: 1, DeliveryId: 100}
+
+	t.Run("should send dead delivery again and reset attempts", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		hook := newReceiver(t, secret)
+		svc := NewService(repo, validator, hook.Client())
+		dead := DeliveryEntity{Id: 100, WebhookId: 1, EventId: 42, EventType: outbox.EmployeeCreated,
+			Payload: []byte(`{"id":42}`), Status: DeliveryDead, Attempts: MaxAttempts, LastStatusCode: 500}
+
+		validator.ExpectValidate(request, nil)
+		repo.On("FindById", ctx, int64(1)).Return(WebhookEntity{Id: 1, Url: hook.URL, Secret: secret, Enabled: true}, nil)
+		repo.On("FindDelivery", ctx, int64(1), int64(100)).Return(dead, nil)
+		claimed := dead
+		claimed.Status = DeliveryDelivering
+		repo.On("ClaimDelivery", ctx, int64(1), int64(100), mock.Anything, deliveryLease).Return(claimed, nil).Once()
+		repo.On("FinishDelivery", ctx, mock.MatchedBy(func(d *DeliveryEntity) bool {
+			return d.Status == DeliveryDelivered && d.Attempts == 1
+		}), mock.Anything).Return(nil).Once()
+
+		response, err := svc.Redeliver(ctx, request)
+
+		a.Nil(err)
+		a.Equal(DeliveryDelivered, response.Status)
+		a.Len(hook.requests(), 1)
+		a.True(hook.requests()[0].signatureOk)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return conflict when webhook is disabled", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		svc := NewService(repo, validator, http.DefaultClient)
+
+		validator.ExpectValidate(request, nil)
+		repo.On("FindById", ctx, int64(1)).Return(WebhookEntity{Id: 1, Enabled: false}, nil)
+
+		_, err := svc.Redeliver(ctx, request)
+
+		a.ErrorAs(err, &domain.ConflictError{})
+		repo.AssertNotCalled(t, "FindDelivery", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return conflict when delivery is being delivered", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		hook := newReceiver(t, secret)
+		svc := NewService(repo, validator, hook.Client())
+
+		validator.ExpectValidate(request, nil)
+		repo.On("FindById", ctx, int64(1)).Return(WebhookEntity{Id: 1, Url: hook.URL, Secret: secret, Enabled: true}, nil)
+		repo.On("FindDelivery", ctx, int64(1), int64(100)).Return(DeliveryEntity{Id: 100, WebhookId: 1, Status: DeliveryDelivering}, nil)
+		repo.On("ClaimDelivery", ctx, int64(1), int64(100), mock.Anything, deliveryLease).Return(DeliveryEntity{}, sql.ErrNoRows).Once()
+
+		_, err := svc.Redeliver(ctx, request)
+
+		a.ErrorAs(err, &domain.ConflictError{})
+		a.Empty(hook.requests())
+		repo.AssertNotCalled(t, "FinishDelivery", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found when delivery belongs to another webhook", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		svc := NewService(repo, validator, http.DefaultClient)
+
+		validator.ExpectValidate(request, nil)
+		repo.On("FindById", ctx, int64(1)).Return(WebhookEntity{Id: 1, Enabled: true}, nil)
+		repo.On("FindDelivery", ctx, int64(1), int64(100)).Return(DeliveryEntity{}, sql.ErrNoRows)
+
+		_, err := svc.Redeliver(ctx, request)
+
+		a.ErrorAs(err, &domain.NotFoundError{})
+	})
+}
+
+func TestWebhookService_Webhooks(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	t.Run("should create enabled webhook for all events by default", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		svc := NewService(repo, validator, http.DefaultClient)
+		request := CreateRequest{Url: "https://hooks.example.com/idm", Secret: "0123456789abcdef"}
+
+		validator.ExpectValidate(request, nil)
+		repo.On("Create", ctx, mock.MatchedBy(func(entity *WebhookEntity) bool {
+			return entity.Enabled && entity.EventTypes != nil && len(entity.EventTypes) == 0
+		})).Return(WebhookEntity{Id: 1, Url: request.Url, Secret: request.Secret, Enabled: true}, nil).Once()
+
+		response, err := svc.Create(ctx, request)
+
+		a.Nil(err)
+		a.True(response.HasSecret)
+		a.Equal([]string{}, response.EventTypes)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject unknown event type", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		svc := NewService(repo, validator, http.DefaultClient)
+		request := CreateRequest{Url: "https://hooks.example.com/idm", Secret: "0123456789abcdef",
+			EventTypes: []string{outbox.RoleAssigned, "RoleExploded"}}
+
+		validator.ExpectValidate(request, nil)
+
+		_, err := svc.Create(ctx, request)
+
+		a.ErrorAs(err, &domain.RequestValidationError{})
+		a.ErrorContains(err, "RoleExploded")
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should keep secret when updating without it", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		svc := NewService(repo, validator, http.DefaultClient)
+		request := UpdateRequest{Id: 1, Url: "https://hooks.example.com/v2", Enabled: true}
+
+		validator.ExpectValidate(request, nil)
+		repo.On("FindById", ctx, int64(1)).Return(WebhookEntity{Id: 1, Secret: "0123456789abcdef"}, nil)
+		repo.On("Update", ctx, mock.MatchedBy(func(entity *WebhookEntity) bool {
+			return entity.Secret == "" && entity.Url == request.Url
+		})).Return(WebhookEntity{Id: 1, Url: request.Url, Secret: "0123456789abcdef", Enabled: true}, nil).Once()
+
+		response, err := svc.Update(ctx, 1, request)
+
+		a.Nil(err)
+		a.True(response.HasSecret)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when deleting missing webhook", func(t *testing.T) {
+		repo := new(MockRepo)
+		validator := new(MockValidator)
+		svc := NewService(repo, validator, http.DefaultClient)
+
+		validator.ExpectValidate(FindByIDRequest{ID: 9}, nil)
+		repo.On("FindById", ctx, int64(9)).Return(WebhookEntity{}, sql.ErrNoRows)
+
+		_, err := svc.DeleteById(ctx, 9)
+
+		a.ErrorAs(err, &domain.NotFoundError{})
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSink_Publish(t *testing.T) {
+	var a = assert.New(t)
+	ctx := context.Background()
+
+	repo := new(MockRepo)
+	sink := NewSink(repo)
+	message := outbox.Message{Id: 42, Type: outbox.EmployeeCreated, AggregateType: "employee", AggregateId: 7,
+		Payload: json.RawMessage(`{"id":7}`)}
+
+	repo.On("Enqueue", ctx, int64(42), outbox.EmployeeCreated, mock.MatchedBy(func(payload []byte) bool {
+		var got outbox.Message
+		return json.Unmarshal(payload, &got) == nil && got.Id == 42 && string(got.Payload) == `{"id":7}`
+	})).Return(int64(2), nil).Once()
+
+	a.Nil(sink.Publish(ctx, message))
+	a.Equal("webhooks", sink.Name())
+	repo.AssertExpectations(t)
+}
+
+func TestSign(t *testing.T) {
+	var a = assert.New(t)
+
+	// значение, которое подписчик получит, посчитав HMAC-SHA256 от "1700000000.{}" ключом "secret"
+	a.Equal("sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163", Sign("secret", 1700000000, []byte("{}")))
+	a.NotEqual(Sign("secret", 1700000000, []byte("{}")), Sign("secret", 1700000001, []byte("{}")))
+	a.NotEqual(Sign("secret", 1700000000, []byte("{}")), Sign("other", 1700000000, []byte("{}")))
+}
+
+func TestRetryDelay(t *testing.T) {
+	var a = assert.New(t)
+
+	a.Equal(10*time.Second, retry.Delay(1, retryBaseDelay, retryMaxDelay))
+	a.Equal(40*time.Second, retry.Delay(3, retryBaseDelay, retryMaxDelay))
+	a.Equal(time.Hour, retry.Delay(MaxAttempts, retryBaseDelay, retryMaxDelay))
+	a.Equal(time.Hour, retry.Delay(1000, retryBaseDelay, retryMaxDelay))
+}
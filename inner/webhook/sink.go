@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"idm/inner/outbox"
+)
+
+// SinkRepo - постановка событий в очередь доставки подписчикам
+type SinkRepo interface {
+	Enqueue(ctx context.Context, eventId int64, eventType string, payload []byte) (int64, error)
+}
+
+// Sink - подписчик outbox: ставит событие в очередь доставки каждой подписке на его тип.
+// Сама отправка идёт отдельно (Service.Deliver), чтобы недоступный подписчик не задерживал outbox
+type Sink struct {
+	repo SinkRepo
+}
+
+// NewSink - функция-конструктор
+func NewSink(repo SinkRepo) *Sink {
+	return &Sink{repo: repo}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+// Publish - тело запроса к подписчикам - событие в представлении outbox.Message
+func (s *Sink) Publish(ctx context.Context, message outbox.Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshal event %d: %w", message.Id, err)
+	}
+	if _, err := s.repo.Enqueue(ctx, message.Id, message.Type, payload); err != nil {
+		return fmt.Errorf("error enqueue webhook deliveries of event %d: %w", message.Id, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.employee_roles (
+    employee_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT employee_roles_pk PRIMARY KEY (employee_id, role_id),
+    CONSTRAINT fk_employee_roles_employee FOREIGN KEY (employee_id) REFERENCES public.employees(id) ON DELETE CASCADE,
+    CONSTRAINT fk_employee_roles_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS employee_roles_role_id_idx ON public.employee_roles (role_id);
+
+-- Переносим существующие привязки roles.employee_id в таблицу связей
+INSERT INTO public.employee_roles(employee_id, role_id, created_at)
+SELECT employee_id, id, updated_at
+FROM public.roles
+WHERE employee_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+DROP INDEX IF EXISTS public.roles_employee_id_idx;
+ALTER TABLE public.roles DROP CONSTRAINT IF EXISTS fk_employee;
+ALTER TABLE public.roles DROP COLUMN IF EXISTS employee_id;
+
+COMMENT ON TABLE public.employee_roles IS 'Связь сотрудников и ролей (многие ко многим)';
+COMMENT ON COLUMN public.employee_roles.employee_id IS 'Ссылка на сотрудника (FK)';
+COMMENT ON COLUMN public.employee_roles.role_id IS 'Ссылка на роль (FK)';
+COMMENT ON COLUMN public.employee_roles.created_at IS 'Дата назначения роли';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.roles ADD COLUMN IF NOT EXISTS employee_id BIGINT DEFAULT NULL;
+ALTER TABLE public.roles
+    ADD CONSTRAINT fk_employee FOREIGN KEY (employee_id) REFERENCES public.employees(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS roles_employee_id_idx ON public.roles (employee_id);
+
+-- В старой схеме у роли может быть только один сотрудник - берём самое раннее назначение
+UPDATE public.roles r
+SET employee_id = er.employee_id
+FROM (SELECT DISTINCT ON (role_id) role_id, employee_id
+      FROM public.employee_roles
+      ORDER BY role_id, created_at) er
+WHERE r.id = er.role_id;
+
+DROP TABLE IF EXISTS public.employee_roles CASCADE;
+-- +goose StatementEnd
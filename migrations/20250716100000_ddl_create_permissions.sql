@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.permissions (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    name VARCHAR(155) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT permissions_name_unique UNIQUE (name)
+    );
+
+CREATE TABLE IF NOT EXISTS public.role_permissions (
+    role_id BIGINT NOT NULL,
+    permission_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT role_permissions_pk PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_id) REFERENCES public.permissions(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS role_permissions_permission_id_idx ON public.role_permissions (permission_id);
+
+COMMENT ON TABLE public.permissions IS 'Информация о разрешениях';
+COMMENT ON COLUMN public.permissions.id IS 'Уникальный идентификатор разрешения';
+COMMENT ON COLUMN public.permissions.name IS 'Наименование разрешения, например employees:read';
+COMMENT ON COLUMN public.permissions.description IS 'Описание разрешения';
+COMMENT ON COLUMN public.permissions.created_at IS 'Дата создания записи';
+COMMENT ON COLUMN public.permissions.updated_at IS 'Дата последнего обновления записи';
+
+COMMENT ON TABLE public.role_permissions IS 'Разрешения, выданные ролям';
+COMMENT ON COLUMN public.role_permissions.role_id IS 'Ссылка на роль (FK)';
+COMMENT ON COLUMN public.role_permissions.permission_id IS 'Ссылка на разрешение (FK)';
+COMMENT ON COLUMN public.role_permissions.created_at IS 'Дата выдачи разрешения';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.role_permissions CASCADE;
+DROP TABLE IF EXISTS public.permissions CASCADE;
+-- +goose StatementEnd
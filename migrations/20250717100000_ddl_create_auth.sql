@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.employee_credentials (
+    employee_id BIGINT PRIMARY KEY,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_employee_credentials_employee FOREIGN KEY (employee_id) REFERENCES public.employees(id) ON DELETE CASCADE
+    );
+
+CREATE TABLE IF NOT EXISTS public.oauth_clients (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    client_id VARCHAR(64) NOT NULL,
+    name VARCHAR(155) NOT NULL DEFAULT '',
+    secret_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT oauth_clients_client_id_unique UNIQUE (client_id)
+    );
+
+CREATE TABLE IF NOT EXISTS public.refresh_tokens (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    token_hash CHAR(64) NOT NULL,
+    family_id UUID NOT NULL,
+    employee_id BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT refresh_tokens_token_hash_unique UNIQUE (token_hash),
+    CONSTRAINT fk_refresh_tokens_employee FOREIGN KEY (employee_id) REFERENCES public.employees(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON public.refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_employee_id_idx ON public.refresh_tokens (employee_id);
+
+COMMENT ON TABLE public.employee_credentials IS 'Учётные данные сотрудников для выдачи токенов';
+COMMENT ON COLUMN public.employee_credentials.employee_id IS 'Ссылка на сотрудника (FK)';
+COMMENT ON COLUMN public.employee_credentials.password_hash IS 'bcrypt-хеш пароля';
+COMMENT ON COLUMN public.employee_credentials.created_at IS 'Дата создания записи';
+COMMENT ON COLUMN public.employee_credentials.updated_at IS 'Дата последней смены пароля';
+
+COMMENT ON TABLE public.oauth_clients IS 'Клиенты (сервисы) для grant_type=client_credentials';
+COMMENT ON COLUMN public.oauth_clients.id IS 'Уникальный идентификатор записи';
+COMMENT ON COLUMN public.oauth_clients.client_id IS 'Публичный идентификатор клиента';
+COMMENT ON COLUMN public.oauth_clients.name IS 'Наименование клиента';
+COMMENT ON COLUMN public.oauth_clients.secret_hash IS 'bcrypt-хеш секрета клиента';
+COMMENT ON COLUMN public.oauth_clients.created_at IS 'Дата создания записи';
+COMMENT ON COLUMN public.oauth_clients.updated_at IS 'Дата последнего обновления записи';
+
+COMMENT ON TABLE public.refresh_tokens IS 'Выданные refresh-токены (хранится только хеш)';
+COMMENT ON COLUMN public.refresh_tokens.id IS 'Уникальный идентификатор записи';
+COMMENT ON COLUMN public.refresh_tokens.token_hash IS 'SHA-256 хеш refresh-токена в hex';
+COMMENT ON COLUMN public.refresh_tokens.family_id IS 'Цепочка ротации: все токены, полученные из одного входа';
+COMMENT ON COLUMN public.refresh_tokens.employee_id IS 'Ссылка на сотрудника (FK)';
+COMMENT ON COLUMN public.refresh_tokens.expires_at IS 'Дата истечения токена';
+COMMENT ON COLUMN public.refresh_tokens.revoked_at IS 'Дата отзыва токена (NULL - действующий)';
+COMMENT ON COLUMN public.refresh_tokens.created_at IS 'Дата выдачи токена';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.refresh_tokens CASCADE;
+DROP TABLE IF EXISTS public.oauth_clients CASCADE;
+DROP TABLE IF EXISTS public.employee_credentials CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Разрешения, которые требуют маршруты API (см. inner/web/authorization.go)
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('employees:read', 'Просмотр сотрудников', NOW(), NOW()),
+       ('employees:write', 'Создание и изменение сотрудников', NOW(), NOW()),
+       ('employees:delete', 'Удаление сотрудников', NOW(), NOW()),
+       ('roles:read', 'Просмотр ролей', NOW(), NOW()),
+       ('roles:write', 'Создание и изменение ролей', NOW(), NOW()),
+       ('roles:delete', 'Удаление ролей', NOW(), NOW()),
+       ('roles:assign', 'Назначение и отзыв ролей сотрудников', NOW(), NOW()),
+       ('permissions:read', 'Просмотр разрешений', NOW(), NOW()),
+       ('permissions:write', 'Управление разрешениями и их выдачей ролям', NOW(), NOW()),
+       ('credentials:write', 'Установка паролей сотрудников', NOW(), NOW()),
+       ('clients:read', 'Просмотр клиентов client_credentials', NOW(), NOW()),
+       ('clients:write', 'Регистрация и удаление клиентов client_credentials', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+-- ADMIN получает все разрешения API, USER - только чтение
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON r.name = 'ADMIN'
+    OR (r.name = 'USER' AND p.name IN ('employees:read', 'roles:read'))
+WHERE p.name IN ('employees:read', 'employees:write', 'employees:delete',
+                 'roles:read', 'roles:write', 'roles:delete', 'roles:assign',
+                 'permissions:read', 'permissions:write', 'credentials:write',
+                 'clients:read', 'clients:write')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions
+WHERE name IN ('employees:read', 'employees:write', 'employees:delete',
+               'roles:read', 'roles:write', 'roles:delete', 'roles:assign',
+               'permissions:read', 'permissions:write', 'credentials:write',
+               'clients:read', 'clients:write');
+-- +goose StatementEnd
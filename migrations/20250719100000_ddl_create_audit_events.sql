@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.audit_events (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor VARCHAR(155) NOT NULL,
+    actor_type VARCHAR(55) NOT NULL DEFAULT '',
+    action VARCHAR(55) NOT NULL,
+    entity_type VARCHAR(55) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    before_data JSONB NULL,
+    after_data JSONB NULL,
+    request_id VARCHAR(155) NOT NULL DEFAULT ''
+    );
+
+CREATE INDEX IF NOT EXISTS audit_events_occurred_at_idx ON public.audit_events (occurred_at);
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON public.audit_events (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON public.audit_events (actor);
+
+COMMENT ON TABLE public.audit_events IS 'Журнал аудита изменений данных';
+COMMENT ON COLUMN public.audit_events.id IS 'Уникальный идентификатор события';
+COMMENT ON COLUMN public.audit_events.occurred_at IS 'Время изменения';
+COMMENT ON COLUMN public.audit_events.actor IS 'Кто выполнил изменение (subject токена или system)';
+COMMENT ON COLUMN public.audit_events.actor_type IS 'Тип исполнителя: employee, client или system';
+COMMENT ON COLUMN public.audit_events.action IS 'Действие, например create, update, delete';
+COMMENT ON COLUMN public.audit_events.entity_type IS 'Тип изменённой сущности, например employee';
+COMMENT ON COLUMN public.audit_events.entity_id IS 'Идентификатор изменённой сущности';
+COMMENT ON COLUMN public.audit_events.before_data IS 'Снимок сущности до изменения';
+COMMENT ON COLUMN public.audit_events.after_data IS 'Снимок сущности после изменения';
+COMMENT ON COLUMN public.audit_events.request_id IS 'Идентификатор HTTP-запроса (X-Request-ID)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.audit_events CASCADE;
+-- +goose StatementEnd
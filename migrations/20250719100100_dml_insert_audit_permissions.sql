@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('audit:read', 'Просмотр журнала аудита', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON p.name = 'audit:read'
+WHERE r.name = 'ADMIN'
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name = 'audit:read';
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.audit_chain (
+    seq BIGINT PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    actor VARCHAR(155) NOT NULL,
+    actor_type VARCHAR(55) NOT NULL DEFAULT '',
+    action VARCHAR(55) NOT NULL,
+    entity_type VARCHAR(55) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    before_data TEXT NULL,
+    after_data TEXT NULL,
+    request_id VARCHAR(155) NOT NULL DEFAULT '',
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    CONSTRAINT audit_chain_hash_unique UNIQUE (hash)
+    );
+
+-- Журнал только дополняется: изменение и удаление строк запрещены на уровне БД
+CREATE OR REPLACE FUNCTION public.audit_chain_forbid_modification() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_chain is append-only: % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_chain_append_only
+    BEFORE UPDATE OR DELETE ON public.audit_chain
+    FOR EACH ROW EXECUTE FUNCTION public.audit_chain_forbid_modification();
+
+COMMENT ON TABLE public.audit_chain IS 'Неизменяемая цепочка событий аудита: каждая запись хранит хеш предыдущей';
+COMMENT ON COLUMN public.audit_chain.seq IS 'Порядковый номер записи в цепочке, без пропусков начиная с 1';
+COMMENT ON COLUMN public.audit_chain.occurred_at IS 'Время изменения';
+COMMENT ON COLUMN public.audit_chain.actor IS 'Кто выполнил изменение (subject токена или system)';
+COMMENT ON COLUMN public.audit_chain.actor_type IS 'Тип исполнителя: employee, client или system';
+COMMENT ON COLUMN public.audit_chain.action IS 'Действие, например create, update, delete';
+COMMENT ON COLUMN public.audit_chain.entity_type IS 'Тип изменённой сущности, например employee';
+COMMENT ON COLUMN public.audit_chain.entity_id IS 'Идентификатор изменённой сущности';
+COMMENT ON COLUMN public.audit_chain.before_data IS 'Снимок сущности до изменения (JSON как есть, для воспроизводимости хеша)';
+COMMENT ON COLUMN public.audit_chain.after_data IS 'Снимок сущности после изменения (JSON как есть, для воспроизводимости хеша)';
+COMMENT ON COLUMN public.audit_chain.request_id IS 'Идентификатор HTTP-запроса (X-Request-ID)';
+COMMENT ON COLUMN public.audit_chain.prev_hash IS 'Хеш предыдущей записи (нули для первой)';
+COMMENT ON COLUMN public.audit_chain.hash IS 'SHA-256 содержимого записи вместе с prev_hash';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.audit_chain CASCADE;
+DROP FUNCTION IF EXISTS public.audit_chain_forbid_modification();
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.employees ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+ALTER TABLE public.roles ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+
+-- имя роли уникально только среди неудалённых, иначе удалённая роль блокирует создание новой с тем же именем
+ALTER TABLE public.roles DROP CONSTRAINT IF EXISTS roles_name_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS roles_name_active_unique ON public.roles (name) WHERE deleted_at IS NULL;
+
+-- для задачи очистки: поиск удалённых строк старше срока хранения
+CREATE INDEX IF NOT EXISTS employees_deleted_at_idx ON public.employees (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS roles_deleted_at_idx ON public.roles (deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMENT ON COLUMN public.employees.deleted_at IS 'Дата мягкого удаления (NULL - сотрудник не удалён)';
+COMMENT ON COLUMN public.roles.deleted_at IS 'Дата мягкого удаления (NULL - роль не удалена)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.roles WHERE deleted_at IS NOT NULL;
+DELETE FROM public.employees WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS public.roles_deleted_at_idx;
+DROP INDEX IF EXISTS public.employees_deleted_at_idx;
+DROP INDEX IF EXISTS public.roles_name_active_unique;
+ALTER TABLE public.roles ADD CONSTRAINT roles_name_unique UNIQUE (name);
+ALTER TABLE public.roles DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE public.employees DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.employees ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE public.employees ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ NULL;
+
+ALTER TABLE public.employees ADD CONSTRAINT employees_status_check
+    CHECK (status IN ('pre_hire', 'active', 'suspended', 'terminated'));
+
+CREATE INDEX IF NOT EXISTS employees_status_idx ON public.employees (status);
+
+COMMENT ON COLUMN public.employees.status IS 'Состояние жизненного цикла: pre_hire, active, suspended, terminated';
+COMMENT ON COLUMN public.employees.status_changed_at IS 'Дата последней смены состояния (NULL - не менялось с создания)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.employees_status_idx;
+ALTER TABLE public.employees DROP CONSTRAINT IF EXISTS employees_status_check;
+ALTER TABLE public.employees DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE public.employees DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('employees:lifecycle', 'Смена состояния сотрудника: активация, приостановка, увольнение, повторный приём', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON p.name = 'employees:lifecycle'
+WHERE r.name = 'ADMIN'
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name = 'employees:lifecycle';
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.employees
+    ADD COLUMN IF NOT EXISTS login VARCHAR(64) NULL,
+    ADD COLUMN IF NOT EXISTS email VARCHAR(255) NULL,
+    ADD COLUMN IF NOT EXISTS department VARCHAR(155) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS title VARCHAR(155) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS manager_id BIGINT NULL,
+    ADD COLUMN IF NOT EXISTS start_date DATE NULL,
+    ADD COLUMN IF NOT EXISTS end_date DATE NULL,
+    ADD COLUMN IF NOT EXISTS phone VARCHAR(32) NOT NULL DEFAULT '';
+
+ALTER TABLE public.employees
+    ADD CONSTRAINT fk_employees_manager FOREIGN KEY (manager_id) REFERENCES public.employees(id) ON DELETE SET NULL,
+    ADD CONSTRAINT employees_manager_not_self CHECK (manager_id IS NULL OR manager_id <> id),
+    ADD CONSTRAINT employees_employment_dates CHECK (end_date IS NULL OR start_date IS NULL OR end_date >= start_date);
+
+-- login и email уникальны без учёта регистра, в том числе среди мягко удалённых (не переиспользуются до очистки)
+CREATE UNIQUE INDEX IF NOT EXISTS employees_login_unique ON public.employees (lower(login));
+CREATE UNIQUE INDEX IF NOT EXISTS employees_email_unique ON public.employees (lower(email));
+CREATE INDEX IF NOT EXISTS employees_manager_id_idx ON public.employees (manager_id);
+
+COMMENT ON COLUMN public.employees.login IS 'Логин сотрудника (уникален без учёта регистра)';
+COMMENT ON COLUMN public.employees.email IS 'Рабочий email сотрудника (уникален без учёта регистра)';
+COMMENT ON COLUMN public.employees.department IS 'Подразделение';
+COMMENT ON COLUMN public.employees.title IS 'Должность';
+COMMENT ON COLUMN public.employees.manager_id IS 'Руководитель (id сотрудника)';
+COMMENT ON COLUMN public.employees.start_date IS 'Дата начала работы';
+COMMENT ON COLUMN public.employees.end_date IS 'Дата окончания работы';
+COMMENT ON COLUMN public.employees.phone IS 'Телефон';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.employees_manager_id_idx;
+DROP INDEX IF EXISTS public.employees_email_unique;
+DROP INDEX IF EXISTS public.employees_login_unique;
+ALTER TABLE public.employees
+    DROP CONSTRAINT IF EXISTS employees_employment_dates,
+    DROP CONSTRAINT IF EXISTS employees_manager_not_self,
+    DROP CONSTRAINT IF EXISTS fk_employees_manager;
+ALTER TABLE public.employees
+    DROP COLUMN IF EXISTS phone,
+    DROP COLUMN IF EXISTS end_date,
+    DROP COLUMN IF EXISTS start_date,
+    DROP COLUMN IF EXISTS manager_id,
+    DROP COLUMN IF EXISTS title,
+    DROP COLUMN IF EXISTS department,
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS login;
+-- +goose StatementEnd
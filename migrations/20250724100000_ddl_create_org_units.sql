@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.org_units (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    parent_id BIGINT NULL,
+    name VARCHAR(155) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- подразделение с дочерними удалить нельзя, сначала переносятся или удаляются дочерние
+    CONSTRAINT fk_org_units_parent FOREIGN KEY (parent_id) REFERENCES public.org_units(id) ON DELETE RESTRICT,
+    CONSTRAINT org_units_parent_not_self CHECK (parent_id IS NULL OR parent_id <> id)
+    );
+
+-- имена уникальны среди подразделений одного родителя (без учёта регистра), корневые - среди корневых
+CREATE UNIQUE INDEX IF NOT EXISTS org_units_parent_name_unique ON public.org_units (COALESCE(parent_id, 0), lower(name));
+CREATE INDEX IF NOT EXISTS org_units_parent_id_idx ON public.org_units (parent_id);
+
+ALTER TABLE public.employees ADD COLUMN IF NOT EXISTS org_unit_id BIGINT NULL;
+ALTER TABLE public.employees
+    ADD CONSTRAINT fk_employees_org_unit FOREIGN KEY (org_unit_id) REFERENCES public.org_units(id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS employees_org_unit_id_idx ON public.employees (org_unit_id);
+
+COMMENT ON TABLE public.org_units IS 'Организационные подразделения (дерево)';
+COMMENT ON COLUMN public.org_units.id IS 'Уникальный идентификатор подразделения';
+COMMENT ON COLUMN public.org_units.parent_id IS 'Родительское подразделение (NULL - корневое)';
+COMMENT ON COLUMN public.org_units.name IS 'Наименование подразделения';
+COMMENT ON COLUMN public.org_units.created_at IS 'Дата создания';
+COMMENT ON COLUMN public.org_units.updated_at IS 'Дата последнего обновления';
+COMMENT ON COLUMN public.employees.org_unit_id IS 'Подразделение сотрудника (NULL - не распределён)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.employees_org_unit_id_idx;
+ALTER TABLE public.employees DROP CONSTRAINT IF EXISTS fk_employees_org_unit;
+ALTER TABLE public.employees DROP COLUMN IF EXISTS org_unit_id;
+DROP TABLE IF EXISTS public.org_units;
+-- +goose StatementEnd
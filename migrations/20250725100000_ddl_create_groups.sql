@@ -0,0 +1,92 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.groups (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    name VARCHAR(155) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS groups_name_unique ON public.groups (lower(name));
+
+CREATE TABLE IF NOT EXISTS public.group_employees (
+    group_id BIGINT NOT NULL,
+    employee_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT group_employees_pk PRIMARY KEY (group_id, employee_id),
+    CONSTRAINT fk_group_employees_group FOREIGN KEY (group_id) REFERENCES public.groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_group_employees_employee FOREIGN KEY (employee_id) REFERENCES public.employees(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS group_employees_employee_id_idx ON public.group_employees (employee_id);
+
+CREATE TABLE IF NOT EXISTS public.group_subgroups (
+    group_id BIGINT NOT NULL,
+    member_group_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT group_subgroups_pk PRIMARY KEY (group_id, member_group_id),
+    CONSTRAINT fk_group_subgroups_group FOREIGN KEY (group_id) REFERENCES public.groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_group_subgroups_member FOREIGN KEY (member_group_id) REFERENCES public.groups(id) ON DELETE CASCADE,
+    CONSTRAINT group_subgroups_not_self CHECK (group_id <> member_group_id)
+    );
+
+CREATE INDEX IF NOT EXISTS group_subgroups_member_group_id_idx ON public.group_subgroups (member_group_id);
+
+CREATE TABLE IF NOT EXISTS public.group_roles (
+    group_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT group_roles_pk PRIMARY KEY (group_id, role_id),
+    CONSTRAINT fk_group_roles_group FOREIGN KEY (group_id) REFERENCES public.groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_group_roles_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS group_roles_role_id_idx ON public.group_roles (role_id);
+
+-- Эффективные роли сотрудников: назначенные напрямую (group_id IS NULL) и выданные группам,
+-- в которые сотрудник входит напрямую или через вложенные группы.
+-- UNION (а не UNION ALL) отбрасывает уже найденные пары, поэтому рекурсия завершается и при цикле групп
+CREATE OR REPLACE VIEW public.employee_effective_roles AS
+WITH RECURSIVE memberships AS (
+    SELECT employee_id, group_id FROM public.group_employees
+    UNION
+    SELECT m.employee_id, gs.group_id
+    FROM memberships m
+    JOIN public.group_subgroups gs ON gs.member_group_id = m.group_id
+)
+SELECT employee_id, role_id, NULL::BIGINT AS group_id FROM public.employee_roles
+UNION
+SELECT m.employee_id, gr.role_id, gr.group_id
+FROM memberships m
+JOIN public.group_roles gr ON gr.group_id = m.group_id;
+
+COMMENT ON TABLE public.groups IS 'Группы сотрудников';
+COMMENT ON COLUMN public.groups.id IS 'Уникальный идентификатор группы';
+COMMENT ON COLUMN public.groups.name IS 'Наименование группы (уникально без учёта регистра)';
+COMMENT ON COLUMN public.groups.description IS 'Описание группы';
+COMMENT ON COLUMN public.groups.created_at IS 'Дата создания';
+COMMENT ON COLUMN public.groups.updated_at IS 'Дата последнего обновления';
+COMMENT ON TABLE public.group_employees IS 'Сотрудники - прямые участники групп';
+COMMENT ON COLUMN public.group_employees.group_id IS 'Ссылка на группу (FK)';
+COMMENT ON COLUMN public.group_employees.employee_id IS 'Ссылка на сотрудника (FK)';
+COMMENT ON COLUMN public.group_employees.created_at IS 'Дата включения в группу';
+COMMENT ON TABLE public.group_subgroups IS 'Вложенные группы: участники member_group_id становятся участниками group_id';
+COMMENT ON COLUMN public.group_subgroups.group_id IS 'Ссылка на группу-контейнер (FK)';
+COMMENT ON COLUMN public.group_subgroups.member_group_id IS 'Ссылка на вложенную группу (FK)';
+COMMENT ON COLUMN public.group_subgroups.created_at IS 'Дата вложения группы';
+COMMENT ON TABLE public.group_roles IS 'Роли, выданные группам';
+COMMENT ON COLUMN public.group_roles.group_id IS 'Ссылка на группу (FK)';
+COMMENT ON COLUMN public.group_roles.role_id IS 'Ссылка на роль (FK)';
+COMMENT ON COLUMN public.group_roles.created_at IS 'Дата выдачи роли группе';
+COMMENT ON VIEW public.employee_effective_roles IS 'Эффективные роли сотрудников с учётом вложенного членства в группах';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW IF EXISTS public.employee_effective_roles;
+DROP TABLE IF EXISTS public.group_roles;
+DROP TABLE IF EXISTS public.group_subgroups;
+DROP TABLE IF EXISTS public.group_employees;
+DROP TABLE IF EXISTS public.groups;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('groups:read', 'Просмотр групп, их участников и ролей', NOW(), NOW()),
+       ('groups:write', 'Создание, изменение и удаление групп, управление участниками и ролями групп', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+-- ADMIN получает оба разрешения, USER - только чтение
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON r.name = 'ADMIN'
+    OR (r.name = 'USER' AND p.name = 'groups:read')
+WHERE p.name IN ('groups:read', 'groups:write')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name IN ('groups:read', 'groups:write');
+-- +goose StatementEnd
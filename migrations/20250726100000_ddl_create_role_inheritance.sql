@@ -0,0 +1,82 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.role_inheritance (
+    role_id BIGINT NOT NULL,
+    inherited_role_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT role_inheritance_pk PRIMARY KEY (role_id, inherited_role_id),
+    CONSTRAINT fk_role_inheritance_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE,
+    CONSTRAINT fk_role_inheritance_inherited FOREIGN KEY (inherited_role_id) REFERENCES public.roles(id) ON DELETE CASCADE,
+    CONSTRAINT role_inheritance_not_self CHECK (role_id <> inherited_role_id)
+    );
+
+CREATE INDEX IF NOT EXISTS role_inheritance_inherited_role_id_idx ON public.role_inheritance (inherited_role_id);
+
+-- Замыкание наследования: каждая неудалённая роль подразумевает саму себя (level = 0)
+-- и все роли, унаследованные прямо или транзитивно (level - длина кратчайшей цепочки).
+-- Мягко удалённая роль ничего не подразумевает и разрывает цепочку; path защищает от зацикливания
+CREATE OR REPLACE VIEW public.role_implied_roles AS
+WITH RECURSIVE implied AS (
+    SELECT id AS role_id, id AS implied_role_id, 0 AS level, ARRAY[id] AS path
+    FROM public.roles
+    WHERE deleted_at IS NULL
+    UNION ALL
+    SELECT i.role_id, ri.inherited_role_id, i.level + 1, i.path || ri.inherited_role_id
+    FROM implied i
+    JOIN public.role_inheritance ri ON ri.role_id = i.implied_role_id
+    JOIN public.roles r ON r.id = ri.inherited_role_id AND r.deleted_at IS NULL
+    WHERE NOT ri.inherited_role_id = ANY(i.path)
+)
+SELECT role_id, implied_role_id, MIN(level) AS level
+FROM implied
+GROUP BY role_id, implied_role_id;
+
+-- Эффективные роли сотрудников теперь раскрываются по наследованию ролей
+CREATE OR REPLACE VIEW public.employee_effective_roles AS
+WITH RECURSIVE memberships AS (
+    SELECT employee_id, group_id FROM public.group_employees
+    UNION
+    SELECT m.employee_id, gs.group_id
+    FROM memberships m
+    JOIN public.group_subgroups gs ON gs.member_group_id = m.group_id
+),
+granted AS (
+    SELECT employee_id, role_id, NULL::BIGINT AS group_id FROM public.employee_roles
+    UNION
+    SELECT m.employee_id, gr.role_id, gr.group_id
+    FROM memberships m
+    JOIN public.group_roles gr ON gr.group_id = m.group_id
+)
+SELECT DISTINCT g.employee_id, ir.implied_role_id AS role_id, g.group_id
+FROM granted g
+JOIN public.role_implied_roles ir ON ir.role_id = g.role_id;
+
+COMMENT ON TABLE public.role_inheritance IS 'Наследование ролей: роль role_id подразумевает роль inherited_role_id';
+COMMENT ON COLUMN public.role_inheritance.role_id IS 'Ссылка на наследующую роль (FK)';
+COMMENT ON COLUMN public.role_inheritance.inherited_role_id IS 'Ссылка на унаследованную роль (FK)';
+COMMENT ON COLUMN public.role_inheritance.created_at IS 'Дата установки наследования';
+COMMENT ON VIEW public.role_implied_roles IS 'Транзитивное замыкание наследования ролей';
+COMMENT ON VIEW public.employee_effective_roles IS 'Эффективные роли сотрудников с учётом вложенного членства в группах и наследования ролей';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW public.employee_effective_roles AS
+WITH RECURSIVE memberships AS (
+    SELECT employee_id, group_id FROM public.group_employees
+    UNION
+    SELECT m.employee_id, gs.group_id
+    FROM memberships m
+    JOIN public.group_subgroups gs ON gs.member_group_id = m.group_id
+)
+SELECT employee_id, role_id, NULL::BIGINT AS group_id FROM public.employee_roles
+UNION
+SELECT m.employee_id, gr.role_id, gr.group_id
+FROM memberships m
+JOIN public.group_roles gr ON gr.group_id = m.group_id;
+
+COMMENT ON VIEW public.employee_effective_roles IS 'Эффективные роли сотрудников с учётом вложенного членства в группах';
+
+DROP VIEW IF EXISTS public.role_implied_roles;
+DROP TABLE IF EXISTS public.role_inheritance;
+-- +goose StatementEnd
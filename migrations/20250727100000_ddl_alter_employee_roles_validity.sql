@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.employee_roles
+    ADD COLUMN IF NOT EXISTS valid_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS valid_until TIMESTAMPTZ NULL;
+
+UPDATE public.employee_roles SET valid_from = created_at;
+
+ALTER TABLE public.employee_roles
+    ADD CONSTRAINT employee_roles_validity_chk CHECK (valid_until IS NULL OR valid_until > valid_from);
+
+-- истекающие назначения выбираются планировщиком по valid_until
+CREATE INDEX IF NOT EXISTS employee_roles_valid_until_idx
+    ON public.employee_roles (valid_until)
+    WHERE valid_until IS NOT NULL;
+
+-- Прямые назначения учитываются только в пределах срока действия
+CREATE OR REPLACE VIEW public.employee_effective_roles AS
+WITH RECURSIVE memberships AS (
+    SELECT employee_id, group_id FROM public.group_employees
+    UNION
+    SELECT m.employee_id, gs.group_id
+    FROM memberships m
+    JOIN public.group_subgroups gs ON gs.member_group_id = m.group_id
+),
+granted AS (
+    SELECT employee_id, role_id, NULL::BIGINT AS group_id
+    FROM public.employee_roles
+    WHERE valid_from <= NOW() AND (valid_until IS NULL OR valid_until > NOW())
+    UNION
+    SELECT m.employee_id, gr.role_id, gr.group_id
+    FROM memberships m
+    JOIN public.group_roles gr ON gr.group_id = m.group_id
+)
+SELECT DISTINCT g.employee_id, ir.implied_role_id AS role_id, g.group_id
+FROM granted g
+JOIN public.role_implied_roles ir ON ir.role_id = g.role_id;
+
+COMMENT ON COLUMN public.employee_roles.valid_from IS 'Начало действия назначения';
+COMMENT ON COLUMN public.employee_roles.valid_until IS 'Окончание действия назначения (NULL - бессрочно)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW public.employee_effective_roles AS
+WITH RECURSIVE memberships AS (
+    SELECT employee_id, group_id FROM public.group_employees
+    UNION
+    SELECT m.employee_id, gs.group_id
+    FROM memberships m
+    JOIN public.group_subgroups gs ON gs.member_group_id = m.group_id
+),
+granted AS (
+    SELECT employee_id, role_id, NULL::BIGINT AS group_id FROM public.employee_roles
+    UNION
+    SELECT m.employee_id, gr.role_id, gr.group_id
+    FROM memberships m
+    JOIN public.group_roles gr ON gr.group_id = m.group_id
+)
+SELECT DISTINCT g.employee_id, ir.implied_role_id AS role_id, g.group_id
+FROM granted g
+JOIN public.role_implied_roles ir ON ir.role_id = g.role_id;
+
+DROP INDEX IF EXISTS public.employee_roles_valid_until_idx;
+ALTER TABLE public.employee_roles
+    DROP CONSTRAINT IF EXISTS employee_roles_validity_chk,
+    DROP COLUMN IF EXISTS valid_until,
+    DROP COLUMN IF EXISTS valid_from;
+-- +goose StatementEnd
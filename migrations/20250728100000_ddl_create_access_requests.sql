@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+-- владелец роли согласует заявки на неё; без владельца заявку согласует руководитель сотрудника
+ALTER TABLE public.roles
+    ADD COLUMN IF NOT EXISTS owner_id BIGINT NULL;
+
+ALTER TABLE public.roles
+    ADD CONSTRAINT fk_roles_owner FOREIGN KEY (owner_id) REFERENCES public.employees(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS public.access_requests (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    employee_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    approver_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    justification VARCHAR(1000) NOT NULL,
+    valid_until TIMESTAMPTZ NULL,
+    decision_comment VARCHAR(1000) NOT NULL DEFAULT '',
+    decided_at TIMESTAMPTZ NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_access_requests_employee FOREIGN KEY (employee_id) REFERENCES public.employees(id) ON DELETE CASCADE,
+    CONSTRAINT fk_access_requests_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE,
+    CONSTRAINT fk_access_requests_approver FOREIGN KEY (approver_id) REFERENCES public.employees(id) ON DELETE CASCADE,
+    CONSTRAINT access_requests_status_chk
+        CHECK (status IN ('pending', 'approved', 'rejected', 'expired', 'cancelled'))
+    );
+
+-- на одну роль у сотрудника может быть только одна заявка на рассмотрении
+CREATE UNIQUE INDEX IF NOT EXISTS access_requests_pending_unique
+    ON public.access_requests (employee_id, role_id)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS access_requests_employee_id_idx ON public.access_requests (employee_id);
+CREATE INDEX IF NOT EXISTS access_requests_approver_pending_idx
+    ON public.access_requests (approver_id)
+    WHERE status = 'pending';
+
+COMMENT ON COLUMN public.roles.owner_id IS 'Владелец роли, согласующий заявки на неё (FK)';
+COMMENT ON TABLE public.access_requests IS 'Заявки сотрудников на получение роли';
+COMMENT ON COLUMN public.access_requests.employee_id IS 'Сотрудник, запросивший роль (FK)';
+COMMENT ON COLUMN public.access_requests.role_id IS 'Запрошенная роль (FK)';
+COMMENT ON COLUMN public.access_requests.approver_id IS 'Согласующий: владелец роли или руководитель сотрудника (FK)';
+COMMENT ON COLUMN public.access_requests.status IS 'Состояние: pending, approved, rejected, expired, cancelled';
+COMMENT ON COLUMN public.access_requests.justification IS 'Обоснование запроса';
+COMMENT ON COLUMN public.access_requests.valid_until IS 'Запрошенный срок действия назначения (NULL - бессрочно)';
+COMMENT ON COLUMN public.access_requests.decision_comment IS 'Комментарий согласующего';
+COMMENT ON COLUMN public.access_requests.decided_at IS 'Дата решения по заявке';
+COMMENT ON COLUMN public.access_requests.expires_at IS 'Срок рассмотрения, после которого заявка истекает';
+COMMENT ON COLUMN public.access_requests.created_at IS 'Дата создания заявки';
+COMMENT ON COLUMN public.access_requests.updated_at IS 'Дата последнего изменения заявки';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.access_requests;
+ALTER TABLE public.roles
+    DROP CONSTRAINT IF EXISTS fk_roles_owner,
+    DROP COLUMN IF EXISTS owner_id;
+-- +goose StatementEnd
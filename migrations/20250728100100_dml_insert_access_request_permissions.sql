@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('access_requests:create', 'Подача и отзыв собственных заявок на роли', NOW(), NOW()),
+       ('access_requests:approve', 'Согласование и отклонение заявок на роли, направленных сотруднику', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+-- заявки подают и согласуют все сотрудники: ADMIN и USER получают оба разрешения
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON r.name IN ('ADMIN', 'USER')
+WHERE p.name IN ('access_requests:create', 'access_requests:approve')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name IN ('access_requests:create', 'access_requests:approve');
+-- +goose StatementEnd
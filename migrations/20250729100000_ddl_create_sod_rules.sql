@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.sod_rules (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    role_id BIGINT NOT NULL,
+    conflicting_role_id BIGINT NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sod_rules_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sod_rules_conflicting_role FOREIGN KEY (conflicting_role_id) REFERENCES public.roles(id) ON DELETE CASCADE,
+    CONSTRAINT sod_rules_not_self CHECK (role_id <> conflicting_role_id)
+    );
+
+-- правило симметрично: пара (A, B) запрещает и (B, A)
+CREATE UNIQUE INDEX IF NOT EXISTS sod_rules_pair_unique
+    ON public.sod_rules (LEAST(role_id, conflicting_role_id), GREATEST(role_id, conflicting_role_id));
+CREATE INDEX IF NOT EXISTS sod_rules_conflicting_role_id_idx ON public.sod_rules (conflicting_role_id);
+
+COMMENT ON TABLE public.sod_rules IS 'Правила разделения полномочий: пары ролей, которые нельзя иметь одновременно';
+COMMENT ON COLUMN public.sod_rules.id IS 'Уникальный идентификатор правила';
+COMMENT ON COLUMN public.sod_rules.role_id IS 'Ссылка на первую роль пары (FK)';
+COMMENT ON COLUMN public.sod_rules.conflicting_role_id IS 'Ссылка на конфликтующую роль (FK)';
+COMMENT ON COLUMN public.sod_rules.description IS 'Описание правила';
+COMMENT ON COLUMN public.sod_rules.created_at IS 'Дата создания правила';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.sod_rules;
+-- +goose StatementEnd
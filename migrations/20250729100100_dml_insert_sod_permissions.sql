@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('sod:read', 'Просмотр правил разделения полномочий и отчёта о нарушениях', NOW(), NOW()),
+       ('sod:write', 'Создание и удаление правил разделения полномочий', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+-- правила разделения полномочий ведут только администраторы
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON r.name = 'ADMIN'
+WHERE p.name IN ('sod:read', 'sod:write')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name IN ('sod:read', 'sod:write');
+-- +goose StatementEnd
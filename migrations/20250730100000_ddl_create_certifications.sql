@@ -0,0 +1,79 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.certification_campaigns (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    name VARCHAR(155) NOT NULL,
+    scope_type VARCHAR(16) NOT NULL,
+    scope_id BIGINT NULL,
+    deadline TIMESTAMPTZ NOT NULL,
+    auto_revoke BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_by BIGINT NULL,
+    completed_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_certification_campaigns_created_by FOREIGN KEY (created_by) REFERENCES public.employees(id) ON DELETE SET NULL,
+    CONSTRAINT certification_campaigns_scope_chk CHECK (
+        (scope_type = 'all' AND scope_id IS NULL) OR (scope_type IN ('role', 'org_unit') AND scope_id IS NOT NULL)
+    ),
+    CONSTRAINT certification_campaigns_status_chk CHECK (status IN ('active', 'completed'))
+    );
+
+-- планировщик выбирает активные кампании с наступившим сроком
+CREATE INDEX IF NOT EXISTS certification_campaigns_active_deadline_idx
+    ON public.certification_campaigns (deadline)
+    WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS public.certification_items (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    campaign_id BIGINT NOT NULL,
+    employee_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    reviewer_id BIGINT NULL,
+    decision VARCHAR(16) NOT NULL DEFAULT 'pending',
+    comment VARCHAR(1000) NOT NULL DEFAULT '',
+    decided_by BIGINT NULL,
+    decided_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_certification_items_campaign FOREIGN KEY (campaign_id) REFERENCES public.certification_campaigns(id) ON DELETE CASCADE,
+    CONSTRAINT fk_certification_items_employee FOREIGN KEY (employee_id) REFERENCES public.employees(id) ON DELETE CASCADE,
+    CONSTRAINT fk_certification_items_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE,
+    CONSTRAINT fk_certification_items_reviewer FOREIGN KEY (reviewer_id) REFERENCES public.employees(id) ON DELETE SET NULL,
+    CONSTRAINT fk_certification_items_decided_by FOREIGN KEY (decided_by) REFERENCES public.employees(id) ON DELETE SET NULL,
+    CONSTRAINT certification_items_unique UNIQUE (campaign_id, employee_id, role_id),
+    CONSTRAINT certification_items_decision_chk CHECK (decision IN ('pending', 'certified', 'revoked', 'expired'))
+    );
+
+CREATE INDEX IF NOT EXISTS certification_items_reviewer_pending_idx
+    ON public.certification_items (reviewer_id)
+    WHERE decision = 'pending';
+
+COMMENT ON TABLE public.certification_campaigns IS 'Кампании периодической ресертификации назначений ролей';
+COMMENT ON COLUMN public.certification_campaigns.id IS 'Уникальный идентификатор кампании';
+COMMENT ON COLUMN public.certification_campaigns.name IS 'Наименование кампании';
+COMMENT ON COLUMN public.certification_campaigns.scope_type IS 'Охват: all - все роли, role - одна роль, org_unit - подразделение с подчинёнными';
+COMMENT ON COLUMN public.certification_campaigns.scope_id IS 'Роль или подразделение охвата (NULL для all)';
+COMMENT ON COLUMN public.certification_campaigns.deadline IS 'Срок проверки';
+COMMENT ON COLUMN public.certification_campaigns.auto_revoke IS 'Отзывать не проверенные к сроку назначения';
+COMMENT ON COLUMN public.certification_campaigns.status IS 'Состояние: active, completed';
+COMMENT ON COLUMN public.certification_campaigns.created_by IS 'Сотрудник, создавший кампанию (FK)';
+COMMENT ON COLUMN public.certification_campaigns.completed_at IS 'Дата завершения кампании';
+COMMENT ON COLUMN public.certification_campaigns.created_at IS 'Дата создания';
+COMMENT ON COLUMN public.certification_campaigns.updated_at IS 'Дата последнего обновления';
+COMMENT ON TABLE public.certification_items IS 'Назначения ролей на проверке в кампании ресертификации';
+COMMENT ON COLUMN public.certification_items.campaign_id IS 'Ссылка на кампанию (FK)';
+COMMENT ON COLUMN public.certification_items.employee_id IS 'Сотрудник, которому назначена роль (FK)';
+COMMENT ON COLUMN public.certification_items.role_id IS 'Проверяемая роль (FK)';
+COMMENT ON COLUMN public.certification_items.reviewer_id IS 'Проверяющий: руководитель сотрудника, владелец роли или создатель кампании (FK)';
+COMMENT ON COLUMN public.certification_items.decision IS 'Решение: pending, certified, revoked, expired';
+COMMENT ON COLUMN public.certification_items.comment IS 'Комментарий проверяющего';
+COMMENT ON COLUMN public.certification_items.decided_by IS 'Кто принял решение (NULL - автоматически по сроку) (FK)';
+COMMENT ON COLUMN public.certification_items.decided_at IS 'Дата решения';
+COMMENT ON COLUMN public.certification_items.created_at IS 'Дата создания';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.certification_items;
+DROP TABLE IF EXISTS public.certification_campaigns;
+-- +goose StatementEnd
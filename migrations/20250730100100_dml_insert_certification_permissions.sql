@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('certifications:read', 'Просмотр и выгрузка кампаний ресертификации', NOW(), NOW()),
+       ('certifications:write', 'Создание кампаний ресертификации', NOW(), NOW()),
+       ('certifications:review', 'Подтверждение и отзыв назначений ролей, направленных на проверку', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+-- ADMIN получает все разрешения, USER - только проверку: руководители - обычные сотрудники
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON r.name = 'ADMIN'
+    OR (r.name = 'USER' AND p.name = 'certifications:review')
+WHERE p.name IN ('certifications:read', 'certifications:write', 'certifications:review')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name IN ('certifications:read', 'certifications:write', 'certifications:review');
+-- +goose StatementEnd
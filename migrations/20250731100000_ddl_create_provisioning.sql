@@ -0,0 +1,90 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.provisioning_targets (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    name VARCHAR(155) NOT NULL,
+    type VARCHAR(16) NOT NULL DEFAULT 'scim',
+    base_url VARCHAR(500) NOT NULL,
+    token VARCHAR(2000) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    change_seq BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT provisioning_targets_name_unique UNIQUE (name),
+    CONSTRAINT provisioning_targets_type_chk CHECK (type IN ('scim'))
+    );
+
+CREATE TABLE IF NOT EXISTS public.provisioning_operations (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    target_id BIGINT NOT NULL,
+    resource_type VARCHAR(16) NOT NULL,
+    resource_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_provisioning_operations_target FOREIGN KEY (target_id) REFERENCES public.provisioning_targets(id) ON DELETE CASCADE,
+    CONSTRAINT provisioning_operations_resource_type_chk CHECK (resource_type IN ('User', 'Group')),
+    CONSTRAINT provisioning_operations_status_chk CHECK (status IN ('pending', 'delivering', 'delivered', 'failed'))
+    );
+
+-- доставка выбирает операции, срок попытки которых наступил
+CREATE INDEX IF NOT EXISTS provisioning_operations_due_idx
+    ON public.provisioning_operations (next_attempt_at)
+    WHERE status IN ('pending', 'delivering');
+CREATE INDEX IF NOT EXISTS provisioning_operations_target_idx
+    ON public.provisioning_operations (target_id, resource_type, resource_id);
+
+CREATE TABLE IF NOT EXISTS public.provisioning_sync_state (
+    target_id BIGINT NOT NULL,
+    resource_type VARCHAR(16) NOT NULL,
+    resource_id BIGINT NOT NULL,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    last_error VARCHAR(1000) NOT NULL DEFAULT '',
+    synced_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT provisioning_sync_state_pk PRIMARY KEY (target_id, resource_type, resource_id),
+    CONSTRAINT fk_provisioning_sync_state_target FOREIGN KEY (target_id) REFERENCES public.provisioning_targets(id) ON DELETE CASCADE,
+    CONSTRAINT provisioning_sync_state_status_chk CHECK (status IN ('synced', 'failed'))
+    );
+
+COMMENT ON TABLE public.provisioning_targets IS 'Внешние системы, в которые передаются сотрудники и роли';
+COMMENT ON COLUMN public.provisioning_targets.id IS 'Уникальный идентификатор целевой системы';
+COMMENT ON COLUMN public.provisioning_targets.name IS 'Наименование целевой системы';
+COMMENT ON COLUMN public.provisioning_targets.type IS 'Тип коннектора: scim';
+COMMENT ON COLUMN public.provisioning_targets.base_url IS 'Базовый адрес API целевой системы, например https://host/scim/v2';
+COMMENT ON COLUMN public.provisioning_targets.token IS 'Bearer-токен доступа к целевой системе';
+COMMENT ON COLUMN public.provisioning_targets.enabled IS 'Передавать изменения в целевую систему';
+COMMENT ON COLUMN public.provisioning_targets.change_seq IS 'Последняя запись audit_chain, изменения по которой поставлены в очередь';
+COMMENT ON COLUMN public.provisioning_targets.created_at IS 'Дата создания';
+COMMENT ON COLUMN public.provisioning_targets.updated_at IS 'Дата последнего обновления';
+COMMENT ON TABLE public.provisioning_operations IS 'Очередь операций провижининга: передать текущее состояние ресурса в целевую систему';
+COMMENT ON COLUMN public.provisioning_operations.target_id IS 'Ссылка на целевую систему (FK)';
+COMMENT ON COLUMN public.provisioning_operations.resource_type IS 'Тип ресурса: User - сотрудник, Group - роль';
+COMMENT ON COLUMN public.provisioning_operations.resource_id IS 'Идентификатор сотрудника или роли';
+COMMENT ON COLUMN public.provisioning_operations.status IS 'Состояние: pending, delivering, delivered, failed';
+COMMENT ON COLUMN public.provisioning_operations.attempts IS 'Число выполненных попыток доставки';
+COMMENT ON COLUMN public.provisioning_operations.next_attempt_at IS 'Время следующей попытки (для delivering - окончание захвата)';
+COMMENT ON COLUMN public.provisioning_operations.last_error IS 'Ошибка последней попытки';
+COMMENT ON COLUMN public.provisioning_operations.created_at IS 'Дата постановки в очередь';
+COMMENT ON COLUMN public.provisioning_operations.updated_at IS 'Дата последнего обновления';
+COMMENT ON TABLE public.provisioning_sync_state IS 'Состояние синхронизации ресурсов с целевыми системами';
+COMMENT ON COLUMN public.provisioning_sync_state.target_id IS 'Ссылка на целевую систему (FK)';
+COMMENT ON COLUMN public.provisioning_sync_state.resource_type IS 'Тип ресурса: User или Group';
+COMMENT ON COLUMN public.provisioning_sync_state.resource_id IS 'Идентификатор сотрудника или роли';
+COMMENT ON COLUMN public.provisioning_sync_state.external_id IS 'Идентификатор ресурса в целевой системе (пусто, если ещё не создан)';
+COMMENT ON COLUMN public.provisioning_sync_state.status IS 'Состояние: synced - передан, failed - попытки доставки исчерпаны';
+COMMENT ON COLUMN public.provisioning_sync_state.last_error IS 'Ошибка последней неудачной доставки';
+COMMENT ON COLUMN public.provisioning_sync_state.synced_at IS 'Время последней успешной доставки';
+COMMENT ON COLUMN public.provisioning_sync_state.updated_at IS 'Дата последнего обновления';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.provisioning_sync_state;
+DROP TABLE IF EXISTS public.provisioning_operations;
+DROP TABLE IF EXISTS public.provisioning_targets;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('provisioning:read', 'Просмотр целевых систем провижининга, очереди операций и состояния синхронизации', NOW(), NOW()),
+       ('provisioning:write', 'Управление целевыми системами провижининга и сверка с ними', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON r.name = 'ADMIN'
+WHERE p.name IN ('provisioning:read', 'provisioning:write')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name IN ('provisioning:read', 'provisioning:write');
+-- +goose StatementEnd
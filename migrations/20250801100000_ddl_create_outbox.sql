@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.outbox (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    payload JSONB NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    actor_type VARCHAR(32) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    delivered_to TEXT[] NOT NULL DEFAULT '{}',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error VARCHAR(1000) NOT NULL DEFAULT '',
+    published_at TIMESTAMPTZ NULL,
+    CONSTRAINT outbox_status_chk CHECK (status IN ('pending', 'published'))
+    );
+
+-- отправка выбирает события, срок попытки которых наступил
+CREATE INDEX IF NOT EXISTS outbox_due_idx
+    ON public.outbox (next_attempt_at)
+    WHERE status = 'pending';
+
+COMMENT ON TABLE public.outbox IS 'Доменные события изменений сотрудников, ролей и назначений для интеграций (transactional outbox)';
+COMMENT ON COLUMN public.outbox.id IS 'Уникальный идентификатор события, по нему подписчики отбрасывают повторы';
+COMMENT ON COLUMN public.outbox.event_type IS 'Тип события, например EmployeeCreated, RoleAssigned';
+COMMENT ON COLUMN public.outbox.aggregate_type IS 'Тип изменённой сущности: employee, role, employee_role';
+COMMENT ON COLUMN public.outbox.aggregate_id IS 'Идентификатор изменённой сущности (для employee_role - сотрудника)';
+COMMENT ON COLUMN public.outbox.payload IS 'Состояние сущности после изменения (для удаления - до него)';
+COMMENT ON COLUMN public.outbox.occurred_at IS 'Время изменения';
+COMMENT ON COLUMN public.outbox.actor IS 'Кто выполнил изменение';
+COMMENT ON COLUMN public.outbox.actor_type IS 'Тип субъекта: employee, client или system';
+COMMENT ON COLUMN public.outbox.request_id IS 'Идентификатор запроса, в котором выполнено изменение';
+COMMENT ON COLUMN public.outbox.status IS 'Состояние: pending - ждёт отправки, published - получено всеми подписчиками';
+COMMENT ON COLUMN public.outbox.delivered_to IS 'Подписчики, уже получившие событие: при повторе они пропускаются';
+COMMENT ON COLUMN public.outbox.attempts IS 'Число неудачных попыток отправки';
+COMMENT ON COLUMN public.outbox.next_attempt_at IS 'Время следующей попытки (для захваченного события - окончание захвата)';
+COMMENT ON COLUMN public.outbox.last_error IS 'Ошибка последней попытки';
+COMMENT ON COLUMN public.outbox.published_at IS 'Время получения события последним подписчиком';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.outbox;
+-- +goose StatementEnd
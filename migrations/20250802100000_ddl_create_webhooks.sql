@@ -0,0 +1,83 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.webhooks (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    url VARCHAR(500) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    secret VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS public.webhook_deliveries (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    webhook_id BIGINT NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(1000) NOT NULL DEFAULT '',
+    delivered_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES public.webhooks(id) ON DELETE CASCADE,
+    CONSTRAINT webhook_deliveries_event_unique UNIQUE (webhook_id, event_id),
+    CONSTRAINT webhook_deliveries_status_chk CHECK (status IN ('pending', 'delivering', 'delivered', 'dead'))
+    );
+
+-- доставка выбирает события, срок попытки которых наступил
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+    ON public.webhook_deliveries (next_attempt_at)
+    WHERE status IN ('pending', 'delivering');
+
+CREATE TABLE IF NOT EXISTS public.webhook_attempts (
+    id BIGINT PRIMARY KEY DEFAULT nextval('public.global_enterprise_sequence'),
+    delivery_id BIGINT NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status_code INT NOT NULL DEFAULT 0,
+    error VARCHAR(1000) NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id) REFERENCES public.webhook_deliveries(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS webhook_attempts_delivery_idx ON public.webhook_attempts (delivery_id);
+
+COMMENT ON TABLE public.webhooks IS 'Подписки внешних систем на доменные события по HTTP';
+COMMENT ON COLUMN public.webhooks.id IS 'Уникальный идентификатор подписки';
+COMMENT ON COLUMN public.webhooks.url IS 'Адрес, на который отправляются события методом POST';
+COMMENT ON COLUMN public.webhooks.event_types IS 'Типы событий подписки; пустой список - все события';
+COMMENT ON COLUMN public.webhooks.secret IS 'Секрет подписи HMAC-SHA256 тела запроса';
+COMMENT ON COLUMN public.webhooks.enabled IS 'Отправлять события подписчику';
+COMMENT ON COLUMN public.webhooks.created_at IS 'Дата создания';
+COMMENT ON COLUMN public.webhooks.updated_at IS 'Дата последнего обновления';
+COMMENT ON TABLE public.webhook_deliveries IS 'Доставка доменного события подписчику webhook';
+COMMENT ON COLUMN public.webhook_deliveries.webhook_id IS 'Ссылка на подписку (FK)';
+COMMENT ON COLUMN public.webhook_deliveries.event_id IS 'Идентификатор доменного события в outbox';
+COMMENT ON COLUMN public.webhook_deliveries.event_type IS 'Тип доменного события';
+COMMENT ON COLUMN public.webhook_deliveries.payload IS 'Тело запроса - событие в том виде, в котором оно подписывается и отправляется';
+COMMENT ON COLUMN public.webhook_deliveries.status IS 'Состояние: pending, delivering, delivered, dead - попытки исчерпаны';
+COMMENT ON COLUMN public.webhook_deliveries.attempts IS 'Число выполненных попыток доставки';
+COMMENT ON COLUMN public.webhook_deliveries.next_attempt_at IS 'Время следующей попытки (для delivering - окончание захвата)';
+COMMENT ON COLUMN public.webhook_deliveries.last_status_code IS 'HTTP-статус ответа на последнюю попытку, 0 - ответа не было';
+COMMENT ON COLUMN public.webhook_deliveries.last_error IS 'Ошибка последней попытки';
+COMMENT ON COLUMN public.webhook_deliveries.delivered_at IS 'Время успешной доставки';
+COMMENT ON COLUMN public.webhook_deliveries.created_at IS 'Дата постановки в очередь';
+COMMENT ON COLUMN public.webhook_deliveries.updated_at IS 'Дата последнего обновления';
+COMMENT ON TABLE public.webhook_attempts IS 'История попыток доставки событий подписчикам webhook';
+COMMENT ON COLUMN public.webhook_attempts.delivery_id IS 'Ссылка на доставку (FK)';
+COMMENT ON COLUMN public.webhook_attempts.attempted_at IS 'Время попытки';
+COMMENT ON COLUMN public.webhook_attempts.status_code IS 'HTTP-статус ответа, 0 - ответа не было';
+COMMENT ON COLUMN public.webhook_attempts.error IS 'Ошибка попытки, пусто - доставлено';
+COMMENT ON COLUMN public.webhook_attempts.duration_ms IS 'Длительность запроса в миллисекундах';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.webhook_attempts;
+DROP TABLE IF EXISTS public.webhook_deliveries;
+DROP TABLE IF EXISTS public.webhooks;
+-- +goose StatementEnd
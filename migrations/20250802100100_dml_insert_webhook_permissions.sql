@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO public.permissions(name, description, created_at, updated_at)
+VALUES ('webhooks:read', 'Просмотр подписок webhook и истории доставки событий', NOW(), NOW()),
+       ('webhooks:write', 'Управление подписками webhook и повторная доставка событий', NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO public.role_permissions(role_id, permission_id)
+SELECT r.id, p.id
+FROM public.roles r
+JOIN public.permissions p ON r.name = 'ADMIN'
+WHERE p.name IN ('webhooks:read', 'webhooks:write')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM public.permissions WHERE name IN ('webhooks:read', 'webhooks:write');
+-- +goose StatementEnd
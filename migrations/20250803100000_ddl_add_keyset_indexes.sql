@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- индексы keyset-пагинации: выдача сортируется по (ключ, id) и продолжается сравнением кортежей
+CREATE INDEX IF NOT EXISTS employees_name_id_idx ON public.employees (name, id);
+CREATE INDEX IF NOT EXISTS employees_created_at_id_idx ON public.employees (created_at, id);
+CREATE INDEX IF NOT EXISTS roles_name_id_idx ON public.roles (name, id);
+CREATE INDEX IF NOT EXISTS roles_created_at_id_idx ON public.roles (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.roles_created_at_id_idx;
+DROP INDEX IF EXISTS public.roles_name_id_idx;
+DROP INDEX IF EXISTS public.employees_created_at_id_idx;
+DROP INDEX IF EXISTS public.employees_name_id_idx;
+-- +goose StatementEnd
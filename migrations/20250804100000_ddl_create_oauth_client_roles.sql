@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Роли клиентов client_credentials: разрешения клиента - разрешения его ролей с учётом наследования
+CREATE TABLE IF NOT EXISTS public.oauth_client_roles (
+    client_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT oauth_client_roles_pk PRIMARY KEY (client_id, role_id),
+    CONSTRAINT fk_oauth_client_roles_client FOREIGN KEY (client_id) REFERENCES public.oauth_clients(id) ON DELETE CASCADE,
+    CONSTRAINT fk_oauth_client_roles_role FOREIGN KEY (role_id) REFERENCES public.roles(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS oauth_client_roles_role_id_idx ON public.oauth_client_roles (role_id);
+
+COMMENT ON TABLE public.oauth_client_roles IS 'Роли, выданные клиентам client_credentials';
+COMMENT ON COLUMN public.oauth_client_roles.client_id IS 'Ссылка на клиента (FK oauth_clients.id)';
+COMMENT ON COLUMN public.oauth_client_roles.role_id IS 'Ссылка на роль (FK)';
+COMMENT ON COLUMN public.oauth_client_roles.created_at IS 'Дата выдачи роли';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.oauth_client_roles;
+-- +goose StatementEnd
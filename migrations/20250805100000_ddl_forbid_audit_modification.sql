@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- TRUNCATE не вызывает строковые триггеры, поэтому журналы аудита защищаются отдельным триггером на оператор
+CREATE OR REPLACE FUNCTION public.audit_forbid_modification() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only: % is not allowed', TG_TABLE_NAME, TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_chain_no_truncate
+    BEFORE TRUNCATE ON public.audit_chain
+    FOR EACH STATEMENT EXECUTE FUNCTION public.audit_forbid_modification();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON public.audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION public.audit_forbid_modification();
+
+-- журнал audit_events дополняется так же, как цепочка: изменение и удаление строк запрещены
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON public.audit_events
+    FOR EACH ROW EXECUTE FUNCTION public.audit_forbid_modification();
+
+COMMENT ON FUNCTION public.audit_forbid_modification() IS 'Запрет изменения, удаления и очистки журналов аудита';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS audit_events_append_only ON public.audit_events;
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON public.audit_events;
+DROP TRIGGER IF EXISTS audit_chain_no_truncate ON public.audit_chain;
+DROP FUNCTION IF EXISTS public.audit_forbid_modification();
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Эффективные роли одного сотрудника. Представление employee_effective_roles раскрывает группы и наследование
+-- для всей организации и лишь затем фильтруется по сотруднику; здесь сотрудник задаёт начало обеих рекурсий,
+-- поэтому проверка разрешений стоит O(групп и ролей сотрудника). Семантика совпадает с представлением
+CREATE OR REPLACE FUNCTION public.employee_effective_roles_of(p_employee_id BIGINT)
+    RETURNS TABLE (employee_id BIGINT, role_id BIGINT, group_id BIGINT)
+    LANGUAGE sql STABLE AS $$
+WITH RECURSIVE memberships AS (
+    SELECT ge.group_id FROM public.group_employees ge WHERE ge.employee_id = p_employee_id
+    UNION
+    SELECT gs.group_id
+    FROM memberships m
+    JOIN public.group_subgroups gs ON gs.member_group_id = m.group_id
+),
+granted AS (
+    SELECT er.role_id, NULL::BIGINT AS group_id
+    FROM public.employee_roles er
+    WHERE er.employee_id = p_employee_id
+      AND er.valid_from <= NOW() AND (er.valid_until IS NULL OR er.valid_until > NOW())
+    UNION
+    SELECT gr.role_id, gr.group_id
+    FROM memberships m
+    JOIN public.group_roles gr ON gr.group_id = m.group_id
+),
+implied AS (
+    SELECT g.role_id, g.group_id, ARRAY[g.role_id] AS path
+    FROM granted g
+    JOIN public.roles r ON r.id = g.role_id AND r.deleted_at IS NULL
+    UNION ALL
+    SELECT ri.inherited_role_id, i.group_id, i.path || ri.inherited_role_id
+    FROM implied i
+    JOIN public.role_inheritance ri ON ri.role_id = i.role_id
+    JOIN public.roles r ON r.id = ri.inherited_role_id AND r.deleted_at IS NULL
+    WHERE NOT ri.inherited_role_id = ANY(i.path)
+)
+SELECT DISTINCT p_employee_id, i.role_id, i.group_id
+FROM implied i
+$$;
+
+COMMENT ON FUNCTION public.employee_effective_roles_of(BIGINT) IS 'Эффективные роли сотрудника (как employee_effective_roles, но с фильтром в начале рекурсии)';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS public.employee_effective_roles_of(BIGINT);
+-- +goose StatementEnd
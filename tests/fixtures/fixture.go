@@ -2,29 +2,72 @@ package fixtures
 
 import (
 	"github.com/jmoiron/sqlx"
+	"idm/inner/accessrequest"
+	"idm/inner/audit"
+	"idm/inner/auth"
+	"idm/inner/certification"
 	"idm/inner/employee"
+	"idm/inner/group"
+	"idm/inner/orgunit"
+	"idm/inner/outbox"
+	"idm/inner/permission"
+	"idm/inner/provisioning"
 	"idm/inner/role"
+	"idm/inner/sod"
+	"idm/inner/webhook"
 )
 
 // Fixture - общая фикстура для всех сущностей
 type Fixture struct {
-	db        *sqlx.DB
-	employees *employee.Repository
-	roles     *role.Repository
+	db             *sqlx.DB
+	employees      *employee.Repository
+	roles          *role.Repository
+	permissions    *permission.Repository
+	auth           *auth.Repository
+	audit          *audit.Repository
+	orgUnits       *orgunit.Repository
+	groups         *group.Repository
+	accessRequests *accessrequest.Repository
+	sodRules       *sod.Repository
+	certifications *certification.Repository
+	provisioning   *provisioning.Repository
+	outbox         *outbox.Repository
+	webhooks       *webhook.Repository
 }
 
 // NewFixture - функция конструктор, создает новую фикстуру
 func NewFixture(db *sqlx.DB) *Fixture {
 	return &Fixture{
-		db:        db,
-		employees: employee.NewRepository(db),
-		roles:     role.NewRepository(db),
+		db:             db,
+		employees:      employee.NewRepository(db),
+		roles:          role.NewRepository(db),
+		permissions:    permission.NewRepository(db),
+		auth:           auth.NewRepository(db),
+		audit:          audit.NewRepository(db),
+		orgUnits:       orgunit.NewRepository(db),
+		groups:         group.NewRepository(db),
+		accessRequests: accessrequest.NewRepository(db),
+		sodRules:       sod.NewRepository(db),
+		certifications: certification.NewRepository(db),
+		provisioning:   provisioning.NewRepository(db),
+		outbox:         outbox.NewRepository(db),
+		webhooks:       webhook.NewRepository(db),
 	}
 }
 
-// CleanDatabase - очищает все таблицы
+// CleanDatabase - очищает все таблицы. Журналы аудита защищены от очистки триггерами,
+// поэтому на время TRUNCATE они отключаются в той же транзакции
 func (f *Fixture) CleanDatabase() {
-	f.db.MustExec("TRUNCATE TABLE employees, roles RESTART IDENTITY CASCADE")
+	tx := f.db.MustBegin()
+	defer func() { _ = tx.Rollback() }()
+	tx.MustExec("ALTER TABLE audit_chain DISABLE TRIGGER audit_chain_no_truncate")
+	tx.MustExec("ALTER TABLE audit_events DISABLE TRIGGER audit_events_no_truncate")
+	tx.MustExec("TRUNCATE TABLE audit_chain, audit_events, outbox, refresh_tokens, employee_credentials, oauth_clients, role_permissions, permissions, employee_roles, employees, org_units, groups, role_inheritance, access_requests, sod_rules, certification_items, certification_campaigns, provisioning_sync_state, provisioning_operations, provisioning_targets, webhook_attempts, webhook_deliveries, webhooks, roles RESTART IDENTITY CASCADE")
+	tx.MustExec("ALTER TABLE audit_events ENABLE TRIGGER audit_events_no_truncate")
+	tx.MustExec("ALTER TABLE audit_chain ENABLE TRIGGER audit_chain_no_truncate")
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
 }
 
 // EmployeeRepository возвращает репозиторий для работы с сотрудниками
@@ -36,3 +79,58 @@ func (f *Fixture) EmployeeRepository() *employee.Repository {
 func (f *Fixture) RoleRepository() *role.Repository {
 	return f.roles
 }
+
+// PermissionRepository возвращает репозиторий для работы с разрешениями
+func (f *Fixture) PermissionRepository() *permission.Repository {
+	return f.permissions
+}
+
+// AuthRepository возвращает репозиторий учётных данных и токенов
+func (f *Fixture) AuthRepository() *auth.Repository {
+	return f.auth
+}
+
+// AuditRepository возвращает репозиторий журнала аудита
+func (f *Fixture) AuditRepository() *audit.Repository {
+	return f.audit
+}
+
+// OrgUnitRepository возвращает репозиторий подразделений
+func (f *Fixture) OrgUnitRepository() *orgunit.Repository {
+	return f.orgUnits
+}
+
+// GroupRepository возвращает репозиторий групп
+func (f *Fixture) GroupRepository() *group.Repository {
+	return f.groups
+}
+
+// AccessRequestRepository возвращает репозиторий для работы с заявками на роли
+func (f *Fixture) AccessRequestRepository() *accessrequest.Repository {
+	return f.accessRequests
+}
+
+// SodRepository возвращает репозиторий правил разделения полномочий
+func (f *Fixture) SodRepository() *sod.Repository {
+	return f.sodRules
+}
+
+// CertificationRepository возвращает репозиторий кампаний ресертификации
+func (f *Fixture) CertificationRepository() *certification.Repository {
+	return f.certifications
+}
+
+// ProvisioningRepository возвращает репозиторий исходящего провижининга
+func (f *Fixture) ProvisioningRepository() *provisioning.Repository {
+	return f.provisioning
+}
+
+// OutboxRepository возвращает репозиторий доменных событий
+func (f *Fixture) OutboxRepository() *outbox.Repository {
+	return f.outbox
+}
+
+// WebhookRepository возвращает репозиторий подписок webhook
+func (f *Fixture) WebhookRepository() *webhook.Repository {
+	return f.webhooks
+}
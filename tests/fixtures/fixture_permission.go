@@ -0,0 +1,35 @@
+package fixtures
+
+import (
+	"context"
+	"idm/inner/permission"
+)
+
+type FixturePermission struct {
+	permissions *permission.Repository
+}
+
+// NewFixturePermission - функция-конструктор, принимающая на вход permission.Repository
+func NewFixturePermission(permissions *permission.Repository) *FixturePermission {
+	return &FixturePermission{permissions}
+}
+
+// Permission создает тестовое разрешение и, если передан roleID, выдаёт его роли
+func (f *FixturePermission) Permission(
+	ctx context.Context,
+	name string,
+	roleID *int64,
+) int64 {
+	var result, err = f.permissions.CreatePermission(ctx, &permission.Entity{Name: name})
+	if err != nil {
+		panic(err)
+	}
+
+	if roleID != nil {
+		if err := f.permissions.GrantToRole(ctx, *roleID, result.Id); err != nil {
+			panic(err)
+		}
+	}
+
+	return result.Id
+}
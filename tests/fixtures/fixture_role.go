@@ -15,15 +15,14 @@ func NewFixtureRole(roles *role.Repository) *FixtureRole {
 	return &FixtureRole{roles}
 }
 
-// Role создает тестовую роль
+// Role создает тестовую роль и, если передан employeeID, назначает её сотруднику
 func (f *FixtureRole) Role(
 	ctx context.Context,
 	name string,
 	employeeID *int64,
 ) int64 {
 	roleEntity := &role.Entity{
-		Name:       name,
-		EmployeeID: employeeID,
+		Name: name,
 	}
 
 	var result, err = f.role.CreateRole(ctx, roleEntity)
@@ -31,22 +30,26 @@ func (f *FixtureRole) Role(
 		panic(err)
 	}
 
+	if employeeID != nil {
+		if err := f.role.AssignEmployee(ctx, role.AssignmentEntity{RoleId: result.Id, EmployeeId: *employeeID}); err != nil {
+			panic(err)
+		}
+	}
+
 	return result.Id
 }
 
 func (f *FixtureRole) RoleUpdate(
 	id int64,
 	name string,
-	employeeID *int64,
 	createAt time.Time,
 	updateAt time.Time,
 ) role.Entity {
 	roleEntity := role.Entity{
-		Id:         id,
-		Name:       name,
-		EmployeeID: employeeID,
-		CreatedAt:  createAt,
-		UpdatedAt:  updateAt,
+		Id:        id,
+		Name:      name,
+		CreatedAt: createAt,
+		UpdatedAt: updateAt,
 	}
 
 	return roleEntity
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/accessrequest"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestAccessRequestRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase()
+
+	repo := fixture.AccessRequestRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+	var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+
+	var request = func(employeeId, roleId, approverId int64, expiresAt time.Time) int64 {
+		created, err := repo.Create(appContext, &accessrequest.Entity{
+			EmployeeId:    employeeId,
+			RoleId:        roleId,
+			ApproverId:    approverId,
+			Justification: "on-call rotation",
+			ExpiresAt:     expiresAt,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return created.Id
+	}
+
+	t.Run("route to role owner, then to manager", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		carolID := fixtureEmployee.Employee(appContext, "Carol Doe")
+		roleID := fixtureRole.Role(appContext, "DBA", nil)
+		db.MustExec("UPDATE employees SET manager_id = $1 WHERE id = $2", bobID, aliceID)
+
+		approverID, err := repo.FindApproverId(appContext, aliceID, roleID)
+		a.Nil(err)
+		a.Equal(bobID, *approverID)
+
+		db.MustExec("UPDATE roles SET owner_id = $1 WHERE id = $2", carolID, roleID)
+		approverID, err = repo.FindApproverId(appContext, aliceID, roleID)
+		a.Nil(err)
+		a.Equal(carolID, *approverID)
+
+		// владелец сам запрашивает свою роль - у него руководителя нет
+		approverID, err = repo.FindApproverId(appContext, carolID, roleID)
+		a.Nil(err)
+		a.Nil(approverID)
+
+		clearDatabase()
+	})
+
+	t.Run("approve request and assign role", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		roleID := fixtureRole.Role(appContext, "DBA", nil)
+		requestID := request(aliceID, roleID, bobID, time.Now().Add(time.Hour))
+
+		awaiting, err := repo.FindPendingByApproverId(appContext, bobID)
+		a.Nil(err)
+		a.Len(awaiting, 1)
+		a.Equal("Alice Doe", awaiting[0].EmployeeName)
+		a.Equal("DBA", awaiting[0].RoleName)
+
+		isDecided, err := repo.Approve(appContext, requestID, "ok")
+		a.Nil(err)
+		a.True(isDecided)
+
+		isAssigned, err := repo.ExistsAssignment(appContext, aliceID, roleID)
+		a.Nil(err)
+		a.True(isAssigned)
+
+		isDecided, err = repo.Decide(appContext, requestID, accessrequest.StatusRejected, "")
+		a.Nil(err)
+		a.False(isDecided)
+
+		got, err := repo.FindById(appContext, requestID)
+		a.Nil(err)
+		a.Equal(accessrequest.StatusApproved, got.Status)
+		a.Equal("ok", got.DecisionComment)
+		a.NotNil(got.DecidedAt)
+
+		clearDatabase()
+	})
+
+	t.Run("expire overdue requests", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		roleID := fixtureRole.Role(appContext, "DBA", nil)
+		overdueID := request(aliceID, roleID, bobID, time.Now().Add(-time.Minute))
+
+		expired, err := repo.ExpirePending(appContext, time.Now())
+		a.Nil(err)
+		a.Len(expired, 1)
+		a.Equal(overdueID, expired[0].Id)
+		a.Equal(accessrequest.StatusExpired, expired[0].Status)
+
+		isDecided, err := repo.Approve(appContext, overdueID, "")
+		a.Nil(err)
+		a.False(isDecided)
+
+		clearDatabase()
+	})
+}
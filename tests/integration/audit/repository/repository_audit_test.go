@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/audit"
+	"idm/inner/common"
+	"idm/inner/employee"
+	"idm/inner/validator"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+)
+
+func TestAuditRepository(t *testing.T) {
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase()
+
+	repo := fixture.AuditRepository()
+	employees := fixture.EmployeeRepository()
+	appContext := common.WithCaller(context.Background(), common.Caller{
+		Subject:     "42",
+		SubjectType: "employee",
+		RequestId:   "req-1",
+	})
+
+	t.Run("employee mutations are written to audit log with caller", func(t *testing.T) {
+		created, err := employees.CreateEmployee(appContext, &employee.Entity{Name: "John Doe"})
+		a.Nil(err)
+		err = employees.UpdateEmployee(appContext, &employee.Entity{Id: created.Id, Name: "John Smith"})
+		a.Nil(err)
+		err = employees.DeleteEmployeeById(appContext, created.Id)
+		a.Nil(err)
+
+		got, total, err := repo.FindPage(appContext, audit.Filter{
+			EntityType: audit.EntityEmployee,
+			EntityId:   created.Id,
+			Limit:      10,
+		})
+
+		a.Nil(err)
+		a.Equal(int64(3), total)
+		a.Equal(audit.ActionDelete, got[0].Action)
+		a.Equal(audit.ActionUpdate, got[1].Action)
+		a.Equal(audit.ActionCreate, got[2].Action)
+		a.Equal("42", got[1].Actor)
+		a.Equal("req-1", got[1].RequestId)
+		a.Nil(got[2].BeforeData)
+
+		var before, after employee.Response
+		a.Nil(json.Unmarshal(got[1].BeforeData, &before))
+		a.Nil(json.Unmarshal(got[1].AfterData, &after))
+		a.Equal("John Doe", before.Name)
+		a.Equal("John Smith", after.Name)
+
+		clearDatabase()
+	})
+
+	t.Run("update of missing employee writes no event", func(t *testing.T) {
+		err := employees.UpdateEmployee(appContext, &employee.Entity{Id: 999999, Name: "Nobody"})
+		a.Nil(err)
+
+		_, total, err := repo.FindPage(appContext, audit.Filter{Limit: 10})
+
+		a.Nil(err)
+		a.Equal(int64(0), total)
+	})
+
+	t.Run("mutations without caller are attributed to system", func(t *testing.T) {
+		_, err := employees.CreateEmployee(context.Background(), &employee.Entity{Name: "Batch User"})
+		a.Nil(err)
+
+		got, total, err := repo.FindPage(context.Background(), audit.Filter{Actor: common.SystemActor, Limit: 10})
+
+		a.Nil(err)
+		a.Equal(int64(1), total)
+		a.Equal(audit.ActionCreate, got[0].Action)
+
+		clearDatabase()
+	})
+
+	t.Run("audit chain links every event and rejects modification", func(t *testing.T) {
+		service := audit.NewService(repo, validator.NewValidator())
+		created, err := employees.CreateEmployee(appContext, &employee.Entity{Name: "Jane Doe"})
+		a.Nil(err)
+		err = employees.UpdateEmployee(appContext, &employee.Entity{Id: created.Id, Name: "Jane Smith"})
+		a.Nil(err)
+
+		chain, err := repo.FindChainAfter(appContext, 0, 10)
+		a.Nil(err)
+		a.Len(chain, 2)
+		a.Equal(audit.GenesisHash, chain[0].PrevHash)
+		a.Equal(chain[0].Hash, chain[1].PrevHash)
+
+		got, err := service.VerifyChain(appContext)
+		a.Nil(err)
+		a.True(got.Valid)
+		a.Equal(int64(2), got.Checked)
+
+		_, err = db.Exec("UPDATE audit_chain SET actor = 'forged' WHERE seq = 1")
+		a.ErrorContains(err, "append-only")
+		_, err = db.Exec("DELETE FROM audit_chain WHERE seq = 1")
+		a.ErrorContains(err, "append-only")
+		_, err = db.Exec("TRUNCATE audit_chain")
+		a.ErrorContains(err, "append-only")
+		_, err = db.Exec("TRUNCATE audit_events")
+		a.ErrorContains(err, "append-only")
+		_, err = db.Exec("DELETE FROM audit_events")
+		a.ErrorContains(err, "append-only")
+
+		clearDatabase()
+	})
+}
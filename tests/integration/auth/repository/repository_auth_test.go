@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/auth"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestAuthRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	repo := fixture.AuthRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+
+	t.Run("upsert and find employee credential", func(t *testing.T) {
+		employeeID := fixtureEmployee.Employee(appContext, "John Doe")
+
+		a.Nil(repo.UpsertCredential(appContext, employeeID, "hash-1"))
+		a.Nil(repo.UpsertCredential(appContext, employeeID, "hash-2"))
+
+		got, err := repo.FindCredentialByEmployeeId(appContext, employeeID)
+		a.Nil(err)
+		a.Equal("hash-2", got.PasswordHash)
+
+		clearDatabase()
+	})
+
+	t.Run("create find and delete client", func(t *testing.T) {
+		created, err := repo.CreateClient(appContext, &auth.ClientEntity{ClientId: "billing", Name: "Billing", SecretHash: "hash"})
+		a.Nil(err)
+		a.NotZero(created.Id)
+
+		isExists, err := repo.ExistsClientByClientId(appContext, "billing")
+		a.Nil(err)
+		a.True(isExists)
+
+		isDeleted, err := repo.DeleteClientByClientId(appContext, "billing")
+		a.Nil(err)
+		a.True(isDeleted)
+
+		clearDatabase()
+	})
+
+	t.Run("create client with roles and replace them", func(t *testing.T) {
+		var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+		readerID := fixtureRole.Role(appContext, "READER", nil)
+		writerID := fixtureRole.Role(appContext, "WRITER", nil)
+
+		created, err := repo.CreateClient(appContext, &auth.ClientEntity{
+			ClientId: "hr-sync", Name: "HR sync", SecretHash: "hash", RoleIds: pq.Int64Array{readerID},
+		})
+		a.Nil(err)
+		a.Equal(pq.Int64Array{readerID}, created.RoleIds)
+
+		replaced, err := repo.ReplaceClientRoles(appContext, "hr-sync", []int64{writerID})
+		a.Nil(err)
+		a.Equal(pq.Int64Array{writerID}, replaced.RoleIds)
+
+		found, err := repo.FindClientByClientId(appContext, "hr-sync")
+		a.Nil(err)
+		a.Equal(pq.Int64Array{writerID}, found.RoleIds)
+
+		_, err = repo.ReplaceClientRoles(appContext, "unknown", []int64{writerID})
+		a.ErrorIs(err, sql.ErrNoRows)
+
+		missing, err := repo.FindMissingRoleIds(appContext, []int64{readerID, writerID + 100})
+		a.Nil(err)
+		a.Equal([]int64{writerID + 100}, missing)
+
+		clearDatabase()
+	})
+
+	t.Run("rotate refresh token only once", func(t *testing.T) {
+		employeeID := fixtureEmployee.Employee(appContext, "John Doe")
+		familyID := uuid.New().String()
+		expiresAt := time.Now().Add(time.Hour)
+
+		a.Nil(repo.CreateRefreshToken(appContext, &auth.RefreshTokenEntity{
+			TokenHash: auth.HashToken("first"), FamilyId: familyID, EmployeeId: employeeID, ExpiresAt: expiresAt,
+		}))
+		first, err := repo.FindRefreshTokenByHash(appContext, auth.HashToken("first"))
+		a.Nil(err)
+
+		isRotated, err := repo.RotateRefreshToken(appContext, first.Id, &auth.RefreshTokenEntity{
+			TokenHash: auth.HashToken("second"), FamilyId: familyID, EmployeeId: employeeID, ExpiresAt: expiresAt,
+		})
+		a.Nil(err)
+		a.True(isRotated)
+
+		// повторная ротация того же токена не проходит
+		isRotated, err = repo.RotateRefreshToken(appContext, first.Id, &auth.RefreshTokenEntity{
+			TokenHash: auth.HashToken("third"), FamilyId: familyID, EmployeeId: employeeID, ExpiresAt: expiresAt,
+		})
+		a.Nil(err)
+		a.False(isRotated)
+
+		a.Nil(repo.RevokeRefreshTokenFamily(appContext, familyID))
+		second, err := repo.FindRefreshTokenByHash(appContext, auth.HashToken("second"))
+		a.Nil(err)
+		a.True(second.RevokedAt.Valid)
+
+		clearDatabase()
+	})
+}
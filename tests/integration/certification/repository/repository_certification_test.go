@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/certification"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestCertificationRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase()
+
+	repo := fixture.CertificationRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+	var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+
+	var countAssignments = func(employeeId, roleId int64) int {
+		var count int
+		if err := db.Get(&count, "SELECT COUNT(*) FROM employee_roles WHERE employee_id = $1 AND role_id = $2", employeeId, roleId); err != nil {
+			panic(err)
+		}
+		return count
+	}
+
+	t.Run("route items to reviewers and apply decisions", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		carolID := fixtureEmployee.Employee(appContext, "Carol Doe")
+		dbaID := fixtureRole.Role(appContext, "DBA", nil)
+		// у Alice есть руководитель, у Bob проверяющим станет владелец роли
+		db.MustExec("UPDATE employees SET manager_id = $1 WHERE id = $2", bobID, aliceID)
+		db.MustExec("UPDATE roles SET owner_id = $1 WHERE id = $2", carolID, dbaID)
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, aliceID, dbaID))
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, bobID, dbaID))
+
+		campaign, err := repo.CreateCampaign(appContext, &certification.CampaignEntity{
+			Name: "DBA review", ScopeType: certification.ScopeRole, ScopeId: &dbaID,
+			Deadline: time.Now().Add(time.Hour),
+		})
+		a.Nil(err)
+		a.Equal(int64(2), campaign.TotalItems)
+
+		items, err := repo.FindItems(appContext, campaign.Id)
+		a.Nil(err)
+		a.Len(items, 2)
+		a.Equal(aliceID, items[0].EmployeeId)
+		a.Equal(bobID, *items[0].ReviewerId)
+		a.Equal(bobID, items[1].EmployeeId)
+		a.Equal(carolID, *items[1].ReviewerId)
+
+		awaiting, err := repo.FindPendingByReviewerId(appContext, carolID)
+		a.Nil(err)
+		a.Len(awaiting, 1)
+
+		isDecided, err := repo.Certify(appContext, items[0].Id, bobID, "still needed")
+		a.Nil(err)
+		a.True(isDecided)
+		isDecided, err = repo.Revoke(appContext, items[1].Id, carolID, "")
+		a.Nil(err)
+		a.True(isDecided)
+		// повторное решение не применяется
+		isDecided, err = repo.Certify(appContext, items[1].Id, carolID, "")
+		a.Nil(err)
+		a.False(isDecided)
+
+		a.Equal(1, countAssignments(aliceID, dbaID))
+		a.Equal(0, countAssignments(bobID, dbaID))
+
+		found, err := repo.FindById(appContext, campaign.Id)
+		a.Nil(err)
+		a.Equal(int64(1), found.CertifiedItems)
+		a.Equal(int64(1), found.RevokedItems)
+		a.Equal(int64(0), found.PendingItems)
+
+		clearDatabase()
+	})
+
+	t.Run("complete due campaigns", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		dbaID := fixtureRole.Role(appContext, "DBA", nil)
+		auditorID := fixtureRole.Role(appContext, "AUDITOR", nil)
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, aliceID, dbaID))
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, bobID, auditorID))
+
+		revoking, err := repo.CreateCampaign(appContext, &certification.CampaignEntity{
+			Name: "DBA review", ScopeType: certification.ScopeRole, ScopeId: &dbaID,
+			Deadline: time.Now().Add(time.Hour), AutoRevoke: true,
+		})
+		a.Nil(err)
+		keeping, err := repo.CreateCampaign(appContext, &certification.CampaignEntity{
+			Name: "Auditor review", ScopeType: certification.ScopeRole, ScopeId: &auditorID,
+			Deadline: time.Now().Add(time.Hour),
+		})
+		a.Nil(err)
+
+		// до срока ничего не закрывается
+		completed, err := repo.CompleteDue(appContext, time.Now())
+		a.Nil(err)
+		a.Len(completed, 0)
+
+		completed, err = repo.CompleteDue(appContext, time.Now().Add(2*time.Hour))
+		a.Nil(err)
+		a.Len(completed, 2)
+
+		found, err := repo.FindById(appContext, revoking.Id)
+		a.Nil(err)
+		a.Equal(certification.CampaignCompleted, found.Status)
+		a.Equal(int64(1), found.RevokedItems)
+		a.Equal(0, countAssignments(aliceID, dbaID))
+
+		found, err = repo.FindById(appContext, keeping.Id)
+		a.Nil(err)
+		a.Equal(int64(1), found.ExpiredItems)
+		a.Equal(1, countAssignments(bobID, auditorID))
+
+		clearDatabase()
+	})
+}
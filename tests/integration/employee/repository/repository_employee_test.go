@@ -4,9 +4,15 @@ import (
 	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"idm/inner/database"
+	"idm/inner/employee"
+	"idm/inner/pagination"
+	"idm/inner/role"
+	"idm/inner/validator"
 	"idm/tests/fixtures"
 	"idm/tests/testutils"
 	"log"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -46,7 +52,7 @@ func TestEmployeeRepository(t *testing.T) {
 		time.Sleep(100 * time.Millisecond)
 		log.Printf("EmployeeID -->> : %d", employeeID)
 
-		got, err := repo.FindById(appContext, employeeID)
+		got, err := repo.FindById(appContext, employeeID, false)
 
 		a.Nil(err)
 		a.NotEmpty(got)
@@ -66,7 +72,7 @@ func TestEmployeeRepository(t *testing.T) {
 		var newEmployeeId = fixtureEmployee.Employee(appContext, "John Sena")
 		a.NotZero(newEmployeeId)
 
-		expt, err := repo.FindById(appContext, newEmployeeId)
+		expt, err := repo.FindById(appContext, newEmployeeId, false)
 		a.Nil(err)
 		a.NotEmpty(expt)
 
@@ -84,7 +90,7 @@ func TestEmployeeRepository(t *testing.T) {
 	t.Run("create and find employee by id", func(t *testing.T) {
 		var newEmployeeId = fixtureEmployee.Employee(appContext, "John Doe")
 
-		got, err := repo.FindById(appContext, newEmployeeId)
+		got, err := repo.FindById(appContext, newEmployeeId, false)
 
 		a.Nil(err)
 		a.NotEmpty(got)
@@ -116,10 +122,10 @@ func TestEmployeeRepository(t *testing.T) {
 		_ = fixtureEmployee.Employee(appContext, "John Sena")
 
 		// делаем промежуточную проверку на наличие сотрудника
-		got1, err := repo.FindById(appContext, newEmployeeId1)
+		got1, err := repo.FindById(appContext, newEmployeeId1, false)
 		t.Log("Result Set: ", got1.Name, " ", err)
 
-		got, err := repo.FindAllEmployees(appContext)
+		got, err := repo.FindAllEmployees(appContext, false)
 
 		a.Nil(err)
 		a.NotEmpty(got)
@@ -137,7 +143,7 @@ func TestEmployeeRepository(t *testing.T) {
 		employeeTwoId := fixtureEmployee.Employee(appContext, "Test2 2Name")
 		var ids = []int64{employeeOneId, employeeTwoId}
 
-		got, err := repo.FindAllEmployeesByIds(appContext, ids)
+		got, err := repo.FindAllEmployeesByIds(appContext, ids, false)
 
 		a.Nil(err)
 		a.NotEmpty(got)
@@ -158,7 +164,7 @@ func TestEmployeeRepository(t *testing.T) {
 		err := repo.DeleteAllEmployeesByIds(appContext, ids)
 		a.Nil(err, "Delete should not return error")
 
-		res, err := repo.FindById(appContext, employeeTwoId)
+		res, err := repo.FindById(appContext, employeeTwoId, false)
 		log.Println("Result Set: ", res.Name, " ", err)
 
 		a.Error(err, "Should return error after deletion")
@@ -171,8 +177,8 @@ func TestEmployeeRepository(t *testing.T) {
 		}
 
 		// Проверка, что оба сотрудника удалены
-		_, err1 := repo.FindById(appContext, employeeOneId)
-		_, err2 := repo.FindById(appContext, employeeTwoId)
+		_, err1 := repo.FindById(appContext, employeeOneId, false)
+		_, err2 := repo.FindById(appContext, employeeTwoId, false)
 		a.Error(err1)
 		a.Error(err2)
 		clearDatabase()
@@ -185,10 +191,422 @@ func TestEmployeeRepository(t *testing.T) {
 
 		a.Nil(err)
 
-		_, err = repo.FindById(appContext, employeeOneId)
+		_, err = repo.FindById(appContext, employeeOneId, false)
 		a.Error(err)
 		a.Contains(err.Error(), "no rows")
 
+		clearDatabase()
+	})
+	t.Run("soft deleted employee keeps roles and is restored", func(t *testing.T) {
+		var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+		employeeId := fixtureEmployee.Employee(appContext, "Test Name")
+		fixtureRole.Role(appContext, "ADMIN", &employeeId)
+
+		a.Nil(repo.DeleteEmployeeById(appContext, employeeId))
+
+		all, err := repo.FindAllEmployees(appContext, false)
+		a.Nil(err)
+		a.Empty(all)
+
+		deleted, err := repo.FindById(appContext, employeeId, true)
+		a.Nil(err)
+		a.NotNil(deleted.DeletedAt)
+
+		isRestored, err := repo.RestoreEmployee(appContext, employeeId)
+		a.Nil(err)
+		a.True(isRestored)
+
+		restored, err := repo.FindById(appContext, employeeId, false)
+		a.Nil(err)
+		a.Nil(restored.DeletedAt)
+
+		roles, err := repo.FindRolesByEmployeeId(appContext, employeeId)
+		a.Nil(err)
+		a.Len(roles, 1)
+
+		isRestored, err = repo.RestoreEmployee(appContext, employeeId) // повторно восстанавливать нечего
+		a.Nil(err)
+		a.False(isRestored)
+
+		clearDatabase()
+	})
+
+	t.Run("purge deleted employees after retention", func(t *testing.T) {
+		deletedId := fixtureEmployee.Employee(appContext, "Test Name")
+		activeId := fixtureEmployee.Employee(appContext, "Test2 2Name")
+		a.Nil(repo.DeleteEmployeeById(appContext, deletedId))
+
+		purged, err := repo.PurgeDeletedEmployees(appContext, time.Now().Add(-time.Hour))
+		a.Nil(err)
+		a.Zero(purged) // срок хранения ещё не истёк
+
+		purged, err = repo.PurgeDeletedEmployees(appContext, time.Now().Add(time.Second))
+		a.Nil(err)
+		a.Equal(int64(1), purged)
+
+		_, err = repo.FindById(appContext, deletedId, true)
+		a.Error(err)
+		_, err = repo.FindById(appContext, activeId, false)
+		a.Nil(err)
+
+		clearDatabase()
+	})
+
+	t.Run("terminate employee revokes all roles", func(t *testing.T) {
+		var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+		employeeId := fixtureEmployee.Employee(appContext, "Test Name")
+		fixtureRole.Role(appContext, "ADMIN", &employeeId)
+		fixtureRole.Role(appContext, "USER", &employeeId)
+
+		created, err := repo.FindById(appContext, employeeId, false)
+		a.Nil(err)
+		a.Equal(employee.StatusActive, created.Status)
+
+		isChanged, err := repo.ChangeStatus(appContext, employeeId, employee.StatusSuspended, employee.StatusTerminated)
+		a.Nil(err)
+		a.False(isChanged) // сотрудник не в состоянии suspended
+
+		isChanged, err = repo.ChangeStatus(appContext, employeeId, employee.StatusActive, employee.StatusTerminated)
+		a.Nil(err)
+		a.True(isChanged)
+
+		got, err := repo.FindById(appContext, employeeId, false)
+		a.Nil(err)
+		a.Equal(employee.StatusTerminated, got.Status)
+		a.NotNil(got.StatusChangedAt)
+
+		roles, err := repo.FindRolesByEmployeeId(appContext, employeeId)
+		a.Nil(err)
+		a.Empty(roles)
+
+		clearDatabase()
+	})
+
+	t.Run("create and update employee profile", func(t *testing.T) {
+		managerId := fixtureEmployee.Employee(appContext, "Manager Name")
+		var request = employee.CreateRequest{
+			Name:    "Test Name",
+			Profile: employee.Profile{Login: "jdoe", Email: "JDoe@example.com", Department: "IT", ManagerId: managerId},
+		}
+
+		created, err := repo.CreateEmployee(appContext, request.ToEntity())
+		a.Nil(err)
+		a.Equal("jdoe", *created.Login)
+		a.Equal(managerId, *created.ManagerId)
+		a.Equal("IT", created.Department)
+
+		isExists, err := repo.ExistsByLoginOrEmail(appContext, "JDOE", "", 0)
+		a.Nil(err)
+		a.True(isExists) // login сравнивается без учёта регистра
+		isExists, err = repo.ExistsByLoginOrEmail(appContext, "", "jdoe@example.com", created.Id)
+		a.Nil(err)
+		a.False(isExists) // сам сотрудник не учитывается
+
+		var update = employee.UpdateRequest{Id: created.Id, Name: "Test Name", Profile: employee.Profile{Title: "Engineer"}}
+		err = repo.UpdateEmployee(appContext, update.ToEntity())
+		a.Nil(err)
+
+		got, err := repo.FindById(appContext, created.Id, false)
+		a.Nil(err)
+		a.Equal("Engineer", got.Title)
+		a.Nil(got.Login)
+		a.Nil(got.ManagerId)
+
+		clearDatabase()
+	})
+
+	t.Run("org chart and manager cycle", func(t *testing.T) {
+		createWithManager := func(name string, managerId int64) employee.Entity {
+			var request = employee.CreateRequest{Name: name, Profile: employee.Profile{ManagerId: managerId}}
+			created, err := repo.CreateEmployee(appContext, request.ToEntity())
+			a.Nil(err)
+			return created
+		}
+		ceoId := fixtureEmployee.Employee(appContext, "Ceo Name")
+		cto := createWithManager("Cto Name", ceoId)
+		lead := createWithManager("Lead Name", cto.Id)
+		dev := createWithManager("Dev Name", lead.Id)
+
+		reports, err := repo.FindReports(appContext, ceoId, 1)
+		a.Nil(err)
+		a.Len(reports, 1)
+		a.Equal(cto.Id, reports[0].Id)
+
+		reports, err = repo.FindReports(appContext, ceoId, 3)
+		a.Nil(err)
+		a.Len(reports, 3)
+		a.Equal(dev.Id, reports[2].Id)
+		a.Equal(int64(3), reports[2].Level)
+
+		chain, err := repo.FindChainOfCommand(appContext, dev.Id)
+		a.Nil(err)
+		a.Len(chain, 3)
+		a.Equal(lead.Id, chain[0].Id)
+		a.Equal(ceoId, chain[2].Id)
+
+		// руководитель ceo не может быть его косвенным подчинённым
+		var update = employee.UpdateRequest{Id: ceoId, Name: "Ceo Name", Profile: employee.Profile{ManagerId: dev.Id}}
+		err = repo.UpdateEmployee(appContext, update.ToEntity())
+		a.ErrorIs(err, employee.ErrManagerCycle)
+
+		got, err := repo.FindById(appContext, ceoId, false)
+		a.Nil(err)
+		a.Nil(got.ManagerId)
+
+		clearDatabase()
+	})
+
+	t.Run("import employees with per-row savepoints", func(t *testing.T) {
+		var service = employee.NewService(repo, validator.NewValidator())
+		managerId := fixtureEmployee.Employee(appContext, "Manager Name")
+		var file = "login,name,email,managerId\n" +
+			"alice,Alice Doe,alice@example.com," + strconv.FormatInt(managerId, 10) + "\n" +
+			"bob,Bob Doe,alice@example.com,\n" + // email уже занят первой строкой
+			"carol,Carol Doe,,\n"
+
+		report, err := service.Import(
+			appContext,
+			employee.ImportRequest{Format: employee.ImportCsv, Mode: employee.ImportAtomic},
+			strings.NewReader(file),
+		)
+		a.Nil(err)
+		a.False(report.Committed)
+		a.Equal(1, report.Failed)
+		all, err := repo.FindAllEmployees(appContext, false)
+		a.Nil(err)
+		a.Len(all, 1) // atomic: откачены и успешные строки
+
+		report, err = service.Import(
+			appContext,
+			employee.ImportRequest{Format: employee.ImportCsv, Mode: employee.ImportBestEffort},
+			strings.NewReader(file),
+		)
+		a.Nil(err)
+		a.True(report.Committed)
+		a.Equal(2, report.Created)
+		a.Equal(employee.ImportFailed, report.Rows[1].Action)
+
+		alice, err := repo.FindById(appContext, report.Rows[0].Id, false)
+		a.Nil(err)
+		a.Equal(managerId, *alice.ManagerId)
+
+		// повторный импорт обновляет найденных по login
+		report, err = service.Import(
+			appContext,
+			employee.ImportRequest{Format: employee.ImportNdjson, Mode: employee.ImportAtomic},
+			strings.NewReader(`{"login":"ALICE","title":"Engineer","managerId":0}`),
+		)
+		a.Nil(err)
+		a.True(report.Committed)
+		a.Equal(1, report.Updated)
+		alice, err = repo.FindById(appContext, alice.Id, false)
+		a.Nil(err)
+		a.Equal("Engineer", alice.Title)
+		a.Equal("alice@example.com", *alice.Email)
+		a.Nil(alice.ManagerId)
+
+		clearDatabase()
+	})
+
+	t.Run("export employees with roles through cursor", func(t *testing.T) {
+		johnId := fixtureEmployee.Employee(appContext, "John Sena")
+		janeId := fixtureEmployee.Employee(appContext, "Jane Sena")
+		fixtureEmployee.Employee(appContext, "Other Name")
+		for _, name := range []string{"USER", "ADMIN"} {
+			var roleId int64
+			a.Nil(db.Get(&roleId, "INSERT INTO roles (name) VALUES ($1) RETURNING id", name))
+			a.Nil(repo.AssignRole(appContext, johnId, roleId))
+		}
+		a.Nil(repo.DeleteEmployeeById(appContext, janeId))
+
+		var exported []employee.ExportEntity
+		var collect = func(entity employee.ExportEntity) error {
+			exported = append(exported, entity)
+			return nil
+		}
+		a.Nil(repo.Export(appContext, "sena", false, collect))
+		a.Len(exported, 1)
+		a.Equal(johnId, exported[0].Id)
+		a.Equal([]string{"ADMIN", "USER"}, []string(exported[0].Roles))
+
+		exported = nil
+		a.Nil(repo.Export(appContext, "Sena", true, collect))
+		a.Len(exported, 2)
+		a.Equal(janeId, exported[1].Id)
+		a.Empty(exported[1].Roles)
+
+		// несколько порций курсора
+		db.MustExec("INSERT INTO employees (name) SELECT 'Bulk ' || g FROM generate_series(1, $1::int) g", database.CursorBatchSize+10)
+		var count int
+		var lastId int64
+		a.Nil(repo.Export(appContext, "", false, func(entity employee.ExportEntity) error {
+			a.Greater(entity.Id, lastId) // по порядку id
+			lastId = entity.Id
+			count++
+			return nil
+		}))
+		a.Equal(database.CursorBatchSize+12, count)
+
+		// ошибка fn прерывает обход
+		var stop = errors.New("client disconnected")
+		count = 0
+		err := repo.Export(appContext, "", false, func(entity employee.ExportEntity) error {
+			count++
+			return stop
+		})
+		a.ErrorIs(err, stop)
+		a.Equal(1, count)
+
+		clearDatabase()
+	})
+
+	t.Run("keyset pages ordered by name and id", func(t *testing.T) {
+		for _, name := range []string{"Dan", "Bob", "Anna", "Bob", "Carl"} {
+			fixtureEmployee.Employee(appContext, name)
+		}
+		deletedId := fixtureEmployee.Employee(appContext, "Bobby")
+		a.Nil(repo.DeleteEmployeeById(appContext, deletedId))
+
+		var byName = pagination.SortKey[employee.Entity]{Column: "name", Key: func(e employee.Entity) pagination.Key {
+			return pagination.Key{Value: e.Name, Id: e.Id}
+		}}
+		var fetch = func(query pagination.Query, textFilter string, includeDeleted bool) pagination.Page[employee.Entity] {
+			rows, err := repo.FindKeysetPage(appContext, query, textFilter, includeDeleted)
+			a.Nil(err)
+			return pagination.NewPage(rows, query, byName)
+		}
+
+		// вперёд по страницам из 2 строк: одинаковые имена различаются по id и не теряются на границе страниц
+		var query = pagination.Query{Order: pagination.Order{Column: "name"}, Limit: 2}
+		var pages []pagination.Page[employee.Entity]
+		var names []string
+		for {
+			page := fetch(query, "", false)
+			pages = append(pages, page)
+			for _, entity := range page.Rows {
+				names = append(names, entity.Name)
+			}
+			if page.Next == nil {
+				break
+			}
+			query.After = page.Next
+		}
+		a.Equal([]string{"Anna", "Bob", "Bob", "Carl", "Dan"}, names)
+		a.Len(pages, 3)
+		a.Nil(pages[0].Prev)
+
+		// назад от последней страницы - та же средняя страница
+		back := fetch(pagination.Query{Order: query.Order, Limit: 2, Before: pages[2].Prev}, "", false)
+		a.Equal(pages[1].Rows, back.Rows)
+		a.Equal(pages[1].Next, back.Next)
+		a.NotNil(back.Prev)
+
+		// по убыванию с фильтром и удалёнными
+		desc := fetch(pagination.Query{Order: pagination.Order{Column: "name", Desc: true}, Limit: 10}, "bob", true)
+		a.Len(desc.Rows, 3)
+		a.Equal("Bobby", desc.Rows[0].Name)
+		a.Greater(desc.Rows[1].Id, desc.Rows[2].Id)
+		a.Nil(desc.Next)
+
+		total, err := repo.CountEmployees(appContext, "bob", true)
+		a.Nil(err)
+		a.Equal(int64(3), total)
+		total, err = repo.CountEmployees(appContext, "", false)
+		a.Nil(err)
+		a.Equal(int64(5), total)
+
+		// позиция по created_at передаётся строкой RFC3339 и сравнивается как timestamptz
+		var byCreated = pagination.SortKey[employee.Entity]{Column: "created_at", Key: func(e employee.Entity) pagination.Key {
+			return pagination.Key{Value: e.CreatedAt.Format(time.RFC3339Nano), Id: e.Id}
+		}}
+		var seen = map[int64]bool{}
+		query = pagination.Query{Order: pagination.Order{Column: "created_at", Desc: true}, Limit: 1}
+		for {
+			rows, err := repo.FindKeysetPage(appContext, query, "", false)
+			a.Nil(err)
+			page := pagination.NewPage(rows, query, byCreated)
+			for _, entity := range page.Rows {
+				a.False(seen[entity.Id])
+				seen[entity.Id] = true
+			}
+			if page.Next == nil || len(seen) > 5 {
+				break
+			}
+			query.After = page.Next
+		}
+		a.Len(seen, 5)
+
+		clearDatabase()
+	})
+
+	t.Run("find filtered page and roles of several employees", func(t *testing.T) {
+		var create = func(name string, login string, email string) int64 {
+			request := employee.CreateRequest{Name: name, Profile: employee.Profile{Login: login, Email: email}}
+			created, err := repo.CreateEmployee(appContext, request.ToEntity())
+			if err != nil {
+				panic(err)
+			}
+			return created.Id
+		}
+		aliceId := create("Alice", "alice", "alice@example.com")
+		bobId := create("Bob", "", "Bob_Smith@example.com")
+		carlId := create("Carl", "al%ex", "")
+		deletedId := create("Deleted", "alina", "")
+		a.Nil(repo.DeleteEmployeeById(appContext, deletedId))
+
+		var find = func(field string, op string, value string, offset int64, limit int64) ([]int64, int64) {
+			rows, total, err := repo.FindFilteredPage(appContext, field, op, value, offset, limit)
+			a.Nil(err)
+			var ids = make([]int64, 0, len(rows))
+			for _, row := range rows {
+				ids = append(ids, row.Id)
+			}
+			return ids, total
+		}
+
+		ids, total := find("", "", "", 1, 1)
+		a.Equal([]int64{bobId}, ids)
+		a.Equal(int64(3), total) // удалённые не учитываются
+
+		// без login имя пользователя - email, без учёта регистра
+		ids, total = find(employee.FilterFieldUserName, employee.FilterOpEq, "BOB_SMITH@example.com", 0, 10)
+		a.Equal([]int64{bobId}, ids)
+		a.Equal(int64(1), total)
+		ids, _ = find(employee.FilterFieldUserName, employee.FilterOpEq, "alice@example.com", 0, 10)
+		a.Empty(ids) // email не имя пользователя, если есть login
+
+		ids, total = find(employee.FilterFieldUserName, employee.FilterOpSw, "AL", 0, 10)
+		a.Equal([]int64{aliceId, carlId}, ids)
+		a.Equal(int64(2), total)
+
+		// спецсимволы LIKE в значении сравниваются буквально
+		ids, _ = find(employee.FilterFieldUserName, employee.FilterOpCo, "%e", 0, 10)
+		a.Equal([]int64{carlId}, ids)
+		ids, _ = find(employee.FilterFieldUserName, employee.FilterOpCo, "b_s", 0, 10)
+		a.Equal([]int64{bobId}, ids)
+		ids, _ = find(employee.FilterFieldUserName, employee.FilterOpCo, "l_c", 0, 10)
+		a.Empty(ids)
+
+		ids, _ = find(employee.FilterFieldId, employee.FilterOpEq, strconv.FormatInt(carlId, 10), 0, 10)
+		a.Equal([]int64{carlId}, ids)
+		ids, total = find(employee.FilterFieldId, employee.FilterOpEq, "carl", 0, 10)
+		a.Empty(ids)
+		a.Zero(total)
+
+		var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+		adminId := fixtureRole.Role(appContext, "ADMIN", &aliceId)
+		fixtureRole.Role(appContext, "AUDITOR", &carlId)
+		a.Nil(fixture.RoleRepository().AssignEmployee(appContext, role.AssignmentEntity{RoleId: adminId, EmployeeId: carlId}))
+
+		roles, err := repo.FindRolesByEmployeeIds(appContext, []int64{aliceId, bobId, carlId})
+		a.Nil(err)
+		a.Len(roles, 3)
+		a.Equal(aliceId, roles[0].EmployeeId)
+		a.Equal("ADMIN", roles[0].Name)
+		a.Equal(carlId, roles[1].EmployeeId)
+		a.Equal(adminId, roles[1].Id)
+		a.Equal("AUDITOR", roles[2].Name)
+
 		clearDatabase()
 	})
 }
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/group"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+)
+
+func TestGroupRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase() // миграции заполняют справочник разрешений API, начинаем с пустых таблиц
+
+	repo := fixture.GroupRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+	var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+	var fixturePermission = fixtures.NewFixturePermission(fixture.PermissionRepository())
+
+	var createGroup = func(name string) int64 {
+		created, err := repo.CreateGroup(appContext, &group.Entity{Name: name})
+		if err != nil {
+			panic(err)
+		}
+		return created.Id
+	}
+
+	t.Run("resolve roles through nested groups", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		adminID := fixtureRole.Role(appContext, "ADMIN", &aliceID)
+		_ = fixturePermission.Permission(appContext, "employees:delete", &adminID)
+		staffID := createGroup("Staff")
+		opsID := createGroup("Ops")
+
+		// Bob -> Ops -> Staff, роль выдана Staff
+		a.Nil(repo.AddEmployee(appContext, opsID, bobID))
+		a.Nil(repo.AddGroup(appContext, staffID, opsID))
+		a.Nil(repo.GrantRole(appContext, staffID, adminID))
+
+		roles, err := repo.FindEffectiveRolesByEmployeeId(appContext, bobID)
+		a.Nil(err)
+		a.Len(roles, 1)
+		a.Equal("ADMIN", roles[0].Name)
+		a.Equal("Staff", *roles[0].GroupName)
+
+		isGranted, err := fixture.PermissionRepository().ExistsEmployeePermission(appContext, bobID, "employees:delete")
+		a.Nil(err)
+		a.True(isGranted)
+
+		employees, err := fixture.RoleRepository().FindEffectiveEmployeesByRoleId(appContext, adminID)
+		a.Nil(err)
+		a.Len(employees, 2)
+		a.Equal(aliceID, employees[0].Id)
+		a.Nil(employees[0].GroupName)
+		a.Equal(bobID, employees[1].Id)
+
+		isRemoved, err := repo.RemoveGroup(appContext, staffID, opsID)
+		a.Nil(err)
+		a.True(isRemoved)
+
+		isGranted, err = fixture.PermissionRepository().ExistsEmployeePermission(appContext, bobID, "employees:delete")
+		a.Nil(err)
+		a.False(isGranted)
+
+		clearDatabase()
+	})
+
+	t.Run("effective roles of one employee match the view", func(t *testing.T) {
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		_ = fixtureEmployee.Employee(appContext, "Alice Doe")
+		adminID := fixtureRole.Role(appContext, "ADMIN", nil)
+		_ = fixtureRole.Role(appContext, "USER", &bobID)
+		auditorID := fixtureRole.Role(appContext, "AUDITOR", nil)
+		db.MustExec("INSERT INTO role_inheritance (role_id, inherited_role_id) VALUES ($1, $2)", adminID, auditorID)
+		staffID := createGroup("Staff")
+		opsID := createGroup("Ops")
+		a.Nil(repo.AddEmployee(appContext, opsID, bobID))
+		a.Nil(repo.AddGroup(appContext, staffID, opsID))
+		a.Nil(repo.GrantRole(appContext, staffID, adminID))
+
+		type row struct {
+			EmployeeId int64  `db:"employee_id"`
+			RoleId     int64  `db:"role_id"`
+			GroupId    *int64 `db:"group_id"`
+		}
+		var fromView, fromFunction []row
+		a.Nil(db.Select(&fromView, "SELECT employee_id, role_id, group_id FROM employee_effective_roles WHERE employee_id = $1 ORDER BY role_id, group_id", bobID))
+		a.Nil(db.Select(&fromFunction, "SELECT employee_id, role_id, group_id FROM employee_effective_roles_of($1) ORDER BY role_id, group_id", bobID))
+		a.Len(fromView, 3) // USER напрямую, ADMIN и подразумеваемый AUDITOR через Staff
+		a.Equal(fromView, fromFunction)
+
+		clearDatabase()
+	})
+
+	t.Run("reject nesting cycle", func(t *testing.T) {
+		firstID := createGroup("First")
+		secondID := createGroup("Second")
+		thirdID := createGroup("Third")
+
+		a.Nil(repo.AddGroup(appContext, firstID, secondID))
+		a.Nil(repo.AddGroup(appContext, secondID, thirdID))
+
+		err := repo.AddGroup(appContext, thirdID, firstID)
+		a.ErrorIs(err, group.ErrGroupCycle)
+
+		members, err := repo.FindGroupMembers(appContext, thirdID)
+		a.Nil(err)
+		a.Empty(members)
+
+		clearDatabase()
+	})
+}
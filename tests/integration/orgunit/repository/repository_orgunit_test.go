@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/orgunit"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+)
+
+func TestOrgUnitRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	repo := fixture.OrgUnitRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+
+	var createUnit = func(name string, parentId *int64) int64 {
+		created, err := repo.CreateOrgUnit(appContext, &orgunit.Entity{Name: name, ParentId: parentId})
+		if err != nil {
+			panic(err)
+		}
+		return created.Id
+	}
+
+	t.Run("reject moving unit into its own subtree", func(t *testing.T) {
+		rootID := createUnit("Company", nil)
+		itID := createUnit("IT", &rootID)
+		backendID := createUnit("Backend", &itID)
+
+		_, err := repo.UpdateOrgUnit(appContext, &orgunit.Entity{Id: rootID, Name: "Company", ParentId: &backendID})
+		a.ErrorIs(err, orgunit.ErrParentCycle)
+
+		updated, err := repo.UpdateOrgUnit(appContext, &orgunit.Entity{Id: backendID, Name: "Backend", ParentId: &rootID})
+		a.Nil(err)
+		a.Equal(rootID, *updated.ParentId)
+
+		clearDatabase()
+	})
+
+	t.Run("move employees and list members with subunits", func(t *testing.T) {
+		rootID := createUnit("Company", nil)
+		itID := createUnit("IT", &rootID)
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+
+		moved, err := repo.MoveEmployees(appContext, rootID, []int64{aliceID})
+		a.Nil(err)
+		a.Equal(int64(1), moved)
+		moved, err = repo.MoveEmployees(appContext, itID, []int64{aliceID, bobID})
+		a.Nil(err)
+		a.Equal(int64(2), moved)
+
+		members, total, err := repo.FindMembersPage(appContext, orgunit.MembersFilter{OrgUnitId: rootID, Limit: 10})
+		a.Nil(err)
+		a.Equal(int64(0), total)
+		a.Empty(members)
+
+		members, total, err = repo.FindMembersPage(appContext,
+			orgunit.MembersFilter{OrgUnitId: rootID, IncludeSubunits: true, Limit: 1, Offset: 1})
+		a.Nil(err)
+		a.Equal(int64(2), total)
+		a.Len(members, 1)
+		a.Equal("Bob Doe", members[0].Name)
+
+		children, employees, err := repo.FindUsage(appContext, itID)
+		a.Nil(err)
+		a.Equal(int64(0), children)
+		a.Equal(int64(2), employees)
+
+		isRemoved, err := repo.RemoveMember(appContext, itID, bobID)
+		a.Nil(err)
+		a.True(isRemoved)
+		isRemoved, err = repo.RemoveMember(appContext, itID, bobID)
+		a.Nil(err)
+		a.False(isRemoved)
+
+		clearDatabase()
+	})
+}
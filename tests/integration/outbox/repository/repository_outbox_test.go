@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"idm/inner/common"
+	"idm/inner/employee"
+	"idm/inner/outbox"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink - подписчик, запоминающий типы полученных событий
+type recordingSink struct {
+	mu    sync.Mutex
+	types []string
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Publish(_ context.Context, message outbox.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types = append(s.types, message.Type)
+	return nil
+}
+
+func TestOutboxRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase()
+
+	repo := fixture.OutboxRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+	var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+	var logger = &common.Logger{Logger: zap.NewNop()}
+
+	var countEvents = func() int {
+		var count int
+		if err := db.Get(&count, "SELECT COUNT(*) FROM outbox"); err != nil {
+			panic(err)
+		}
+		return count
+	}
+
+	t.Run("write events in transaction of change", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		dbaID := fixtureRole.Role(appContext, "DBA", nil)
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, aliceID, dbaID))
+		a.Equal(4, countEvents())
+
+		// откат изменения откатывает и событие: Bob не может стать руководителем своего руководителя
+		a.Nil(fixture.EmployeeRepository().UpdateEmployee(appContext, &employee.Entity{
+			Id: bobID, Name: "Bob Doe", ManagerId: &aliceID,
+		}))
+		err := fixture.EmployeeRepository().UpdateEmployee(appContext, &employee.Entity{
+			Id: aliceID, Name: "Alice Doe", ManagerId: &bobID,
+		})
+		a.ErrorIs(err, employee.ErrManagerCycle)
+		a.Equal(5, countEvents())
+
+		events, err := repo.ClaimDue(appContext, time.Now(), time.Minute, 10)
+		a.Nil(err)
+		a.Len(events, 5)
+		var types []string
+		for _, event := range events {
+			types = append(types, event.EventType)
+		}
+		a.Equal([]string{
+			outbox.EmployeeCreated, outbox.EmployeeCreated, outbox.RoleCreated, outbox.RoleAssigned, outbox.EmployeeUpdated,
+		}, types)
+
+		var assigned map[string]int64
+		a.Nil(json.Unmarshal(events[3].Payload, &assigned))
+		a.Equal(map[string]int64{"employeeId": aliceID, "roleId": dbaID}, assigned)
+
+		// захваченные события недоступны до истечения захвата
+		again, err := repo.ClaimDue(appContext, time.Now(), time.Minute, 10)
+		a.Nil(err)
+		a.Empty(again)
+
+		clearDatabase()
+	})
+
+	t.Run("drain publishes pending events and remembers delivery", func(t *testing.T) {
+		fixtureEmployee.Employee(appContext, "Carol Doe")
+		fixtureRole.Role(appContext, "Auditor", nil)
+		sink := &recordingSink{}
+		dispatcher := outbox.NewDispatcher(repo, time.Hour, logger, sink)
+
+		a.Nil(dispatcher.Drain(appContext))
+		a.Equal([]string{outbox.EmployeeCreated, outbox.RoleCreated}, sink.types)
+
+		var published int
+		a.Nil(db.Get(&published, "SELECT COUNT(*) FROM outbox WHERE status = 'published' AND 'recording' = ANY(delivered_to)"))
+		a.Equal(2, published)
+
+		purged, err := repo.PurgePublished(appContext, time.Now().Add(time.Minute))
+		a.Nil(err)
+		a.Equal(int64(2), purged)
+
+		clearDatabase()
+	})
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/auth"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+)
+
+func TestPermissionRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase() // миграции заполняют справочник разрешений API, начинаем с пустых таблиц
+
+	repo := fixture.PermissionRepository()
+	var fixturePermission = fixtures.NewFixturePermission(repo)
+	var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+
+	t.Run("create and find permission by id", func(t *testing.T) {
+		permissionID := fixturePermission.Permission(appContext, "employees:read", nil)
+
+		got, err := repo.FindById(appContext, permissionID)
+
+		a.Nil(err)
+		a.Equal(permissionID, got.Id)
+		a.Equal("employees:read", got.Name)
+		a.NotEmpty(got.CreatedAt)
+
+		clearDatabase()
+	})
+
+	t.Run("grant and revoke permission for role", func(t *testing.T) {
+		roleID := fixtureRole.Role(appContext, "ADMIN", nil)
+		permissionID := fixturePermission.Permission(appContext, "employees:delete", &roleID)
+
+		got, err := repo.FindPermissionsByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Len(got, 1)
+		a.Equal(permissionID, got[0].Id)
+
+		isRevoked, err := repo.RevokeFromRole(appContext, roleID, permissionID)
+		a.Nil(err)
+		a.True(isRevoked)
+
+		got, err = repo.FindPermissionsByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Empty(got)
+
+		clearDatabase()
+	})
+
+	t.Run("resolve effective permissions through employee roles", func(t *testing.T) {
+		empID := fixtureEmployee.Employee(appContext, "John Doe")
+		adminID := fixtureRole.Role(appContext, "ADMIN", &empID)
+		userID := fixtureRole.Role(appContext, "USER", &empID)
+		readID := fixturePermission.Permission(appContext, "employees:read", &adminID)
+		a.Nil(repo.GrantToRole(appContext, userID, readID))
+		_ = fixturePermission.Permission(appContext, "employees:delete", &adminID)
+		_ = fixturePermission.Permission(appContext, "roles:write", nil)
+
+		grants, err := repo.FindEffectiveByEmployeeId(appContext, empID)
+		a.Nil(err)
+		a.Len(grants, 3) // employees:read через ADMIN и USER, employees:delete через ADMIN
+
+		isGranted, err := repo.ExistsEmployeePermission(appContext, empID, "employees:delete")
+		a.Nil(err)
+		a.True(isGranted)
+
+		isGranted, err = repo.ExistsEmployeePermission(appContext, empID, "roles:write")
+		a.Nil(err)
+		a.False(isGranted)
+
+		clearDatabase()
+	})
+
+	t.Run("resolve client permissions through client roles and inheritance", func(t *testing.T) {
+		syncID := fixtureRole.Role(appContext, "HR_SYNC", nil)
+		readerID := fixtureRole.Role(appContext, "READER", nil)
+		removedID := fixtureRole.Role(appContext, "REMOVED", nil)
+		_ = fixturePermission.Permission(appContext, "scim:write", &syncID)
+		_ = fixturePermission.Permission(appContext, "employees:read", &readerID)
+		_ = fixturePermission.Permission(appContext, "employees:delete", &removedID)
+		db.MustExec("INSERT INTO role_inheritance (role_id, inherited_role_id) VALUES ($1, $2)", syncID, readerID)
+
+		_, err := fixture.AuthRepository().CreateClient(appContext, &auth.ClientEntity{
+			ClientId: "hr-sync", Name: "HR sync", SecretHash: "hash", RoleIds: pq.Int64Array{syncID, removedID},
+		})
+		a.Nil(err)
+		a.Nil(fixture.RoleRepository().DeleteRoleById(appContext, removedID))
+
+		names, err := repo.FindClientPermissionNames(appContext, "hr-sync")
+		a.Nil(err)
+		a.Equal([]string{"employees:read", "scim:write"}, names) // удалённая роль разрешений не даёт
+
+		names, err = repo.FindClientPermissionNames(appContext, "unknown")
+		a.Nil(err)
+		a.Empty(names)
+
+		clearDatabase()
+	})
+}
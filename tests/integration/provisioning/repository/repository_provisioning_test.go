@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/provisioning"
+	"idm/inner/scim"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestProvisioningRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase()
+
+	repo := fixture.ProvisioningRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+	var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+
+	var createTarget = func(name string) provisioning.TargetEntity {
+		target, err := repo.CreateTarget(appContext, &provisioning.TargetEntity{
+			Name: name, Type: provisioning.TargetScim, BaseUrl: "https://" + name + ".example.com/scim/v2",
+			Token: "secret", Enabled: true,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return target
+	}
+
+	t.Run("capture changes made after target registration", func(t *testing.T) {
+		// сотрудник до регистрации целевой системы не попадает в очередь - его передаёт сверка
+		fixtureEmployee.Employee(appContext, "Early Doe")
+		target := createTarget("slack")
+
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		dbaID := fixtureRole.Role(appContext, "DBA", nil)
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, aliceID, dbaID))
+
+		captured, err := repo.CaptureChanges(appContext)
+		a.Nil(err)
+		// Alice (User) и DBA (Group): назначение роли не дублирует операцию по группе
+		a.Equal(int64(2), captured)
+
+		operations, err := repo.FindOperations(appContext, target.Id, provisioning.OperationPending)
+		a.Nil(err)
+		a.Len(operations, 2)
+		var resources = map[string]int64{}
+		for _, operation := range operations {
+			resources[operation.ResourceType] = operation.ResourceId
+		}
+		a.Equal(map[string]int64{scim.ResourceUser: aliceID, scim.ResourceGroup: dbaID}, resources)
+
+		// повторный запуск без новых изменений ничего не добавляет
+		captured, err = repo.CaptureChanges(appContext)
+		a.Nil(err)
+		a.Equal(int64(0), captured)
+
+		clearDatabase()
+	})
+
+	t.Run("claim due operations and record attempts", func(t *testing.T) {
+		target := createTarget("jira")
+		a.Nil(repo.Enqueue(appContext, target.Id, scim.ResourceUser, 7))
+		a.Nil(repo.Enqueue(appContext, target.Id, scim.ResourceUser, 7)) // уже ждёт доставки
+		a.Nil(repo.Enqueue(appContext, target.Id, scim.ResourceGroup, 5))
+
+		claimed, err := repo.ClaimDue(appContext, time.Now(), time.Minute, 10)
+		a.Nil(err)
+		a.Len(claimed, 2)
+		a.Equal(provisioning.OperationDelivering, claimed[0].Status)
+
+		// захваченные операции недоступны до истечения захвата
+		again, err := repo.ClaimDue(appContext, time.Now(), time.Minute, 10)
+		a.Nil(err)
+		a.Empty(again)
+
+		claimed[0].Status = provisioning.OperationPending
+		claimed[0].Attempts = 1
+		claimed[0].NextAttemptAt = time.Now().Add(time.Hour)
+		claimed[0].LastError = "target responded with status 503"
+		a.Nil(repo.FinishOperation(appContext, &claimed[0]))
+		claimed[1].Status = provisioning.OperationDelivered
+		claimed[1].Attempts = 1
+		a.Nil(repo.FinishOperation(appContext, &claimed[1]))
+
+		pending, err := repo.FindOperations(appContext, target.Id, provisioning.OperationPending)
+		a.Nil(err)
+		a.Len(pending, 1)
+		a.Equal(1, pending[0].Attempts)
+		a.Equal("target responded with status 503", pending[0].LastError)
+
+		// отключённая целевая система не доставляется
+		_, err = repo.UpdateTarget(appContext, &provisioning.TargetEntity{
+			Id: target.Id, Name: target.Name, BaseUrl: target.BaseUrl, Enabled: false,
+		})
+		a.Nil(err)
+		claimed, err = repo.ClaimDue(appContext, time.Now().Add(2*time.Hour), time.Minute, 10)
+		a.Nil(err)
+		a.Empty(claimed)
+
+		clearDatabase()
+	})
+
+	t.Run("keep known external id when delivery fails", func(t *testing.T) {
+		target := createTarget("github")
+		var now = time.Now()
+		a.Nil(repo.SaveSyncState(appContext, &provisioning.SyncStateEntity{
+			TargetId: target.Id, ResourceType: scim.ResourceUser, ResourceId: 7,
+			ExternalId: "u-1", Status: provisioning.SyncSynced, SyncedAt: &now,
+		}))
+		a.Nil(repo.SaveSyncState(appContext, &provisioning.SyncStateEntity{
+			TargetId: target.Id, ResourceType: scim.ResourceUser, ResourceId: 7,
+			Status: provisioning.SyncFailed, LastError: "target responded with status 500",
+		}))
+
+		state, err := repo.FindSyncState(appContext, target.Id, scim.ResourceUser, 7)
+		a.Nil(err)
+		a.Equal("u-1", state.ExternalId)
+		a.Equal(provisioning.SyncFailed, state.Status)
+		a.NotNil(state.SyncedAt)
+
+		a.Nil(repo.DeleteSyncState(appContext, target.Id, scim.ResourceUser, 7))
+		_, err = repo.FindSyncState(appContext, target.Id, scim.ResourceUser, 7)
+		a.ErrorIs(err, sql.ErrNoRows)
+
+		clearDatabase()
+	})
+
+	t.Run("update target keeps token when empty", func(t *testing.T) {
+		target := createTarget("zoom")
+
+		updated, err := repo.UpdateTarget(appContext, &provisioning.TargetEntity{
+			Id: target.Id, Name: "zoom-eu", BaseUrl: target.BaseUrl, Enabled: true,
+		})
+		a.Nil(err)
+		a.Equal("zoom-eu", updated.Name)
+		a.Equal("secret", updated.Token)
+
+		isExists, err := repo.ExistsTargetByName(appContext, "zoom-eu", 0)
+		a.Nil(err)
+		a.True(isExists)
+		isExists, err = repo.ExistsTargetByName(appContext, "zoom-eu", target.Id)
+		a.Nil(err)
+		a.False(isExists)
+
+		clearDatabase()
+	})
+}
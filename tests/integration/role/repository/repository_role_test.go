@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
+	"idm/inner/pagination"
 	"idm/inner/role"
 	"idm/tests/fixtures"
 	"idm/tests/testutils"
@@ -45,7 +46,7 @@ func TestRoleRepository(t *testing.T) {
 		empID := fixtureEmployee.Employee(appContext, "John Doe") // Создаём сотрудника
 		roleID := fixtureRole.Role(appContext, "DBA", &empID)     // Создаём роль с сотрудником
 
-		got, err := repo.FindById(appContext, roleID)
+		got, err := repo.FindById(appContext, roleID, false)
 
 		a.Nil(err)
 		a.NotEmpty(got)
@@ -64,7 +65,7 @@ func TestRoleRepository(t *testing.T) {
 		empID := fixtureEmployee.Employee(appContext, "John Doe")
 		roleID := fixtureRole.Role(appContext, "DBA", &empID)
 
-		var roleEntity = fixtureRole.RoleUpdate(roleID, "DBA", &empID, time.Now(), time.Now())
+		var roleEntity = fixtureRole.RoleUpdate(roleID, "DBA", time.Now(), time.Now())
 		err := repo.UpdateRole(appContext, &roleEntity)
 
 		a.Nil(err)
@@ -78,7 +79,7 @@ func TestRoleRepository(t *testing.T) {
 		roleId := fixtureRole.Role(appContext, "DBU", &empID1)
 		_ = fixtureRole.Role(appContext, "DBA", &empID2)
 
-		got, err := repo.FindAllRoles(appContext)
+		got, err := repo.FindAllRoles(appContext, false)
 
 		a.Nil(err)
 		a.NotEmpty(got)
@@ -98,7 +99,7 @@ func TestRoleRepository(t *testing.T) {
 		roleTwoId := fixtureRole.Role(appContext, "DBA", &empID2)
 		var ids = []int64{roleOneId, roleTwoId}
 
-		got, err := repo.FindAllRolesByIds(appContext, ids)
+		got, err := repo.FindAllRolesByIds(appContext, ids, false)
 
 		a.Nil(err)
 		a.NotEmpty(got)
@@ -121,7 +122,7 @@ func TestRoleRepository(t *testing.T) {
 		// Assert - Проверка, что оба сотрудника удалены
 		a.Nil(err)
 		for _, id := range ids {
-			_, err := repo.FindById(appContext, id)
+			_, err := repo.FindById(appContext, id, false)
 			a.Error(err)
 			a.Contains(err.Error(), "no rows")
 		}
@@ -139,7 +140,7 @@ func TestRoleRepository(t *testing.T) {
 		a.Nil(err, "DeleteRoleById should not return error")
 
 		// Пытаемся найти удалённую роль
-		res, err := repo.FindById(appContext, roleID)
+		res, err := repo.FindById(appContext, roleID, false)
 		expected := role.Entity{}
 
 		// Проверяем, что роль не найдена
@@ -158,24 +159,274 @@ func TestRoleRepository(t *testing.T) {
 		clearDatabase()
 	})
 
-	t.Run("when delete employee, role should be deleted (CASCADE)", func(t *testing.T) {
+	t.Run("when delete employee, role assignment should be deleted (CASCADE)", func(t *testing.T) {
 		// Создаём сотрудника и роль
 		empID := fixtureEmployee.Employee(appContext, "John Doe")
 		roleID := fixtureRole.Role(appContext, "DBA", &empID)
 
 		// Проверяем, что роль привязана к сотруднику
-		role, err := repo.FindById(appContext, roleID)
-		assert.NoError(t, err, "Role should exist")
-		assert.Equal(t, empID, *role.EmployeeID, "Role should be linked to employee")
+		employees, err := repo.FindEmployeesByRoleId(appContext, roleID)
+		assert.NoError(t, err, "Role employees should be found")
+		assert.Len(t, employees, 1)
+		assert.Equal(t, empID, employees[0].Id, "Role should be linked to employee")
 
-		// Удаляем сотрудника (должно удалить роль из-за ON DELETE CASCADE)
+		// Удаляем сотрудника (должно удалить назначение из-за ON DELETE CASCADE)
 		err = employeeRepo.DeleteEmployeeById(appContext, empID)
 		assert.NoError(t, err, "DeleteEmployeeById should not fail")
 
-		// Проверяем, что роль удалилась
-		_, err = repo.FindById(appContext, roleID)
-		assert.Error(t, err, "Role should be deleted after employee deletion")
-		assert.Contains(t, err.Error(), "no rows in result set", "Error should be 'not found'")
+		// Проверяем, что назначение удалилось, а сама роль осталась
+		employees, err = repo.FindEmployeesByRoleId(appContext, roleID)
+		assert.NoError(t, err)
+		assert.Empty(t, employees, "Role assignment should be deleted after employee deletion")
+
+		_, err = repo.FindById(appContext, roleID, false)
+		assert.NoError(t, err, "Role should still exist after employee deletion")
+
+		clearDatabase()
+	})
+
+	t.Run("assign and revoke role for many employees", func(t *testing.T) {
+		empID1 := fixtureEmployee.Employee(appContext, "John Doe")
+		empID2 := fixtureEmployee.Employee(appContext, "Alice Marcus")
+		roleID := fixtureRole.Role(appContext, "ADMIN", nil)
+
+		a.Nil(repo.AssignEmployee(appContext, role.AssignmentEntity{RoleId: roleID, EmployeeId: empID1}))
+		a.Nil(repo.AssignEmployee(appContext, role.AssignmentEntity{RoleId: roleID, EmployeeId: empID2}))
+		a.Nil(repo.AssignEmployee(appContext, role.AssignmentEntity{RoleId: roleID, EmployeeId: empID2})) // повторное назначение игнорируется
+
+		employees, err := repo.FindEmployeesByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Len(employees, 2)
+
+		isRevoked, err := repo.RevokeEmployee(appContext, roleID, empID1)
+		a.Nil(err)
+		a.True(isRevoked)
+
+		isRevoked, err = repo.RevokeEmployee(appContext, roleID, empID1)
+		a.Nil(err)
+		a.False(isRevoked)
+
+		employees, err = repo.FindEmployeesByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Len(employees, 1)
+		a.Equal(empID2, employees[0].Id)
+
+		clearDatabase()
+	})
+	t.Run("restore soft deleted role and reuse its name", func(t *testing.T) {
+		empID := fixtureEmployee.Employee(appContext, "John Doe")
+		roleID := fixtureRole.Role(appContext, "ADMIN", &empID)
+
+		a.Nil(repo.DeleteRoleById(appContext, roleID))
+
+		isExists, err := repo.ExistsByName(appContext, "ADMIN")
+		a.Nil(err)
+		a.False(isExists)
+
+		employees, err := repo.FindEmployeesByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Len(employees, 1) // назначение сохранено
+
+		isRestored, err := repo.RestoreRole(appContext, roleID)
+		a.Nil(err)
+		a.True(isRestored)
+
+		got, err := repo.FindById(appContext, roleID, false)
+		a.Nil(err)
+		a.Nil(got.DeletedAt)
+
+		a.Nil(repo.DeleteRoleById(appContext, roleID))
+		newRoleID := fixtureRole.Role(appContext, "ADMIN", nil) // имя удалённой роли свободно
+		a.NotEqual(roleID, newRoleID)
+
+		clearDatabase()
+	})
+
+	t.Run("purge deleted roles after retention", func(t *testing.T) {
+		roleID := fixtureRole.Role(appContext, "ADMIN", nil)
+		a.Nil(repo.DeleteRoleById(appContext, roleID))
+
+		purged, err := repo.PurgeDeletedRoles(appContext, time.Now().Add(time.Second))
+		a.Nil(err)
+		a.Equal(int64(1), purged)
+
+		_, err = repo.FindById(appContext, roleID, true)
+		a.Error(err)
+
+		clearDatabase()
+	})
+
+	t.Run("inherited role grants its permissions and cycles are rejected", func(t *testing.T) {
+		empID := fixtureEmployee.Employee(appContext, "John Doe")
+		adminID := fixtureRole.Role(appContext, "ADMIN", &empID)
+		userID := fixtureRole.Role(appContext, "USER", nil)
+		_ = fixtures.NewFixturePermission(fixture.PermissionRepository()).Permission(appContext, "employees:read", &userID)
+
+		admin, err := repo.FindById(appContext, adminID, false)
+		a.Nil(err)
+		admin.Inherits = []int64{userID}
+		a.Nil(repo.UpdateRole(appContext, &admin))
+
+		implied, err := repo.FindImpliedRoles(appContext, adminID)
+		a.Nil(err)
+		a.Len(implied, 2)
+		a.Equal(adminID, implied[0].Id)
+		a.Equal(userID, implied[1].Id)
+		a.Equal(int64(1), implied[1].Level)
+
+		isGranted, err := fixture.PermissionRepository().ExistsEmployeePermission(appContext, empID, "employees:read")
+		a.Nil(err)
+		a.True(isGranted)
+
+		user, err := repo.FindById(appContext, userID, false)
+		a.Nil(err)
+		user.Inherits = []int64{adminID}
+		a.ErrorIs(repo.UpdateRole(appContext, &user), role.ErrRoleCycle)
+
+		admin.Inherits = []int64{}
+		a.Nil(repo.UpdateRole(appContext, &admin))
+		isGranted, err = fixture.PermissionRepository().ExistsEmployeePermission(appContext, empID, "employees:read")
+		a.Nil(err)
+		a.False(isGranted)
+
+		clearDatabase()
+	})
+
+	t.Run("expired assignments are ignored and revoked", func(t *testing.T) {
+		empID := fixtureEmployee.Employee(appContext, "John Doe")
+		roleID := fixtureRole.Role(appContext, "CONTRACTOR", nil)
+		_ = fixtures.NewFixturePermission(fixture.PermissionRepository()).Permission(appContext, "employees:read", &roleID)
+		validFrom := time.Now().Add(-2 * time.Hour)
+		validUntil := time.Now().Add(-time.Hour)
+
+		err := repo.AssignEmployee(appContext, role.AssignmentEntity{
+			RoleId: roleID, EmployeeId: empID, ValidFrom: &validFrom, ValidUntil: &validUntil,
+		})
+		a.Nil(err)
+
+		employees, err := repo.FindEmployeesByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Empty(employees)
+
+		isGranted, err := fixture.PermissionRepository().ExistsEmployeePermission(appContext, empID, "employees:read")
+		a.Nil(err)
+		a.False(isGranted)
+
+		expired, err := repo.RevokeExpiredAssignments(appContext, time.Now())
+		a.Nil(err)
+		a.Len(expired, 1)
+		a.Equal(empID, expired[0].EmployeeId)
+
+		expired, err = repo.RevokeExpiredAssignments(appContext, time.Now())
+		a.Nil(err)
+		a.Empty(expired)
+
+		clearDatabase()
+	})
+
+	t.Run("future assignments are ignored until they start", func(t *testing.T) {
+		empID := fixtureEmployee.Employee(appContext, "John Doe")
+		roleID := fixtureRole.Role(appContext, "CONTRACTOR", nil)
+		validFrom := time.Now().Add(48 * time.Hour)
+
+		a.Nil(repo.AssignEmployee(appContext, role.AssignmentEntity{RoleId: roleID, EmployeeId: empID, ValidFrom: &validFrom}))
+
+		employees, err := repo.FindEmployeesByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Empty(employees)
+
+		roles, err := fixture.EmployeeRepository().FindRolesByEmployeeId(appContext, empID)
+		a.Nil(err)
+		a.Empty(roles)
+
+		// повторное назначение без начала начинается сейчас и может закончиться раньше прежнего начала
+		validUntil := time.Now().Add(24 * time.Hour)
+		a.Nil(repo.AssignEmployee(appContext, role.AssignmentEntity{RoleId: roleID, EmployeeId: empID, ValidUntil: &validUntil}))
+
+		employees, err = repo.FindEmployeesByRoleId(appContext, roleID)
+		a.Nil(err)
+		a.Len(employees, 1)
+		a.True(employees[0].ValidFrom.Before(validUntil))
+
+		clearDatabase()
+	})
+
+	t.Run("export roles with assigned employees", func(t *testing.T) {
+		empID1 := fixtureEmployee.Employee(appContext, "John Doe")
+		empID2 := fixtureEmployee.Employee(appContext, "Alice Marcus")
+		adminID := fixtureRole.Role(appContext, "ADMIN", &empID2)
+		a.Nil(repo.AssignEmployee(appContext, role.AssignmentEntity{RoleId: adminID, EmployeeId: empID1}))
+		deletedID := fixtureRole.Role(appContext, "OLD_ADMIN", nil)
+		fixtureRole.Role(appContext, "USER", nil)
+		a.Nil(repo.DeleteRoleById(appContext, deletedID))
+
+		var exported []role.ExportEntity
+		var collect = func(entity role.ExportEntity) error {
+			exported = append(exported, entity)
+			return nil
+		}
+		a.Nil(repo.Export(appContext, "admin", false, collect))
+		a.Len(exported, 1)
+		a.Equal(adminID, exported[0].Id)
+		a.Equal([]int64{empID1, empID2}, []int64(exported[0].Employees))
+
+		exported = nil
+		a.Nil(repo.Export(appContext, "admin", true, collect))
+		a.Len(exported, 2)
+		a.NotNil(exported[1].DeletedAt)
+		a.Empty(exported[1].Employees)
+
+		clearDatabase()
+	})
+
+	t.Run("keyset pages of roles in both directions", func(t *testing.T) {
+		for _, name := range []string{"DBA", "ADMIN", "USER", "AUDITOR"} {
+			fixtureRole.Role(appContext, name, nil)
+		}
+		deletedID := fixtureRole.Role(appContext, "OLD_ADMIN", nil)
+		a.Nil(repo.DeleteRoleById(appContext, deletedID))
+
+		var byName = pagination.SortKey[role.Entity]{Column: "name", Key: func(e role.Entity) pagination.Key {
+			return pagination.Key{Value: e.Name, Id: e.Id}
+		}}
+		var names = func(page pagination.Page[role.Entity]) []string {
+			var result []string
+			for _, entity := range page.Rows {
+				result = append(result, entity.Name)
+			}
+			return result
+		}
+
+		var query = pagination.Query{Order: pagination.Order{Column: "name", Desc: true}, Limit: 3}
+		rows, err := repo.FindKeysetPage(appContext, query, "", false)
+		a.Nil(err)
+		first := pagination.NewPage(rows, query, byName)
+		a.Equal([]string{"USER", "DBA", "AUDITOR"}, names(first))
+		a.NotNil(first.Next)
+
+		query.After = first.Next
+		rows, err = repo.FindKeysetPage(appContext, query, "", false)
+		a.Nil(err)
+		second := pagination.NewPage(rows, query, byName)
+		a.Equal([]string{"ADMIN"}, names(second))
+		a.Nil(second.Next)
+
+		query.After, query.Before = nil, second.Prev
+		rows, err = repo.FindKeysetPage(appContext, query, "", false)
+		a.Nil(err)
+		a.Equal(names(first), names(pagination.NewPage(rows, query, byName)))
+
+		rows, err = repo.FindKeysetPage(appContext, pagination.Query{Order: pagination.Order{Column: "id"}, Limit: 10}, "admin", true)
+		a.Nil(err)
+		a.Len(rows, 2)
+		a.Less(rows[0].Id, rows[1].Id)
+
+		total, err := repo.CountRoles(appContext, "admin", true)
+		a.Nil(err)
+		a.Equal(int64(2), total)
+		total, err = repo.CountRoles(appContext, "", false)
+		a.Nil(err)
+		a.Equal(int64(4), total)
 
 		clearDatabase()
 	})
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/group"
+	"idm/inner/role"
+	"idm/inner/sod"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+)
+
+func TestSodRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase()
+
+	repo := fixture.SodRepository()
+	var fixtureEmployee = fixtures.NewFixtureEmployee(fixture.EmployeeRepository())
+	var fixtureRole = fixtures.NewFixtureRole(fixture.RoleRepository())
+
+	var rule = func(roleId, conflictingRoleId int64) int64 {
+		created, err := repo.CreateRule(appContext, &sod.Entity{RoleId: roleId, ConflictingRoleId: conflictingRoleId})
+		if err != nil {
+			panic(err)
+		}
+		return created.Id
+	}
+
+	t.Run("reject conflicting assignments", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		creatorID := fixtureRole.Role(appContext, "PAYMENT_CREATOR", nil)
+		approverID := fixtureRole.Role(appContext, "PAYMENT_APPROVER", nil)
+		seniorID := fixtureRole.Role(appContext, "SENIOR_ACCOUNTANT", nil)
+		db.MustExec("INSERT INTO role_inheritance (role_id, inherited_role_id) VALUES ($1, $2)", seniorID, approverID)
+		ruleID := rule(approverID, creatorID)
+
+		isExists, err := repo.ExistsRule(appContext, creatorID, approverID)
+		a.Nil(err)
+		a.True(isExists)
+
+		err = fixture.RoleRepository().AssignEmployee(appContext, role.AssignmentEntity{RoleId: creatorID, EmployeeId: aliceID})
+		a.Nil(err)
+
+		err = fixture.EmployeeRepository().AssignRole(appContext, aliceID, approverID)
+		a.True(errors.Is(err, sod.ErrViolation))
+
+		// роль, подразумевающая конфликтующую, тоже запрещена
+		err = fixture.RoleRepository().AssignEmployee(appContext, role.AssignmentEntity{RoleId: seniorID, EmployeeId: aliceID})
+		a.True(errors.Is(err, sod.ErrViolation))
+
+		var count int
+		a.Nil(db.Get(&count, "SELECT COUNT(*) FROM employee_roles WHERE employee_id = $1", aliceID))
+		a.Equal(1, count)
+
+		a.Nil(repo.DeleteRule(appContext, ruleID))
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, aliceID, approverID))
+
+		clearDatabase()
+	})
+
+	t.Run("reject conflicting grants through groups and inheritance", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		creatorID := fixtureRole.Role(appContext, "PAYMENT_CREATOR", &aliceID)
+		approverID := fixtureRole.Role(appContext, "PAYMENT_APPROVER", nil)
+		accountantID := fixtureRole.Role(appContext, "ACCOUNTANT", &aliceID)
+		ruleID := rule(creatorID, approverID)
+
+		groups := fixture.GroupRepository()
+		approvers, err := groups.CreateGroup(appContext, &group.Entity{Name: "Approvers"})
+		a.Nil(err)
+		team, err := groups.CreateGroup(appContext, &group.Entity{Name: "Team"})
+		a.Nil(err)
+		a.Nil(groups.GrantRole(appContext, approvers.Id, approverID))
+		a.Nil(groups.AddEmployee(appContext, team.Id, aliceID))
+
+		// включение в группу с конфликтующей ролью
+		err = groups.AddEmployee(appContext, approvers.Id, aliceID)
+		a.True(errors.Is(err, sod.ErrViolation))
+
+		// выдача конфликтующей роли группе, в которой состоит сотрудник
+		err = groups.GrantRole(appContext, team.Id, approverID)
+		a.True(errors.Is(err, sod.ErrViolation))
+
+		// вложение группы сотрудника в группу с конфликтующей ролью
+		err = groups.AddGroup(appContext, approvers.Id, team.Id)
+		a.True(errors.Is(err, sod.ErrViolation))
+
+		// наследование конфликтующей роли ролью сотрудника
+		err = fixture.RoleRepository().UpdateRole(appContext, &role.Entity{
+			Id: accountantID, Name: "ACCOUNTANT", Inherits: []int64{approverID},
+		})
+		a.True(errors.Is(err, sod.ErrViolation))
+
+		var count int
+		a.Nil(db.Get(&count, "SELECT COUNT(*) FROM employee_effective_roles WHERE employee_id = $1 AND role_id = $2", aliceID, approverID))
+		a.Zero(count)
+		violations, err := repo.FindViolations(appContext)
+		a.Nil(err)
+		a.Empty(violations)
+
+		a.Nil(repo.DeleteRule(appContext, ruleID))
+		a.Nil(groups.AddGroup(appContext, approvers.Id, team.Id))
+
+		clearDatabase()
+	})
+
+	t.Run("report existing violations", func(t *testing.T) {
+		aliceID := fixtureEmployee.Employee(appContext, "Alice Doe")
+		bobID := fixtureEmployee.Employee(appContext, "Bob Doe")
+		creatorID := fixtureRole.Role(appContext, "PAYMENT_CREATOR", nil)
+		approverID := fixtureRole.Role(appContext, "PAYMENT_APPROVER", nil)
+
+		// назначения до появления правила
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, aliceID, creatorID))
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, aliceID, approverID))
+		// у Bob конфликтующая роль выдана через группу
+		approvers, err := fixture.GroupRepository().CreateGroup(appContext, &group.Entity{Name: "Approvers"})
+		a.Nil(err)
+		a.Nil(fixture.GroupRepository().AddEmployee(appContext, approvers.Id, bobID))
+		a.Nil(fixture.GroupRepository().GrantRole(appContext, approvers.Id, approverID))
+		a.Nil(fixture.EmployeeRepository().AssignRole(appContext, bobID, creatorID))
+
+		ruleID := rule(creatorID, approverID)
+
+		violations, err := repo.FindViolations(appContext)
+		a.Nil(err)
+		a.Len(violations, 2)
+		a.Equal(ruleID, violations[0].RuleId)
+		a.Equal(aliceID, violations[0].EmployeeId)
+		a.Equal("PAYMENT_APPROVER", violations[0].ConflictingRoleName)
+		a.Equal(bobID, violations[1].EmployeeId)
+
+		clearDatabase()
+	})
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"idm/inner/webhook"
+	"idm/tests/fixtures"
+	"idm/tests/testutils"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	appContext := context.Background()
+	a := assert.New(t)
+
+	var db = testutils.InitTestDB() //add connect, run migrations
+	fixture := fixtures.NewFixture(db)
+
+	var clearDatabase = func() {
+		fixture.CleanDatabase() // truncate tables
+	}
+
+	// func for cleaning DB in case panic
+	defer func() {
+		if err := recover(); err != nil {
+			log.Print("The recovery function received an error while executing!")
+		}
+		clearDatabase()
+	}()
+
+	clearDatabase()
+
+	repo := fixture.WebhookRepository()
+
+	var createWebhook = func(url string, enabled bool, eventTypes ...string) webhook.WebhookEntity {
+		var request = webhook.CreateRequest{Url: url, EventTypes: eventTypes, Secret: "0123456789abcdef", Enabled: &enabled}
+		var entity = request.ToEntity()
+		created, err := repo.Create(appContext, &entity)
+		a.Nil(err)
+		return created
+	}
+
+	t.Run("create and update webhook keeping secret", func(t *testing.T) {
+		created := createWebhook("https://hooks.example.com/idm", true)
+		a.Equal([]string{}, []string(created.EventTypes))
+		a.Equal("0123456789abcdef", created.Secret)
+
+		updated, err := repo.Update(appContext, &webhook.WebhookEntity{
+			Id: created.Id, Url: "https://hooks.example.com/v2", EventTypes: []string{"RoleAssigned"}, Enabled: false,
+		})
+		a.Nil(err)
+		a.Equal("https://hooks.example.com/v2", updated.Url)
+		a.Equal([]string{"RoleAssigned"}, []string(updated.EventTypes))
+		a.Equal("0123456789abcdef", updated.Secret)
+		a.False(updated.Enabled)
+
+		var audited int
+		a.Nil(db.Get(&audited, "SELECT COUNT(*) FROM audit_events WHERE entity_type = 'webhook' AND entity_id = $1", created.Id))
+		a.Equal(2, audited)
+
+		clearDatabase()
+	})
+
+	t.Run("enqueue event to matching enabled webhooks once", func(t *testing.T) {
+		all := createWebhook("https://a.example.com", true)
+		assigned := createWebhook("https://b.example.com", true, "RoleAssigned")
+		createWebhook("https://c.example.com", false)
+		createWebhook("https://d.example.com", true, "EmployeeCreated")
+
+		enqueued, err := repo.Enqueue(appContext, 10, "RoleAssigned", []byte(`{"id":10}`))
+		a.Nil(err)
+		a.Equal(int64(2), enqueued)
+
+		// повтор события из outbox не создаёт вторую доставку
+		enqueued, err = repo.Enqueue(appContext, 10, "RoleAssigned", []byte(`{"id":10}`))
+		a.Nil(err)
+		a.Equal(int64(0), enqueued)
+
+		forAll, err := repo.FindDeliveries(appContext, all.Id, "")
+		a.Nil(err)
+		a.Len(forAll, 1)
+		a.Equal(webhook.DeliveryPending, forAll[0].Status)
+		a.JSONEq(`{"id":10}`, string(forAll[0].Payload))
+		forAssigned, err := repo.FindDeliveries(appContext, assigned.Id, webhook.DeliveryPending)
+		a.Nil(err)
+		a.Len(forAssigned, 1)
+
+		clearDatabase()
+	})
+
+	t.Run("claim and finish delivery with attempt history", func(t *testing.T) {
+		created := createWebhook("https://a.example.com", true)
+		_, err := repo.Enqueue(appContext, 1, "EmployeeCreated", []byte(`{"id":1}`))
+		a.Nil(err)
+		_, err = repo.Enqueue(appContext, 2, "EmployeeUpdated", []byte(`{"id":2}`))
+		a.Nil(err)
+
+		claimed, err := repo.ClaimDue(appContext, time.Now(), time.Minute, 10)
+		a.Nil(err)
+		a.Len(claimed, 2)
+		a.Equal(int64(1), claimed[0].EventId)
+		a.Equal(webhook.DeliveryDelivering, claimed[0].Status)
+
+		// захваченные доставки недоступны до истечения захвата
+		again, err := repo.ClaimDue(appContext, time.Now(), time.Minute, 10)
+		a.Nil(err)
+		a.Empty(again)
+
+		var delivery = claimed[0]
+		delivery.Status = webhook.DeliveryPending
+		delivery.Attempts = 1
+		delivery.LastStatusCode = 503
+		delivery.LastError = "subscriber responded with status 503"
+		delivery.NextAttemptAt = time.Now().Add(-time.Second)
+		a.Nil(repo.FinishDelivery(appContext, &delivery, &webhook.AttemptEntity{
+			AttemptedAt: time.Now(), StatusCode: 503, Error: delivery.LastError, DurationMs: 12,
+		}))
+		var now = time.Now()
+		delivery.Status = webhook.DeliveryDelivered
+		delivery.Attempts = 2
+		delivery.LastStatusCode = 200
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		a.Nil(repo.FinishDelivery(appContext, &delivery, &webhook.AttemptEntity{
+			AttemptedAt: now, StatusCode: 200, DurationMs: 8,
+		}))
+
+		found, err := repo.FindDelivery(appContext, created.Id, delivery.Id)
+		a.Nil(err)
+		a.Equal(webhook.DeliveryDelivered, found.Status)
+		a.Equal(2, found.Attempts)
+		a.NotNil(found.DeliveredAt)
+
+		attempts, err := repo.FindAttempts(appContext, delivery.Id)
+		a.Nil(err)
+		a.Len(attempts, 2)
+		a.Equal(503, attempts[0].StatusCode)
+		a.Equal(200, attempts[1].StatusCode)
+		a.Empty(attempts[1].Error)
+
+		purged, err := repo.PurgeDelivered(appContext, time.Now().Add(time.Minute))
+		a.Nil(err)
+		a.Equal(int64(1), purged)
+
+		clearDatabase()
+	})
+
+	t.Run("claim single delivery only when scheduler does not hold it", func(t *testing.T) {
+		created := createWebhook("https://a.example.com", true)
+		_, err := repo.Enqueue(appContext, 1, "EmployeeCreated", []byte(`{"id":1}`))
+		a.Nil(err)
+
+		claimed, err := repo.ClaimDue(appContext, time.Now(), time.Minute, 10)
+		a.Nil(err)
+		a.Len(claimed, 1)
+
+		// планировщик держит захват - повторная отправка вне очереди невозможна
+		_, err = repo.ClaimDelivery(appContext, created.Id, claimed[0].Id, time.Now(), time.Minute)
+		a.ErrorIs(err, sql.ErrNoRows)
+
+		// после истечения захвата доставку можно забрать
+		single, err := repo.ClaimDelivery(appContext, created.Id, claimed[0].Id, time.Now().Add(2*time.Minute), time.Minute)
+		a.Nil(err)
+		a.Equal(webhook.DeliveryDelivering, single.Status)
+
+		again, err := repo.ClaimDue(appContext, time.Now().Add(2*time.Minute), time.Minute, 10)
+		a.Nil(err)
+		a.Empty(again)
+
+		clearDatabase()
+	})
+
+	t.Run("delete webhook with deliveries", func(t *testing.T) {
+		created := createWebhook("https://a.example.com", true)
+		_, err := repo.Enqueue(appContext, 1, "EmployeeCreated", []byte(`{"id":1}`))
+		a.Nil(err)
+
+		isDeleted, err := repo.Delete(appContext, created.Id)
+		a.Nil(err)
+		a.True(isDeleted)
+
+		var deliveries int
+		a.Nil(db.Get(&deliveries, "SELECT COUNT(*) FROM webhook_deliveries"))
+		a.Equal(0, deliveries)
+
+		clearDatabase()
+	})
+}